@@ -43,6 +43,8 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -51,6 +53,9 @@ import (
 	"syscall"
 	"time"
 
+	"entgo.io/ent/dialect"
+	_ "github.com/lib/pq" // PostgreSQL 驱动
+
 	appuser "github.com/yourusername/golang/internal/app/user"
 	"github.com/yourusername/golang/internal/config"
 	entdb "github.com/yourusername/golang/internal/infra/database/ent"
@@ -59,6 +64,9 @@ import (
 	"github.com/yourusername/golang/internal/infra/workflow/temporal"
 	chiRouter "github.com/yourusername/golang/internal/interfaces/http/chi"
 	temporalhandler "github.com/yourusername/golang/internal/interfaces/workflow/temporal"
+	"github.com/yourusername/golang/pkg/eventbus"
+	"github.com/yourusername/golang/pkg/outbox"
+	"github.com/yourusername/golang/pkg/transaction"
 )
 
 func main() {
@@ -141,22 +149,27 @@ func main() {
 	// 连接池配置：
 	// - MaxOpenConns: 最大打开连接数（默认 25）
 	// - MaxIdleConns: 最大空闲连接数（默认 5）
+	//
+	// Ent 客户端与事务管理器共享同一个 *sql.DB，
+	// 仓储才能在事务管理器开启的事务中写入（见步骤 4.2）
 	var userService *appuser.Service
-	entClient, err := entdb.NewClientFromConfig(
-		ctx,
+	dsn := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Database.Host,
-		fmt.Sprintf("%d", cfg.Database.Port),
+		cfg.Database.Port,
 		cfg.Database.User,
 		cfg.Database.Password,
 		cfg.Database.Database,
 		cfg.Database.SSLMode,
 	)
+	db, err := sql.Open(dialect.Postgres, dsn)
 	if err != nil {
 		logger.Error("Failed to initialize database", "error", err)
 		logger.Info("Please ensure PostgreSQL is running and configuration is correct")
 		os.Exit(1)
 	}
-	// 确保在程序退出时关闭数据库连接
+	entClient := entdb.NewClientFromDB(db, dialect.Postgres)
+	// 确保在程序退出时关闭数据库连接（同时关闭 db）
 	defer entClient.Close()
 	logger.Info("Database connected successfully")
 
@@ -181,13 +194,47 @@ func main() {
 	//
 	// 依赖关系：
 	//   EntClient → UserRepository → UserService → Router
+	//   TransactionManager + OutboxWriter → UserService
 	//
 	// 依赖注入流程：
 	// 1. 创建仓储（Repository）：依赖数据库客户端
-	// 2. 创建应用服务（Service）：依赖仓储
+	// 2. 创建应用服务（Service）：依赖仓储、事务管理器和发件箱写入器
 	// 3. 创建路由（Router）：依赖应用服务
+	//
+	// 事务说明：
+	// - 创建用户与写入 UserCreated 事件在同一个事务中提交或回滚
+	// - 仓储通过上下文中的事务执行写入（EntUserRepository 使用 Client.WithContextTx）
+	txManager := transaction.NewSQLTransactionManager(db)
 	userRepo := repository.NewEntUserRepository(entClient)
-	userService = appuser.NewService(userRepo)
+	userService = appuser.NewService(
+		userRepo,
+		appuser.WithTransactionManager(txManager),
+		appuser.WithEventRecorder(outbox.NewWriter(outbox.DialectPostgres)),
+	)
+
+	// 步骤 4.3: 启动事件总线和发件箱中继
+	//
+	// 发件箱说明：
+	// - 事件先随业务数据写入 outbox_messages 表（迁移见 migrations/postgres）
+	// - Relay 在事务提交后轮询发件箱，把事件同步投递到事件总线
+	// - 处理器失败时消息保留在发件箱中，由 Relay 重试
+	eventBus := eventbus.NewEventBus(1000)
+	if err := eventBus.Start(); err != nil {
+		logger.Error("Failed to start event bus", "error", err)
+		os.Exit(1)
+	}
+	defer eventBus.Stop()
+
+	relay := outbox.NewRelay(txManager, outbox.DialectPostgres, outbox.NewEventBusPublisher(eventBus), outbox.RelayConfig{})
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		if err := relay.Run(relayCtx); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Error("Outbox relay stopped", "error", err)
+		}
+	}()
+	logger.Info("Outbox relay started")
 
 	// 步骤 5: 初始化 Temporal 客户端（可选）
 	//
@@ -297,9 +344,11 @@ func main() {
 	//
 	// 关闭顺序：
 	// 1. HTTP 服务器（停止接收新请求，等待请求完成）
-	// 2. 数据库连接（通过 defer entClient.Close()）
-	// 3. Temporal 客户端（通过 defer temporalClient.Close()）
-	// 4. OpenTelemetry Tracer（通过 defer shutdownTracer()）
+	// 2. 发件箱中继（取消上下文并等待当前批次结束）
+	// 3. 事件总线（通过 defer eventBus.Stop()）
+	// 4. 数据库连接（通过 defer entClient.Close()）
+	// 5. Temporal 客户端（通过 defer temporalClient.Close()）
+	// 6. OpenTelemetry Tracer（通过 defer shutdownTracer()）
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -308,5 +357,8 @@ func main() {
 		os.Exit(1)
 	}
 
+	stopRelay()
+	<-relayDone
+
 	logger.Info("Application gracefully stopped.")
 }
@@ -189,6 +189,26 @@ type EventHandler[T Event] interface {
 	Handle(ctx context.Context, event T) error
 }
 
+// EventHandlerFunc 函数形式的事件处理器
+//
+// 与 pkg/eventbus 的类型化订阅配合使用时，事件类型为 *eventbus.TypedEvent[P]，
+// 负载类型 P 在编译期检查：
+//
+// 示例：
+//   var handler patterns.EventHandler[*eventbus.TypedEvent[UserCreated]] =
+//       patterns.EventHandlerFunc[*eventbus.TypedEvent[UserCreated]](
+//           func(ctx context.Context, event *eventbus.TypedEvent[UserCreated]) error {
+//               return emailService.SendWelcomeEmail(ctx, event.Payload().Email)
+//           })
+//
+//   eventbus.Subscribe[UserCreated](eventBus, "user.created", handler)
+type EventHandlerFunc[T Event] func(ctx context.Context, event T) error
+
+// Handle 调用函数本身
+func (f EventHandlerFunc[T]) Handle(ctx context.Context, event T) error {
+	return f(ctx, event)
+}
+
 // BaseEvent 基础事件实现
 //
 // 设计原理：
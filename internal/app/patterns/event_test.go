@@ -8,6 +8,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/golang/pkg/eventbus"
 )
 
 // MockEventData 测试用的事件数据
@@ -308,6 +310,36 @@ func TestEventHandlerInterface(t *testing.T) {
 	})
 }
 
+// ==================== 类型化事件总线测试 ====================
+
+// TestEventHandlerFunc_TypedEventBus 测试 EventHandler 与 eventbus 类型化订阅的集成
+func TestEventHandlerFunc_TypedEventBus(t *testing.T) {
+	// *eventbus.TypedEvent[T] 满足 patterns.Event
+	var _ Event = (*eventbus.TypedEvent[MockEventData])(nil)
+
+	bus := eventbus.NewEventBus(10)
+	require.NoError(t, bus.Start())
+	defer bus.Stop()
+
+	received := make(chan MockEventData, 1)
+	var handler EventHandler[*eventbus.TypedEvent[MockEventData]] = EventHandlerFunc[*eventbus.TypedEvent[MockEventData]](
+		func(ctx context.Context, event *eventbus.TypedEvent[MockEventData]) error {
+			received <- event.Payload()
+			return nil
+		})
+
+	_, err := eventbus.Subscribe[MockEventData](bus, "mock.created", handler)
+	require.NoError(t, err)
+	require.NoError(t, eventbus.Publish(bus, "mock.created", MockEventData{ID: "1", Name: "test"}))
+
+	select {
+	case data := <-received:
+		assert.Equal(t, MockEventData{ID: "1", Name: "test"}, data)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
 // ==================== Event 类型测试 ====================
 
 // TestEventTypes 测试不同类型的事件
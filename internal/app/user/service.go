@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/yourusername/golang/internal/domain/user"
+	"github.com/yourusername/golang/pkg/eventbus"
+	"github.com/yourusername/golang/pkg/transaction"
 )
 
 // 错误类型常量，用于统一错误标识
@@ -15,9 +17,42 @@ const (
 	ErrTypeInternal          = "internal_error"
 )
 
+// EventTypeUserCreated 用户创建事件类型
+const EventTypeUserCreated = "user.created"
+
 // Service 用户应用服务
 type Service struct {
-	repo UserRepository
+	repo     UserRepository
+	txm      transaction.Manager
+	recorder EventRecorder
+}
+
+// EventRecorder 领域事件记录接口
+//
+// outbox.Writer 实现了此接口，事件在当前事务中写入发件箱，
+// 由 outbox.Relay 在事务提交后发布
+type EventRecorder interface {
+	Record(ctx context.Context, event eventbus.Event) error
+}
+
+// ServiceOption 用户服务配置选项
+type ServiceOption func(*Service)
+
+// WithTransactionManager 设置事务管理器
+//
+// 与 WithEventRecorder 同时配置时，CreateUser 在同一个事务中保存用户和记录事件；
+// 仓储需要通过 transaction.GetSQLTx(ctx) 使用该事务
+func WithTransactionManager(txm transaction.Manager) ServiceOption {
+	return func(s *Service) {
+		s.txm = txm
+	}
+}
+
+// WithEventRecorder 设置领域事件记录器
+func WithEventRecorder(recorder EventRecorder) ServiceOption {
+	return func(s *Service) {
+		s.recorder = recorder
+	}
 }
 
 // UserRepository 用户仓储接口
@@ -31,10 +66,14 @@ type UserRepository interface {
 }
 
 // NewService 创建用户服务
-func NewService(repo UserRepository) *Service {
-	return &Service{
+func NewService(repo UserRepository, opts ...ServiceOption) *Service {
+	s := &Service{
 		repo: repo,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetUser 获取用户
@@ -58,9 +97,28 @@ func (s *Service) CreateUser(ctx context.Context, email, name string) (*user.Use
 		return nil, fmt.Errorf("invalid user: %w", err)
 	}
 
-	// 保存
-	if err := s.repo.Save(ctx, newUser); err != nil {
-		return nil, fmt.Errorf("failed to save user: %w", err)
+	// 保存（配置了事件记录器时，与 user.created 事件在同一个事务中提交）
+	if s.txm == nil || s.recorder == nil {
+		if err := s.repo.Save(ctx, newUser); err != nil {
+			return nil, fmt.Errorf("failed to save user: %w", err)
+		}
+		return newUser, nil
+	}
+
+	err = s.txm.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Save(ctx, newUser); err != nil {
+			return fmt.Errorf("failed to save user: %w", err)
+		}
+
+		event := eventbus.NewTypedEvent(EventTypeUserCreated, newUser)
+		event.SetMetadata(eventbus.MetadataKeyAggregateID, newUser.ID)
+		if err := s.recorder.Record(ctx, event); err != nil {
+			return fmt.Errorf("failed to record user created event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return newUser, nil
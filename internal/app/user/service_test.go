@@ -10,6 +10,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/yourusername/golang/internal/domain/user"
+	"github.com/yourusername/golang/pkg/eventbus"
+	"github.com/yourusername/golang/pkg/transaction"
 )
 
 // MockUserRepository 是用户仓储的mock实现
@@ -299,6 +301,95 @@ func TestService_CreateUser_NameTooShort(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+// fakeTxManager 记录事务结果的事务管理器
+type fakeTxManager struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (m *fakeTxManager) Begin(ctx context.Context) (transaction.Transaction, error) {
+	return nil, errors.New("not supported")
+}
+
+func (m *fakeTxManager) Get(ctx context.Context) (transaction.Transaction, error) {
+	return nil, transaction.ErrTransactionNotFound
+}
+
+func (m *fakeTxManager) Commit(ctx context.Context) error { return nil }
+
+func (m *fakeTxManager) Rollback(ctx context.Context) error { return nil }
+
+func (m *fakeTxManager) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		m.rolledBack = true
+		return err
+	}
+	m.committed = true
+	return nil
+}
+
+// fakeRecorder 记录事件的事件记录器
+type fakeRecorder struct {
+	events []eventbus.Event
+	err    error
+}
+
+func (r *fakeRecorder) Record(ctx context.Context, event eventbus.Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// TestService_CreateUser_RecordsEvent 测试创建用户时在事务中记录事件
+func TestService_CreateUser_RecordsEvent(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockUserRepository)
+	txm := &fakeTxManager{}
+	recorder := &fakeRecorder{}
+	service := NewService(repo, WithTransactionManager(txm), WithEventRecorder(recorder))
+
+	email := "newuser@example.com"
+	repo.On("FindByEmail", ctx, email).Return(nil, errors.New("not found"))
+	repo.On("Save", ctx, mock.AnythingOfType("*user.User")).Return(nil)
+
+	result, err := service.CreateUser(ctx, email, "New User")
+
+	require.NoError(t, err)
+	assert.True(t, txm.committed)
+	require.Len(t, recorder.events, 1)
+
+	event := recorder.events[0].(*eventbus.BaseEvent)
+	assert.Equal(t, EventTypeUserCreated, event.Type())
+	aggregateID, _ := event.GetMetadata(eventbus.MetadataKeyAggregateID)
+	assert.Equal(t, result.ID, aggregateID)
+
+	repo.AssertExpectations(t)
+}
+
+// TestService_CreateUser_RecordError 测试记录事件失败时回滚
+func TestService_CreateUser_RecordError(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockUserRepository)
+	txm := &fakeTxManager{}
+	recorder := &fakeRecorder{err: errors.New("outbox unavailable")}
+	service := NewService(repo, WithTransactionManager(txm), WithEventRecorder(recorder))
+
+	email := "newuser@example.com"
+	repo.On("FindByEmail", ctx, email).Return(nil, errors.New("not found"))
+	repo.On("Save", ctx, mock.AnythingOfType("*user.User")).Return(nil)
+
+	result, err := service.CreateUser(ctx, email, "New User")
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.True(t, txm.rolledBack)
+	assert.Contains(t, err.Error(), "failed to record user created event")
+
+	repo.AssertExpectations(t)
+}
+
 // ==================== UpdateUserName 测试 ====================
 
 // TestService_UpdateUser 测试更新用户
@@ -171,13 +171,35 @@ type RedisConfig struct {
 // - Brokers: Kafka Broker 地址列表（默认：["localhost:9092"]）
 // - ConsumerGroup: 消费者组 ID
 // - Timeout: 操作超时时间
+// - Producer: 生产者配置（幂等、压缩、批量发送）
 //
 // 环境变量：
 // - APP_KAFKA_BROKERS: Kafka Broker 地址（逗号分隔）
+// - APP_KAFKA_PRODUCER_IDEMPOTENT: 是否启用幂等生产者
+// - APP_KAFKA_PRODUCER_COMPRESSION: 压缩算法
 type KafkaConfig struct {
-	Brokers       []string      `mapstructure:"brokers"`
-	ConsumerGroup string        `mapstructure:"consumer_group"`
-	Timeout       time.Duration `mapstructure:"timeout"`
+	Brokers       []string            `mapstructure:"brokers"`
+	ConsumerGroup string              `mapstructure:"consumer_group"`
+	Timeout       time.Duration       `mapstructure:"timeout"`
+	Producer      KafkaProducerConfig `mapstructure:"producer"`
+}
+
+// KafkaProducerConfig 是 Kafka 生产者的配置。
+//
+// 字段说明：
+// - Idempotent: 启用幂等生产者（Broker 按生产者 ID 和序列号去重，重试不会产生重复消息）
+// - Compression: 压缩算法：none、gzip、snappy、lz4、zstd（默认：none）
+// - MaxRetries: 发送失败的最大重试次数（默认：5）
+// - FlushMessages: 累积多少条消息后发送一批（0 表示不按条数触发）
+// - FlushFrequency: 最长等待多久发送一批（0 表示不按时间触发）
+// - FlushBytes: 累积多少字节后发送一批（0 表示不按字节数触发）
+type KafkaProducerConfig struct {
+	Idempotent     bool          `mapstructure:"idempotent"`
+	Compression    string        `mapstructure:"compression"`
+	MaxRetries     int           `mapstructure:"max_retries"`
+	FlushMessages  int           `mapstructure:"flush_messages"`
+	FlushFrequency time.Duration `mapstructure:"flush_frequency"`
+	FlushBytes     int           `mapstructure:"flush_bytes"`
 }
 
 // MQTTConfig 是 MQTT 消息队列的配置。
@@ -487,6 +509,8 @@ func bindEnvVars(v *viper.Viper) {
 
 	// Kafka
 	v.BindEnv("kafka.brokers", "APP_KAFKA_BROKERS")
+	v.BindEnv("kafka.producer.idempotent", "APP_KAFKA_PRODUCER_IDEMPOTENT")
+	v.BindEnv("kafka.producer.compression", "APP_KAFKA_PRODUCER_COMPRESSION")
 
 	// MQTT
 	v.BindEnv("mqtt.broker", "APP_MQTT_BROKER")
@@ -559,6 +583,12 @@ func setDefaults(c *Config) {
 	if len(c.Kafka.Brokers) == 0 {
 		c.Kafka.Brokers = []string{"localhost:9092"}
 	}
+	if c.Kafka.Producer.Compression == "" {
+		c.Kafka.Producer.Compression = "none"
+	}
+	if c.Kafka.Producer.MaxRetries == 0 {
+		c.Kafka.Producer.MaxRetries = 5
+	}
 
 	// MQTT 默认值
 	if c.MQTT.Broker == "" {
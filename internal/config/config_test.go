@@ -36,6 +36,9 @@ func TestLoad_Defaults(t *testing.T) {
 
 	// 验证 Kafka 默认值
 	assert.Equal(t, []string{"localhost:9092"}, cfg.Kafka.Brokers)
+	assert.Equal(t, "none", cfg.Kafka.Producer.Compression)
+	assert.Equal(t, 5, cfg.Kafka.Producer.MaxRetries)
+	assert.False(t, cfg.Kafka.Producer.Idempotent)
 
 	// 验证 MQTT 默认值
 	assert.Equal(t, "tcp://localhost:1883", cfg.MQTT.Broker)
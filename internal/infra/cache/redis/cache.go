@@ -0,0 +1,500 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrCacheMiss 缓存中没有该键
+	ErrCacheMiss = errors.New("redis: cache miss")
+
+	// ErrNotFound 值不存在
+	//
+	// 加载函数返回该错误（或 WithNegativeCaching 指定的错误）时写入负缓存，
+	// 命中负缓存时 Get 和 GetOrLoad 返回该错误。
+	ErrNotFound = errors.New("redis: value not found")
+)
+
+// 写入 Redis 的值的类型标记
+const (
+	tagValue    byte = 'v'
+	tagNegative byte = 'n'
+)
+
+// Loader 缓存未命中时加载值的函数
+type Loader[T any] func(ctx context.Context, key string) (T, error)
+
+// CacheStats 缓存统计
+//
+// 所有计数从创建缓存开始累计
+type CacheStats struct {
+	L1Hits        uint64 // 一级缓存命中次数
+	L1Misses      uint64 // 一级缓存未命中次数
+	L2Hits        uint64 // Redis 命中次数
+	L2Misses      uint64 // Redis 未命中次数
+	NegativeHits  uint64 // 命中负缓存的次数（包含在 L1Hits/L2Hits 中）
+	Loads         uint64 // 调用加载函数的次数
+	LoadErrors    uint64 // 加载函数返回错误的次数（不包括"不存在"）
+	Errors        uint64 // Redis 访问或解码失败的次数
+	Invalidations uint64 // 收到其他实例失效通知的次数
+}
+
+// HitRate 返回缓存命中率（一级缓存和 Redis 合计）
+func (s CacheStats) HitRate() float64 {
+	hits := s.L1Hits + s.L2Hits
+	total := hits + s.L2Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
+
+// cacheCounters 缓存统计计数器
+type cacheCounters struct {
+	l1Hits, l1Misses, l2Hits, l2Misses, negativeHits atomic.Uint64
+	loads, loadErrors, errors, invalidations         atomic.Uint64
+}
+
+// cacheEntry 缓存条目，negative 为 true 表示负缓存
+type cacheEntry[T any] struct {
+	value    T
+	negative bool
+}
+
+// invalidation 失效通知消息
+type invalidation struct {
+	Source string   `json:"source"`
+	Keys   []string `json:"keys,omitempty"`
+	All    bool     `json:"all,omitempty"`
+}
+
+// Cache 是带类型的多级缓存：进程内 LRU（L1）+ Redis（L2）。
+//
+// 设计原理：
+// 1. 读取顺序为 L1 → Redis → 加载函数，Redis 命中时回填 L1，加载成功后写入 Redis 和 L1
+// 2. 同一个键的并发加载通过 singleflight 合并为一次，防止热点键过期时的缓存击穿
+// 3. 加载函数返回"不存在"时写入负缓存（需启用 WithNegativeCaching），防止缓存穿透
+// 4. Set、Delete、Clear 通过 Redis 发布订阅通知其他实例删除 L1 中的旧值
+//
+// 一致性：
+// - 失效通知是尽力而为的，连接中断期间错过的通知不会重发，L1 中的旧值最多保留 L1 的 TTL
+// - 因此 L1 的 TTL 应该比 Redis 的 TTL 短得多
+// - 加载函数写入的值不发送失效通知：其他实例的 L1 中不会有 Redis 中不存在的值（除非已过期）
+//
+// 使用示例：
+//
+//	cache, err := redis.NewCache[User](client, "users",
+//	    redis.WithTTL(10*time.Minute),
+//	    redis.WithL1(10000, 30*time.Second),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer cache.Close()
+//
+//	user, err := cache.GetOrLoad(ctx, id, func(ctx context.Context, id string) (User, error) {
+//	    return repo.FindByID(ctx, id)
+//	})
+type Cache[T any] struct {
+	rdb        *redis.Client
+	namespace  string
+	instanceID string
+	opts       cacheOptions
+
+	l1    *lru[cacheEntry[T]]
+	group singleflight.Group
+	stats cacheCounters
+
+	pubsub    *redis.PubSub
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewCache 创建多级缓存。
+//
+// 参数：
+//   - client: Redis 客户端
+//   - namespace: 命名空间，Redis 中的键为 "<namespace>:<key>"
+//   - opts: 可选配置，如 WithTTL、WithL1、WithNegativeCaching
+//
+// 返回：
+//   - *Cache[T]: 多级缓存
+//   - error: 订阅失效通知频道失败时返回错误
+//
+// 注意事项：
+// - 启用 L1 且未调用 WithoutInvalidation 时会订阅失效通知频道，退出前应调用 Close
+// - 未启用 L1 的实例不订阅，但写入时仍会发送失效通知，使其他实例的 L1 失效
+func NewCache[T any](client *Client, namespace string, opts ...CacheOption) (*Cache[T], error) {
+	c := &Cache[T]{
+		rdb:        client.client,
+		namespace:  namespace,
+		instanceID: uuid.NewString(),
+		opts:       newCacheOptions(namespace, opts),
+	}
+	if c.opts.l1Size <= 0 {
+		return c, nil
+	}
+
+	c.l1 = newLRU[cacheEntry[T]](c.opts.l1Size)
+	if c.opts.invalidation {
+		if err := c.subscribe(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Get 读取缓存，不调用加载函数。
+//
+// 返回：
+//   - T: 缓存的值
+//   - error: 未命中返回 ErrCacheMiss，命中负缓存返回 ErrNotFound，Redis 访问失败返回对应错误
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+	entry, ok, err := c.lookup(ctx, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if !ok {
+		var zero T
+		return zero, ErrCacheMiss
+	}
+	return entry.result()
+}
+
+// GetOrLoad 读取缓存，未命中时调用加载函数并写入缓存。
+//
+// 功能说明：
+// - 同一个键的并发调用只会执行一次加载函数，其他调用等待并共享结果
+// - 加载函数使用不会被取消的 ctx 执行，调用方 ctx 取消时只是自己提前返回，不影响其他等待者
+// - Redis 不可用时降级为直接调用加载函数
+//
+// 参数：
+//   - ctx: 上下文
+//   - key: 缓存键
+//   - loader: 加载函数
+//
+// 返回：
+//   - T: 缓存或加载的值
+//   - error: 加载函数的错误；命中负缓存时返回 ErrNotFound；ctx 取消时返回 ctx.Err()
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, loader Loader[T]) (T, error) {
+	if entry, ok, err := c.lookup(ctx, key); err == nil && ok {
+		return entry.result()
+	}
+
+	loadCtx := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(key, func() (any, error) {
+		return c.load(loadCtx, key, loader)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			var zero T
+			return zero, res.Err
+		}
+		return res.Val.(T), nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Set 写入缓存，并通知其他实例删除 L1 中的旧值。
+//
+// 返回：
+//   - error: 写入 Redis 或发送失效通知失败时返回错误
+func (c *Cache[T]) Set(ctx context.Context, key string, value T) error {
+	if err := c.store(ctx, key, cacheEntry[T]{value: value}); err != nil {
+		return err
+	}
+	return c.publish(ctx, invalidation{Keys: []string{key}})
+}
+
+// Delete 删除缓存，并通知其他实例删除 L1 中的值。
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if c.l1 != nil {
+		c.l1.delete(keys...)
+	}
+
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = c.redisKey(key)
+	}
+	if err := c.rdb.Del(ctx, redisKeys...).Err(); err != nil {
+		c.stats.errors.Add(1)
+		return fmt.Errorf("failed to delete cache keys: %w", err)
+	}
+	return c.publish(ctx, invalidation{Keys: keys})
+}
+
+// Clear 删除命名空间下的所有缓存，并通知其他实例清空 L1。
+//
+// 注意事项：
+// - 使用 SCAN 遍历命名空间，键很多时耗时较长；命名空间应该按用途划分得足够小
+func (c *Cache[T]) Clear(ctx context.Context) error {
+	if c.l1 != nil {
+		c.l1.purge()
+	}
+
+	iter := c.rdb.Scan(ctx, 0, c.redisKey("*"), 100).Iterator()
+	var batch []string
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == 100 {
+			if err := c.rdb.Del(ctx, batch...).Err(); err != nil {
+				c.stats.errors.Add(1)
+				return fmt.Errorf("failed to clear cache: %w", err)
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		c.stats.errors.Add(1)
+		return fmt.Errorf("failed to scan cache keys: %w", err)
+	}
+	if len(batch) > 0 {
+		if err := c.rdb.Del(ctx, batch...).Err(); err != nil {
+			c.stats.errors.Add(1)
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+	}
+	return c.publish(ctx, invalidation{All: true})
+}
+
+// Stats 返回缓存统计的快照
+func (c *Cache[T]) Stats() CacheStats {
+	return CacheStats{
+		L1Hits:        c.stats.l1Hits.Load(),
+		L1Misses:      c.stats.l1Misses.Load(),
+		L2Hits:        c.stats.l2Hits.Load(),
+		L2Misses:      c.stats.l2Misses.Load(),
+		NegativeHits:  c.stats.negativeHits.Load(),
+		Loads:         c.stats.loads.Load(),
+		LoadErrors:    c.stats.loadErrors.Load(),
+		Errors:        c.stats.errors.Load(),
+		Invalidations: c.stats.invalidations.Load(),
+	}
+}
+
+// Close 取消订阅失效通知频道
+//
+// 不会关闭 Redis 客户端
+func (c *Cache[T]) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.pubsub != nil {
+			err = c.pubsub.Close()
+			c.wg.Wait()
+		}
+	})
+	return err
+}
+
+// lookup 依次查找 L1 和 Redis，ok 为 false 表示未命中
+func (c *Cache[T]) lookup(ctx context.Context, key string) (cacheEntry[T], bool, error) {
+	if c.l1 != nil {
+		if entry, ok := c.l1.get(key); ok {
+			c.stats.l1Hits.Add(1)
+			if entry.negative {
+				c.stats.negativeHits.Add(1)
+			}
+			return entry, true, nil
+		}
+		c.stats.l1Misses.Add(1)
+	}
+
+	data, err := c.rdb.Get(ctx, c.redisKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.stats.l2Misses.Add(1)
+		return cacheEntry[T]{}, false, nil
+	}
+	if err != nil {
+		c.stats.errors.Add(1)
+		return cacheEntry[T]{}, false, fmt.Errorf("failed to get cache key: %w", err)
+	}
+
+	entry, err := c.decode(data)
+	if err != nil {
+		c.stats.errors.Add(1)
+		return cacheEntry[T]{}, false, err
+	}
+	c.stats.l2Hits.Add(1)
+	if entry.negative {
+		c.stats.negativeHits.Add(1)
+	}
+	c.setL1(key, entry)
+	return entry, true, nil
+}
+
+// load 调用加载函数并写入缓存
+func (c *Cache[T]) load(ctx context.Context, key string, loader Loader[T]) (T, error) {
+	c.stats.loads.Add(1)
+	value, err := loader(ctx, key)
+	if err != nil {
+		if c.opts.isNotFound(err) {
+			if c.opts.negativeTTL > 0 {
+				_ = c.store(ctx, key, cacheEntry[T]{negative: true})
+			}
+		} else {
+			c.stats.loadErrors.Add(1)
+		}
+		return value, err
+	}
+
+	// 写入缓存失败不影响本次读取，下次读取会重新加载
+	_ = c.store(ctx, key, cacheEntry[T]{value: value})
+	return value, nil
+}
+
+// store 写入 Redis 和 L1
+func (c *Cache[T]) store(ctx context.Context, key string, entry cacheEntry[T]) error {
+	data, err := c.encode(entry)
+	if err != nil {
+		return err
+	}
+
+	ttl := c.opts.ttl
+	if entry.negative {
+		ttl = c.opts.negativeTTL
+	}
+	if err := c.rdb.Set(ctx, c.redisKey(key), data, max(ttl, 0)).Err(); err != nil {
+		c.stats.errors.Add(1)
+		return fmt.Errorf("failed to set cache key: %w", err)
+	}
+	c.setL1(key, entry)
+	return nil
+}
+
+// setL1 写入 L1，负缓存的 L1 过期时间不超过负缓存的 TTL
+func (c *Cache[T]) setL1(key string, entry cacheEntry[T]) {
+	if c.l1 == nil {
+		return
+	}
+	ttl := c.opts.l1TTL
+	if entry.negative && (ttl <= 0 || c.opts.negativeTTL < ttl) {
+		ttl = c.opts.negativeTTL
+	}
+	c.l1.set(key, entry, ttl)
+}
+
+// encode 编码缓存条目，第一个字节为类型标记
+func (c *Cache[T]) encode(entry cacheEntry[T]) ([]byte, error) {
+	if entry.negative {
+		return []byte{tagNegative}, nil
+	}
+	data, err := c.opts.serializer.Marshal(entry.value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cache value: %w", err)
+	}
+	return append([]byte{tagValue}, data...), nil
+}
+
+// decode 解码缓存条目
+func (c *Cache[T]) decode(data []byte) (cacheEntry[T], error) {
+	if len(data) == 0 {
+		return cacheEntry[T]{}, errors.New("failed to decode cache value: empty data")
+	}
+	switch data[0] {
+	case tagNegative:
+		return cacheEntry[T]{negative: true}, nil
+	case tagValue:
+		var value T
+		if err := c.opts.serializer.Unmarshal(data[1:], &value); err != nil {
+			return cacheEntry[T]{}, fmt.Errorf("failed to decode cache value: %w", err)
+		}
+		return cacheEntry[T]{value: value}, nil
+	default:
+		return cacheEntry[T]{}, fmt.Errorf("failed to decode cache value: unknown tag %q", data[0])
+	}
+}
+
+// redisKey 返回 Redis 中的键
+func (c *Cache[T]) redisKey(key string) string {
+	return c.namespace + ":" + key
+}
+
+// subscribe 订阅失效通知频道，并启动处理 goroutine
+func (c *Cache[T]) subscribe() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pubsub := c.rdb.Subscribe(ctx, c.opts.channel)
+	// 等待订阅确认，保证 NewCache 返回后不会错过通知
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return fmt.Errorf("failed to subscribe invalidation channel: %w", err)
+	}
+	c.pubsub = pubsub
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for msg := range pubsub.Channel() {
+			c.handleInvalidation(msg.Payload)
+		}
+	}()
+	return nil
+}
+
+// handleInvalidation 处理其他实例的失效通知
+func (c *Cache[T]) handleInvalidation(payload string) {
+	var msg invalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		slog.Warn("invalid cache invalidation message", "channel", c.opts.channel, "error", err)
+		return
+	}
+	// 自己发出的通知在发送前已经处理过
+	if msg.Source == c.instanceID {
+		return
+	}
+
+	c.stats.invalidations.Add(1)
+	if msg.All {
+		c.l1.purge()
+		return
+	}
+	c.l1.delete(msg.Keys...)
+}
+
+// publish 发送失效通知
+//
+// 没有 L1 的实例同样需要发送，否则其他实例的 L1 会在 TTL 内返回旧值
+func (c *Cache[T]) publish(ctx context.Context, msg invalidation) error {
+	if !c.opts.invalidation {
+		return nil
+	}
+	msg.Source = c.instanceID
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode invalidation message: %w", err)
+	}
+	if err := c.rdb.Publish(ctx, c.opts.channel, data).Err(); err != nil {
+		c.stats.errors.Add(1)
+		return fmt.Errorf("failed to publish invalidation: %w", err)
+	}
+	return nil
+}
+
+// result 将缓存条目转换为返回值
+func (e cacheEntry[T]) result() (T, error) {
+	if e.negative {
+		var zero T
+		return zero, ErrNotFound
+	}
+	return e.value, nil
+}
@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"errors"
+	"time"
+)
+
+// 缓存默认配置
+const (
+	DefaultCacheTTL  = 10 * time.Minute
+	DefaultL1Size    = 10000
+	DefaultL1TTL     = 30 * time.Second
+	invalidatePrefix = "cache:invalidate:"
+)
+
+// cacheOptions 多级缓存的可选配置
+type cacheOptions struct {
+	ttl          time.Duration
+	l1Size       int
+	l1TTL        time.Duration
+	negativeTTL  time.Duration
+	isNotFound   func(error) bool
+	serializer   Serializer
+	invalidation bool
+	channel      string
+}
+
+// CacheOption 多级缓存配置选项
+type CacheOption func(*cacheOptions)
+
+// newCacheOptions 返回应用了 opts 的配置
+func newCacheOptions(namespace string, opts []CacheOption) cacheOptions {
+	o := cacheOptions{
+		ttl:          DefaultCacheTTL,
+		l1Size:       DefaultL1Size,
+		l1TTL:        DefaultL1TTL,
+		isNotFound:   func(err error) bool { return errors.Is(err, ErrNotFound) },
+		serializer:   JSONSerializer{},
+		invalidation: true,
+		channel:      invalidatePrefix + namespace,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithTTL 设置 Redis（二级缓存）中值的过期时间，默认 DefaultCacheTTL
+//
+// ttl <= 0 表示不过期
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithL1 设置进程内一级缓存
+//
+// 参数：
+//   - size: 最多缓存的键数，<= 0 表示禁用一级缓存
+//   - ttl: 一级缓存的过期时间，决定了错过失效通知时数据最长的不一致时间
+func WithL1(size int, ttl time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.l1Size = size
+		o.l1TTL = ttl
+	}
+}
+
+// WithoutL1 禁用一级缓存，所有读取直接访问 Redis
+func WithoutL1() CacheOption {
+	return WithL1(0, 0)
+}
+
+// WithNegativeCaching 启用负缓存
+//
+// 设计原理：
+// 加载函数返回"不存在"错误时，在缓存中写入一个空标记，
+// 过期之前的读取直接返回 ErrNotFound，避免不存在的键反复穿透到数据库（缓存穿透）。
+//
+// 参数：
+//   - ttl: 空标记的过期时间，应明显短于正常值的过期时间
+//   - isNotFound: 判断加载函数的错误是否表示"不存在"，为 nil 时使用 errors.Is(err, ErrNotFound)
+//
+// 示例：
+//
+//	cache, err := redis.NewCache[User](client, "users",
+//	    redis.WithNegativeCaching(time.Minute, func(err error) bool {
+//	        return errors.Is(err, user.ErrUserNotFound)
+//	    }),
+//	)
+func WithNegativeCaching(ttl time.Duration, isNotFound func(error) bool) CacheOption {
+	return func(o *cacheOptions) {
+		o.negativeTTL = ttl
+		if isNotFound != nil {
+			o.isNotFound = isNotFound
+		}
+	}
+}
+
+// WithSerializer 设置序列化器，默认 JSONSerializer
+func WithSerializer(serializer Serializer) CacheOption {
+	return func(o *cacheOptions) {
+		o.serializer = serializer
+	}
+}
+
+// WithInvalidationChannel 设置一级缓存失效通知使用的 Redis 频道
+//
+// 默认频道为 "cache:invalidate:<namespace>"，共享同一命名空间的实例必须使用相同的频道。
+func WithInvalidationChannel(channel string) CacheOption {
+	return func(o *cacheOptions) {
+		o.channel = channel
+	}
+}
+
+// WithoutInvalidation 禁用跨实例的失效通知
+//
+// 适用于单实例部署，或者可以接受一级缓存在 TTL 内不一致的场景
+func WithoutInvalidation() CacheOption {
+	return func(o *cacheOptions) {
+		o.invalidation = false
+	}
+}
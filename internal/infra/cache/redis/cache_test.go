@@ -0,0 +1,301 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cachedUser struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// newTestClient 创建连接 miniredis 的客户端
+func newTestClient(t *testing.T, mr *miniredis.Miniredis) *Client {
+	t.Helper()
+	client, err := NewClient(Config{Addr: mr.Addr(), PoolSize: 10, MaxRetries: -1})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// newTestCache 创建多级缓存，测试结束时关闭
+func newTestCache[T any](t *testing.T, client *Client, namespace string, opts ...CacheOption) *Cache[T] {
+	t.Helper()
+	cache, err := NewCache[T](client, namespace, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache
+}
+
+func TestCache_GetOrLoadFillsBothTiers(t *testing.T) {
+	mr := setupMiniredis(t)
+	cache := newTestCache[cachedUser](t, newTestClient(t, mr), "users")
+	ctx := context.Background()
+
+	loads := 0
+	loader := func(ctx context.Context, id string) (cachedUser, error) {
+		loads++
+		return cachedUser{ID: id, Name: "alice"}, nil
+	}
+
+	_, err := cache.Get(ctx, "1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	u, err := cache.GetOrLoad(ctx, "1", loader)
+	require.NoError(t, err)
+	assert.Equal(t, cachedUser{ID: "1", Name: "alice"}, u)
+	assert.True(t, mr.Exists("users:1"))
+	assert.Equal(t, DefaultCacheTTL, mr.TTL("users:1"))
+
+	u, err = cache.GetOrLoad(ctx, "1", loader)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.Name)
+	assert.Equal(t, 1, loads)
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1), stats.L1Hits)
+	assert.Equal(t, uint64(2), stats.L1Misses)
+	assert.Equal(t, uint64(2), stats.L2Misses)
+	assert.Equal(t, uint64(1), stats.Loads)
+	assert.InDelta(t, 1.0/3, stats.HitRate(), 0.001)
+}
+
+func TestCache_ReadsFromRedisWhenL1Misses(t *testing.T) {
+	mr := setupMiniredis(t)
+	client := newTestClient(t, mr)
+	ctx := context.Background()
+
+	writer := newTestCache[cachedUser](t, client, "users", WithoutL1())
+	require.NoError(t, writer.Set(ctx, "1", cachedUser{ID: "1", Name: "bob"}))
+
+	reader := newTestCache[cachedUser](t, client, "users")
+	u, err := reader.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", u.Name)
+
+	// Redis 中的值被删除后，L1 仍然可以命中
+	mr.Del("users:1")
+	u, err = reader.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", u.Name)
+	assert.Equal(t, uint64(1), reader.Stats().L2Hits)
+	assert.Equal(t, uint64(1), reader.Stats().L1Hits)
+}
+
+func TestCache_SingleflightPreventsStampede(t *testing.T) {
+	mr := setupMiniredis(t)
+	cache := newTestCache[cachedUser](t, newTestClient(t, mr), "users")
+	ctx := context.Background()
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, id string) (cachedUser, error) {
+		loads.Add(1)
+		<-release
+		return cachedUser{ID: id}, nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.GetOrLoad(ctx, "hot", loader)
+			errs <- err
+		}()
+	}
+
+	// 等待所有调用进入 singleflight 后再放行加载函数
+	assert.Eventually(t, func() bool { return cache.Stats().L2Misses == callers }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), loads.Load())
+	assert.Equal(t, uint64(1), cache.Stats().Loads)
+}
+
+func TestCache_CallerCancelDoesNotAbortLoad(t *testing.T) {
+	mr := setupMiniredis(t)
+	cache := newTestCache[cachedUser](t, newTestClient(t, mr), "users")
+
+	release := make(chan struct{})
+	loaded := make(chan struct{})
+	loader := func(ctx context.Context, id string) (cachedUser, error) {
+		<-release
+		defer close(loaded)
+		return cachedUser{ID: id}, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache.GetOrLoad(ctx, "1", loader)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	<-loaded
+	assert.Eventually(t, func() bool { return mr.Exists("users:1") }, time.Second, 5*time.Millisecond)
+}
+
+func TestCache_NegativeCaching(t *testing.T) {
+	mr := setupMiniredis(t)
+	errMissing := errors.New("user not found")
+	cache := newTestCache[cachedUser](t, newTestClient(t, mr), "users",
+		WithNegativeCaching(time.Minute, func(err error) bool { return errors.Is(err, errMissing) }),
+	)
+	ctx := context.Background()
+
+	loads := 0
+	loader := func(ctx context.Context, id string) (cachedUser, error) {
+		loads++
+		return cachedUser{}, errMissing
+	}
+
+	_, err := cache.GetOrLoad(ctx, "404", loader)
+	assert.ErrorIs(t, err, errMissing)
+	assert.Equal(t, time.Minute, mr.TTL("users:404"))
+
+	_, err = cache.GetOrLoad(ctx, "404", loader)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = cache.Get(ctx, "404")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 1, loads)
+	assert.Equal(t, uint64(2), cache.Stats().NegativeHits)
+	assert.Equal(t, uint64(0), cache.Stats().LoadErrors)
+
+	// 写入后负缓存被覆盖
+	require.NoError(t, cache.Set(ctx, "404", cachedUser{ID: "404"}))
+	u, err := cache.Get(ctx, "404")
+	require.NoError(t, err)
+	assert.Equal(t, "404", u.ID)
+}
+
+func TestCache_LoadErrorIsNotCached(t *testing.T) {
+	mr := setupMiniredis(t)
+	cache := newTestCache[cachedUser](t, newTestClient(t, mr), "users", WithNegativeCaching(time.Minute, nil))
+	ctx := context.Background()
+
+	boom := errors.New("db down")
+	_, err := cache.GetOrLoad(ctx, "1", func(ctx context.Context, id string) (cachedUser, error) {
+		return cachedUser{}, boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.False(t, mr.Exists("users:1"))
+	assert.Equal(t, uint64(1), cache.Stats().LoadErrors)
+}
+
+func TestCache_InvalidationAcrossInstances(t *testing.T) {
+	mr := setupMiniredis(t)
+	ctx := context.Background()
+	a := newTestCache[cachedUser](t, newTestClient(t, mr), "users")
+	b := newTestCache[cachedUser](t, newTestClient(t, mr), "users")
+
+	require.NoError(t, a.Set(ctx, "1", cachedUser{ID: "1", Name: "v1"}))
+	u, err := b.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", u.Name)
+
+	// a 更新后，b 的 L1 收到通知被删除，下次读取从 Redis 获取新值
+	require.NoError(t, a.Set(ctx, "1", cachedUser{ID: "1", Name: "v2"}))
+	assert.Eventually(t, func() bool { return b.Stats().Invalidations == 2 }, time.Second, 5*time.Millisecond)
+	u, err = b.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", u.Name)
+	assert.Equal(t, uint64(0), a.Stats().Invalidations, "own messages are ignored")
+
+	require.NoError(t, a.Delete(ctx, "1"))
+	assert.Eventually(t, func() bool { return b.Stats().Invalidations == 3 }, time.Second, 5*time.Millisecond)
+	_, err = b.Get(ctx, "1")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	require.NoError(t, a.Set(ctx, "2", cachedUser{ID: "2"}))
+	_, err = b.Get(ctx, "2")
+	require.NoError(t, err)
+	require.NoError(t, a.Clear(ctx))
+	assert.Eventually(t, func() bool { return b.Stats().Invalidations == 5 }, time.Second, 5*time.Millisecond)
+	_, err = b.Get(ctx, "2")
+	assert.ErrorIs(t, err, ErrCacheMiss)
+}
+
+func TestCache_WriterWithoutL1PublishesInvalidation(t *testing.T) {
+	mr := setupMiniredis(t)
+	ctx := context.Background()
+	writer := newTestCache[cachedUser](t, newTestClient(t, mr), "users", WithoutL1())
+	reader := newTestCache[cachedUser](t, newTestClient(t, mr), "users")
+
+	require.NoError(t, writer.Set(ctx, "1", cachedUser{ID: "1", Name: "v1"}))
+	_, err := reader.Get(ctx, "1")
+	require.NoError(t, err)
+
+	// 写入方没有 L1，读取方的 L1 仍然要收到通知
+	require.NoError(t, writer.Set(ctx, "1", cachedUser{ID: "1", Name: "v2"}))
+	assert.Eventually(t, func() bool { return reader.Stats().Invalidations == 2 }, time.Second, 5*time.Millisecond)
+	u, err := reader.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", u.Name)
+}
+
+func TestCache_ClearOnlyAffectsNamespace(t *testing.T) {
+	mr := setupMiniredis(t)
+	client := newTestClient(t, mr)
+	ctx := context.Background()
+
+	users := newTestCache[int](t, client, "users", WithoutInvalidation())
+	for i := range 250 {
+		require.NoError(t, users.Set(ctx, strconv.Itoa(i), i))
+	}
+	require.NoError(t, mr.Set("orders:1", "keep"))
+
+	require.NoError(t, users.Clear(ctx))
+	assert.Equal(t, []string{"orders:1"}, mr.Keys())
+}
+
+func TestCache_SerializerAndTTL(t *testing.T) {
+	mr := setupMiniredis(t)
+	cache := newTestCache[cachedUser](t, newTestClient(t, mr), "users",
+		WithSerializer(GobSerializer{}), WithTTL(time.Hour), WithoutL1())
+	ctx := context.Background()
+
+	require.NoError(t, cache.Set(ctx, "1", cachedUser{ID: "1", Name: "gob"}))
+	assert.Equal(t, time.Hour, mr.TTL("users:1"))
+
+	u, err := cache.Get(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "gob", u.Name)
+	assert.Equal(t, uint64(1), cache.Stats().L2Hits)
+	assert.Equal(t, uint64(0), cache.Stats().L1Misses)
+
+	require.NoError(t, mr.Set("users:2", "garbage"))
+	_, err = cache.Get(ctx, "2")
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1), cache.Stats().Errors)
+}
+
+func TestCache_RedisDownFallsBackToLoader(t *testing.T) {
+	mr := setupMiniredis(t)
+	cache := newTestCache[cachedUser](t, newTestClient(t, mr), "users", WithoutL1())
+	mr.Close()
+
+	u, err := cache.GetOrLoad(context.Background(), "1", func(ctx context.Context, id string) (cachedUser, error) {
+		return cachedUser{ID: id}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1", u.ID)
+	assert.NotZero(t, cache.Stats().Errors)
+}
@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruItem LRU 链表中的元素
+type lruItem[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// lru 带过期时间的 LRU 缓存（进程内一级缓存）
+//
+// 设计原理：
+// 1. 哈希表 + 双向链表，Get/Set/Delete 均为 O(1)
+// 2. 链表头部是最近访问的元素，容量满时淘汰链表尾部的元素
+// 3. 过期检查是惰性的：读取时发现过期才删除，不启动后台清理 goroutine
+type lru[V any] struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+// newLRU 创建 LRU 缓存
+//
+// 参数：
+//   - size: 最大元素数，必须大于 0
+func newLRU[V any](size int) *lru[V] {
+	return &lru[V]{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+		now:   time.Now,
+	}
+}
+
+// get 返回未过期的元素，并将其移到链表头部
+func (c *lru[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	item := elem.Value.(*lruItem[V])
+	if !item.expiresAt.IsZero() && !c.now().Before(item.expiresAt) {
+		c.removeElement(elem)
+		return zero, false
+	}
+	c.ll.MoveToFront(elem)
+	return item.value, true
+}
+
+// set 写入元素，容量满时淘汰最久未访问的元素
+//
+// ttl <= 0 表示不过期（只按容量淘汰）
+func (c *lru[V]) set(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*lruItem[V])
+		item.value = value
+		item.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&lruItem[V]{key: key, value: value, expiresAt: expiresAt})
+	for c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// delete 删除元素
+func (c *lru[V]) delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// purge 清空所有元素
+func (c *lru[V]) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	clear(c.items)
+}
+
+// len 返回元素数（包括尚未被惰性删除的过期元素）
+func (c *lru[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// removeElement 从链表和哈希表中删除元素，调用方需持有锁
+func (c *lru[V]) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*lruItem[V]).key)
+}
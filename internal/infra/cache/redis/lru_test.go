@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRU[int](2)
+	c.set("a", 1, 0)
+	c.set("b", 2, 0)
+
+	// 访问 a 后，b 成为最久未访问的元素
+	_, ok := c.get("a")
+	assert.True(t, ok)
+	c.set("c", 3, 0)
+
+	_, ok = c.get("b")
+	assert.False(t, ok)
+	v, ok := c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 2, c.len())
+}
+
+func TestLRU_Expiration(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newLRU[string](10)
+	c.now = func() time.Time { return now }
+
+	c.set("short", "x", time.Second)
+	c.set("forever", "y", 0)
+
+	now = now.Add(2 * time.Second)
+	_, ok := c.get("short")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.len(), "expired item is removed lazily on get")
+
+	v, ok := c.get("forever")
+	assert.True(t, ok)
+	assert.Equal(t, "y", v)
+}
+
+func TestLRU_UpdateDeletePurge(t *testing.T) {
+	c := newLRU[int](10)
+	c.set("a", 1, 0)
+	c.set("a", 2, 0)
+	v, _ := c.get("a")
+	assert.Equal(t, 2, v)
+	assert.Equal(t, 1, c.len())
+
+	c.set("b", 3, 0)
+	c.delete("a", "missing")
+	_, ok := c.get("a")
+	assert.False(t, ok)
+
+	c.purge()
+	assert.Equal(t, 0, c.len())
+}
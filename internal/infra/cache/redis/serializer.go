@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Serializer 缓存值的序列化器
+//
+// Cache 使用 Serializer 将值编码后写入 Redis，读取时再解码。
+// 同一个 Redis 命名空间下的所有实例必须使用相同的序列化器。
+//
+// 实现：
+// - JSONSerializer: 默认实现，可读性好，便于排查问题
+// - GobSerializer: 体积更小，但只能在 Go 程序之间共享
+type Serializer interface {
+	// Marshal 将值编码为字节
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal 将字节解码到 v（指针）
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONSerializer 使用 encoding/json 序列化
+type JSONSerializer struct{}
+
+// Marshal 将值编码为 JSON
+func (JSONSerializer) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal 解码 JSON
+func (JSONSerializer) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobSerializer 使用 encoding/gob 序列化
+//
+// 注意事项：
+// - 接口类型的字段需要先调用 gob.Register 注册具体类型
+type GobSerializer struct{}
+
+// Marshal 将值编码为 gob
+func (GobSerializer) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal 解码 gob
+func (GobSerializer) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
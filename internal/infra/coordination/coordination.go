@@ -0,0 +1,80 @@
+// Package coordination 提供分布式锁和主节点选举。
+//
+// 设计原则：
+// 1. 租约：锁总是带过期时间，持有者崩溃后锁会自动释放，不会永久阻塞其他实例
+// 2. 防护令牌（fencing token）：每次获取锁都会得到一个单调递增的令牌，
+// 下游资源拒绝比已见过的令牌更小的写入，防止 GC 停顿或网络分区后的"旧持有者"继续写入
+// 3. 可替换实现：Locker 接口有 Redis（Redlock）和内存两种实现，测试中无需启动 Redis
+//
+// 核心组件：
+// - Locker/Lease: 获取、续期、释放租约的底层接口
+// - Mutex/Lock: 带重试和自动续期的互斥锁，锁丢失时通过 Lost 通道通知
+// - LeaderElector: 基于 Mutex 的主节点选举，成为/失去主节点时调用回调
+//
+// 示例：
+//
+//	locker := coordination.NewRedisLocker([]*redis.Client{client})
+//	mutex := coordination.NewMutex(locker, "jobs:daily-report", coordination.WithLeaseTTL(30*time.Second))
+//
+//	lock, err := mutex.Lock(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	defer lock.Unlock(context.Background())
+//
+//	// 将 lock.Token() 随写入一起发送给下游，下游拒绝更小的令牌
+//	err = report.Generate(lock.Context(ctx), lock.Token())
+package coordination
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotAcquired 锁已被其他持有者占用
+	ErrNotAcquired = errors.New("coordination: lock not acquired")
+
+	// ErrLockLost 锁已过期或被其他持有者获取
+	ErrLockLost = errors.New("coordination: lock lost")
+
+	// ErrInvalidTTL 租约时间无效：必须大于 0，Mutex 的租约还不能小于 MinLeaseTTL
+	ErrInvalidTTL = errors.New("coordination: ttl must be positive")
+)
+
+// Locker 分布式锁的底层接口
+//
+// 实现：
+// - RedisLocker: Redlock 算法，支持单个或多个独立的 Redis 实例
+// - MemoryLocker: 进程内实现，用于测试和单实例部署
+//
+// 实现要求：
+// - 同一个名称同时最多只有一个有效租约
+// - 同一个名称每次获取成功返回的防护令牌严格递增
+// - 所有方法必须是并发安全的
+type Locker interface {
+	// TryAcquire 尝试获取锁，不等待
+	//
+	// 锁已被占用时返回 ErrNotAcquired
+	TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lease, error)
+}
+
+// Lease 锁的租约
+type Lease interface {
+	// Name 返回锁名称
+	Name() string
+
+	// Token 返回防护令牌
+	Token() uint64
+
+	// Refresh 将租约延长为从现在起 ttl
+	//
+	// 租约已过期或被其他持有者获取时返回 ErrLockLost
+	Refresh(ctx context.Context, ttl time.Duration) error
+
+	// Release 释放锁
+	//
+	// 租约已经失效时返回 nil
+	Release(ctx context.Context) error
+}
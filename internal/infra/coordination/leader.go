@@ -0,0 +1,165 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// electorOptions LeaderElector 的可选配置
+type electorOptions struct {
+	mutexOptions []MutexOption
+	onElected    func(ctx context.Context)
+	onDemoted    func()
+}
+
+// ElectorOption LeaderElector 配置选项
+type ElectorOption func(*electorOptions)
+
+// WithOnElected 设置成为主节点时的回调
+//
+// 回调在单独的 goroutine 中执行，ctx 在失去主节点或 Run 的 ctx 取消时取消。
+// 回调可以阻塞执行主节点的工作，但必须在 ctx 取消后尽快返回：
+// 回调返回之前不会释放锁，也不会调用 OnDemoted。
+func WithOnElected(fn func(ctx context.Context)) ElectorOption {
+	return func(o *electorOptions) {
+		o.onElected = fn
+	}
+}
+
+// WithOnDemoted 设置失去主节点时的回调，在 OnElected 返回之后调用
+func WithOnDemoted(fn func()) ElectorOption {
+	return func(o *electorOptions) {
+		o.onDemoted = fn
+	}
+}
+
+// WithElectionMutex 设置选举使用的锁的配置，如 WithLeaseTTL、WithRetryInterval
+func WithElectionMutex(opts ...MutexOption) ElectorOption {
+	return func(o *electorOptions) {
+		o.mutexOptions = append(o.mutexOptions, opts...)
+	}
+}
+
+// LeaderElector 基于分布式锁的主节点选举
+//
+// 设计原理：
+// 1. 所有候选者竞争同一个锁，持有锁的实例是主节点
+// 2. 主节点持续续期；锁丢失（续期失败、网络分区）时立即取消 OnElected 的 ctx
+// 3. 失去主节点后重新参与竞争，直到 Run 的 ctx 取消
+// 4. Run 退出前主动释放锁，其他候选者无需等待租约过期
+//
+// 使用场景：
+// - 定时任务只在一个实例上执行
+// - Temporal Worker 中只能有一个实例运行的后台循环
+//
+// 示例：
+//
+//	elector := coordination.NewLeaderElector(locker, "cron:billing",
+//	    coordination.WithOnElected(func(ctx context.Context) {
+//	        scheduler.Run(ctx) // 阻塞直到 ctx 取消
+//	    }),
+//	    coordination.WithOnDemoted(func() {
+//	        logger.Info("no longer leader")
+//	    }),
+//	    coordination.WithElectionMutex(coordination.WithLeaseTTL(15*time.Second)),
+//	)
+//	go elector.Run(ctx)
+type LeaderElector struct {
+	mutex  *Mutex
+	opts   electorOptions
+	leader atomic.Bool
+	token  atomic.Uint64
+}
+
+// NewLeaderElector 创建主节点选举器
+//
+// 参数：
+//   - locker: 锁实现
+//   - name: 选举名称，同一组候选者必须相同
+//   - opts: 可选配置，如 WithOnElected、WithOnDemoted
+func NewLeaderElector(locker Locker, name string, opts ...ElectorOption) *LeaderElector {
+	var o electorOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &LeaderElector{
+		mutex: NewMutex(locker, name, o.mutexOptions...),
+		opts:  o,
+	}
+}
+
+// Run 参与选举，阻塞直到 ctx 取消
+//
+// 返回：
+//   - error: ctx 取消时返回 nil；Locker 返回无法重试的错误（如 ErrInvalidTTL）时返回该错误
+func (e *LeaderElector) Run(ctx context.Context) error {
+	for {
+		lock, err := e.mutex.Lock(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, ErrInvalidTTL) {
+				return err
+			}
+			// 存储暂时不可用，稍后重试
+			slog.Warn("leader election failed", "election", e.mutex.name, "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(e.mutex.opts.retryInterval):
+			}
+			continue
+		}
+
+		e.lead(ctx, lock)
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// IsLeader 返回当前是否是主节点
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Token 返回成为主节点时获得的防护令牌，不是主节点时返回 0
+func (e *LeaderElector) Token() uint64 {
+	return e.token.Load()
+}
+
+// lead 作为主节点运行，直到锁丢失或 ctx 取消
+func (e *LeaderElector) lead(ctx context.Context, lock *Lock) {
+	leaderCtx, cancel := lock.Context(ctx)
+	defer cancel()
+
+	e.token.Store(lock.Token())
+	e.leader.Store(true)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if e.opts.onElected != nil {
+			e.opts.onElected(leaderCtx)
+		}
+	}()
+
+	<-leaderCtx.Done()
+	<-done
+
+	e.leader.Store(false)
+	e.token.Store(0)
+	if e.opts.onDemoted != nil {
+		e.opts.onDemoted()
+	}
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer releaseCancel()
+	if err := lock.Unlock(releaseCtx); err != nil {
+		slog.Warn("failed to release leadership", "election", e.mutex.name, "error", err)
+	}
+}
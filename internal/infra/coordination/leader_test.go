@@ -0,0 +1,123 @@
+package coordination
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// electionRecorder 记录选举回调
+type electionRecorder struct {
+	mu      sync.Mutex
+	elected int
+	demoted int
+	active  int
+	overlap bool
+}
+
+func (r *electionRecorder) options() []ElectorOption {
+	return []ElectorOption{
+		WithOnElected(func(ctx context.Context) {
+			r.mu.Lock()
+			r.elected++
+			r.active++
+			if r.active > 1 {
+				r.overlap = true
+			}
+			r.mu.Unlock()
+
+			<-ctx.Done()
+
+			r.mu.Lock()
+			r.active--
+			r.mu.Unlock()
+		}),
+		WithOnDemoted(func() {
+			r.mu.Lock()
+			r.demoted++
+			r.mu.Unlock()
+		}),
+		WithElectionMutex(WithLeaseTTL(60*time.Millisecond), WithRetryInterval(5*time.Millisecond)),
+	}
+}
+
+func (r *electionRecorder) counts() (elected, demoted int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.elected, r.demoted
+}
+
+func TestLeaderElector_Failover(t *testing.T) {
+	locker := NewMemoryLocker()
+	recorder := &electionRecorder{}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+
+	a := NewLeaderElector(locker, "cron", recorder.options()...)
+	b := NewLeaderElector(locker, "cron", recorder.options()...)
+
+	doneA := make(chan error, 1)
+	go func() { doneA <- a.Run(ctxA) }()
+	require.Eventually(t, a.IsLeader, time.Second, 5*time.Millisecond)
+	firstToken := a.Token()
+
+	doneB := make(chan error, 1)
+	go func() { doneB <- b.Run(ctxB) }()
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, b.IsLeader(), "only one leader at a time")
+
+	// a 退出后 b 接任，令牌更大
+	cancelA()
+	require.NoError(t, <-doneA)
+	assert.False(t, a.IsLeader())
+	require.Eventually(t, b.IsLeader, time.Second, 5*time.Millisecond)
+	assert.Greater(t, b.Token(), firstToken)
+
+	cancelB()
+	require.NoError(t, <-doneB)
+
+	elected, demoted := recorder.counts()
+	assert.Equal(t, 2, elected)
+	assert.Equal(t, 2, demoted)
+	assert.False(t, recorder.overlap)
+}
+
+func TestLeaderElector_LosesLeadership(t *testing.T) {
+	locker := NewMemoryLocker()
+	recorder := &electionRecorder{}
+	elector := NewLeaderElector(locker, "cron", recorder.options()...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- elector.Run(ctx) }()
+	require.Eventually(t, elector.IsLeader, time.Second, 5*time.Millisecond)
+	firstToken := elector.Token()
+
+	// 锁被其他实例抢占后失去主节点，之后重新参与选举
+	locker.Expire("cron")
+	intruder, err := locker.TryAcquire(ctx, "cron", 100*time.Millisecond)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		_, demoted := recorder.counts()
+		return demoted == 1
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, intruder.Release(ctx))
+	require.Eventually(t, elector.IsLeader, time.Second, 5*time.Millisecond)
+	assert.Greater(t, elector.Token(), intruder.Token())
+	assert.Greater(t, intruder.Token(), firstToken)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestLeaderElector_InvalidTTL(t *testing.T) {
+	elector := NewLeaderElector(NewMemoryLocker(), "cron", WithElectionMutex(WithLeaseTTL(0)))
+	assert.ErrorIs(t, elector.Run(context.Background()), ErrInvalidTTL)
+}
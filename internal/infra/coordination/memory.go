@@ -0,0 +1,117 @@
+package coordination
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryLock 内存锁的状态
+type memoryLock struct {
+	owner     uint64 // 当前持有者的令牌，0 表示未被持有
+	expiresAt time.Time
+}
+
+// MemoryLocker 进程内的 Locker 实现
+//
+// 设计原理：
+// 1. 与 RedisLocker 语义一致：租约会过期，防护令牌按名称单调递增
+// 2. 过期检查是惰性的，不启动后台 goroutine
+//
+// 使用场景：
+// - 单元测试中替代 RedisLocker
+// - 单实例部署时的互斥和选举
+type MemoryLocker struct {
+	mu     sync.Mutex
+	locks  map[string]*memoryLock
+	fences map[string]uint64
+	now    func() time.Time
+}
+
+// NewMemoryLocker 创建内存锁
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		locks:  make(map[string]*memoryLock),
+		fences: make(map[string]uint64),
+		now:    time.Now,
+	}
+}
+
+// TryAcquire 尝试获取锁
+func (l *MemoryLocker) TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lease, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if lock, ok := l.locks[name]; ok && lock.owner != 0 && now.Before(lock.expiresAt) {
+		return nil, ErrNotAcquired
+	}
+
+	l.fences[name]++
+	token := l.fences[name]
+	l.locks[name] = &memoryLock{owner: token, expiresAt: now.Add(ttl)}
+	return &memoryLease{locker: l, name: name, token: token}, nil
+}
+
+// Expire 立即使锁过期，用于测试锁丢失的场景
+func (l *MemoryLocker) Expire(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.locks, name)
+}
+
+// memoryLease 内存锁的租约
+type memoryLease struct {
+	locker *MemoryLocker
+	name   string
+	token  uint64
+}
+
+// Name 返回锁名称
+func (l *memoryLease) Name() string {
+	return l.name
+}
+
+// Token 返回防护令牌
+func (l *memoryLease) Token() uint64 {
+	return l.token
+}
+
+// Refresh 延长租约
+func (l *memoryLease) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+
+	now := l.locker.now()
+	lock, ok := l.locker.locks[l.name]
+	if !ok || lock.owner != l.token || !now.Before(lock.expiresAt) {
+		return ErrLockLost
+	}
+	lock.expiresAt = now.Add(ttl)
+	return nil
+}
+
+// Release 释放锁
+func (l *memoryLease) Release(ctx context.Context) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+
+	if lock, ok := l.locker.locks[l.name]; ok && lock.owner == l.token {
+		delete(l.locker.locks, l.name)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLocker_ExclusiveAndFencing(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+
+	first, err := locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "job", first.Name())
+	assert.Equal(t, uint64(1), first.Token())
+
+	_, err = locker.TryAcquire(ctx, "job", time.Minute)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	// 不同名称互不影响
+	other, err := locker.TryAcquire(ctx, "other", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), other.Token())
+
+	require.NoError(t, first.Release(ctx))
+	second, err := locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), second.Token())
+
+	// 旧租约不能释放或续期新持有者的锁
+	require.NoError(t, first.Release(ctx))
+	assert.ErrorIs(t, first.Refresh(ctx, time.Minute), ErrLockLost)
+	assert.NoError(t, second.Refresh(ctx, time.Minute))
+
+	_, err = locker.TryAcquire(ctx, "job", 0)
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+}
+
+func TestMemoryLocker_Expiration(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	locker := NewMemoryLocker()
+	locker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	lease, err := locker.TryAcquire(ctx, "job", time.Second)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Second)
+	assert.ErrorIs(t, lease.Refresh(ctx, time.Second), ErrLockLost)
+
+	next, err := locker.TryAcquire(ctx, "job", time.Second)
+	require.NoError(t, err)
+	assert.Greater(t, next.Token(), lease.Token())
+}
+
+func TestMutex_AutoRenewal(t *testing.T) {
+	locker := NewMemoryLocker()
+	mutex := NewMutex(locker, "job", WithLeaseTTL(60*time.Millisecond))
+	ctx := context.Background()
+
+	lock, err := mutex.TryLock(ctx)
+	require.NoError(t, err)
+
+	// 超过租约时间后仍然持有锁
+	time.Sleep(200 * time.Millisecond)
+	_, err = mutex.TryLock(ctx)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	select {
+	case <-lock.Lost():
+		t.Fatal("lock should not be lost while renewing")
+	default:
+	}
+
+	require.NoError(t, lock.Unlock(ctx))
+	again, err := mutex.TryLock(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), again.Token())
+	require.NoError(t, again.Unlock(ctx))
+}
+
+func TestMutex_InvalidTTL(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+
+	for _, ttl := range []time.Duration{-time.Second, 0, 2 * time.Nanosecond, MinLeaseTTL - 1} {
+		mutex := NewMutex(locker, "job", WithLeaseTTL(ttl))
+		_, err := mutex.TryLock(ctx)
+		assert.ErrorIs(t, err, ErrInvalidTTL, ttl)
+		_, err = mutex.Lock(ctx)
+		assert.ErrorIs(t, err, ErrInvalidTTL, ttl)
+	}
+
+	lock, err := NewMutex(locker, "job", WithLeaseTTL(MinLeaseTTL)).TryLock(ctx)
+	require.NoError(t, err)
+	require.NoError(t, lock.Unlock(ctx))
+}
+
+func TestMutex_LockWaitsForRelease(t *testing.T) {
+	locker := NewMemoryLocker()
+	mutex := NewMutex(locker, "job", WithRetryInterval(5*time.Millisecond))
+	ctx := context.Background()
+
+	held, err := mutex.Lock(ctx)
+	require.NoError(t, err)
+
+	acquired := make(chan *Lock)
+	go func() {
+		lock, err := mutex.Lock(ctx)
+		assert.NoError(t, err)
+		acquired <- lock
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	require.NoError(t, held.Unlock(ctx))
+	select {
+	case lock := <-acquired:
+		assert.Equal(t, uint64(2), lock.Token())
+		require.NoError(t, lock.Unlock(ctx))
+	case <-time.After(time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+
+	cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	held, err = mutex.Lock(ctx)
+	require.NoError(t, err)
+	_, err = mutex.Lock(cancelled)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NoError(t, held.Unlock(ctx))
+}
+
+func TestMutex_LostCancelsContext(t *testing.T) {
+	locker := NewMemoryLocker()
+	mutex := NewMutex(locker, "job", WithLeaseTTL(30*time.Millisecond))
+	ctx := context.Background()
+
+	lock, err := mutex.TryLock(ctx)
+	require.NoError(t, err)
+	lockCtx, cancel := lock.Context(ctx)
+	defer cancel()
+
+	// 模拟租约过期后被其他实例获取
+	locker.Expire("job")
+	_, err = locker.TryAcquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lock loss not detected")
+	}
+	<-lockCtx.Done()
+	assert.ErrorIs(t, context.Cause(lockCtx), ErrLockLost)
+	assert.NoError(t, lock.Unlock(ctx))
+}
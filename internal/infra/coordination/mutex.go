@@ -0,0 +1,222 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Mutex 默认配置
+const (
+	DefaultLeaseTTL      = 30 * time.Second
+	DefaultRetryInterval = 200 * time.Millisecond
+
+	// MinLeaseTTL 允许的最小租约时间
+	//
+	// Redis 以毫秒为单位设置过期时间，续期间隔为 ttl/3，更短的租约无法可靠续期
+	MinLeaseTTL = 10 * time.Millisecond
+)
+
+// mutexOptions Mutex 的可选配置
+type mutexOptions struct {
+	ttl           time.Duration
+	retryInterval time.Duration
+}
+
+// MutexOption Mutex 配置选项
+type MutexOption func(*mutexOptions)
+
+// WithLeaseTTL 设置租约时间，默认 DefaultLeaseTTL
+//
+// 持有锁期间每 ttl/3 续期一次；进程崩溃后其他实例最多等待 ttl 获取锁。
+// ttl 小于 MinLeaseTTL 时 TryLock 和 Lock 返回 ErrInvalidTTL
+func WithLeaseTTL(ttl time.Duration) MutexOption {
+	return func(o *mutexOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithRetryInterval 设置 Lock 重试获取的间隔，默认 DefaultRetryInterval
+func WithRetryInterval(interval time.Duration) MutexOption {
+	return func(o *mutexOptions) {
+		o.retryInterval = interval
+	}
+}
+
+// Mutex 带自动续期的分布式互斥锁
+//
+// 设计原理：
+// 1. Lock 按 retryInterval 重试 TryAcquire，直到获取成功或 ctx 取消
+// 2. 获取成功后后台 goroutine 每 ttl/3 续期一次
+// 3. 续期返回 ErrLockLost，或者连续失败直到租约过期时，认为锁已丢失并关闭 Lost 通道
+//
+// 注意事项：
+// - 锁丢失后持有者必须立即停止受保护的操作，推荐使用 Lock.Context 传播取消
+// - 分布式锁无法完全避免两个持有者同时运行（如 GC 停顿），需要正确性保证时应使用 Token 作为防护令牌
+type Mutex struct {
+	locker Locker
+	name   string
+	opts   mutexOptions
+}
+
+// NewMutex 创建分布式互斥锁
+//
+// 参数：
+//   - locker: 锁实现，如 NewRedisLocker、NewMemoryLocker
+//   - name: 锁名称
+//   - opts: 可选配置，如 WithLeaseTTL
+func NewMutex(locker Locker, name string, opts ...MutexOption) *Mutex {
+	o := mutexOptions{
+		ttl:           DefaultLeaseTTL,
+		retryInterval: DefaultRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Mutex{locker: locker, name: name, opts: o}
+}
+
+// TryLock 尝试获取锁，不等待
+//
+// 返回：
+//   - *Lock: 获取成功的锁，已经开始自动续期
+//   - error: 锁已被占用时返回 ErrNotAcquired；租约时间小于 MinLeaseTTL 时返回 ErrInvalidTTL
+func (m *Mutex) TryLock(ctx context.Context) (*Lock, error) {
+	if m.opts.ttl < MinLeaseTTL {
+		return nil, fmt.Errorf("%w: lease ttl %s is below %s", ErrInvalidTTL, m.opts.ttl, MinLeaseTTL)
+	}
+	lease, err := m.locker.TryAcquire(ctx, m.name, m.opts.ttl)
+	if err != nil {
+		return nil, err
+	}
+	return newLock(lease, m.opts.ttl), nil
+}
+
+// Lock 获取锁，锁被占用时等待
+//
+// 返回：
+//   - *Lock: 获取成功的锁，已经开始自动续期
+//   - error: ctx 取消时返回 ctx.Err()；Locker 返回 ErrNotAcquired 以外的错误时直接返回
+//
+// 部分 Redis 实例不可用导致未达到多数时，RedisLocker 返回包装了 ErrNotAcquired 的错误，Lock 会继续重试
+func (m *Mutex) Lock(ctx context.Context) (*Lock, error) {
+	for {
+		lock, err := m.TryLock(ctx)
+		if err == nil {
+			return lock, nil
+		}
+		if !errors.Is(err, ErrNotAcquired) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(m.opts.retryInterval):
+		}
+	}
+}
+
+// Lock 已获取的锁
+type Lock struct {
+	lease Lease
+	ttl   time.Duration
+
+	lost     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newLock 创建锁并启动续期 goroutine
+func newLock(lease Lease, ttl time.Duration) *Lock {
+	l := &Lock{
+		lease: lease,
+		ttl:   ttl,
+		lost:  make(chan struct{}),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go l.renew()
+	return l
+}
+
+// Name 返回锁名称
+func (l *Lock) Name() string {
+	return l.lease.Name()
+}
+
+// Token 返回防护令牌，同一个锁名称每次获取都会更大
+func (l *Lock) Token() uint64 {
+	return l.lease.Token()
+}
+
+// Lost 返回锁丢失时关闭的通道
+//
+// 调用 Unlock 不会关闭该通道
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Context 返回在锁丢失或 parent 取消时取消的 ctx
+//
+// 锁丢失时 context.Cause 返回 ErrLockLost
+func (l *Lock) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	go func() {
+		select {
+		case <-l.lost:
+			cancel(ErrLockLost)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// Unlock 停止续期并释放锁
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	return l.lease.Release(ctx)
+}
+
+// renew 定期续期，直到 Unlock 或锁丢失
+func (l *Lock) renew() {
+	defer close(l.done)
+
+	// TryLock 已经保证 ttl >= MinLeaseTTL，这里再设置下限，避免 NewTicker 因间隔为 0 panic
+	interval := max(l.ttl/3, time.Millisecond)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// 时钟漂移按租约时间的 1% 加 2ms 估算，与 RedisLocker.TryAcquire 一致
+	drift := l.ttl/100 + 2*time.Millisecond
+	validUntil := time.Now().Add(l.ttl - drift)
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// 租约从发出请求时开始计算，不包含请求耗时
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := l.lease.Refresh(ctx, l.ttl)
+		cancel()
+		if err == nil {
+			validUntil = start.Add(l.ttl - drift)
+			continue
+		}
+
+		if errors.Is(err, ErrLockLost) || !time.Now().Before(validUntil) {
+			slog.Warn("distributed lock lost", "lock", l.lease.Name(), "token", l.lease.Token(), "error", err)
+			close(l.lost)
+			return
+		}
+		slog.Warn("failed to refresh distributed lock", "lock", l.lease.Name(), "error", err)
+	}
+}
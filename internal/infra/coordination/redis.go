@@ -0,0 +1,322 @@
+package coordination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/golang/internal/infra/cache/redis"
+)
+
+// DefaultKeyPrefix RedisLocker 的默认键前缀
+const DefaultKeyPrefix = "coordination:"
+
+// acquireScript 获取锁，成功时返回当前的防护令牌，失败时返回 -1
+var acquireScript = goredis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return tonumber(redis.call('GET', KEYS[2]) or '0')
+end
+return -1
+`)
+
+// fenceScript 将防护令牌提升到 ARGV[1]（只增不减）
+var fenceScript = goredis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if tonumber(ARGV[1]) > current then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// refreshScript 值匹配时延长过期时间
+var refreshScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript 值匹配时删除锁
+var releaseScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// redisLockerOptions RedisLocker 的可选配置
+type redisLockerOptions struct {
+	prefix  string
+	timeout time.Duration
+}
+
+// RedisLockerOption RedisLocker 配置选项
+type RedisLockerOption func(*redisLockerOptions)
+
+// WithKeyPrefix 设置键前缀，默认 DefaultKeyPrefix
+//
+// 锁的键为 "<prefix>lock:<name>"，防护令牌的键为 "<prefix>fence:<name>"
+func WithKeyPrefix(prefix string) RedisLockerOption {
+	return func(o *redisLockerOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithInstanceTimeout 设置访问单个 Redis 实例的超时，默认 100ms
+//
+// 超时应该远小于租约时间，避免某个实例故障时拖慢获取锁
+func WithInstanceTimeout(timeout time.Duration) RedisLockerOption {
+	return func(o *redisLockerOptions) {
+		o.timeout = timeout
+	}
+}
+
+// RedisLocker 基于 Redis 的 Locker 实现（Redlock 算法）
+//
+// 设计原理：
+// 1. 在 N 个独立的 Redis 实例上用 SET NX PX 写入随机值，多数（N/2+1）成功且耗时小于租约时间才算获取成功
+// 2. 获取失败时在所有实例上释放，避免残留的部分锁阻塞其他竞争者
+// 3. 续期和释放使用 Lua 脚本比较值，只影响自己的锁
+// 4. 防护令牌：获取成功后读取多数实例上的令牌最大值并加一，再写回这些实例；
+// 任意两个多数派至少有一个公共实例，因此后一次获取的令牌一定更大
+//
+// 注意事项：
+// - 只有一个实例时退化为单节点锁，Redis 主从切换可能导致锁丢失，需要依靠防护令牌保证正确性
+// - 多个实例必须是互相独立的主节点，而不是同一个集群的主从
+type RedisLocker struct {
+	clients []*goredis.Client
+	quorum  int
+	opts    redisLockerOptions
+}
+
+// NewRedisLocker 创建基于 Redis 的分布式锁
+//
+// 参数：
+//   - clients: 独立的 Redis 实例，至少一个，推荐 1、3 或 5 个
+//   - opts: 可选配置，如 WithKeyPrefix
+//
+// 示例：
+//
+//	locker := coordination.NewRedisLocker([]*redis.Client{redis1, redis2, redis3})
+func NewRedisLocker(clients []*redis.Client, opts ...RedisLockerOption) *RedisLocker {
+	o := redisLockerOptions{
+		prefix:  DefaultKeyPrefix,
+		timeout: 100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	rdbs := make([]*goredis.Client, len(clients))
+	for i, client := range clients {
+		rdbs[i] = client.GetClient()
+	}
+	return &RedisLocker{
+		clients: rdbs,
+		quorum:  len(rdbs)/2 + 1,
+		opts:    o,
+	}
+}
+
+// TryAcquire 尝试获取锁
+func (l *RedisLocker) TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lease, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	if len(l.clients) == 0 {
+		return nil, errors.New("coordination: no redis instances configured")
+	}
+
+	lease := &redisLease{
+		locker:   l,
+		name:     name,
+		value:    uuid.NewString(),
+		lockKey:  l.opts.prefix + "lock:" + name,
+		fenceKey: l.opts.prefix + "fence:" + name,
+	}
+
+	start := time.Now()
+	fences := make([]int64, len(l.clients))
+	errs := l.each(ctx, func(ctx context.Context, i int, rdb *goredis.Client) error {
+		fence, err := acquireScript.Run(ctx, rdb, []string{lease.lockKey, lease.fenceKey}, lease.value, ttl.Milliseconds()).Int64()
+		if err != nil {
+			return err
+		}
+		if fence < 0 {
+			return ErrNotAcquired
+		}
+		fences[i] = fence
+		return nil
+	})
+
+	var acquired []int
+	var maxFence int64
+	for i, err := range errs {
+		if err == nil {
+			acquired = append(acquired, i)
+			maxFence = max(maxFence, fences[i])
+		}
+	}
+
+	// 时钟漂移按租约时间的 1% 加 2ms 估算
+	drift := ttl/100 + 2*time.Millisecond
+	if len(acquired) < l.quorum || time.Since(start)+drift >= ttl {
+		l.releaseAll(lease)
+		if err := firstError(errs, ErrNotAcquired); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNotAcquired, err)
+		}
+		return nil, ErrNotAcquired
+	}
+
+	lease.token = uint64(maxFence) + 1
+	if err := l.fence(ctx, lease, acquired); err != nil {
+		l.releaseAll(lease)
+		return nil, err
+	}
+	return lease, nil
+}
+
+// fence 在获取成功的实例上写入新的防护令牌
+func (l *RedisLocker) fence(ctx context.Context, lease *redisLease, acquired []int) error {
+	clients := make([]*goredis.Client, len(acquired))
+	for i, idx := range acquired {
+		clients[i] = l.clients[idx]
+	}
+	errs := l.run(ctx, clients, func(ctx context.Context, _ int, rdb *goredis.Client) error {
+		return fenceScript.Run(ctx, rdb, []string{lease.fenceKey}, lease.token).Err()
+	})
+
+	ok := 0
+	for _, err := range errs {
+		if err == nil {
+			ok++
+		}
+	}
+	if ok < l.quorum {
+		return fmt.Errorf("failed to store fencing token: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// releaseAll 在所有实例上释放锁（忽略错误，未释放的锁会自动过期）
+func (l *RedisLocker) releaseAll(lease *redisLease) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.opts.timeout)
+	defer cancel()
+	l.each(ctx, func(ctx context.Context, _ int, rdb *goredis.Client) error {
+		return releaseScript.Run(ctx, rdb, []string{lease.lockKey}, lease.value).Err()
+	})
+}
+
+// each 并发地对每个实例执行 fn，返回每个实例的错误
+func (l *RedisLocker) each(ctx context.Context, fn func(ctx context.Context, i int, rdb *goredis.Client) error) []error {
+	return l.run(ctx, l.clients, fn)
+}
+
+// run 并发地对 clients 执行 fn，每次调用使用单独的超时
+func (l *RedisLocker) run(ctx context.Context, clients []*goredis.Client, fn func(ctx context.Context, i int, rdb *goredis.Client) error) []error {
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, rdb := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, l.opts.timeout)
+			defer cancel()
+			errs[i] = fn(ctx, i, rdb)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// firstError 返回第一个不是 ignore 的错误
+func firstError(errs []error, ignore error) error {
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, ignore) {
+			return err
+		}
+	}
+	return nil
+}
+
+// redisLease Redis 锁的租约
+type redisLease struct {
+	locker   *RedisLocker
+	name     string
+	value    string
+	token    uint64
+	lockKey  string
+	fenceKey string
+}
+
+// Name 返回锁名称
+func (l *redisLease) Name() string {
+	return l.name
+}
+
+// Token 返回防护令牌
+func (l *redisLease) Token() uint64 {
+	return l.token
+}
+
+// Refresh 在多数实例上延长租约
+//
+// 多数实例上的锁已经不属于自己时返回 ErrLockLost；网络错误导致未达到多数时返回其他错误，可以重试
+func (l *redisLease) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidTTL
+	}
+
+	errs := l.locker.each(ctx, func(ctx context.Context, _ int, rdb *goredis.Client) error {
+		n, err := refreshScript.Run(ctx, rdb, []string{l.lockKey}, l.value, ttl.Milliseconds()).Int64()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrLockLost
+		}
+		return nil
+	})
+
+	ok, lost := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, ErrLockLost):
+			lost++
+		}
+	}
+	if ok >= l.locker.quorum {
+		return nil
+	}
+	if lost > len(errs)-l.locker.quorum {
+		return ErrLockLost
+	}
+	return fmt.Errorf("failed to refresh lock: %w", errors.Join(errs...))
+}
+
+// Release 在所有实例上释放锁
+//
+// 多数实例释放成功即返回 nil，其余实例上的锁会在租约到期后自动过期
+func (l *redisLease) Release(ctx context.Context) error {
+	errs := l.locker.each(ctx, func(ctx context.Context, _ int, rdb *goredis.Client) error {
+		return releaseScript.Run(ctx, rdb, []string{l.lockKey}, l.value).Err()
+	})
+
+	ok := 0
+	for _, err := range errs {
+		if err == nil {
+			ok++
+		}
+	}
+	if ok < l.locker.quorum {
+		return fmt.Errorf("failed to release lock: %w", errors.Join(errs...))
+	}
+	return nil
+}
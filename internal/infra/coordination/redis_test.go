@@ -0,0 +1,153 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/golang/internal/infra/cache/redis"
+)
+
+// newRedisInstances 启动 n 个独立的 miniredis 实例
+func newRedisInstances(t *testing.T, n int) ([]*miniredis.Miniredis, []*redis.Client) {
+	t.Helper()
+	servers := make([]*miniredis.Miniredis, n)
+	clients := make([]*redis.Client, n)
+	for i := range n {
+		servers[i] = miniredis.RunT(t)
+		client, err := redis.NewClient(redis.Config{Addr: servers[i].Addr(), PoolSize: 5, MaxRetries: -1})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = client.Close() })
+		clients[i] = client
+	}
+	return servers, clients
+}
+
+func TestRedisLocker_AcquireRefreshRelease(t *testing.T) {
+	servers, clients := newRedisInstances(t, 1)
+	mr := servers[0]
+	locker := NewRedisLocker(clients)
+	ctx := context.Background()
+
+	lease, err := locker.TryAcquire(ctx, "job", 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), lease.Token())
+	assert.True(t, mr.Exists("coordination:lock:job"))
+	assert.Equal(t, 10*time.Second, mr.TTL("coordination:lock:job"))
+
+	_, err = locker.TryAcquire(ctx, "job", 10*time.Second)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	require.NoError(t, lease.Refresh(ctx, 20*time.Second))
+	assert.Equal(t, 20*time.Second, mr.TTL("coordination:lock:job"))
+
+	require.NoError(t, lease.Release(ctx))
+	assert.False(t, mr.Exists("coordination:lock:job"))
+
+	next, err := locker.TryAcquire(ctx, "job", 10*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), next.Token())
+
+	// 旧租约的释放不影响新持有者
+	require.NoError(t, lease.Release(ctx))
+	assert.True(t, mr.Exists("coordination:lock:job"))
+	assert.ErrorIs(t, lease.Refresh(ctx, time.Second), ErrLockLost)
+}
+
+func TestRedisLocker_ExpiredLeaseIsLost(t *testing.T) {
+	servers, clients := newRedisInstances(t, 1)
+	locker := NewRedisLocker(clients, WithKeyPrefix("app:"))
+	ctx := context.Background()
+
+	lease, err := locker.TryAcquire(ctx, "job", time.Second)
+	require.NoError(t, err)
+	servers[0].FastForward(2 * time.Second)
+
+	assert.ErrorIs(t, lease.Refresh(ctx, time.Second), ErrLockLost)
+	next, err := locker.TryAcquire(ctx, "job", time.Second)
+	require.NoError(t, err)
+	assert.Greater(t, next.Token(), lease.Token())
+	assert.Equal(t, "2", mustGet(t, servers[0], "app:fence:job"))
+}
+
+func TestRedisLocker_Quorum(t *testing.T) {
+	servers, clients := newRedisInstances(t, 3)
+	locker := NewRedisLocker(clients)
+	ctx := context.Background()
+
+	// 一个实例宕机时仍然可以获取（2/3）
+	servers[2].Close()
+	lease, err := locker.TryAcquire(ctx, "job", 10*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, lease.Refresh(ctx, 10*time.Second))
+
+	// 另一个竞争者只能拿到剩余的实例
+	_, err = locker.TryAcquire(ctx, "job", 10*time.Second)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	// 多数实例释放成功即可
+	require.NoError(t, lease.Release(ctx))
+	assert.False(t, servers[0].Exists("coordination:lock:job"))
+	assert.False(t, servers[1].Exists("coordination:lock:job"))
+}
+
+func TestRedisLocker_PartialLockIsRolledBack(t *testing.T) {
+	servers, clients := newRedisInstances(t, 3)
+	locker := NewRedisLocker(clients)
+	ctx := context.Background()
+
+	// 其他持有者占用了多数实例
+	require.NoError(t, servers[0].Set("coordination:lock:job", "someone-else"))
+	require.NoError(t, servers[1].Set("coordination:lock:job", "someone-else"))
+
+	_, err := locker.TryAcquire(ctx, "job", 10*time.Second)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+	assert.False(t, servers[2].Exists("coordination:lock:job"))
+}
+
+func TestRedisLocker_FencingAcrossQuorums(t *testing.T) {
+	servers, clients := newRedisInstances(t, 3)
+	locker := NewRedisLocker(clients)
+	ctx := context.Background()
+
+	// 多数派变化时令牌仍然递增
+	var last uint64
+	for i := range 3 {
+		servers[i].SetError("DOWN")
+		lease, err := locker.TryAcquire(ctx, "job", 10*time.Second)
+		require.NoError(t, err)
+		assert.Greater(t, lease.Token(), last)
+		last = lease.Token()
+		require.NoError(t, lease.Release(ctx))
+		servers[i].SetError("")
+	}
+}
+
+func TestMutex_RedisLostWhenKeyDeleted(t *testing.T) {
+	servers, clients := newRedisInstances(t, 1)
+	mutex := NewMutex(NewRedisLocker(clients), "job", WithLeaseTTL(60*time.Millisecond))
+	ctx := context.Background()
+
+	lock, err := mutex.TryLock(ctx)
+	require.NoError(t, err)
+	servers[0].Del("coordination:lock:job")
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lock loss not detected")
+	}
+	assert.NoError(t, lock.Unlock(ctx))
+}
+
+// mustGet 读取 miniredis 中的字符串值
+func mustGet(t *testing.T, mr *miniredis.Miniredis, key string) string {
+	t.Helper()
+	value, err := mr.Get(key)
+	require.NoError(t, err)
+	return value
+}
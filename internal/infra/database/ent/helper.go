@@ -13,10 +13,13 @@ package ent
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
 	"github.com/yourusername/golang/internal/infra/database/ent/migrate"
+	"github.com/yourusername/golang/pkg/transaction"
 )
 
 // NewClientFromConfig 从配置创建 Ent 客户端
@@ -77,6 +80,53 @@ func NewClientFromConfig(
 	return client, nil
 }
 
+// NewClientFromDB 基于已打开的 *sql.DB 创建 Ent 客户端
+//
+// 设计原理：
+// 1. 与 transaction.SQLTransactionManager 共享同一个连接池
+// 2. 事务管理器开启的 *sql.Tx 与 Ent 客户端指向同一个数据库，才能通过 WithContextTx 参与同一事务
+//
+// 参数：
+//   - db: 已打开的数据库连接
+//   - driverName: Ent 方言名称（dialect.Postgres、dialect.SQLite 等）
+//
+// 返回：
+//   - *Client: Ent 客户端实例，关闭客户端会同时关闭 db
+func NewClientFromDB(db *sql.DB, driverName string) *Client {
+	return NewClient(Driver(entsql.OpenDB(driverName, db)))
+}
+
+// WithContextTx 返回在上下文事务中执行的客户端
+//
+// 设计原理：
+// 1. 上下文中携带 *sql.Tx（transaction.GetSQLTx）时，返回绑定到该事务的客户端
+// 2. 否则返回客户端本身，调用方无需区分是否处于事务中
+//
+// 注意事项：
+// - 返回的客户端不负责提交或回滚，事务生命周期由 transaction.Manager 管理
+// - 事务必须来自与本客户端相同的数据库，否则写入会落到另一个连接上
+//
+// 参数：
+//   - ctx: 上下文
+//
+// 返回：
+//   - *Client: 事务客户端或客户端本身
+func (c *Client) WithContextTx(ctx context.Context) *Client {
+	tx, ok := transaction.GetSQLTx(ctx)
+	if !ok {
+		return c
+	}
+	drv := entsql.NewDriver(c.driver.Dialect(), entsql.Conn{ExecQuerier: tx})
+	cfg := c.config
+	cfg.driver = drv
+	if cfg.debug {
+		cfg.driver = dialect.Debug(drv, cfg.log)
+	}
+	client := &Client{config: cfg}
+	client.init()
+	return client
+}
+
 // Migrate 运行数据库迁移
 //
 // 设计原理：
@@ -0,0 +1,51 @@
+// Package sqldialect 提供手写 SQL 的方言差异
+//
+// 设计原理：
+// 1. 不经过 Ent 直接使用 database/sql 的存储（RBAC、ReBAC、发件箱）共用同一套方言定义
+// 2. 只处理这些存储用到的差异：参数占位符和行锁子句
+//
+// 各存储包通过类型别名导出 Dialect（如 rbac.DialectPostgres），调用方不需要引用本包
+package sqldialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect SQL 方言
+type Dialect int
+
+const (
+	// Postgres PostgreSQL 方言（$1 占位符，支持 FOR UPDATE SKIP LOCKED）
+	Postgres Dialect = iota
+
+	// SQLite SQLite 方言（? 占位符）
+	SQLite
+)
+
+// Placeholder 返回第 n 个（从 1 开始）参数占位符
+func (d Dialect) Placeholder(n int) string {
+	if d == Postgres {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// Placeholders 返回从 1 开始的 n 个占位符，用逗号分隔
+func (d Dialect) Placeholders(n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = d.Placeholder(i + 1)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LockClause 返回批量认领行时使用的行锁子句
+//
+// PostgreSQL 使用 SKIP LOCKED，允许多个实例并行处理不同的行；SQLite 的写事务本身是串行的，返回空字符串
+func (d Dialect) LockClause() string {
+	if d == Postgres {
+		return " FOR UPDATE SKIP LOCKED"
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package sqldialect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialect_Placeholders(t *testing.T) {
+	assert.Equal(t, "$3", Postgres.Placeholder(3))
+	assert.Equal(t, "$1, $2, $3", Postgres.Placeholders(3))
+	assert.Equal(t, "?", SQLite.Placeholder(3))
+	assert.Equal(t, "?, ?", SQLite.Placeholders(2))
+	assert.Equal(t, "", SQLite.Placeholders(0))
+}
+
+func TestDialect_LockClause(t *testing.T) {
+	assert.Equal(t, " FOR UPDATE SKIP LOCKED", Postgres.LockClause())
+	assert.Empty(t, SQLite.LockClause())
+}
@@ -0,0 +1,264 @@
+// Package bridge 将进程内事件总线（pkg/eventbus）桥接到外部消息中间件。
+//
+// 设计原则：
+// 1. 解耦：事件总线只面向 Broker 接口，Kafka、NATS、MQTT 通过适配器接入
+// 2. 可插拔编解码：事件通过 Codec 编码为消息负载，内置 JSON 和 Protobuf 两种实现
+// 3. 可测试：MemoryBroker 提供内存实现，测试中无需启动真实的消息中间件
+// 4. 防回环：从中间件收到的事件会标记来源，不会被同一个 Bridge 再次转发
+//
+// 核心功能：
+// - Forward（出站）：订阅本地事件类型（支持通配符），编码后发送到中间件主题
+// - Receive（入站）：订阅中间件主题，解码后作为本地事件重新发布
+//
+// 示例：
+//
+//	producer, _ := kafka.NewProducer([]string{"localhost:9092"})
+//	broker := bridge.NewKafkaBroker(producer, []string{"localhost:9092"}, "user-service")
+//
+//	b := bridge.New(eventBus, broker, bridge.WithCodec(bridge.NewJSONCodec()))
+//	defer b.Close()
+//
+//	// 将所有 user.* 事件转发到 Kafka 主题 user-events
+//	b.Forward("user.*", "user-events")
+//
+//	// 将 Kafka 主题 order-events 中的消息作为本地事件发布
+//	b.Receive(ctx, "order-events")
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/golang/pkg/eventbus"
+)
+
+// MetadataKeyBridgeOrigin 事件来源元数据键
+// 从中间件接收的事件会携带接收它的 Bridge ID，用于防止回环转发
+const MetadataKeyBridgeOrigin = "bridge_origin"
+
+// HeaderContentType 消息头中的内容类型键
+const HeaderContentType = "content-type"
+
+// ErrBridgeClosed Bridge 已关闭
+var ErrBridgeClosed = errors.New("bridge is closed")
+
+// Message 中间件消息
+//
+// 字段说明：
+// - Topic: 主题（Kafka topic、NATS subject 或 MQTT topic）
+// - Key: 消息键，用于分区路由（仅 Kafka 使用）
+// - Headers: 消息头，不支持消息头的中间件会忽略
+// - Payload: 由 Codec 编码的事件
+type Message struct {
+	Topic   string
+	Key     string
+	Headers map[string]string
+	Payload []byte
+}
+
+// MessageHandler 中间件消息处理函数
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+// Broker 消息中间件适配器接口
+//
+// 实现要求：
+// - Publish 发送一条消息，发送失败时返回错误
+// - Subscribe 订阅主题，返回用于取消订阅的函数
+// - 所有方法必须是并发安全的
+type Broker interface {
+	// Publish 发送消息
+	Publish(ctx context.Context, msg *Message) error
+
+	// Subscribe 订阅主题
+	Subscribe(ctx context.Context, topic string, handler MessageHandler) (unsubscribe func() error, err error)
+}
+
+// Bridge 事件总线与消息中间件之间的桥接器
+type Bridge struct {
+	id     string
+	bus    *eventbus.EventBus
+	broker Broker
+	codec  Codec
+
+	subscriptionIDs []string
+	unsubscribers   []func() error
+	closed          bool
+	mu              sync.Mutex
+}
+
+// Option Bridge 配置选项
+type Option func(*Bridge)
+
+// WithCodec 设置编解码器，默认使用 JSONCodec
+func WithCodec(codec Codec) Option {
+	return func(b *Bridge) {
+		b.codec = codec
+	}
+}
+
+// WithID 设置 Bridge ID，默认随机生成
+//
+// ID 会写入入站事件的 MetadataKeyBridgeOrigin 元数据
+func WithID(id string) Option {
+	return func(b *Bridge) {
+		b.id = id
+	}
+}
+
+// New 创建 Bridge
+//
+// 参数：
+//   - bus: 本地事件总线
+//   - broker: 消息中间件适配器
+//   - opts: 可选配置
+func New(bus *eventbus.EventBus, broker Broker, opts ...Option) *Bridge {
+	b := &Bridge{
+		id:     uuid.NewString(),
+		bus:    bus,
+		broker: broker,
+		codec:  NewJSONCodec(),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// ID 返回 Bridge ID
+func (b *Bridge) ID() string {
+	return b.id
+}
+
+// Forward 将本地事件转发到中间件主题
+//
+// 参数：
+//   - eventType: 事件类型或通配符模式，如 "user.*"
+//   - topic: 目标主题，为空时使用事件类型作为主题
+//   - opts: 订阅选项，如重试策略；发送失败的事件按事件总线的重试和死信机制处理
+//
+// 消息键取自事件的 aggregate_id 元数据（eventbus.MetadataKeyAggregateID），
+// 保证同一聚合的事件进入同一个 Kafka 分区
+func (b *Bridge) Forward(eventType, topic string, opts ...eventbus.SubscribeOption) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBridgeClosed
+	}
+
+	handler := func(ctx context.Context, event eventbus.Event) error {
+		// 入站事件不再转发，防止回环
+		if origin := metadataString(event, MetadataKeyBridgeOrigin); origin == b.id {
+			return nil
+		}
+
+		payload, err := b.codec.Encode(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", event.Type(), err)
+		}
+
+		msgTopic := topic
+		if msgTopic == "" {
+			msgTopic = event.Type()
+		}
+
+		return b.broker.Publish(ctx, &Message{
+			Topic:   msgTopic,
+			Key:     metadataString(event, eventbus.MetadataKeyAggregateID),
+			Headers: map[string]string{HeaderContentType: b.codec.ContentType()},
+			Payload: payload,
+		})
+	}
+
+	subID, err := b.bus.Subscribe(eventType, handler, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
+	}
+	b.subscriptionIDs = append(b.subscriptionIDs, subID)
+	return nil
+}
+
+// Receive 订阅中间件主题，将收到的消息作为本地事件发布
+//
+// 参数：
+//   - ctx: 上下文，用于建立订阅
+//   - topic: 中间件主题
+//
+// 注意事项：
+// - 事件类型、时间戳和元数据从消息负载中还原
+// - 无法解码的消息会返回错误给中间件适配器处理
+func (b *Bridge) Receive(ctx context.Context, topic string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBridgeClosed
+	}
+
+	handler := func(ctx context.Context, msg *Message) error {
+		event, err := b.codec.Decode(msg.Payload)
+		if err != nil {
+			return fmt.Errorf("failed to decode message from %s: %w", msg.Topic, err)
+		}
+		event.SetMetadata(MetadataKeyBridgeOrigin, b.id)
+
+		return b.bus.Publish(event)
+	}
+
+	unsubscribe, err := b.broker.Subscribe(ctx, topic, handler)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to topic %s: %w", topic, err)
+	}
+	b.unsubscribers = append(b.unsubscribers, unsubscribe)
+	return nil
+}
+
+// Close 取消所有本地订阅和中间件订阅
+//
+// 不会关闭事件总线和中间件客户端，它们的生命周期由调用方管理
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	var errs []error
+	for _, subID := range b.subscriptionIDs {
+		if err := b.bus.Unsubscribe(subID); err != nil && !errors.Is(err, eventbus.ErrSubscriptionNotFound) {
+			errs = append(errs, err)
+		}
+	}
+	for _, unsubscribe := range b.unsubscribers {
+		if err := unsubscribe(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	b.subscriptionIDs = nil
+	b.unsubscribers = nil
+	return errors.Join(errs...)
+}
+
+// metadataString 读取字符串形式的事件元数据
+func metadataString(event eventbus.Event, key string) string {
+	carrier, ok := event.(interface {
+		GetMetadata(key string) (interface{}, bool)
+	})
+	if !ok {
+		return ""
+	}
+	value, ok := carrier.GetMetadata(key)
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,192 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/golang/internal/infra/messaging/kafka"
+	"github.com/yourusername/golang/pkg/eventbus"
+)
+
+// collect 订阅事件并收集收到的事件
+func collect(t *testing.T, bus *eventbus.EventBus, eventType string) func() []eventbus.Event {
+	var mu sync.Mutex
+	var events []eventbus.Event
+	_, err := bus.Subscribe(eventType, func(ctx context.Context, event eventbus.Event) error {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		return nil
+	})
+	require.NoError(t, err)
+
+	return func() []eventbus.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]eventbus.Event(nil), events...)
+	}
+}
+
+// TestBridge_ForwardAndReceive 测试两个服务通过中间件交换事件
+func TestBridge_ForwardAndReceive(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	producerBus := eventbus.NewEventBus(10)
+	require.NoError(t, producerBus.Start())
+	defer producerBus.Stop()
+
+	consumerBus := eventbus.NewEventBus(10)
+	require.NoError(t, consumerBus.Start())
+	defer consumerBus.Stop()
+
+	outbound := New(producerBus, broker)
+	defer outbound.Close()
+	require.NoError(t, outbound.Forward("user.*", "user-events"))
+
+	inbound := New(consumerBus, broker)
+	defer inbound.Close()
+	require.NoError(t, inbound.Receive(context.Background(), "user-events"))
+
+	received := collect(t, consumerBus, "user.created")
+
+	event := eventbus.NewEvent("user.created", map[string]string{"email": "a@example.com"})
+	event.SetMetadata(eventbus.MetadataKeyAggregateID, "user-1")
+	require.NoError(t, producerBus.Publish(event))
+
+	assert.Eventually(t, func() bool { return len(received()) == 1 }, time.Second, 10*time.Millisecond)
+
+	msgs := broker.Messages("user-events")
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "user-1", msgs[0].Key)
+	assert.Equal(t, "application/json", msgs[0].Headers[HeaderContentType])
+
+	got := received()[0].(*eventbus.BaseEvent)
+	assert.Equal(t, event.Timestamp().UnixNano(), got.Timestamp().UnixNano())
+	origin, _ := got.GetMetadata(MetadataKeyBridgeOrigin)
+	assert.Equal(t, inbound.ID(), origin)
+
+	var data map[string]string
+	require.NoError(t, json.Unmarshal(got.Data().(json.RawMessage), &data))
+	assert.Equal(t, "a@example.com", data["email"])
+}
+
+// TestBridge_NoLoop 测试同一个 Bridge 不会转发自己接收的事件
+func TestBridge_NoLoop(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	bus := eventbus.NewEventBus(10)
+	require.NoError(t, bus.Start())
+	defer bus.Stop()
+
+	b := New(bus, broker)
+	defer b.Close()
+	require.NoError(t, b.Forward("order.>", "orders"))
+	require.NoError(t, b.Receive(context.Background(), "orders"))
+
+	received := collect(t, bus, "order.created")
+
+	require.NoError(t, bus.Publish(eventbus.NewEvent("order.created", "order-1")))
+
+	assert.Eventually(t, func() bool { return len(received()) == 2 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	// 本地事件一次 + 回流事件一次，回流事件不会再次转发
+	assert.Len(t, received(), 2)
+	assert.Len(t, broker.Messages("orders"), 1)
+}
+
+// TestBridge_Close 测试关闭后不再转发
+func TestBridge_Close(t *testing.T) {
+	broker := NewMemoryBroker()
+	bus := eventbus.NewEventBus(10)
+	require.NoError(t, bus.Start())
+	defer bus.Stop()
+
+	b := New(bus, broker)
+	require.NoError(t, b.Forward("user.created", ""))
+	require.NoError(t, b.Close())
+
+	require.NoError(t, bus.Publish(eventbus.NewEvent("user.created", nil)))
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Empty(t, broker.Messages("user.created"))
+	assert.ErrorIs(t, b.Forward("user.created", ""), ErrBridgeClosed)
+}
+
+// fakeKafkaProducer 记录发送的消息
+type fakeKafkaProducer struct {
+	msg kafka.Message
+}
+
+func (p *fakeKafkaProducer) Send(ctx context.Context, msg kafka.Message) error {
+	p.msg = msg
+	return nil
+}
+
+// fakeKafkaConsumer 在 Consume 时投递一条消息
+type fakeKafkaConsumer struct {
+	handler kafka.MessageHandler
+	closed  bool
+}
+
+func (c *fakeKafkaConsumer) Consume(ctx context.Context, topics []string) error {
+	if err := c.handler(ctx, "key-1", []byte("payload")); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (c *fakeKafkaConsumer) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestKafkaBroker 测试 Kafka 适配器
+func TestKafkaBroker(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	consumer := &fakeKafkaConsumer{}
+	broker := &KafkaBroker{
+		producer: producer,
+		newConsumer: func(handler kafka.MessageHandler) (kafkaConsumer, error) {
+			consumer.handler = handler
+			return consumer, nil
+		},
+	}
+
+	err := broker.Publish(context.Background(), &Message{
+		Topic:   "events",
+		Key:     "k",
+		Headers: map[string]string{HeaderContentType: "application/json"},
+		Payload: []byte("v"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "events", producer.msg.Topic)
+	assert.Equal(t, "k", producer.msg.Key)
+	assert.Equal(t, []byte("v"), producer.msg.Value)
+	assert.Equal(t, "application/json", producer.msg.Headers[HeaderContentType])
+
+	received := make(chan *Message, 1)
+	unsubscribe, err := broker.Subscribe(context.Background(), "events", func(ctx context.Context, msg *Message) error {
+		received <- msg
+		return nil
+	})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "events", msg.Topic)
+		assert.Equal(t, "key-1", msg.Key)
+	case <-time.After(time.Second):
+		t.Fatal("Expected message from consumer")
+	}
+
+	require.NoError(t, unsubscribe())
+	assert.True(t, consumer.closed)
+}
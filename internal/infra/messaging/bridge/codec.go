@@ -0,0 +1,299 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/yourusername/golang/pkg/eventbus"
+)
+
+// ErrUnsupportedData 事件数据类型不受编解码器支持
+var ErrUnsupportedData = errors.New("unsupported event data type")
+
+// Codec 事件编解码器接口
+//
+// 编码结果包含事件类型、时间戳、元数据和数据，解码后可以完整还原事件
+type Codec interface {
+	// Encode 将事件编码为消息负载
+	Encode(event eventbus.Event) ([]byte, error)
+
+	// Decode 将消息负载解码为事件
+	Decode(payload []byte) (*eventbus.BaseEvent, error)
+
+	// ContentType 返回编码格式，写入消息头 content-type
+	ContentType() string
+}
+
+// JSONCodec JSON 编解码器
+//
+// 编码格式：
+//
+//	{"type": "user.created", "timestamp": "...", "metadata": {...}, "data": {...}}
+//
+// 注意事项：
+// - 解码后事件的 Data() 为 json.RawMessage，需要处理器自行解码为具体类型
+type JSONCodec struct{}
+
+// jsonEnvelope JSON 消息信封
+type jsonEnvelope struct {
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Data      json.RawMessage        `json:"data,omitempty"`
+}
+
+// NewJSONCodec 创建 JSON 编解码器
+func NewJSONCodec() *JSONCodec {
+	return &JSONCodec{}
+}
+
+// Encode 将事件编码为 JSON
+func (c *JSONCodec) Encode(event eventbus.Event) ([]byte, error) {
+	data, err := json.Marshal(event.Data())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	return json.Marshal(jsonEnvelope{
+		Type:      event.Type(),
+		Timestamp: event.Timestamp(),
+		Metadata:  eventMetadata(event),
+		Data:      data,
+	})
+}
+
+// Decode 将 JSON 解码为事件
+func (c *JSONCodec) Decode(payload []byte) (*eventbus.BaseEvent, error) {
+	var envelope jsonEnvelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+	}
+	if envelope.Type == "" {
+		return nil, errors.New("event type is missing")
+	}
+
+	event := eventbus.NewEventAt(envelope.Type, envelope.Data, envelope.Timestamp)
+	for k, v := range envelope.Metadata {
+		event.SetMetadata(k, v)
+	}
+	return event, nil
+}
+
+// ContentType 返回 application/json
+func (c *JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+// Protobuf 信封字段编号
+const (
+	protoFieldType      protowire.Number = 1
+	protoFieldTimestamp protowire.Number = 2
+	protoFieldMetadata  protowire.Number = 3
+	protoFieldData      protowire.Number = 4
+
+	protoFieldMapKey   protowire.Number = 1
+	protoFieldMapValue protowire.Number = 2
+)
+
+// ProtobufCodec Protobuf 编解码器
+//
+// 信封格式（等价的 proto 定义）：
+//
+//	message Envelope {
+//	    string type = 1;
+//	    int64 timestamp_unix_nano = 2;
+//	    map<string, string> metadata = 3;
+//	    bytes data = 4;
+//	}
+//
+// 数据要求：
+// - 事件数据必须是 proto.Message 或 []byte（nil 表示没有数据）
+// - 元数据值以 fmt.Sprint 的字符串形式传输
+// - 通过 Register 注册的事件类型解码为对应的 proto.Message，否则 Data() 为 []byte
+type ProtobufCodec struct {
+	types map[string]proto.Message
+	mu    sync.RWMutex
+}
+
+// NewProtobufCodec 创建 Protobuf 编解码器
+func NewProtobufCodec() *ProtobufCodec {
+	return &ProtobufCodec{types: make(map[string]proto.Message)}
+}
+
+// Register 注册事件类型对应的消息类型
+//
+// 示例：
+//
+//	codec := bridge.NewProtobufCodec()
+//	codec.Register("user.created", &userpb.User{})
+func (c *ProtobufCodec) Register(eventType string, prototype proto.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.types[eventType] = prototype
+}
+
+// Encode 将事件编码为 Protobuf
+func (c *ProtobufCodec) Encode(event eventbus.Event) ([]byte, error) {
+	var data []byte
+	switch v := event.Data().(type) {
+	case nil:
+	case proto.Message:
+		encoded, err := proto.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal event data: %w", err)
+		}
+		data = encoded
+	case []byte:
+		data = v
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedData, v)
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, protoFieldType, protowire.BytesType)
+	b = protowire.AppendString(b, event.Type())
+	b = protowire.AppendTag(b, protoFieldTimestamp, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(event.Timestamp().UnixNano()))
+
+	for k, v := range eventMetadata(event) {
+		var entry []byte
+		entry = protowire.AppendTag(entry, protoFieldMapKey, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, protoFieldMapValue, protowire.BytesType)
+		entry = protowire.AppendString(entry, fmt.Sprint(v))
+
+		b = protowire.AppendTag(b, protoFieldMetadata, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+
+	if len(data) > 0 {
+		b = protowire.AppendTag(b, protoFieldData, protowire.BytesType)
+		b = protowire.AppendBytes(b, data)
+	}
+	return b, nil
+}
+
+// Decode 将 Protobuf 解码为事件
+func (c *ProtobufCodec) Decode(payload []byte) (*eventbus.BaseEvent, error) {
+	var (
+		eventType string
+		timestamp int64
+		data      []byte
+		metadata  = make(map[string]string)
+	)
+
+	for len(payload) > 0 {
+		num, typ, n := protowire.ConsumeTag(payload)
+		if n < 0 {
+			return nil, fmt.Errorf("failed to decode envelope: %w", protowire.ParseError(n))
+		}
+		payload = payload[n:]
+
+		switch {
+		case num == protoFieldType && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(payload)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to decode event type: %w", protowire.ParseError(n))
+			}
+			eventType, payload = v, payload[n:]
+		case num == protoFieldTimestamp && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(payload)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to decode timestamp: %w", protowire.ParseError(n))
+			}
+			timestamp, payload = int64(v), payload[n:]
+		case num == protoFieldMetadata && typ == protowire.BytesType:
+			entry, n := protowire.ConsumeBytes(payload)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to decode metadata: %w", protowire.ParseError(n))
+			}
+			key, value, err := decodeMapEntry(entry)
+			if err != nil {
+				return nil, err
+			}
+			metadata[key], payload = value, payload[n:]
+		case num == protoFieldData && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(payload)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to decode data: %w", protowire.ParseError(n))
+			}
+			data, payload = append([]byte(nil), v...), payload[n:]
+		default:
+			// 跳过未知字段，保持向前兼容
+			n := protowire.ConsumeFieldValue(num, typ, payload)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to skip field %d: %w", num, protowire.ParseError(n))
+			}
+			payload = payload[n:]
+		}
+	}
+
+	if eventType == "" {
+		return nil, errors.New("event type is missing")
+	}
+
+	var value interface{} = data
+	c.mu.RLock()
+	prototype, registered := c.types[eventType]
+	c.mu.RUnlock()
+	if registered {
+		msg := prototype.ProtoReflect().New().Interface()
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s data: %w", eventType, err)
+		}
+		value = msg
+	}
+
+	event := eventbus.NewEventAt(eventType, value, time.Unix(0, timestamp))
+	for k, v := range metadata {
+		event.SetMetadata(k, v)
+	}
+	return event, nil
+}
+
+// ContentType 返回 application/x-protobuf
+func (c *ProtobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// decodeMapEntry 解码 map<string, string> 条目
+func decodeMapEntry(entry []byte) (string, string, error) {
+	var key, value string
+	for len(entry) > 0 {
+		num, typ, n := protowire.ConsumeTag(entry)
+		if n < 0 || typ != protowire.BytesType {
+			return "", "", errors.New("failed to decode metadata entry")
+		}
+		entry = entry[n:]
+
+		v, n := protowire.ConsumeString(entry)
+		if n < 0 {
+			return "", "", errors.New("failed to decode metadata entry")
+		}
+		entry = entry[n:]
+
+		switch num {
+		case protoFieldMapKey:
+			key = v
+		case protoFieldMapValue:
+			value = v
+		}
+	}
+	return key, value, nil
+}
+
+// eventMetadata 提取事件元数据
+func eventMetadata(event eventbus.Event) map[string]interface{} {
+	if carrier, ok := event.(interface {
+		Metadata() map[string]interface{}
+	}); ok {
+		return carrier.Metadata()
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package bridge
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/yourusername/golang/pkg/eventbus"
+)
+
+// TestJSONCodec_RoundTrip 测试 JSON 编解码
+func TestJSONCodec_RoundTrip(t *testing.T) {
+	codec := NewJSONCodec()
+
+	event := eventbus.NewEventAt("user.created", map[string]int{"id": 7}, time.Unix(1700000000, 123))
+	event.SetMetadata("request_id", "req-1")
+
+	payload, err := codec.Encode(event)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "user.created", decoded.Type())
+	assert.True(t, event.Timestamp().Equal(decoded.Timestamp()))
+	assert.JSONEq(t, `{"id":7}`, string(decoded.Data().(json.RawMessage)))
+
+	requestID, _ := decoded.GetMetadata("request_id")
+	assert.Equal(t, "req-1", requestID)
+
+	_, err = codec.Decode([]byte(`{"data":{}}`))
+	assert.Error(t, err)
+}
+
+// TestProtobufCodec_RoundTrip 测试 Protobuf 编解码
+func TestProtobufCodec_RoundTrip(t *testing.T) {
+	codec := NewProtobufCodec()
+	codec.Register("user.renamed", &wrapperspb.StringValue{})
+
+	event := eventbus.NewEventAt("user.renamed", wrapperspb.String("Alice"), time.Unix(1700000000, 456))
+	event.SetMetadata(eventbus.MetadataKeyAggregateID, "user-1")
+
+	payload, err := codec.Encode(event)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "user.renamed", decoded.Type())
+	assert.Equal(t, event.Timestamp().UnixNano(), decoded.Timestamp().UnixNano())
+	assert.True(t, proto.Equal(wrapperspb.String("Alice"), decoded.Data().(proto.Message)))
+
+	aggregateID, _ := decoded.GetMetadata(eventbus.MetadataKeyAggregateID)
+	assert.Equal(t, "user-1", aggregateID)
+}
+
+// TestProtobufCodec_Unregistered 测试未注册类型解码为原始字节
+func TestProtobufCodec_Unregistered(t *testing.T) {
+	codec := NewProtobufCodec()
+
+	payload, err := codec.Encode(eventbus.NewEvent("raw.event", []byte{1, 2, 3}))
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(payload)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, decoded.Data())
+}
+
+// TestProtobufCodec_UnsupportedData 测试不支持的数据类型
+func TestProtobufCodec_UnsupportedData(t *testing.T) {
+	_, err := NewProtobufCodec().Encode(eventbus.NewEvent("user.created", map[string]string{}))
+	assert.ErrorIs(t, err, ErrUnsupportedData)
+}
@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/yourusername/golang/internal/infra/messaging/kafka"
+)
+
+// kafkaPublisher Kafka 发送接口，便于测试替换
+type kafkaPublisher interface {
+	Send(ctx context.Context, msg kafka.Message) error
+}
+
+// kafkaConsumer Kafka 消费接口，便于测试替换
+type kafkaConsumer interface {
+	Consume(ctx context.Context, topics []string) error
+	Close() error
+}
+
+// KafkaBroker Kafka 适配器
+//
+// 设计说明：
+// - 出站：通过 kafka.Producer 发送，消息键用于分区路由，Message.Headers 作为消息头发送并注入 ctx 中的追踪上下文
+// - 入站：每个订阅创建一个消费者组成员，在后台 goroutine 中消费；追踪上下文由消费者提取到 ctx 中
+type KafkaBroker struct {
+	producer    kafkaPublisher
+	newConsumer func(handler kafka.MessageHandler) (kafkaConsumer, error)
+}
+
+// NewKafkaBroker 创建 Kafka 适配器
+//
+// 参数：
+//   - producer: Kafka 生产者
+//   - brokers: Kafka Broker 地址列表，用于创建入站消费者
+//   - groupID: 入站消费者组 ID
+func NewKafkaBroker(producer *kafka.Producer, brokers []string, groupID string) *KafkaBroker {
+	return &KafkaBroker{
+		producer: producer,
+		newConsumer: func(handler kafka.MessageHandler) (kafkaConsumer, error) {
+			return kafka.NewConsumer(brokers, groupID, handler)
+		},
+	}
+}
+
+// Publish 发送消息到 Kafka 主题
+func (b *KafkaBroker) Publish(ctx context.Context, msg *Message) error {
+	return b.producer.Send(ctx, kafka.Message{
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Value:   msg.Payload,
+		Headers: msg.Headers,
+	})
+}
+
+// Subscribe 订阅 Kafka 主题
+//
+// 处理函数返回错误时，消费者会离开消费者组并触发重平衡，消息会被重新投递
+func (b *KafkaBroker) Subscribe(ctx context.Context, topic string, handler MessageHandler) (func() error, error) {
+	consumer, err := b.newConsumer(func(ctx context.Context, key string, value []byte) error {
+		return handler(ctx, &Message{Topic: topic, Key: key, Payload: value})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	consumeCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := consumer.Consume(consumeCtx, []string{topic}); err != nil && consumeCtx.Err() == nil {
+			slog.Error("Kafka bridge consumer stopped", "topic", topic, "error", err)
+		}
+	}()
+
+	return func() error {
+		cancel()
+		err := consumer.Close()
+		<-done
+		return err
+	}, nil
+}
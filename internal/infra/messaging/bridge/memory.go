@@ -0,0 +1,86 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// MemoryBroker 内存消息中间件
+//
+// 设计原理：
+// 1. 按主题精确匹配，Publish 在调用方 goroutine 中同步调用所有订阅者
+// 2. 记录所有已发送的消息，便于测试断言
+// 3. 可以被多个 Bridge 共享，模拟多个服务实例通过同一个中间件通信
+//
+// 使用场景：
+// - 单元测试中替代 Kafka、NATS、MQTT
+type MemoryBroker struct {
+	handlers  map[string]map[int]MessageHandler
+	published []Message
+	nextID    int
+	mu        sync.RWMutex
+}
+
+// NewMemoryBroker 创建内存消息中间件
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		handlers: make(map[string]map[int]MessageHandler),
+	}
+}
+
+// Publish 发送消息并同步调用订阅者
+//
+// 返回所有订阅者错误的合并结果
+func (b *MemoryBroker) Publish(ctx context.Context, msg *Message) error {
+	b.mu.Lock()
+	b.published = append(b.published, *msg)
+	handlers := make([]MessageHandler, 0, len(b.handlers[msg.Topic]))
+	for _, handler := range b.handlers[msg.Topic] {
+		handlers = append(handlers, handler)
+	}
+	b.mu.Unlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		copied := *msg
+		if err := handler(ctx, &copied); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Subscribe 订阅主题
+func (b *MemoryBroker) Subscribe(ctx context.Context, topic string, handler MessageHandler) (func() error, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	if b.handlers[topic] == nil {
+		b.handlers[topic] = make(map[int]MessageHandler)
+	}
+	b.handlers[topic][id] = handler
+
+	return func() error {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers[topic], id)
+		return nil
+	}, nil
+}
+
+// Messages 返回发送到指定主题的所有消息
+func (b *MemoryBroker) Messages(topic string) []Message {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var result []Message
+	for _, msg := range b.published {
+		if msg.Topic == topic {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
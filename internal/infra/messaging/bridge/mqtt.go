@@ -0,0 +1,52 @@
+package bridge
+
+import (
+	"context"
+
+	"github.com/yourusername/golang/internal/infra/messaging/mqtt"
+)
+
+// mqttClient MQTT 客户端接口，便于测试替换
+type mqttClient interface {
+	Publish(ctx context.Context, topic string, qos byte, retained bool, payload interface{}) error
+	Subscribe(ctx context.Context, topic string, qos byte, handler mqtt.MessageHandler) error
+	Unsubscribe(ctx context.Context, topics ...string) error
+}
+
+// MQTTBroker MQTT 适配器
+//
+// 设计说明：
+// - 主题对应 MQTT topic，注意 MQTT 使用 "/" 作为层级分隔符
+// - 所有消息使用相同的 QoS 发送和订阅，不使用保留消息
+type MQTTBroker struct {
+	client mqttClient
+	qos    byte
+}
+
+// NewMQTTBroker 创建 MQTT 适配器
+//
+// 参数：
+//   - client: MQTT 客户端
+//   - qos: 发布和订阅使用的 QoS 等级（0、1、2）
+func NewMQTTBroker(client *mqtt.Client, qos byte) *MQTTBroker {
+	return &MQTTBroker{client: client, qos: qos}
+}
+
+// Publish 发送消息到 MQTT topic
+func (b *MQTTBroker) Publish(ctx context.Context, msg *Message) error {
+	return b.client.Publish(ctx, msg.Topic, b.qos, false, msg.Payload)
+}
+
+// Subscribe 订阅 MQTT topic
+func (b *MQTTBroker) Subscribe(ctx context.Context, topic string, handler MessageHandler) (func() error, error) {
+	err := b.client.Subscribe(ctx, topic, b.qos, func(ctx context.Context, msgTopic string, payload []byte) error {
+		return handler(ctx, &Message{Topic: msgTopic, Payload: payload})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return b.client.Unsubscribe(context.Background(), topic)
+	}, nil
+}
@@ -0,0 +1,50 @@
+package bridge
+
+import (
+	"context"
+	"log/slog"
+
+	natsgo "github.com/nats-io/nats.go"
+
+	"github.com/yourusername/golang/internal/infra/messaging/nats"
+)
+
+// natsClient NATS 客户端接口，便于测试替换
+type natsClient interface {
+	Publish(subject string, data interface{}) error
+	Subscribe(subject string, handler func(*natsgo.Msg)) (*natsgo.Subscription, error)
+}
+
+// NATSBroker NATS 适配器
+//
+// 设计说明：
+// - 主题对应 NATS subject，可以直接使用事件类型（如 "user.created"）
+// - 核心 NATS 不保证投递，处理失败的消息只记录日志
+type NATSBroker struct {
+	client natsClient
+}
+
+// NewNATSBroker 创建 NATS 适配器
+func NewNATSBroker(client *nats.Client) *NATSBroker {
+	return &NATSBroker{client: client}
+}
+
+// Publish 发送消息到 NATS subject
+func (b *NATSBroker) Publish(ctx context.Context, msg *Message) error {
+	return b.client.Publish(msg.Topic, msg.Payload)
+}
+
+// Subscribe 订阅 NATS subject
+func (b *NATSBroker) Subscribe(ctx context.Context, topic string, handler MessageHandler) (func() error, error) {
+	sub, err := b.client.Subscribe(topic, func(m *natsgo.Msg) {
+		msg := &Message{Topic: m.Subject, Payload: m.Data}
+		if err := handler(context.Background(), msg); err != nil {
+			slog.Warn("NATS bridge failed to handle message", "subject", m.Subject, "error", err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.Unsubscribe, nil
+}
@@ -3,6 +3,9 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/IBM/sarama"
 )
@@ -70,6 +73,10 @@ type MessageHandler func(ctx context.Context, key string, value []byte) error
 type Consumer struct {
 	consumer sarama.ConsumerGroup
 	handler  MessageHandler
+	settings consumerSettings
+
+	// ownsProducer 失败转发生产者是否由 NewConsumer 创建（Close 时一并关闭）
+	ownsProducer bool
 }
 
 // NewConsumer 创建并初始化 Kafka 消费者。
@@ -87,6 +94,7 @@ type Consumer struct {
 //   用于负载均衡和消息分发
 // - handler: 消息处理函数
 //   当收到消息时，会调用此函数处理消息
+// - opts: 可选配置，如提交策略、重试主题、死信主题、延迟和重平衡回调
 //
 // 返回：
 // - *Consumer: 配置好的消费者实例
@@ -100,6 +108,12 @@ type Consumer struct {
 // - Offsets.Initial: 初始偏移量
 //   - OffsetNewest: 从最新消息开始消费（默认）
 //   - OffsetOldest: 从最早消息开始消费
+// - 提交策略: 默认每秒自动提交已标记的偏移量，见 WithCommitAfterSuccess、WithBatchCommit
+//
+// 失败处理：
+// - 未配置重试或死信主题：处理函数返回错误时不标记消息，结束当前分区的消费，消息会被重新投递
+// - 配置了重试主题：失败的消息转发到下一个重试主题，延迟到期后重新处理
+// - 配置了死信主题：重试耗尽后转发到死信主题，消息头包含错误信息
 //
 // 使用示例：
 //
@@ -114,18 +128,35 @@ type Consumer struct {
 //	    handler,
 //	)
 //
+//	// 批量提交，失败消息经过两级重试后进入死信主题
+//	consumer, err = kafka.NewConsumer(brokers, "order-service", handler,
+//	    kafka.WithBatchCommit(50),
+//	    kafka.WithRetryTopics(
+//	        kafka.RetryTopic{Topic: "orders.retry.10s", Delay: 10 * time.Second},
+//	        kafka.RetryTopic{Topic: "orders.retry.5m", Delay: 5 * time.Minute},
+//	    ),
+//	    kafka.WithDeadLetterTopic("orders.dlq"),
+//	    kafka.WithLagHandler(func(topic string, partition int32, lag int64) {
+//	        consumerLag.WithLabelValues(topic, strconv.Itoa(int(partition))).Set(float64(lag))
+//	    }),
+//	)
+//
 // 注意事项：
 // - 确保 Kafka 集群已启动并可访问
 // - 消费者组 ID 应具有业务意义，便于管理
 // - 多个消费者实例使用相同的 groupID 可以实现负载均衡
 // - 应在应用程序生命周期中复用消费者实例
-func NewConsumer(brokers []string, groupID string, handler MessageHandler) (*Consumer, error) {
+func NewConsumer(brokers []string, groupID string, handler MessageHandler, opts ...ConsumerOption) (*Consumer, error) {
+	var settings consumerSettings
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
 	config := sarama.NewConfig()
 	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRoundRobin() // 轮询分配策略
 	config.Consumer.Offsets.Initial = sarama.OffsetNewest                            // 从最新消息开始
+	settings.apply(config)                                                           // 偏移量提交策略
 	// 其他可选配置：
-	// config.Consumer.Offsets.AutoCommit.Enable = true  // 自动提交偏移量
-	// config.Consumer.Offsets.AutoCommit.Interval = 1 * time.Second
 	// config.Consumer.MaxProcessingTime = 30 * time.Second
 
 	consumer, err := sarama.NewConsumerGroup(brokers, groupID, config)
@@ -133,10 +164,28 @@ func NewConsumer(brokers []string, groupID string, handler MessageHandler) (*Con
 		return nil, fmt.Errorf("failed to create consumer: %w", err)
 	}
 
-	return &Consumer{
+	c := &Consumer{
 		consumer: consumer,
 		handler:  handler,
-	}, nil
+		settings: settings,
+	}
+
+	// 转发重试和死信消息需要生产者
+	if settings.routesFailures() && settings.producer == nil {
+		producerConfig := sarama.NewConfig()
+		producerConfig.Producer.Return.Successes = true
+		producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+
+		producer, err := sarama.NewSyncProducer(brokers, producerConfig)
+		if err != nil {
+			_ = consumer.Close()
+			return nil, fmt.Errorf("failed to create failure producer: %w", err)
+		}
+		c.settings.producer = producer
+		c.ownsProducer = true
+	}
+
+	return c, nil
 }
 
 // Consume 开始消费指定主题的消息。
@@ -179,10 +228,19 @@ func NewConsumer(brokers []string, groupID string, handler MessageHandler) (*Con
 // 注意事项：
 // - 方法会阻塞，应在单独的 goroutine 中运行
 // - 使用上下文控制消费的开始和停止
-// - 如果处理函数返回错误，消息处理会失败
+// - 如果处理函数返回错误，消息处理会失败（配置了重试或死信主题时转发后继续消费）
 // - 消费者组会自动管理分区分配和重平衡
+// - 配置了重试主题时会自动订阅所有重试主题
 func (c *Consumer) Consume(ctx context.Context, topics []string) error {
-	handler := &consumerGroupHandler{handler: c.handler}
+	handler := &consumerGroupHandler{handler: c.handler, settings: c.settings}
+
+	// 同时订阅重试主题
+	topics = append([]string(nil), topics...)
+	for _, retry := range c.settings.retryTopics {
+		if !slices.Contains(topics, retry.Topic) {
+			topics = append(topics, retry.Topic)
+		}
+	}
 
 	// 持续消费消息，直到上下文取消
 	for {
@@ -218,9 +276,16 @@ func (c *Consumer) Consume(ctx context.Context, topics []string) error {
 // - 关闭后不应再使用该消费者
 // - 关闭会触发重平衡，其他消费者会接管分区
 func (c *Consumer) Close() error {
-	return c.consumer.Close()
+	err := c.consumer.Close()
+	if c.ownsProducer {
+		if perr := c.settings.producer.Close(); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return err
 }
 
+
 // consumerGroupHandler 是消费者组处理器的实现。
 //
 // 功能说明：
@@ -233,36 +298,43 @@ func (c *Consumer) Close() error {
 // 2. ConsumeClaim: 处理分配的分区中的消息
 // 3. Cleanup: 消费者离开消费者组时调用
 type consumerGroupHandler struct {
-	handler MessageHandler
+	handler  MessageHandler
+	settings consumerSettings
 }
 
 // Setup 在消费者加入消费者组时调用。
 //
 // 功能说明：
 // - 消费者成功加入消费者组后调用
-// - 可以在此处进行初始化操作
+// - 配置了 WithRebalanceHandlers 时，以本次分配到的分区调用 onAssigned
 //
 // 参数：
 // - session: 消费者组会话
 //
 // 返回：
 // - error: 如果设置失败，返回错误信息
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	if h.settings.onAssigned != nil && session != nil {
+		h.settings.onAssigned(session.Claims())
+	}
 	return nil
 }
 
 // Cleanup 在消费者离开消费者组时调用。
 //
 // 功能说明：
-// - 消费者离开消费者组前调用
-// - 可以在此处进行清理操作
+// - 消费者离开消费者组前调用（所有 ConsumeClaim 已经返回）
+// - 配置了 WithRebalanceHandlers 时，以被回收的分区调用 onRevoked
 //
 // 参数：
 // - session: 消费者组会话
 //
 // 返回：
 // - error: 如果清理失败，返回错误信息
-func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+func (h *consumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	if h.settings.onRevoked != nil && session != nil {
+		h.settings.onRevoked(session.Claims())
+	}
 	return nil
 }
 
@@ -271,7 +343,7 @@ func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
 // 功能说明：
 // - 从分配的分区接收消息
 // - 调用消息处理函数处理消息
-// - 标记消息已处理（提交偏移量）
+// - 标记消息已处理，并按提交策略提交偏移量
 //
 // 参数：
 // - session: 消费者组会话，用于标记消息和提交偏移量
@@ -282,39 +354,205 @@ func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
 //   返回错误会导致消费者离开消费者组并触发重平衡
 //
 // 工作流程：
-// 1. 从分区声明中接收消息
+// 1. 从分区声明中接收消息，重试主题中的消息等待延迟到期
 // 2. 调用消息处理函数处理消息
-// 3. 如果处理成功，标记消息已处理
-// 4. 如果处理失败，返回错误（可能导致重试）
+// 3. 如果处理成功，标记消息已处理并按提交策略提交
+// 4. 如果处理失败且配置了重试或死信主题，转发消息后标记；否则返回错误（可能导致重试）
 //
 // 注意事项：
 // - 消息处理应该是幂等的（可以安全地重复处理）
-// - 处理函数返回错误会导致消息处理失败
-// - 标记消息后，偏移量会在下次提交时更新
+// - 批量提交策略下，分区回收或会话结束时提交剩余偏移量
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	pending := 0
+
 	for {
 		select {
-		case <-session.Context().Done():
-			// 会话已取消，停止处理
+		case <-ctx.Done():
+			// 会话已取消，提交剩余偏移量后停止处理
+			h.flush(session, pending)
 			return nil
-		case message := <-claim.Messages():
-			// 接收消息
-			if message == nil {
+		case message, ok := <-claim.Messages():
+			if !ok {
 				// 分区已关闭，退出
+				h.flush(session, pending)
+				return nil
+			}
+			if message == nil {
 				continue
 			}
 
-			// 处理消息
-			key := string(message.Key)
-			if err := h.handler(session.Context(), key, message.Value); err != nil {
-				// 处理失败，返回错误
+			if err := h.process(ctx, message); err != nil {
+				// 处理失败且无法转发，返回错误
 				// 这会导致消费者离开消费者组并触发重平衡
+				h.flush(session, pending)
 				return err
 			}
 
 			// 标记消息已处理
-			// 偏移量会在下次自动提交时更新
 			session.MarkMessage(message, "")
+			pending++
+			if h.shouldCommit(pending) {
+				session.Commit()
+				pending = 0
+			}
+
+			if h.settings.onLag != nil {
+				lag := claim.HighWaterMarkOffset() - message.Offset - 1
+				if lag < 0 {
+					lag = 0
+				}
+				h.settings.onLag(message.Topic, message.Partition, lag)
+			}
 		}
 	}
 }
+
+// process 处理单条消息
+//
+// 返回 nil 表示消息可以标记（处理成功，或失败后已转发到重试/死信主题）
+func (h *consumerGroupHandler) process(ctx context.Context, message *sarama.ConsumerMessage) error {
+	// 重试主题中的消息等待延迟到期
+	if notBefore, ok := headerInt(message, HeaderRetryNotBefore); ok {
+		wait := time.UnixMilli(notBefore).Sub(h.now())
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+	}
+
+	// 继续生产者注入的追踪链路
+	err := h.handler(ExtractTraceContext(ctx, message.Headers), string(message.Key), message.Value)
+	if err == nil || !h.settings.routesFailures() {
+		return err
+	}
+	return h.forward(message, err)
+}
+
+// forward 将失败的消息转发到下一个重试主题，重试耗尽后转发到死信主题
+func (h *consumerGroupHandler) forward(message *sarama.ConsumerMessage, cause error) error {
+	attempt := 0
+	if n, ok := headerInt(message, HeaderRetryAttempt); ok {
+		attempt = int(n)
+	}
+
+	now := h.now()
+	var topic string
+	var notBefore time.Time
+	if attempt < len(h.settings.retryTopics) {
+		retry := h.settings.retryTopics[attempt]
+		topic = retry.Topic
+		notBefore = now.Add(retry.Delay)
+	} else if h.settings.deadLetterTopic != "" {
+		topic = h.settings.deadLetterTopic
+	} else {
+		// 重试耗尽且未配置死信主题
+		return cause
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+7)
+	for _, header := range message.Headers {
+		if header == nil {
+			continue
+		}
+		switch string(header.Key) {
+		case HeaderRetryAttempt, HeaderRetryNotBefore, HeaderError, HeaderFailedAt:
+			// 由本次转发重新设置
+			continue
+		}
+		headers = append(headers, *header)
+	}
+
+	// 只在第一次失败时记录原始位置
+	if _, ok := headerValue(message, HeaderOriginalTopic); !ok {
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte(HeaderOriginalTopic), Value: []byte(message.Topic)},
+			sarama.RecordHeader{Key: []byte(HeaderOriginalPartition), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+			sarama.RecordHeader{Key: []byte(HeaderOriginalOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderRetryAttempt), Value: []byte(strconv.Itoa(attempt + 1))},
+		sarama.RecordHeader{Key: []byte(HeaderError), Value: []byte(cause.Error())},
+		sarama.RecordHeader{Key: []byte(HeaderFailedAt), Value: []byte(now.UTC().Format(time.RFC3339))},
+	)
+	if !notBefore.IsZero() {
+		headers = append(headers, sarama.RecordHeader{
+			Key:   []byte(HeaderRetryNotBefore),
+			Value: []byte(strconv.FormatInt(notBefore.UnixMilli(), 10)),
+		})
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		msg.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	if _, _, err := h.settings.producer.SendMessage(msg); err != nil {
+		return fmt.Errorf("failed to forward message to %s: %w (handler error: %v)", topic, err, cause)
+	}
+	return nil
+}
+
+// shouldCommit 按提交策略判断是否需要同步提交
+func (h *consumerGroupHandler) shouldCommit(pending int) bool {
+	switch h.settings.commitStrategy {
+	case CommitAfterSuccess:
+		return true
+	case CommitBatch:
+		size := h.settings.commitBatchSize
+		if size <= 0 {
+			size = defaultCommitBatchSize
+		}
+		return pending >= size
+	default:
+		return false
+	}
+}
+
+// flush 批量提交策略下提交剩余偏移量
+func (h *consumerGroupHandler) flush(session sarama.ConsumerGroupSession, pending int) {
+	if pending > 0 && h.settings.commitStrategy == CommitBatch {
+		session.Commit()
+	}
+}
+
+// now 返回当前时间
+func (h *consumerGroupHandler) now() time.Time {
+	if h.settings.now != nil {
+		return h.settings.now()
+	}
+	return time.Now()
+}
+
+// headerValue 返回消息头的值
+func headerValue(message *sarama.ConsumerMessage, key string) ([]byte, bool) {
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) == key {
+			return header.Value, true
+		}
+	}
+	return nil, false
+}
+
+// headerInt 返回整数形式的消息头
+func headerInt(message *sarama.ConsumerMessage, key string) (int64, bool) {
+	value, ok := headerValue(message, key)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,203 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// 失败消息转发时写入的消息头
+const (
+	// HeaderOriginalTopic 消息最初所在的主题
+	HeaderOriginalTopic = "x-original-topic"
+
+	// HeaderOriginalPartition 消息最初所在的分区
+	HeaderOriginalPartition = "x-original-partition"
+
+	// HeaderOriginalOffset 消息最初的偏移量
+	HeaderOriginalOffset = "x-original-offset"
+
+	// HeaderRetryAttempt 已经进行的重试次数（主主题失败后转发到第一个重试主题时为 1）
+	HeaderRetryAttempt = "x-retry-attempt"
+
+	// HeaderRetryNotBefore 重试主题中的消息最早可以处理的时间（Unix 毫秒）
+	HeaderRetryNotBefore = "x-retry-not-before"
+
+	// HeaderError 最后一次处理失败的错误信息
+	HeaderError = "x-error"
+
+	// HeaderFailedAt 最后一次处理失败的时间（RFC3339）
+	HeaderFailedAt = "x-failed-at"
+)
+
+const (
+	// defaultCommitInterval 周期提交的默认间隔
+	defaultCommitInterval = time.Second
+
+	// defaultCommitBatchSize 批量提交的默认批大小
+	defaultCommitBatchSize = 100
+)
+
+// CommitStrategy 偏移量提交策略
+//
+// 策略说明：
+// - CommitPeriodic: 处理成功后标记消息，由 sarama 按固定间隔自动提交（默认）
+// - CommitAfterSuccess: 每条消息处理成功后立即同步提交，重复消费最少，吞吐量最低
+// - CommitBatch: 每处理成功 N 条消息同步提交一次，分区回收时提交剩余部分
+type CommitStrategy int
+
+const (
+	// CommitPeriodic 周期自动提交
+	CommitPeriodic CommitStrategy = iota
+
+	// CommitAfterSuccess 每条消息成功后提交
+	CommitAfterSuccess
+
+	// CommitBatch 批量提交
+	CommitBatch
+)
+
+// RetryTopic 重试主题
+//
+// 字段说明：
+// - Topic: 重试主题名称，如 "orders.retry.1m"
+// - Delay: 消息进入该主题后至少等待多久才重新处理
+//
+// 重试主题按配置顺序使用：主主题失败后进入第一个重试主题，
+// 第一个重试主题再失败进入第二个，最后一个失败后进入死信主题
+type RetryTopic struct {
+	Topic string
+	Delay time.Duration
+}
+
+// LagHandler 消费延迟回调
+//
+// 参数：
+// - topic/partition: 分区
+// - lag: 分区最新偏移量与已处理偏移量之差，0 表示已追上
+type LagHandler func(topic string, partition int32, lag int64)
+
+// RebalanceHandler 重平衡回调
+//
+// 参数：
+// - claims: 主题到分区列表的映射
+type RebalanceHandler func(claims map[string][]int32)
+
+// consumerSettings 消费者配置
+type consumerSettings struct {
+	commitStrategy  CommitStrategy
+	commitInterval  time.Duration
+	commitBatchSize int
+
+	retryTopics     []RetryTopic
+	deadLetterTopic string
+	producer        sarama.SyncProducer
+
+	onLag      LagHandler
+	onAssigned RebalanceHandler
+	onRevoked  RebalanceHandler
+
+	now func() time.Time
+}
+
+// ConsumerOption 消费者配置选项
+type ConsumerOption func(*consumerSettings)
+
+// WithPeriodicCommit 按固定间隔自动提交偏移量（默认策略，默认间隔 1 秒）
+func WithPeriodicCommit(interval time.Duration) ConsumerOption {
+	return func(s *consumerSettings) {
+		s.commitStrategy = CommitPeriodic
+		s.commitInterval = interval
+	}
+}
+
+// WithCommitAfterSuccess 每条消息处理成功后立即同步提交偏移量
+func WithCommitAfterSuccess() ConsumerOption {
+	return func(s *consumerSettings) {
+		s.commitStrategy = CommitAfterSuccess
+	}
+}
+
+// WithBatchCommit 每处理成功 size 条消息同步提交一次偏移量
+//
+// size <= 0 时使用默认值 100
+func WithBatchCommit(size int) ConsumerOption {
+	return func(s *consumerSettings) {
+		s.commitStrategy = CommitBatch
+		s.commitBatchSize = size
+	}
+}
+
+// WithRetryTopics 设置重试主题
+//
+// Consume 会自动订阅所有重试主题；失败的消息带上原始位置、重试次数和错误信息消息头转发到下一个重试主题。
+//
+// 示例：
+//
+//	kafka.WithRetryTopics(
+//	    kafka.RetryTopic{Topic: "orders.retry.10s", Delay: 10 * time.Second},
+//	    kafka.RetryTopic{Topic: "orders.retry.5m", Delay: 5 * time.Minute},
+//	)
+func WithRetryTopics(topics ...RetryTopic) ConsumerOption {
+	return func(s *consumerSettings) {
+		s.retryTopics = append([]RetryTopic(nil), topics...)
+	}
+}
+
+// WithDeadLetterTopic 设置死信主题
+//
+// 重试耗尽（或未配置重试主题）时，失败的消息转发到死信主题，消息头包含 HeaderError 等错误信息
+func WithDeadLetterTopic(topic string) ConsumerOption {
+	return func(s *consumerSettings) {
+		s.deadLetterTopic = topic
+	}
+}
+
+// WithFailureProducer 设置转发重试和死信消息使用的生产者
+//
+// 未设置且配置了重试或死信主题时，NewConsumer 会使用相同的 brokers 创建一个同步生产者
+func WithFailureProducer(producer sarama.SyncProducer) ConsumerOption {
+	return func(s *consumerSettings) {
+		s.producer = producer
+	}
+}
+
+// WithLagHandler 设置消费延迟回调
+//
+// 每处理一条消息调用一次，回调应该尽快返回（如只更新指标）
+func WithLagHandler(handler LagHandler) ConsumerOption {
+	return func(s *consumerSettings) {
+		s.onLag = handler
+	}
+}
+
+// WithRebalanceHandlers 设置重平衡回调
+//
+// 参数：
+// - onAssigned: 新会话分配到分区后调用
+// - onRevoked: 会话结束、分区被回收前调用（此时批量提交的剩余偏移量已提交）
+func WithRebalanceHandlers(onAssigned, onRevoked RebalanceHandler) ConsumerOption {
+	return func(s *consumerSettings) {
+		s.onAssigned = onAssigned
+		s.onRevoked = onRevoked
+	}
+}
+
+// routesFailures 是否配置了失败消息转发
+func (s *consumerSettings) routesFailures() bool {
+	return len(s.retryTopics) > 0 || s.deadLetterTopic != ""
+}
+
+// apply 将提交策略写入 sarama 配置
+func (s *consumerSettings) apply(config *sarama.Config) {
+	if s.commitStrategy == CommitPeriodic {
+		interval := s.commitInterval
+		if interval <= 0 {
+			interval = defaultCommitInterval
+		}
+		config.Consumer.Offsets.AutoCommit.Enable = true
+		config.Consumer.Offsets.AutoCommit.Interval = interval
+		return
+	}
+	config.Consumer.Offsets.AutoCommit.Enable = false
+}
@@ -3,10 +3,14 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -223,3 +227,309 @@ func TestConsumerGroupHandler_Struct(t *testing.T) {
 	err := handler.handler(ctx, "test", []byte("value"))
 	assert.NoError(t, err)
 }
+
+// fakeSession 测试用消费者组会话
+type fakeSession struct {
+	ctx    context.Context
+	claims map[string][]int32
+
+	mu      sync.Mutex
+	marked  []int64
+	commits int
+}
+
+func (s *fakeSession) Claims() map[string][]int32 { return s.claims }
+func (s *fakeSession) MemberID() string           { return "member-1" }
+func (s *fakeSession) GenerationID() int32        { return 1 }
+func (s *fakeSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *fakeSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+}
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func (s *fakeSession) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commits++
+}
+
+// fakeClaim 测试用分区声明
+type fakeClaim struct {
+	topic     string
+	messages  chan *sarama.ConsumerMessage
+	highWater int64
+}
+
+func (c *fakeClaim) Topic() string                            { return c.topic }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return c.highWater }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// newFakeClaim 创建包含给定消息的已关闭分区声明
+func newFakeClaim(topic string, msgs ...*sarama.ConsumerMessage) *fakeClaim {
+	ch := make(chan *sarama.ConsumerMessage, len(msgs))
+	for i, msg := range msgs {
+		if msg.Topic == "" {
+			msg.Topic = topic
+		}
+		msg.Offset = int64(i)
+		ch <- msg
+	}
+	close(ch)
+	return &fakeClaim{topic: topic, messages: ch, highWater: int64(len(msgs))}
+}
+
+// headerMap 将生产者消息头转换为 map
+func headerMap(msg *sarama.ProducerMessage) map[string]string {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	return headers
+}
+
+// TestConsumeClaim_CommitStrategies 测试提交策略
+func TestConsumeClaim_CommitStrategies(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ConsumerOption
+		commits int
+	}{
+		{"periodic", nil, 0},
+		{"after success", []ConsumerOption{WithCommitAfterSuccess()}, 5},
+		{"batch", []ConsumerOption{WithBatchCommit(2)}, 3}, // 2 + 2 + 分区关闭时剩余的 1
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var settings consumerSettings
+			for _, opt := range tt.opts {
+				opt(&settings)
+			}
+			handler := &consumerGroupHandler{
+				handler:  func(ctx context.Context, key string, value []byte) error { return nil },
+				settings: settings,
+			}
+
+			msgs := make([]*sarama.ConsumerMessage, 5)
+			for i := range msgs {
+				msgs[i] = &sarama.ConsumerMessage{Value: []byte("v")}
+			}
+			session := &fakeSession{ctx: context.Background()}
+			require.NoError(t, handler.ConsumeClaim(session, newFakeClaim("orders", msgs...)))
+
+			assert.Equal(t, []int64{0, 1, 2, 3, 4}, session.marked)
+			assert.Equal(t, tt.commits, session.commits)
+		})
+	}
+}
+
+// TestConsumerSettings_Apply 测试提交策略对 sarama 配置的影响
+func TestConsumerSettings_Apply(t *testing.T) {
+	config := sarama.NewConfig()
+	(&consumerSettings{}).apply(config)
+	assert.True(t, config.Consumer.Offsets.AutoCommit.Enable)
+	assert.Equal(t, defaultCommitInterval, config.Consumer.Offsets.AutoCommit.Interval)
+
+	settings := consumerSettings{}
+	WithPeriodicCommit(5 * time.Second)(&settings)
+	settings.apply(config)
+	assert.Equal(t, 5*time.Second, config.Consumer.Offsets.AutoCommit.Interval)
+
+	WithBatchCommit(10)(&settings)
+	settings.apply(config)
+	assert.False(t, config.Consumer.Offsets.AutoCommit.Enable)
+}
+
+// TestConsumeClaim_ErrorWithoutRouting 测试未配置转发时处理失败返回错误
+func TestConsumeClaim_ErrorWithoutRouting(t *testing.T) {
+	handlerErr := errors.New("boom")
+	handler := &consumerGroupHandler{
+		handler: func(ctx context.Context, key string, value []byte) error { return handlerErr },
+	}
+
+	session := &fakeSession{ctx: context.Background()}
+	err := handler.ConsumeClaim(session, newFakeClaim("orders", &sarama.ConsumerMessage{}))
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Empty(t, session.marked)
+}
+
+// TestConsumeClaim_RetryTopic 测试失败消息转发到重试主题
+func TestConsumeClaim_RetryTopic(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "orders.retry.1m", msg.Topic)
+		key, _ := msg.Key.Encode()
+		assert.Equal(t, "order-1", string(key))
+
+		headers := headerMap(msg)
+		assert.Equal(t, "trace-1", headers["traceparent"])
+		assert.Equal(t, "orders", headers[HeaderOriginalTopic])
+		assert.Equal(t, "0", headers[HeaderOriginalPartition])
+		assert.Equal(t, "0", headers[HeaderOriginalOffset])
+		assert.Equal(t, "1", headers[HeaderRetryAttempt])
+		assert.Equal(t, "boom", headers[HeaderError])
+		assert.Equal(t, "2024-01-02T03:04:05Z", headers[HeaderFailedAt])
+		assert.Equal(t, strconv.FormatInt(now.Add(time.Minute).UnixMilli(), 10), headers[HeaderRetryNotBefore])
+		return nil
+	})
+
+	settings := consumerSettings{now: func() time.Time { return now }}
+	WithRetryTopics(RetryTopic{Topic: "orders.retry.1m", Delay: time.Minute})(&settings)
+	WithDeadLetterTopic("orders.dlq")(&settings)
+	WithFailureProducer(producer)(&settings)
+
+	handler := &consumerGroupHandler{
+		handler:  func(ctx context.Context, key string, value []byte) error { return errors.New("boom") },
+		settings: settings,
+	}
+
+	session := &fakeSession{ctx: context.Background()}
+	msg := &sarama.ConsumerMessage{
+		Key:     []byte("order-1"),
+		Value:   []byte(`{"id":"order-1"}`),
+		Headers: []*sarama.RecordHeader{{Key: []byte("traceparent"), Value: []byte("trace-1")}},
+	}
+	require.NoError(t, handler.ConsumeClaim(session, newFakeClaim("orders", msg)))
+
+	// 转发成功后原消息被标记，不会阻塞分区
+	assert.Equal(t, []int64{0}, session.marked)
+	require.NoError(t, producer.Close())
+}
+
+// TestConsumeClaim_DeadLetterAfterRetries 测试重试耗尽后转发到死信主题
+func TestConsumeClaim_DeadLetterAfterRetries(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		assert.Equal(t, "orders.dlq", msg.Topic)
+
+		headers := headerMap(msg)
+		// 保留第一次失败时的原始位置
+		assert.Equal(t, "orders", headers[HeaderOriginalTopic])
+		assert.Equal(t, "42", headers[HeaderOriginalOffset])
+		assert.Equal(t, "2", headers[HeaderRetryAttempt])
+		assert.Equal(t, "still failing", headers[HeaderError])
+		_, ok := headers[HeaderRetryNotBefore]
+		assert.False(t, ok, "dead letter should not carry retry delay")
+		return nil
+	})
+
+	settings := consumerSettings{}
+	WithRetryTopics(RetryTopic{Topic: "orders.retry", Delay: 0})(&settings)
+	WithDeadLetterTopic("orders.dlq")(&settings)
+	WithFailureProducer(producer)(&settings)
+
+	handler := &consumerGroupHandler{
+		handler:  func(ctx context.Context, key string, value []byte) error { return errors.New("still failing") },
+		settings: settings,
+	}
+
+	msg := &sarama.ConsumerMessage{
+		Topic: "orders.retry",
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderOriginalTopic), Value: []byte("orders")},
+			{Key: []byte(HeaderOriginalPartition), Value: []byte("0")},
+			{Key: []byte(HeaderOriginalOffset), Value: []byte("42")},
+			{Key: []byte(HeaderRetryAttempt), Value: []byte("1")},
+			{Key: []byte(HeaderError), Value: []byte("boom")},
+		},
+	}
+	session := &fakeSession{ctx: context.Background()}
+	require.NoError(t, handler.ConsumeClaim(session, newFakeClaim("orders.retry", msg)))
+	assert.Equal(t, []int64{0}, session.marked)
+	require.NoError(t, producer.Close())
+}
+
+// TestConsumeClaim_ForwardFailure 测试转发失败时不标记消息
+func TestConsumeClaim_ForwardFailure(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+	settings := consumerSettings{}
+	WithDeadLetterTopic("orders.dlq")(&settings)
+	WithFailureProducer(producer)(&settings)
+
+	handler := &consumerGroupHandler{
+		handler:  func(ctx context.Context, key string, value []byte) error { return errors.New("boom") },
+		settings: settings,
+	}
+
+	session := &fakeSession{ctx: context.Background()}
+	err := handler.ConsumeClaim(session, newFakeClaim("orders", &sarama.ConsumerMessage{}))
+	assert.ErrorIs(t, err, sarama.ErrOutOfBrokers)
+	assert.Empty(t, session.marked)
+	require.NoError(t, producer.Close())
+}
+
+// TestConsumeClaim_WaitsForRetryDelay 测试重试主题中的消息等待延迟到期
+func TestConsumeClaim_WaitsForRetryDelay(t *testing.T) {
+	notBefore := time.Now().Add(50 * time.Millisecond)
+	var handledAt time.Time
+	handler := &consumerGroupHandler{
+		handler: func(ctx context.Context, key string, value []byte) error {
+			handledAt = time.Now()
+			return nil
+		},
+	}
+
+	msg := &sarama.ConsumerMessage{
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderRetryNotBefore), Value: []byte(strconv.FormatInt(notBefore.UnixMilli(), 10))},
+		},
+	}
+	session := &fakeSession{ctx: context.Background()}
+	require.NoError(t, handler.ConsumeClaim(session, newFakeClaim("orders.retry", msg)))
+	assert.False(t, handledAt.Before(notBefore.Truncate(time.Millisecond)))
+
+	// 会话结束时停止等待
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	msg = &sarama.ConsumerMessage{
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte(HeaderRetryNotBefore), Value: []byte(strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10))},
+		},
+	}
+	assert.ErrorIs(t, handler.process(ctx, msg), context.Canceled)
+}
+
+// TestConsumeClaim_LagAndRebalanceCallbacks 测试延迟和重平衡回调
+func TestConsumeClaim_LagAndRebalanceCallbacks(t *testing.T) {
+	var lags []int64
+	var assigned, revoked map[string][]int32
+
+	settings := consumerSettings{}
+	WithLagHandler(func(topic string, partition int32, lag int64) {
+		assert.Equal(t, "orders", topic)
+		lags = append(lags, lag)
+	})(&settings)
+	WithRebalanceHandlers(
+		func(claims map[string][]int32) { assigned = claims },
+		func(claims map[string][]int32) { revoked = claims },
+	)(&settings)
+
+	handler := &consumerGroupHandler{
+		handler:  func(ctx context.Context, key string, value []byte) error { return nil },
+		settings: settings,
+	}
+
+	claims := map[string][]int32{"orders": {0, 1}}
+	session := &fakeSession{ctx: context.Background(), claims: claims}
+
+	require.NoError(t, handler.Setup(session))
+	assert.Equal(t, claims, assigned)
+
+	require.NoError(t, handler.ConsumeClaim(session, newFakeClaim("orders",
+		&sarama.ConsumerMessage{}, &sarama.ConsumerMessage{}, &sarama.ConsumerMessage{})))
+	assert.Equal(t, []int64{2, 1, 0}, lags)
+
+	require.NoError(t, handler.Cleanup(session))
+	assert.Equal(t, claims, revoked)
+}
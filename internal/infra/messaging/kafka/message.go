@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Message 要发送的 Kafka 消息
+//
+// 字段说明：
+// - Topic: 主题名称
+// - Key: 消息键（用于分区路由），为空时轮询分配分区
+// - Value: 已编码的消息值
+// - Headers: 消息头，与注入的追踪上下文同名时以这里的值为准
+// - Timestamp: 消息时间戳，为零值时由 sarama 使用发送时间
+type Message struct {
+	Topic     string
+	Key       string
+	Value     []byte
+	Headers   map[string]string
+	Timestamp time.Time
+}
+
+// headerCarrier 将消息头适配为 propagation.TextMapCarrier
+type headerCarrier map[string]string
+
+// Get 返回消息头的值
+func (c headerCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set 设置消息头
+func (c headerCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys 返回所有消息头名称
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// producerMessage 将 Message 转换为 sarama 消息，并注入上下文中的追踪信息
+//
+// 返回的 sarama 消息 Metadata 字段保存注入追踪信息后的 Message，用于投递回调
+func producerMessage(ctx context.Context, propagator propagation.TextMapPropagator, msg Message) *sarama.ProducerMessage {
+	headers := headerCarrier{}
+	if propagator != nil {
+		propagator.Inject(ctx, headers)
+	}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	msg.Headers = headers
+
+	keys := headers.Keys()
+	sort.Strings(keys)
+	recordHeaders := make([]sarama.RecordHeader, 0, len(keys))
+	for _, key := range keys {
+		recordHeaders = append(recordHeaders, sarama.RecordHeader{
+			Key:   []byte(key),
+			Value: []byte(headers[key]),
+		})
+	}
+
+	return &sarama.ProducerMessage{
+		Topic:     msg.Topic,
+		Key:       sarama.StringEncoder(msg.Key),
+		Value:     sarama.ByteEncoder(msg.Value),
+		Headers:   recordHeaders,
+		Timestamp: msg.Timestamp,
+		Metadata:  msg,
+	}
+}
+
+// ExtractTraceContext 从消费到的消息头中提取追踪上下文
+//
+// 与 Producer 注入的 traceparent/tracestate/baggage 对应，用于在消费端继续同一条链路
+//
+// 示例：
+//
+//	ctx = kafka.ExtractTraceContext(ctx, message.Headers)
+//	ctx, span := tracer.Start(ctx, "orders.process")
+//	defer span.End()
+func ExtractTraceContext(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	carrier := headerCarrier{}
+	for _, header := range headers {
+		if header != nil {
+			carrier[string(header.Key)] = string(header.Value)
+		}
+	}
+	return newProducerSettings(nil).propagator.Extract(ctx, carrier)
+}
@@ -9,7 +9,8 @@
 // 4. 容错性：支持消息重试和错误处理
 //
 // 核心功能：
-// - Producer: 发送消息到 Kafka 主题
+// - Producer: 发送消息到 Kafka 主题（单条、批量，自动注入 W3C traceparent）
+// - AsyncProducer: 异步发送消息，通过回调报告投递结果
 // - Consumer: 从 Kafka 主题消费消息
 // - 消息序列化：支持 JSON 格式的消息序列化
 //
@@ -41,6 +42,8 @@ import (
 	"fmt"
 
 	"github.com/IBM/sarama"
+
+	"github.com/yourusername/golang/internal/config"
 )
 
 // Producer 是 Kafka 生产者的封装，用于发送消息到 Kafka 主题。
@@ -49,6 +52,7 @@ import (
 // - 使用同步生产者确保消息发送成功
 // - 支持消息序列化（JSON 格式）
 // - 支持消息键（Key）用于分区路由
+// - 支持消息头和批量发送，自动将上下文中的追踪信息注入消息头
 //
 // 设计说明：
 // - 同步发送：等待消息发送成功后才返回
@@ -67,6 +71,7 @@ import (
 //	err = producer.SendMessage(ctx, "my-topic", "message-key", messageData)
 type Producer struct {
 	producer sarama.SyncProducer
+	settings producerSettings
 }
 
 // NewProducer 创建并初始化 Kafka 生产者。
@@ -80,6 +85,7 @@ type Producer struct {
 // - brokers: Kafka Broker 地址列表
 //   格式：[]string{"host1:9092", "host2:9092"}
 //   至少提供一个 Broker 地址
+// - opts: 可选配置，如追踪上下文传播器
 //
 // 返回：
 // - *Producer: 配置好的生产者实例
@@ -110,21 +116,43 @@ type Producer struct {
 // - 生产环境建议使用集群配置（多个 Broker）
 // - 应在应用程序生命周期中复用生产者实例
 // - 退出前应调用 Close() 关闭生产者
-func NewProducer(brokers []string) (*Producer, error) {
-	config := sarama.NewConfig()
-	config.Producer.Return.Successes = true  // 返回成功发送的消息
-	config.Producer.RequiredAcks = sarama.WaitForAll // 等待所有副本确认
-	config.Producer.Retry.Max = 5            // 最大重试 5 次
-	// 其他可选配置：
-	// config.Producer.Timeout = 10 * time.Second
-	// config.Producer.Compression = sarama.CompressionSnappy
-
-	producer, err := sarama.NewSyncProducer(brokers, config)
+func NewProducer(brokers []string, opts ...ProducerOption) (*Producer, error) {
+	return NewProducerFromConfig(&config.KafkaConfig{Brokers: brokers}, opts...)
+}
+
+// NewProducerFromConfig 根据应用配置创建 Kafka 生产者。
+//
+// 功能说明：
+// - 使用 cfg.Brokers 连接 Kafka 集群
+// - 按 cfg.Producer 配置幂等、压缩和批量发送
+//
+// 参数：
+// - cfg: Kafka 配置
+// - opts: 可选配置
+//
+// 返回：
+// - *Producer: 配置好的生产者实例
+// - error: 配置无效（如未知的压缩算法）或连接失败时返回错误
+//
+// 使用示例：
+//
+//	producer, err := kafka.NewProducerFromConfig(&cfg.Kafka)
+//
+// 注意事项：
+// - 幂等生产者要求 Broker 版本 >= 0.11，并且会限制每个连接只有一个在途请求
+// - 同步生产者的批量发送使用 SendBatch；Flush 配置主要影响 AsyncProducer
+func NewProducerFromConfig(cfg *config.KafkaConfig, opts ...ProducerOption) (*Producer, error) {
+	saramaConfig, err := newProducerConfig(cfg.Producer)
+	if err != nil {
+		return nil, err
+	}
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, saramaConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create producer: %w", err)
 	}
 
-	return &Producer{producer: producer}, nil
+	return &Producer{producer: producer, settings: newProducerSettings(opts)}, nil
 }
 
 // SendMessage 发送消息到指定的 Kafka 主题。
@@ -136,7 +164,7 @@ func NewProducer(brokers []string) (*Producer, error) {
 // - 同步等待发送结果
 //
 // 参数：
-// - ctx: 上下文，用于控制请求超时和取消（当前未使用，保留用于未来扩展）
+// - ctx: 上下文，其中的追踪信息会注入消息头
 // - topic: Kafka 主题名称
 // - key: 消息键（用于分区路由，相同键的消息会发送到同一分区）
 // - value: 消息值（可以是任意类型，会自动序列化为 JSON）
@@ -183,16 +211,55 @@ func (p *Producer) SendMessage(ctx context.Context, topic string, key string, va
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	msg := &sarama.ProducerMessage{
-		Topic: topic,
-		Key:   sarama.StringEncoder(key),
-		Value: sarama.ByteEncoder(data),
-		// 其他可选字段：
-		// Headers: []sarama.RecordHeader{...}, // 消息头
-		// Timestamp: time.Now(),               // 消息时间戳
+	return p.Send(ctx, Message{Topic: topic, Key: key, Value: data})
+}
+
+// SendBytes 发送已编码的消息到指定的 Kafka 主题。
+//
+// 功能说明：
+// - 与 SendMessage 相同，但直接发送原始字节，不做 JSON 序列化
+// - 适用于消息已经由调用方编码的场景（如 protobuf、事件桥接）
+//
+// 参数：
+// - ctx: 上下文，其中的追踪信息会注入消息头
+// - topic: Kafka 主题名称
+// - key: 消息键（用于分区路由）
+// - value: 已编码的消息值
+//
+// 返回：
+// - error: 如果发送失败，返回错误信息
+func (p *Producer) SendBytes(ctx context.Context, topic string, key string, value []byte) error {
+	return p.Send(ctx, Message{Topic: topic, Key: key, Value: value})
+}
+
+// Send 发送一条消息。
+//
+// 功能说明：
+// - 发送带消息头的消息
+// - 将 ctx 中的追踪上下文以 W3C traceparent/tracestate 消息头注入
+// - 同步等待发送结果
+//
+// 参数：
+// - ctx: 上下文，发送前已取消时直接返回 ctx.Err()
+// - msg: 要发送的消息
+//
+// 返回：
+// - error: 如果发送失败，返回错误信息
+//
+// 使用示例：
+//
+//	err := producer.Send(ctx, kafka.Message{
+//	    Topic:   "orders",
+//	    Key:     order.ID,
+//	    Value:   payload,
+//	    Headers: map[string]string{"content-type": "application/json"},
+//	})
+func (p *Producer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	_, _, err = p.producer.SendMessage(msg)
+	_, _, err := p.producer.SendMessage(producerMessage(ctx, p.settings.propagator, msg))
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
@@ -200,6 +267,43 @@ func (p *Producer) SendMessage(ctx context.Context, topic string, key string, va
 	return nil
 }
 
+// SendBatch 批量发送消息。
+//
+// 功能说明：
+// - 一次提交多条消息，由 sarama 按分区合并为批量请求
+// - 每条消息都注入 ctx 中的追踪上下文
+// - 同步等待所有消息发送完成
+//
+// 参数：
+// - ctx: 上下文，发送前已取消时直接返回 ctx.Err()
+// - msgs: 要发送的消息
+//
+// 返回：
+// - error: 部分或全部消息发送失败时返回错误，可以用 errors.As 取出 sarama.ProducerErrors 查看失败的消息
+//
+// 注意事项：
+// - 批量发送不是事务性的，失败时其他消息可能已经写入
+// - 需要不重复写入时，配合幂等生产者（config.KafkaProducerConfig.Idempotent）重试
+func (p *Producer) SendBatch(ctx context.Context, msgs []Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	batch := make([]*sarama.ProducerMessage, len(msgs))
+	for i, msg := range msgs {
+		batch[i] = producerMessage(ctx, p.settings.propagator, msg)
+	}
+
+	if err := p.producer.SendMessages(batch); err != nil {
+		return fmt.Errorf("failed to send batch: %w", err)
+	}
+
+	return nil
+}
+
 // Close 关闭 Kafka 生产者。
 //
 // 功能说明：
@@ -0,0 +1,200 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+
+	"github.com/yourusername/golang/internal/config"
+)
+
+// ErrProducerClosed 生产者已关闭
+var ErrProducerClosed = errors.New("kafka producer is closed")
+
+// AsyncProducer 是 Kafka 异步生产者的封装。
+//
+// 功能说明：
+// - Send 只把消息放入发送队列，不等待 Broker 确认
+// - sarama 按 config.KafkaProducerConfig 的 Flush 配置将消息合并为批量请求
+// - 每条消息的投递结果通过 DeliveryHandler 回调报告
+//
+// 设计说明：
+// - 后台 goroutine 读取 sarama 的 Successes/Errors 通道并调用回调，避免通道阻塞发送
+// - Close 会等待队列中的消息全部发送完成、回调全部执行后才返回
+//
+// 使用示例：
+//
+//	producer, err := kafka.NewAsyncProducerFromConfig(&cfg.Kafka,
+//	    kafka.WithDeliveryHandler(func(report kafka.DeliveryReport) {
+//	        if report.Err != nil {
+//	            logger.Error("kafka delivery failed", "topic", report.Message.Topic, "error", report.Err)
+//	        }
+//	    }),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer producer.Close()
+//
+//	err = producer.Send(ctx, kafka.Message{Topic: "clicks", Key: userID, Value: payload})
+type AsyncProducer struct {
+	producer sarama.AsyncProducer
+	settings producerSettings
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewAsyncProducer 创建 Kafka 异步生产者，使用默认的生产者配置。
+//
+// 参数：
+// - brokers: Kafka Broker 地址列表
+// - opts: 可选配置，如 WithDeliveryHandler
+//
+// 返回：
+// - *AsyncProducer: 配置好的异步生产者实例
+// - error: 如果创建失败，返回错误信息
+func NewAsyncProducer(brokers []string, opts ...ProducerOption) (*AsyncProducer, error) {
+	return NewAsyncProducerFromConfig(&config.KafkaConfig{Brokers: brokers}, opts...)
+}
+
+// NewAsyncProducerFromConfig 根据应用配置创建 Kafka 异步生产者。
+//
+// 参数：
+// - cfg: Kafka 配置，cfg.Producer 控制幂等、压缩和批量发送
+// - opts: 可选配置，如 WithDeliveryHandler
+//
+// 返回：
+// - *AsyncProducer: 配置好的异步生产者实例
+// - error: 配置无效或连接失败时返回错误
+func NewAsyncProducerFromConfig(cfg *config.KafkaConfig, opts ...ProducerOption) (*AsyncProducer, error) {
+	saramaConfig, err := newProducerConfig(cfg.Producer)
+	if err != nil {
+		return nil, err
+	}
+	saramaConfig.Producer.Return.Errors = true
+
+	producer, err := sarama.NewAsyncProducer(cfg.Brokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create async producer: %w", err)
+	}
+
+	return newAsyncProducer(producer, newProducerSettings(opts)), nil
+}
+
+// newAsyncProducer 包装 sarama 异步生产者并启动投递结果处理 goroutine
+//
+// sarama 配置需要同时启用 Return.Successes 和 Return.Errors
+func newAsyncProducer(producer sarama.AsyncProducer, settings producerSettings) *AsyncProducer {
+	p := &AsyncProducer{
+		producer: producer,
+		settings: settings,
+	}
+
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		for msg := range producer.Successes() {
+			p.report(msg, nil)
+		}
+	}()
+	go func() {
+		defer p.wg.Done()
+		for perr := range producer.Errors() {
+			p.report(perr.Msg, perr.Err)
+		}
+	}()
+
+	return p
+}
+
+// Send 将消息放入发送队列。
+//
+// 功能说明：
+// - 注入 ctx 中的追踪上下文后放入发送队列，不等待 Broker 确认
+// - 发送队列已满时阻塞，直到有空位或 ctx 取消
+//
+// 参数：
+// - ctx: 上下文
+// - msg: 要发送的消息
+//
+// 返回：
+// - error: ctx 取消时返回 ctx.Err()，生产者已关闭时返回 ErrProducerClosed；投递失败通过 DeliveryHandler 报告
+func (p *AsyncProducer) Send(ctx context.Context, msg Message) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrProducerClosed
+	}
+
+	select {
+	case p.producer.Input() <- producerMessage(ctx, p.settings.propagator, msg):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SendBatch 将多条消息依次放入发送队列。
+//
+// 返回：
+// - error: 与 Send 相同；返回错误时，之前的消息已经进入发送队列
+func (p *AsyncProducer) SendBatch(ctx context.Context, msgs []Message) error {
+	for _, msg := range msgs {
+		if err := p.Send(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 关闭异步生产者。
+//
+// 功能说明：
+// - 停止接收新消息
+// - 等待队列中的消息发送完成、投递回调执行完毕
+//
+// 返回：
+// - error: 始终为 nil，关闭过程中的发送失败通过 DeliveryHandler 报告
+func (p *AsyncProducer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	// 关闭后 sarama 会关闭 Successes/Errors 通道，处理 goroutine 随之退出
+	p.producer.AsyncClose()
+	p.wg.Wait()
+	return nil
+}
+
+// report 调用投递结果回调
+func (p *AsyncProducer) report(msg *sarama.ProducerMessage, err error) {
+	if p.settings.onDelivery == nil || msg == nil {
+		return
+	}
+
+	report := DeliveryReport{
+		Partition: -1,
+		Offset:    -1,
+		Err:       err,
+	}
+	if original, ok := msg.Metadata.(Message); ok {
+		report.Message = original
+	} else {
+		report.Message = Message{Topic: msg.Topic}
+	}
+	if err == nil {
+		report.Partition = msg.Partition
+		report.Offset = msg.Offset
+	}
+
+	p.settings.onDelivery(report)
+}
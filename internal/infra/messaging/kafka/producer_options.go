@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/yourusername/golang/internal/config"
+)
+
+// ErrUnknownCompression 不支持的压缩算法
+var ErrUnknownCompression = errors.New("unknown kafka compression codec")
+
+// DeliveryReport 异步发送的投递结果
+//
+// 字段说明：
+// - Message: 发送的消息（Headers 包含注入的追踪上下文）
+// - Partition/Offset: 消息写入的分区和偏移量（失败时为 -1）
+// - Err: 发送失败的原因，成功时为 nil
+type DeliveryReport struct {
+	Message   Message
+	Partition int32
+	Offset    int64
+	Err       error
+}
+
+// DeliveryHandler 投递结果回调
+//
+// 回调在 AsyncProducer 的后台 goroutine 中按完成顺序依次调用，应该尽快返回
+type DeliveryHandler func(report DeliveryReport)
+
+// producerSettings 生产者配置
+type producerSettings struct {
+	propagator propagation.TextMapPropagator
+	onDelivery DeliveryHandler
+}
+
+// ProducerOption 生产者配置选项
+type ProducerOption func(*producerSettings)
+
+// WithPropagator 设置追踪上下文传播器
+//
+// 默认使用 W3C Trace Context（traceparent/tracestate）和 Baggage
+func WithPropagator(propagator propagation.TextMapPropagator) ProducerOption {
+	return func(s *producerSettings) {
+		s.propagator = propagator
+	}
+}
+
+// WithDeliveryHandler 设置异步发送的投递结果回调（仅对 AsyncProducer 生效）
+func WithDeliveryHandler(handler DeliveryHandler) ProducerOption {
+	return func(s *producerSettings) {
+		s.onDelivery = handler
+	}
+}
+
+// newProducerSettings 应用配置选项
+func newProducerSettings(opts []ProducerOption) producerSettings {
+	settings := producerSettings{
+		propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
+	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return settings
+}
+
+// newProducerConfig 根据 config.KafkaProducerConfig 创建 sarama 生产者配置
+//
+// 配置说明：
+// - RequiredAcks 固定为 WaitForAll
+// - Idempotent: 同时要求 Net.MaxOpenRequests = 1、Kafka 版本 >= 0.11
+// - Compression: zstd 要求 Kafka 版本 >= 2.1
+// - Flush*: 控制批量发送，未设置时 sarama 尽快发送
+func newProducerConfig(cfg config.KafkaProducerConfig) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Producer.Return.Successes = true          // 返回成功发送的消息
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll // 等待所有副本确认
+	saramaConfig.Producer.Retry.Max = 5                    // 最大重试 5 次
+	if cfg.MaxRetries > 0 {
+		saramaConfig.Producer.Retry.Max = cfg.MaxRetries
+	}
+
+	codec, err := parseCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	saramaConfig.Producer.Compression = codec
+	if codec == sarama.CompressionZSTD {
+		saramaConfig.Version = sarama.V2_1_0_0
+	}
+
+	if cfg.Idempotent {
+		saramaConfig.Producer.Idempotent = true
+		saramaConfig.Net.MaxOpenRequests = 1
+		if !saramaConfig.Version.IsAtLeast(sarama.V0_11_0_0) {
+			saramaConfig.Version = sarama.V0_11_0_0
+		}
+	}
+
+	saramaConfig.Producer.Flush.Messages = cfg.FlushMessages
+	saramaConfig.Producer.Flush.Frequency = cfg.FlushFrequency
+	saramaConfig.Producer.Flush.Bytes = cfg.FlushBytes
+
+	if err := saramaConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka producer config: %w", err)
+	}
+	return saramaConfig, nil
+}
+
+// parseCompression 解析压缩算法名称
+func parseCompression(name string) (sarama.CompressionCodec, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return sarama.CompressionNone, nil
+	case "gzip":
+		return sarama.CompressionGZIP, nil
+	case "snappy":
+		return sarama.CompressionSnappy, nil
+	case "lz4":
+		return sarama.CompressionLZ4, nil
+	case "zstd":
+		return sarama.CompressionZSTD, nil
+	default:
+		return sarama.CompressionNone, fmt.Errorf("%w: %s", ErrUnknownCompression, name)
+	}
+}
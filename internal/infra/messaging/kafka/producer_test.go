@@ -2,10 +2,20 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/yourusername/golang/internal/config"
 )
 
 // TestNewProducer_InvalidBrokers 测试无效 broker 地址
@@ -65,3 +75,204 @@ func TestNewProducer_Config(t *testing.T) {
 		defer producer.Close()
 	}
 }
+
+// TestProducer_SendBytes 测试发送原始字节（不做 JSON 序列化）
+func TestProducer_SendBytes(t *testing.T) {
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithCheckerFunctionAndSucceed(func(val []byte) error {
+		if string(val) != "\x01\x02raw" {
+			return fmt.Errorf("unexpected value: %q", val)
+		}
+		return nil
+	})
+
+	producer := &Producer{producer: mock}
+	err := producer.SendBytes(context.Background(), "events", "key", []byte("\x01\x02raw"))
+	assert.NoError(t, err)
+	assert.NoError(t, mock.Close())
+}
+
+// tracedContext 返回带有固定 span 上下文的 context
+func tracedContext(t *testing.T) context.Context {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// TestProducer_Send_HeadersAndTraceparent 测试消息头和追踪上下文注入
+func TestProducer_Send_HeadersAndTraceparent(t *testing.T) {
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		headers := headerMap(msg)
+		if headers["traceparent"] != testTraceparent {
+			return fmt.Errorf("unexpected traceparent: %q", headers["traceparent"])
+		}
+		if headers["content-type"] != "application/json" {
+			return fmt.Errorf("unexpected content-type: %q", headers["content-type"])
+		}
+		key, _ := msg.Key.Encode()
+		if string(key) != "order-1" {
+			return fmt.Errorf("unexpected key: %q", key)
+		}
+		return nil
+	})
+
+	producer := &Producer{producer: mock, settings: newProducerSettings(nil)}
+	err := producer.Send(tracedContext(t), Message{
+		Topic:   "orders",
+		Key:     "order-1",
+		Value:   []byte(`{}`),
+		Headers: map[string]string{"content-type": "application/json"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.Close())
+}
+
+// TestProducer_SendBatch 测试批量发送
+func TestProducer_SendBatch(t *testing.T) {
+	mock := mocks.NewSyncProducer(t, nil)
+	for i := 0; i < 3; i++ {
+		mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			if headerMap(msg)["traceparent"] != testTraceparent {
+				return errors.New("missing traceparent")
+			}
+			return nil
+		})
+	}
+
+	producer := &Producer{producer: mock, settings: newProducerSettings(nil)}
+	err := producer.SendBatch(tracedContext(t), []Message{
+		{Topic: "orders", Value: []byte("1")},
+		{Topic: "orders", Value: []byte("2")},
+		{Topic: "payments", Value: []byte("3")},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.Close())
+}
+
+// TestProducer_SendBatch_PartialFailure 测试批量发送部分失败
+func TestProducer_SendBatch_PartialFailure(t *testing.T) {
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageAndSucceed()
+	mock.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+
+	producer := &Producer{producer: mock}
+	err := producer.SendBatch(context.Background(), []Message{
+		{Topic: "orders", Value: []byte("1")},
+		{Topic: "orders", Value: []byte("2")},
+	})
+	// 真实的 sarama 生产者返回 sarama.ProducerErrors，mock 直接返回期望的错误
+	assert.ErrorIs(t, err, sarama.ErrOutOfBrokers)
+	assert.NoError(t, mock.Close())
+}
+
+// TestProducer_Send_ContextCancelled 测试上下文取消后不再发送
+func TestProducer_Send_ContextCancelled(t *testing.T) {
+	mock := mocks.NewSyncProducer(t, nil)
+	producer := &Producer{producer: mock}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, producer.Send(ctx, Message{Topic: "orders"}), context.Canceled)
+	assert.ErrorIs(t, producer.SendBatch(ctx, []Message{{Topic: "orders"}}), context.Canceled)
+	assert.NoError(t, mock.Close())
+}
+
+// TestExtractTraceContext 测试从消息头提取追踪上下文
+func TestExtractTraceContext(t *testing.T) {
+	pm := producerMessage(tracedContext(t), newProducerSettings(nil).propagator, Message{Topic: "orders"})
+
+	headers := make([]*sarama.RecordHeader, len(pm.Headers))
+	for i := range pm.Headers {
+		headers[i] = &pm.Headers[i]
+	}
+
+	sc := trace.SpanContextFromContext(ExtractTraceContext(context.Background(), headers))
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+	assert.True(t, sc.IsRemote())
+}
+
+// TestNewProducerConfig 测试根据应用配置生成 sarama 配置
+func TestNewProducerConfig(t *testing.T) {
+	cfg, err := newProducerConfig(config.KafkaProducerConfig{
+		Idempotent:    true,
+		Compression:   "zstd",
+		MaxRetries:    10,
+		FlushMessages: 100,
+	})
+	require.NoError(t, err)
+	assert.True(t, cfg.Producer.Idempotent)
+	assert.Equal(t, 1, cfg.Net.MaxOpenRequests)
+	assert.Equal(t, sarama.WaitForAll, cfg.Producer.RequiredAcks)
+	assert.Equal(t, sarama.CompressionZSTD, cfg.Producer.Compression)
+	assert.True(t, cfg.Version.IsAtLeast(sarama.V2_1_0_0))
+	assert.Equal(t, 10, cfg.Producer.Retry.Max)
+	assert.Equal(t, 100, cfg.Producer.Flush.Messages)
+
+	cfg, err = newProducerConfig(config.KafkaProducerConfig{})
+	require.NoError(t, err)
+	assert.False(t, cfg.Producer.Idempotent)
+	assert.Equal(t, sarama.CompressionNone, cfg.Producer.Compression)
+	assert.Equal(t, 5, cfg.Producer.Retry.Max)
+
+	_, err = newProducerConfig(config.KafkaProducerConfig{Compression: "brotli"})
+	assert.ErrorIs(t, err, ErrUnknownCompression)
+}
+
+// TestAsyncProducer_DeliveryReports 测试异步发送的投递回调
+func TestAsyncProducer_DeliveryReports(t *testing.T) {
+	saramaConfig := mocks.NewTestConfig()
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.Return.Errors = true
+
+	mock := mocks.NewAsyncProducer(t, saramaConfig)
+	mock.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		if headerMap(msg)["traceparent"] != testTraceparent {
+			return errors.New("missing traceparent")
+		}
+		return nil
+	})
+	mock.ExpectInputAndFail(sarama.ErrMessageSizeTooLarge)
+
+	var mu sync.Mutex
+	var reports []DeliveryReport
+	producer := newAsyncProducer(mock, newProducerSettings([]ProducerOption{
+		WithDeliveryHandler(func(report DeliveryReport) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, report)
+		}),
+	}))
+
+	ctx := tracedContext(t)
+	require.NoError(t, producer.SendBatch(ctx, []Message{
+		{Topic: "clicks", Key: "u-1", Value: []byte("ok")},
+		{Topic: "clicks", Key: "u-2", Value: []byte("too large")},
+	}))
+	require.NoError(t, producer.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, reports, 2)
+
+	byKey := map[string]DeliveryReport{}
+	for _, report := range reports {
+		byKey[report.Message.Key] = report
+	}
+	assert.NoError(t, byKey["u-1"].Err)
+	assert.Equal(t, testTraceparent, byKey["u-1"].Message.Headers["traceparent"])
+	assert.ErrorIs(t, byKey["u-2"].Err, sarama.ErrMessageSizeTooLarge)
+	assert.Equal(t, int64(-1), byKey["u-2"].Offset)
+
+	assert.ErrorIs(t, producer.Send(ctx, Message{Topic: "clicks"}), ErrProducerClosed)
+}
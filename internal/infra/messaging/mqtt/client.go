@@ -12,7 +12,10 @@
 // 核心功能：
 // - 发布消息：向指定主题发布消息
 // - 订阅主题：订阅主题并接收消息
-// - 连接管理：自动重连、心跳保持等
+// - 连接管理：自动重连、心跳保持、持久会话
+// - 离线队列：断开期间的发布消息缓存在队列中（可选文件持久化），重连后按顺序发布
+// - 主题路由：Router 按通配符过滤器将消息分发给多个处理函数
+// - 共享订阅：$share/group/filter，组内订阅者负载均衡
 //
 // 使用场景：
 // - IoT 设备通信：传感器数据采集、设备控制
@@ -44,6 +47,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -78,6 +84,7 @@ var defaultFactory mqttClientFactory = mqtt.NewClient
 // - 基于 Eclipse Paho MQTT Go 客户端
 // - 支持自动重连和连接保持
 // - 支持 QoS 0、1、2 三种消息质量等级
+// - 记录所有订阅，每次重连后自动重新订阅
 //
 // 使用示例：
 //
@@ -88,6 +95,22 @@ var defaultFactory mqttClientFactory = mqtt.NewClient
 //	defer client.Close()
 type Client struct {
 	client mqttClient
+	queue  OutboundQueue
+
+	mu               sync.Mutex
+	subscriptions    map[string]subscription
+	onConnect        []func()
+	onConnectionLost []func(error)
+
+	// flushMu 保证离线队列按顺序发布
+	flushMu sync.Mutex
+}
+
+// subscription 已订阅的主题，重连后用于重新订阅
+type subscription struct {
+	ctx     context.Context
+	qos     byte
+	handler MessageHandler
 }
 
 // MessageHandler 是消息处理函数的类型定义。
@@ -139,6 +162,7 @@ func resetMqttClientFactory() {
 //   用于标识客户端，相同 ID 的客户端会互相踢下线
 // - username: 用户名（可选，如果为空则不使用认证）
 // - password: 密码（可选，如果为空则不使用密码）
+// - opts: 可选配置，如持久会话、离线队列、连接回调
 //
 // 返回：
 // - *Client: 配置好的客户端实例
@@ -164,24 +188,51 @@ func resetMqttClientFactory() {
 //	    "password",
 //	)
 //
+//	// 持久会话 + 文件离线队列
+//	queue, _ := mqtt.NewFileQueue("./data/mqtt-outbox", 10000)
+//	client, err := mqtt.NewClient(broker, "gateway-01", "", "",
+//	    mqtt.WithPersistentSession("./data/mqtt-store"),
+//	    mqtt.WithOfflineQueue(queue),
+//	    mqtt.WithOnConnect(func() { log.Println("mqtt connected") }),
+//	)
+//
 // 注意事项：
 // - 确保 MQTT Broker 已启动并可访问
 // - 客户端 ID 应具有唯一性，避免冲突
 // - 生产环境建议使用 TLS/SSL 连接
 // - 应在应用程序生命周期中复用客户端实例
-func NewClient(broker, clientID, username, password string) (*Client, error) {
-	opts := mqtt.NewClientOptions()
-	opts.AddBroker(broker)                        // Broker 地址
-	opts.SetClientID(clientID)                    // 客户端 ID
-	opts.SetUsername(username)                    // 用户名
-	opts.SetPassword(password)                    // 密码
-	opts.SetAutoReconnect(true)                   // 自动重连
-	opts.SetConnectRetry(true)                    // 连接重试
-	opts.SetConnectRetryInterval(5 * time.Second) // 重试间隔
-	opts.SetKeepAlive(60 * time.Second)           // 心跳间隔
-	opts.SetPingTimeout(10 * time.Second)         // Ping 超时
-
-	client := defaultFactory(opts)
+func NewClient(broker, clientID, username, password string, opts ...Option) (*Client, error) {
+	var settings clientOptions
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	c := &Client{
+		queue:            settings.queue,
+		onConnect:        settings.onConnect,
+		onConnectionLost: settings.onConnectionLost,
+	}
+
+	clientOpts := mqtt.NewClientOptions()
+	clientOpts.AddBroker(broker)                        // Broker 地址
+	clientOpts.SetClientID(clientID)                    // 客户端 ID
+	clientOpts.SetUsername(username)                    // 用户名
+	clientOpts.SetPassword(password)                    // 密码
+	clientOpts.SetAutoReconnect(true)                   // 自动重连
+	clientOpts.SetConnectRetry(true)                    // 连接重试
+	clientOpts.SetConnectRetryInterval(5 * time.Second) // 重试间隔
+	clientOpts.SetKeepAlive(60 * time.Second)           // 心跳间隔
+	clientOpts.SetPingTimeout(10 * time.Second)         // Ping 超时
+	clientOpts.SetOnConnectHandler(func(mqtt.Client) {  // 每次（重新）连接后
+		c.handleConnect()
+	})
+	clientOpts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		c.handleConnectionLost(err)
+	})
+	settings.apply(clientOpts)
+
+	client := defaultFactory(clientOpts)
+	c.client = client
 
 	// 连接到 Broker
 	token := client.Connect()
@@ -189,7 +240,7 @@ func NewClient(broker, clientID, username, password string) (*Client, error) {
 		return nil, fmt.Errorf("failed to connect: %w", token.Error())
 	}
 
-	return &Client{client: client}, nil
+	return c, nil
 }
 
 // newClientWithClient creates a Client with a pre-existing mqtt client (for testing)
@@ -248,6 +299,37 @@ func newClientWithClient(c mqttClient) *Client {
 // - QoS 1 保证消息至少到达一次，但可能重复
 // - QoS 2 保证消息恰好到达一次，但性能较低
 // - 保留消息会占用 Broker 存储空间
+// - 配置了离线队列时，连接断开期间的消息进入队列并返回 nil，重连后按顺序发布
+// - 离线队列中还有积压时，新消息也进入队列，保持发布顺序
+func (c *Client) Publish(ctx context.Context, topic string, qos byte, retained bool, payload interface{}) error {
+	data, err := convertPayload(payload)
+	if err != nil {
+		return err
+	}
+
+	if c.queue != nil && (!c.client.IsConnected() || c.queue.Len() > 0) {
+		if err := c.enqueue(topic, qos, retained, data); err != nil {
+			return err
+		}
+		if c.client.IsConnected() {
+			c.flush()
+		}
+		return nil
+	}
+
+	// 发布消息
+	token := c.client.Publish(topic, qos, retained, data)
+	if token.Wait() && token.Error() != nil {
+		// 发布过程中连接断开，放入离线队列等待重连
+		if c.queue != nil && !c.client.IsConnected() {
+			return c.enqueue(topic, qos, retained, data)
+		}
+		return fmt.Errorf("failed to publish: %w", token.Error())
+	}
+
+	return nil
+}
+
 // convertPayload 将不同类型的 payload 转换为字节数组
 // 该函数提取出来以便进行单元测试
 func convertPayload(payload interface{}) ([]byte, error) {
@@ -268,21 +350,6 @@ func convertPayload(payload interface{}) ([]byte, error) {
 	}
 }
 
-func (c *Client) Publish(ctx context.Context, topic string, qos byte, retained bool, payload interface{}) error {
-	data, err := convertPayload(payload)
-	if err != nil {
-		return err
-	}
-
-	// 发布消息
-	token := c.client.Publish(topic, qos, retained, data)
-	if token.Wait() && token.Error() != nil {
-		return fmt.Errorf("failed to publish: %w", token.Error())
-	}
-
-	return nil
-}
-
 // Subscribe 订阅 MQTT 主题并设置消息处理函数。
 //
 // 功能说明：
@@ -326,25 +393,72 @@ func (c *Client) Publish(ctx context.Context, topic string, qos byte, retained b
 // - 处理函数中的错误会被记录，但不会中断订阅
 // - 可以多次订阅不同的主题
 // - 使用 Unsubscribe 取消订阅
+// - 订阅会被记录，每次重连后自动重新订阅
+// - 需要把一个订阅的消息分发给多个处理函数时，使用 Router.HandleMessage 作为处理函数
 func (c *Client) Subscribe(ctx context.Context, topic string, qos byte, handler MessageHandler) error {
+	sub := subscription{ctx: ctx, qos: qos, handler: handler}
+
 	// 订阅主题并设置消息回调
-	token := c.client.Subscribe(topic, qos, func(client mqtt.Client, msg mqtt.Message) {
-		// 调用处理函数处理消息
-		if err := handler(ctx, msg.Topic(), msg.Payload()); err != nil {
-			// 记录错误，但不中断订阅
-			// 生产环境应使用日志库记录错误
-			fmt.Printf("Error handling message: %v\n", err)
-		}
-	})
+	token := c.client.Subscribe(topic, qos, sub.callback)
 
 	// 等待订阅完成
 	if token.Wait() && token.Error() != nil {
 		return fmt.Errorf("failed to subscribe: %w", token.Error())
 	}
 
+	c.mu.Lock()
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[string]subscription)
+	}
+	c.subscriptions[topic] = sub
+	c.mu.Unlock()
+
 	return nil
 }
 
+// SubscribeShared 以共享订阅方式订阅主题。
+//
+// 功能说明：
+// - 订阅 $share/group/filter，同一共享组内的订阅者负载均衡地接收消息
+// - 适合多个实例共同处理同一个主题的场景（如多个 worker 消费设备上报）
+//
+// 参数：
+// - ctx: 上下文，传递给处理函数
+// - group: 共享组名称，不能为空，不能包含 '/'、'+'、'#'
+// - filter: 主题过滤器（支持通配符）
+// - qos: 订阅的 QoS 等级
+// - handler: 消息处理函数，收到的 topic 为消息的实际主题
+//
+// 返回：
+// - error: 共享组或过滤器不合法时返回 ErrInvalidFilter，订阅失败时返回错误
+//
+// 使用示例：
+//
+//	err := client.SubscribeShared(ctx, "ingest-workers", "devices/+/telemetry", 1, handler)
+//
+// 注意事项：
+// - 需要 Broker 支持共享订阅（Mosquitto 1.6+、EMQX、HiveMQ 等）
+// - 取消订阅时使用 SharedTopic(group, filter) 作为主题
+func (c *Client) SubscribeShared(ctx context.Context, group, filter string, qos byte, handler MessageHandler) error {
+	if group == "" || strings.ContainsAny(group, "/+#") {
+		return fmt.Errorf("%w: invalid share group %q", ErrInvalidFilter, group)
+	}
+	topic := SharedTopic(group, filter)
+	if err := ValidateFilter(topic); err != nil {
+		return err
+	}
+	return c.Subscribe(ctx, topic, qos, handler)
+}
+
+// callback 返回 paho 消息回调
+func (s subscription) callback(client mqtt.Client, msg mqtt.Message) {
+	// 调用处理函数处理消息
+	if err := s.handler(s.ctx, msg.Topic(), msg.Payload()); err != nil {
+		// 记录错误，但不中断订阅
+		slog.Error("Error handling MQTT message", "topic", msg.Topic(), "error", err)
+	}
+}
+
 // Unsubscribe 取消订阅一个或多个 MQTT 主题。
 //
 // 功能说明：
@@ -377,9 +491,110 @@ func (c *Client) Unsubscribe(ctx context.Context, topics ...string) error {
 	if token.Wait() && token.Error() != nil {
 		return fmt.Errorf("failed to unsubscribe: %w", token.Error())
 	}
+
+	c.mu.Lock()
+	for _, topic := range topics {
+		delete(c.subscriptions, topic)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// QueueLen 返回离线队列中等待发布的消息数（未配置离线队列时为 0）
+func (c *Client) QueueLen() int {
+	if c.queue == nil {
+		return 0
+	}
+	return c.queue.Len()
+}
+
+// handleConnect 连接（包括重连）成功后重新订阅、发布离线队列并调用回调
+func (c *Client) handleConnect() {
+	c.resubscribe()
+	c.flush()
+
+	c.mu.Lock()
+	hooks := append([]func(){}, c.onConnect...)
+	c.mu.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// handleConnectionLost 连接断开时调用回调
+func (c *Client) handleConnectionLost(err error) {
+	slog.Warn("MQTT connection lost", "error", err)
+
+	c.mu.Lock()
+	hooks := append([]func(error){}, c.onConnectionLost...)
+	c.mu.Unlock()
+	for _, hook := range hooks {
+		hook(err)
+	}
+}
+
+// resubscribe 重新订阅所有已记录的主题
+func (c *Client) resubscribe() {
+	c.mu.Lock()
+	subs := make(map[string]subscription, len(c.subscriptions))
+	for topic, sub := range c.subscriptions {
+		subs[topic] = sub
+	}
+	c.mu.Unlock()
+
+	for topic, sub := range subs {
+		token := c.client.Subscribe(topic, sub.qos, sub.callback)
+		if token.Wait() && token.Error() != nil {
+			slog.Error("MQTT resubscribe failed", "topic", topic, "error", token.Error())
+		}
+	}
+}
+
+// enqueue 将消息放入离线队列
+func (c *Client) enqueue(topic string, qos byte, retained bool, data []byte) error {
+	err := c.queue.Push(QueuedMessage{
+		Topic:    topic,
+		QoS:      qos,
+		Retained: retained,
+		Payload:  data,
+		QueuedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
 	return nil
 }
 
+// flush 按顺序发布离线队列中的消息，连接断开或发布失败时停止
+func (c *Client) flush() {
+	if c.queue == nil {
+		return
+	}
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	for c.client.IsConnected() {
+		msg, ok, err := c.queue.Peek()
+		if err != nil {
+			slog.Error("MQTT offline queue read failed", "error", err)
+			return
+		}
+		if !ok {
+			return
+		}
+
+		token := c.client.Publish(msg.Topic, msg.QoS, msg.Retained, msg.Payload)
+		if token.Wait() && token.Error() != nil {
+			slog.Warn("MQTT offline queue publish failed", "topic", msg.Topic, "error", token.Error())
+			return
+		}
+		if err := c.queue.Pop(); err != nil {
+			slog.Error("MQTT offline queue remove failed", "error", err)
+			return
+		}
+	}
+}
+
 // Close 关闭 MQTT 客户端连接。
 //
 // 功能说明：
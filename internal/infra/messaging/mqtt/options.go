@@ -0,0 +1,75 @@
+package mqtt
+
+import (
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// clientOptions 客户端可选配置
+type clientOptions struct {
+	persistent       bool
+	storeDir         string
+	queue            OutboundQueue
+	onConnect        []func()
+	onConnectionLost []func(error)
+}
+
+// Option 客户端配置选项
+type Option func(*clientOptions)
+
+// WithPersistentSession 使用持久会话
+//
+// 设计原理：
+// 1. 连接时 CleanSession 为 false，Broker 在断开期间保留订阅和 QoS 1/2 消息
+// 2. storeDir 不为空时，未确认的 QoS 1/2 消息（in-flight）保存到文件，进程重启后继续投递
+//
+// 注意事项：
+// - 持久会话以 clientID 区分，clientID 必须固定且唯一
+// - 即使使用持久会话，客户端也会在重连后重新订阅，保证处理函数与 Broker 订阅一致
+func WithPersistentSession(storeDir string) Option {
+	return func(o *clientOptions) {
+		o.persistent = true
+		o.storeDir = storeDir
+	}
+}
+
+// WithOfflineQueue 设置离线发布队列
+//
+// 连接断开时 Publish 将消息放入队列并返回 nil，重新连接后按顺序发布。
+// 使用 NewFileQueue 时，进程重启后队列中的消息会在首次连接后发布。
+//
+// 示例：
+//
+//	queue, err := mqtt.NewFileQueue("./data/mqtt-outbox", 10000)
+//	client, err := mqtt.NewClient(broker, "gateway-01", "", "", mqtt.WithOfflineQueue(queue))
+func WithOfflineQueue(queue OutboundQueue) Option {
+	return func(o *clientOptions) {
+		o.queue = queue
+	}
+}
+
+// WithOnConnect 添加连接（包括每次重连）成功后的回调
+//
+// 回调在重新订阅和发布离线队列之后调用
+func WithOnConnect(handler func()) Option {
+	return func(o *clientOptions) {
+		o.onConnect = append(o.onConnect, handler)
+	}
+}
+
+// WithOnConnectionLost 添加连接断开时的回调
+func WithOnConnectionLost(handler func(err error)) Option {
+	return func(o *clientOptions) {
+		o.onConnectionLost = append(o.onConnectionLost, handler)
+	}
+}
+
+// apply 将会话配置写入 paho 选项
+func (o *clientOptions) apply(opts *mqtt.ClientOptions) {
+	if o.persistent {
+		opts.SetCleanSession(false)
+		opts.SetResumeSubs(true)
+		if o.storeDir != "" {
+			opts.SetStore(mqtt.NewFileStore(o.storeDir))
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publishedMessage 记录 mock 客户端发布的消息
+type publishedMessage struct {
+	topic   string
+	payload []byte
+}
+
+// newSessionClient 使用 mock 工厂创建客户端，返回 mock 和 paho 选项
+func newSessionClient(t *testing.T, opts ...Option) (*Client, *mockMqttClient, *mqtt.ClientOptions, *[]publishedMessage, map[string]int) {
+	t.Helper()
+
+	originalFactory := defaultFactory
+	t.Cleanup(func() { defaultFactory = originalFactory })
+
+	var published []publishedMessage
+	subscribed := map[string]int{}
+	mock := newMockMqttClient()
+	mock.publishFunc = func(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+		published = append(published, publishedMessage{topic: topic, payload: payload.([]byte)})
+		return newMockToken(nil)
+	}
+	mock.subscribeFunc = func(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+		subscribed[topic]++
+		return newMockToken(nil)
+	}
+
+	var options *mqtt.ClientOptions
+	defaultFactory = func(o *mqtt.ClientOptions) mqtt.Client {
+		options = o
+		return mock
+	}
+
+	client, err := NewClient("tcp://localhost:1883", "session-client", "", "", opts...)
+	require.NoError(t, err)
+	return client, mock, options, &published, subscribed
+}
+
+// TestClient_PersistentSessionOptions 测试持久会话选项
+func TestClient_PersistentSessionOptions(t *testing.T) {
+	_, _, options, _, _ := newSessionClient(t, WithPersistentSession(t.TempDir()))
+	assert.False(t, options.CleanSession)
+	assert.True(t, options.ResumeSubs)
+	assert.NotNil(t, options.Store)
+
+	_, _, options, _, _ = newSessionClient(t)
+	assert.True(t, options.CleanSession)
+}
+
+// TestClient_ReconnectResubscribesAndFlushes 测试重连后重新订阅并发布离线消息
+func TestClient_ReconnectResubscribesAndFlushes(t *testing.T) {
+	var connects, lost int
+	client, mock, options, published, subscribed := newSessionClient(t,
+		WithOfflineQueue(NewMemoryQueue(0)),
+		WithOnConnect(func() { connects++ }),
+		WithOnConnectionLost(func(err error) { lost++ }),
+	)
+	ctx := context.Background()
+
+	handler := func(ctx context.Context, topic string, payload []byte) error { return nil }
+	require.NoError(t, client.Subscribe(ctx, "sensors/#", 1, handler))
+	require.NoError(t, client.SubscribeShared(ctx, "workers", "jobs/+", 1, handler))
+	require.NoError(t, client.Subscribe(ctx, "tmp/topic", 0, handler))
+	require.NoError(t, client.Unsubscribe(ctx, "tmp/topic"))
+
+	// 断开连接：消息进入离线队列
+	mock.connected = false
+	options.OnConnectionLost(mock, errors.New("network down"))
+	require.NoError(t, client.Publish(ctx, "status", 1, false, "first"))
+	require.NoError(t, client.Publish(ctx, "status", 1, false, "second"))
+	assert.Empty(t, *published)
+	assert.Equal(t, 2, client.QueueLen())
+	assert.Equal(t, 1, lost)
+
+	// 重新连接：重新订阅、按顺序发布离线消息、调用回调
+	mock.connected = true
+	options.OnConnect(mock)
+
+	assert.Equal(t, 2, subscribed["sensors/#"])
+	assert.Equal(t, 2, subscribed["$share/workers/jobs/+"])
+	assert.Equal(t, 1, subscribed["tmp/topic"])
+	require.Len(t, *published, 2)
+	assert.Equal(t, "first", string((*published)[0].payload))
+	assert.Equal(t, "second", string((*published)[1].payload))
+	assert.Equal(t, 0, client.QueueLen())
+	assert.Equal(t, 1, connects)
+
+	// 连接正常时直接发布
+	require.NoError(t, client.Publish(ctx, "status", 1, false, "third"))
+	assert.Len(t, *published, 3)
+}
+
+// TestClient_PublishFailureKeepsQueueOrder 测试发布失败时消息保留在队列中
+func TestClient_PublishFailureKeepsQueueOrder(t *testing.T) {
+	queue := NewMemoryQueue(0)
+	client, mock, options, _, _ := newSessionClient(t, WithOfflineQueue(queue))
+	ctx := context.Background()
+
+	mock.connected = false
+	require.NoError(t, client.Publish(ctx, "status", 1, false, "queued"))
+
+	// 重连后发布失败，消息仍在队列中
+	mock.publishFunc = func(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+		return newMockToken(errors.New("publish failed"))
+	}
+	mock.connected = true
+	options.OnConnect(mock)
+	assert.Equal(t, 1, queue.Len())
+
+	// 队列有积压时，新消息排在后面
+	require.NoError(t, client.Publish(ctx, "status", 1, false, "next"))
+	assert.Equal(t, 2, queue.Len())
+	msg, _, err := queue.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "queued", string(msg.Payload))
+}
+
+// TestClient_OfflineWithoutQueue 测试未配置离线队列时保持原有行为
+func TestClient_OfflineWithoutQueue(t *testing.T) {
+	mock := newMockClientWithErrors(errors.New("not connected"), nil, nil)
+	mock.connected = false
+	client := mockClient(mock)
+
+	err := client.Publish(context.Background(), "status", 1, false, "data")
+	assert.Error(t, err)
+	assert.Equal(t, 0, client.QueueLen())
+}
+
+// TestClient_SubscribeShared_InvalidGroup 测试共享组校验
+func TestClient_SubscribeShared_InvalidGroup(t *testing.T) {
+	client := mockClient(newMockMqttClient())
+	handler := func(ctx context.Context, topic string, payload []byte) error { return nil }
+
+	assert.ErrorIs(t, client.SubscribeShared(context.Background(), "", "jobs/#", 1, handler), ErrInvalidFilter)
+	assert.ErrorIs(t, client.SubscribeShared(context.Background(), "a/b", "jobs/#", 1, handler), ErrInvalidFilter)
+	assert.ErrorIs(t, client.SubscribeShared(context.Background(), "workers", "jobs/#/x", 1, handler), ErrInvalidFilter)
+}
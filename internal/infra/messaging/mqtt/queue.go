@@ -0,0 +1,270 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull 离线队列已满
+var ErrQueueFull = errors.New("mqtt: offline queue is full")
+
+// QueuedMessage 离线队列中的待发布消息
+type QueuedMessage struct {
+	Topic    string    `json:"topic"`
+	QoS      byte      `json:"qos"`
+	Retained bool      `json:"retained"`
+	Payload  []byte    `json:"payload"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// OutboundQueue 离线发布队列
+//
+// 设计原理：
+// 1. 连接断开时 Publish 将消息放入队列，而不是返回错误
+// 2. 重新连接后按入队顺序发布，发布成功后才从队列中移除（至少一次）
+// 3. 实现需要并发安全
+//
+// 实现：
+// - NewMemoryQueue: 内存队列，进程重启后丢失
+// - NewFileQueue: 文件队列，每条消息一个文件，进程重启后继续发布
+type OutboundQueue interface {
+	// Push 将消息追加到队尾，队列已满时返回 ErrQueueFull
+	Push(msg QueuedMessage) error
+
+	// Peek 返回队首消息，队列为空时 ok 为 false
+	Peek() (msg QueuedMessage, ok bool, err error)
+
+	// Pop 移除队首消息
+	Pop() error
+
+	// Len 返回队列中的消息数
+	Len() int
+}
+
+// MemoryQueue 内存离线队列
+type MemoryQueue struct {
+	mu       sync.Mutex
+	messages []QueuedMessage
+	max      int
+}
+
+// NewMemoryQueue 创建内存离线队列
+//
+// 参数：
+//   - max: 最多缓存的消息数，<= 0 表示不限制
+func NewMemoryQueue(max int) *MemoryQueue {
+	return &MemoryQueue{max: max}
+}
+
+// Push 将消息追加到队尾
+func (q *MemoryQueue) Push(msg QueuedMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.max > 0 && len(q.messages) >= q.max {
+		return ErrQueueFull
+	}
+	q.messages = append(q.messages, msg)
+	return nil
+}
+
+// Peek 返回队首消息
+func (q *MemoryQueue) Peek() (QueuedMessage, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.messages) == 0 {
+		return QueuedMessage{}, false, nil
+	}
+	return q.messages[0], true, nil
+}
+
+// Pop 移除队首消息
+func (q *MemoryQueue) Pop() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.messages) > 0 {
+		q.messages[0] = QueuedMessage{}
+		q.messages = q.messages[1:]
+	}
+	return nil
+}
+
+// Len 返回队列中的消息数
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+const (
+	// fileQueueExt 文件队列中消息文件的扩展名
+	fileQueueExt = ".msg"
+	// fileQueueCorruptDir 无法解码的消息文件被移入的隔离子目录
+	fileQueueCorruptDir = "corrupt"
+)
+
+// FileQueue 基于文件的离线队列
+//
+// 设计原理：
+// 1. 每条消息保存为一个 JSON 文件，文件名为递增序号，按文件名排序即为入队顺序
+// 2. 先写临时文件再重命名，进程崩溃时不会留下不完整的消息
+// 3. 打开时扫描目录恢复队列，进程重启后未发布的消息不会丢失
+// 4. 无法解码的消息文件移入 corrupt 子目录并记录日志，不会阻塞后续消息的发布
+type FileQueue struct {
+	mu   sync.Mutex
+	dir  string
+	max  int
+	seqs []uint64 // 队列中消息的序号（升序）
+	next uint64
+}
+
+// NewFileQueue 创建（或打开已有的）文件离线队列
+//
+// 参数：
+//   - dir: 队列目录，不存在时自动创建；每个客户端应该使用单独的目录
+//   - max: 最多缓存的消息数，<= 0 表示不限制
+//
+// 返回：
+//   - *FileQueue: 文件队列
+//   - error: 目录无法创建或读取时返回错误
+func NewFileQueue(dir string, max int) (*FileQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue directory: %w", err)
+	}
+
+	q := &FileQueue{dir: dir, max: max}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileQueueExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, fileQueueExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.seqs = append(q.seqs, seq)
+	}
+	sort.Slice(q.seqs, func(i, j int) bool { return q.seqs[i] < q.seqs[j] })
+	if n := len(q.seqs); n > 0 {
+		q.next = q.seqs[n-1] + 1
+	}
+	return q, nil
+}
+
+// Push 将消息写入文件并追加到队尾
+func (q *FileQueue) Push(msg QueuedMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode queued message: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.max > 0 && len(q.seqs) >= q.max {
+		return ErrQueueFull
+	}
+
+	seq := q.next
+	tmp := filepath.Join(q.dir, q.fileName(seq)+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write queued message: %w", err)
+	}
+	if err := os.Rename(tmp, q.path(seq)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to commit queued message: %w", err)
+	}
+
+	q.seqs = append(q.seqs, seq)
+	q.next++
+	return nil
+}
+
+// Peek 读取队首消息
+//
+// 注意事项：
+// - 文件已被外部删除时跳过该消息
+// - 文件内容无法解码时移入隔离目录后继续读取下一条，只有隔离失败时才返回错误
+func (q *FileQueue) Peek() (QueuedMessage, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.seqs) > 0 {
+		seq := q.seqs[0]
+		data, err := os.ReadFile(q.path(seq))
+		if errors.Is(err, os.ErrNotExist) {
+			q.seqs = q.seqs[1:]
+			continue
+		}
+		if err != nil {
+			return QueuedMessage{}, false, fmt.Errorf("failed to read queued message: %w", err)
+		}
+
+		var msg QueuedMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			if qerr := q.quarantine(seq); qerr != nil {
+				return QueuedMessage{}, false, qerr
+			}
+			slog.Warn("MQTT offline queue message quarantined", "file", q.fileName(seq)+fileQueueExt, "error", err)
+			q.seqs = q.seqs[1:]
+			continue
+		}
+		return msg, true, nil
+	}
+	return QueuedMessage{}, false, nil
+}
+
+// quarantine 将无法解码的消息文件移入隔离目录
+func (q *FileQueue) quarantine(seq uint64) error {
+	dir := filepath.Join(q.dir, fileQueueCorruptDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+	name := q.fileName(seq) + fileQueueExt
+	if err := os.Rename(q.path(seq), filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("failed to quarantine queued message: %w", err)
+	}
+	return nil
+}
+
+// Pop 删除队首消息的文件
+func (q *FileQueue) Pop() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.seqs) == 0 {
+		return nil
+	}
+	if err := os.Remove(q.path(q.seqs[0])); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove queued message: %w", err)
+	}
+	q.seqs = q.seqs[1:]
+	return nil
+}
+
+// Len 返回队列中的消息数
+func (q *FileQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.seqs)
+}
+
+// fileName 返回序号对应的文件名（定长，按字典序排序即为数字顺序）
+func (q *FileQueue) fileName(seq uint64) string {
+	return fmt.Sprintf("%020d", seq)
+}
+
+// path 返回序号对应的文件路径
+func (q *FileQueue) path(seq uint64) string {
+	return filepath.Join(q.dir, q.fileName(seq)+fileQueueExt)
+}
@@ -0,0 +1,111 @@
+package mqtt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testQueues 返回需要测试的离线队列实现
+func testQueues(t *testing.T, max int) map[string]OutboundQueue {
+	fileQueue, err := NewFileQueue(t.TempDir(), max)
+	require.NoError(t, err)
+	return map[string]OutboundQueue{
+		"memory": NewMemoryQueue(max),
+		"file":   fileQueue,
+	}
+}
+
+// TestOutboundQueue_FIFO 测试队列先进先出和容量限制
+func TestOutboundQueue_FIFO(t *testing.T) {
+	for name, queue := range testQueues(t, 2) {
+		t.Run(name, func(t *testing.T) {
+			_, ok, err := queue.Peek()
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			require.NoError(t, queue.Push(QueuedMessage{Topic: "a", QoS: 1, Payload: []byte("1")}))
+			require.NoError(t, queue.Push(QueuedMessage{Topic: "b", Retained: true, Payload: []byte("2")}))
+			assert.ErrorIs(t, queue.Push(QueuedMessage{Topic: "c"}), ErrQueueFull)
+			assert.Equal(t, 2, queue.Len())
+
+			msg, ok, err := queue.Peek()
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, "a", msg.Topic)
+			assert.Equal(t, byte(1), msg.QoS)
+			assert.Equal(t, []byte("1"), msg.Payload)
+
+			require.NoError(t, queue.Pop())
+			msg, _, err = queue.Peek()
+			require.NoError(t, err)
+			assert.Equal(t, "b", msg.Topic)
+			assert.True(t, msg.Retained)
+
+			require.NoError(t, queue.Pop())
+			assert.Equal(t, 0, queue.Len())
+			assert.NoError(t, queue.Pop())
+		})
+	}
+}
+
+// TestFileQueue_SurvivesRestart 测试文件队列在重新打开后恢复
+func TestFileQueue_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	queue, err := NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	for _, topic := range []string{"a", "b", "c"} {
+		require.NoError(t, queue.Push(QueuedMessage{Topic: topic}))
+	}
+	require.NoError(t, queue.Pop())
+
+	reopened, err := NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+
+	// 新消息排在恢复的消息之后
+	require.NoError(t, reopened.Push(QueuedMessage{Topic: "d"}))
+
+	var topics []string
+	for {
+		msg, ok, err := reopened.Peek()
+		require.NoError(t, err)
+		if !ok {
+			break
+		}
+		topics = append(topics, msg.Topic)
+		require.NoError(t, reopened.Pop())
+	}
+	assert.Equal(t, []string{"b", "c", "d"}, topics)
+}
+
+// TestFileQueue_QuarantinesCorruptMessage 测试无法解码的消息被隔离且不阻塞队列
+func TestFileQueue_QuarantinesCorruptMessage(t *testing.T) {
+	dir := t.TempDir()
+
+	queue, err := NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	for _, topic := range []string{"a", "b"} {
+		require.NoError(t, queue.Push(QueuedMessage{Topic: topic}))
+	}
+	corrupt := queue.path(0)
+	require.NoError(t, os.WriteFile(corrupt, []byte("not json"), 0o644))
+
+	msg, ok, err := queue.Peek()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "b", msg.Topic)
+	assert.Equal(t, 1, queue.Len())
+
+	assert.NoFileExists(t, corrupt)
+	assert.FileExists(t, filepath.Join(dir, fileQueueCorruptDir, filepath.Base(corrupt)))
+
+	// 重新打开时不会加载隔离目录中的文件
+	reopened, err := NewFileQueue(dir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reopened.Len())
+}
@@ -0,0 +1,195 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// sharePrefix 共享订阅前缀
+const sharePrefix = "$share/"
+
+// ErrInvalidFilter 主题过滤器格式不正确
+var ErrInvalidFilter = errors.New("mqtt: invalid topic filter")
+
+// SharedTopic 返回共享订阅主题
+//
+// 同一个共享组内的订阅者负载均衡地接收消息，每条消息只投递给组内一个订阅者。
+// 共享订阅是 MQTT 5 的特性，Mosquitto、EMQX、HiveMQ 等 Broker 对 MQTT 3.1.1 客户端同样支持。
+//
+// 示例：
+//
+//	mqtt.SharedTopic("workers", "jobs/#") // "$share/workers/jobs/#"
+func SharedTopic(group, filter string) string {
+	return sharePrefix + group + "/" + filter
+}
+
+// splitShared 拆分共享订阅主题，返回共享组和实际的过滤器
+//
+// 非共享订阅返回空的共享组和原过滤器
+func splitShared(filter string) (group, topicFilter string) {
+	if !strings.HasPrefix(filter, sharePrefix) {
+		return "", filter
+	}
+	rest := filter[len(sharePrefix):]
+	idx := strings.IndexByte(rest, '/')
+	if idx < 0 {
+		return rest, ""
+	}
+	return rest[:idx], rest[idx+1:]
+}
+
+// ValidateFilter 校验主题过滤器
+//
+// 规则：
+// - 过滤器不能为空
+// - '+' 必须独占一个层级
+// - '#' 必须独占最后一个层级
+// - 共享订阅的共享组不能为空，且不能包含通配符
+func ValidateFilter(filter string) error {
+	group, topicFilter := splitShared(filter)
+	if strings.HasPrefix(filter, sharePrefix) && (group == "" || strings.ContainsAny(group, "+#")) {
+		return fmt.Errorf("%w: %q has an invalid share group", ErrInvalidFilter, filter)
+	}
+	if topicFilter == "" {
+		return fmt.Errorf("%w: empty filter", ErrInvalidFilter)
+	}
+
+	levels := strings.Split(topicFilter, "/")
+	for i, level := range levels {
+		if strings.Contains(level, "#") && (level != "#" || i != len(levels)-1) {
+			return fmt.Errorf("%w: %q, '#' must be the last level", ErrInvalidFilter, filter)
+		}
+		if strings.Contains(level, "+") && level != "+" {
+			return fmt.Errorf("%w: %q, '+' must occupy an entire level", ErrInvalidFilter, filter)
+		}
+	}
+	return nil
+}
+
+// MatchTopic 判断主题是否匹配过滤器
+//
+// 匹配规则（MQTT 规范 4.7）：
+// - '+' 匹配一个层级，'#' 匹配剩余的零个或多个层级（"a/#" 同时匹配 "a"）
+// - 以 '$' 开头的主题（如 $SYS/...）不匹配以通配符开头的过滤器
+// - 共享订阅过滤器（$share/group/filter）按其中的 filter 匹配
+func MatchTopic(filter, topic string) bool {
+	_, filter = splitShared(filter)
+	if filter == "" || topic == "" {
+		return false
+	}
+	if strings.HasPrefix(topic, "$") && (filter[0] == '+' || filter[0] == '#') {
+		return false
+	}
+
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// route 路由规则
+type route struct {
+	filter  string
+	handler MessageHandler
+}
+
+// Router 按主题过滤器将消息分发给多个处理函数
+//
+// 设计原理：
+// 1. 一个 Broker 订阅（如 "sensors/#"）接收消息，再在本地按更细的过滤器分发
+// 2. 一条消息匹配多个过滤器时，按注册顺序依次调用所有匹配的处理函数
+// 3. 没有匹配的过滤器时调用 NotFound 处理函数（如果设置）
+//
+// 相比为每个过滤器单独向 Broker 订阅，过滤器重叠时不会收到重复消息。
+//
+// 示例：
+//
+//	router := mqtt.NewRouter()
+//	router.Handle("sensors/+/temperature", temperatureHandler)
+//	router.Handle("sensors/+/humidity", humidityHandler)
+//	router.Handle("sensors/#", auditHandler)
+//
+//	err := client.Subscribe(ctx, "sensors/#", 1, router.HandleMessage)
+type Router struct {
+	mu       sync.RWMutex
+	routes   []route
+	notFound MessageHandler
+}
+
+// NewRouter 创建路由器
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle 注册过滤器的处理函数
+//
+// 返回：
+//   - error: 过滤器格式不正确时返回 ErrInvalidFilter
+func (r *Router) Handle(filter string, handler MessageHandler) error {
+	if err := ValidateFilter(filter); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes = append(r.routes, route{filter: filter, handler: handler})
+	return nil
+}
+
+// Remove 移除过滤器的所有处理函数
+func (r *Router) Remove(filter string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	routes := r.routes[:0]
+	for _, rt := range r.routes {
+		if rt.filter != filter {
+			routes = append(routes, rt)
+		}
+	}
+	r.routes = routes
+}
+
+// NotFound 设置没有匹配的过滤器时的处理函数
+func (r *Router) NotFound(handler MessageHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notFound = handler
+}
+
+// HandleMessage 分发消息，签名与 MessageHandler 一致，可以直接用于 Client.Subscribe
+//
+// 返回：
+//   - error: 所有处理函数返回的错误（errors.Join）
+func (r *Router) HandleMessage(ctx context.Context, topic string, payload []byte) error {
+	r.mu.RLock()
+	var handlers []MessageHandler
+	for _, rt := range r.routes {
+		if MatchTopic(rt.filter, topic) {
+			handlers = append(handlers, rt.handler)
+		}
+	}
+	if len(handlers) == 0 && r.notFound != nil {
+		handlers = append(handlers, r.notFound)
+	}
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, topic, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,99 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMatchTopic 测试主题过滤器匹配
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{"sensors/temperature", "sensors/temperature", true},
+		{"sensors/temperature", "sensors/humidity", false},
+		{"sensors/+/temperature", "sensors/room1/temperature", true},
+		{"sensors/+/temperature", "sensors/room1/a/temperature", false},
+		{"sensors/+", "sensors", false},
+		{"sensors/#", "sensors", true},
+		{"sensors/#", "sensors/room1/temperature", true},
+		{"#", "sensors/room1", true},
+		{"+/+", "a/b", true},
+		{"+", "/a", false},
+		{"#", "$SYS/broker/uptime", false},
+		{"+/broker/uptime", "$SYS/broker/uptime", false},
+		{"$SYS/#", "$SYS/broker/uptime", true},
+		{"$share/workers/jobs/#", "jobs/1", true},
+		{"$share/workers/jobs/+", "other/1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter+" "+tt.topic, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchTopic(tt.filter, tt.topic))
+		})
+	}
+}
+
+// TestValidateFilter 测试过滤器校验
+func TestValidateFilter(t *testing.T) {
+	valid := []string{"a", "a/b", "a/+/c", "a/#", "#", "+", "$share/g/a/#"}
+	for _, filter := range valid {
+		assert.NoError(t, ValidateFilter(filter), filter)
+	}
+
+	invalid := []string{"", "a/#/b", "a/b#", "a/b+", "$share//a", "$share/g", "$share/g+/a"}
+	for _, filter := range invalid {
+		assert.ErrorIs(t, ValidateFilter(filter), ErrInvalidFilter, filter)
+	}
+}
+
+// TestSharedTopic 测试共享订阅主题
+func TestSharedTopic(t *testing.T) {
+	assert.Equal(t, "$share/workers/jobs/#", SharedTopic("workers", "jobs/#"))
+
+	group, filter := splitShared("$share/workers/jobs/#")
+	assert.Equal(t, "workers", group)
+	assert.Equal(t, "jobs/#", filter)
+}
+
+// TestRouter_Dispatch 测试路由器分发
+func TestRouter_Dispatch(t *testing.T) {
+	router := NewRouter()
+	var calls []string
+	record := func(name string, err error) MessageHandler {
+		return func(ctx context.Context, topic string, payload []byte) error {
+			calls = append(calls, name+":"+topic)
+			return err
+		}
+	}
+
+	errHumidity := errors.New("humidity failed")
+	require.NoError(t, router.Handle("sensors/+/temperature", record("temp", nil)))
+	require.NoError(t, router.Handle("sensors/+/humidity", record("humidity", errHumidity)))
+	require.NoError(t, router.Handle("sensors/#", record("audit", nil)))
+	assert.ErrorIs(t, router.Handle("sensors/#/x", record("bad", nil)), ErrInvalidFilter)
+	router.NotFound(record("notfound", nil))
+
+	ctx := context.Background()
+	assert.NoError(t, router.HandleMessage(ctx, "sensors/room1/temperature", nil))
+	assert.Equal(t, []string{"temp:sensors/room1/temperature", "audit:sensors/room1/temperature"}, calls)
+
+	calls = nil
+	assert.ErrorIs(t, router.HandleMessage(ctx, "sensors/room1/humidity", nil), errHumidity)
+	assert.Equal(t, []string{"humidity:sensors/room1/humidity", "audit:sensors/room1/humidity"}, calls)
+
+	calls = nil
+	assert.NoError(t, router.HandleMessage(ctx, "devices/1/status", nil))
+	assert.Equal(t, []string{"notfound:devices/1/status"}, calls)
+
+	router.Remove("sensors/#")
+	calls = nil
+	assert.NoError(t, router.HandleMessage(ctx, "sensors/room1/temperature", nil))
+	assert.Equal(t, []string{"temp:sensors/room1/temperature"}, calls)
+}
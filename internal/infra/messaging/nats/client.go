@@ -14,6 +14,7 @@
 // - 订阅主题：订阅主题并接收消息
 // - Request/Reply：请求-响应模式
 // - 队列订阅：负载均衡订阅
+// - JetStream：流和持久消费者、消息去重、KV 和对象存储（见 jetstream.go）
 //
 // 使用场景：
 // - 微服务间通信：服务发现、事件通知
@@ -52,6 +53,7 @@ import (
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 // natsConn defines the interface for NATS connection operations
@@ -70,6 +72,7 @@ type natsConn interface {
 // Client NATS 客户端封装
 type Client struct {
 	conn natsConn
+	js   jetstream.JetStream // 未启用 JetStream 时为 nil
 }
 
 // NewClient 创建 NATS 客户端
@@ -103,7 +106,26 @@ func NewClient(cfg Config) (*Client, error) {
 	}
 
 	slog.Info("NATS connected", "url", conn.ConnectedUrl())
-	return &Client{conn: conn}, nil
+	client := &Client{conn: conn}
+
+	if cfg.JetStream.Enabled {
+		if cfg.JetStream.Domain != "" {
+			client.js, err = jetstream.NewWithDomain(conn, cfg.JetStream.Domain)
+		} else {
+			client.js, err = jetstream.New(conn)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+		}
+
+		if err := client.provision(cfg.JetStream); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
 }
 
 // newClientWithConn creates a Client with a pre-existing connection (for testing)
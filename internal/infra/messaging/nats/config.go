@@ -1,17 +1,42 @@
 package nats
 
-import "time"
+import (
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
 
 // Config NATS 客户端配置
 type Config struct {
-	URL            string        // NATS 服务器地址，例如: "nats://localhost:4222"
-	MaxReconnects  int           // 最大重连次数，-1 表示无限重连
-	ReconnectWait  time.Duration // 重连等待时间
-	Timeout        time.Duration // 连接超时
-	Name           string        // 客户端名称
-	Token          string        // 认证 Token（可选）
-	Username       string        // 用户名（可选）
-	Password       string        // 密码（可选）
+	URL           string          // NATS 服务器地址，例如: "nats://localhost:4222"
+	MaxReconnects int             // 最大重连次数，-1 表示无限重连
+	ReconnectWait time.Duration   // 重连等待时间
+	Timeout       time.Duration   // 连接超时
+	Name          string          // 客户端名称
+	Token         string          // 认证 Token（可选）
+	Username      string          // 用户名（可选）
+	Password      string          // 密码（可选）
+	JetStream     JetStreamConfig // JetStream 配置（可选）
+}
+
+// JetStreamConfig JetStream 配置
+//
+// 启用后 NewClient 会创建 JetStream 上下文，并按配置创建或更新流、消费者和存储桶，
+// 配置可以安全地在每次启动时重复应用
+type JetStreamConfig struct {
+	Enabled      bool                          // 是否启用 JetStream
+	Domain       string                        // JetStream 域（leaf node 场景，可选）
+	Timeout      time.Duration                 // 创建资源的超时时间，默认 5 秒
+	Streams      []jetstream.StreamConfig      // 启动时创建或更新的流
+	Consumers    []ConsumerConfig              // 启动时创建或更新的持久消费者
+	KeyValue     []jetstream.KeyValueConfig    // 启动时创建或更新的 KV 存储桶
+	ObjectStores []jetstream.ObjectStoreConfig // 启动时创建或更新的对象存储桶
+}
+
+// ConsumerConfig 持久消费者配置
+type ConsumerConfig struct {
+	Stream string // 所属的流
+	jetstream.ConsumerConfig
 }
 
 // DefaultConfig 返回默认配置
@@ -0,0 +1,305 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// defaultJetStreamTimeout 创建 JetStream 资源的默认超时时间
+const defaultJetStreamTimeout = 5 * time.Second
+
+var (
+	// ErrJetStreamDisabled 未启用 JetStream（Config.JetStream.Enabled 为 false）
+	ErrJetStreamDisabled = errors.New("nats: jetstream is not enabled")
+
+	// ErrTerminate 处理函数返回包装了 ErrTerminate 的错误时，消息被终止（Term），不再重新投递
+	ErrTerminate = errors.New("nats: terminate message")
+)
+
+// JetStreamHandler JetStream 消息处理函数
+//
+// 返回值决定消息的确认方式：
+// - nil: Ack，消息处理完成
+// - 包装了 ErrTerminate 的错误: Term，消息不再重新投递（如无法解析的消息）
+// - 其他错误: Nak，消息按 NakDelay 延迟后重新投递，直到达到消费者的 MaxDeliver
+type JetStreamHandler func(ctx context.Context, msg jetstream.Msg) error
+
+// ConsumeOption 拉取消费选项
+type ConsumeOption func(*consumeSettings)
+
+// consumeSettings 拉取消费配置
+type consumeSettings struct {
+	batch    int
+	maxWait  time.Duration
+	nakDelay time.Duration
+}
+
+// WithBatch 设置每次拉取的最大消息数（默认 10）
+func WithBatch(size int) ConsumeOption {
+	return func(s *consumeSettings) {
+		if size > 0 {
+			s.batch = size
+		}
+	}
+}
+
+// WithFetchMaxWait 设置每次拉取的最长等待时间（默认 5 秒）
+func WithFetchMaxWait(wait time.Duration) ConsumeOption {
+	return func(s *consumeSettings) {
+		if wait > 0 {
+			s.maxWait = wait
+		}
+	}
+}
+
+// WithNakDelay 设置处理失败后重新投递的延迟（默认立即重新投递）
+func WithNakDelay(delay time.Duration) ConsumeOption {
+	return func(s *consumeSettings) {
+		s.nakDelay = delay
+	}
+}
+
+// JetStream 返回 JetStream 上下文
+//
+// 需要直接使用 jetstream 包的高级功能时使用，如 OrderedConsumer、流管理 API
+func (c *Client) JetStream() (jetstream.JetStream, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	return c.js, nil
+}
+
+// EnsureStream 创建或更新流
+func (c *Client) EnsureStream(ctx context.Context, cfg jetstream.StreamConfig) (jetstream.Stream, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	stream, err := c.js.CreateOrUpdateStream(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure stream %s: %w", cfg.Name, err)
+	}
+	return stream, nil
+}
+
+// EnsureConsumer 在流上创建或更新持久消费者
+//
+// cfg.Durable 为空时创建临时消费者，连接断开后由服务器删除
+func (c *Client) EnsureConsumer(ctx context.Context, stream string, cfg jetstream.ConsumerConfig) (jetstream.Consumer, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	consumer, err := c.js.CreateOrUpdateConsumer(ctx, stream, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure consumer %s on stream %s: %w", cfg.Durable, stream, err)
+	}
+	return consumer, nil
+}
+
+// PublishJS 发布消息到 JetStream 并等待服务器确认
+//
+// 参数：
+//   - ctx: 上下文，控制等待确认的时间
+//   - subject: 主题，必须被某个流捕获
+//   - data: 消息数据（[]byte、string 或可 JSON 序列化的值）
+//   - opts: 发布选项，如 jetstream.WithExpectLastSequence
+//
+// 返回：
+//   - *jetstream.PubAck: 服务器确认，包含流名称和序列号
+//   - error: 没有流捕获该主题或发布失败时返回错误
+func (c *Client) PublishJS(ctx context.Context, subject string, data interface{}, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	payload, err := marshalPayload(data)
+	if err != nil {
+		return nil, err
+	}
+	ack, err := c.js.Publish(ctx, subject, payload, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish to jetstream: %w", err)
+	}
+	return ack, nil
+}
+
+// PublishWithID 使用去重 ID 发布消息
+//
+// 流的 Duplicates 窗口内相同 msgID 的消息只会存储一次，返回的 PubAck.Duplicate 为 true，
+// 适合在重试发布时保证不重复（如使用业务事件 ID 或发件箱消息 ID）
+//
+// 示例：
+//
+//	ack, err := client.PublishWithID(ctx, "orders.created", order.ID, order)
+//	if err == nil && ack.Duplicate {
+//	    // 之前已经发布过
+//	}
+func (c *Client) PublishWithID(ctx context.Context, subject, msgID string, data interface{}) (*jetstream.PubAck, error) {
+	return c.PublishJS(ctx, subject, data, jetstream.WithMsgID(msgID))
+}
+
+// Consume 从持久拉取消费者消费消息，直到上下文取消
+//
+// 设计原理：
+// 1. 按批拉取消息（WithBatch、WithFetchMaxWait），逐条调用处理函数
+// 2. 根据处理函数的返回值 Ack、Nak 或 Term，见 JetStreamHandler
+// 3. 多个进程使用同一个持久消费者时，消息在它们之间负载均衡
+//
+// 参数：
+//   - ctx: 上下文，取消后停止拉取并返回 nil
+//   - stream: 流名称
+//   - consumer: 持久消费者名称（需要事先通过配置或 EnsureConsumer 创建）
+//   - handler: 消息处理函数
+//   - opts: 消费选项
+//
+// 返回：
+//   - error: 消费者不存在或连接关闭时返回错误
+//
+// 示例：
+//
+//	err := client.Consume(ctx, "ORDERS", "billing", func(ctx context.Context, msg jetstream.Msg) error {
+//	    var order Order
+//	    if err := json.Unmarshal(msg.Data(), &order); err != nil {
+//	        return fmt.Errorf("%w: %v", nats.ErrTerminate, err)
+//	    }
+//	    return billing.Charge(ctx, order)
+//	}, nats.WithBatch(50), nats.WithNakDelay(5*time.Second))
+func (c *Client) Consume(ctx context.Context, stream, consumer string, handler JetStreamHandler, opts ...ConsumeOption) error {
+	if c.js == nil {
+		return ErrJetStreamDisabled
+	}
+
+	settings := consumeSettings{batch: 10, maxWait: 5 * time.Second}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	cons, err := c.js.Consumer(ctx, stream, consumer)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to get consumer %s on stream %s: %w", consumer, stream, err)
+	}
+
+	for ctx.Err() == nil {
+		// 上下文取消时立即结束等待中的拉取请求
+		fetchCtx, cancel := context.WithTimeout(ctx, settings.maxWait)
+		batch, err := cons.Fetch(settings.batch, jetstream.FetchContext(fetchCtx))
+		if err != nil {
+			cancel()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch messages: %w", err)
+		}
+
+		for msg := range batch.Messages() {
+			settle(ctx, msg, handler(ctx, msg), settings.nakDelay)
+		}
+		cancel()
+		if err := batch.Error(); err != nil && !errors.Is(err, jetstream.ErrNoMessages) && !errors.Is(err, context.DeadlineExceeded) {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, jetstream.ErrConsumerDeleted) || errors.Is(err, jetstream.ErrConsumerNotFound) {
+				return fmt.Errorf("failed to fetch messages: %w", err)
+			}
+			slog.Warn("NATS JetStream fetch error", "stream", stream, "consumer", consumer, "error", err)
+		}
+	}
+	return nil
+}
+
+// settle 根据处理结果确认消息
+func settle(ctx context.Context, msg jetstream.Msg, err error, nakDelay time.Duration) {
+	var ackErr error
+	switch {
+	case err == nil:
+		ackErr = msg.Ack()
+	case errors.Is(err, ErrTerminate):
+		ackErr = msg.TermWithReason(err.Error())
+	case nakDelay > 0:
+		ackErr = msg.NakWithDelay(nakDelay)
+	default:
+		ackErr = msg.Nak()
+	}
+	if ackErr != nil && ctx.Err() == nil {
+		slog.Warn("NATS JetStream ack failed", "subject", msg.Subject(), "error", ackErr)
+	}
+}
+
+// KeyValue 返回已存在的 KV 存储桶
+func (c *Client) KeyValue(ctx context.Context, bucket string) (jetstream.KeyValue, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	return c.js.KeyValue(ctx, bucket)
+}
+
+// EnsureKeyValue 创建或更新 KV 存储桶
+func (c *Client) EnsureKeyValue(ctx context.Context, cfg jetstream.KeyValueConfig) (jetstream.KeyValue, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	kv, err := c.js.CreateOrUpdateKeyValue(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure key-value bucket %s: %w", cfg.Bucket, err)
+	}
+	return kv, nil
+}
+
+// ObjectStore 返回已存在的对象存储桶
+func (c *Client) ObjectStore(ctx context.Context, bucket string) (jetstream.ObjectStore, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	return c.js.ObjectStore(ctx, bucket)
+}
+
+// EnsureObjectStore 创建或更新对象存储桶
+func (c *Client) EnsureObjectStore(ctx context.Context, cfg jetstream.ObjectStoreConfig) (jetstream.ObjectStore, error) {
+	if c.js == nil {
+		return nil, ErrJetStreamDisabled
+	}
+	store, err := c.js.CreateOrUpdateObjectStore(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure object store %s: %w", cfg.Bucket, err)
+	}
+	return store, nil
+}
+
+// provision 按配置创建或更新流、消费者和存储桶
+func (c *Client) provision(cfg JetStreamConfig) error {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultJetStreamTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, stream := range cfg.Streams {
+		if _, err := c.EnsureStream(ctx, stream); err != nil {
+			return err
+		}
+	}
+	for _, consumer := range cfg.Consumers {
+		if _, err := c.EnsureConsumer(ctx, consumer.Stream, consumer.ConsumerConfig); err != nil {
+			return err
+		}
+	}
+	for _, kv := range cfg.KeyValue {
+		if _, err := c.EnsureKeyValue(ctx, kv); err != nil {
+			return err
+		}
+	}
+	for _, store := range cfg.ObjectStores {
+		if _, err := c.EnsureObjectStore(ctx, store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
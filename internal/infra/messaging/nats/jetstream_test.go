@@ -0,0 +1,263 @@
+package nats
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runJetStreamServer 启动启用 JetStream 的嵌入式 NATS 服务器
+func runJetStreamServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("embedded NATS server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
+
+// newJetStreamClient 连接嵌入式服务器并按配置创建 JetStream 资源
+func newJetStreamClient(t *testing.T, srv *server.Server, js JetStreamConfig) *Client {
+	t.Helper()
+
+	cfg := DefaultConfig()
+	cfg.URL = srv.ClientURL()
+	cfg.JetStream = js
+	cfg.JetStream.Enabled = true
+
+	client, err := NewClient(cfg)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestJetStream_Disabled(t *testing.T) {
+	client := newClientWithConn(&MockNatsConn{connected: true})
+	ctx := context.Background()
+
+	_, err := client.JetStream()
+	assert.ErrorIs(t, err, ErrJetStreamDisabled)
+	_, err = client.PublishWithID(ctx, "orders.created", "id-1", "data")
+	assert.ErrorIs(t, err, ErrJetStreamDisabled)
+	assert.ErrorIs(t, client.Consume(ctx, "ORDERS", "billing", nil), ErrJetStreamDisabled)
+	_, err = client.KeyValue(ctx, "config")
+	assert.ErrorIs(t, err, ErrJetStreamDisabled)
+	_, err = client.ObjectStore(ctx, "files")
+	assert.ErrorIs(t, err, ErrJetStreamDisabled)
+}
+
+func TestJetStream_ProvisionFromConfig(t *testing.T) {
+	srv := runJetStreamServer(t)
+	client := newJetStreamClient(t, srv, JetStreamConfig{
+		Streams: []jetstream.StreamConfig{
+			{Name: "ORDERS", Subjects: []string{"orders.>"}},
+		},
+		Consumers: []ConsumerConfig{
+			{Stream: "ORDERS", ConsumerConfig: jetstream.ConsumerConfig{Durable: "billing", AckPolicy: jetstream.AckExplicitPolicy}},
+		},
+		KeyValue:     []jetstream.KeyValueConfig{{Bucket: "config"}},
+		ObjectStores: []jetstream.ObjectStoreConfig{{Bucket: "files"}},
+	})
+
+	ctx := context.Background()
+	js, err := client.JetStream()
+	require.NoError(t, err)
+
+	stream, err := js.Stream(ctx, "ORDERS")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders.>"}, stream.CachedInfo().Config.Subjects)
+
+	_, err = js.Consumer(ctx, "ORDERS", "billing")
+	assert.NoError(t, err)
+	_, err = client.KeyValue(ctx, "config")
+	assert.NoError(t, err)
+	_, err = client.ObjectStore(ctx, "files")
+	assert.NoError(t, err)
+
+	// 重复应用配置是幂等的
+	second := newJetStreamClient(t, srv, JetStreamConfig{
+		Streams: []jetstream.StreamConfig{{Name: "ORDERS", Subjects: []string{"orders.>"}}},
+	})
+	assert.True(t, second.IsConnected())
+}
+
+func TestJetStream_PublishWithIDDeduplicates(t *testing.T) {
+	srv := runJetStreamServer(t)
+	client := newJetStreamClient(t, srv, JetStreamConfig{
+		Streams: []jetstream.StreamConfig{
+			{Name: "ORDERS", Subjects: []string{"orders.>"}, Duplicates: time.Minute},
+		},
+	})
+	ctx := context.Background()
+
+	ack, err := client.PublishWithID(ctx, "orders.created", "order-1", map[string]string{"id": "order-1"})
+	require.NoError(t, err)
+	assert.False(t, ack.Duplicate)
+	assert.Equal(t, "ORDERS", ack.Stream)
+
+	ack, err = client.PublishWithID(ctx, "orders.created", "order-1", map[string]string{"id": "order-1"})
+	require.NoError(t, err)
+	assert.True(t, ack.Duplicate)
+
+	js, err := client.JetStream()
+	require.NoError(t, err)
+	stream, err := js.Stream(ctx, "ORDERS")
+	require.NoError(t, err)
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs)
+
+	_, err = client.PublishJS(ctx, "unbound.subject", "data")
+	assert.Error(t, err)
+}
+
+func TestJetStream_ConsumeAckNakTerm(t *testing.T) {
+	srv := runJetStreamServer(t)
+	client := newJetStreamClient(t, srv, JetStreamConfig{
+		Streams: []jetstream.StreamConfig{{Name: "TASKS", Subjects: []string{"tasks.>"}}},
+		Consumers: []ConsumerConfig{{
+			Stream: "TASKS",
+			ConsumerConfig: jetstream.ConsumerConfig{
+				Durable:    "workers",
+				AckPolicy:  jetstream.AckExplicitPolicy,
+				MaxDeliver: 5,
+			},
+		}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, body := range []string{"ok", "flaky", "poison"} {
+		_, err := client.PublishJS(ctx, "tasks."+body, body)
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	deliveries := map[string]int{}
+	done := make(chan struct{})
+
+	handler := func(ctx context.Context, msg jetstream.Msg) error {
+		mu.Lock()
+		defer mu.Unlock()
+		body := string(msg.Data())
+		deliveries[body]++
+
+		switch body {
+		case "flaky":
+			if deliveries[body] < 3 {
+				return errors.New("temporary failure")
+			}
+		case "poison":
+			return fmt.Errorf("%w: cannot decode", ErrTerminate)
+		}
+		if deliveries["ok"] == 1 && deliveries["flaky"] == 3 {
+			close(done)
+		}
+		return nil
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- client.Consume(ctx, "TASKS", "workers", handler,
+			WithBatch(5), WithFetchMaxWait(100*time.Millisecond))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for redelivery")
+	}
+	// 留出时间确认被终止的消息不会重新投递
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Consume did not return after cancel")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, deliveries["ok"])
+	assert.Equal(t, 3, deliveries["flaky"])
+	assert.Equal(t, 1, deliveries["poison"], "terminated message must not be redelivered")
+
+	js, err := client.JetStream()
+	require.NoError(t, err)
+	consumer, err := js.Consumer(context.Background(), "TASKS", "workers")
+	require.NoError(t, err)
+	info, err := consumer.Info(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, info.NumAckPending)
+	assert.Equal(t, uint64(0), info.NumPending)
+}
+
+func TestJetStream_ConsumeMissingConsumer(t *testing.T) {
+	srv := runJetStreamServer(t)
+	client := newJetStreamClient(t, srv, JetStreamConfig{
+		Streams: []jetstream.StreamConfig{{Name: "TASKS", Subjects: []string{"tasks.>"}}},
+	})
+
+	err := client.Consume(context.Background(), "TASKS", "missing", func(ctx context.Context, msg jetstream.Msg) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, jetstream.ErrConsumerNotFound)
+}
+
+func TestJetStream_KeyValueAndObjectStore(t *testing.T) {
+	srv := runJetStreamServer(t)
+	client := newJetStreamClient(t, srv, JetStreamConfig{})
+	ctx := context.Background()
+
+	kv, err := client.EnsureKeyValue(ctx, jetstream.KeyValueConfig{Bucket: "flags", History: 3})
+	require.NoError(t, err)
+
+	rev, err := kv.Put(ctx, "checkout.v2", []byte("on"))
+	require.NoError(t, err)
+	entry, err := kv.Get(ctx, "checkout.v2")
+	require.NoError(t, err)
+	assert.Equal(t, "on", string(entry.Value()))
+	assert.Equal(t, rev, entry.Revision())
+
+	// 乐观并发控制
+	_, err = kv.Update(ctx, "checkout.v2", []byte("off"), rev+10)
+	assert.Error(t, err)
+
+	store, err := client.EnsureObjectStore(ctx, jetstream.ObjectStoreConfig{Bucket: "reports"})
+	require.NoError(t, err)
+
+	content := bytes.Repeat([]byte("report-data "), 1000)
+	_, err = store.PutBytes(ctx, "2024/01.csv", content)
+	require.NoError(t, err)
+
+	reader, err := store.Get(ctx, "2024/01.csv")
+	require.NoError(t, err)
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, content, got)
+}
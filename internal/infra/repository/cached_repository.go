@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/golang/internal/domain/interfaces"
+	"github.com/yourusername/golang/internal/domain/user"
+	"github.com/yourusername/golang/internal/infra/cache/redis"
+)
+
+// 缓存装饰器的默认过期时间
+const (
+	DefaultFindByIDTTL = 10 * time.Minute
+	DefaultListTTL     = time.Minute
+)
+
+// cacheSettings 缓存装饰器的可选配置
+type cacheSettings struct {
+	findByIDTTL  time.Duration
+	listTTL      time.Duration
+	negativeTTL  time.Duration
+	notFound     error
+	cacheOptions []redis.CacheOption
+}
+
+// CacheOption 缓存装饰器配置选项
+type CacheOption func(*cacheSettings)
+
+// WithFindByIDTTL 设置 FindByID 结果的过期时间，默认 DefaultFindByIDTTL
+func WithFindByIDTTL(ttl time.Duration) CacheOption {
+	return func(s *cacheSettings) {
+		s.findByIDTTL = ttl
+	}
+}
+
+// WithListTTL 设置 List 结果的过期时间，默认 DefaultListTTL
+//
+// 任何写操作都会使 List 缓存失效，TTL 只限制其他途径（如直接修改数据库）造成的不一致时间，
+// 同时决定失效后旧代际的缓存在 Redis 中保留多久
+func WithListTTL(ttl time.Duration) CacheOption {
+	return func(s *cacheSettings) {
+		s.listTTL = ttl
+	}
+}
+
+// WithNegativeTTL 缓存 FindByID 的"不存在"结果，默认不缓存
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(s *cacheSettings) {
+		s.negativeTTL = ttl
+	}
+}
+
+// WithNotFoundError 设置被装饰的仓储表示"不存在"的错误
+//
+// 被装饰的仓储返回该错误（errors.Is）时按"不存在"处理，命中负缓存时也返回该错误。
+// 未设置时，FindByID 返回 nil 和 nil error 表示不存在（interfaces.Repository 的约定）。
+func WithNotFoundError(err error) CacheOption {
+	return func(s *cacheSettings) {
+		s.notFound = err
+	}
+}
+
+// WithCacheOptions 设置底层 redis.Cache 的选项，如 WithL1、WithSerializer
+//
+// TTL 和负缓存由 WithFindByIDTTL、WithListTTL、WithNegativeTTL 设置
+func WithCacheOptions(opts ...redis.CacheOption) CacheOption {
+	return func(s *cacheSettings) {
+		s.cacheOptions = append(s.cacheOptions, opts...)
+	}
+}
+
+// CachedRepository 是仓储的旁路缓存（cache-aside）装饰器。
+//
+// 设计原理：
+// 1. 实现 interfaces.Repository[T]，可以替换任何仓储实现，应用服务无需修改
+// 2. FindByID 和 List 先读缓存，未命中时调用被装饰的仓储并写入缓存
+// 3. Create、Update、Delete 成功后删除对应的 ID 缓存，并递增 List 代际（写操作会改变任意分页的结果）
+// 4. List 缓存键包含代际，递增代际即让所有分页失效：每次写操作只执行一次 INCR，不需要扫描键空间
+// 5. List 先读取代际再加载数据，与写操作并发时旧数据只会写入旧代际的键，不会被后续读取命中
+// 6. FindByID 在加载前读取代际，写入缓存后代际已变化时再次删除 ID 缓存，加载期间并发写入的旧值不会保留整个 TTL
+// 7. 缓存的是值的副本，调用方修改返回的实体不会影响缓存
+//
+// 缓存结构（namespace 为构造函数参数）：
+// - "<namespace>:id:<id>": FindByID 的结果
+// - "<namespace>:list:gen": 代际，写操作时递增
+// - "<namespace>:list:<gen>:<limit>:<offset>": List 的结果
+//
+// 注意事项：
+// - 写操作成功后清理缓存失败只记录日志，不返回错误（数据已经写入），缓存最多在 TTL 内不一致
+// - 旧代际的 List 缓存不会主动删除，在 TTL 后过期
+// - 读取代际失败时 List 直接访问被装饰的仓储
+// - 多个实例通过 redis.Cache 的失效通知同步一级缓存
+//
+// 使用示例：
+//
+//	repo, err := repository.NewCachedRepository[order.Order](orderRepo, redisClient, "orders",
+//	    func(o *order.Order) string { return o.ID },
+//	    repository.WithFindByIDTTL(30*time.Minute),
+//	    repository.WithListTTL(30*time.Second),
+//	)
+type CachedRepository[T any] struct {
+	next     interfaces.Repository[T]
+	idOf     func(*T) string
+	byID     *redis.Cache[T]
+	lists    *redis.Cache[[]T]
+	rdb      *goredis.Client
+	genKey   string
+	notFound error
+}
+
+// NewCachedRepository 创建仓储的缓存装饰器。
+//
+// 参数：
+//   - next: 被装饰的仓储
+//   - client: Redis 客户端
+//   - namespace: 缓存命名空间，不同实体类型必须不同
+//   - idOf: 返回实体 ID 的函数，写操作时用于删除 ID 缓存
+//   - opts: 可选配置，如 WithFindByIDTTL、WithListTTL、WithNegativeTTL
+//
+// 返回：
+//   - *CachedRepository[T]: 缓存装饰器
+//   - error: 创建缓存失败时返回错误
+func NewCachedRepository[T any](
+	next interfaces.Repository[T],
+	client *redis.Client,
+	namespace string,
+	idOf func(*T) string,
+	opts ...CacheOption,
+) (*CachedRepository[T], error) {
+	s := cacheSettings{
+		findByIDTTL: DefaultFindByIDTTL,
+		listTTL:     DefaultListTTL,
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	byIDOptions := append([]redis.CacheOption{}, s.cacheOptions...)
+	byIDOptions = append(byIDOptions, redis.WithTTL(s.findByIDTTL))
+	if s.negativeTTL > 0 {
+		byIDOptions = append(byIDOptions, redis.WithNegativeCaching(s.negativeTTL, nil))
+	}
+	byID, err := redis.NewCache[T](client, namespace+":id", byIDOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	listOptions := append([]redis.CacheOption{}, s.cacheOptions...)
+	listOptions = append(listOptions, redis.WithTTL(s.listTTL))
+	lists, err := redis.NewCache[[]T](client, namespace+":list", listOptions...)
+	if err != nil {
+		_ = byID.Close()
+		return nil, err
+	}
+
+	return &CachedRepository[T]{
+		next:     next,
+		idOf:     idOf,
+		byID:     byID,
+		lists:    lists,
+		rdb:      client.GetClient(),
+		genKey:   namespace + ":list:gen",
+		notFound: s.notFound,
+	}, nil
+}
+
+// Create 创建实体，成功后清理缓存
+func (r *CachedRepository[T]) Create(ctx context.Context, entity *T) error {
+	if err := r.next.Create(ctx, entity); err != nil {
+		return err
+	}
+	// 可能存在该 ID 的负缓存
+	r.invalidate(ctx, r.idOf(entity))
+	return nil
+}
+
+// FindByID 根据 ID 查找实体，优先从缓存读取
+func (r *CachedRepository[T]) FindByID(ctx context.Context, id string) (*T, error) {
+	// 只有实际执行了加载的调用才需要检查代际，见设计原理 6
+	loaded := false
+	var gen int64
+	entity, err := r.byID.GetOrLoad(ctx, id, func(ctx context.Context, id string) (T, error) {
+		var zero T
+		var genErr error
+		loaded = true
+		if gen, genErr = r.listGeneration(ctx); genErr != nil {
+			gen = -1 // 无法读取代际时按已变化处理
+		}
+		found, err := r.next.FindByID(ctx, id)
+		if err != nil {
+			if r.notFound != nil && errors.Is(err, r.notFound) {
+				return zero, redis.ErrNotFound
+			}
+			return zero, err
+		}
+		if found == nil {
+			return zero, redis.ErrNotFound
+		}
+		return *found, nil
+	})
+	if loaded {
+		r.dropIfStale(ctx, id, gen)
+	}
+	if errors.Is(err, redis.ErrNotFound) {
+		return nil, r.notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Update 更新实体，成功后清理缓存
+func (r *CachedRepository[T]) Update(ctx context.Context, entity *T) error {
+	if err := r.next.Update(ctx, entity); err != nil {
+		return err
+	}
+	r.invalidate(ctx, r.idOf(entity))
+	return nil
+}
+
+// Delete 删除实体，成功后清理缓存
+func (r *CachedRepository[T]) Delete(ctx context.Context, id string) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// List 列出实体，优先从缓存读取
+func (r *CachedRepository[T]) List(ctx context.Context, limit, offset int) ([]*T, error) {
+	// 必须在加载数据之前读取代际，见设计原理 5
+	gen, err := r.listGeneration(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read list cache generation", "error", err)
+		return r.next.List(ctx, limit, offset)
+	}
+
+	key := strconv.FormatInt(gen, 10) + ":" + strconv.Itoa(limit) + ":" + strconv.Itoa(offset)
+	values, err := r.lists.GetOrLoad(ctx, key, func(ctx context.Context, _ string) ([]T, error) {
+		entities, err := r.next.List(ctx, limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]T, 0, len(entities))
+		for _, entity := range entities {
+			if entity != nil {
+				values = append(values, *entity)
+			}
+		}
+		return values, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]*T, len(values))
+	for i := range values {
+		entity := values[i]
+		entities[i] = &entity
+	}
+	return entities, nil
+}
+
+// Close 关闭缓存（取消失效通知订阅），不会关闭被装饰的仓储和 Redis 客户端
+func (r *CachedRepository[T]) Close() error {
+	return errors.Join(r.byID.Close(), r.lists.Close())
+}
+
+// listGeneration 返回 List 缓存的当前代际，键不存在时为 0
+func (r *CachedRepository[T]) listGeneration(ctx context.Context) (int64, error) {
+	gen, err := r.rdb.Get(ctx, r.genKey).Int64()
+	if errors.Is(err, goredis.Nil) {
+		return 0, nil
+	}
+	return gen, err
+}
+
+// dropIfStale 代际与加载前不同时删除 ID 缓存
+func (r *CachedRepository[T]) dropIfStale(ctx context.Context, id string, gen int64) {
+	current, err := r.listGeneration(ctx)
+	if err == nil && current == gen {
+		return
+	}
+	if err := r.byID.Delete(ctx, id); err != nil {
+		slog.WarnContext(ctx, "failed to drop possibly stale cached entity", "id", id, "error", err)
+	}
+}
+
+// invalidate 删除 ID 缓存并递增代际
+func (r *CachedRepository[T]) invalidate(ctx context.Context, id string) {
+	if id != "" {
+		if err := r.byID.Delete(ctx, id); err != nil {
+			slog.WarnContext(ctx, "failed to invalidate cached entity", "id", id, "error", err)
+		}
+	}
+	if err := r.rdb.Incr(ctx, r.genKey).Err(); err != nil {
+		slog.WarnContext(ctx, "failed to invalidate cached lists", "error", err)
+	}
+}
+
+// CachedUserRepository 是用户仓储的缓存装饰器
+//
+// FindByID 和 List 使用缓存；FindByEmail 直接访问被装饰的仓储，
+// 因为邮箱可以修改，按邮箱缓存需要额外维护旧邮箱的失效。
+type CachedUserRepository struct {
+	*CachedRepository[user.User]
+	next user.Repository
+}
+
+// NewCachedUserRepository 创建用户仓储的缓存装饰器
+//
+// 缓存命名空间为 "users"，user.ErrUserNotFound 表示用户不存在。
+//
+// 示例：
+//
+//	repo, err := repository.NewCachedUserRepository(repository.NewEntUserRepository(entClient), redisClient,
+//	    repository.WithNegativeTTL(30*time.Second),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer repo.Close()
+//	userService := appuser.NewService(repo)
+func NewCachedUserRepository(next user.Repository, client *redis.Client, opts ...CacheOption) (*CachedUserRepository, error) {
+	opts = append([]CacheOption{WithNotFoundError(user.ErrUserNotFound)}, opts...)
+	cached, err := NewCachedRepository[user.User](next, client, "users", func(u *user.User) string {
+		return u.ID
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &CachedUserRepository{CachedRepository: cached, next: next}, nil
+}
+
+// Save 保存用户，与 EntUserRepository.Save 一致，等同于 Create
+//
+// 使 CachedUserRepository 满足应用层的 UserRepository 接口
+func (r *CachedUserRepository) Save(ctx context.Context, u *user.User) error {
+	return r.Create(ctx, u)
+}
+
+// FindByEmail 根据邮箱查找用户（不使用缓存）
+func (r *CachedUserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	return r.next.FindByEmail(ctx, email)
+}
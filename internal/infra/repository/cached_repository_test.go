@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appuser "github.com/yourusername/golang/internal/app/user"
+	"github.com/yourusername/golang/internal/domain/user"
+	"github.com/yourusername/golang/internal/infra/cache/redis"
+	"github.com/yourusername/golang/internal/infra/database/ent/enttest"
+)
+
+// countingUserRepository 统计对被装饰仓储的读取次数
+type countingUserRepository struct {
+	user.Repository
+	findByID int
+	list     int
+
+	// afterFindByID 在读取数据库之后、返回之前调用，用于模拟并发写操作
+	afterFindByID func()
+}
+
+func (r *countingUserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
+	r.findByID++
+	found, err := r.Repository.FindByID(ctx, id)
+	if hook := r.afterFindByID; hook != nil {
+		r.afterFindByID = nil
+		hook()
+	}
+	return found, err
+}
+
+func (r *countingUserRepository) List(ctx context.Context, limit, offset int) ([]*user.User, error) {
+	r.list++
+	return r.Repository.List(ctx, limit, offset)
+}
+
+// setupCachedUserRepository 创建装饰 EntUserRepository 的缓存仓储
+func setupCachedUserRepository(t *testing.T, opts ...CacheOption) (*CachedUserRepository, *countingUserRepository, *miniredis.Miniredis) {
+	t.Helper()
+
+	client := enttest.Open(t, "sqlite3", fmt.Sprintf("file:%s?mode=memory&cache=shared&_fk=1", t.Name()))
+	t.Cleanup(func() { _ = client.Close() })
+	counting := &countingUserRepository{Repository: NewEntUserRepository(client)}
+
+	mr := miniredis.RunT(t)
+	redisClient, err := redis.NewClient(redis.Config{Addr: mr.Addr(), PoolSize: 5})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = redisClient.Close() })
+
+	repo, err := NewCachedUserRepository(counting, redisClient, opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo, counting, mr
+}
+
+func TestCachedUserRepository_FindByIDUsesCache(t *testing.T) {
+	repo, counting, mr := setupCachedUserRepository(t, WithFindByIDTTL(time.Hour))
+	ctx := context.Background()
+
+	u := user.NewUser("cached@example.com", "Cached User")
+	require.NoError(t, repo.Create(ctx, u))
+
+	for range 3 {
+		found, err := repo.FindByID(ctx, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Cached User", found.Name)
+	}
+	assert.Equal(t, 1, counting.findByID)
+	assert.Equal(t, time.Hour, mr.TTL("users:id:"+u.ID))
+
+	// 修改返回值不影响缓存
+	found, err := repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	found.Name = "mutated"
+	found, err = repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Cached User", found.Name)
+}
+
+func TestCachedUserRepository_UpdateAndDeleteInvalidate(t *testing.T) {
+	repo, counting, _ := setupCachedUserRepository(t)
+	ctx := context.Background()
+
+	u := user.NewUser("update@example.com", "Before")
+	require.NoError(t, repo.Create(ctx, u))
+	_, err := repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+
+	u.UpdateName("After")
+	require.NoError(t, repo.Update(ctx, u))
+	found, err := repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "After", found.Name)
+	assert.Equal(t, 2, counting.findByID)
+
+	require.NoError(t, repo.Delete(ctx, u.ID))
+	_, err = repo.FindByID(ctx, u.ID)
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+	assert.Equal(t, 3, counting.findByID)
+
+	// 写操作失败时不清理缓存，错误原样返回
+	assert.ErrorIs(t, repo.Delete(ctx, u.ID), user.ErrUserNotFound)
+}
+
+func TestCachedUserRepository_FindByIDDoesNotCacheStaleLoad(t *testing.T) {
+	repo, counting, mr := setupCachedUserRepository(t, WithFindByIDTTL(time.Hour))
+	ctx := context.Background()
+
+	u := user.NewUser("stale@example.com", "Before")
+	require.NoError(t, repo.Create(ctx, u))
+
+	// 加载读到旧行之后、写入缓存之前，另一个请求完成了更新
+	counting.afterFindByID = func() {
+		u.UpdateName("After")
+		require.NoError(t, repo.Update(ctx, u))
+	}
+	found, err := repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Before", found.Name)
+	assert.False(t, mr.Exists("users:id:"+u.ID))
+
+	found, err = repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "After", found.Name)
+	assert.Equal(t, 2, counting.findByID)
+}
+
+func TestCachedUserRepository_NegativeCaching(t *testing.T) {
+	repo, counting, mr := setupCachedUserRepository(t, WithNegativeTTL(30*time.Second))
+	ctx := context.Background()
+
+	u := user.NewUser("late@example.com", "Late")
+	for range 3 {
+		_, err := repo.FindByID(ctx, u.ID)
+		assert.ErrorIs(t, err, user.ErrUserNotFound)
+	}
+	assert.Equal(t, 1, counting.findByID)
+	assert.Equal(t, 30*time.Second, mr.TTL("users:id:"+u.ID))
+
+	// 创建后负缓存被删除
+	require.NoError(t, repo.Create(ctx, u))
+	found, err := repo.FindByID(ctx, u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, found.ID)
+}
+
+func TestCachedUserRepository_ListInvalidatedByWrites(t *testing.T) {
+	repo, counting, mr := setupCachedUserRepository(t, WithListTTL(5*time.Second))
+	ctx := context.Background()
+
+	require.NoError(t, repo.Create(ctx, user.NewUser("a@example.com", "Alice")))
+
+	users, err := repo.List(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+	_, err = repo.List(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counting.list)
+	assert.Equal(t, 5*time.Second, mr.TTL("users:list:1:10:0"))
+
+	// 不同分页参数使用不同的缓存键
+	_, err = repo.List(ctx, 5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, counting.list)
+
+	// 写操作只递增代际，不扫描和删除已有的 List 缓存
+	require.NoError(t, repo.Save(ctx, user.NewUser("b@example.com", "Bob")))
+	gen, err := mr.Get("users:list:gen")
+	require.NoError(t, err)
+	assert.Equal(t, "2", gen)
+	assert.True(t, mr.Exists("users:list:1:10:0"))
+
+	_, err = repo.List(ctx, 5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, counting.list)
+
+	users, err = repo.List(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, 4, counting.list)
+	assert.True(t, mr.Exists("users:list:2:10:0"))
+}
+
+func TestCachedUserRepository_FindByEmailBypassesCache(t *testing.T) {
+	repo, _, mr := setupCachedUserRepository(t)
+	ctx := context.Background()
+
+	u := user.NewUser("email@example.com", "Email")
+	require.NoError(t, repo.Create(ctx, u))
+
+	found, err := repo.FindByEmail(ctx, "email@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, found.ID)
+	// 只有写操作递增的 List 代际，没有缓存实体
+	assert.Equal(t, []string{"users:list:gen"}, mr.Keys())
+}
+
+func TestCachedUserRepository_SatisfiesInterfaces(t *testing.T) {
+	var _ user.Repository = (*CachedUserRepository)(nil)
+	var _ appuser.UserRepository = (*CachedUserRepository)(nil)
+}
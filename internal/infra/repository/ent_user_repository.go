@@ -65,7 +65,7 @@ func (r *EntUserRepository) Save(ctx context.Context, u *user.User) error {
 
 // Create 创建用户
 func (r *EntUserRepository) Create(ctx context.Context, u *user.User) error {
-	entUser, err := r.client.User.Create().
+	entUser, err := r.db(ctx).User.Create().
 		SetID(u.ID).
 		SetEmail(u.Email).
 		SetName(u.Name).
@@ -83,7 +83,7 @@ func (r *EntUserRepository) Create(ctx context.Context, u *user.User) error {
 
 // FindByID 根据 ID 查找用户
 func (r *EntUserRepository) FindByID(ctx context.Context, id string) (*user.User, error) {
-	entUser, err := r.client.User.Get(ctx, id)
+	entUser, err := r.db(ctx).User.Get(ctx, id)
 	if err != nil {
 		if ent.IsNotFound(err) {
 			return nil, user.ErrUserNotFound
@@ -96,7 +96,7 @@ func (r *EntUserRepository) FindByID(ctx context.Context, id string) (*user.User
 
 // FindByEmail 根据邮箱查找用户
 func (r *EntUserRepository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
-	entUser, err := r.client.User.Query().
+	entUser, err := r.db(ctx).User.Query().
 		Where(entuser.EmailEQ(email)).
 		Only(ctx)
 	if err != nil {
@@ -111,7 +111,7 @@ func (r *EntUserRepository) FindByEmail(ctx context.Context, email string) (*use
 
 // Update 更新用户
 func (r *EntUserRepository) Update(ctx context.Context, u *user.User) error {
-	_, err := r.client.User.UpdateOneID(u.ID).
+	_, err := r.db(ctx).User.UpdateOneID(u.ID).
 		SetName(u.Name).
 		SetEmail(u.Email).
 		Save(ctx)
@@ -127,7 +127,7 @@ func (r *EntUserRepository) Update(ctx context.Context, u *user.User) error {
 
 // Delete 删除用户
 func (r *EntUserRepository) Delete(ctx context.Context, id string) error {
-	err := r.client.User.DeleteOneID(id).Exec(ctx)
+	err := r.db(ctx).User.DeleteOneID(id).Exec(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
 			return user.ErrUserNotFound
@@ -140,7 +140,7 @@ func (r *EntUserRepository) Delete(ctx context.Context, id string) error {
 
 // List 列出用户（支持分页）
 func (r *EntUserRepository) List(ctx context.Context, limit, offset int) ([]*user.User, error) {
-	entUsers, err := r.client.User.Query().
+	entUsers, err := r.db(ctx).User.Query().
 		Limit(limit).
 		Offset(offset).
 		All(ctx)
@@ -156,6 +156,14 @@ func (r *EntUserRepository) List(ctx context.Context, limit, offset int) ([]*use
 	return users, nil
 }
 
+// db 返回当前上下文应使用的客户端
+//
+// 上下文携带 transaction.Manager 开启的事务时，写入和查询都在该事务中执行，
+// 使用户写入与同一事务中的其他写入（例如 outbox 事件）一起提交或回滚。
+func (r *EntUserRepository) db(ctx context.Context) *ent.Client {
+	return r.client.WithContextTx(ctx)
+}
+
 // toDomain 将 Ent 用户转换为领域用户
 func (r *EntUserRepository) toDomain(entUser *ent.User) *user.User {
 	return &user.User{
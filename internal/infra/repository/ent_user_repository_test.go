@@ -11,14 +11,23 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"os"
 	"testing"
+	"time"
 
+	"entgo.io/ent/dialect"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	appuser "github.com/yourusername/golang/internal/app/user"
 	"github.com/yourusername/golang/internal/domain/user"
 	"github.com/yourusername/golang/internal/infra/database/ent"
 	"github.com/yourusername/golang/internal/infra/database/ent/enttest"
+	"github.com/yourusername/golang/pkg/eventbus"
+	"github.com/yourusername/golang/pkg/outbox"
+	"github.com/yourusername/golang/pkg/transaction"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -288,6 +297,87 @@ func TestEntUserRepository_WithTx(t *testing.T) {
 	assert.Equal(t, "Transaction User", found.Name)
 }
 
+// recorderFunc 函数形式的事件记录器
+type recorderFunc func(ctx context.Context, event eventbus.Event) error
+
+func (f recorderFunc) Record(ctx context.Context, event eventbus.Event) error {
+	return f(ctx, event)
+}
+
+// setupTransactionalUserService 创建共享同一个 *sql.DB 的仓储、事务管理器和应用服务
+//
+// 数据库限制为单连接：仓储如果绕过上下文中的事务，会一直等待连接，直到上下文超时
+func setupTransactionalUserService(t *testing.T, recorder appuser.EventRecorder) (*appuser.Service, *EntUserRepository, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", "file::memory:?_fk=1")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	client := ent.NewClientFromDB(db, dialect.SQLite)
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Schema.Create(context.Background()))
+
+	migration, err := os.ReadFile("../../../migrations/sqlite3/001_create_outbox_messages.up.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(string(migration))
+	require.NoError(t, err)
+
+	repo := NewEntUserRepository(client)
+	service := appuser.NewService(repo,
+		appuser.WithTransactionManager(transaction.NewSQLTransactionManager(db)),
+		appuser.WithEventRecorder(recorder),
+	)
+	return service, repo, db
+}
+
+// countOutboxMessages 统计发件箱中的消息数
+func countOutboxMessages(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var n int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM outbox_messages").Scan(&n))
+	return n
+}
+
+// TestEntUserRepository_ContextTransactionCommit 测试仓储与发件箱在同一个事务中提交
+func TestEntUserRepository_ContextTransactionCommit(t *testing.T) {
+	service, repo, db := setupTransactionalUserService(t, outbox.NewWriter(outbox.DialectSQLite))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := service.CreateUser(ctx, "commit@example.com", "Commit User")
+	require.NoError(t, err)
+
+	found, err := repo.FindByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "commit@example.com", found.Email)
+	assert.Equal(t, 1, countOutboxMessages(t, db))
+}
+
+// TestEntUserRepository_ContextTransactionRollback 测试事件写入失败时用户写入随事务回滚
+func TestEntUserRepository_ContextTransactionRollback(t *testing.T) {
+	writer := outbox.NewWriter(outbox.DialectSQLite)
+	recorder := recorderFunc(func(ctx context.Context, event eventbus.Event) error {
+		if err := writer.Record(ctx, event); err != nil {
+			return err
+		}
+		return errors.New("outbox unavailable")
+	})
+	service, repo, db := setupTransactionalUserService(t, recorder)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := service.CreateUser(ctx, "rollback@example.com", "Rollback User")
+	require.Error(t, err)
+
+	_, err = repo.FindByEmail(ctx, "rollback@example.com")
+	assert.ErrorIs(t, err, user.ErrUserNotFound)
+	assert.Equal(t, 0, countOutboxMessages(t, db))
+}
+
 // TestEntUserRepository_CRUDSequence 测试完整的 CRUD 流程
 func TestEntUserRepository_CRUDSequence(t *testing.T) {
 	repo, client := setupEntUserRepository(t)
@@ -0,0 +1,415 @@
+// Package handlers provides HTTP handlers for RBAC administration.
+//
+// RBAC 管理处理器负责：
+// 1. 角色的增删改查，包括权限列表和父角色（角色继承）
+// 2. 权限的增删改查，以及为角色授予、撤销权限
+// 3. 用户在域（租户）中的角色分配
+//
+// 设计原则：
+// 1. 直接调用 rbac.RBAC，配置了 rbac.Store 时修改会持久化，重启后仍然生效
+// 2. 路径中的角色或权限不存在时返回 404，请求体引用的角色或权限不存在时返回 400
+// 3. 管理接口本身需要由调用方挂载认证和授权中间件保护
+//
+// 架构位置：
+// - 位置：Interfaces Layer (internal/interfaces/http/chi/handlers/)
+// - 职责：RBAC 管理的 HTTP 协议适配、请求处理、响应格式化
+// - 依赖：pkg/security/rbac
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	apperrors "github.com/yourusername/golang/pkg/errors"
+	"github.com/yourusername/golang/pkg/security/rbac"
+)
+
+// RBACHandler RBAC 管理 HTTP 处理器
+type RBACHandler struct {
+	rbac *rbac.RBAC
+}
+
+// NewRBACHandler 创建 RBAC 管理 HTTP 处理器
+func NewRBACHandler(r *rbac.RBAC) *RBACHandler {
+	return &RBACHandler{
+		rbac: r,
+	}
+}
+
+// RoleRequest 创建或更新角色请求
+type RoleRequest struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Inherits    []string `json:"inherits"`
+}
+
+// RoleResponse 角色响应
+type RoleResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Inherits    []string `json:"inherits"`
+}
+
+// PermissionRequest 创建或更新权限请求
+type PermissionRequest struct {
+	ID          string `json:"id"`
+	Resource    string `json:"resource"`
+	Action      string `json:"action"`
+	Description string `json:"description"`
+}
+
+// PermissionResponse 权限响应
+type PermissionResponse struct {
+	ID          string `json:"id"`
+	Resource    string `json:"resource"`
+	Action      string `json:"action"`
+	Description string `json:"description"`
+}
+
+// ListRoles 列出角色。
+// GET /roles
+func (h *RBACHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles := h.rbac.ListRoles()
+	sort.Slice(roles, func(i, j int) bool { return roles[i].ID < roles[j].ID })
+
+	response := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, toRoleResponse(role))
+	}
+	Success(w, http.StatusOK, response)
+}
+
+// CreateRole 创建角色。
+// POST /roles
+func (h *RBACHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, apperrors.NewInvalidInputError("invalid request body"))
+		return
+	}
+	if req.ID == "" {
+		Error(w, http.StatusBadRequest, apperrors.NewInvalidInputError("role id is required"))
+		return
+	}
+
+	role := req.toRole(req.ID)
+	if err := h.rbac.CreateRole(r.Context(), role); err != nil {
+		writeRBACError(w, err, "failed to create role")
+		return
+	}
+
+	Success(w, http.StatusCreated, toRoleResponse(role))
+}
+
+// GetRole 获取角色。
+// GET /roles/{id}
+func (h *RBACHandler) GetRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	role, err := h.rbac.GetRole(id)
+	if err != nil {
+		Error(w, http.StatusNotFound, apperrors.NewNotFoundError("role", id))
+		return
+	}
+
+	Success(w, http.StatusOK, toRoleResponse(role))
+}
+
+// UpdateRole 更新角色，整体替换权限列表和父角色。
+// PUT /roles/{id}
+func (h *RBACHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := h.rbac.GetRole(id); err != nil {
+		Error(w, http.StatusNotFound, apperrors.NewNotFoundError("role", id))
+		return
+	}
+
+	var req RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, apperrors.NewInvalidInputError("invalid request body"))
+		return
+	}
+
+	role := req.toRole(id)
+	if err := h.rbac.UpdateRole(r.Context(), role); err != nil {
+		writeRBACError(w, err, "failed to update role")
+		return
+	}
+
+	Success(w, http.StatusOK, toRoleResponse(role))
+}
+
+// DeleteRole 删除角色及其所有分配。
+// DELETE /roles/{id}
+func (h *RBACHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.rbac.DeleteRole(r.Context(), id); err != nil {
+		if errors.Is(err, rbac.ErrRoleNotFound) {
+			Error(w, http.StatusNotFound, apperrors.NewNotFoundError("role", id))
+			return
+		}
+		writeRBACError(w, err, "failed to delete role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GrantPermission 为角色授予权限。
+// PUT /roles/{id}/permissions/{permissionID}
+func (h *RBACHandler) GrantPermission(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	permID := chi.URLParam(r, "permissionID")
+	if err := h.rbac.GrantPermission(r.Context(), id, permID); err != nil {
+		writePathError(w, err, id, permID, "failed to grant permission")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokePermission 撤销角色的权限。
+// DELETE /roles/{id}/permissions/{permissionID}
+func (h *RBACHandler) RevokePermission(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	permID := chi.URLParam(r, "permissionID")
+	if err := h.rbac.RevokePermission(r.Context(), id, permID); err != nil {
+		writePathError(w, err, id, permID, "failed to revoke permission")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListPermissions 列出权限。
+// GET /permissions
+func (h *RBACHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	perms := h.rbac.ListPermissions()
+	sort.Slice(perms, func(i, j int) bool { return perms[i].ID < perms[j].ID })
+
+	response := make([]PermissionResponse, 0, len(perms))
+	for _, perm := range perms {
+		response = append(response, toPermissionResponse(perm))
+	}
+	Success(w, http.StatusOK, response)
+}
+
+// CreatePermission 创建权限。
+// POST /permissions
+func (h *RBACHandler) CreatePermission(w http.ResponseWriter, r *http.Request) {
+	var req PermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, apperrors.NewInvalidInputError("invalid request body"))
+		return
+	}
+	if req.ID == "" {
+		Error(w, http.StatusBadRequest, apperrors.NewInvalidInputError("permission id is required"))
+		return
+	}
+	if err := req.validate(); err != nil {
+		Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	perm := req.toPermission(req.ID)
+	if err := h.rbac.CreatePermission(r.Context(), perm); err != nil {
+		writeRBACError(w, err, "failed to create permission")
+		return
+	}
+
+	Success(w, http.StatusCreated, toPermissionResponse(perm))
+}
+
+// GetPermission 获取权限。
+// GET /permissions/{id}
+func (h *RBACHandler) GetPermission(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	perm, err := h.rbac.GetPermission(id)
+	if err != nil {
+		Error(w, http.StatusNotFound, apperrors.NewNotFoundError("permission", id))
+		return
+	}
+
+	Success(w, http.StatusOK, toPermissionResponse(perm))
+}
+
+// UpdatePermission 更新权限。
+// PUT /permissions/{id}
+func (h *RBACHandler) UpdatePermission(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req PermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		Error(w, http.StatusBadRequest, apperrors.NewInvalidInputError("invalid request body"))
+		return
+	}
+	if err := req.validate(); err != nil {
+		Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	perm := req.toPermission(id)
+	if err := h.rbac.UpdatePermission(r.Context(), perm); err != nil {
+		if errors.Is(err, rbac.ErrPermissionNotFound) {
+			Error(w, http.StatusNotFound, apperrors.NewNotFoundError("permission", id))
+			return
+		}
+		writeRBACError(w, err, "failed to update permission")
+		return
+	}
+
+	Success(w, http.StatusOK, toPermissionResponse(perm))
+}
+
+// DeletePermission 删除权限，并从所有角色中移除。
+// DELETE /permissions/{id}
+func (h *RBACHandler) DeletePermission(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.rbac.DeletePermission(r.Context(), id); err != nil {
+		if errors.Is(err, rbac.ErrPermissionNotFound) {
+			Error(w, http.StatusNotFound, apperrors.NewNotFoundError("permission", id))
+			return
+		}
+		writeRBACError(w, err, "failed to delete permission")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAssignments 列出角色分配，支持 domain、user_id、role_id 查询参数过滤。
+// GET /assignments
+func (h *RBACHandler) ListAssignments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	assignments := h.rbac.ListAssignments(rbac.AssignmentFilter{
+		Domain: query.Get("domain"),
+		UserID: query.Get("user_id"),
+		RoleID: query.Get("role_id"),
+	})
+	if assignments == nil {
+		assignments = []rbac.Assignment{}
+	}
+
+	Success(w, http.StatusOK, assignments)
+}
+
+// GetUserRoles 获取用户在域中生效的角色（包括全局域的角色）。
+// GET /domains/{domain}/users/{userID}/roles
+func (h *RBACHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	roles := h.rbac.UserRoles(chi.URLParam(r, "domain"), chi.URLParam(r, "userID"))
+	if roles == nil {
+		roles = []string{}
+	}
+
+	Success(w, http.StatusOK, roles)
+}
+
+// AssignRole 在域中为用户分配角色。
+// PUT /domains/{domain}/users/{userID}/roles/{roleID}
+func (h *RBACHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	roleID := chi.URLParam(r, "roleID")
+	err := h.rbac.AssignRole(r.Context(), chi.URLParam(r, "domain"), chi.URLParam(r, "userID"), roleID)
+	if err != nil {
+		writePathError(w, err, roleID, "", "failed to assign role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeRole 撤销用户在域中的角色。
+// DELETE /domains/{domain}/users/{userID}/roles/{roleID}
+func (h *RBACHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	err := h.rbac.RevokeRole(r.Context(), chi.URLParam(r, "domain"), chi.URLParam(r, "userID"), chi.URLParam(r, "roleID"))
+	if err != nil {
+		writeRBACError(w, err, "failed to revoke role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (req RoleRequest) toRole(id string) *rbac.Role {
+	return &rbac.Role{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		Inherits:    req.Inherits,
+	}
+}
+
+func toRoleResponse(role *rbac.Role) RoleResponse {
+	response := RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		Inherits:    role.Inherits,
+	}
+	if response.Permissions == nil {
+		response.Permissions = []string{}
+	}
+	if response.Inherits == nil {
+		response.Inherits = []string{}
+	}
+	return response
+}
+
+func (req PermissionRequest) validate() error {
+	if req.Resource == "" {
+		return apperrors.NewInvalidInputError("resource is required")
+	}
+	if req.Action == "" {
+		return apperrors.NewInvalidInputError("action is required")
+	}
+	return nil
+}
+
+func (req PermissionRequest) toPermission(id string) *rbac.Permission {
+	return &rbac.Permission{
+		ID:          id,
+		Resource:    req.Resource,
+		Action:      req.Action,
+		Description: req.Description,
+	}
+}
+
+func toPermissionResponse(perm *rbac.Permission) PermissionResponse {
+	return PermissionResponse{
+		ID:          perm.ID,
+		Resource:    perm.Resource,
+		Action:      perm.Action,
+		Description: perm.Description,
+	}
+}
+
+// writePathError 路径中的角色或权限不存在时返回 404，其他错误交给 writeRBACError
+func writePathError(w http.ResponseWriter, err error, roleID, permID, message string) {
+	switch {
+	case errors.Is(err, rbac.ErrRoleNotFound):
+		Error(w, http.StatusNotFound, apperrors.NewNotFoundError("role", roleID))
+	case errors.Is(err, rbac.ErrPermissionNotFound):
+		Error(w, http.StatusNotFound, apperrors.NewNotFoundError("permission", permID))
+	default:
+		writeRBACError(w, err, message)
+	}
+}
+
+// writeRBACError 将 RBAC 错误映射为 HTTP 响应
+func writeRBACError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, rbac.ErrRoleExists), errors.Is(err, rbac.ErrPermissionExists), errors.Is(err, rbac.ErrRoleInUse):
+		Error(w, http.StatusConflict, apperrors.NewConflictError(err.Error()))
+	case errors.Is(err, rbac.ErrRoleNotFound), errors.Is(err, rbac.ErrPermissionNotFound),
+		errors.Is(err, rbac.ErrCircularInheritance), errors.Is(err, rbac.ErrInvalidAssignment):
+		Error(w, http.StatusBadRequest, apperrors.NewInvalidInputError(err.Error()))
+	default:
+		Error(w, http.StatusInternalServerError, apperrors.NewInternalError(message, err))
+	}
+}
@@ -0,0 +1,183 @@
+// Package handlers provides tests for RBAC admin HTTP handlers.
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/golang/pkg/security/rbac"
+)
+
+// newRBACTestRouter 创建挂载 RBAC 管理处理器的路由
+func newRBACTestRouter(t *testing.T) (*rbac.RBAC, http.Handler) {
+	t.Helper()
+
+	system := rbac.NewRBAC()
+	require.NoError(t, system.InitializeDefaultRoles())
+
+	h := NewRBACHandler(system)
+	r := chi.NewRouter()
+	r.Get("/roles", h.ListRoles)
+	r.Post("/roles", h.CreateRole)
+	r.Get("/roles/{id}", h.GetRole)
+	r.Put("/roles/{id}", h.UpdateRole)
+	r.Delete("/roles/{id}", h.DeleteRole)
+	r.Put("/roles/{id}/permissions/{permissionID}", h.GrantPermission)
+	r.Delete("/roles/{id}/permissions/{permissionID}", h.RevokePermission)
+	r.Get("/permissions", h.ListPermissions)
+	r.Post("/permissions", h.CreatePermission)
+	r.Get("/permissions/{id}", h.GetPermission)
+	r.Put("/permissions/{id}", h.UpdatePermission)
+	r.Delete("/permissions/{id}", h.DeletePermission)
+	r.Get("/assignments", h.ListAssignments)
+	r.Get("/domains/{domain}/users/{userID}/roles", h.GetUserRoles)
+	r.Put("/domains/{domain}/users/{userID}/roles/{roleID}", h.AssignRole)
+	r.Delete("/domains/{domain}/users/{userID}/roles/{roleID}", h.RevokeRole)
+	return system, r
+}
+
+func doRBACRequest(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRBACHandler_Roles(t *testing.T) {
+	system, handler := newRBACTestRouter(t)
+
+	rec := doRBACRequest(t, handler, http.MethodPost, "/roles",
+		`{"id": "editor", "name": "Editor", "permissions": ["user.update"], "inherits": ["user"]}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodPost, "/roles", `{"id": "editor"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodPost, "/roles", `{"id": "broken", "inherits": ["missing"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodPost, "/roles", `{"name": "no id"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodGet, "/roles/editor", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Data RoleResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Editor", resp.Data.Name)
+	assert.Equal(t, []string{"user"}, resp.Data.Inherits)
+
+	rec = doRBACRequest(t, handler, http.MethodPut, "/roles/user", `{"name": "User", "inherits": ["editor"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "circular inheritance")
+
+	rec = doRBACRequest(t, handler, http.MethodPut, "/roles/missing", `{"name": "Missing"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodDelete, "/roles/user", "")
+	assert.Equal(t, http.StatusConflict, rec.Code, "user is inherited")
+
+	rec = doRBACRequest(t, handler, http.MethodPut, "/roles/editor/permissions/user.delete", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	allowed, err := system.CheckPermission(context.Background(), []string{"editor"}, "user", "delete")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	rec = doRBACRequest(t, handler, http.MethodPut, "/roles/editor/permissions/missing", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodDelete, "/roles/editor/permissions/user.delete", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodDelete, "/roles/editor", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = doRBACRequest(t, handler, http.MethodGet, "/roles/editor", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodGet, "/roles", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list struct {
+		Data []RoleResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 3)
+	assert.Equal(t, "admin", list.Data[0].ID)
+}
+
+func TestRBACHandler_Permissions(t *testing.T) {
+	_, handler := newRBACTestRouter(t)
+
+	rec := doRBACRequest(t, handler, http.MethodPost, "/permissions", `{"id": "doc.read", "resource": "doc", "action": "read"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodPost, "/permissions", `{"id": "doc.read", "resource": "doc", "action": "read"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodPost, "/permissions", `{"id": "doc.write", "resource": "doc"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodPut, "/permissions/doc.read", `{"resource": "doc", "action": "list"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodPut, "/permissions/missing", `{"resource": "doc", "action": "list"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodGet, "/permissions/doc.read", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp struct {
+		Data PermissionResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "list", resp.Data.Action)
+
+	rec = doRBACRequest(t, handler, http.MethodGet, "/permissions", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRBACRequest(t, handler, http.MethodDelete, "/permissions/doc.read", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = doRBACRequest(t, handler, http.MethodDelete, "/permissions/doc.read", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRBACHandler_Assignments(t *testing.T) {
+	system, handler := newRBACTestRouter(t)
+
+	rec := doRBACRequest(t, handler, http.MethodPut, "/domains/tenant-a/users/alice/roles/moderator", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = doRBACRequest(t, handler, http.MethodPut, "/domains/tenant-a/users/alice/roles/missing", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	allowed, err := system.CheckUserPermission(context.Background(), "tenant-a", "alice", "user", "update")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	rec = doRBACRequest(t, handler, http.MethodGet, "/domains/tenant-a/users/alice/roles", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var roles struct {
+		Data []string `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &roles))
+	assert.Equal(t, []string{"moderator"}, roles.Data)
+
+	rec = doRBACRequest(t, handler, http.MethodGet, "/assignments?domain=tenant-a", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var assignments struct {
+		Data []rbac.Assignment `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &assignments))
+	require.Len(t, assignments.Data, 1)
+	assert.Equal(t, "alice", assignments.Data[0].UserID)
+
+	rec = doRBACRequest(t, handler, http.MethodDelete, "/domains/tenant-a/users/alice/roles/moderator", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, system.UserRoles("tenant-a", "alice"))
+}
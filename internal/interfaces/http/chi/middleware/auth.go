@@ -5,12 +5,14 @@ import (
 
 	"github.com/yourusername/golang/pkg/security/jwt"
 	"github.com/yourusername/golang/pkg/security/rbac"
+	"github.com/yourusername/golang/pkg/security/rebac"
 )
 
 // AuthMiddleware 认证中间件配置
 type AuthMiddleware struct {
-	jwtMiddleware  *jwt.Middleware
-	rbacMiddleware *rbac.Middleware
+	jwtMiddleware   *jwt.Middleware
+	rbacMiddleware  *rbac.Middleware
+	rebacMiddleware *rebac.Middleware
 }
 
 // NewAuthMiddleware 创建认证中间件
@@ -21,6 +23,12 @@ func NewAuthMiddleware(jwtMw *jwt.Middleware, rbacMw *rbac.Middleware) *AuthMidd
 	}
 }
 
+// WithReBAC 设置 ReBAC 中间件，启用 RequireRelation
+func (am *AuthMiddleware) WithReBAC(rebacMw *rebac.Middleware) *AuthMiddleware {
+	am.rebacMiddleware = rebacMw
+	return am
+}
+
 // Authenticate JWT 认证
 func (am *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return am.jwtMiddleware.Authenticate(next)
@@ -39,6 +47,21 @@ func (am *AuthMiddleware) RequirePermission(resource, action string) func(http.H
 	}
 }
 
+// RequireRelation 要求当前用户与请求对象存在关系
+//
+// 先进行 JWT 认证，再将 JWT Claims 转换为 RBAC 上下文（ReBAC 中间件从中读取用户ID），最后检查关系。
+// 需要先通过 WithReBAC 设置 ReBAC 中间件，否则在构建路由时 panic，而不是在每个请求中 panic
+func (am *AuthMiddleware) RequireRelation(namespace, relation string, objectID rebac.ObjectIDFunc) func(http.Handler) http.Handler {
+	if am.rebacMiddleware == nil {
+		panic("middleware: RequireRelation requires WithReBAC to be called first")
+	}
+	requireRelation := am.rebacMiddleware.RequireRelation(namespace, relation, objectID)
+	return func(next http.Handler) http.Handler {
+		// 先认证，再检查对象关系
+		return am.jwtMiddleware.Authenticate(convertJWTClaimsToRBACContext(requireRelation(next)))
+	}
+}
+
 // RequireRole 要求特定角色
 func (am *AuthMiddleware) RequireRole(roles ...string) func(http.Handler) http.Handler {
 	return am.jwtMiddleware.RequireRoles(roles...)
@@ -49,10 +72,12 @@ func convertJWTClaimsToRBACContext(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// 从 JWT claims 获取角色
 		claims, ok := jwt.GetClaims(r.Context())
-		if ok && len(claims.Roles) > 0 {
-			// 将角色添加到 RBAC 上下文
-			ctx := rbac.WithUserRoles(r.Context(), claims.Roles)
-			ctx = rbac.WithUserID(ctx, claims.UserID)
+		if ok {
+			// 将用户ID和角色添加到 RBAC 上下文
+			ctx := rbac.WithUserID(r.Context(), claims.UserID)
+			if len(claims.Roles) > 0 {
+				ctx = rbac.WithUserRoles(ctx, claims.Roles)
+			}
 			r = r.WithContext(ctx)
 		}
 		next.ServeHTTP(w, r)
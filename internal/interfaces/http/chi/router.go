@@ -24,6 +24,7 @@
 // - /health - 健康检查
 // - /api/v1/users - 用户相关 API
 // - /api/v1/workflows - 工作流相关 API
+// - /api/v1/admin/rbac - RBAC 管理 API（MountRBACAdmin）
 package chi
 
 import (
@@ -180,6 +181,82 @@ func userRoutes(userHandler *handlers.UserHandler) http.Handler {
 	return r
 }
 
+// MountRBACAdmin 挂载 RBAC 管理 API
+//
+// 设计原理：
+// 1. RBAC 管理 API 是可选的，与 NewRouter 分开挂载
+// 2. 管理 API 必须受保护，guard 是必填参数，通常是认证和授权中间件（如 AuthMiddleware.RequirePermission）
+// 3. guard 为 nil 时在挂载时 panic，而不是暴露一个没有认证的角色和权限管理 API
+//
+// 路径：/api/v1/admin/rbac
+//
+// 参数：
+//   - rbacHandler: RBAC 管理处理器
+//   - guard: 保护管理 API 的认证授权中间件，不能为 nil
+//   - middlewares: 在 guard 之后执行的其他中间件
+//
+// 使用示例：
+//   router := chiRouter.NewRouter(userService, temporalHandler)
+//   router.MountRBACAdmin(handlers.NewRBACHandler(rbacSystem), authMiddleware.RequirePermission("rbac", "manage"))
+func (r *Router) MountRBACAdmin(rbacHandler *handlers.RBACHandler, guard func(http.Handler) http.Handler, middlewares ...func(http.Handler) http.Handler) {
+	if guard == nil {
+		panic("chi: MountRBACAdmin requires a non-nil guard middleware")
+	}
+	r.router.With(guard).With(middlewares...).Mount("/api/v1/admin/rbac", rbacAdminRoutes(rbacHandler))
+}
+
+// rbacAdminRoutes RBAC 管理路由
+//
+// 路由定义：
+// - GET    /roles                                      - 列出角色
+// - POST   /roles                                      - 创建角色
+// - GET    /roles/{id}                                 - 获取角色
+// - PUT    /roles/{id}                                 - 更新角色（整体替换权限和父角色）
+// - DELETE /roles/{id}                                 - 删除角色及其分配
+// - PUT    /roles/{id}/permissions/{permissionID}      - 授予权限
+// - DELETE /roles/{id}/permissions/{permissionID}      - 撤销权限
+// - GET    /permissions                                - 列出权限
+// - POST   /permissions                                - 创建权限
+// - GET    /permissions/{id}                           - 获取权限
+// - PUT    /permissions/{id}                           - 更新权限
+// - DELETE /permissions/{id}                           - 删除权限
+// - GET    /assignments                                - 列出角色分配
+// - GET    /domains/{domain}/users/{userID}/roles      - 用户在域中生效的角色
+// - PUT    /domains/{domain}/users/{userID}/roles/{roleID} - 分配角色
+// - DELETE /domains/{domain}/users/{userID}/roles/{roleID} - 撤销角色
+//
+// 参数：
+//   - rbacHandler: RBAC 管理处理器
+//
+// 返回：
+//   - http.Handler: 路由处理器
+func rbacAdminRoutes(rbacHandler *handlers.RBACHandler) http.Handler {
+	r := chi.NewRouter()
+	r.Route("/roles", func(r chi.Router) {
+		r.Get("/", rbacHandler.ListRoles)
+		r.Post("/", rbacHandler.CreateRole)
+		r.Get("/{id}", rbacHandler.GetRole)
+		r.Put("/{id}", rbacHandler.UpdateRole)
+		r.Delete("/{id}", rbacHandler.DeleteRole)
+		r.Put("/{id}/permissions/{permissionID}", rbacHandler.GrantPermission)
+		r.Delete("/{id}/permissions/{permissionID}", rbacHandler.RevokePermission)
+	})
+	r.Route("/permissions", func(r chi.Router) {
+		r.Get("/", rbacHandler.ListPermissions)
+		r.Post("/", rbacHandler.CreatePermission)
+		r.Get("/{id}", rbacHandler.GetPermission)
+		r.Put("/{id}", rbacHandler.UpdatePermission)
+		r.Delete("/{id}", rbacHandler.DeletePermission)
+	})
+	r.Get("/assignments", rbacHandler.ListAssignments)
+	r.Route("/domains/{domain}/users/{userID}/roles", func(r chi.Router) {
+		r.Get("/", rbacHandler.GetUserRoles)
+		r.Put("/{roleID}", rbacHandler.AssignRole)
+		r.Delete("/{roleID}", rbacHandler.RevokeRole)
+	})
+	return r
+}
+
 // workflowRoutes 工作流路由
 //
 // 设计原理：
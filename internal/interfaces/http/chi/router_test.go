@@ -3,11 +3,15 @@ package chi
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourusername/golang/internal/interfaces/http/chi/handlers"
+	"github.com/yourusername/golang/pkg/security/rbac"
 )
 
 func TestRouterStruct(t *testing.T) {
@@ -223,3 +227,35 @@ func TestTimeoutConfiguration(t *testing.T) {
 	middleware := TimeoutMiddleware(timeout)
 	assert.NotNil(t, middleware)
 }
+
+func TestRouter_MountRBACAdmin(t *testing.T) {
+	system := rbac.NewRBAC()
+	require.NoError(t, system.InitializeDefaultRoles())
+
+	router := &Router{router: chi.NewRouter()}
+	router.router.Route("/api/v1", func(r chi.Router) {
+		r.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	guard := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Admin") == "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	assert.Panics(t, func() { router.MountRBACAdmin(handlers.NewRBACHandler(system), nil) })
+	router.MountRBACAdmin(handlers.NewRBACHandler(system), guard)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/rbac/roles/admin", nil)
+	rec := httptest.NewRecorder()
+	router.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/rbac/roles/admin", nil)
+	req.Header.Set("X-Admin", "1")
+	rec = httptest.NewRecorder()
+	router.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
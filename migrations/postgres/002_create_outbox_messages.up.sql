@@ -0,0 +1,18 @@
+-- 创建事务性发件箱表
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id BIGSERIAL PRIMARY KEY,
+    message_id VARCHAR(36) UNIQUE NOT NULL,
+    aggregate_id VARCHAR(255),
+    event_type VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    metadata JSONB,
+    occurred_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+-- 创建索引（只索引未发布的消息，供 Relay 轮询）
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_unpublished ON outbox_messages(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_aggregate_id ON outbox_messages(aggregate_id);
@@ -0,0 +1,14 @@
+-- 创建 ReBAC 关系元组表：object#relation@subject
+-- subject_relation 为空字符串表示具体主体，非空表示用户集（如 group:eng#member）
+CREATE TABLE IF NOT EXISTS rebac_tuples (
+    namespace VARCHAR(255) NOT NULL,
+    object_id VARCHAR(255) NOT NULL,
+    relation VARCHAR(255) NOT NULL,
+    subject_namespace VARCHAR(255) NOT NULL,
+    subject_id VARCHAR(255) NOT NULL,
+    subject_relation VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+);
+
+-- 创建索引（按主体查询元组，如清理某个用户的全部关系）
+CREATE INDEX IF NOT EXISTS idx_rebac_tuples_subject ON rebac_tuples(subject_namespace, subject_id, subject_relation);
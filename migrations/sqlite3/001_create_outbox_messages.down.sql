@@ -0,0 +1,6 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_outbox_messages_aggregate_id;
+DROP INDEX IF EXISTS idx_outbox_messages_unpublished;
+
+-- 删除表
+DROP TABLE IF EXISTS outbox_messages;
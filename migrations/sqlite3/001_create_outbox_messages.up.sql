@@ -0,0 +1,18 @@
+-- 创建事务性发件箱表
+CREATE TABLE IF NOT EXISTS outbox_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id TEXT UNIQUE NOT NULL,
+    aggregate_id TEXT,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    metadata TEXT,
+    occurred_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at DATETIME,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+-- 创建索引（只索引未发布的消息，供 Relay 轮询）
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_unpublished ON outbox_messages(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_aggregate_id ON outbox_messages(aggregate_id);
@@ -0,0 +1,11 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_rbac_assignments_role_id;
+DROP INDEX IF EXISTS idx_rbac_assignments_user_id;
+DROP INDEX IF EXISTS idx_rbac_role_permissions_permission_id;
+
+-- 删除表
+DROP TABLE IF EXISTS rbac_assignments;
+DROP TABLE IF EXISTS rbac_role_parents;
+DROP TABLE IF EXISTS rbac_role_permissions;
+DROP TABLE IF EXISTS rbac_permissions;
+DROP TABLE IF EXISTS rbac_roles;
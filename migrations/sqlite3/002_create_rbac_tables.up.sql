@@ -0,0 +1,40 @@
+-- 创建 RBAC 表：角色、权限、角色-权限、角色继承、域内角色分配
+CREATE TABLE IF NOT EXISTS rbac_roles (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS rbac_permissions (
+    id TEXT PRIMARY KEY,
+    resource TEXT NOT NULL,
+    action TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS rbac_role_permissions (
+    role_id TEXT NOT NULL,
+    permission_id TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE IF NOT EXISTS rbac_role_parents (
+    role_id TEXT NOT NULL,
+    parent_id TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (role_id, parent_id)
+);
+
+CREATE TABLE IF NOT EXISTS rbac_assignments (
+    domain TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    role_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (domain, user_id, role_id)
+);
+
+-- 创建索引（按权限删除关联、按用户和角色查询分配）
+CREATE INDEX IF NOT EXISTS idx_rbac_role_permissions_permission_id ON rbac_role_permissions(permission_id);
+CREATE INDEX IF NOT EXISTS idx_rbac_assignments_user_id ON rbac_assignments(user_id);
+CREATE INDEX IF NOT EXISTS idx_rbac_assignments_role_id ON rbac_assignments(role_id);
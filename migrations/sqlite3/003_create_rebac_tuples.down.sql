@@ -0,0 +1,5 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_rebac_tuples_subject;
+
+-- 删除表
+DROP TABLE IF EXISTS rebac_tuples;
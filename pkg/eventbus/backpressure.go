@@ -0,0 +1,406 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrBufferFull 事件缓冲区已满，事件被丢弃
+	ErrBufferFull = errors.New("event buffer is full, event dropped")
+
+	// ErrSubscriberQueueFull 订阅者队列已满
+	// 被订阅者队列丢弃的事件会以此错误写入死信存储，可以重新投递
+	ErrSubscriberQueueFull = errors.New("subscriber queue is full")
+)
+
+// spillRetryInterval 读取溢出存储失败后的重试间隔
+const spillRetryInterval = 100 * time.Millisecond
+
+// OverflowPolicy 缓冲区满时的处理策略
+//
+// 策略说明：
+// - OverflowFail: 立即返回 ErrBufferFull（默认，与早期版本行为一致）
+// - OverflowBlock: 阻塞直到缓冲区有空位；PublishContext 在上下文取消或超时后返回
+// - OverflowDropNewest: 丢弃新事件，返回 nil
+// - OverflowDropOldest: 丢弃缓冲区中最旧的事件，为新事件腾出空间
+// - OverflowSpill: 将事件写入溢出存储（见 WithSpillStore），缓冲区有空位后按顺序取回
+type OverflowPolicy int
+
+const (
+	// OverflowFail 立即返回错误
+	OverflowFail OverflowPolicy = iota
+
+	// OverflowBlock 阻塞等待
+	OverflowBlock
+
+	// OverflowDropNewest 丢弃最新事件
+	OverflowDropNewest
+
+	// OverflowDropOldest 丢弃最旧事件
+	OverflowDropOldest
+
+	// OverflowSpill 溢出到磁盘
+	OverflowSpill
+)
+
+// String 返回策略名称
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowFail:
+		return "fail"
+	case OverflowBlock:
+		return "block"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowSpill:
+		return "spill"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// WithOverflowPolicy 设置事件缓冲区满时的处理策略
+//
+// 使用 OverflowSpill 时需要同时配置 WithSpillStore，否则按 OverflowFail 处理。
+//
+// 示例：
+//
+//	// 缓冲区满时阻塞发布者，最多等待 100ms
+//	eb := eventbus.NewEventBus(1000, eventbus.WithOverflowPolicy(eventbus.OverflowBlock))
+//
+//	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+//	defer cancel()
+//	if err := eb.PublishContext(ctx, event); errors.Is(err, eventbus.ErrBufferFull) {
+//	    // 超时仍未写入
+//	}
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(eb *EventBus) {
+		eb.overflow = policy
+	}
+}
+
+// WithSpillStore 设置溢出存储，配合 OverflowSpill 使用
+//
+// 设计原理：
+// 1. 缓冲区满时事件追加到溢出存储，而不是丢弃或阻塞发布者
+// 2. Start() 启动后台 goroutine，缓冲区有空位时按偏移量顺序将事件取回缓冲区
+// 3. 溢出存储中还有未取回的事件时，新事件也写入溢出存储，保持发布顺序
+// 4. 溢出存储实现 CommittableStore（如 FileEventStore）时，每取回一批事件提交一次偏移量，已取回的分段被删除，重启后从提交的偏移量继续取回
+//
+// 注意事项：
+// - 溢出存储应该专用，不要与 WithEventStore 共用同一个存储
+// - 不支持提交偏移量的存储只取回创建事件总线之后写入的事件
+// - 事件取回到缓冲区后即提交，缓冲区中尚未处理的事件在进程崩溃时会丢失；需要完整的持久化语义时使用 WithEventStore + Replay
+// - 使用 FileEventStore 时，取回事件的 Data() 为 json.RawMessage（Subscribe[T] 会自动解码）
+//
+// 示例：
+//
+//	spill, _ := eventbus.NewFileEventStore(eventbus.FileStoreConfig{Dir: "./data/spill"})
+//	eb := eventbus.NewEventBus(1000,
+//	    eventbus.WithOverflowPolicy(eventbus.OverflowSpill),
+//	    eventbus.WithSpillStore(spill),
+//	)
+func WithSpillStore(store EventStore) Option {
+	return func(eb *EventBus) {
+		eb.spill = store
+	}
+}
+
+// PublishContext 发布事件，缓冲区满时按溢出策略处理
+//
+// 参数：
+//   - ctx: 上下文，OverflowBlock 策略下用于控制最长等待时间
+//   - event: 要发布的事件
+//
+// 返回：
+//   - error: 发布失败时返回错误
+//   - ErrEventBusStopped: 事件总线已停止
+//   - ErrInvalidEventType: 事件类型包含通配符层级
+//   - ErrBufferFull: 缓冲区满（OverflowFail），或等待超时（OverflowBlock，同时包装 ctx.Err()）
+func (eb *EventBus) PublishContext(ctx context.Context, event Event) error {
+	// 首先检查 context 是否已取消
+	select {
+	case <-eb.ctx.Done():
+		return ErrEventBusStopped
+	default:
+	}
+
+	if err := validateEventType(event.Type()); err != nil {
+		return err
+	}
+
+	// 先持久化事件
+	if eb.store != nil {
+		if _, err := eb.store.Append(event); err != nil {
+			return fmt.Errorf("failed to persist event: %w", err)
+		}
+	}
+
+	return eb.enqueue(ctx, event, eb.overflow)
+}
+
+// enqueue 按溢出策略将事件放入缓冲区
+func (eb *EventBus) enqueue(ctx context.Context, event Event, policy OverflowPolicy) error {
+	if policy == OverflowSpill && eb.spill == nil {
+		policy = OverflowFail
+	}
+
+	// 溢出存储中还有积压时继续写入溢出存储，保持发布顺序
+	if policy == OverflowSpill && eb.spillBacklog() {
+		return eb.spillEvent(event)
+	}
+
+	select {
+	case eb.eventChan <- event:
+		eb.countPublished()
+		return nil
+	case <-eb.ctx.Done():
+		return ErrEventBusStopped
+	default:
+	}
+
+	switch policy {
+	case OverflowBlock:
+		select {
+		case eb.eventChan <- event:
+			eb.countPublished()
+			return nil
+		case <-eb.ctx.Done():
+			return ErrEventBusStopped
+		case <-ctx.Done():
+			eb.countDropped()
+			return fmt.Errorf("%w: %w", ErrBufferFull, ctx.Err())
+		}
+
+	case OverflowDropNewest:
+		eb.countDropped()
+		return nil
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case eb.eventChan <- event:
+				eb.countPublished()
+				return nil
+			case <-eb.ctx.Done():
+				return ErrEventBusStopped
+			default:
+			}
+
+			select {
+			case <-eb.eventChan:
+				eb.countDropped()
+			default:
+			}
+		}
+
+	case OverflowSpill:
+		return eb.spillEvent(event)
+
+	default:
+		eb.countDropped()
+		return ErrBufferFull
+	}
+}
+
+// countPublished 记录进入缓冲区的事件
+func (eb *EventBus) countPublished() {
+	eb.metrics.mu.Lock()
+	eb.metrics.TotalEvents++
+	eb.metrics.mu.Unlock()
+}
+
+// countDropped 记录被丢弃的事件
+func (eb *EventBus) countDropped() {
+	eb.metrics.mu.Lock()
+	eb.metrics.DroppedEvents++
+	eb.metrics.mu.Unlock()
+}
+
+// spillBacklog 溢出存储中是否还有未取回的事件
+func (eb *EventBus) spillBacklog() bool {
+	return eb.spill.NextOffset() > atomic.LoadUint64(&eb.spillOffset)
+}
+
+// spillEvent 将事件写入溢出存储
+func (eb *EventBus) spillEvent(event Event) error {
+	if _, err := eb.spill.Append(event); err != nil {
+		eb.countDropped()
+		return fmt.Errorf("failed to spill event: %w", err)
+	}
+
+	eb.metrics.mu.Lock()
+	eb.metrics.TotalEvents++
+	eb.metrics.SpilledEvents++
+	eb.metrics.mu.Unlock()
+
+	select {
+	case eb.spillNotify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// drainSpill 将溢出存储中的事件按顺序取回缓冲区（内部goroutine）
+func (eb *EventBus) drainSpill() {
+	defer eb.wg.Done()
+
+	for {
+		offset := atomic.LoadUint64(&eb.spillOffset)
+		batch, err := eb.spill.ReadFrom(offset, defaultReplayBatchSize)
+		if err != nil || len(batch) == 0 {
+			wait := eb.spillNotify
+			var retry <-chan time.Time
+			if err != nil {
+				retry = time.After(spillRetryInterval)
+				wait = nil
+			}
+			select {
+			case <-wait:
+			case <-retry:
+			case <-eb.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		for _, stored := range batch {
+			select {
+			case eb.eventChan <- stored.Event:
+				atomic.StoreUint64(&eb.spillOffset, stored.Offset+1)
+			case <-eb.ctx.Done():
+				eb.commitSpill()
+				return
+			}
+		}
+		eb.commitSpill()
+	}
+}
+
+// commitSpill 提交溢出存储的取回进度，回收已取回事件占用的空间
+//
+// 提交失败不影响取回，下次提交时会覆盖
+func (eb *EventBus) commitSpill() {
+	if committable, ok := eb.spill.(CommittableStore); ok {
+		_ = committable.Commit(atomic.LoadUint64(&eb.spillOffset))
+	}
+}
+
+// WithQueue 为订阅设置有界队列
+//
+// 设计原理：
+// 1. 事件先进入订阅自己的有界队列，由固定数量的 worker goroutine 处理
+// 2. 队列满时按订阅的溢出策略处理，慢处理器不会阻塞事件分发，也不会无限创建 goroutine
+// 3. 与 WithOrderedDelivery 同时使用时，size 为每个分区队列的容量，workers 被忽略（每个分区一个 goroutine）
+//
+// 参数：
+//   - size: 队列容量，<= 0 时使用事件总线的 bufferSize
+//   - workers: 并发处理的 goroutine 数，<= 0 时为 1
+//   - overflow: 队列满时的策略，支持 OverflowBlock、OverflowDropNewest、OverflowDropOldest，
+//     其他取值按 OverflowDropNewest 处理
+//
+// 注意事项：
+// - 被丢弃的事件计入 DroppedEvents，并以 ErrSubscriberQueueFull 写入死信存储，可以通过 RedriveDeadLetter 重新投递
+// - OverflowBlock 会在队列满时阻塞事件分发，影响其他订阅，只适合不能丢事件的场景
+// - 取消订阅后，队列中尚未处理的事件会被丢弃
+//
+// 示例：
+//
+//	// 最多积压 1000 个事件，4 个 worker 并发处理，积压时丢弃最旧的事件
+//	eb.Subscribe("metrics.reported", handler,
+//	    eventbus.WithQueue(1000, 4, eventbus.OverflowDropOldest),
+//	)
+func WithQueue(size, workers int, overflow OverflowPolicy) SubscribeOption {
+	return func(s *Subscription) {
+		if workers <= 0 {
+			workers = 1
+		}
+		switch overflow {
+		case OverflowBlock, OverflowDropNewest, OverflowDropOldest:
+		default:
+			overflow = OverflowDropNewest
+		}
+		s.QueueSize = size
+		s.QueueWorkers = workers
+		s.QueueOverflow = overflow
+	}
+}
+
+// bounded 是否配置了有界队列
+func (s *Subscription) bounded() bool {
+	return s.QueueWorkers > 0
+}
+
+// queueCapacity 返回订阅队列（或分区队列）的容量
+func (eb *EventBus) queueCapacity(sub *Subscription) int {
+	if sub.bounded() && sub.QueueSize > 0 {
+		return sub.QueueSize
+	}
+	return eb.bufferSize
+}
+
+// startQueue 为有界队列订阅启动 worker goroutine
+//
+// 调用方需要持有 eb.mu 写锁
+func (eb *EventBus) startQueue(sub *Subscription) {
+	sub.queue = make(chan Event, eb.queueCapacity(sub))
+	sub.done = make(chan struct{})
+
+	for i := 0; i < sub.QueueWorkers; i++ {
+		eb.wg.Add(1)
+		go eb.runWorker(sub, sub.queue)
+	}
+}
+
+// offer 按订阅的溢出策略将事件放入订阅队列
+//
+// 未配置 WithQueue 的有序订阅使用阻塞策略
+func (eb *EventBus) offer(sub *Subscription, queue chan Event, event Event) {
+	policy := OverflowBlock
+	if sub.bounded() {
+		policy = sub.QueueOverflow
+	}
+
+	switch policy {
+	case OverflowDropNewest:
+		select {
+		case queue <- event:
+		default:
+			eb.overflowed(sub, event)
+		}
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue <- event:
+				return
+			default:
+			}
+
+			select {
+			case old := <-queue:
+				eb.overflowed(sub, old)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case queue <- event:
+		case <-sub.done:
+		case <-eb.ctx.Done():
+		}
+	}
+}
+
+// overflowed 记录被订阅队列丢弃的事件
+func (eb *EventBus) overflowed(sub *Subscription, event Event) {
+	eb.countDropped()
+	eb.deadLetter(sub, event, ErrSubscriberQueueFull, 0)
+}
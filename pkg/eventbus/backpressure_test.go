@@ -0,0 +1,364 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOverflow_FailIsDefault(t *testing.T) {
+	eb := NewEventBus(1)
+	defer eb.Stop()
+
+	if err := eb.Publish(NewEvent("test", 1)); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if err := eb.Publish(NewEvent("test", 2)); !errors.Is(err, ErrBufferFull) {
+		t.Errorf("Expected ErrBufferFull, got %v", err)
+	}
+	if dropped := eb.GetMetrics().DroppedEvents; dropped != 1 {
+		t.Errorf("Expected 1 dropped event, got %d", dropped)
+	}
+}
+
+func TestOverflow_BlockWithDeadline(t *testing.T) {
+	eb := NewEventBus(1, WithOverflowPolicy(OverflowBlock))
+	defer eb.Stop()
+
+	if err := eb.Publish(NewEvent("test", 1)); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := eb.PublishContext(ctx, NewEvent("test", 2))
+	if !errors.Is(err, ErrBufferFull) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected ErrBufferFull wrapping DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestOverflow_BlockUntilSpace(t *testing.T) {
+	eb := NewEventBus(1, WithOverflowPolicy(OverflowBlock))
+	defer eb.Stop()
+
+	var mu sync.Mutex
+	var received []int
+	eb.Subscribe("test", func(ctx context.Context, event Event) error {
+		mu.Lock()
+		received = append(received, event.Data().(int))
+		mu.Unlock()
+		return nil
+	}, WithQueue(10, 1, OverflowBlock))
+
+	if err := eb.Publish(NewEvent("test", 1)); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	published := make(chan error, 1)
+	go func() {
+		published <- eb.Publish(NewEvent("test", 2))
+	}()
+
+	select {
+	case err := <-published:
+		t.Fatalf("Expected Publish to block, returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	eb.Start()
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish did not unblock after Start")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && eb.GetMetrics().HandledEvents < 2 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[0] != 1 || received[1] != 2 {
+		t.Errorf("Expected [1 2], got %v", received)
+	}
+}
+
+func TestOverflow_DropNewest(t *testing.T) {
+	eb := NewEventBus(2, WithOverflowPolicy(OverflowDropNewest))
+	defer eb.Stop()
+
+	for i := 1; i <= 4; i++ {
+		if err := eb.Publish(NewEvent("test", i)); err != nil {
+			t.Fatalf("Expected nil error for drop-newest, got %v", err)
+		}
+	}
+
+	if got := drainBuffer(eb); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("Expected oldest events [1 2] to be kept, got %v", got)
+	}
+	if dropped := eb.GetMetrics().DroppedEvents; dropped != 2 {
+		t.Errorf("Expected 2 dropped events, got %d", dropped)
+	}
+}
+
+func TestOverflow_DropOldest(t *testing.T) {
+	eb := NewEventBus(2, WithOverflowPolicy(OverflowDropOldest))
+	defer eb.Stop()
+
+	for i := 1; i <= 4; i++ {
+		if err := eb.Publish(NewEvent("test", i)); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	if got := drainBuffer(eb); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("Expected newest events [3 4] to be kept, got %v", got)
+	}
+	if dropped := eb.GetMetrics().DroppedEvents; dropped != 2 {
+		t.Errorf("Expected 2 dropped events, got %d", dropped)
+	}
+}
+
+func TestOverflow_SpillPreservesOrder(t *testing.T) {
+	store, err := NewFileEventStore(FileStoreConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create spill store: %v", err)
+	}
+	defer store.Close()
+
+	eb := NewEventBus(2, WithOverflowPolicy(OverflowSpill), WithSpillStore(store))
+	defer eb.Stop()
+
+	const total = 10
+	for i := 0; i < total; i++ {
+		if err := eb.Publish(NewEvent("test", i)); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+	if spilled := eb.GetMetrics().SpilledEvents; spilled != total-2 {
+		t.Errorf("Expected %d spilled events, got %d", total-2, spilled)
+	}
+
+	received := make(chan int, total)
+	_, err = Subscribe[int](eb, "test", TypedHandlerFunc[int](func(ctx context.Context, event *TypedEvent[int]) error {
+		received <- event.Payload()
+		return nil
+	}), WithQueue(total, 1, OverflowBlock))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	eb.Start()
+
+	for want := 0; want < total; want++ {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("Expected event %d, got %d", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for event %d", want)
+		}
+	}
+}
+
+func TestOverflow_SpillResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	config := FileStoreConfig{Dir: dir, MaxSegmentBytes: 256}
+
+	store, err := NewFileEventStore(config)
+	if err != nil {
+		t.Fatalf("Failed to create spill store: %v", err)
+	}
+
+	// 事件总线未启动，缓冲区满后的事件写入溢出存储，重启前没有被取回
+	eb := NewEventBus(2, WithOverflowPolicy(OverflowSpill), WithSpillStore(store))
+	for i := 0; i < 10; i++ {
+		eb.Publish(NewEvent("test", i))
+	}
+	eb.Stop()
+	store.Close()
+
+	store, err = NewFileEventStore(config)
+	if err != nil {
+		t.Fatalf("Failed to reopen spill store: %v", err)
+	}
+	defer store.Close()
+
+	eb = NewEventBus(2, WithOverflowPolicy(OverflowSpill), WithSpillStore(store))
+	defer eb.Stop()
+
+	received := make(chan int, 10)
+	_, err = Subscribe[int](eb, "test", TypedHandlerFunc[int](func(ctx context.Context, event *TypedEvent[int]) error {
+		received <- event.Payload()
+		return nil
+	}), WithQueue(10, 1, OverflowBlock))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	eb.Start()
+
+	// 缓冲区中的 0 和 1 随进程退出丢失，溢出的 2-9 从溢出存储取回
+	for want := 2; want < 10; want++ {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("Expected event %d, got %d", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for event %d", want)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for store.Committed() != 8 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if store.Committed() != 8 {
+		t.Fatalf("Expected committed offset 8, got %d", store.Committed())
+	}
+	if segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentFileExt)); len(segments) != 1 {
+		t.Errorf("Expected drained segments to be removed, got %d segments", len(segments))
+	}
+}
+
+func TestPublishAsync_DoesNotBlock(t *testing.T) {
+	eb := NewEventBus(1, WithOverflowPolicy(OverflowBlock))
+	defer eb.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		eb.PublishAsync(NewEvent("test", 1))
+		eb.PublishAsync(NewEvent("test", 2))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PublishAsync blocked")
+	}
+	metrics := eb.GetMetrics()
+	if metrics.TotalEvents != 1 || metrics.DroppedEvents != 1 {
+		t.Errorf("Expected 1 published and 1 dropped, got total=%d dropped=%d",
+			metrics.TotalEvents, metrics.DroppedEvents)
+	}
+}
+
+// failingStore Append 总是失败的事件存储
+type failingStore struct {
+	EventStore
+}
+
+func (failingStore) Append(Event) (uint64, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestPublishAsync_CountsStoreFailureAsDropped(t *testing.T) {
+	eb := NewEventBus(10, WithEventStore(failingStore{NewMemoryEventStore(10)}))
+	defer eb.Stop()
+
+	eb.PublishAsync(NewEvent("test", 1))
+
+	metrics := eb.GetMetrics()
+	if metrics.TotalEvents != 0 || metrics.DroppedEvents != 1 {
+		t.Errorf("Expected 0 published and 1 dropped, got total=%d dropped=%d",
+			metrics.TotalEvents, metrics.DroppedEvents)
+	}
+}
+
+func TestSubscriberQueue_SlowHandlerDoesNotStarveOthers(t *testing.T) {
+	eb := NewEventBus(100)
+	eb.Start()
+	defer eb.Stop()
+
+	release := make(chan struct{})
+	slowSub, err := eb.Subscribe("test", func(ctx context.Context, event Event) error {
+		<-release
+		return nil
+	}, WithQueue(2, 1, OverflowDropNewest))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	fast := make(chan int, 20)
+	eb.Subscribe("test", func(ctx context.Context, event Event) error {
+		fast <- event.Data().(int)
+		return nil
+	}, WithQueue(20, 1, OverflowBlock))
+
+	const total = 10
+	for i := 0; i < total; i++ {
+		if err := eb.Publish(NewEvent("test", i)); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	for i := 0; i < total; i++ {
+		select {
+		case <-fast:
+		case <-time.After(time.Second):
+			t.Fatalf("Fast subscriber starved after %d events", i)
+		}
+	}
+	close(release)
+
+	// 慢订阅：1 个正在处理，2 个在队列中，其余被丢弃并写入死信
+	letters, err := eb.ListDeadLetters()
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) < total-3 {
+		t.Fatalf("Expected at least %d dead letters, got %d", total-3, len(letters))
+	}
+	for _, letter := range letters {
+		if letter.SubscriptionID != slowSub || letter.Error != ErrSubscriberQueueFull.Error() {
+			t.Errorf("Unexpected dead letter: %s %s", letter.SubscriptionID, letter.Error)
+		}
+	}
+	if dropped := eb.GetMetrics().DroppedEvents; dropped != int64(len(letters)) {
+		t.Errorf("Expected %d dropped events, got %d", len(letters), dropped)
+	}
+}
+
+func TestSubscriberQueue_OrderedDropOldest(t *testing.T) {
+	eb := NewEventBus(100)
+	defer eb.Stop()
+
+	sub, err := eb.Subscribe("test", func(ctx context.Context, event Event) error {
+		return nil
+	}, WithOrderedDelivery(MetadataKeyAggregateID, 1), WithQueue(2, 1, OverflowDropOldest))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// WithQueue 的容量作用于每个分区队列
+	eb.mu.RLock()
+	s := eb.subIndex[sub]
+	eb.mu.RUnlock()
+	if cap(s.partitions[0]) != 2 {
+		t.Errorf("Expected partition capacity 2, got %d", cap(s.partitions[0]))
+	}
+	if s.QueueOverflow != OverflowDropOldest {
+		t.Errorf("Expected drop-oldest policy, got %s", s.QueueOverflow)
+	}
+}
+
+// drainBuffer 读出缓冲区中的全部事件数据
+func drainBuffer(eb *EventBus) []int {
+	var result []int
+	for {
+		select {
+		case event := <-eb.eventChan:
+			result = append(result, event.Data().(int))
+		default:
+			return result
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultDeadLetterCapacity 默认内存死信存储容量
+const defaultDeadLetterCapacity = 10000
+
+// ErrDeadLetterNotFound 死信未找到
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter 死信
+//
+// 设计原理：
+// 1. 达到最大重试次数后仍然失败的事件会被保存为死信，而不是直接丢弃
+// 2. 死信记录了原始事件、所属订阅和最后一次错误，便于排查和重新投递
+//
+// 字段说明：
+// - ID: 死信唯一标识
+// - SubscriptionID: 处理失败的订阅ID
+// - EventType: 事件类型
+// - Event: 原始事件
+// - Error: 最后一次处理错误
+// - Attempts: 已尝试次数
+// - FailedAt: 进入死信存储的时间
+type DeadLetter struct {
+	// ID 死信唯一标识
+	ID string
+
+	// SubscriptionID 处理失败的订阅ID
+	SubscriptionID string
+
+	// EventType 事件类型
+	EventType string
+
+	// Event 原始事件
+	Event Event
+
+	// Error 最后一次处理错误
+	Error string
+
+	// Attempts 已尝试次数
+	Attempts int
+
+	// FailedAt 进入死信存储的时间
+	FailedAt time.Time
+}
+
+// DeadLetterStore 死信存储接口
+//
+// 实现要求：
+// - 所有方法必须是并发安全的
+// - List 按 FailedAt 升序返回死信
+// - Get/Remove 在死信不存在时返回 ErrDeadLetterNotFound
+type DeadLetterStore interface {
+	// Add 添加死信
+	Add(letter DeadLetter) error
+
+	// List 列出所有死信
+	List() ([]DeadLetter, error)
+
+	// Get 获取指定死信
+	Get(id string) (DeadLetter, error)
+
+	// Remove 删除指定死信
+	Remove(id string) error
+
+	// Purge 清空所有死信
+	Purge() error
+}
+
+// MemoryDeadLetterStore 内存死信存储
+//
+// 超出容量时淘汰最早的死信，避免内存无限增长
+type MemoryDeadLetterStore struct {
+	letters  map[string]DeadLetter
+	order    []string
+	capacity int
+	mu       sync.RWMutex
+}
+
+// NewMemoryDeadLetterStore 创建内存死信存储
+//
+// 参数：
+//   - capacity: 最多保留的死信数量，<= 0 表示不限制
+func NewMemoryDeadLetterStore(capacity int) *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{
+		letters:  make(map[string]DeadLetter),
+		capacity: capacity,
+	}
+}
+
+// Add 添加死信
+func (s *MemoryDeadLetterStore) Add(letter DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.letters[letter.ID]; !exists {
+		s.order = append(s.order, letter.ID)
+	}
+	s.letters[letter.ID] = letter
+
+	for s.capacity > 0 && len(s.order) > s.capacity {
+		delete(s.letters, s.order[0])
+		s.order = s.order[1:]
+	}
+	return nil
+}
+
+// List 列出所有死信
+func (s *MemoryDeadLetterStore) List() ([]DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]DeadLetter, 0, len(s.order))
+	for _, id := range s.order {
+		result = append(result, s.letters[id])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FailedAt.Before(result[j].FailedAt)
+	})
+	return result, nil
+}
+
+// Get 获取指定死信
+func (s *MemoryDeadLetterStore) Get(id string) (DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	letter, exists := s.letters[id]
+	if !exists {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return letter, nil
+}
+
+// Remove 删除指定死信
+func (s *MemoryDeadLetterStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.letters[id]; !exists {
+		return ErrDeadLetterNotFound
+	}
+	delete(s.letters, id)
+	for i, existing := range s.order {
+		if existing == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Purge 清空所有死信
+func (s *MemoryDeadLetterStore) Purge() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.letters = make(map[string]DeadLetter)
+	s.order = nil
+	return nil
+}
+
+// WithDeadLetterStore 设置死信存储
+//
+// 默认使用容量为 10000 的 MemoryDeadLetterStore
+func WithDeadLetterStore(store DeadLetterStore) Option {
+	return func(eb *EventBus) {
+		eb.deadLetters = store
+	}
+}
+
+// deadLetter 将失败事件写入死信存储
+func (eb *EventBus) deadLetter(sub *Subscription, event Event, err error, attempts int) {
+	if eb.deadLetters == nil {
+		return
+	}
+
+	letter := DeadLetter{
+		ID:             fmt.Sprintf("dlq_%d", atomic.AddInt64(&eb.nextDeadLetterID, 1)),
+		SubscriptionID: sub.ID,
+		EventType:      event.Type(),
+		Event:          event,
+		Attempts:       attempts,
+		FailedAt:       time.Now(),
+	}
+	if err != nil {
+		letter.Error = err.Error()
+	}
+
+	if addErr := eb.deadLetters.Add(letter); addErr == nil {
+		eb.metrics.mu.Lock()
+		eb.metrics.DeadLetteredEvents++
+		eb.metrics.mu.Unlock()
+	}
+}
+
+// ListDeadLetters 列出所有死信
+//
+// 示例：
+//
+//	letters, _ := eb.ListDeadLetters()
+//	for _, letter := range letters {
+//	    log.Printf("%s: %s failed %d times: %s",
+//	        letter.ID, letter.EventType, letter.Attempts, letter.Error)
+//	}
+func (eb *EventBus) ListDeadLetters() ([]DeadLetter, error) {
+	if eb.deadLetters == nil {
+		return nil, nil
+	}
+	return eb.deadLetters.List()
+}
+
+// RedriveDeadLetter 重新投递死信
+//
+// 设计原理：
+// 1. 从死信存储中取出死信，按原订阅的重试策略重新投递给原订阅
+// 2. 在调用方 goroutine 中同步执行，调用方可以直接得到投递结果
+// 3. 重新投递仍然失败时，事件会作为新的死信写回存储
+//
+// 参数：
+//   - ctx: 上下文，用于取消重新投递
+//   - id: 死信ID
+//
+// 返回：
+//   - error: 重新投递失败时返回错误
+//   - ErrDeadLetterNotFound: 死信不存在
+//   - ErrSubscriptionNotFound: 原订阅已取消（死信保留在存储中）
+//
+// 示例：
+//
+//	if err := eb.RedriveDeadLetter(ctx, "dlq_1"); err != nil {
+//	    log.Printf("redrive failed: %v", err)
+//	}
+func (eb *EventBus) RedriveDeadLetter(ctx context.Context, id string) error {
+	if eb.deadLetters == nil {
+		return ErrDeadLetterNotFound
+	}
+
+	letter, err := eb.deadLetters.Get(id)
+	if err != nil {
+		return err
+	}
+
+	eb.mu.RLock()
+	sub, exists := eb.subIndex[letter.SubscriptionID]
+	eb.mu.RUnlock()
+	if !exists {
+		return ErrSubscriptionNotFound
+	}
+
+	if err := eb.deadLetters.Remove(id); err != nil {
+		return err
+	}
+
+	return eb.deliver(ctx, sub, letter.Event)
+}
+
+// RemoveDeadLetter 删除指定死信（放弃投递）
+func (eb *EventBus) RemoveDeadLetter(id string) error {
+	if eb.deadLetters == nil {
+		return ErrDeadLetterNotFound
+	}
+	return eb.deadLetters.Remove(id)
+}
+
+// PurgeDeadLetters 清空所有死信
+func (eb *EventBus) PurgeDeadLetters() error {
+	if eb.deadLetters == nil {
+		return nil
+	}
+	return eb.deadLetters.Purge()
+}
@@ -0,0 +1,200 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestEventBus_RetrySucceeds(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+	defer eb.Stop()
+
+	var calls int32
+	handler := func(ctx context.Context, event Event) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}
+
+	_, err := eb.Subscribe("test.event", handler,
+		WithMaxAttempts(3),
+		WithBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	eb.Publish(NewEvent("test.event", "test"))
+	time.Sleep(100 * time.Millisecond)
+
+	metrics := eb.GetMetrics()
+	if metrics.HandledEvents != 1 || metrics.RetriedEvents != 2 || metrics.FailedEvents != 0 {
+		t.Errorf("Unexpected metrics: handled=%d retried=%d failed=%d dead-lettered=%d",
+			metrics.HandledEvents, metrics.RetriedEvents, metrics.FailedEvents, metrics.DeadLetteredEvents)
+	}
+	if letters, _ := eb.ListDeadLetters(); len(letters) != 0 {
+		t.Errorf("Expected no dead letters, got %d", len(letters))
+	}
+}
+
+func TestEventBus_HandlerTimeout(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+	defer eb.Stop()
+
+	handler := func(ctx context.Context, event Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	eb.Subscribe("test.event", handler, WithHandlerTimeout(10*time.Millisecond))
+	eb.Publish(NewEvent("test.event", "test"))
+	time.Sleep(100 * time.Millisecond)
+
+	letters, _ := eb.ListDeadLetters()
+	if len(letters) != 1 || letters[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Expected timed out event in dead letters, got %+v", letters)
+	}
+}
+
+func TestEventBus_DeadLetterRedrive(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+	defer eb.Stop()
+
+	var healthy atomic.Bool
+	var handled int32
+	handler := func(ctx context.Context, event Event) error {
+		if !healthy.Load() {
+			return errors.New("downstream unavailable")
+		}
+		atomic.AddInt32(&handled, 1)
+		return nil
+	}
+
+	subID, _ := eb.Subscribe("billing.charged", handler,
+		WithMaxAttempts(2),
+		WithBackoff(time.Millisecond, time.Millisecond),
+	)
+
+	eb.Publish(NewEvent("billing.charged", "invoice-1"))
+	eb.Publish(NewEvent("billing.charged", "invoice-2"))
+	time.Sleep(100 * time.Millisecond)
+
+	letters, err := eb.ListDeadLetters()
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) != 2 {
+		t.Fatalf("Expected 2 dead letters, got %d", len(letters))
+	}
+	if letters[0].SubscriptionID != subID || letters[0].Attempts != 2 {
+		t.Errorf("Unexpected dead letter: %+v", letters[0])
+	}
+
+	metrics := eb.GetMetrics()
+	if metrics.FailedEvents != 2 || metrics.DeadLetteredEvents != 2 {
+		t.Errorf("Unexpected metrics: handled=%d retried=%d failed=%d dead-lettered=%d",
+			metrics.HandledEvents, metrics.RetriedEvents, metrics.FailedEvents, metrics.DeadLetteredEvents)
+	}
+
+	// 下游恢复后重新投递
+	healthy.Store(true)
+	if err := eb.RedriveDeadLetter(context.Background(), letters[0].ID); err != nil {
+		t.Fatalf("Failed to redrive: %v", err)
+	}
+	if atomic.LoadInt32(&handled) != 1 {
+		t.Errorf("Expected redriven event to be handled")
+	}
+	if err := eb.RedriveDeadLetter(context.Background(), letters[0].ID); err != ErrDeadLetterNotFound {
+		t.Errorf("Expected ErrDeadLetterNotFound, got %v", err)
+	}
+
+	if err := eb.PurgeDeadLetters(); err != nil {
+		t.Fatalf("Failed to purge: %v", err)
+	}
+	if letters, _ := eb.ListDeadLetters(); len(letters) != 0 {
+		t.Errorf("Expected no dead letters after purge, got %d", len(letters))
+	}
+}
+
+func TestMemoryDeadLetterStore_Capacity(t *testing.T) {
+	store := NewMemoryDeadLetterStore(2)
+	for _, id := range []string{"a", "b", "c"} {
+		store.Add(DeadLetter{ID: id, FailedAt: time.Now()})
+	}
+
+	letters, _ := store.List()
+	if len(letters) != 2 || letters[0].ID != "b" {
+		t.Errorf("Expected oldest dead letter to be evicted, got %+v", letters)
+	}
+	if _, err := store.Get("a"); err != ErrDeadLetterNotFound {
+		t.Errorf("Expected ErrDeadLetterNotFound, got %v", err)
+	}
+	if err := store.Remove("b"); err != nil {
+		t.Errorf("Failed to remove: %v", err)
+	}
+}
+
+func TestEventBus_PublishSyncReturnsHandlerOutcome(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+	defer eb.Stop()
+
+	var calls int32
+	eb.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	})
+	failing, _ := eb.Subscribe("test.*", func(ctx context.Context, event Event) error {
+		return errors.New("permanent failure")
+	}, WithMaxAttempts(2), WithBackoff(time.Millisecond, time.Millisecond))
+
+	// 返回前所有处理器已经执行完成，失败的订阅写入死信存储并返回错误
+	err := eb.PublishSync(context.Background(), NewEvent("test.event", "test"))
+	if err == nil || !strings.Contains(err.Error(), failing) {
+		t.Errorf("Expected failure of subscription %s, got %v", failing, err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected handler to run once before PublishSync returns, got %d", n)
+	}
+	if letters, _ := eb.ListDeadLetters(); len(letters) != 1 {
+		t.Errorf("Expected 1 dead letter, got %d", len(letters))
+	}
+
+	if err := eb.Unsubscribe(failing); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if err := eb.PublishSync(context.Background(), NewEvent("test.event", "test")); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
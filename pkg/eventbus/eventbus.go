@@ -58,6 +58,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -181,6 +182,20 @@ func NewEvent(eventType string, data interface{}) *BaseEvent {
 	}
 }
 
+// NewEventAt 创建指定时间戳的基础事件
+//
+// 用于还原已经发生过的事件，如从存储重放或从消息中间件接收的事件，
+// 保留事件原始的发生时间
+//
+// 示例：
+//
+//	event := eventbus.NewEventAt("user.created", data, envelope.Timestamp)
+func NewEventAt(eventType string, data interface{}, timestamp time.Time) *BaseEvent {
+	event := NewEvent(eventType, data)
+	event.timestamp = timestamp
+	return event
+}
+
 // Type 返回事件类型
 func (e *BaseEvent) Type() string {
 	return e.eventType
@@ -240,6 +255,19 @@ func (e *BaseEvent) GetMetadata(key string) (interface{}, bool) {
 	return value, ok
 }
 
+// Metadata 返回全部元数据的副本
+//
+// 用途：
+// - 事件持久化时保存元数据（见 FileEventStore）
+// - 跨进程传递事件时携带上下文信息
+func (e *BaseEvent) Metadata() map[string]interface{} {
+	result := make(map[string]interface{}, len(e.metadata))
+	for k, v := range e.metadata {
+		result[k] = v
+	}
+	return result
+}
+
 // Handler 事件处理器函数类型
 //
 // 设计原理：
@@ -316,6 +344,10 @@ type Filter func(event Event) bool
 // - EventType: 订阅的事件类型
 // - Handler: 事件处理器函数
 // - Filter: 事件过滤器函数（可选）
+// - RetryPolicy: 重试策略（默认不重试）
+// - Timeout: 单次处理器执行超时时间（默认 5 秒）
+// - OrderingKey/Partitions: 有序投递配置（见 WithOrderedDelivery）
+// - QueueSize/QueueWorkers/QueueOverflow: 有界队列配置（见 WithQueue）
 // - CreatedAt: 订阅创建时间
 type Subscription struct {
 	// ID 订阅唯一标识
@@ -331,8 +363,42 @@ type Subscription struct {
 	// 如果为 nil，则处理所有匹配类型的事件
 	Filter Filter
 
+	// RetryPolicy 重试策略
+	// 零值表示只执行一次，失败后直接进入死信存储
+	RetryPolicy RetryPolicy
+
+	// Timeout 单次处理器执行超时时间
+	// <= 0 时使用默认值 5 秒
+	Timeout time.Duration
+
+	// OrderingKey 有序投递的分区键（元数据键）
+	// 为空时每个事件在独立的 goroutine 中处理，不保证顺序
+	OrderingKey string
+
+	// Partitions 有序投递的分区数
+	Partitions int
+
+	// QueueSize 有界队列容量（见 WithQueue），<= 0 时使用事件总线的 bufferSize
+	QueueSize int
+
+	// QueueWorkers 有界队列的 worker 数
+	// 为 0 时不使用有界队列，每个事件在独立的 goroutine 中处理
+	QueueWorkers int
+
+	// QueueOverflow 有界队列满时的策略
+	QueueOverflow OverflowPolicy
+
 	// CreatedAt 订阅创建时间
 	CreatedAt time.Time
+
+	// partitions 有序投递的分区队列
+	partitions []chan Event
+
+	// queue 有界队列（无序订阅）
+	queue chan Event
+
+	// done 关闭后分区和 worker goroutine 退出
+	done chan struct{}
 }
 
 // EventBus 事件总线
@@ -364,6 +430,10 @@ type EventBus struct {
 	// key: 事件类型，value: 订阅列表
 	subscriptions map[string][]*Subscription
 
+	// patterns 通配符订阅前缀树（如 "user.*"、"order.>"）
+	// 精确订阅不进入前缀树，不影响精确匹配的性能
+	patterns *topicTrie
+
 	// subIndex 订阅ID到订阅的映射
 	// 用于快速查找和取消订阅
 	subIndex map[string]*Subscription
@@ -391,6 +461,50 @@ type EventBus struct {
 
 	// nextSubID 下一个订阅ID的计数器
 	nextSubID int64
+
+	// store 事件存储（可选）
+	// 配置后，发布的事件会先写入存储，支持重放
+	store EventStore
+
+	// deadLetters 死信存储，保存重试耗尽后仍然失败的事件
+	deadLetters DeadLetterStore
+
+	// nextDeadLetterID 下一个死信ID的计数器
+	nextDeadLetterID int64
+
+	// overflow 缓冲区满时的处理策略
+	overflow OverflowPolicy
+
+	// spill 溢出存储（OverflowSpill 策略使用）
+	spill EventStore
+
+	// spillOffset 下一个待取回的溢出事件偏移量
+	spillOffset uint64
+
+	// spillNotify 有新的溢出事件时通知取回 goroutine
+	spillNotify chan struct{}
+}
+
+// Option 事件总线配置选项
+type Option func(*EventBus)
+
+// WithEventStore 设置事件存储
+//
+// 配置后：
+// - Publish() 会先将事件追加到存储，再放入缓冲区
+// - 缓冲区满时事件虽然不会被实时分发，但仍然保留在存储中，可以通过 Replay() 重放
+// - 存储的生命周期由调用方管理，Stop() 不会关闭存储
+//
+// 示例：
+//
+//	store, _ := eventbus.NewFileEventStore(eventbus.FileStoreConfig{Dir: "./data/events"})
+//	defer store.Close()
+//
+//	eb := eventbus.NewEventBus(100, eventbus.WithEventStore(store))
+func WithEventStore(store EventStore) Option {
+	return func(eb *EventBus) {
+		eb.store = store
+	}
 }
 
 // Metrics 事件总线指标
@@ -403,8 +517,11 @@ type EventBus struct {
 // 指标说明：
 // - TotalEvents: 总事件数（包括成功、失败、丢弃）
 // - HandledEvents: 成功处理的事件数
-// - FailedEvents: 处理失败的事件数
-// - DroppedEvents: 因缓冲区满而丢弃的事件数
+// - FailedEvents: 处理失败的事件数（重试耗尽后）
+// - RetriedEvents: 重试次数
+// - DeadLetteredEvents: 进入死信存储的事件数
+// - DroppedEvents: 因缓冲区或订阅者队列满而丢弃的事件数
+// - SpilledEvents: 缓冲区满时写入溢出存储的事件数
 // - ActiveSubscriptions: 当前活跃的订阅数
 //
 // 使用场景：
@@ -428,9 +545,18 @@ type Metrics struct {
 	// FailedEvents 处理失败的事件数
 	FailedEvents int64
 
-	// DroppedEvents 因缓冲区满而丢弃的事件数
+	// RetriedEvents 重试次数
+	RetriedEvents int64
+
+	// DeadLetteredEvents 进入死信存储的事件数
+	DeadLetteredEvents int64
+
+	// DroppedEvents 因缓冲区或订阅者队列满而丢弃的事件数
 	DroppedEvents int64
 
+	// SpilledEvents 写入溢出存储的事件数
+	SpilledEvents int64
+
 	// ActiveSubscriptions 当前活跃的订阅数
 	ActiveSubscriptions int
 
@@ -443,6 +569,7 @@ type Metrics struct {
 // 参数：
 //   - bufferSize: 事件缓冲区大小，用于缓冲待处理的事件
 //     如果 bufferSize <= 0，则使用默认值 100
+//   - opts: 可选配置，如 WithEventStore()、WithOverflowPolicy()
 //
 // 返回：
 //   - *EventBus: 创建的事件总线实例
@@ -469,21 +596,38 @@ type Metrics struct {
 // - 低频率事件（< 100/秒）：100-500
 // - 中频率事件（100-1000/秒）：500-2000
 // - 高频率事件（> 1000/秒）：2000-10000
-func NewEventBus(bufferSize int) *EventBus {
+func NewEventBus(bufferSize int, opts ...Option) *EventBus {
 	if bufferSize <= 0 {
 		bufferSize = 100
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &EventBus{
+	eb := &EventBus{
 		subscriptions: make(map[string][]*Subscription),
+		patterns:      newTopicTrie(),
 		subIndex:      make(map[string]*Subscription),
 		eventChan:     make(chan Event, bufferSize),
 		ctx:           ctx,
 		cancel:        cancel,
 		bufferSize:    bufferSize,
+		deadLetters:   NewMemoryDeadLetterStore(defaultDeadLetterCapacity),
 	}
+
+	for _, opt := range opts {
+		opt(eb)
+	}
+
+	if eb.spill != nil {
+		eb.spillOffset = eb.spill.NextOffset()
+		// 支持提交偏移量的溢出存储从上次提交处继续取回，重启前溢出但未取回的事件不会丢失
+		if committable, ok := eb.spill.(CommittableStore); ok {
+			eb.spillOffset = committable.Committed()
+		}
+		eb.spillNotify = make(chan struct{}, 1)
+	}
+
+	return eb
 }
 
 // Start 启动事件总线
@@ -510,6 +654,11 @@ func NewEventBus(bufferSize int) *EventBus {
 func (eb *EventBus) Start() error {
 	eb.wg.Add(1)
 	go eb.processEvents()
+
+	if eb.spill != nil {
+		eb.wg.Add(1)
+		go eb.drainSpill()
+	}
 	return nil
 }
 
@@ -549,8 +698,9 @@ func (eb *EventBus) Stop() error {
 // Subscribe 订阅事件
 //
 // 参数：
-//   - eventType: 事件类型，如 "user.created"
+//   - eventType: 事件类型，如 "user.created"，支持通配符模式（见 SubscribeWithFilter）
 //   - handler: 事件处理器函数
+//   - opts: 订阅选项，如 WithMaxAttempts()、WithBackoff()、WithHandlerTimeout()
 //
 // 返回：
 //   - string: 订阅ID，用于取消订阅
@@ -571,20 +721,32 @@ func (eb *EventBus) Stop() error {
 //
 //	// 稍后取消订阅
 //	eventBus.Unsubscribe(subID)
-func (eb *EventBus) Subscribe(eventType string, handler Handler) (string, error) {
-	return eb.SubscribeWithFilter(eventType, handler, nil)
+//
+//	// 失败时最多尝试 5 次，指数退避
+//	subID, err = eventBus.Subscribe("billing.charged", handler,
+//	    eventbus.WithMaxAttempts(5),
+//	    eventbus.WithBackoff(200*time.Millisecond, 10*time.Second),
+//	)
+func (eb *EventBus) Subscribe(eventType string, handler Handler, opts ...SubscribeOption) (string, error) {
+	return eb.SubscribeWithFilter(eventType, handler, nil, opts...)
 }
 
 // SubscribeWithFilter 订阅事件（带过滤器）
 //
 // 参数：
-//   - eventType: 事件类型
+//   - eventType: 事件类型或通配符模式
 //   - handler: 事件处理器函数
 //   - filter: 事件过滤器函数（可选，nil 表示不过滤）
+//   - opts: 订阅选项
 //
 // 返回：
 //   - string: 订阅ID，用于取消订阅
-//   - error: 订阅失败时返回错误（当前实现总是返回 nil）
+//   - error: 订阅失败时返回错误
+//   - ErrInvalidPattern: 通配符模式非法
+//
+// 通配符模式（按 "." 分层）：
+// - "*" 匹配恰好一个层级："user.*" 匹配 "user.created"，不匹配 "user.profile.updated"
+// - ">" 匹配剩余的一个或多个层级，只能出现在末尾："order.>" 匹配 "order.created" 和 "order.item.added"
 //
 // 过滤器说明：
 // - 过滤器在处理器执行前调用
@@ -605,7 +767,14 @@ func (eb *EventBus) Subscribe(eventType string, handler Handler) (string, error)
 //	}
 //
 //	subID, err := eventBus.SubscribeWithFilter("user.created", handler, filter)
-func (eb *EventBus) SubscribeWithFilter(eventType string, handler Handler, filter Filter) (string, error) {
+func (eb *EventBus) SubscribeWithFilter(eventType string, handler Handler, filter Filter, opts ...SubscribeOption) (string, error) {
+	pattern := isPattern(eventType)
+	if pattern {
+		if err := validatePattern(eventType); err != nil {
+			return "", fmt.Errorf("%w: %q", err, eventType)
+		}
+	}
+
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
@@ -619,8 +788,20 @@ func (eb *EventBus) SubscribeWithFilter(eventType string, handler Handler, filte
 		Filter:    filter,
 		CreatedAt: time.Now(),
 	}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	if sub.ordered() {
+		eb.startPartitions(sub)
+	} else if sub.bounded() {
+		eb.startQueue(sub)
+	}
 
-	eb.subscriptions[eventType] = append(eb.subscriptions[eventType], sub)
+	if pattern {
+		eb.patterns.insert(sub)
+	} else {
+		eb.subscriptions[eventType] = append(eb.subscriptions[eventType], sub)
+	}
 	eb.subIndex[subID] = sub
 
 	eb.metrics.mu.Lock()
@@ -662,15 +843,20 @@ func (eb *EventBus) Unsubscribe(subscriptionID string) error {
 	}
 
 	// 从订阅列表中移除
-	subs := eb.subscriptions[sub.EventType]
-	for i, s := range subs {
-		if s.ID == subscriptionID {
-			eb.subscriptions[sub.EventType] = append(subs[:i], subs[i+1:]...)
-			break
+	if isPattern(sub.EventType) {
+		eb.patterns.remove(sub)
+	} else {
+		subs := eb.subscriptions[sub.EventType]
+		for i, s := range subs {
+			if s.ID == subscriptionID {
+				eb.subscriptions[sub.EventType] = append(subs[:i], subs[i+1:]...)
+				break
+			}
 		}
 	}
 
 	delete(eb.subIndex, subscriptionID)
+	sub.stopWorkers()
 
 	eb.metrics.mu.Lock()
 	eb.metrics.ActiveSubscriptions--
@@ -682,9 +868,10 @@ func (eb *EventBus) Unsubscribe(subscriptionID string) error {
 // Publish 发布事件
 //
 // 设计原理：
-// 1. 将事件放入缓冲区，不阻塞调用者
-// 2. 如果缓冲区满，则丢弃事件并返回错误
+// 1. 将事件放入缓冲区，默认不阻塞调用者
+// 2. 如果缓冲区满，按溢出策略处理（见 WithOverflowPolicy），默认丢弃事件并返回 ErrBufferFull
 // 3. 如果事件总线已停止，则返回错误
+// 4. 如果配置了事件存储，事件会先写入存储（缓冲区满时仍可重放）
 //
 // 参数：
 //   - event: 要发布的事件
@@ -692,63 +879,40 @@ func (eb *EventBus) Unsubscribe(subscriptionID string) error {
 // 返回：
 //   - error: 发布失败时返回错误
 //   - ErrEventBusStopped: 事件总线已停止
-//   - 其他错误: 缓冲区满，事件被丢弃
+//   - ErrInvalidEventType: 事件类型包含 "*" 或 ">" 层级（通配符只能用于订阅）
+//   - ErrBufferFull: 缓冲区满，事件被丢弃
 //
 // 注意事项：
 // - 发布是异步的，不会等待事件处理完成
-// - 如果缓冲区满，事件会被丢弃，需要监控指标
+// - OverflowBlock 策略下会一直阻塞到缓冲区有空位，需要超时控制时使用 PublishContext
 // - 事件处理错误不会返回给发布者
 //
 // 示例：
 //
 //	event := eventbus.NewEvent("user.created", user)
 //	if err := eventBus.Publish(event); err != nil {
-//	    if err == eventbus.ErrEventBusStopped {
+//	    if errors.Is(err, eventbus.ErrEventBusStopped) {
 //	        log.Printf("Event bus is stopped")
-//	    } else {
+//	    } else if errors.Is(err, eventbus.ErrBufferFull) {
 //	        log.Printf("Event buffer is full, event dropped")
 //	    }
 //	}
 func (eb *EventBus) Publish(event Event) error {
-	// 首先检查 context 是否已取消
-	select {
-	case <-eb.ctx.Done():
-		return ErrEventBusStopped
-	default:
-	}
-
-	// 尝试发送事件
-	select {
-	case eb.eventChan <- event:
-		eb.metrics.mu.Lock()
-		eb.metrics.TotalEvents++
-		eb.metrics.mu.Unlock()
-		return nil
-	case <-eb.ctx.Done():
-		return ErrEventBusStopped
-	default:
-		// 缓冲区满，丢弃事件
-		eb.metrics.mu.Lock()
-		eb.metrics.DroppedEvents++
-		eb.metrics.mu.Unlock()
-		return fmt.Errorf("event buffer is full, event dropped")
-	}
+	return eb.PublishContext(context.Background(), event)
 }
 
-// PublishAsync 异步发布事件（不阻塞）
+// PublishAsync 发布事件，不阻塞且忽略错误
 //
 // 设计原理：
-// 1. 在独立的 goroutine 中发布事件
-// 2. 完全不阻塞调用者，即使缓冲区满也不会阻塞
-// 3. 错误会被忽略，适合不关心发布结果的场景
+// 1. 在调用方 goroutine 中直接放入缓冲区，不再为每次调用创建 goroutine
+// 2. 缓冲区满时按溢出策略处理；OverflowBlock 策略下不等待，按 OverflowFail 丢弃
 //
 // 参数：
 //   - event: 要发布的事件
 //
 // 注意事项：
-// - 不返回错误，错误会被忽略
-// - 适合不关心发布结果的场景
-// - 如果缓冲区满，事件会被丢弃（不会阻塞）
+// - 不返回错误，丢弃的事件（包括事件类型包含通配符、写入事件存储失败的事件）计入 DroppedEvents 并记录日志
+// - 配置了事件存储或溢出存储时，写入存储的 I/O 在调用方 goroutine 中进行
 //
 // 使用场景：
 // 1. 日志记录等不重要的操作
@@ -760,9 +924,97 @@ func (eb *EventBus) Publish(event Event) error {
 //	event := eventbus.NewEvent("user.created", user)
 //	eventBus.PublishAsync(event)  // 不阻塞，不关心结果
 func (eb *EventBus) PublishAsync(event Event) {
-	go func() {
-		_ = eb.Publish(event)
-	}()
+	select {
+	case <-eb.ctx.Done():
+		return
+	default:
+	}
+
+	if err := validateEventType(event.Type()); err != nil {
+		eb.countDropped()
+		slog.Warn("eventbus: async event dropped", "type", event.Type(), "error", err)
+		return
+	}
+
+	if eb.store != nil {
+		if _, err := eb.store.Append(event); err != nil {
+			eb.countDropped()
+			slog.Warn("eventbus: async event dropped, failed to persist", "type", event.Type(), "error", err)
+			return
+		}
+	}
+
+	policy := eb.overflow
+	if policy == OverflowBlock {
+		policy = OverflowFail
+	}
+	_ = eb.enqueue(eb.ctx, event, policy)
+}
+
+// PublishSync 同步发布事件：在调用方 goroutine 中投递给所有匹配的订阅，返回投递结果
+//
+// 设计原理：
+// 1. 与 PublishContext 一样先写入事件存储，但不经过缓冲区，不受溢出策略影响
+// 2. 按订阅的重试策略依次执行处理器，重试耗尽的事件与异步投递一样写入死信存储
+// 3. 任一订阅最终处理失败时返回错误，调用方据此决定是否重新发布
+//
+// 参数：
+//   - ctx: 上下文，取消时停止等待重试
+//   - event: 要发布的事件
+//
+// 返回：
+//   - error: 事件总线已停止返回 ErrEventBusStopped；事件类型包含通配符返回 ErrInvalidEventType；
+//     写入事件存储失败或有订阅处理失败时返回错误
+//
+// 注意事项：
+// - 有序订阅和有界队列订阅同样在调用方 goroutine 中直接执行，不经过分区队列
+// - 返回错误后重新发布时，已经处理成功的订阅会再次收到事件，处理器需要幂等；死信存储中也会留下每次失败的记录
+// - 用于发件箱中继等需要确认投递结果的场景，普通场景使用 Publish
+func (eb *EventBus) PublishSync(ctx context.Context, event Event) error {
+	select {
+	case <-eb.ctx.Done():
+		return ErrEventBusStopped
+	default:
+	}
+
+	if err := validateEventType(event.Type()); err != nil {
+		return err
+	}
+
+	if eb.store != nil {
+		if _, err := eb.store.Append(event); err != nil {
+			return fmt.Errorf("failed to persist event: %w", err)
+		}
+	}
+	eb.countPublished()
+
+	var errs []error
+	for _, sub := range eb.matchSubscriptions(event.Type()) {
+		if sub.Filter != nil && !sub.Filter(event) {
+			continue
+		}
+		if err := eb.deliver(ctx, sub, event); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", sub.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// matchSubscriptions 返回匹配事件类型的订阅：精确订阅查 map，存在通配符订阅时再查前缀树
+func (eb *EventBus) matchSubscriptions(eventType string) []*Subscription {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	subs := eb.subscriptions[eventType]
+	if eb.patterns.size > 0 {
+		if matched := eb.patterns.match(eventType); len(matched) > 0 {
+			// 复制一份，避免 append 修改 map 中切片的底层数组
+			all := make([]*Subscription, 0, len(subs)+len(matched))
+			all = append(all, subs...)
+			subs = append(all, matched...)
+		}
+	}
+	return subs
 }
 
 // processEvents 处理事件（内部goroutine）
@@ -801,11 +1053,11 @@ func (eb *EventBus) processEvents() {
 // 3. 为每个订阅启动独立的 goroutine 处理事件
 //
 // 处理流程：
-// 1. 获取所有匹配的订阅（读锁保护）
+// 1. 获取所有匹配的订阅（读锁保护）：精确订阅查 map，存在通配符订阅时再查前缀树
 // 2. 遍历订阅，应用过滤器
-// 3. 为每个订阅启动 goroutine 异步处理
-// 4. 使用超时上下文（5秒）防止处理器阻塞
-// 5. 更新指标（成功/失败）
+// 3. 为每个订阅启动 goroutine 异步处理（有序订阅则放入对应分区队列）
+// 4. 按订阅的重试策略执行处理器（见 deliver()），超时上下文防止处理器阻塞
+// 5. 更新指标（成功/失败/重试），重试耗尽的事件写入死信存储
 //
 // 性能优化：
 // - 使用读锁，允许多个事件并发处理
@@ -813,38 +1065,33 @@ func (eb *EventBus) processEvents() {
 // - 超时控制，防止处理器阻塞
 //
 // 注意事项：
-// - 每个订阅在独立的 goroutine 中处理
-// - 处理器超时时间为 5 秒
+// - 每个订阅在独立的 goroutine 中处理，有序订阅在所属分区 goroutine 中顺序处理
+// - 处理器默认超时时间为 5 秒，可通过 WithHandlerTimeout() 调整
 // - 处理器错误不会影响其他订阅
 func (eb *EventBus) handleEvent(event Event) {
-	eb.mu.RLock()
-	subs := eb.subscriptions[event.Type()]
-	eb.mu.RUnlock()
-
-	for _, sub := range subs {
+	for _, sub := range eb.matchSubscriptions(event.Type()) {
 		// 应用过滤器
 		if sub.Filter != nil && !sub.Filter(event) {
 			continue
 		}
 
+		// 有序订阅：放入分区队列，由分区 goroutine 顺序处理
+		if sub.ordered() {
+			eb.dispatchOrdered(sub, event)
+			continue
+		}
+
+		// 有界队列订阅：放入订阅队列，由 worker goroutine 处理
+		if sub.bounded() {
+			eb.offer(sub, sub.queue, event)
+			continue
+		}
+
 		// 异步执行处理器
 		eb.wg.Add(1)
 		go func(subscription *Subscription) {
 			defer eb.wg.Done()
-
-			// 使用超时上下文，防止处理器阻塞
-			ctx, cancel := context.WithTimeout(eb.ctx, 5*time.Second)
-			defer cancel()
-
-			if err := subscription.Handler(ctx, event); err != nil {
-				eb.metrics.mu.Lock()
-				eb.metrics.FailedEvents++
-				eb.metrics.mu.Unlock()
-			} else {
-				eb.metrics.mu.Lock()
-				eb.metrics.HandledEvents++
-				eb.metrics.mu.Unlock()
-			}
+			_ = eb.deliver(eb.ctx, subscription, event)
 		}(sub)
 	}
 }
@@ -887,7 +1134,10 @@ func (eb *EventBus) GetMetrics() Metrics {
 		TotalEvents:         eb.metrics.TotalEvents,
 		HandledEvents:       eb.metrics.HandledEvents,
 		FailedEvents:        eb.metrics.FailedEvents,
+		RetriedEvents:       eb.metrics.RetriedEvents,
+		DeadLetteredEvents:  eb.metrics.DeadLetteredEvents,
 		DroppedEvents:       eb.metrics.DroppedEvents,
+		SpilledEvents:       eb.metrics.SpilledEvents,
 		ActiveSubscriptions: eb.metrics.ActiveSubscriptions,
 	}
 }
@@ -954,7 +1204,12 @@ func (eb *EventBus) Clear() {
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
+	for _, sub := range eb.subIndex {
+		sub.stopWorkers()
+	}
+
 	eb.subscriptions = make(map[string][]*Subscription)
+	eb.patterns = newTopicTrie()
 	eb.subIndex = make(map[string]*Subscription)
 
 	eb.metrics.mu.Lock()
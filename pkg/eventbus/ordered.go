@@ -0,0 +1,130 @@
+package eventbus
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+const (
+	// MetadataKeyAggregateID 聚合根ID元数据键
+	// 推荐作为有序投递的分区键，保证同一聚合的事件按发布顺序处理
+	MetadataKeyAggregateID = "aggregate_id"
+
+	// defaultPartitions 默认分区数
+	defaultPartitions = 8
+)
+
+// WithOrderedDelivery 启用按键有序投递
+//
+// 设计原理：
+// 1. 从事件元数据中读取 metadataKey 对应的值作为分区键
+// 2. 按分区键哈希将事件分配到固定数量的分区，每个分区由一个 goroutine 顺序处理
+// 3. 同一分区内严格按发布顺序投递，不同分区之间并行处理
+//
+// 参数：
+//   - metadataKey: 分区键对应的元数据键，如 MetadataKeyAggregateID
+//   - partitions: 分区数（并行度），<= 0 时使用默认值 8
+//
+// 注意事项：
+// - 没有该元数据（或事件未实现 GetMetadata）的事件使用空键，全部进入同一分区
+// - 重试在分区内同步进行，失败事件会阻塞同一分区的后续事件直到重试耗尽
+// - 分区队列满时默认阻塞事件分发；配合 WithQueue 可以限制分区队列容量并选择丢弃策略
+// - 取消订阅后，分区中尚未处理的事件会被丢弃
+//
+// 示例：
+//
+//	event := eventbus.NewEvent("user.updated", user)
+//	event.SetMetadata(eventbus.MetadataKeyAggregateID, user.ID)
+//
+//	eb.Subscribe("user.updated", projector,
+//	    eventbus.WithOrderedDelivery(eventbus.MetadataKeyAggregateID, 16),
+//	)
+func WithOrderedDelivery(metadataKey string, partitions int) SubscribeOption {
+	return func(s *Subscription) {
+		if partitions <= 0 {
+			partitions = defaultPartitions
+		}
+		s.OrderingKey = metadataKey
+		s.Partitions = partitions
+	}
+}
+
+// ordered 是否启用有序投递
+func (s *Subscription) ordered() bool {
+	return s.OrderingKey != ""
+}
+
+// startPartitions 为有序订阅启动分区 goroutine
+//
+// 调用方需要持有 eb.mu 写锁
+func (eb *EventBus) startPartitions(sub *Subscription) {
+	sub.partitions = make([]chan Event, sub.Partitions)
+	sub.done = make(chan struct{})
+
+	for i := range sub.partitions {
+		queue := make(chan Event, eb.queueCapacity(sub))
+		sub.partitions[i] = queue
+
+		eb.wg.Add(1)
+		go eb.runWorker(sub, queue)
+	}
+}
+
+// stopWorkers 停止有序订阅的分区 goroutine 和有界队列的 worker goroutine
+//
+// 调用方需要持有 eb.mu 写锁，每个订阅只能调用一次
+func (s *Subscription) stopWorkers() {
+	if s.done != nil {
+		close(s.done)
+	}
+}
+
+// runWorker 顺序处理单个分区（或有界队列）中的事件
+func (eb *EventBus) runWorker(sub *Subscription, queue chan Event) {
+	defer eb.wg.Done()
+
+	for {
+		select {
+		case event := <-queue:
+			_ = eb.deliver(eb.ctx, sub, event)
+		case <-sub.done:
+			return
+		case <-eb.ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatchOrdered 将事件放入分区队列
+//
+// 在 processEvents goroutine 中调用，保证同一分区内的入队顺序与发布顺序一致
+func (eb *EventBus) dispatchOrdered(sub *Subscription, event Event) {
+	queue := sub.partitions[partitionFor(orderingKey(event, sub.OrderingKey), len(sub.partitions))]
+	eb.offer(sub, queue, event)
+}
+
+// orderingKey 从事件元数据中读取分区键
+func orderingKey(event Event, metadataKey string) string {
+	carrier, ok := event.(interface {
+		GetMetadata(key string) (interface{}, bool)
+	})
+	if !ok {
+		return ""
+	}
+
+	value, ok := carrier.GetMetadata(metadataKey)
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// partitionFor 计算分区键对应的分区序号
+func partitionFor(key string, partitions int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(partitions))
+}
@@ -0,0 +1,106 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventBus_OrderedDelivery(t *testing.T) {
+	eb := NewEventBus(100)
+	eb.Start()
+	defer eb.Stop()
+
+	var mu sync.Mutex
+	received := make(map[string][]int)
+	handler := func(ctx context.Context, event Event) error {
+		key, _ := event.(*BaseEvent).GetMetadata(MetadataKeyAggregateID)
+		seq := event.Data().(int)
+		// 让后发布的事件处理得更快，无序投递时很容易乱序
+		time.Sleep(time.Duration(10-seq%10) * 100 * time.Microsecond)
+
+		mu.Lock()
+		received[key.(string)] = append(received[key.(string)], seq)
+		mu.Unlock()
+		return nil
+	}
+
+	_, err := eb.Subscribe("user.updated", handler, WithOrderedDelivery(MetadataKeyAggregateID, 4))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	const perKey = 20
+	keys := []string{"user-1", "user-2", "user-3"}
+	for i := 0; i < perKey; i++ {
+		for _, key := range keys {
+			event := NewEvent("user.updated", i)
+			event.SetMetadata(MetadataKeyAggregateID, key)
+			if err := eb.Publish(event); err != nil {
+				t.Fatalf("Failed to publish: %v", err)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if eb.GetMetrics().HandledEvents == int64(perKey*len(keys)) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, key := range keys {
+		seqs := received[key]
+		if len(seqs) != perKey {
+			t.Fatalf("Expected %d events for %s, got %d", perKey, key, len(seqs))
+		}
+		for i, seq := range seqs {
+			if seq != i {
+				t.Fatalf("Events for %s out of order: %v", key, seqs)
+			}
+		}
+	}
+}
+
+func TestEventBus_OrderedUnsubscribe(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+
+	subID, _ := eb.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		return nil
+	}, WithOrderedDelivery("key", 2))
+
+	if err := eb.Unsubscribe(subID); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+
+	// 分区 goroutine 已退出，Stop 不应阻塞
+	done := make(chan struct{})
+	go func() {
+		eb.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked after unsubscribing ordered subscription")
+	}
+}
+
+func TestPartitionFor(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		p := partitionFor(key, 8)
+		if p < 0 || p >= 8 {
+			t.Fatalf("Partition out of range: %d", p)
+		}
+		if partitionFor(key, 8) != p {
+			t.Fatalf("Partition for %s is not stable", key)
+		}
+	}
+}
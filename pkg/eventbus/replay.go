@@ -0,0 +1,116 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultReplayBatchSize 默认每批从存储读取的事件数
+const defaultReplayBatchSize = 100
+
+// ReplayOptions 重放选项
+//
+// 字段说明：
+// - FromOffset: 起始偏移量（包含）
+// - Since: 起始时间，非零时优先于 FromOffset，从第一个时间戳不早于 Since 的事件开始
+// - BatchSize: 每批从存储读取的事件数，<= 0 时使用默认值 100
+type ReplayOptions struct {
+	// FromOffset 起始偏移量（包含）
+	FromOffset uint64
+
+	// Since 起始时间（可选）
+	Since time.Time
+
+	// BatchSize 每批读取的事件数
+	BatchSize int
+}
+
+// Replay 将存储中的历史事件重放给指定订阅
+//
+// 设计原理：
+// 1. 从事件存储中按偏移量顺序读取事件
+// 2. 只重放与订阅事件类型（或通配符模式）匹配、且通过订阅过滤器的事件
+// 3. 在调用方 goroutine 中同步调用处理器，保证重放顺序
+//
+// 参数：
+//   - ctx: 上下文，取消后停止重放
+//   - subscriptionID: 订阅ID，由 Subscribe() 或 SubscribeWithFilter() 返回
+//   - opts: 重放选项
+//
+// 返回：
+//   - uint64: 下一个待重放的偏移量，可用于断点续放
+//   - error: 重放失败时返回错误
+//   - ErrNoEventStore: 未配置事件存储
+//   - ErrSubscriptionNotFound: 订阅不存在
+//   - 处理器错误: 重放在出错的事件处停止，返回的偏移量指向该事件
+//
+// 注意事项：
+// - 重放不会更新事件总线指标
+// - 重放与实时分发相互独立，处理器可能同时收到重放事件和新事件，应该保证幂等
+//
+// 示例：
+//
+//	subID, _ := eb.Subscribe("user.created", handler)
+//
+//	// 重放最近一小时的事件
+//	next, err := eb.Replay(ctx, subID, eventbus.ReplayOptions{
+//	    Since: time.Now().Add(-time.Hour),
+//	})
+//	if err != nil {
+//	    log.Printf("replay stopped at offset %d: %v", next, err)
+//	}
+func (eb *EventBus) Replay(ctx context.Context, subscriptionID string, opts ReplayOptions) (uint64, error) {
+	if eb.store == nil {
+		return opts.FromOffset, ErrNoEventStore
+	}
+
+	eb.mu.RLock()
+	sub, exists := eb.subIndex[subscriptionID]
+	eb.mu.RUnlock()
+	if !exists {
+		return opts.FromOffset, ErrSubscriptionNotFound
+	}
+
+	offset := opts.FromOffset
+	if !opts.Since.IsZero() {
+		var err error
+		offset, err = eb.store.OffsetAt(opts.Since)
+		if err != nil {
+			return opts.FromOffset, fmt.Errorf("failed to locate replay offset: %w", err)
+		}
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReplayBatchSize
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return offset, err
+		}
+
+		batch, err := eb.store.ReadFrom(offset, batchSize)
+		if err != nil {
+			return offset, fmt.Errorf("failed to read events from store: %w", err)
+		}
+		if len(batch) == 0 {
+			return offset, nil
+		}
+
+		for _, stored := range batch {
+			if err := ctx.Err(); err != nil {
+				return stored.Offset, err
+			}
+
+			event := stored.Event
+			if matchTopic(sub.EventType, event.Type()) && (sub.Filter == nil || sub.Filter(event)) {
+				if err := sub.Handler(ctx, event); err != nil {
+					return stored.Offset, fmt.Errorf("failed to replay event at offset %d: %w", stored.Offset, err)
+				}
+			}
+			offset = stored.Offset + 1
+		}
+	}
+}
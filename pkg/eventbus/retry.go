@@ -0,0 +1,191 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// defaultHandlerTimeout 默认处理器超时时间
+	defaultHandlerTimeout = 5 * time.Second
+
+	// defaultInitialBackoff 默认首次重试等待时间
+	defaultInitialBackoff = 100 * time.Millisecond
+
+	// defaultMaxBackoff 默认最大重试等待时间
+	defaultMaxBackoff = 30 * time.Second
+
+	// defaultBackoffMultiplier 默认退避倍数
+	defaultBackoffMultiplier = 2.0
+)
+
+// RetryPolicy 重试策略
+//
+// 设计原理：
+// 1. 处理器返回错误时按指数退避（exponential backoff）重试
+// 2. 第 n 次重试前等待 InitialBackoff * Multiplier^(n-1)，不超过 MaxBackoff
+// 3. 达到 MaxAttempts 后仍失败的事件进入死信存储（DeadLetterStore）
+//
+// 字段说明：
+// - MaxAttempts: 最大尝试次数（包含首次执行），<= 0 时为 1（不重试）
+// - InitialBackoff: 首次重试等待时间，<= 0 时为 100ms
+// - MaxBackoff: 最大等待时间，<= 0 时为 30s
+// - Multiplier: 退避倍数，< 1 时为 2
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（包含首次执行）
+	MaxAttempts int
+
+	// InitialBackoff 首次重试等待时间
+	InitialBackoff time.Duration
+
+	// MaxBackoff 最大重试等待时间
+	MaxBackoff time.Duration
+
+	// Multiplier 退避倍数
+	Multiplier float64
+}
+
+// withDefaults 返回填充默认值后的策略
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultBackoffMultiplier
+	}
+	return p
+}
+
+// Backoff 返回第 attempt 次失败后、下一次重试前的等待时间
+//
+// 参数：
+//   - attempt: 已经失败的次数，从 1 开始
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	p = p.withDefaults()
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= p.Multiplier
+		if backoff >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	return time.Duration(backoff)
+}
+
+// SubscribeOption 订阅配置选项
+type SubscribeOption func(*Subscription)
+
+// WithRetryPolicy 设置订阅的重试策略
+//
+// 示例：
+//
+//	eb.Subscribe("billing.charged", handler, eventbus.WithRetryPolicy(eventbus.RetryPolicy{
+//	    MaxAttempts:    5,
+//	    InitialBackoff: 200 * time.Millisecond,
+//	    MaxBackoff:     10 * time.Second,
+//	}))
+func WithRetryPolicy(policy RetryPolicy) SubscribeOption {
+	return func(s *Subscription) {
+		s.RetryPolicy = policy
+	}
+}
+
+// WithMaxAttempts 设置最大尝试次数（包含首次执行）
+func WithMaxAttempts(attempts int) SubscribeOption {
+	return func(s *Subscription) {
+		s.RetryPolicy.MaxAttempts = attempts
+	}
+}
+
+// WithBackoff 设置指数退避的初始等待时间和最大等待时间
+func WithBackoff(initial, max time.Duration) SubscribeOption {
+	return func(s *Subscription) {
+		s.RetryPolicy.InitialBackoff = initial
+		s.RetryPolicy.MaxBackoff = max
+	}
+}
+
+// WithHandlerTimeout 设置单次处理器执行的超时时间
+//
+// 默认 5 秒；每次重试都会重新计时
+func WithHandlerTimeout(timeout time.Duration) SubscribeOption {
+	return func(s *Subscription) {
+		s.Timeout = timeout
+	}
+}
+
+// deliver 按订阅的重试策略投递事件
+//
+// 处理流程：
+// 1. 使用 parent 派生的超时上下文执行处理器
+// 2. 失败时按指数退避等待后重试，直到达到最大尝试次数
+// 3. 最终失败时更新指标并写入死信存储
+//
+// 注意事项：
+// - parent 被取消时立即停止重试，事件进入死信存储，避免丢失
+func (eb *EventBus) deliver(parent context.Context, sub *Subscription, event Event) error {
+	policy := sub.RetryPolicy.withDefaults()
+
+	timeout := sub.Timeout
+	if timeout <= 0 {
+		timeout = defaultHandlerTimeout
+	}
+
+	var err error
+	attempts := 0
+	for attempts < policy.MaxAttempts {
+		attempts++
+
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		err = sub.Handler(ctx, event)
+		cancel()
+
+		if err == nil {
+			eb.metrics.mu.Lock()
+			eb.metrics.HandledEvents++
+			eb.metrics.mu.Unlock()
+			return nil
+		}
+
+		// 负载类型不匹配是确定性错误，重试没有意义
+		if attempts >= policy.MaxAttempts || errors.Is(err, ErrPayloadTypeMismatch) {
+			break
+		}
+
+		eb.metrics.mu.Lock()
+		eb.metrics.RetriedEvents++
+		eb.metrics.mu.Unlock()
+
+		timer := time.NewTimer(policy.Backoff(attempts))
+		select {
+		case <-timer.C:
+		case <-parent.Done():
+			timer.Stop()
+			eb.fail(sub, event, err, attempts)
+			return err
+		}
+	}
+
+	eb.fail(sub, event, err, attempts)
+	return err
+}
+
+// fail 记录最终失败的事件并写入死信存储
+func (eb *EventBus) fail(sub *Subscription, event Event, err error, attempts int) {
+	eb.metrics.mu.Lock()
+	eb.metrics.FailedEvents++
+	eb.metrics.mu.Unlock()
+
+	eb.deadLetter(sub, event, err, attempts)
+}
@@ -0,0 +1,221 @@
+package eventbus
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoEventStore 未配置事件存储
+	// 当事件总线没有配置 EventStore 时，调用 Replay 会返回此错误
+	ErrNoEventStore = errors.New("event store is not configured")
+
+	// ErrEventStoreClosed 事件存储已关闭
+	ErrEventStoreClosed = errors.New("event store is closed")
+
+	// ErrEventStoreFailed 事件存储处于失败状态
+	// 写入失败且无法回滚时，存储拒绝后续追加，需要重新打开（打开时会截断残缺的记录）
+	ErrEventStoreFailed = errors.New("event store is in failed state")
+)
+
+// EventStore 事件存储接口
+//
+// 设计原理：
+// 1. 事件存储是只追加（append-only）的日志，每个事件分配单调递增的偏移量（offset）
+// 2. 发布的事件先写入存储，再进入 eventChan 分发，缓冲区满时事件不会丢失
+// 3. 订阅者可以从指定偏移量或时间点重放（replay）历史事件
+//
+// 实现要求：
+// - Append 必须是并发安全的，偏移量从 0 开始连续分配
+// - ReadFrom 返回偏移量 >= offset 的事件，按偏移量升序排列
+// - OffsetAt 返回第一个时间戳 >= t 的事件偏移量，不存在时返回 NextOffset()
+//
+// 内置实现：
+// - MemoryEventStore: 内存存储，进程重启后数据丢失
+// - FileEventStore: 基于文件的分段日志，事件在重启后依然可以重放
+//
+// 注意事项：
+// - 事件存储是可选的，NewEventBus 默认不配置存储，需要通过 WithEventStore 启用；未启用时 Replay 返回 ErrNoEventStore
+type EventStore interface {
+	// Append 追加事件，返回分配的偏移量
+	Append(event Event) (uint64, error)
+
+	// ReadFrom 从指定偏移量开始读取最多 limit 个事件
+	// limit <= 0 表示不限制数量
+	ReadFrom(offset uint64, limit int) ([]StoredEvent, error)
+
+	// OffsetAt 返回第一个时间戳不早于 t 的事件偏移量
+	OffsetAt(t time.Time) (uint64, error)
+
+	// NextOffset 返回下一个将要分配的偏移量
+	NextOffset() uint64
+
+	// Close 关闭存储，释放资源
+	Close() error
+}
+
+// CommittableStore 可以记录消费进度并清理已消费事件的事件存储
+//
+// 用作溢出存储（WithSpillStore）时，事件总线每取回一批事件就提交一次偏移量：
+// 重启后从提交的偏移量继续取回，已取回的事件占用的空间被回收
+//
+// 内置实现：
+// - FileEventStore: 偏移量保存在目录下的 checkpoint 文件中，删除已完全消费的分段
+type CommittableStore interface {
+	EventStore
+
+	// Commit 记录 offset 之前的事件已经消费，存储可以删除这些事件
+	Commit(offset uint64) error
+
+	// Committed 返回最近一次提交的偏移量，没有提交过时返回 0
+	Committed() uint64
+}
+
+// StoredEvent 已存储的事件
+//
+// 字段说明：
+// - Offset: 事件在日志中的偏移量
+// - Event: 事件本身
+//
+// 注意事项：
+// - 从 FileEventStore 读取的事件，Data() 返回 json.RawMessage，
+// 需要由处理器自行解码为具体类型
+type StoredEvent struct {
+	// Offset 事件偏移量
+	Offset uint64
+
+	// Event 事件
+	Event Event
+}
+
+// MemoryEventStore 内存事件存储
+//
+// 设计原理：
+// 1. 使用切片保存事件，偏移量即写入顺序
+// 2. 支持容量限制，达到容量后作为环形缓冲区覆盖最旧的事件，每次追加都是 O(1)
+// 3. 进程重启后数据丢失，适用于测试和单进程重放场景
+type MemoryEventStore struct {
+	// events 事件缓冲区，head 指向最旧的事件；未达到容量前 head 始终为 0
+	events []StoredEvent
+	head   int
+
+	capacity    int
+	firstOffset uint64
+	nextOffset  uint64
+	closed      bool
+	mu          sync.RWMutex
+}
+
+// NewMemoryEventStore 创建内存事件存储
+//
+// 参数：
+//   - capacity: 最多保留的事件数量，<= 0 表示不限制
+//
+// 示例：
+//
+//	store := eventbus.NewMemoryEventStore(10000)
+//	eb := eventbus.NewEventBus(100, eventbus.WithEventStore(store))
+func NewMemoryEventStore(capacity int) *MemoryEventStore {
+	return &MemoryEventStore{
+		capacity: capacity,
+	}
+}
+
+// at 返回第 i 个（从最旧的事件开始计数）保留的事件
+func (s *MemoryEventStore) at(i int) StoredEvent {
+	return s.events[(s.head+i)%len(s.events)]
+}
+
+// Append 追加事件
+func (s *MemoryEventStore) Append(event Event) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, ErrEventStoreClosed
+	}
+
+	offset := s.nextOffset
+	stored := StoredEvent{Offset: offset, Event: event}
+	s.nextOffset++
+
+	// 达到容量时覆盖最旧的事件
+	if s.capacity > 0 && len(s.events) == s.capacity {
+		s.events[s.head] = stored
+		s.head = (s.head + 1) % s.capacity
+		s.firstOffset++
+		return offset, nil
+	}
+
+	s.events = append(s.events, stored)
+	return offset, nil
+}
+
+// ReadFrom 从指定偏移量开始读取事件
+//
+// 如果 offset 早于最旧的保留事件，则从最旧的保留事件开始读取
+func (s *MemoryEventStore) ReadFrom(offset uint64, limit int) ([]StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, ErrEventStoreClosed
+	}
+
+	if offset < s.firstOffset {
+		offset = s.firstOffset
+	}
+	if offset >= s.nextOffset {
+		return nil, nil
+	}
+
+	start := int(offset - s.firstOffset)
+	end := len(s.events)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	result := make([]StoredEvent, end-start)
+	for i := range result {
+		result[i] = s.at(start + i)
+	}
+	return result, nil
+}
+
+// OffsetAt 返回第一个时间戳不早于 t 的事件偏移量
+func (s *MemoryEventStore) OffsetAt(t time.Time) (uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return 0, ErrEventStoreClosed
+	}
+
+	i := sort.Search(len(s.events), func(i int) bool {
+		return !s.at(i).Event.Timestamp().Before(t)
+	})
+	if i == len(s.events) {
+		return s.nextOffset, nil
+	}
+	return s.at(i).Offset, nil
+}
+
+// NextOffset 返回下一个将要分配的偏移量
+func (s *MemoryEventStore) NextOffset() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.nextOffset
+}
+
+// Close 关闭存储
+func (s *MemoryEventStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.events = nil
+	s.head = 0
+	return nil
+}
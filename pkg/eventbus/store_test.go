@@ -0,0 +1,405 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryEventStore(t *testing.T) {
+	store := NewMemoryEventStore(3)
+
+	for i := 0; i < 5; i++ {
+		offset, err := store.Append(NewEvent("test.event", i))
+		if err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+		if offset != uint64(i) {
+			t.Errorf("Expected offset %d, got %d", i, offset)
+		}
+	}
+
+	if store.NextOffset() != 5 {
+		t.Errorf("Expected next offset 5, got %d", store.NextOffset())
+	}
+
+	// 容量为 3，最旧的两个事件已被淘汰
+	events, err := store.ReadFrom(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(events) != 3 || events[0].Offset != 2 {
+		t.Fatalf("Expected 3 events starting at offset 2, got %d", len(events))
+	}
+
+	events, _ = store.ReadFrom(3, 1)
+	if len(events) != 1 || events[0].Event.Data() != 3 {
+		t.Errorf("Expected event 3, got %v", events)
+	}
+
+	store.Close()
+	if _, err := store.Append(NewEvent("test.event", 0)); err != ErrEventStoreClosed {
+		t.Errorf("Expected ErrEventStoreClosed, got %v", err)
+	}
+}
+
+func TestMemoryEventStore_Wraparound(t *testing.T) {
+	store := NewMemoryEventStore(4)
+
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		event := NewEvent("test.event", i)
+		event.timestamp = start.Add(time.Duration(i) * time.Second)
+		store.Append(event)
+	}
+
+	// 保留偏移量 7-10，缓冲区已经多次回绕
+	events, err := store.ReadFrom(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("Expected 4 events, got %d", len(events))
+	}
+	for i, e := range events {
+		if e.Offset != uint64(7+i) || e.Event.Data() != 7+i {
+			t.Errorf("Expected event %d at position %d, got offset %d", 7+i, i, e.Offset)
+		}
+	}
+
+	events, _ = store.ReadFrom(8, 2)
+	if len(events) != 2 || events[0].Offset != 8 || events[1].Offset != 9 {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+
+	if offset, _ := store.OffsetAt(start.Add(9 * time.Second)); offset != 9 {
+		t.Errorf("Expected offset 9, got %d", offset)
+	}
+	if offset, _ := store.OffsetAt(start); offset != 7 {
+		t.Errorf("Expected oldest retained offset 7, got %d", offset)
+	}
+}
+
+func TestFileEventStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileEventStore(FileStoreConfig{Dir: dir, MaxSegmentBytes: 256})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		event := NewEvent("test.event", map[string]int{"n": i})
+		event.timestamp = start.Add(time.Duration(i) * time.Second)
+		event.SetMetadata("request_id", "req-1")
+		if _, err := store.Append(event); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+	store.Close()
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "*"+segmentFileExt))
+	if len(segments) < 2 {
+		t.Errorf("Expected multiple segments, got %d", len(segments))
+	}
+
+	store, err = NewFileEventStore(FileStoreConfig{Dir: dir, MaxSegmentBytes: 256})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	if store.NextOffset() != 10 {
+		t.Fatalf("Expected next offset 10, got %d", store.NextOffset())
+	}
+
+	events, err := store.ReadFrom(4, 3)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(events) != 3 || events[0].Offset != 4 || events[2].Offset != 6 {
+		t.Fatalf("Unexpected events: %+v", events)
+	}
+
+	var data map[string]int
+	if err := json.Unmarshal(events[0].Event.Data().(json.RawMessage), &data); err != nil {
+		t.Fatalf("Failed to decode data: %v", err)
+	}
+	if data["n"] != 4 {
+		t.Errorf("Expected n=4, got %d", data["n"])
+	}
+	if v, ok := events[0].Event.(*BaseEvent).GetMetadata("request_id"); !ok || v != "req-1" {
+		t.Errorf("Expected metadata to be restored, got %v", v)
+	}
+
+	offset, err := store.OffsetAt(start.Add(7 * time.Second))
+	if err != nil || offset != 7 {
+		t.Errorf("Expected offset 7, got %d (%v)", offset, err)
+	}
+
+	// 重新打开后继续追加
+	offset, err = store.Append(NewEvent("test.event", nil))
+	if err != nil || offset != 10 {
+		t.Errorf("Expected offset 10, got %d (%v)", offset, err)
+	}
+}
+
+func TestFileEventStore_TruncatesPartialRecord(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileEventStore(FileStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	store.Append(NewEvent("test.event", "a"))
+	store.Append(NewEvent("test.event", "b"))
+	store.Close()
+
+	// 模拟崩溃时写入了一半的记录
+	path := filepath.Join(dir, "00000000000000000000"+segmentFileExt)
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.Write([]byte{0, 0, 0, 100, 1, 2})
+	f.Close()
+
+	store, err = NewFileEventStore(FileStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	if store.NextOffset() != 2 {
+		t.Errorf("Expected next offset 2, got %d", store.NextOffset())
+	}
+	if offset, _ := store.Append(NewEvent("test.event", "c")); offset != 2 {
+		t.Errorf("Expected offset 2, got %d", offset)
+	}
+	events, _ := store.ReadFrom(0, 0)
+	if len(events) != 3 {
+		t.Errorf("Expected 3 events, got %d", len(events))
+	}
+}
+
+func TestFileEventStore_RollbackTornWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileEventStore(FileStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+	store.Append(NewEvent("test.event", "a"))
+
+	// 模拟写入失败时留下的残缺字节
+	path := filepath.Join(dir, "00000000000000000000"+segmentFileExt)
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	f.Write([]byte{0, 0, 0, 100, 1, 2})
+	f.Close()
+
+	cause := errors.New("short write")
+	if err := store.rollback(store.segments[0], cause); err != cause {
+		t.Fatalf("Expected cause to be returned, got %v", err)
+	}
+
+	if offset, err := store.Append(NewEvent("test.event", "b")); err != nil || offset != 1 {
+		t.Fatalf("Expected offset 1, got %d (%v)", offset, err)
+	}
+	events, err := store.ReadFrom(0, 0)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d (%v)", len(events), err)
+	}
+}
+
+func TestFileEventStore_FailedState(t *testing.T) {
+	store, err := NewFileEventStore(FileStoreConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+	store.Append(NewEvent("test.event", "a"))
+
+	// 关闭底层文件，写入和回滚截断都会失败
+	store.active.Close()
+
+	if _, err := store.Append(NewEvent("test.event", "b")); !errors.Is(err, ErrEventStoreFailed) {
+		t.Fatalf("Expected ErrEventStoreFailed, got %v", err)
+	}
+	if _, err := store.Append(NewEvent("test.event", "c")); !errors.Is(err, ErrEventStoreFailed) {
+		t.Errorf("Expected store to refuse appends, got %v", err)
+	}
+	if store.NextOffset() != 1 {
+		t.Errorf("Expected next offset 1, got %d", store.NextOffset())
+	}
+}
+
+func TestFileEventStore_SparseIndex(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := NewFileEventStore(FileStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		if _, err := store.Append(NewEvent("test.event", i)); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+
+	check := func(store *FileEventStore) {
+		t.Helper()
+		seg := store.segments[0]
+		if len(seg.index) < 2 {
+			t.Fatalf("Expected sparse index entries, got %d", len(seg.index))
+		}
+		if pos := seg.lookup(900); pos == 0 || pos >= seg.size {
+			t.Errorf("Expected lookup to skip ahead, got position %d", pos)
+		}
+		for _, offset := range []uint64{0, 1, 399, 900, 999} {
+			events, err := store.ReadFrom(offset, 2)
+			if err != nil || len(events) == 0 || events[0].Offset != offset {
+				t.Fatalf("Expected events from offset %d, got %+v (%v)", offset, events, err)
+			}
+			if string(events[0].Event.Data().(json.RawMessage)) != strconv.FormatUint(offset, 10) {
+				t.Errorf("Unexpected data at offset %d: %s", offset, events[0].Event.Data())
+			}
+		}
+	}
+
+	check(store)
+	store.Close()
+
+	// 重新打开后重建索引
+	store, err = NewFileEventStore(FileStoreConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	check(store)
+}
+
+func TestFileEventStore_ConcurrentReadAppend(t *testing.T) {
+	store, err := NewFileEventStore(FileStoreConfig{Dir: t.TempDir(), MaxSegmentBytes: 4096})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 500; i++ {
+			store.Append(NewEvent("test.event", i))
+		}
+	}()
+
+	var offset uint64
+	for offset < 500 {
+		events, err := store.ReadFrom(offset, 50)
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+		for _, e := range events {
+			if e.Offset != offset {
+				t.Fatalf("Expected offset %d, got %d", offset, e.Offset)
+			}
+			offset++
+		}
+	}
+	<-done
+}
+
+func TestFileEventStore_Commit(t *testing.T) {
+	dir := t.TempDir()
+	config := FileStoreConfig{Dir: dir, MaxSegmentBytes: 256}
+
+	store, err := NewFileEventStore(config)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		store.Append(NewEvent("test.event", i))
+	}
+	before, _ := filepath.Glob(filepath.Join(dir, "*"+segmentFileExt))
+
+	if err := store.Commit(15); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	after, _ := filepath.Glob(filepath.Join(dir, "*"+segmentFileExt))
+	if len(after) >= len(before) {
+		t.Errorf("Expected consumed segments to be removed, had %d, now %d", len(before), len(after))
+	}
+
+	// 已删除分段中的事件不再返回，未消费的事件全部保留
+	events, err := store.ReadFrom(0, 0)
+	if err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	if len(events) == 0 || events[0].Offset > 15 || events[len(events)-1].Offset != 19 {
+		t.Fatalf("Unexpected events after commit: %+v", events)
+	}
+
+	// 回退的提交被忽略
+	store.Commit(3)
+	if store.Committed() != 15 {
+		t.Errorf("Expected committed offset 15, got %d", store.Committed())
+	}
+	store.Close()
+
+	store, err = NewFileEventStore(config)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	if store.Committed() != 15 || store.NextOffset() != 20 {
+		t.Errorf("Expected committed 15 and next 20, got %d and %d", store.Committed(), store.NextOffset())
+	}
+
+	// 超过 NextOffset 的提交按 NextOffset 处理
+	if err := store.Commit(100); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if store.Committed() != 20 {
+		t.Errorf("Expected committed offset 20, got %d", store.Committed())
+	}
+}
+
+func TestEventBus_Replay(t *testing.T) {
+	store := NewMemoryEventStore(0)
+	eb := NewEventBus(10, WithEventStore(store))
+
+	// 总线未启动，事件只写入存储
+	for i := 0; i < 5; i++ {
+		eb.Publish(NewEvent("test.event", i))
+		eb.Publish(NewEvent("other.event", i))
+	}
+
+	var received []int
+	subID, _ := eb.Subscribe("test.event", func(ctx context.Context, event Event) error {
+		received = append(received, event.Data().(int))
+		return nil
+	})
+
+	next, err := eb.Replay(context.Background(), subID, ReplayOptions{FromOffset: 4, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if next != 10 {
+		t.Errorf("Expected next offset 10, got %d", next)
+	}
+	if len(received) != 3 || received[0] != 2 || received[2] != 4 {
+		t.Errorf("Unexpected replayed events: %v", received)
+	}
+
+	if _, err := eb.Replay(context.Background(), "nonexistent", ReplayOptions{}); err != ErrSubscriptionNotFound {
+		t.Errorf("Expected ErrSubscriptionNotFound, got %v", err)
+	}
+
+	if _, err := NewEventBus(10).Replay(context.Background(), subID, ReplayOptions{}); err != ErrNoEventStore {
+		t.Errorf("Expected ErrNoEventStore, got %v", err)
+	}
+}
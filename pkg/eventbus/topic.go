@@ -0,0 +1,217 @@
+package eventbus
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	// topicSeparator 事件类型层级分隔符
+	topicSeparator = "."
+
+	// wildcardOne 匹配恰好一个层级，如 "user.*" 匹配 "user.created"
+	wildcardOne = "*"
+
+	// wildcardRest 匹配剩余的一个或多个层级，只能出现在末尾，如 "order.>" 匹配 "order.item.added"
+	wildcardRest = ">"
+)
+
+var (
+	// ErrInvalidPattern 订阅模式非法
+	// 模式中存在空层级，或 ">" 不在末尾时返回此错误
+	ErrInvalidPattern = errors.New("invalid subscription pattern")
+
+	// ErrInvalidEventType 发布的事件类型非法
+	// 通配符只能用于订阅模式，事件类型中包含 "*" 或 ">" 层级时返回此错误
+	ErrInvalidEventType = errors.New("invalid event type: wildcards are only allowed in subscription patterns")
+)
+
+// isPattern 判断事件类型是否包含通配符
+func isPattern(eventType string) bool {
+	for _, token := range strings.Split(eventType, topicSeparator) {
+		if token == wildcardOne || token == wildcardRest {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePattern 校验订阅模式
+func validatePattern(pattern string) error {
+	tokens := strings.Split(pattern, topicSeparator)
+	for i, token := range tokens {
+		if token == "" {
+			return ErrInvalidPattern
+		}
+		if token == wildcardRest && i != len(tokens)-1 {
+			return ErrInvalidPattern
+		}
+	}
+	return nil
+}
+
+// validateEventType 校验发布的事件类型
+//
+// 事件类型中的 "*" 或 ">" 层级在前缀树中会被当作通配符节点匹配，
+// 导致事件被投递给意料之外的订阅，因此只允许出现在订阅模式中
+func validateEventType(eventType string) error {
+	if isPattern(eventType) {
+		return ErrInvalidEventType
+	}
+	return nil
+}
+
+// matchTopic 判断事件类型是否匹配订阅（精确类型或通配符模式）
+func matchTopic(pattern, eventType string) bool {
+	if pattern == eventType {
+		return true
+	}
+	if !isPattern(pattern) {
+		return false
+	}
+
+	patternTokens := strings.Split(pattern, topicSeparator)
+	typeTokens := strings.Split(eventType, topicSeparator)
+	for i, token := range patternTokens {
+		if token == wildcardRest {
+			return len(typeTokens) > i
+		}
+		if i >= len(typeTokens) {
+			return false
+		}
+		if token != wildcardOne && token != typeTokens[i] {
+			return false
+		}
+	}
+	return len(patternTokens) == len(typeTokens)
+}
+
+// topicTrie 通配符订阅前缀树
+//
+// 设计原理：
+// 1. 按 "." 将模式拆分为层级，每个层级对应一个节点
+// 2. "*" 作为普通子节点存储，匹配时与具体层级一起查找
+// 3. ">" 的订阅挂在父节点的 rest 列表上，匹配任意剩余层级
+//
+// 复杂度：
+// - 匹配耗时与事件类型层级数和通配符分支数相关，与订阅总数无关
+// - 精确订阅不进入前缀树，仍然通过 map 查找
+type topicTrie struct {
+	root *trieNode
+	size int
+}
+
+// trieNode 前缀树节点
+type trieNode struct {
+	children map[string]*trieNode
+
+	// subs 在此节点结束的订阅
+	subs []*Subscription
+
+	// rest 以 ">" 结尾、匹配此节点之后任意层级的订阅
+	rest []*Subscription
+}
+
+// newTopicTrie 创建前缀树
+func newTopicTrie() *topicTrie {
+	return &topicTrie{root: newTrieNode()}
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[string]*trieNode)}
+}
+
+// insert 插入订阅
+func (t *topicTrie) insert(sub *Subscription) {
+	node := t.root
+	for _, token := range strings.Split(sub.EventType, topicSeparator) {
+		if token == wildcardRest {
+			node.rest = append(node.rest, sub)
+			t.size++
+			return
+		}
+		child, ok := node.children[token]
+		if !ok {
+			child = newTrieNode()
+			node.children[token] = child
+		}
+		node = child
+	}
+	node.subs = append(node.subs, sub)
+	t.size++
+}
+
+// remove 删除订阅
+//
+// 沿路径返回时删除没有订阅也没有子节点的节点，订阅和取消订阅大量不同的模式时前缀树不会无限增长
+func (t *topicTrie) remove(sub *Subscription) {
+	if t.root.remove(strings.Split(sub.EventType, topicSeparator), sub.ID) {
+		t.size--
+	}
+}
+
+// remove 从子树中删除订阅，返回是否找到
+func (n *trieNode) remove(tokens []string, id string) bool {
+	if len(tokens) == 0 {
+		before := len(n.subs)
+		n.subs = removeSubscription(n.subs, id)
+		return len(n.subs) < before
+	}
+	if tokens[0] == wildcardRest {
+		before := len(n.rest)
+		n.rest = removeSubscription(n.rest, id)
+		return len(n.rest) < before
+	}
+
+	child, ok := n.children[tokens[0]]
+	if !ok {
+		return false
+	}
+	removed := child.remove(tokens[1:], id)
+	if child.empty() {
+		delete(n.children, tokens[0])
+	}
+	return removed
+}
+
+// empty 节点没有订阅也没有子节点时可以删除
+func (n *trieNode) empty() bool {
+	return len(n.subs) == 0 && len(n.rest) == 0 && len(n.children) == 0
+}
+
+// match 返回所有匹配事件类型的订阅
+func (t *topicTrie) match(eventType string) []*Subscription {
+	var result []*Subscription
+	t.root.match(strings.Split(eventType, topicSeparator), &result)
+	return result
+}
+
+func (n *trieNode) match(tokens []string, result *[]*Subscription) {
+	if len(tokens) == 0 {
+		*result = append(*result, n.subs...)
+		return
+	}
+
+	// ">" 至少匹配一个层级
+	*result = append(*result, n.rest...)
+
+	if child, ok := n.children[tokens[0]]; ok {
+		child.match(tokens[1:], result)
+	}
+	if child, ok := n.children[wildcardOne]; ok {
+		child.match(tokens[1:], result)
+	}
+}
+
+// removeSubscription 从订阅列表中移除指定订阅
+//
+// 返回新的切片，不修改原切片，避免与正在遍历旧切片的 handleEvent 发生数据竞争
+func removeSubscription(subs []*Subscription, id string) []*Subscription {
+	result := make([]*Subscription, 0, len(subs))
+	for _, s := range subs {
+		if s.ID != id {
+			result = append(result, s)
+		}
+	}
+	return result
+}
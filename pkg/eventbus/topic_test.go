@@ -0,0 +1,197 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		eventType string
+		want      bool
+	}{
+		{"user.created", "user.created", true},
+		{"user.created", "user.deleted", false},
+		{"user.*", "user.created", true},
+		{"user.*", "user.profile.updated", false},
+		{"user.*", "user", false},
+		{"*.created", "order.created", true},
+		{"order.>", "order.created", true},
+		{"order.>", "order.item.added", true},
+		{"order.>", "order", false},
+		{">", "anything.at.all", true},
+		{"order.*.added", "order.item.added", true},
+		{"order.*.added", "order.item.removed", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchTopic(tt.pattern, tt.eventType); got != tt.want {
+			t.Errorf("matchTopic(%q, %q) = %v, want %v", tt.pattern, tt.eventType, got, tt.want)
+		}
+
+		trie := newTopicTrie()
+		if isPattern(tt.pattern) {
+			trie.insert(&Subscription{ID: "sub", EventType: tt.pattern})
+			if got := len(trie.match(tt.eventType)) == 1; got != tt.want {
+				t.Errorf("trie match(%q, %q) = %v, want %v", tt.pattern, tt.eventType, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestEventBus_WildcardSubscribe(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+	defer eb.Stop()
+
+	var userEvents, orderEvents, exactEvents int32
+	eb.Subscribe("user.*", func(ctx context.Context, event Event) error {
+		atomic.AddInt32(&userEvents, 1)
+		return nil
+	})
+	orderSub, _ := eb.Subscribe("order.>", func(ctx context.Context, event Event) error {
+		atomic.AddInt32(&orderEvents, 1)
+		return nil
+	})
+	eb.Subscribe("user.created", func(ctx context.Context, event Event) error {
+		atomic.AddInt32(&exactEvents, 1)
+		return nil
+	})
+
+	for _, eventType := range []string{"user.created", "user.deleted", "user.profile.updated", "order.created", "order.item.added"} {
+		eb.Publish(NewEvent(eventType, nil))
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&userEvents); got != 2 {
+		t.Errorf("Expected 2 user events, got %d", got)
+	}
+	if got := atomic.LoadInt32(&orderEvents); got != 2 {
+		t.Errorf("Expected 2 order events, got %d", got)
+	}
+	if got := atomic.LoadInt32(&exactEvents); got != 1 {
+		t.Errorf("Expected 1 exact event, got %d", got)
+	}
+
+	if err := eb.Unsubscribe(orderSub); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	eb.Publish(NewEvent("order.created", nil))
+	time.Sleep(50 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&orderEvents); got != 2 {
+		t.Errorf("Expected no order events after unsubscribe, got %d", got)
+	}
+}
+
+func TestTopicTrie_RemovePrunesEmptyNodes(t *testing.T) {
+	trie := newTopicTrie()
+	keep := &Subscription{ID: "keep", EventType: "order.*"}
+	trie.insert(keep)
+
+	for i := 0; i < 100; i++ {
+		sub := &Subscription{ID: fmt.Sprintf("sub-%d", i), EventType: fmt.Sprintf("order.*.item%d.>", i)}
+		trie.insert(sub)
+		trie.remove(sub)
+	}
+	// 重复删除不影响计数
+	trie.remove(&Subscription{ID: "sub-0", EventType: "order.*.item0.>"})
+
+	if trie.size != 1 {
+		t.Errorf("Expected size 1, got %d", trie.size)
+	}
+	star := trie.root.children["order"].children[wildcardOne]
+	if len(star.children) != 0 {
+		t.Errorf("Expected empty nodes to be pruned, got %d children", len(star.children))
+	}
+	if got := trie.match("order.created"); len(got) != 1 || got[0] != keep {
+		t.Errorf("Expected remaining subscription to match, got %v", got)
+	}
+
+	trie.remove(keep)
+	if len(trie.root.children) != 0 {
+		t.Errorf("Expected trie to be empty, got %d children", len(trie.root.children))
+	}
+}
+
+func TestEventBus_InvalidPattern(t *testing.T) {
+	eb := NewEventBus(10)
+
+	for _, pattern := range []string{"order.>.created", "user..*", "*."} {
+		if _, err := eb.Subscribe(pattern, func(ctx context.Context, event Event) error {
+			return nil
+		}); !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("Expected ErrInvalidPattern for %q, got %v", pattern, err)
+		}
+	}
+}
+
+func TestEventBus_PublishRejectsWildcardType(t *testing.T) {
+	store := NewMemoryEventStore(0)
+	eb := NewEventBus(10, WithEventStore(store))
+	eb.Start()
+	defer eb.Stop()
+
+	var received atomic.Int32
+	eb.Subscribe("user.*", func(ctx context.Context, event Event) error {
+		received.Add(1)
+		return nil
+	})
+
+	for _, eventType := range []string{"user.*", "user.>", "*", "order.*.created"} {
+		if err := eb.Publish(NewEvent(eventType, nil)); !errors.Is(err, ErrInvalidEventType) {
+			t.Errorf("Expected ErrInvalidEventType for %q, got %v", eventType, err)
+		}
+	}
+	eb.PublishAsync(NewEvent("user.*", nil))
+
+	// 层级中包含通配符字符但不是完整层级时仍然合法
+	if err := eb.Publish(NewEvent("user.created*", nil)); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if n := received.Load(); n != 1 {
+		t.Errorf("Expected 1 delivery, got %d", n)
+	}
+	if store.NextOffset() != 1 {
+		t.Errorf("Expected rejected events not to be persisted, got next offset %d", store.NextOffset())
+	}
+	if dropped := eb.GetMetrics().DroppedEvents; dropped != 1 {
+		t.Errorf("Expected PublishAsync to count 1 dropped event, got %d", dropped)
+	}
+}
+
+func BenchmarkHandleEvent_ExactMatch(b *testing.B) {
+	benchmarkHandleEvent(b, false)
+}
+
+func BenchmarkHandleEvent_ExactMatchWithPatterns(b *testing.B) {
+	benchmarkHandleEvent(b, true)
+}
+
+func benchmarkHandleEvent(b *testing.B, withPatterns bool) {
+	eb := NewEventBus(10)
+	defer eb.Stop()
+
+	handler := func(ctx context.Context, event Event) error { return nil }
+	eb.Subscribe("user.created", handler)
+	if withPatterns {
+		eb.Subscribe("order.*", handler)
+		eb.Subscribe("payment.>", handler)
+	}
+
+	event := NewEvent("user.created", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		eb.handleEvent(event)
+	}
+	eb.wg.Wait()
+}
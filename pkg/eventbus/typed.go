@@ -0,0 +1,212 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// MetadataKeyPayloadType 负载类型元数据键
+// Publish[T] 和 NewTypedEvent[T] 会写入负载的 Go 类型名，Subscribe[T] 据此校验类型
+const MetadataKeyPayloadType = "payload_type"
+
+// ErrPayloadTypeMismatch 事件负载与订阅的类型不匹配
+// 类型不匹配的事件不会重试，直接进入死信存储
+var ErrPayloadTypeMismatch = errors.New("event payload type mismatch")
+
+// TypedEvent 带类型负载的事件
+//
+// 设计原理：
+// 1. 包装原始事件，Data() 和 Payload() 返回已解码的类型化负载
+// 2. 实现 Event 接口（以及 internal/app/patterns.Event），可以作为 patterns.EventHandler[*eventbus.TypedEvent[T]] 的事件类型
+// 3. 元数据从原始事件透传
+type TypedEvent[T any] struct {
+	source  Event
+	payload T
+}
+
+// Type 返回事件类型
+func (e *TypedEvent[T]) Type() string {
+	return e.source.Type()
+}
+
+// Data 返回类型化负载（interface{} 形式）
+func (e *TypedEvent[T]) Data() interface{} {
+	return e.payload
+}
+
+// Payload 返回类型化负载
+func (e *TypedEvent[T]) Payload() T {
+	return e.payload
+}
+
+// Timestamp 返回事件时间戳
+func (e *TypedEvent[T]) Timestamp() time.Time {
+	return e.source.Timestamp()
+}
+
+// GetMetadata 获取原始事件的元数据
+func (e *TypedEvent[T]) GetMetadata(key string) (interface{}, bool) {
+	carrier, ok := e.source.(interface {
+		GetMetadata(key string) (interface{}, bool)
+	})
+	if !ok {
+		return nil, false
+	}
+	return carrier.GetMetadata(key)
+}
+
+// Metadata 返回原始事件元数据的副本
+func (e *TypedEvent[T]) Metadata() map[string]interface{} {
+	return eventMetadata(e.source)
+}
+
+// Source 返回原始事件
+func (e *TypedEvent[T]) Source() Event {
+	return e.source
+}
+
+// TypedHandler 类型化事件处理器接口
+//
+// 与 internal/app/patterns.EventHandler[T Event] 的形状一致：
+// 任何 patterns.EventHandler[*eventbus.TypedEvent[T]] 都实现了 TypedHandler[T]，
+// 处理器的负载类型在编译期检查
+type TypedHandler[T any] interface {
+	Handle(ctx context.Context, event *TypedEvent[T]) error
+}
+
+// TypedHandlerFunc 函数形式的类型化事件处理器
+type TypedHandlerFunc[T any] func(ctx context.Context, event *TypedEvent[T]) error
+
+// Handle 调用函数本身
+func (f TypedHandlerFunc[T]) Handle(ctx context.Context, event *TypedEvent[T]) error {
+	return f(ctx, event)
+}
+
+// NewTypedEvent 创建带负载类型元数据的事件
+//
+// 需要在发布前附加其他元数据（如 aggregate_id）时使用，否则直接使用 Publish[T]
+//
+// 示例：
+//
+//	event := eventbus.NewTypedEvent("user.created", UserCreated{ID: u.ID})
+//	event.SetMetadata(eventbus.MetadataKeyAggregateID, u.ID)
+//	eb.Publish(event)
+func NewTypedEvent[T any](eventType string, payload T) *BaseEvent {
+	event := NewEvent(eventType, payload)
+	event.SetMetadata(MetadataKeyPayloadType, payloadTypeName[T]())
+	return event
+}
+
+// Publish 发布类型化事件
+//
+// 参数：
+//   - eb: 事件总线
+//   - eventType: 事件类型
+//   - payload: 事件负载
+//
+// 返回：
+//   - error: 与 EventBus.Publish 相同
+func Publish[T any](eb *EventBus, eventType string, payload T) error {
+	return eb.Publish(NewTypedEvent(eventType, payload))
+}
+
+// Subscribe 订阅类型化事件
+//
+// 设计原理：
+// 1. 进程内发布的事件，负载必须是 T（或 *T）
+// 2. 来自事件存储、Bridge 或发件箱的事件，负载为 JSON（json.RawMessage 或 []byte），解码为 T
+// 3. 事件携带 MetadataKeyPayloadType 元数据时，类型名必须与 T 一致
+//
+// 类型不匹配时处理器不会被调用，订阅返回包装了 ErrPayloadTypeMismatch 的错误，
+// 事件不重试、直接计入失败指标并写入死信存储
+//
+// 参数：
+//   - eb: 事件总线
+//   - eventType: 事件类型或通配符模式
+//   - handler: 类型化事件处理器
+//   - opts: 订阅选项
+//
+// 返回：
+//   - string: 订阅ID
+//   - error: 与 EventBus.Subscribe 相同
+//
+// 示例：
+//
+//	type UserCreated struct {
+//	    ID    string `json:"id"`
+//	    Email string `json:"email"`
+//	}
+//
+//	eventbus.Subscribe[UserCreated](eb, "user.created",
+//	    eventbus.TypedHandlerFunc[UserCreated](func(ctx context.Context, e *eventbus.TypedEvent[UserCreated]) error {
+//	        return sendWelcomeEmail(ctx, e.Payload().Email)
+//	    }))
+//
+//	eventbus.Publish(eb, "user.created", UserCreated{ID: "1", Email: "a@example.com"})
+func Subscribe[T any](eb *EventBus, eventType string, handler TypedHandler[T], opts ...SubscribeOption) (string, error) {
+	return eb.Subscribe(eventType, func(ctx context.Context, event Event) error {
+		typed, err := Decode[T](event)
+		if err != nil {
+			return err
+		}
+		return handler.Handle(ctx, typed)
+	}, opts...)
+}
+
+// Decode 将事件解码为类型化事件
+//
+// 返回：
+//   - *TypedEvent[T]: 类型化事件
+//   - error: 负载类型不匹配或 JSON 解码失败时返回包装了 ErrPayloadTypeMismatch 的错误
+func Decode[T any](event Event) (*TypedEvent[T], error) {
+	want := payloadTypeName[T]()
+	if got := orderingKey(event, MetadataKeyPayloadType); got != "" && got != want {
+		return nil, fmt.Errorf("%w: event %s carries %s, subscriber expects %s",
+			ErrPayloadTypeMismatch, event.Type(), got, want)
+	}
+
+	switch data := event.Data().(type) {
+	case T:
+		return &TypedEvent[T]{source: event, payload: data}, nil
+	case *T:
+		if data != nil {
+			return &TypedEvent[T]{source: event, payload: *data}, nil
+		}
+	case json.RawMessage:
+		return decodeJSONPayload[T](event, data)
+	case []byte:
+		return decodeJSONPayload[T](event, data)
+	}
+
+	return nil, fmt.Errorf("%w: event %s carries %T, subscriber expects %s",
+		ErrPayloadTypeMismatch, event.Type(), event.Data(), want)
+}
+
+// decodeJSONPayload 将 JSON 负载解码为 T
+func decodeJSONPayload[T any](event Event, data []byte) (*TypedEvent[T], error) {
+	var payload T
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("%w: event %s cannot be decoded as %s: %v",
+			ErrPayloadTypeMismatch, event.Type(), payloadTypeName[T](), err)
+	}
+	return &TypedEvent[T]{source: event, payload: payload}, nil
+}
+
+// payloadTypeName 返回负载类型名
+//
+// 使用包路径限定的类型名，指针类型按元素类型命名，
+// 保证 Publish[*User] 与 Subscribe[User] 经过 JSON 序列化后仍然兼容
+func payloadTypeName[T any]() string {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
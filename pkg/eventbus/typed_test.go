@@ -0,0 +1,156 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type userCreated struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+}
+
+type orderPlaced struct {
+	OrderID string `json:"order_id"`
+}
+
+func TestTypedSubscribe_InProcess(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+	defer eb.Stop()
+
+	received := make(chan *TypedEvent[userCreated], 2)
+	_, err := Subscribe[userCreated](eb, "user.created",
+		TypedHandlerFunc[userCreated](func(ctx context.Context, event *TypedEvent[userCreated]) error {
+			received <- event
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := Publish(eb, "user.created", userCreated{ID: "1", Email: "a@example.com"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	// 指针负载同样可以解码
+	if err := Publish(eb, "user.created", &userCreated{ID: "2"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-received:
+			if event.Payload().ID == "" {
+				t.Errorf("Expected decoded payload, got %+v", event.Payload())
+			}
+			if _, ok := event.GetMetadata(MetadataKeyPayloadType); !ok {
+				t.Error("Expected payload type metadata")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for event")
+		}
+	}
+}
+
+func TestDecode_JSONPayload(t *testing.T) {
+	// 来自事件存储、Bridge 或发件箱的事件负载为 JSON
+	event := NewEvent("user.created", json.RawMessage(`{"id":"1","email":"a@example.com"}`))
+	event.SetMetadata(MetadataKeyPayloadType, payloadTypeName[userCreated]())
+
+	typed, err := Decode[userCreated](event)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if typed.Payload() != (userCreated{ID: "1", Email: "a@example.com"}) {
+		t.Errorf("Unexpected payload: %+v", typed.Payload())
+	}
+	if typed.Type() != "user.created" || !typed.Timestamp().Equal(event.Timestamp()) {
+		t.Error("Expected type and timestamp from source event")
+	}
+
+	if _, err := Decode[userCreated](NewEvent("user.created", []byte("not json"))); !errors.Is(err, ErrPayloadTypeMismatch) {
+		t.Errorf("Expected ErrPayloadTypeMismatch for invalid JSON, got %v", err)
+	}
+}
+
+func TestDecode_Mismatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{"wrong Go type", NewEvent("user.created", "not a struct")},
+		{"nil data", NewEvent("user.created", nil)},
+		{"wrong payload type metadata", NewTypedEvent("user.created", orderPlaced{OrderID: "o-1"})},
+		{"JSON with wrong payload type metadata", func() Event {
+			event := NewEvent("user.created", json.RawMessage(`{"order_id":"o-1"}`))
+			event.SetMetadata(MetadataKeyPayloadType, payloadTypeName[orderPlaced]())
+			return event
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode[userCreated](tt.event); !errors.Is(err, ErrPayloadTypeMismatch) {
+				t.Errorf("Expected ErrPayloadTypeMismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTypedSubscribe_MismatchIsDeadLetteredWithoutRetry(t *testing.T) {
+	eb := NewEventBus(10)
+	eb.Start()
+	defer eb.Stop()
+
+	called := false
+	_, err := Subscribe[userCreated](eb, "user.created",
+		TypedHandlerFunc[userCreated](func(ctx context.Context, event *TypedEvent[userCreated]) error {
+			called = true
+			return nil
+		}), WithMaxAttempts(5), WithBackoff(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	if err := Publish(eb, "user.created", orderPlaced{OrderID: "o-1"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && eb.GetMetrics().FailedEvents == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	letters, err := eb.ListDeadLetters()
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+	if len(letters) != 1 {
+		t.Fatalf("Expected 1 dead letter, got %d", len(letters))
+	}
+	if letters[0].Attempts != 1 {
+		t.Errorf("Expected no retries for type mismatch, got %d attempts", letters[0].Attempts)
+	}
+	if called {
+		t.Error("Handler should not be called on type mismatch")
+	}
+	if metrics := eb.GetMetrics(); metrics.RetriedEvents != 0 {
+		t.Errorf("Expected 0 retried events, got %d", metrics.RetriedEvents)
+	}
+}
+
+func TestPayloadTypeName(t *testing.T) {
+	want := "github.com/yourusername/golang/pkg/eventbus.userCreated"
+	if got := payloadTypeName[userCreated](); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if got := payloadTypeName[*userCreated](); got != want {
+		t.Errorf("Expected pointer type to share name %s, got %s", want, got)
+	}
+	if got := payloadTypeName[map[string]int](); got != "map[string]int" {
+		t.Errorf("Unexpected name for unnamed type: %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+// Package outbox 提供事务性发件箱（Transactional Outbox）实现
+//
+// 设计原理：
+// 1. 业务数据和领域事件在同一个数据库事务中写入，事件写入 outbox_messages 表
+// 2. 事务提交后，Relay 后台轮询发件箱，将未发布的事件发布到 EventBus 或 Kafka
+// 3. 发布成功后才标记为已发布，进程崩溃时事件会被重新发布（至少一次语义）
+//
+// 核心组件：
+// - Writer: 在 transaction.Manager 开启的事务中写入事件
+// - Relay: 轮询发件箱并通过 Publisher 发布事件
+// - Publisher: 发布目标，内置 EventBusPublisher 和 KafkaPublisher
+//
+// 使用场景：
+// 1. 创建用户后发布 user.created 事件，保证"用户存在 ⇔ 事件最终被发布"
+// 2. 任何需要在数据库变更后可靠通知其他模块或服务的场景
+//
+// 架构位置：
+// - 包位置：pkg/outbox/
+// - 表结构：migrations/postgres/002_create_outbox_messages.up.sql、migrations/sqlite3/
+//
+// 示例：
+//
+//	manager := transaction.NewSQLTransactionManager(db)
+//	writer := outbox.NewWriter(outbox.DialectPostgres)
+//
+//	err := manager.WithTransaction(ctx, func(ctx context.Context) error {
+//	    tx, _ := transaction.GetSQLTx(ctx)
+//	    if _, err := tx.ExecContext(ctx, "INSERT INTO users ..."); err != nil {
+//	        return err
+//	    }
+//	    return writer.Record(ctx, eventbus.NewEvent("user.created", user))
+//	})
+//
+//	relay := outbox.NewRelay(manager, outbox.DialectPostgres,
+//	    outbox.NewEventBusPublisher(eventBus), outbox.RelayConfig{})
+//	go relay.Run(ctx)
+//
+// 注意事项：
+// - 仓储必须通过 transaction.GetSQLTx(ctx) 使用同一个事务，否则无法保证原子性
+// - 事件可能被重复发布，事件处理器应该是幂等的（可以用 MessageID 去重）
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/golang/internal/infra/database/sqldialect"
+	"github.com/yourusername/golang/pkg/eventbus"
+	"github.com/yourusername/golang/pkg/transaction"
+)
+
+// TableName 发件箱表名
+const TableName = "outbox_messages"
+
+// MetadataKeyMessageID 发件箱消息ID元数据键
+// Relay 发布的事件会携带此元数据，事件处理器可以用它去重
+const MetadataKeyMessageID = "outbox_message_id"
+
+var (
+	// ErrNoTransaction 上下文中没有 SQL 事务
+	// Writer 必须在 transaction.Manager.WithTransaction 中调用
+	ErrNoTransaction = errors.New("outbox: no sql transaction in context")
+)
+
+// Dialect SQL 方言
+type Dialect = sqldialect.Dialect
+
+const (
+	// DialectPostgres PostgreSQL 方言（$1 占位符，支持 FOR UPDATE SKIP LOCKED）
+	DialectPostgres = sqldialect.Postgres
+
+	// DialectSQLite SQLite 方言（? 占位符）
+	DialectSQLite = sqldialect.SQLite
+)
+
+// Message 发件箱消息
+//
+// 字段说明：
+// - ID: 自增主键，决定发布顺序
+// - MessageID: 全局唯一消息ID（UUID），用于下游去重
+// - AggregateID: 聚合根ID（来自事件的 aggregate_id 元数据）
+// - EventType: 事件类型
+// - Payload: JSON 编码的事件数据
+// - Metadata: 事件元数据
+// - OccurredAt: 事件发生时间
+// - Attempts: 已尝试发布次数
+type Message struct {
+	ID          int64
+	MessageID   string
+	AggregateID string
+	EventType   string
+	Payload     json.RawMessage
+	Metadata    map[string]interface{}
+	OccurredAt  time.Time
+	Attempts    int
+}
+
+// Event 将消息还原为事件
+//
+// 事件的 Data() 为 json.RawMessage，元数据中附加 MetadataKeyMessageID
+func (m *Message) Event() *eventbus.BaseEvent {
+	event := eventbus.NewEventAt(m.EventType, m.Payload, m.OccurredAt)
+	for k, v := range m.Metadata {
+		event.SetMetadata(k, v)
+	}
+	event.SetMetadata(MetadataKeyMessageID, m.MessageID)
+	return event
+}
+
+// Writer 发件箱写入器
+//
+// 设计原理：
+// 1. 从上下文中获取 transaction.Manager 开启的 SQL 事务
+// 2. 在同一个事务中插入发件箱消息，与业务数据一起提交或回滚
+type Writer struct {
+	dialect Dialect
+}
+
+// NewWriter 创建发件箱写入器
+func NewWriter(dialect Dialect) *Writer {
+	return &Writer{dialect: dialect}
+}
+
+// Record 在当前事务中写入事件
+//
+// 参数：
+//   - ctx: 包含 SQL 事务的上下文（由 transaction.Manager.WithTransaction 提供）
+//   - event: 要发布的事件，数据必须可以 JSON 序列化
+//
+// 返回：
+//   - error: 写入失败时返回错误
+//   - ErrNoTransaction: 上下文中没有 SQL 事务
+func (w *Writer) Record(ctx context.Context, event eventbus.Event) error {
+	tx, ok := transaction.GetSQLTx(ctx)
+	if !ok {
+		return ErrNoTransaction
+	}
+
+	payload, err := json.Marshal(event.Data())
+	if err != nil {
+		return fmt.Errorf("outbox: failed to marshal event data: %w", err)
+	}
+
+	var metadata []byte
+	var aggregateID string
+	if carrier, ok := event.(interface {
+		Metadata() map[string]interface{}
+	}); ok {
+		values := carrier.Metadata()
+		if id, ok := values[eventbus.MetadataKeyAggregateID]; ok && id != nil {
+			aggregateID = fmt.Sprint(id)
+		}
+		if len(values) > 0 {
+			if metadata, err = json.Marshal(values); err != nil {
+				return fmt.Errorf("outbox: failed to marshal event metadata: %w", err)
+			}
+		}
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (message_id, aggregate_id, event_type, payload, metadata, occurred_at) VALUES (%s, %s, %s, %s, %s, %s)",
+		TableName,
+		w.dialect.Placeholder(1), w.dialect.Placeholder(2), w.dialect.Placeholder(3),
+		w.dialect.Placeholder(4), w.dialect.Placeholder(5), w.dialect.Placeholder(6),
+	)
+
+	var metadataArg interface{}
+	if metadata != nil {
+		metadataArg = string(metadata)
+	}
+
+	_, err = tx.ExecContext(ctx, query,
+		uuid.NewString(), aggregateID, event.Type(), string(payload), metadataArg, event.Timestamp().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("outbox: failed to insert message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/yourusername/golang/pkg/eventbus"
+	"github.com/yourusername/golang/pkg/transaction"
+)
+
+// openTestDB 打开内存数据库并执行 SQLite 迁移
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// :memory: 数据库每个连接独立，限制为单连接
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migration, err := os.ReadFile("../../migrations/sqlite3/001_create_outbox_messages.up.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.Exec(string(migration)); err != nil {
+		t.Fatalf("Failed to apply migration: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE users (id TEXT PRIMARY KEY, email TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Failed to create users table: %v", err)
+	}
+	return db
+}
+
+// recordUser 在事务中写入用户和 user.created 事件
+func recordUser(ctx context.Context, manager transaction.Manager, writer *Writer, id string, fail bool) error {
+	return manager.WithTransaction(ctx, func(ctx context.Context) error {
+		tx, _ := transaction.GetSQLTx(ctx)
+		if _, err := tx.ExecContext(ctx, "INSERT INTO users (id, email) VALUES (?, ?)", id, id+"@example.com"); err != nil {
+			return err
+		}
+
+		event := eventbus.NewEvent("user.created", map[string]string{"id": id})
+		event.SetMetadata(eventbus.MetadataKeyAggregateID, id)
+		if err := writer.Record(ctx, event); err != nil {
+			return err
+		}
+
+		if fail {
+			return errors.New("business failure")
+		}
+		return nil
+	})
+}
+
+func countRows(t *testing.T, db *sql.DB, query string) int {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow(query).Scan(&n); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	return n
+}
+
+func TestWriter_RecordCommitsWithBusinessData(t *testing.T) {
+	db := openTestDB(t)
+	manager := transaction.NewSQLTransactionManager(db)
+	writer := NewWriter(DialectSQLite)
+	ctx := context.Background()
+
+	if err := recordUser(ctx, manager, writer, "user-1", false); err != nil {
+		t.Fatalf("Failed to record user: %v", err)
+	}
+	if err := recordUser(ctx, manager, writer, "user-2", true); err == nil {
+		t.Fatal("Expected business failure")
+	}
+
+	if n := countRows(t, db, "SELECT COUNT(*) FROM users"); n != 1 {
+		t.Errorf("Expected 1 user, got %d", n)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM outbox_messages"); n != 1 {
+		t.Errorf("Expected 1 outbox message, got %d", n)
+	}
+
+	var aggregateID, eventType, payload string
+	err := db.QueryRow("SELECT aggregate_id, event_type, payload FROM outbox_messages").
+		Scan(&aggregateID, &eventType, &payload)
+	if err != nil {
+		t.Fatalf("Failed to query outbox message: %v", err)
+	}
+	if aggregateID != "user-1" || eventType != "user.created" {
+		t.Errorf("Unexpected message: aggregate_id=%s event_type=%s", aggregateID, eventType)
+	}
+	if payload != `{"id":"user-1"}` {
+		t.Errorf("Unexpected payload: %s", payload)
+	}
+}
+
+func TestWriter_RecordWithoutTransaction(t *testing.T) {
+	writer := NewWriter(DialectSQLite)
+
+	err := writer.Record(context.Background(), eventbus.NewEvent("user.created", nil))
+	if !errors.Is(err, ErrNoTransaction) {
+		t.Errorf("Expected ErrNoTransaction, got %v", err)
+	}
+}
+
+func TestRelay_PublishesToEventBus(t *testing.T) {
+	db := openTestDB(t)
+	manager := transaction.NewSQLTransactionManager(db)
+	writer := NewWriter(DialectSQLite)
+	ctx := context.Background()
+
+	for _, id := range []string{"user-1", "user-2", "user-3"} {
+		if err := recordUser(ctx, manager, writer, id, false); err != nil {
+			t.Fatalf("Failed to record user: %v", err)
+		}
+	}
+
+	bus := eventbus.NewEventBus(10)
+	if err := bus.Start(); err != nil {
+		t.Fatalf("Failed to start event bus: %v", err)
+	}
+	defer bus.Stop()
+
+	var (
+		mu       sync.Mutex
+		received []eventbus.Event
+		done     = make(chan struct{}, 3)
+	)
+	_, err := bus.Subscribe("user.created", func(ctx context.Context, event eventbus.Event) error {
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+		done <- struct{}{}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	relay := NewRelay(manager, DialectSQLite, NewEventBusPublisher(bus), RelayConfig{})
+	n, err := relay.ProcessBatch(ctx)
+	if err != nil {
+		t.Fatalf("Failed to process batch: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 claimed messages, got %d", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for events")
+		}
+	}
+
+	if pending := countRows(t, db, "SELECT COUNT(*) FROM outbox_messages WHERE published_at IS NULL"); pending != 0 {
+		t.Errorf("Expected no pending messages, got %d", pending)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	event := received[0].(*eventbus.BaseEvent)
+	if _, ok := event.GetMetadata(MetadataKeyMessageID); !ok {
+		t.Error("Expected outbox message id in metadata")
+	}
+	if _, ok := event.Data().(json.RawMessage); !ok {
+		t.Errorf("Expected json.RawMessage data, got %T", event.Data())
+	}
+
+	// 已发布的消息不会再次认领
+	n, err = relay.ProcessBatch(ctx)
+	if err != nil || n != 0 {
+		t.Errorf("Expected empty batch, got n=%d err=%v", n, err)
+	}
+}
+
+func TestRelay_EventBusHandlerFailureKeepsMessage(t *testing.T) {
+	db := openTestDB(t)
+	manager := transaction.NewSQLTransactionManager(db)
+	ctx := context.Background()
+
+	if err := recordUser(ctx, manager, NewWriter(DialectSQLite), "user-1", false); err != nil {
+		t.Fatalf("Failed to record user: %v", err)
+	}
+
+	bus := eventbus.NewEventBus(10)
+	if err := bus.Start(); err != nil {
+		t.Fatalf("Failed to start event bus: %v", err)
+	}
+	defer bus.Stop()
+
+	fail := true
+	_, err := bus.Subscribe("user.created", func(ctx context.Context, event eventbus.Event) error {
+		if fail {
+			return errors.New("handler failure")
+		}
+		return nil
+	}, eventbus.WithMaxAttempts(1))
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	// 处理器失败时消息不会被标记为已发布
+	relay := NewRelay(manager, DialectSQLite, NewEventBusPublisher(bus), RelayConfig{})
+	if _, err := relay.ProcessBatch(ctx); err == nil {
+		t.Fatal("Expected handler failure to be returned")
+	}
+	if pending := countRows(t, db, "SELECT COUNT(*) FROM outbox_messages WHERE published_at IS NULL AND attempts = 1"); pending != 1 {
+		t.Errorf("Expected the message to stay pending, got %d", pending)
+	}
+
+	fail = false
+	if _, err := relay.ProcessBatch(ctx); err != nil {
+		t.Fatalf("Failed to process batch: %v", err)
+	}
+	if pending := countRows(t, db, "SELECT COUNT(*) FROM outbox_messages WHERE published_at IS NULL"); pending != 0 {
+		t.Errorf("Expected no pending messages, got %d", pending)
+	}
+}
+
+func TestRelay_FailureStopsBatchAndRecordsError(t *testing.T) {
+	db := openTestDB(t)
+	manager := transaction.NewSQLTransactionManager(db)
+	writer := NewWriter(DialectSQLite)
+	ctx := context.Background()
+
+	for _, id := range []string{"user-1", "user-2", "user-3"} {
+		if err := recordUser(ctx, manager, writer, id, false); err != nil {
+			t.Fatalf("Failed to record user: %v", err)
+		}
+	}
+
+	var published []string
+	publisher := PublisherFunc(func(ctx context.Context, msg *Message) error {
+		if msg.AggregateID == "user-2" {
+			return errors.New("broker unavailable")
+		}
+		published = append(published, msg.AggregateID)
+		return nil
+	})
+
+	relay := NewRelay(manager, DialectSQLite, publisher, RelayConfig{MaxAttempts: 2})
+	if _, err := relay.ProcessBatch(ctx); err == nil {
+		t.Fatal("Expected publish error")
+	}
+	if len(published) != 1 || published[0] != "user-1" {
+		t.Errorf("Expected only user-1 to be published, got %v", published)
+	}
+
+	var attempts int
+	var lastError sql.NullString
+	err := db.QueryRow("SELECT attempts, last_error FROM outbox_messages WHERE aggregate_id = 'user-2'").
+		Scan(&attempts, &lastError)
+	if err != nil {
+		t.Fatalf("Failed to query message: %v", err)
+	}
+	if attempts != 1 || lastError.String != "broker unavailable" {
+		t.Errorf("Unexpected failure record: attempts=%d last_error=%q", attempts, lastError.String)
+	}
+
+	// 第二次失败后达到 MaxAttempts，消息被跳过，后续消息继续发布
+	if _, err := relay.ProcessBatch(ctx); err == nil {
+		t.Fatal("Expected publish error")
+	}
+	if _, err := relay.ProcessBatch(ctx); err != nil {
+		t.Fatalf("Expected exhausted message to be skipped, got %v", err)
+	}
+	if len(published) != 2 || published[1] != "user-3" {
+		t.Errorf("Expected user-3 to be published, got %v", published)
+	}
+}
+
+// fakeKafkaSender 记录发送的 Kafka 消息
+type fakeKafkaSender struct {
+	topic string
+	key   string
+	value []byte
+}
+
+func (s *fakeKafkaSender) SendBytes(ctx context.Context, topic, key string, value []byte) error {
+	s.topic, s.key, s.value = topic, key, value
+	return nil
+}
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	sender := &fakeKafkaSender{}
+	publisher := NewKafkaPublisher(sender, "")
+
+	occurredAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := &Message{
+		MessageID:   "msg-1",
+		AggregateID: "user-1",
+		EventType:   "user.created",
+		Payload:     json.RawMessage(`{"id":"user-1"}`),
+		OccurredAt:  occurredAt,
+	}
+	if err := publisher.Publish(context.Background(), msg); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	if sender.topic != "user.created" || sender.key != "user-1" {
+		t.Errorf("Unexpected topic/key: %s/%s", sender.topic, sender.key)
+	}
+
+	var envelope kafkaEnvelope
+	if err := json.Unmarshal(sender.value, &envelope); err != nil {
+		t.Fatalf("Failed to decode envelope: %v", err)
+	}
+	if envelope.Type != "user.created" || !envelope.Timestamp.Equal(occurredAt) {
+		t.Errorf("Unexpected envelope: %+v", envelope)
+	}
+	if string(envelope.Data) != `{"id":"user-1"}` {
+		t.Errorf("Unexpected data: %s", envelope.Data)
+	}
+	if envelope.Metadata[MetadataKeyMessageID] != "msg-1" {
+		t.Errorf("Expected message id in metadata, got %v", envelope.Metadata)
+	}
+}
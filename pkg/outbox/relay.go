@@ -0,0 +1,308 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/golang/pkg/eventbus"
+	"github.com/yourusername/golang/pkg/transaction"
+)
+
+const (
+	// defaultPollInterval 默认轮询间隔
+	defaultPollInterval = time.Second
+
+	// defaultBatchSize 默认每批处理的消息数
+	defaultBatchSize = 100
+
+	// defaultMaxAttempts 默认最大发布尝试次数
+	defaultMaxAttempts = 10
+)
+
+// Publisher 发布目标接口
+//
+// 实现要求：
+// - 发布成功返回 nil，消息随后被标记为已发布
+// - 返回错误时消息保留在发件箱中，下次轮询重试
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// PublisherFunc 函数形式的 Publisher
+type PublisherFunc func(ctx context.Context, msg *Message) error
+
+// Publish 调用函数本身
+func (f PublisherFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// RelayConfig Relay 配置
+type RelayConfig struct {
+	// PollInterval 轮询间隔，<= 0 时为 1 秒
+	PollInterval time.Duration
+
+	// BatchSize 每批处理的消息数，<= 0 时为 100
+	BatchSize int
+
+	// MaxAttempts 最大发布尝试次数，超过后消息不再自动重试，<= 0 时为 10
+	// 超过次数的消息保留在表中（attempts >= MaxAttempts），需要人工处理
+	MaxAttempts int
+}
+
+// Relay 发件箱中继
+//
+// 设计原理：
+// 1. 在 transaction.Manager 开启的事务中按 ID 顺序认领一批未发布的消息
+// 2. 逐条发布，成功后标记 published_at，失败则增加 attempts 并记录错误
+// 3. 某条消息发布失败时停止处理本批后续消息，保证同一发件箱的发布顺序
+//
+// 可靠性：
+// - 先发布、后标记，进程崩溃时消息会被重新发布（至少一次）
+// - PostgreSQL 使用 FOR UPDATE SKIP LOCKED，可以部署多个 Relay 实例
+type Relay struct {
+	manager   transaction.Manager
+	dialect   Dialect
+	publisher Publisher
+	config    RelayConfig
+}
+
+// NewRelay 创建发件箱中继
+//
+// 参数：
+//   - manager: 事务管理器，必须与业务写入使用同一个数据库
+//   - dialect: SQL 方言
+//   - publisher: 发布目标
+//   - config: 中继配置
+func NewRelay(manager transaction.Manager, dialect Dialect, publisher Publisher, config RelayConfig) *Relay {
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+
+	return &Relay{
+		manager:   manager,
+		dialect:   dialect,
+		publisher: publisher,
+		config:    config,
+	}
+}
+
+// Run 持续轮询发件箱，直到上下文取消
+//
+// 每次轮询会连续处理多批消息，直到发件箱中没有待发布的消息
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.ProcessBatch(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				slog.Warn("Outbox relay batch failed", "error", err)
+				break
+			}
+			if n < r.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch 处理一批待发布的消息
+//
+// 返回：
+//   - int: 本批认领的消息数
+//   - error: 数据库操作失败或消息发布失败时返回错误
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	var (
+		claimed    int
+		publishErr error
+	)
+	err := r.manager.WithTransaction(ctx, func(ctx context.Context) error {
+		tx, ok := transaction.GetSQLTx(ctx)
+		if !ok {
+			return ErrNoTransaction
+		}
+
+		messages, err := r.claim(ctx, tx)
+		if err != nil {
+			return err
+		}
+		claimed = len(messages)
+
+		for _, msg := range messages {
+			if err := r.publisher.Publish(ctx, msg); err != nil {
+				if err := r.markFailed(ctx, tx, msg, err); err != nil {
+					return err
+				}
+				// 返回 nil 以提交已发布标记和失败记录，错误在事务外返回
+				publishErr = fmt.Errorf("outbox: failed to publish message %s: %w", msg.MessageID, err)
+				return nil
+			}
+			if err := r.markPublished(ctx, tx, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return claimed, err
+	}
+	return claimed, publishErr
+}
+
+// claim 认领一批待发布的消息
+func (r *Relay) claim(ctx context.Context, tx *sql.Tx) ([]*Message, error) {
+	query := fmt.Sprintf(
+		"SELECT id, message_id, aggregate_id, event_type, payload, metadata, occurred_at, attempts FROM %s "+
+			"WHERE published_at IS NULL AND attempts < %s ORDER BY id LIMIT %s%s",
+		TableName, r.dialect.Placeholder(1), r.dialect.Placeholder(2), r.dialect.LockClause(),
+	)
+
+	rows, err := tx.QueryContext(ctx, query, r.config.MaxAttempts, r.config.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("outbox: failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var (
+			msg         Message
+			aggregateID sql.NullString
+			payload     []byte
+			metadata    []byte
+		)
+		if err := rows.Scan(&msg.ID, &msg.MessageID, &aggregateID, &msg.EventType,
+			&payload, &metadata, &msg.OccurredAt, &msg.Attempts); err != nil {
+			return nil, fmt.Errorf("outbox: failed to scan message: %w", err)
+		}
+		msg.AggregateID = aggregateID.String
+		msg.Payload = json.RawMessage(payload)
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &msg.Metadata); err != nil {
+				return nil, fmt.Errorf("outbox: failed to decode metadata of message %s: %w", msg.MessageID, err)
+			}
+		}
+		messages = append(messages, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox: failed to iterate messages: %w", err)
+	}
+	return messages, nil
+}
+
+// markPublished 标记消息已发布
+func (r *Relay) markPublished(ctx context.Context, tx *sql.Tx, msg *Message) error {
+	query := fmt.Sprintf("UPDATE %s SET published_at = %s, attempts = attempts + 1 WHERE id = %s",
+		TableName, r.dialect.Placeholder(1), r.dialect.Placeholder(2))
+	if _, err := tx.ExecContext(ctx, query, time.Now().UTC(), msg.ID); err != nil {
+		return fmt.Errorf("outbox: failed to mark message %s as published: %w", msg.MessageID, err)
+	}
+	return nil
+}
+
+// markFailed 记录发布失败
+func (r *Relay) markFailed(ctx context.Context, tx *sql.Tx, msg *Message, pubErr error) error {
+	query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = %s WHERE id = %s",
+		TableName, r.dialect.Placeholder(1), r.dialect.Placeholder(2))
+	if _, err := tx.ExecContext(ctx, query, pubErr.Error(), msg.ID); err != nil {
+		return fmt.Errorf("outbox: failed to record failure of message %s: %w", msg.MessageID, err)
+	}
+	return nil
+}
+
+// EventBusPublisher 将发件箱消息发布到进程内事件总线
+type EventBusPublisher struct {
+	bus *eventbus.EventBus
+}
+
+// NewEventBusPublisher 创建事件总线发布目标
+//
+// 事件的 Data() 为 json.RawMessage，元数据中包含 MetadataKeyMessageID
+func NewEventBusPublisher(bus *eventbus.EventBus) *EventBusPublisher {
+	return &EventBusPublisher{bus: bus}
+}
+
+// Publish 同步投递到事件总线的订阅
+//
+// 使用 EventBus.PublishSync：处理器执行完成后才返回，任一订阅最终处理失败时返回错误，
+// 消息保留在发件箱中并在下次轮询时重试，已处理成功的订阅会再次收到事件，应使用 MetadataKeyMessageID 去重
+func (p *EventBusPublisher) Publish(ctx context.Context, msg *Message) error {
+	return p.bus.PublishSync(ctx, msg.Event())
+}
+
+// KafkaSender Kafka 发送接口
+//
+// internal/infra/messaging/kafka.Producer 实现了此接口
+type KafkaSender interface {
+	SendBytes(ctx context.Context, topic string, key string, value []byte) error
+}
+
+// KafkaPublisher 将发件箱消息发布到 Kafka
+//
+// 消息值为 JSON 信封：{"type", "timestamp", "metadata", "data"}，
+// 与 internal/infra/messaging/bridge.JSONCodec 的格式一致；消息键为 AggregateID
+type KafkaPublisher struct {
+	sender KafkaSender
+	topic  func(msg *Message) string
+}
+
+// NewKafkaPublisher 创建 Kafka 发布目标
+//
+// 参数：
+//   - sender: Kafka 生产者
+//   - topic: 目标主题，为空时使用事件类型作为主题
+func NewKafkaPublisher(sender KafkaSender, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		sender: sender,
+		topic: func(msg *Message) string {
+			if topic == "" {
+				return msg.EventType
+			}
+			return topic
+		},
+	}
+}
+
+// kafkaEnvelope Kafka 消息信封
+type kafkaEnvelope struct {
+	Type      string                 `json:"type"`
+	Timestamp time.Time              `json:"timestamp"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Data      json.RawMessage        `json:"data,omitempty"`
+}
+
+// Publish 发布到 Kafka
+func (p *KafkaPublisher) Publish(ctx context.Context, msg *Message) error {
+	event := msg.Event()
+	value, err := json.Marshal(kafkaEnvelope{
+		Type:      msg.EventType,
+		Timestamp: msg.OccurredAt,
+		Metadata:  event.Metadata(),
+		Data:      msg.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("outbox: failed to encode message %s: %w", msg.MessageID, err)
+	}
+
+	return p.sender.SendBytes(ctx, p.topic(msg), msg.AggregateID, value)
+}
@@ -13,6 +13,7 @@
 //   - Policy: 策略，定义访问控制规则
 //   - Rule: 规则，决定策略是否匹配
 //   - Condition: 条件，评估复杂的属性表达式
+//   - PolicyLoader: 从 JSON/YAML 策略文件加载策略并热更新（见 CompileExpression）
 //
 // 快速开始：
 //
@@ -55,8 +56,8 @@ package abac
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"sort"
 	"sync"
 )
 
@@ -79,6 +80,21 @@ func (r Result) IsDenied() bool {
 	return !r.Allowed || r.Decision == Deny
 }
 
+// MarshalJSON 将 Errors 序列化为错误信息字符串
+//
+// error 接口值直接序列化会得到 {}，DecisionTrace 等返回给管理界面的结果需要可读的错误信息
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	messages := make([]string, len(r.Errors))
+	for i, err := range r.Errors {
+		messages[i] = err.Error()
+	}
+	return json.Marshal(struct {
+		plain
+		Errors []string `json:"errors,omitempty"`
+	}{plain: plain(r), Errors: messages})
+}
+
 // Engine 是 ABAC 引擎的核心结构
 //
 // 负责管理策略集合并执行访问控制评估
@@ -88,6 +104,13 @@ type Engine struct {
 	mu           sync.RWMutex
 	// 可选：策略变更回调
 	onPolicyChange func(event PolicyChangeEvent)
+
+	// generation 策略版本，每次变更递增
+	generation uint64
+	// index 启用策略的目标索引，为 nil 时在下一次评估前重建
+	index *policyIndex
+	// cache 可选的决策缓存
+	cache *decisionCache
 }
 
 // PolicyChangeEvent 表示策略变更事件
@@ -169,6 +192,7 @@ func (e *Engine) AddPolicy(policy Policy) error {
 	}
 
 	e.policies[policy.ID] = policy
+	e.invalidate()
 
 	// 触发回调
 	if e.onPolicyChange != nil {
@@ -198,6 +222,7 @@ func (e *Engine) UpdatePolicy(policy Policy) error {
 	}
 
 	e.policies[policy.ID] = policy
+	e.invalidate()
 
 	// 触发回调
 	if e.onPolicyChange != nil {
@@ -228,6 +253,7 @@ func (e *Engine) RemovePolicy(policyID string) error {
 	}
 
 	delete(e.policies, policyID)
+	e.invalidate()
 
 	// 触发回调
 	if e.onPolicyChange != nil {
@@ -263,7 +289,7 @@ func (e *Engine) GetPolicy(policyID string) (*Policy, error) {
 
 // ListPolicies 列出所有策略
 //
-// 返回按优先级排序的策略列表
+// 返回按评估顺序排序的策略列表：优先级从高到低，优先级相同时按 ID 排序
 func (e *Engine) ListPolicies() []Policy {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -272,11 +298,7 @@ func (e *Engine) ListPolicies() []Policy {
 	for _, policy := range e.policies {
 		policies = append(policies, policy)
 	}
-
-	// 按优先级降序排序
-	sort.Slice(policies, func(i, j int) bool {
-		return policies[i].Priority > policies[j].Priority
-	})
+	sortPolicies(policies)
 
 	return policies
 }
@@ -287,11 +309,14 @@ func (e *Engine) ClearPolicies() {
 	defer e.mu.Unlock()
 
 	e.policies = make(map[string]Policy)
+	e.invalidate()
 }
 
 // Evaluate 评估访问请求
 //
-// 按优先级从高到低依次评估策略，第一个匹配的策略决定结果
+// 按优先级从高到低依次评估策略，第一个匹配的策略决定结果。
+// 评估前通过目标索引排除资源类型、操作或角色不可能匹配的策略；
+// 启用 WithDecisionCache 时先查询决策缓存
 //
 // 参数：
 //   - ctx: 上下文
@@ -317,16 +342,35 @@ func (e *Engine) ClearPolicies() {
 //	if result.IsAllowed() {
 //	    // 允许访问
 //	}
+//
+// 注意事项：
+//   - 被索引排除的策略不会被评估，它们的评估错误也不会出现在 Result.Errors 中
 func (e *Engine) Evaluate(ctx context.Context, req Request) Result {
-	policies := e.ListPolicies()
+	idx := e.currentIndex()
+
+	var key [32]byte
+	cacheable := false
+	if e.cache != nil {
+		if key, cacheable = decisionKey(req); cacheable {
+			if result, ok := e.cache.get(key, idx.generation); ok {
+				return result
+			}
+		}
+	}
 
+	result := e.evaluateCandidates(ctx, req, idx)
+	if cacheable && len(result.Errors) == 0 {
+		e.cache.set(key, idx.generation, result)
+	}
+	return result
+}
+
+// evaluateCandidates 按评估顺序评估索引给出的候选策略
+func (e *Engine) evaluateCandidates(ctx context.Context, req Request, idx *policyIndex) Result {
 	var errors []error
 
-	for _, policy := range policies {
-		// 跳过未启用的策略
-		if !policy.Enabled {
-			continue
-		}
+	for _, i := range idx.candidates(req) {
+		policy := idx.policies[i]
 
 		// 评估策略
 		matched, err := policy.Match(ctx, req)
@@ -357,7 +401,7 @@ func (e *Engine) Evaluate(ctx context.Context, req Request) Result {
 
 // EvaluateWithReason 评估访问请求并返回详细原因
 //
-// 返回所有策略的评估结果，用于调试
+// 返回所有策略的评估结果，用于调试。需要规则树的评估过程和属性值时使用 Explain
 func (e *Engine) EvaluateWithReason(ctx context.Context, req Request) (Result, map[string]bool) {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -365,14 +409,12 @@ func (e *Engine) EvaluateWithReason(ctx context.Context, req Request) (Result, m
 	policyResults := make(map[string]bool)
 	var errors []error
 
-	// 按优先级排序
+	// 按评估顺序排序
 	policies := make([]Policy, 0, len(e.policies))
 	for _, policy := range e.policies {
 		policies = append(policies, policy)
 	}
-	sort.Slice(policies, func(i, j int) bool {
-		return policies[i].Priority > policies[j].Priority
-	})
+	sortPolicies(policies)
 
 	for _, policy := range policies {
 		if !policy.Enabled {
@@ -440,6 +482,32 @@ func (e *Engine) GetStats() EngineStats {
 	return stats
 }
 
+// currentIndex 返回当前策略版本的索引，需要时重建
+func (e *Engine) currentIndex() *policyIndex {
+	e.mu.RLock()
+	idx := e.index
+	e.mu.RUnlock()
+	if idx != nil {
+		return idx
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.index == nil {
+		e.index = buildPolicyIndex(e.policies, e.generation)
+	}
+	return e.index
+}
+
+// invalidate 策略变更后使索引和决策缓存失效，调用方必须持有写锁
+func (e *Engine) invalidate() {
+	e.generation++
+	e.index = nil
+	if e.cache != nil {
+		e.cache.purge()
+	}
+}
+
 // EngineStats 引擎统计信息
 type EngineStats struct {
 	TotalPolicies   int `json:"total_policies"`
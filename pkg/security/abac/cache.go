@@ -0,0 +1,155 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件实现了决策缓存：以请求属性为键缓存 Evaluate 的结果，策略变更时整体失效。
+//
+// 使用示例：
+//
+//	engine := abac.NewEngine(
+//	    abac.WithDecisionCache(10000, time.Minute),
+//	)
+
+package abac
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// WithDecisionCache 启用决策缓存
+//
+// 参数：
+//   - size: 最多缓存的决策数，<= 0 时不启用缓存
+//   - ttl: 决策的有效期，<= 0 表示只在策略变更时失效
+//
+// 注意事项：
+//   - 缓存键是请求的 JSON 序列化结果，属性值必须能完整地序列化为 JSON；
+//     无法序列化的请求不使用缓存
+//   - 只有当规则的结果完全由请求决定时才能启用缓存：依赖外部状态或 ctx 的 RuleFunc 会读到旧结果
+//   - Environment.Time 是缓存键的一部分，按秒变化的时间戳会降低命中率，
+//     基于时间的策略建议使用 env.hour 等粗粒度属性
+//   - 评估出错的结果不缓存
+func WithDecisionCache(size int, ttl time.Duration) EngineOption {
+	return func(e *Engine) {
+		if size > 0 {
+			e.cache = newDecisionCache(size, ttl)
+		}
+	}
+}
+
+// cacheEntry 缓存的决策
+type cacheEntry struct {
+	key        [sha256.Size]byte
+	generation uint64 // 计算结果时的策略版本
+	result     Result
+	expiresAt  time.Time
+}
+
+// decisionCache 带过期时间的 LRU 决策缓存
+//
+// 设计原理：
+// 1. 哈希表 + 双向链表，容量满时淘汰最久未访问的决策
+// 2. 每个决策记录计算时的策略版本，读取时版本不一致视为未命中，避免并发评估写入旧版本的结果
+// 3. 过期检查是惰性的，不启动后台清理 goroutine
+type decisionCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[[sha256.Size]byte]*list.Element
+	now   func() time.Time
+}
+
+func newDecisionCache(size int, ttl time.Duration) *decisionCache {
+	return &decisionCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[[sha256.Size]byte]*list.Element, size),
+		now:   time.Now,
+	}
+}
+
+// decisionKey 计算请求的缓存键，请求无法序列化时返回 false
+func decisionKey(req Request) ([sha256.Size]byte, bool) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256(data), true
+}
+
+// get 返回指定策略版本下未过期的决策
+func (c *decisionCache) get(key [sha256.Size]byte, generation uint64) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return Result{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if entry.generation != generation || (!entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt)) {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		return Result{}, false
+	}
+	c.ll.MoveToFront(elem)
+
+	// 返回副本，调用方修改 MatchedPolicy 不影响缓存
+	result := entry.result
+	if result.MatchedPolicy != nil {
+		policy := *result.MatchedPolicy
+		result.MatchedPolicy = &policy
+	}
+	return result, true
+}
+
+// set 写入决策，容量满时淘汰最久未访问的决策
+func (c *decisionCache) set(key [sha256.Size]byte, generation uint64, result Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = c.now().Add(c.ttl)
+	}
+	if result.MatchedPolicy != nil {
+		policy := *result.MatchedPolicy
+		result.MatchedPolicy = &policy
+	}
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.generation = generation
+		entry.result = result
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, generation: generation, result: result, expiresAt: expiresAt})
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// purge 清空缓存
+func (c *decisionCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[[sha256.Size]byte]*list.Element, c.size)
+}
+
+// len 返回缓存的决策数
+func (c *decisionCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
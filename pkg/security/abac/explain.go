@@ -0,0 +1,310 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件实现了决策解释：记录每个策略规则树的评估过程、解析到的属性值，
+// 以及优先级如何决定最终效果。
+//
+// 使用示例：
+//
+//	trace := engine.Explain(ctx, req)
+//	fmt.Println(trace.Explanation)
+//	for _, p := range trace.Policies {
+//	    fmt.Println(p.PolicyID, p.Status)
+//	}
+//
+//	// 也可以直接序列化为 JSON 返回给管理界面
+//	json.NewEncoder(w).Encode(trace)
+
+package abac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PolicyStatus 表示策略在一次决策中的状态
+type PolicyStatus string
+
+const (
+	// PolicyDecisive 决定最终结果的策略（优先级最高的匹配策略）
+	PolicyDecisive PolicyStatus = "decisive"
+	// PolicyShadowed 规则匹配，但被优先级更高的匹配策略覆盖
+	PolicyShadowed PolicyStatus = "shadowed"
+	// PolicyNotMatched 规则不匹配
+	PolicyNotMatched PolicyStatus = "not_matched"
+	// PolicyErrored 规则评估出错，评估时跳过该策略
+	PolicyErrored PolicyStatus = "error"
+	// PolicyDisabled 策略未启用
+	PolicyDisabled PolicyStatus = "disabled"
+	// PolicyFiltered 目标索引判断策略不可能匹配，与 Evaluate 一样不评估
+	PolicyFiltered PolicyStatus = "filtered"
+)
+
+// RuleTrace 规则树中一个节点的评估记录
+type RuleTrace struct {
+	Rule       string                 `json:"rule"`                 // 规则描述（Rule.String()）
+	Evaluated  bool                   `json:"evaluated"`            // 短路求值时未评估的节点为 false
+	Matched    bool                   `json:"matched"`              // 节点的评估结果
+	Attributes map[string]interface{} `json:"attributes,omitempty"` // 节点解析到的属性值
+	Missing    []string               `json:"missing,omitempty"`    // 请求中不存在的属性
+	Error      string                 `json:"error,omitempty"`
+	Children   []RuleTrace            `json:"children,omitempty"`
+}
+
+// PolicyTrace 单个策略的评估记录
+type PolicyTrace struct {
+	PolicyID string       `json:"policy_id"`
+	Name     string       `json:"name"`
+	Priority int          `json:"priority"`
+	Effect   Effect       `json:"effect"`
+	Status   PolicyStatus `json:"status"`
+	Rule     *RuleTrace   `json:"rule,omitempty"` // 未启用或被索引排除的策略为 nil
+}
+
+// DecisionTrace 一次访问决策的完整解释
+type DecisionTrace struct {
+	Result      Result        `json:"result"`
+	Policies    []PolicyTrace `json:"policies"`    // 按优先级从高到低排列
+	Explanation string        `json:"explanation"` // 可读的决策说明
+}
+
+// Explain 评估访问请求并返回完整的决策解释
+//
+// 设计原理：
+// 1. 按与 Evaluate 相同的优先级顺序评估启用的策略，决定结果后继续评估剩余策略以发现被覆盖的策略
+// 2. 与 Evaluate 使用同一个目标索引，被索引排除的策略标记为 PolicyFiltered，不评估也不产生错误
+// 3. 规则树中的 And/Or 与 Evaluate 一样短路求值，未评估的子节点标记为 Evaluated=false
+// 4. 记录比较、角色、所有者等叶子节点解析到的属性值和缺失的属性
+//
+// 注意事项：
+// - 返回的 Result（包括 Errors）与 Evaluate 的结果一致；Explain 比 Evaluate 慢，只用于调试和审计
+// - 自定义 RuleFunc/ConditionFunc 只记录结果，无法记录内部使用的属性
+func (e *Engine) Explain(ctx context.Context, req Request) DecisionTrace {
+	policies := e.ListPolicies()
+	trace := DecisionTrace{Policies: make([]PolicyTrace, 0, len(policies))}
+
+	idx := e.currentIndex()
+	candidates := make(map[string]bool)
+	for _, i := range idx.candidates(req) {
+		candidates[idx.policies[i].ID] = true
+	}
+
+	var decisive *Policy
+	var shadowed []PolicyTrace
+	var errors []error
+	for i := range policies {
+		policy := policies[i]
+		pt := PolicyTrace{
+			PolicyID: policy.ID,
+			Name:     policy.Name,
+			Priority: policy.Priority,
+			Effect:   policy.Effect,
+			Status:   PolicyDisabled,
+		}
+		if !policy.Enabled {
+			trace.Policies = append(trace.Policies, pt)
+			continue
+		}
+		if !candidates[policy.ID] {
+			pt.Status = PolicyFiltered
+			trace.Policies = append(trace.Policies, pt)
+			continue
+		}
+
+		rt := traceRule(ctx, policy.Rules, req)
+		pt.Rule = &rt
+		switch {
+		case rt.Error != "":
+			pt.Status = PolicyErrored
+			if decisive == nil {
+				errors = append(errors, fmt.Errorf("policy %s evaluation error: %s", policy.ID, rt.Error))
+			}
+		case !rt.Matched:
+			pt.Status = PolicyNotMatched
+		case decisive == nil:
+			pt.Status = PolicyDecisive
+			decisive = &policies[i]
+		default:
+			pt.Status = PolicyShadowed
+			shadowed = append(shadowed, pt)
+		}
+		trace.Policies = append(trace.Policies, pt)
+	}
+
+	if decisive != nil {
+		trace.Result = Result{
+			Allowed:       decisive.Effect == Allow,
+			Decision:      decisive.Effect,
+			MatchedPolicy: decisive,
+			Reason:        fmt.Sprintf("Matched policy: %s (%s)", decisive.Name, decisive.ID),
+			Errors:        errors,
+		}
+	} else {
+		trace.Result = Result{
+			Allowed:  e.defaultEffect == Allow,
+			Decision: e.defaultEffect,
+			Reason:   "No matching policy found",
+			Errors:   errors,
+		}
+	}
+	trace.Explanation = explainDecision(decisive, shadowed, e.defaultEffect, len(errors))
+	return trace
+}
+
+// explainDecision 生成可读的决策说明
+func explainDecision(decisive *Policy, shadowed []PolicyTrace, defaultEffect Effect, errorCount int) string {
+	var b strings.Builder
+	if decisive == nil {
+		fmt.Fprintf(&b, "No enabled policy matched; default effect %s applied.", defaultEffect)
+	} else {
+		fmt.Fprintf(&b, "Policy %s (priority %d) is the highest-priority matching policy; its effect %s decides the request.",
+			decisive.ID, decisive.Priority, decisive.Effect)
+		for _, p := range shadowed {
+			switch {
+			case p.Priority == decisive.Priority && p.Effect != decisive.Effect:
+				fmt.Fprintf(&b, " Warning: policy %s also matched with the same priority but effect %s; equal-priority policies are evaluated in ID order.",
+					p.PolicyID, p.Effect)
+			case p.Effect != decisive.Effect:
+				fmt.Fprintf(&b, " Policy %s (priority %d, %s) also matched but was overridden.", p.PolicyID, p.Priority, p.Effect)
+			}
+		}
+	}
+	if errorCount > 0 {
+		fmt.Fprintf(&b, " %d policies failed to evaluate and were skipped.", errorCount)
+	}
+	return b.String()
+}
+
+// traceRule 评估规则并记录评估过程
+//
+// Rule 和 Condition 的方法集相同，因此条件（包括 RuleFromCondition 包装的条件）也通过这里记录
+func traceRule(ctx context.Context, rule Rule, req Request) RuleTrace {
+	switch r := rule.(type) {
+	case *conditionRule:
+		return traceRule(ctx, r.condition, req)
+	case *andRule:
+		return traceCompound(ctx, r.String(), r.rules, req, false)
+	case *allOfCondition:
+		return traceCompound(ctx, r.String(), conditionsAsRules(r.conditions), req, false)
+	case *orRule:
+		return traceCompound(ctx, r.String(), r.rules, req, true)
+	case *anyOfCondition:
+		return traceCompound(ctx, r.String(), conditionsAsRules(r.conditions), req, true)
+	case *notRule:
+		return traceNot(ctx, r.String(), r.rule, req)
+	case *notCondition:
+		return traceNot(ctx, r.String(), r.condition, req)
+	}
+
+	rt := RuleTrace{Rule: rule.String(), Evaluated: true}
+	matched, err := rule.Evaluate(ctx, req)
+	rt.Matched = matched && err == nil
+	if err != nil {
+		rt.Error = err.Error()
+	}
+	for _, path := range ruleAttributes(rule) {
+		if value, ok := traceAttribute(req, path); ok {
+			if rt.Attributes == nil {
+				rt.Attributes = make(map[string]interface{})
+			}
+			rt.Attributes[path] = value
+		} else {
+			rt.Missing = append(rt.Missing, path)
+		}
+	}
+	return rt
+}
+
+// traceCompound 记录 And/Or 节点，短路后剩余的子节点标记为未评估
+func traceCompound(ctx context.Context, desc string, rules []Rule, req Request, matchAny bool) RuleTrace {
+	rt := RuleTrace{Rule: desc, Evaluated: true, Matched: !matchAny}
+	decided := false
+	for _, child := range rules {
+		if decided {
+			rt.Children = append(rt.Children, RuleTrace{Rule: child.String()})
+			continue
+		}
+		ct := traceRule(ctx, child, req)
+		rt.Children = append(rt.Children, ct)
+		switch {
+		case ct.Error != "":
+			rt.Matched = false
+			rt.Error = ct.Error
+			decided = true
+		case ct.Matched == matchAny:
+			rt.Matched = matchAny
+			decided = true
+		}
+	}
+	return rt
+}
+
+// traceNot 记录 Not 节点
+func traceNot(ctx context.Context, desc string, rule Rule, req Request) RuleTrace {
+	ct := traceRule(ctx, rule, req)
+	rt := RuleTrace{Rule: desc, Evaluated: true, Matched: !ct.Matched && ct.Error == "", Error: ct.Error}
+	rt.Children = []RuleTrace{ct}
+	return rt
+}
+
+func conditionsAsRules(conditions []Condition) []Rule {
+	rules := make([]Rule, len(conditions))
+	for i, c := range conditions {
+		rules[i] = c
+	}
+	return rules
+}
+
+// ruleAttributes 返回叶子规则使用的属性路径
+func ruleAttributes(rule Rule) []string {
+	switch r := rule.(type) {
+	case *comparisonRule:
+		var paths []string
+		for _, o := range []operand{r.left, r.right} {
+			if o.path != "" {
+				paths = append(paths, o.path)
+			}
+		}
+		return paths
+	case *subjectRoleRule, *subjectAnyRoleRule:
+		return []string{"subject.roles"}
+	case *subjectOwnerRule:
+		return []string{"subject.id", "resource.owner"}
+	case *resourceTypeRule:
+		return []string{"resource.type"}
+	case *actionIsRule, *actionInRule:
+		return []string{"action.name"}
+	case *attributeEqualsRule:
+		return []string{strings.ToLower(r.typeName) + "." + r.key}
+	case *equalsCondition:
+		return []string{r.attribute}
+	case *greaterThanCondition:
+		return []string{r.attribute}
+	case *lessThanCondition:
+		return []string{r.attribute}
+	case *inCondition:
+		return []string{r.attribute}
+	case *containsCondition:
+		return []string{r.attribute}
+	case *matchesCondition:
+		return []string{r.attribute}
+	case *startsWithCondition:
+		return []string{r.attribute}
+	case *endsWithCondition:
+		return []string{r.attribute}
+	case *existsCondition:
+		return []string{r.attribute}
+	case *emptyCondition:
+		return []string{r.attribute}
+	}
+	return nil
+}
+
+// traceAttribute 解析属性值用于记录，subject.roles 直接读取角色列表
+func traceAttribute(req Request, path string) (interface{}, bool) {
+	if path == "subject.roles" {
+		return req.Subject.Roles, true
+	}
+	value, err := resolveRequestAttribute(req, path)
+	return value, err == nil
+}
@@ -0,0 +1,124 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件包含决策解释的单元测试
+
+package abac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEngine_Explain 测试决策解释
+func TestEngine_Explain(t *testing.T) {
+	engine := NewEngine()
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "deny-contractors", Name: "Deny contractors", Priority: 200, Effect: Deny, Enabled: true,
+		Rules: MustCompileExpression(`has_role("contractor") && resource.sensitivity == "secret"`),
+	}))
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "deny-secret", Name: "Deny secret", Priority: 150, Effect: Deny, Enabled: true,
+		Rules: MustCompileExpression(`resource.sensitivity == "secret" && subject.department == "engineering"`),
+	}))
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "same-department", Name: "Same department", Priority: 100, Effect: Allow, Enabled: true,
+		Rules: MustCompileExpression(`subject.department == resource.department && env.hour < 18`),
+	}))
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "engineers", Name: "Engineers", Priority: 50, Effect: Deny, Enabled: true,
+		Rules: RuleFromCondition(Eq("subject.department", "engineering")),
+	}))
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "disabled", Name: "Disabled", Priority: 10, Effect: Allow, Enabled: false, Rules: AlwaysAllow(),
+	}))
+
+	req := Request{
+		Subject:     Subject{ID: "alice", Roles: []string{"employee"}, Department: "engineering"},
+		Resource:    Resource{Type: "document", Attributes: map[string]interface{}{"department": "engineering"}},
+		Environment: Environment{Attributes: map[string]interface{}{"hour": 9}},
+	}
+	trace := engine.Explain(context.Background(), req)
+
+	// 结果与 Evaluate 一致
+	result := engine.Evaluate(context.Background(), req)
+	assert.Equal(t, result.Decision, trace.Result.Decision)
+	assert.Equal(t, result.MatchedPolicy.ID, trace.Result.MatchedPolicy.ID)
+
+	require.Len(t, trace.Policies, 5)
+	statuses := map[string]PolicyStatus{}
+	for _, p := range trace.Policies {
+		statuses[p.PolicyID] = p.Status
+	}
+	assert.Equal(t, map[string]PolicyStatus{
+		"deny-contractors": PolicyFiltered,
+		"deny-secret":      PolicyNotMatched,
+		"same-department":  PolicyDecisive,
+		"engineers":        PolicyShadowed,
+		"disabled":         PolicyDisabled,
+	}, statuses)
+
+	// 主体没有 contractor 角色，与 Evaluate 一样被目标索引排除
+	assert.Nil(t, trace.Policies[0].Rule)
+
+	// And 短路：第一个条件不匹配，第二个条件未评估
+	denied := trace.Policies[1].Rule
+	require.Len(t, denied.Children, 2)
+	assert.True(t, denied.Children[0].Evaluated)
+	assert.False(t, denied.Children[0].Matched)
+	assert.Equal(t, []string{"resource.sensitivity"}, denied.Children[0].Missing)
+	assert.False(t, denied.Children[1].Evaluated)
+
+	// 比较两侧的属性值都被记录
+	allowed := trace.Policies[2].Rule
+	assert.True(t, allowed.Matched)
+	assert.Equal(t, map[string]interface{}{
+		"subject.department":  "engineering",
+		"resource.department": "engineering",
+	}, allowed.Children[0].Attributes)
+	assert.Equal(t, 9, allowed.Children[1].Attributes["environment.hour"])
+
+	assert.Contains(t, trace.Explanation, "Policy same-department (priority 100)")
+	assert.Contains(t, trace.Explanation, "Policy engineers (priority 50, Deny) also matched but was overridden")
+
+	_, err := json.Marshal(trace)
+	assert.NoError(t, err)
+}
+
+// TestEngine_Explain_NoMatch 测试没有匹配策略和评估错误
+func TestEngine_Explain_NoMatch(t *testing.T) {
+	engine := NewEngine()
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "broken", Name: "Broken", Priority: 10, Effect: Allow, Enabled: true,
+		Rules: Not(RuleFunc(func(ctx context.Context, req Request) (bool, error) {
+			return false, errors.New("attribute source unavailable")
+		})),
+	}))
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "missing", Name: "Missing", Priority: 5, Effect: Allow, Enabled: true,
+		Rules: MustCompileExpression(`subject.clearance > 3`),
+	}))
+
+	trace := engine.Explain(context.Background(), Request{})
+	assert.Equal(t, Deny, trace.Result.Decision)
+	assert.Nil(t, trace.Result.MatchedPolicy)
+	assert.Len(t, trace.Result.Errors, 1)
+
+	assert.Equal(t, PolicyErrored, trace.Policies[0].Status)
+	assert.Equal(t, "attribute source unavailable", trace.Policies[0].Rule.Error)
+	assert.False(t, trace.Policies[0].Rule.Matched)
+	assert.Equal(t, PolicyNotMatched, trace.Policies[1].Status)
+	assert.Equal(t, []string{"subject.clearance"}, trace.Policies[1].Rule.Missing)
+
+	assert.Contains(t, trace.Explanation, "default effect Deny applied")
+	assert.Contains(t, trace.Explanation, "1 policies failed to evaluate")
+
+	// 评估错误序列化为错误信息
+	data, err := json.Marshal(trace)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"errors":["policy broken evaluation error: attribute source unavailable"]`)
+}
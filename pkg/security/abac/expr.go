@@ -0,0 +1,611 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件实现了策略表达式语言，把文本表达式编译为 Rule 树。
+//
+// 语法：
+//
+//	expr       := or
+//	or         := and ( "||" and )*
+//	and        := unary ( "&&" unary )*
+//	unary      := "!" unary | "(" expr ")" | call | comparison
+//	comparison := operand [ op operand ]
+//	op         := == | != | < | <= | > | >= | in | not in | contains | matches | startsWith | endsWith
+//	operand    := path | string | number | true | false | null | "[" operand, ... "]"
+//	call       := has_role("r") | has_any_role("r1", "r2") | is_owner() | exists(path)
+//
+// 属性路径以 subject、resource、action、environment（可简写为 env）开头，
+// 解析方式与 Eq、Gt 等条件相同。单独的路径等价于 path == true。
+//
+// 使用示例：
+//
+//	rule, err := abac.CompileExpression(
+//	    `subject.department == resource.department && env.hour < 18`,
+//	)
+//
+// 比较语义：
+//   - 属性不存在时比较结果为 false，!= 和 not in 取反后为 true（与 Ne、NotIn 一致）
+//   - 数值按 float64 比较，其他类型使用 CompareValues、GreaterThan、LessThan
+//   - matches 的右侧必须是字符串字面量，正则表达式在编译时检查
+
+package abac
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ExpressionError 表示表达式解析或编译错误
+type ExpressionError struct {
+	Expr string // 原始表达式
+	Pos  int    // 出错位置（字节偏移）
+	Msg  string // 错误描述
+}
+
+// Error 实现 error 接口
+func (e *ExpressionError) Error() string {
+	return fmt.Sprintf("abac expression %q: position %d: %s", e.Expr, e.Pos, e.Msg)
+}
+
+// CompileExpression 把表达式编译为 Rule 树
+//
+// && 编译为 And，|| 编译为 Or，! 编译为 Not；has_role 等函数编译为对应的预定义规则，
+// 比较编译为比较规则。编译结果可以直接作为 Policy.Rules 使用。
+//
+// 参数：
+//   - expr: 表达式文本
+//
+// 返回：
+//   - Rule: 编译后的规则
+//   - error: 语法错误时返回 *ExpressionError
+func CompileExpression(expr string) (Rule, error) {
+	p := &exprParser{src: expr}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	rule, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+	return rule, nil
+}
+
+// MustCompileExpression 编译表达式，出错时 panic
+//
+// 用于包级变量等表达式在编码时已确定的场景
+func MustCompileExpression(expr string) Rule {
+	rule, err := CompileExpression(expr)
+	if err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+// ===== 词法分析 =====
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// operators 按长度降序排列，保证最长匹配
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+type exprParser struct {
+	src    string
+	tokens []token
+	next   int
+}
+
+func (p *exprParser) errorf(tok token, format string, args ...interface{}) error {
+	return &ExpressionError{Expr: p.src, Pos: tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *exprParser) lex() error {
+	src := p.src
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && rune(src[end]) != c {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return &ExpressionError{Expr: src, Pos: i, Msg: "unterminated string"}
+			}
+			value, err := unquote(src[i : end+1])
+			if err != nil {
+				return &ExpressionError{Expr: src, Pos: i, Msg: "invalid string literal"}
+			}
+			p.tokens = append(p.tokens, token{kind: tokString, text: value, pos: i})
+			i = end + 1
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(rune(src[i+1]))):
+			end := i + 1
+			for end < len(src) && (isDigit(rune(src[end])) || src[end] == '.') {
+				end++
+			}
+			p.tokens = append(p.tokens, token{kind: tokNumber, text: src[i:end], pos: i})
+			i = end
+		case isIdentStart(c):
+			end := i + 1
+			for end < len(src) && (isIdentStart(rune(src[end])) || isDigit(rune(src[end])) || src[end] == '.') {
+				end++
+			}
+			p.tokens = append(p.tokens, token{kind: tokIdent, text: src[i:end], pos: i})
+			i = end
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					p.tokens = append(p.tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return &ExpressionError{Expr: src, Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokEOF, pos: len(src)})
+	return nil
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentStart 标识符只允许 ASCII 字母和下划线
+func isIdentStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// unquote 解析单引号或双引号字符串，支持 Go 的转义序列
+func unquote(s string) (string, error) {
+	if s[0] == '\'' {
+		s = `"` + strings.ReplaceAll(strings.ReplaceAll(s[1:len(s)-1], `\'`, `'`), `"`, `\"`) + `"`
+	}
+	return strconv.Unquote(s)
+}
+
+// ===== 语法分析 =====
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *exprParser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+// isOp 检查当前记号是否是指定运算符
+func (p *exprParser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.text == op
+}
+
+func (p *exprParser) expectOp(op string) error {
+	if tok := p.advance(); tok.kind != tokOp || tok.text != op {
+		return p.errorf(tok, "expected %q, got %s", op, tok)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (Rule, error) {
+	rule, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	rules := []Rule{rule}
+	for p.isOp("||") {
+		p.advance()
+		rule, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 1 {
+		return rules[0], nil
+	}
+	return Or(rules...), nil
+}
+
+func (p *exprParser) parseAnd() (Rule, error) {
+	rule, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	rules := []Rule{rule}
+	for p.isOp("&&") {
+		p.advance()
+		rule, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 1 {
+		return rules[0], nil
+	}
+	return And(rules...), nil
+}
+
+func (p *exprParser) parseUnary() (Rule, error) {
+	switch {
+	case p.isOp("!"):
+		p.advance()
+		rule, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(rule), nil
+	case p.isOp("("):
+		p.advance()
+		rule, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectOp(")"); err != nil {
+			return nil, err
+		}
+		return rule, nil
+	}
+
+	tok := p.peek()
+	if tok.kind == tokIdent && p.tokens[p.next+1].kind == tokOp && p.tokens[p.next+1].text == "(" {
+		return p.parseCall()
+	}
+	return p.parseComparison()
+}
+
+// parseCall 解析内置函数调用
+func (p *exprParser) parseCall() (Rule, error) {
+	name := p.advance()
+	p.advance() // (
+
+	var args []operand
+	for !p.isOp(")") {
+		if len(args) > 0 {
+			if err := p.expectOp(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.advance() // )
+
+	strArgs := func() ([]string, error) {
+		values := make([]string, len(args))
+		for i, arg := range args {
+			s, ok := arg.literal.(string)
+			if arg.path != "" || !ok {
+				return nil, p.errorf(name, "%s expects string arguments", name.text)
+			}
+			values[i] = s
+		}
+		return values, nil
+	}
+
+	switch name.text {
+	case "has_role":
+		roles, err := strArgs()
+		if err != nil {
+			return nil, err
+		}
+		if len(roles) != 1 {
+			return nil, p.errorf(name, "has_role expects 1 argument")
+		}
+		return SubjectHasRole(roles[0]), nil
+	case "has_any_role":
+		roles, err := strArgs()
+		if err != nil {
+			return nil, err
+		}
+		if len(roles) == 0 {
+			return nil, p.errorf(name, "has_any_role expects at least 1 argument")
+		}
+		return SubjectHasAnyRole(roles...), nil
+	case "is_owner":
+		if len(args) != 0 {
+			return nil, p.errorf(name, "is_owner expects no arguments")
+		}
+		return SubjectIsOwner(), nil
+	case "exists":
+		if len(args) != 1 || args[0].path == "" {
+			return nil, p.errorf(name, "exists expects 1 attribute path")
+		}
+		return RuleFromCondition(Exists(args[0].path)), nil
+	default:
+		return nil, p.errorf(name, "unknown function %s", name.text)
+	}
+}
+
+// parseComparison 解析比较，没有运算符时要求操作数是属性路径或布尔字面量
+func (p *exprParser) parseComparison() (Rule, error) {
+	start := p.peek()
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	opTok := p.peek()
+	op, ok := p.comparisonOp()
+	if !ok {
+		switch {
+		case left.path != "":
+			return &comparisonRule{op: "==", left: left, right: operand{literal: true}}, nil
+		case left.literal == true:
+			return AlwaysAllow(), nil
+		case left.literal == false:
+			return AlwaysDeny(), nil
+		}
+		return nil, p.errorf(start, "expected a condition, got %s", start)
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &comparisonRule{op: op, left: left, right: right}
+	if op == "matches" {
+		pattern, ok := right.literal.(string)
+		if right.path != "" || !ok {
+			return nil, p.errorf(opTok, "matches expects a string pattern")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.errorf(opTok, "invalid pattern: %v", err)
+		}
+		rule.re = re
+	}
+	return rule, nil
+}
+
+// comparisonOp 读取比较运算符，返回规范化后的名称
+func (p *exprParser) comparisonOp() (string, bool) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokOp:
+		switch tok.text {
+		case "==", "!=", "<", "<=", ">", ">=":
+			p.advance()
+			return tok.text, true
+		}
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "in", "contains", "matches":
+			p.advance()
+			return strings.ToLower(tok.text), true
+		case "startswith":
+			p.advance()
+			return "startsWith", true
+		case "endswith":
+			p.advance()
+			return "endsWith", true
+		case "not":
+			if next := p.tokens[p.next+1]; next.kind == tokIdent && strings.EqualFold(next.text, "in") {
+				p.advance()
+				p.advance()
+				return "not in", true
+			}
+		}
+	}
+	return "", false
+}
+
+// parseOperand 解析操作数：属性路径或字面量
+func (p *exprParser) parseOperand() (operand, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokString:
+		return operand{literal: tok.text}, nil
+	case tokNumber:
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return operand{literal: n}, nil
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return operand{}, p.errorf(tok, "invalid number %s", tok.text)
+		}
+		return operand{literal: f}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return operand{literal: true}, nil
+		case "false":
+			return operand{literal: false}, nil
+		case "null":
+			return operand{literal: nil}, nil
+		}
+		path, err := normalizePath(tok.text)
+		if err != nil {
+			return operand{}, p.errorf(tok, "%v", err)
+		}
+		return operand{path: path}, nil
+	case tokOp:
+		if tok.text == "[" {
+			var values []interface{}
+			for !p.isOp("]") {
+				if len(values) > 0 {
+					if err := p.expectOp(","); err != nil {
+						return operand{}, err
+					}
+				}
+				item, err := p.parseOperand()
+				if err != nil {
+					return operand{}, err
+				}
+				if item.path != "" {
+					return operand{}, p.errorf(tok, "list items must be literals")
+				}
+				values = append(values, item.literal)
+			}
+			p.advance() // ]
+			return operand{literal: values}, nil
+		}
+	}
+	return operand{}, p.errorf(tok, "unexpected %s", tok)
+}
+
+// normalizePath 检查属性路径并把 env 展开为 environment
+func normalizePath(path string) (string, error) {
+	source, key, ok := strings.Cut(path, ".")
+	if !ok || key == "" || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid attribute path %s", path)
+	}
+	switch source {
+	case "subject", "resource", "action", "environment":
+	case "env":
+		source = "environment"
+	default:
+		return "", fmt.Errorf("unknown attribute source %s", source)
+	}
+	return source + "." + key, nil
+}
+
+// ===== 比较规则 =====
+
+// operand 比较的操作数，path 非空时从请求中解析，否则使用 literal
+type operand struct {
+	path    string
+	literal interface{}
+}
+
+// resolve 解析操作数的值，属性不存在时返回 false
+func (o operand) resolve(req Request) (interface{}, bool) {
+	if o.path == "" {
+		return o.literal, true
+	}
+	value, err := resolveRequestAttribute(req, o.path)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+func (o operand) String() string {
+	if o.path != "" {
+		return o.path
+	}
+	switch v := o.literal.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = operand{literal: item}.String()
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// comparisonRule 比较两个操作数的规则
+type comparisonRule struct {
+	op    string
+	left  operand
+	right operand
+	re    *regexp.Regexp // op 为 matches 时预编译的正则表达式
+}
+
+func (r *comparisonRule) Evaluate(ctx context.Context, req Request) (bool, error) {
+	switch r.op {
+	case "!=":
+		match, err := r.compare(req, "==")
+		return !match, err
+	case "not in":
+		match, err := r.compare(req, "in")
+		return !match, err
+	}
+	return r.compare(req, r.op)
+}
+
+// compare 执行肯定形式的比较，任一属性不存在时返回 false
+func (r *comparisonRule) compare(req Request, op string) (bool, error) {
+	left, ok := r.left.resolve(req)
+	if !ok {
+		return false, nil
+	}
+	right, ok := r.right.resolve(req)
+	if !ok {
+		return false, nil
+	}
+
+	switch op {
+	case "==":
+		return CompareValues(left, right), nil
+	case "<":
+		return LessThan(left, right)
+	case ">":
+		return GreaterThan(left, right)
+	case "<=":
+		less, err := LessThan(left, right)
+		return less || CompareValues(left, right), err
+	case ">=":
+		greater, err := GreaterThan(left, right)
+		return greater || CompareValues(left, right), err
+	case "in":
+		return ContainsValue(right, left), nil
+	case "contains":
+		if str, ok := left.(string); ok {
+			return strings.Contains(str, fmt.Sprintf("%v", right)), nil
+		}
+		return ContainsValue(left, right), nil
+	case "matches":
+		return r.re.MatchString(fmt.Sprintf("%v", left)), nil
+	case "startsWith":
+		return strings.HasPrefix(fmt.Sprintf("%v", left), fmt.Sprintf("%v", right)), nil
+	case "endsWith":
+		return strings.HasSuffix(fmt.Sprintf("%v", left), fmt.Sprintf("%v", right)), nil
+	}
+	return false, fmt.Errorf("unknown operator %s", op)
+}
+
+func (r *comparisonRule) String() string {
+	return fmt.Sprintf("%s %s %s", r.left, r.op, r.right)
+}
@@ -0,0 +1,105 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件包含策略表达式语言的单元测试
+
+package abac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompileExpression 测试表达式求值
+func TestCompileExpression(t *testing.T) {
+	req := Request{
+		Subject: Subject{
+			ID:         "alice",
+			Roles:      []string{"manager"},
+			Department: "engineering",
+			Attributes: map[string]interface{}{"clearance": 3, "active": true, "email": "alice@example.com"},
+		},
+		Resource: Resource{
+			Type:       "document",
+			Owner:      "alice",
+			Attributes: map[string]interface{}{"department": "engineering", "tags": []string{"internal", "draft"}},
+		},
+		Action:      Action{Name: "edit"},
+		Environment: Environment{Attributes: map[string]interface{}{"hour": 10}},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`subject.department == resource.department && env.hour < 18`, true},
+		{`subject.department == resource.department && env.hour >= 18`, false},
+		{`subject.department != "sales"`, true},
+		{`subject.clearance >= 3 && subject.clearance <= 3.0`, true},
+		{`subject.clearance > 3 || has_role("manager")`, true},
+		{`action.name in ["read", "edit"]`, true},
+		{`action.name not in ['read', 'edit']`, false},
+		{`resource.tags contains "draft"`, true},
+		{`subject.email matches "@example\\.com$"`, true},
+		{`subject.email startsWith "alice" && subject.email endsWith ".com"`, true},
+		{`!(resource.type == "folder") && is_owner()`, true},
+		{`has_any_role("admin", "auditor")`, false},
+		{`subject.active`, true},
+		{`exists(subject.missing)`, false},
+		// 属性不存在时比较为 false，取反的比较为 true
+		{`subject.missing == null`, false},
+		{`subject.missing != "x"`, true},
+		{`true`, true},
+		{`false || (true && !false)`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			rule, err := CompileExpression(tt.expr)
+			require.NoError(t, err)
+			got, err := rule.Evaluate(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestCompileExpression_RuleTree 测试编译结果是 Rule 树
+func TestCompileExpression_RuleTree(t *testing.T) {
+	rule, err := CompileExpression(`has_role("admin") || (env.hour < 18 && !is_owner())`)
+	require.NoError(t, err)
+	assert.Equal(t, `OR(SubjectHasRole(admin), AND(environment.hour < 18, NOT(SubjectIsOwner())))`, rule.String())
+}
+
+// TestCompileExpression_Errors 测试语法错误
+func TestCompileExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{``, 0},
+		{`subject.department ==`, 21},
+		{`subject.department == "eng`, 22},
+		{`user.department == "eng"`, 0},
+		{`subject == "eng"`, 0},
+		{`has_role(subject.id)`, 0},
+		{`unknown("x")`, 0},
+		{`subject.email matches "("`, 14},
+		{`(subject.active`, 15},
+		{`subject.active subject.active`, 15},
+		{`subject.age # 3`, 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := CompileExpression(tt.expr)
+			var exprErr *ExpressionError
+			require.ErrorAs(t, err, &exprErr)
+			assert.Equal(t, tt.pos, exprErr.Pos)
+		})
+	}
+
+	assert.Panics(t, func() { MustCompileExpression(`subject.`) })
+}
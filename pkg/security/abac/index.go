@@ -0,0 +1,362 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件实现了策略目标索引：从规则树中提取策略对资源类型、操作和主体角色的约束，
+// 评估前先按请求剪枝候选策略，避免对每个请求遍历全部策略。
+//
+// 目标提取规则：
+//   - ResourceTypeIs、resource.type == "x"、resource.type in [...] 约束资源类型
+//   - ActionIs、ActionIn、action.name == "x"、action.name in [...] 约束操作
+//   - SubjectHasRole、SubjectHasAnyRole、has_role、has_any_role 约束主体角色
+//   - And 取子规则约束的交集，Or 在所有子规则都有约束时取并集，Not 和其他规则不产生约束
+//
+// 提取是保守的：候选集合总是包含所有可能匹配的策略，规则本身仍然会被完整评估。
+
+package abac
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// target 策略在一个维度上的约束，nil 表示不约束
+type target map[string]struct{}
+
+// policyTarget 策略在资源类型、操作、主体角色三个维度上的约束
+type policyTarget struct {
+	resourceTypes target
+	actions       target
+	roles         target // 主体至少拥有其中一个角色
+}
+
+// policyIndex 启用策略的不可变索引，策略变更后整体重建
+type policyIndex struct {
+	generation uint64
+	policies   []Policy // 按评估顺序排列的启用策略
+	targets    []policyTarget
+
+	byType     map[string][]int // 值为 policies 下标，升序
+	anyType    []int
+	byAction   map[string][]int
+	anyAction  []int
+	byRole     map[string][]int
+	anyRole    []int
+	allIndices []int
+}
+
+// sortPolicies 按评估顺序排序：优先级从高到低，优先级相同时按 ID 排序保证结果确定
+func sortPolicies(policies []Policy) {
+	sort.Slice(policies, func(i, j int) bool {
+		if policies[i].Priority != policies[j].Priority {
+			return policies[i].Priority > policies[j].Priority
+		}
+		return policies[i].ID < policies[j].ID
+	})
+}
+
+// buildPolicyIndex 为启用的策略建立索引
+func buildPolicyIndex(policies map[string]Policy, generation uint64) *policyIndex {
+	idx := &policyIndex{
+		generation: generation,
+		byType:     make(map[string][]int),
+		byAction:   make(map[string][]int),
+		byRole:     make(map[string][]int),
+	}
+	for _, policy := range policies {
+		if policy.Enabled {
+			idx.policies = append(idx.policies, policy)
+		}
+	}
+	sortPolicies(idx.policies)
+
+	idx.targets = make([]policyTarget, len(idx.policies))
+	idx.allIndices = make([]int, len(idx.policies))
+	for i, policy := range idx.policies {
+		t := extractTarget(policy.Rules)
+		idx.targets[i] = t
+		idx.allIndices[i] = i
+		idx.anyType = addPosting(idx.byType, idx.anyType, t.resourceTypes, i)
+		idx.anyAction = addPosting(idx.byAction, idx.anyAction, t.actions, i)
+		idx.anyRole = addPosting(idx.byRole, idx.anyRole, t.roles, i)
+	}
+	return idx
+}
+
+// addPosting 把策略下标加入约束值对应的列表，不约束时加入 any 列表
+func addPosting(postings map[string][]int, anyList []int, t target, i int) []int {
+	if t == nil {
+		return append(anyList, i)
+	}
+	for value := range t {
+		postings[value] = append(postings[value], i)
+	}
+	return anyList
+}
+
+// candidates 返回可能匹配请求的策略下标，按评估顺序排列
+//
+// 在资源类型、操作、角色三个维度中选择候选最少的一个，再用策略目标过滤其他维度
+func (idx *policyIndex) candidates(req Request) []int {
+	best := idx.allIndices
+	consider := func(list []int) {
+		if len(list) < len(best) {
+			best = list
+		}
+	}
+
+	if key, ok := indexKey(req.Resource.Type); ok {
+		consider(mergePostings(idx.byType[key], idx.anyType))
+	}
+	if key, ok := indexKey(req.Action.Name); ok {
+		consider(mergePostings(idx.byAction[key], idx.anyAction))
+	}
+	roleLists := [][]int{idx.anyRole}
+	rolesIndexed := true
+	for _, role := range req.Subject.Roles {
+		key, ok := indexKey(role)
+		if !ok {
+			rolesIndexed = false
+			break
+		}
+		roleLists = append(roleLists, idx.byRole[key])
+	}
+	if rolesIndexed {
+		consider(mergePostings(roleLists...))
+	}
+
+	result := make([]int, 0, len(best))
+	for _, i := range best {
+		if idx.targets[i].matches(req) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// matches 检查请求是否满足策略目标
+func (t policyTarget) matches(req Request) bool {
+	if !t.resourceTypes.contains(req.Resource.Type) || !t.actions.contains(req.Action.Name) {
+		return false
+	}
+	if t.roles == nil {
+		return true
+	}
+	for _, role := range req.Subject.Roles {
+		if t.roles.contains(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains 检查值是否满足约束；无法建立索引的值（非 ASCII）总是视为满足
+func (t target) contains(value string) bool {
+	if t == nil {
+		return true
+	}
+	key, ok := indexKey(value)
+	if !ok {
+		return true
+	}
+	_, exists := t[key]
+	return exists
+}
+
+// indexKey 返回索引使用的键
+//
+// 规则使用 strings.EqualFold 比较，非 ASCII 字符的大小写折叠与 ToLower 不一致，
+// 因此只为 ASCII 值建立索引，其他值不参与剪枝
+func indexKey(value string) (string, bool) {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= utf8.RuneSelf {
+			return "", false
+		}
+	}
+	return strings.ToLower(value), true
+}
+
+// mergePostings 合并多个升序下标列表并去重
+func mergePostings(lists ...[]int) []int {
+	switch len(lists) {
+	case 1:
+		return lists[0]
+	case 2:
+		if len(lists[0]) == 0 {
+			return lists[1]
+		}
+		if len(lists[1]) == 0 {
+			return lists[0]
+		}
+	}
+
+	var merged []int
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	sort.Ints(merged)
+	out := merged[:0]
+	for i, v := range merged {
+		if i == 0 || v != merged[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ===== 目标提取 =====
+
+// extractTarget 从规则树提取策略目标
+func extractTarget(rule Rule) policyTarget {
+	switch r := rule.(type) {
+	case *conditionRule:
+		return extractTarget(r.condition)
+	case *andRule:
+		return intersectTargets(r.rules)
+	case *allOfCondition:
+		return intersectTargets(conditionsAsRules(r.conditions))
+	case *orRule:
+		return unionTargets(r.rules)
+	case *anyOfCondition:
+		return unionTargets(conditionsAsRules(r.conditions))
+	case *resourceTypeRule:
+		return policyTarget{resourceTypes: newTarget(r.resourceType)}
+	case *actionIsRule:
+		return policyTarget{actions: newTarget(r.action)}
+	case *actionInRule:
+		return policyTarget{actions: newTarget(r.actions...)}
+	case *subjectRoleRule:
+		return policyTarget{roles: newTarget(r.role)}
+	case *subjectAnyRoleRule:
+		return policyTarget{roles: newTarget(r.roles...)}
+	case *comparisonRule:
+		if r.right.path != "" {
+			return policyTarget{}
+		}
+		return attributeTarget(r.left.path, r.op, r.right.literal)
+	case *equalsCondition:
+		return attributeTarget(r.attribute, "==", r.expected)
+	case *inCondition:
+		return attributeTarget(r.attribute, "in", r.values)
+	}
+	return policyTarget{}
+}
+
+// attributeTarget 从 resource.type 或 action.name 与字符串字面量的比较中提取目标
+func attributeTarget(path, op string, literal interface{}) policyTarget {
+	var values []string
+	switch op {
+	case "==":
+		s, ok := literal.(string)
+		if !ok {
+			return policyTarget{}
+		}
+		values = []string{s}
+	case "in":
+		switch list := literal.(type) {
+		case []string:
+			values = list
+		case []interface{}:
+			for _, item := range list {
+				s, ok := item.(string)
+				if !ok {
+					return policyTarget{}
+				}
+				values = append(values, s)
+			}
+		default:
+			return policyTarget{}
+		}
+	default:
+		return policyTarget{}
+	}
+
+	switch path {
+	case "resource.type":
+		return policyTarget{resourceTypes: newTarget(values...)}
+	case "action.name":
+		return policyTarget{actions: newTarget(values...)}
+	}
+	return policyTarget{}
+}
+
+// newTarget 创建约束；任一值无法建立索引时返回 nil（不约束）
+func newTarget(values ...string) target {
+	t := make(target, len(values))
+	for _, value := range values {
+		key, ok := indexKey(value)
+		if !ok {
+			return nil
+		}
+		t[key] = struct{}{}
+	}
+	return t
+}
+
+// intersectTargets And 的所有子规则都必须匹配，每个维度取有约束的子规则的交集
+func intersectTargets(rules []Rule) policyTarget {
+	var result policyTarget
+	for _, rule := range rules {
+		t := extractTarget(rule)
+		result.resourceTypes = intersect(result.resourceTypes, t.resourceTypes)
+		result.actions = intersect(result.actions, t.actions)
+		result.roles = intersectRoles(result.roles, t.roles)
+	}
+	return result
+}
+
+// unionTargets Or 至少一个子规则匹配，只有所有子规则都约束某个维度时该维度才有约束
+func unionTargets(rules []Rule) policyTarget {
+	if len(rules) == 0 {
+		return policyTarget{}
+	}
+	result := extractTarget(rules[0])
+	for _, rule := range rules[1:] {
+		t := extractTarget(rule)
+		result.resourceTypes = union(result.resourceTypes, t.resourceTypes)
+		result.actions = union(result.actions, t.actions)
+		result.roles = union(result.roles, t.roles)
+	}
+	return result
+}
+
+func intersect(a, b target) target {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	out := make(target)
+	for v := range a {
+		if _, ok := b[v]; ok {
+			out[v] = struct{}{}
+		}
+	}
+	return out
+}
+
+// intersectRoles 两个角色约束同时成立时主体可能用不同角色分别满足，
+// 交集会漏掉这种情况，因此保留较小的一个
+func intersectRoles(a, b target) target {
+	if a == nil {
+		return b
+	}
+	if b == nil || len(a) <= len(b) {
+		return a
+	}
+	return b
+}
+
+func union(a, b target) target {
+	if a == nil || b == nil {
+		return nil
+	}
+	out := make(target, len(a)+len(b))
+	for v := range a {
+		out[v] = struct{}{}
+	}
+	for v := range b {
+		out[v] = struct{}{}
+	}
+	return out
+}
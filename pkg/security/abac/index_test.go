@@ -0,0 +1,255 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件包含策略目标索引和决策缓存的单元测试与基准测试
+
+package abac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evaluateLinear 不使用索引的评估路径：按优先级遍历所有启用的策略
+func evaluateLinear(ctx context.Context, e *Engine, req Request) Result {
+	for _, policy := range e.ListPolicies() {
+		if !policy.Enabled {
+			continue
+		}
+		if matched, err := policy.Match(ctx, req); err == nil && matched {
+			return Result{Allowed: policy.Effect == Allow, Decision: policy.Effect, MatchedPolicy: &policy}
+		}
+	}
+	return Result{Allowed: e.defaultEffect == Allow, Decision: e.defaultEffect}
+}
+
+// TestExtractTarget 测试从规则树提取策略目标
+func TestExtractTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		types []string
+		acts  []string
+		roles []string
+	}{
+		{
+			name:  "and of helpers",
+			rule:  And(ResourceTypeIs("Document"), ActionIn("read", "edit"), SubjectHasRole("manager")),
+			types: []string{"document"}, acts: []string{"read", "edit"}, roles: []string{"manager"},
+		},
+		{
+			name:  "expression",
+			rule:  MustCompileExpression(`resource.type in ["doc", "sheet"] && action.name == "read" && subject.level > 3`),
+			types: []string{"doc", "sheet"}, acts: []string{"read"},
+		},
+		{
+			name: "and intersects",
+			rule: And(ActionIn("read", "edit"), MustCompileExpression(`action.name in ["edit", "delete"]`)),
+			acts: []string{"edit"},
+		},
+		{
+			name:  "or unions when all branches constrain",
+			rule:  Or(And(ResourceTypeIs("doc"), ActionIs("read")), ResourceTypeIs("sheet")),
+			types: []string{"doc", "sheet"},
+		},
+		{
+			name: "not is unconstrained",
+			rule: Not(ResourceTypeIs("doc")),
+		},
+		{
+			name:  "conditions",
+			rule:  RuleFromCondition(AllOf(Eq("resource.type", "doc"), In("action.name", []string{"read"}))),
+			types: []string{"doc"}, acts: []string{"read"},
+		},
+		{
+			name: "non-ascii values are not indexed",
+			rule: ActionIs("ſign"),
+		},
+	}
+
+	set := func(values []string) target {
+		if values == nil {
+			return nil
+		}
+		return newTarget(values...)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractTarget(tt.rule)
+			assert.Equal(t, set(tt.types), got.resourceTypes)
+			assert.Equal(t, set(tt.acts), got.actions)
+			assert.Equal(t, set(tt.roles), got.roles)
+		})
+	}
+}
+
+// randomEngine 创建包含随机策略的引擎，模拟大量租户策略
+func randomEngine(tb testing.TB, rng *rand.Rand, n int, opts ...EngineOption) *Engine {
+	engine := NewEngine(opts...)
+	for i := 0; i < n; i++ {
+		tenant := fmt.Sprintf("tenant-%d", rng.Intn(n/10+1))
+		var rule Rule
+		switch i % 4 {
+		case 0:
+			rule = And(ResourceTypeIs(fmt.Sprintf("type-%d", rng.Intn(50))), ActionIs(fmt.Sprintf("action-%d", rng.Intn(5))),
+				SubjectAttributeEquals("tenant", tenant))
+		case 1:
+			rule = MustCompileExpression(fmt.Sprintf(`action.name in ["action-%d", "action-%d"] && subject.tenant == %q`,
+				rng.Intn(5), rng.Intn(5), tenant))
+		case 2:
+			rule = And(SubjectHasAnyRole(fmt.Sprintf("role-%d", rng.Intn(20)), "admin"), ResourceTypeIs(fmt.Sprintf("type-%d", rng.Intn(50))))
+		default:
+			rule = Or(ResourceTypeIs(fmt.Sprintf("TYPE-%d", rng.Intn(50))), Not(SubjectAttributeEquals("tenant", tenant)))
+		}
+		effect := Allow
+		if rng.Intn(3) == 0 {
+			effect = Deny
+		}
+		require.NoError(tb, engine.AddPolicy(Policy{
+			ID: fmt.Sprintf("policy-%d", i), Name: "generated", Priority: rng.Intn(10), Effect: effect, Rules: rule, Enabled: true,
+		}))
+	}
+	return engine
+}
+
+func randomRequest(rng *rand.Rand, n int) Request {
+	return Request{
+		Subject: Subject{
+			ID:         "user",
+			Roles:      []string{fmt.Sprintf("role-%d", rng.Intn(20))},
+			Attributes: map[string]interface{}{"tenant": fmt.Sprintf("tenant-%d", rng.Intn(n/10+1))},
+		},
+		Resource: Resource{Type: fmt.Sprintf("type-%d", rng.Intn(50))},
+		Action:   Action{Name: fmt.Sprintf("action-%d", rng.Intn(5))},
+	}
+}
+
+// TestEngine_IndexMatchesLinearEvaluation 测试索引不改变评估结果
+func TestEngine_IndexMatchesLinearEvaluation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	engine := randomEngine(t, rng, 500)
+
+	for i := 0; i < 2000; i++ {
+		req := randomRequest(rng, 500)
+		if i%10 == 0 {
+			req.Subject.Roles = append(req.Subject.Roles, "ADMIN")
+		}
+		want := evaluateLinear(context.Background(), engine, req)
+		got := engine.Evaluate(context.Background(), req)
+		require.Equal(t, want.Decision, got.Decision, "request %d", i)
+		require.Equal(t, matchedPolicyID(want), matchedPolicyID(got), "request %d", i)
+	}
+}
+
+// TestEngine_IndexInvalidation 测试策略变更后索引重建
+func TestEngine_IndexInvalidation(t *testing.T) {
+	ctx := context.Background()
+	engine := NewEngine()
+	req := Request{Resource: Resource{Type: "doc"}, Action: Action{Name: "read"}}
+
+	require.NoError(t, engine.AddPolicy(Policy{ID: "p1", Name: "p1", Effect: Allow, Enabled: true, Rules: ResourceTypeIs("sheet")}))
+	assert.False(t, engine.Evaluate(ctx, req).Allowed)
+
+	require.NoError(t, engine.UpdatePolicy(Policy{ID: "p1", Name: "p1", Effect: Allow, Enabled: true, Rules: ResourceTypeIs("doc")}))
+	assert.True(t, engine.Evaluate(ctx, req).Allowed)
+
+	require.NoError(t, engine.AddPolicy(Policy{ID: "p2", Name: "p2", Priority: 10, Effect: Deny, Enabled: true, Rules: ActionIs("READ")}))
+	assert.Equal(t, "p2", engine.Evaluate(ctx, req).MatchedPolicy.ID)
+
+	require.NoError(t, engine.RemovePolicy("p2"))
+	assert.Equal(t, "p1", engine.Evaluate(ctx, req).MatchedPolicy.ID)
+
+	engine.ClearPolicies()
+	assert.Nil(t, engine.Evaluate(ctx, req).MatchedPolicy)
+}
+
+// TestEngine_DecisionCache 测试决策缓存和失效
+func TestEngine_DecisionCache(t *testing.T) {
+	ctx := context.Background()
+	engine := NewEngine(WithDecisionCache(2, time.Minute))
+	now := time.Unix(1700000000, 0)
+	engine.cache.now = func() time.Time { return now }
+
+	calls := 0
+	counting := RuleFunc(func(ctx context.Context, req Request) (bool, error) {
+		calls++
+		return req.Subject.ID == "alice", nil
+	})
+	require.NoError(t, engine.AddPolicy(Policy{ID: "p1", Name: "p1", Effect: Allow, Enabled: true, Rules: counting}))
+
+	alice := Request{Subject: Subject{ID: "alice"}}
+	assert.True(t, engine.Evaluate(ctx, alice).Allowed)
+	result := engine.Evaluate(ctx, alice)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, calls)
+
+	// 修改返回的结果不影响缓存
+	result.MatchedPolicy.ID = "changed"
+	assert.Equal(t, "p1", engine.Evaluate(ctx, alice).MatchedPolicy.ID)
+
+	// 添加策略后缓存失效
+	require.NoError(t, engine.AddPolicy(Policy{ID: "p2", Name: "p2", Priority: 10, Effect: Deny, Enabled: true, Rules: AlwaysAllow()}))
+	assert.Equal(t, 0, engine.cache.len())
+	assert.False(t, engine.Evaluate(ctx, alice).Allowed)
+
+	// 删除策略后缓存失效
+	require.NoError(t, engine.RemovePolicy("p2"))
+	assert.True(t, engine.Evaluate(ctx, alice).Allowed)
+	assert.Equal(t, 2, calls)
+
+	// 过期
+	now = now.Add(time.Minute)
+	assert.True(t, engine.Evaluate(ctx, alice).Allowed)
+	assert.Equal(t, 3, calls)
+
+	// 容量
+	engine.Evaluate(ctx, Request{Subject: Subject{ID: "bob"}})
+	engine.Evaluate(ctx, Request{Subject: Subject{ID: "carol"}})
+	assert.Equal(t, 2, engine.cache.len())
+
+	// 出错的结果不缓存
+	require.NoError(t, engine.AddPolicy(Policy{ID: "p3", Name: "p3", Priority: 20, Effect: Deny, Enabled: true,
+		Rules: RuleFunc(func(ctx context.Context, req Request) (bool, error) { return false, errors.New("unavailable") })}))
+	engine.Evaluate(ctx, alice)
+	assert.Equal(t, 0, engine.cache.len())
+
+	// 无法序列化的请求不使用缓存
+	engine.Evaluate(ctx, Request{Subject: Subject{ID: "dave", Attributes: map[string]interface{}{"fn": func() {}}}})
+	assert.Equal(t, 0, engine.cache.len())
+}
+
+func benchmarkEvaluate(b *testing.B, policies int, evaluate func(*Engine, Request) Result, opts ...EngineOption) {
+	rng := rand.New(rand.NewSource(1))
+	engine := randomEngine(b, rng, policies, opts...)
+	requests := make([]Request, 1024)
+	for i := range requests {
+		requests[i] = randomRequest(rng, policies)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		evaluate(engine, requests[i%len(requests)])
+	}
+}
+
+// BenchmarkEngine_EvaluateScale 比较线性评估、索引评估和决策缓存
+func BenchmarkEngine_EvaluateScale(b *testing.B) {
+	ctx := context.Background()
+	linear := func(e *Engine, req Request) Result { return evaluateLinear(ctx, e, req) }
+	indexed := func(e *Engine, req Request) Result { return e.Evaluate(ctx, req) }
+
+	for _, n := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) { benchmarkEvaluate(b, n, linear) })
+		b.Run(fmt.Sprintf("indexed/%d", n), func(b *testing.B) { benchmarkEvaluate(b, n, indexed) })
+		b.Run(fmt.Sprintf("cached/%d", n), func(b *testing.B) {
+			benchmarkEvaluate(b, n, indexed, WithDecisionCache(4096, 0))
+		})
+	}
+}
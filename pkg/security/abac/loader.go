@@ -0,0 +1,404 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件实现了策略文件的加载和热更新。
+//
+// 策略文件使用 JSON 或 YAML 格式，条件使用表达式语言（见 CompileExpression）：
+//
+//	policies:
+//	  - id: same-department-business-hours
+//	    name: Same department during business hours
+//	    priority: 100
+//	    effect: allow
+//	    condition: subject.department == resource.department && env.hour < 18
+//
+// 使用示例：
+//
+//	engine := abac.NewEngine()
+//	loader := abac.NewPolicyLoader(engine, "/etc/app/policies")
+//	if err := loader.Load(); err != nil {
+//	    log.Fatal(err)
+//	}
+//	go loader.Watch(ctx)
+
+package abac
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultReloadDebounce 文件变化后等待的时间，合并编辑器和部署工具的连续写入
+const DefaultReloadDebounce = 200 * time.Millisecond
+
+// PolicySpec 策略文件中的单个策略
+type PolicySpec struct {
+	ID          string `json:"id" yaml:"id"`
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"` // 为空时使用 ID
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Priority    int    `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Effect      string `json:"effect" yaml:"effect"`                       // allow 或 deny
+	Condition   string `json:"condition" yaml:"condition"`                 // 策略表达式
+	Enabled     *bool  `json:"enabled,omitempty" yaml:"enabled,omitempty"` // 默认启用
+}
+
+// PolicyDocument 策略文件
+type PolicyDocument struct {
+	Policies []PolicySpec `json:"policies" yaml:"policies"`
+}
+
+// ParseEffect 解析效果名称（allow、deny，不区分大小写）
+func ParseEffect(s string) (Effect, error) {
+	switch strings.ToLower(s) {
+	case "allow":
+		return Allow, nil
+	case "deny":
+		return Deny, nil
+	default:
+		return Deny, fmt.Errorf("unknown effect %q", s)
+	}
+}
+
+// Compile 编译策略定义
+func (s PolicySpec) Compile() (Policy, error) {
+	effect, err := ParseEffect(s.Effect)
+	if err != nil {
+		return Policy{}, fmt.Errorf("policy %s: %w", s.ID, err)
+	}
+	rule, err := CompileExpression(s.Condition)
+	if err != nil {
+		return Policy{}, fmt.Errorf("policy %s: %w", s.ID, err)
+	}
+
+	policy := Policy{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		Priority:    s.Priority,
+		Effect:      effect,
+		Rules:       rule,
+		Enabled:     s.Enabled == nil || *s.Enabled,
+	}
+	if policy.Name == "" {
+		policy.Name = policy.ID
+	}
+	if err := policy.Validate(); err != nil {
+		return Policy{}, fmt.Errorf("policy %s: %w", s.ID, err)
+	}
+	return policy, nil
+}
+
+// ParsePolicies 解析并编译策略文件内容
+//
+// 参数：
+//   - data: 文件内容
+//   - format: "json" 或 "yaml"
+//
+// 注意事项：
+//   - 未知字段视为错误，防止拼写错误的字段被静默忽略
+//   - 任一策略编译失败时返回错误，不返回部分结果
+func ParsePolicies(data []byte, format string) ([]Policy, error) {
+	var doc PolicyDocument
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse policy document: %w", err)
+		}
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// 空文件视为没有策略
+		if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse policy document: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy format %q", format)
+	}
+
+	policies := make([]Policy, 0, len(doc.Policies))
+	for _, spec := range doc.Policies {
+		policy, err := spec.Compile()
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// LoadPolicyFile 加载单个策略文件，格式由扩展名（.json、.yaml、.yml）决定
+func LoadPolicyFile(path string) ([]Policy, error) {
+	format := policyFormat(path)
+	if format == "" {
+		return nil, fmt.Errorf("unsupported policy file %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := ParsePolicies(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policies, nil
+}
+
+// LoadPolicyDir 加载目录下的所有策略文件
+//
+// 只读取目录第一层的 .json、.yaml、.yml 文件，跳过以 . 开头的文件（编辑器临时文件、
+// Kubernetes ConfigMap 的 ..data 目录）。不同文件中的策略 ID 不能重复。
+func LoadPolicyDir(dir string) ([]Policy, error) {
+	policies, _, err := loadPolicyDir(dir)
+	return policies, err
+}
+
+// loadPolicyDir 加载目录并返回文件内容的摘要，用于判断是否需要重新应用
+func loadPolicyDir(dir string) ([]Policy, [sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, digest, err
+	}
+
+	hash := sha256.New()
+	seen := make(map[string]string)
+	var policies []Policy
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || policyFormat(name) == "" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path) // 跟随符号链接
+		if err != nil {
+			return nil, digest, err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, digest, err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", name, len(data))
+		hash.Write(data)
+
+		filePolicies, err := ParsePolicies(data, policyFormat(name))
+		if err != nil {
+			return nil, digest, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, policy := range filePolicies {
+			if other, exists := seen[policy.ID]; exists {
+				return nil, digest, fmt.Errorf("policy %s defined in both %s and %s", policy.ID, other, name)
+			}
+			seen[policy.ID] = name
+		}
+		policies = append(policies, filePolicies...)
+	}
+
+	copy(digest[:], hash.Sum(nil))
+	return policies, digest, nil
+}
+
+// policyFormat 根据文件扩展名返回格式，不支持时返回空字符串
+func policyFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return ""
+	}
+}
+
+// PolicyLoader 从目录加载策略并热更新到引擎
+//
+// 设计原理：
+// 1. 每次加载读取整个目录并编译全部策略，全部成功后才原子地替换引擎中的策略
+// 2. 任一文件有错误时保留当前策略，不会因为一次错误的发布清空或部分更新策略
+// 3. 只管理自己加载的策略，通过 AddPolicy 添加的其他策略不受影响
+// 4. Watch 使用 fsnotify 监听目录，连续的文件变化合并为一次加载
+//
+// 注意事项：
+// - 目录内容未变化时 Load 不修改引擎
+// - 加载的策略 ID 不能与通过其他方式添加的策略冲突
+type PolicyLoader struct {
+	engine   *Engine
+	dir      string
+	debounce time.Duration
+
+	mu     sync.Mutex
+	loaded map[string]struct{}
+	digest [sha256.Size]byte
+}
+
+// LoaderOption 策略加载器选项
+type LoaderOption func(*PolicyLoader)
+
+// WithReloadDebounce 设置文件变化后等待的时间，默认 DefaultReloadDebounce
+func WithReloadDebounce(d time.Duration) LoaderOption {
+	return func(l *PolicyLoader) {
+		if d > 0 {
+			l.debounce = d
+		}
+	}
+}
+
+// NewPolicyLoader 创建策略加载器
+func NewPolicyLoader(engine *Engine, dir string, opts ...LoaderOption) *PolicyLoader {
+	l := &PolicyLoader{
+		engine:   engine,
+		dir:      dir,
+		debounce: DefaultReloadDebounce,
+		loaded:   make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Load 加载目录中的策略并替换上一次加载的策略
+//
+// 通常在启动时调用一次，出错时拒绝启动；之后由 Watch 负责重新加载
+func (l *PolicyLoader) Load() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	policies, digest, err := loadPolicyDir(l.dir)
+	if err != nil {
+		return err
+	}
+	if digest == l.digest {
+		return nil
+	}
+
+	if err := l.engine.replacePolicies(l.loaded, policies); err != nil {
+		return err
+	}
+	l.loaded = make(map[string]struct{}, len(policies))
+	for _, policy := range policies {
+		l.loaded[policy.ID] = struct{}{}
+	}
+	l.digest = digest
+	return nil
+}
+
+// Watch 监听目录变化并重新加载，直到 ctx 结束
+//
+// 加载失败时记录日志并保留当前策略。只有创建监听器失败时返回错误。
+func (l *PolicyLoader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create policy watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(l.dir); err != nil {
+		return fmt.Errorf("failed to watch policy directory: %w", err)
+	}
+
+	timer := time.NewTimer(l.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			timer.Reset(l.debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("abac policy watcher error", "dir", l.dir, "error", err)
+		case <-timer.C:
+			if err := l.Load(); err != nil {
+				slog.Warn("failed to reload abac policies", "dir", l.dir, "error", err)
+			}
+		}
+	}
+}
+
+// replacePolicies 原子地用 next 替换 previous 中的策略
+//
+// next 中的 ID 不能与 previous 以外的现有策略冲突；出错时引擎保持不变
+func (e *Engine) replacePolicies(previous map[string]struct{}, next []Policy) error {
+	for _, policy := range next {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("invalid policy: %w", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	incoming := make(map[string]struct{}, len(next))
+	for _, policy := range next {
+		if _, exists := incoming[policy.ID]; exists {
+			return fmt.Errorf("duplicate policy ID %s", policy.ID)
+		}
+		incoming[policy.ID] = struct{}{}
+		if _, owned := previous[policy.ID]; !owned {
+			if _, exists := e.policies[policy.ID]; exists {
+				return fmt.Errorf("policy with ID %s already exists", policy.ID)
+			}
+		}
+	}
+
+	var events []PolicyChangeEvent
+	removed := make([]string, 0, len(previous))
+	for id := range previous {
+		if _, kept := incoming[id]; !kept {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	for _, id := range removed {
+		policy, exists := e.policies[id]
+		if !exists {
+			continue
+		}
+		delete(e.policies, id)
+		events = append(events, PolicyChangeEvent{Type: "remove", PolicyID: id, Policy: &policy})
+	}
+	for _, policy := range next {
+		eventType := "add"
+		if _, exists := e.policies[policy.ID]; exists {
+			eventType = "update"
+		}
+		e.policies[policy.ID] = policy
+		events = append(events, PolicyChangeEvent{Type: eventType, PolicyID: policy.ID, Policy: &policy})
+	}
+
+	if len(events) > 0 {
+		e.invalidate()
+	}
+	if e.onPolicyChange != nil {
+		for _, event := range events {
+			e.onPolicyChange(event)
+		}
+	}
+	return nil
+}
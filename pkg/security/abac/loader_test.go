@@ -0,0 +1,171 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件包含策略文件加载和热更新的单元测试
+
+package abac
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicyYAML = `
+policies:
+  - id: same-department
+    name: Same department during business hours
+    priority: 100
+    effect: allow
+    condition: subject.department == resource.department && env.hour < 18
+  - id: deny-contractors
+    priority: 200
+    effect: deny
+    condition: has_role("contractor")
+  - id: disabled
+    effect: allow
+    condition: "true"
+    enabled: false
+`
+
+// TestParsePolicies 测试解析 JSON 和 YAML 策略文件
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies([]byte(testPolicyYAML), "yaml")
+	require.NoError(t, err)
+	require.Len(t, policies, 3)
+	assert.Equal(t, "Same department during business hours", policies[0].Name)
+	assert.Equal(t, Allow, policies[0].Effect)
+	assert.Equal(t, "deny-contractors", policies[1].Name)
+	assert.Equal(t, Deny, policies[1].Effect)
+	assert.False(t, policies[2].Enabled)
+
+	policies, err = ParsePolicies([]byte(`{"policies":[{"id":"p1","effect":"Allow","condition":"action.name == \"read\""}]}`), "json")
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.True(t, policies[0].Enabled)
+
+	policies, err = ParsePolicies(nil, "yaml")
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+
+	for name, tc := range map[string]struct{ data, format string }{
+		"unknown field":  {`{"policies":[{"id":"p1","effect":"allow","conditon":"true"}]}`, "json"},
+		"bad effect":     {`{"policies":[{"id":"p1","effect":"permit","condition":"true"}]}`, "json"},
+		"bad expression": {"policies:\n  - id: p1\n    effect: allow\n    condition: subject.x ==\n", "yaml"},
+		"missing id":     {"policies:\n  - effect: allow\n    condition: \"true\"\n", "yaml"},
+		"bad format":     {"", "toml"},
+	} {
+		_, err := ParsePolicies([]byte(tc.data), tc.format)
+		assert.Error(t, err, name)
+	}
+}
+
+// TestEngine_FromPolicyFile 测试加载的策略参与评估
+func TestEngine_FromPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicyYAML), 0o600))
+
+	policies, err := LoadPolicyFile(path)
+	require.NoError(t, err)
+	engine := NewEngine()
+	for _, policy := range policies {
+		require.NoError(t, engine.AddPolicy(policy))
+	}
+
+	req := Request{
+		Subject:     Subject{ID: "alice", Department: "engineering"},
+		Resource:    Resource{Type: "document", Attributes: map[string]interface{}{"department": "engineering"}},
+		Environment: Environment{Attributes: map[string]interface{}{"hour": 9}},
+	}
+	assert.True(t, engine.Evaluate(context.Background(), req).IsAllowed())
+
+	req.Subject.Roles = []string{"contractor"}
+	result := engine.Evaluate(context.Background(), req)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, "deny-contractors", result.MatchedPolicy.ID)
+}
+
+// TestPolicyLoader_Load 测试目录加载和替换
+func TestPolicyLoader_Load(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	write("a.yaml", "policies:\n  - id: a\n    effect: allow\n    condition: has_role(\"a\")\n")
+	write("b.json", `{"policies":[{"id":"b","effect":"deny","condition":"has_role(\"b\")"}]}`)
+	write(".a.yaml.swp", "not a policy")
+	write("README.md", "ignored")
+
+	var events []PolicyChangeEvent
+	engine := NewEngine(WithPolicyChangeCallback(func(event PolicyChangeEvent) {
+		events = append(events, event)
+	}))
+	require.NoError(t, engine.AddPolicy(Policy{ID: "manual", Name: "manual", Rules: AlwaysDeny(), Enabled: true}))
+
+	loader := NewPolicyLoader(engine, dir)
+	require.NoError(t, loader.Load())
+	assert.Equal(t, 3, engine.GetStats().TotalPolicies)
+
+	// 内容未变化时不修改引擎
+	events = nil
+	require.NoError(t, loader.Load())
+	assert.Empty(t, events)
+
+	// 删除 b，更新 a，保留手动添加的策略
+	require.NoError(t, os.Remove(filepath.Join(dir, "b.json")))
+	write("a.yaml", "policies:\n  - id: a\n    effect: deny\n    condition: has_role(\"a\")\n")
+	require.NoError(t, loader.Load())
+	assert.Equal(t, []PolicyChangeEvent{
+		{Type: "remove", PolicyID: "b", Policy: events[0].Policy},
+		{Type: "update", PolicyID: "a", Policy: events[1].Policy},
+	}, events)
+	policy, err := engine.GetPolicy("a")
+	require.NoError(t, err)
+	assert.Equal(t, Deny, policy.Effect)
+	_, err = engine.GetPolicy("manual")
+	assert.NoError(t, err)
+
+	// 错误的文件不影响当前策略
+	write("c.yaml", "policies:\n  - id: c\n    effect: allow\n    condition: subject.x ==\n")
+	assert.Error(t, loader.Load())
+	assert.Equal(t, 2, engine.GetStats().TotalPolicies)
+
+	// 重复 ID 和与手动策略冲突都被拒绝
+	write("c.yaml", "policies:\n  - id: a\n    effect: allow\n    condition: \"true\"\n")
+	assert.Error(t, loader.Load())
+	write("c.yaml", "policies:\n  - id: manual\n    effect: allow\n    condition: \"true\"\n")
+	assert.Error(t, loader.Load())
+	policy, err = engine.GetPolicy("manual")
+	require.NoError(t, err)
+	assert.Equal(t, "AlwaysDeny()", policy.Rules.String())
+}
+
+// TestPolicyLoader_Watch 测试文件变化后自动重新加载
+func TestPolicyLoader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("policies: []\n"), 0o600))
+
+	engine := NewEngine()
+	loader := NewPolicyLoader(engine, dir, WithReloadDebounce(10*time.Millisecond))
+	require.NoError(t, loader.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- loader.Watch(ctx) }()
+
+	// 等待监听器启动后再写入
+	assert.Eventually(t, func() bool {
+		_ = os.WriteFile(path, []byte("policies:\n  - id: a\n    effect: allow\n    condition: \"true\"\n"), 0o600)
+		_, err := engine.GetPolicy("a")
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
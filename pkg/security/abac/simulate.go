@@ -0,0 +1,109 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件实现了策略模拟（what-if）：在不修改线上引擎的情况下，
+// 用一批请求比较候选策略集与当前策略集的决策差异。
+//
+// 使用示例：
+//
+//	candidate, err := abac.LoadPolicyDir("policies-next/")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	report, err := engine.Simulate(ctx, candidate, recordedRequests)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, diff := range report.Diffs {
+//	    fmt.Println(diff.Index, diff.Live.Decision, "->", diff.Candidate.Decision)
+//	}
+
+package abac
+
+import (
+	"context"
+	"fmt"
+)
+
+// SimulationDiff 一个请求在当前策略集和候选策略集下的决策差异
+type SimulationDiff struct {
+	Index           int     `json:"index"` // 请求在输入中的下标
+	Request         Request `json:"request"`
+	Live            Result  `json:"live"`
+	Candidate       Result  `json:"candidate"`
+	DecisionChanged bool    `json:"decision_changed"` // false 表示效果相同但决定结果的策略不同
+}
+
+// SimulationReport 策略模拟报告
+type SimulationReport struct {
+	Total        int              `json:"total"`         // 请求总数
+	Changed      int              `json:"changed"`       // 效果改变的请求数
+	NewlyAllowed int              `json:"newly_allowed"` // 由拒绝变为允许的请求数
+	NewlyDenied  int              `json:"newly_denied"`  // 由允许变为拒绝的请求数
+	Diffs        []SimulationDiff `json:"diffs"`         // 效果或决定策略发生变化的请求
+}
+
+// Simulate 用候选策略集评估一批请求，并与当前引擎的结果比较
+//
+// 参数：
+//   - ctx: 上下文
+//   - candidate: 候选策略集，完整替换当前策略（不是增量）
+//   - requests: 用于比较的请求，通常来自审计日志的采样
+//
+// 返回：
+//   - *SimulationReport: 只包含有差异的请求
+//   - error: 候选策略无效或 ID 重复时返回错误
+//
+// 注意事项：
+//   - 候选引擎使用与当前引擎相同的默认效果
+//   - 不修改当前引擎，也不触发策略变更回调
+func (e *Engine) Simulate(ctx context.Context, candidate []Policy, requests []Request) (*SimulationReport, error) {
+	e.mu.RLock()
+	defaultEffect := e.defaultEffect
+	e.mu.RUnlock()
+
+	sandbox := NewEngine(WithDefaultEffect(defaultEffect))
+	for _, policy := range candidate {
+		if err := sandbox.AddPolicy(policy); err != nil {
+			return nil, fmt.Errorf("candidate policy set: %w", err)
+		}
+	}
+
+	report := &SimulationReport{Total: len(requests)}
+	for i, req := range requests {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		live := e.Evaluate(ctx, req)
+		next := sandbox.Evaluate(ctx, req)
+		decisionChanged := live.Allowed != next.Allowed
+		if !decisionChanged && matchedPolicyID(live) == matchedPolicyID(next) {
+			continue
+		}
+
+		report.Diffs = append(report.Diffs, SimulationDiff{
+			Index:           i,
+			Request:         req,
+			Live:            live,
+			Candidate:       next,
+			DecisionChanged: decisionChanged,
+		})
+		if decisionChanged {
+			report.Changed++
+			if next.Allowed {
+				report.NewlyAllowed++
+			} else {
+				report.NewlyDenied++
+			}
+		}
+	}
+	return report, nil
+}
+
+// matchedPolicyID 返回决定结果的策略 ID，没有匹配策略时返回空字符串
+func matchedPolicyID(result Result) string {
+	if result.MatchedPolicy == nil {
+		return ""
+	}
+	return result.MatchedPolicy.ID
+}
@@ -0,0 +1,78 @@
+// Package abac provides Attribute-Based Access Control (ABAC) implementation.
+//
+// 本文件包含策略模拟的单元测试
+
+package abac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEngine_Simulate 测试候选策略集与当前策略集的差异
+func TestEngine_Simulate(t *testing.T) {
+	engine := NewEngine()
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "readers", Name: "Readers", Priority: 10, Effect: Allow, Enabled: true,
+		Rules: MustCompileExpression(`action.name == "read"`),
+	}))
+	require.NoError(t, engine.AddPolicy(Policy{
+		ID: "owners", Name: "Owners", Priority: 20, Effect: Allow, Enabled: true,
+		Rules: MustCompileExpression(`is_owner()`),
+	}))
+
+	candidate, err := ParsePolicies([]byte(`
+policies:
+  - id: readers
+    priority: 10
+    effect: allow
+    condition: action.name == "read" && subject.department == resource.department
+  - id: owners-v2
+    priority: 20
+    effect: allow
+    condition: is_owner()
+  - id: admins
+    priority: 30
+    effect: allow
+    condition: has_role("admin")
+`), "yaml")
+	require.NoError(t, err)
+
+	requests := []Request{
+		// 0: 跨部门读取，变为拒绝
+		{Subject: Subject{ID: "bob", Department: "sales"}, Resource: Resource{Owner: "alice", Attributes: map[string]interface{}{"department": "engineering"}}, Action: Action{Name: "read"}},
+		// 1: 同部门读取，不变
+		{Subject: Subject{ID: "bob", Department: "engineering"}, Resource: Resource{Owner: "alice", Attributes: map[string]interface{}{"department": "engineering"}}, Action: Action{Name: "read"}},
+		// 2: 管理员删除，变为允许
+		{Subject: Subject{ID: "carol", Roles: []string{"admin"}}, Resource: Resource{Owner: "alice"}, Action: Action{Name: "delete"}},
+		// 3: 所有者编辑，效果不变但决定策略改变
+		{Subject: Subject{ID: "alice"}, Resource: Resource{Owner: "alice"}, Action: Action{Name: "edit"}},
+	}
+
+	report, err := engine.Simulate(context.Background(), candidate, requests)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Changed)
+	assert.Equal(t, 1, report.NewlyAllowed)
+	assert.Equal(t, 1, report.NewlyDenied)
+
+	require.Len(t, report.Diffs, 3)
+	assert.Equal(t, 0, report.Diffs[0].Index)
+	assert.True(t, report.Diffs[0].DecisionChanged)
+	assert.False(t, report.Diffs[0].Candidate.Allowed)
+	assert.Equal(t, 2, report.Diffs[1].Index)
+	assert.Equal(t, "admins", report.Diffs[1].Candidate.MatchedPolicy.ID)
+	assert.Equal(t, 3, report.Diffs[2].Index)
+	assert.False(t, report.Diffs[2].DecisionChanged)
+	assert.Equal(t, "owners-v2", report.Diffs[2].Candidate.MatchedPolicy.ID)
+
+	// 当前引擎不受影响
+	assert.Equal(t, 2, engine.GetStats().TotalPolicies)
+
+	// 无效的候选策略集
+	_, err = engine.Simulate(context.Background(), append(candidate, candidate[0]), requests)
+	assert.Error(t, err)
+}
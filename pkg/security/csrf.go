@@ -1,11 +1,12 @@
 package security
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"sync"
 	"time"
 )
 
@@ -17,27 +18,30 @@ var (
 )
 
 // CSRFProtection CSRF 防护
+//
+// 令牌保存在 CSRFTokenStore 中，默认使用进程内的 MemoryCSRFTokenStore；
+// 多副本部署时通过 CSRFConfig.Store 传入 RedisCSRFTokenStore，
+// 否则在一个副本上生成的令牌会在其他副本上验证失败。
 type CSRFProtection struct {
-	tokens      map[string]*CSRFToken
-	mu          sync.RWMutex
+	store       CSRFTokenStore
+	ownsStore   bool
 	secret      []byte
 	tokenLength int
 	expiry      time.Duration
-	cleanup     *time.Ticker
-	stopCleanup chan struct{}
 }
 
 // CSRFToken CSRF 令牌
 type CSRFToken struct {
-	Token     string
-	ExpiresAt time.Time
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // CSRFConfig CSRF 配置
 type CSRFConfig struct {
-	Secret      []byte        // 密钥
-	TokenLength int           // 令牌长度
-	Expiry      time.Duration // 过期时间
+	Secret      []byte         // 密钥
+	TokenLength int            // 令牌长度
+	Expiry      time.Duration  // 过期时间
+	Store       CSRFTokenStore // 令牌存储，为 nil 时使用 MemoryCSRFTokenStore
 }
 
 // DefaultCSRFConfig 默认 CSRF 配置
@@ -55,7 +59,7 @@ func DefaultCSRFConfig() CSRFConfig {
 // NewCSRFProtection 创建 CSRF 防护
 func NewCSRFProtection(config CSRFConfig) *CSRFProtection {
 	if config.Secret == nil {
-		config = DefaultCSRFConfig()
+		config.Secret = DefaultCSRFConfig().Secret
 	}
 	if config.TokenLength == 0 {
 		config.TokenLength = 32
@@ -65,22 +69,26 @@ func NewCSRFProtection(config CSRFConfig) *CSRFProtection {
 	}
 
 	csrf := &CSRFProtection{
-		tokens:      make(map[string]*CSRFToken),
+		store:       config.Store,
 		secret:      config.Secret,
 		tokenLength: config.TokenLength,
 		expiry:      config.Expiry,
-		stopCleanup: make(chan struct{}),
 	}
-
-	// 启动清理协程
-	csrf.cleanup = time.NewTicker(1 * time.Hour)
-	go csrf.cleanupExpired()
+	if csrf.store == nil {
+		csrf.store = NewMemoryCSRFTokenStore()
+		csrf.ownsStore = true
+	}
 
 	return csrf
 }
 
-// GenerateToken 生成 CSRF 令牌
+// GenerateToken 生成 CSRF 令牌，等同于使用 context.Background() 调用 GenerateTokenContext
 func (c *CSRFProtection) GenerateToken(sessionID string) (string, error) {
+	return c.GenerateTokenContext(context.Background(), sessionID)
+}
+
+// GenerateTokenContext 生成 CSRF 令牌，ctx 用于控制存储访问的超时和取消
+func (c *CSRFProtection) GenerateTokenContext(ctx context.Context, sessionID string) (string, error) {
 	if sessionID == "" {
 		return "", errors.New("session ID is required")
 	}
@@ -94,77 +102,65 @@ func (c *CSRFProtection) GenerateToken(sessionID string) (string, error) {
 	token := base64.URLEncoding.EncodeToString(tokenBytes)
 
 	// 存储令牌
-	c.mu.Lock()
-	c.tokens[sessionID] = &CSRFToken{
+	err := c.store.Save(ctx, sessionID, &CSRFToken{
 		Token:     token,
 		ExpiresAt: time.Now().Add(c.expiry),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save token: %w", err)
 	}
-	c.mu.Unlock()
 
 	return token, nil
 }
 
-// ValidateToken 验证 CSRF 令牌
+// ValidateToken 验证 CSRF 令牌，等同于使用 context.Background() 调用 ValidateTokenContext
 func (c *CSRFProtection) ValidateToken(sessionID, token string) error {
+	return c.ValidateTokenContext(context.Background(), sessionID, token)
+}
+
+// ValidateTokenContext 验证 CSRF 令牌
+//
+// 存储不可用时返回存储的错误，调用方应当拒绝请求。
+// 在 HTTP 处理中应传入 r.Context()，客户端断开或超时后不再等待存储
+func (c *CSRFProtection) ValidateTokenContext(ctx context.Context, sessionID, token string) error {
 	if sessionID == "" || token == "" {
 		return ErrInvalidCSRFToken
 	}
 
-	c.mu.RLock()
-	storedToken, exists := c.tokens[sessionID]
-	c.mu.RUnlock()
-
-	if !exists {
-		return ErrInvalidCSRFToken
+	storedToken, err := c.store.Get(ctx, sessionID)
+	if err != nil {
+		return err
 	}
 
-	if storedToken.Token != token {
+	if subtle.ConstantTimeCompare([]byte(storedToken.Token), []byte(token)) != 1 {
 		return ErrInvalidCSRFToken
 	}
 
 	if time.Now().After(storedToken.ExpiresAt) {
 		// 删除过期令牌
-		c.mu.Lock()
-		delete(c.tokens, sessionID)
-		c.mu.Unlock()
+		_ = c.store.Delete(ctx, sessionID)
 		return ErrCSRFTokenExpired
 	}
 
 	return nil
 }
 
-// RevokeToken 撤销 CSRF 令牌
+// RevokeToken 撤销 CSRF 令牌，等同于使用 context.Background() 调用 RevokeTokenContext
 func (c *CSRFProtection) RevokeToken(sessionID string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	delete(c.tokens, sessionID)
-	return nil
+	return c.RevokeTokenContext(context.Background(), sessionID)
 }
 
-// cleanupExpired 清理过期令牌
-func (c *CSRFProtection) cleanupExpired() {
-	for {
-		select {
-		case <-c.cleanup.C:
-			c.mu.Lock()
-			now := time.Now()
-			for sessionID, token := range c.tokens {
-				if now.After(token.ExpiresAt) {
-					delete(c.tokens, sessionID)
-				}
-			}
-			c.mu.Unlock()
-
-		case <-c.stopCleanup:
-			c.cleanup.Stop()
-			return
-		}
-	}
+// RevokeTokenContext 撤销 CSRF 令牌
+func (c *CSRFProtection) RevokeTokenContext(ctx context.Context, sessionID string) error {
+	return c.store.Delete(ctx, sessionID)
 }
 
 // Shutdown 关闭 CSRF 防护
+//
+// 只关闭默认创建的 MemoryCSRFTokenStore，通过配置传入的存储由调用方负责关闭
 func (c *CSRFProtection) Shutdown() error {
-	close(c.stopCleanup)
+	if c.ownsStore {
+		closeStore(c.store)
+	}
 	return nil
 }
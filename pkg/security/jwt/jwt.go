@@ -1,26 +1,41 @@
 package jwt
 
 import (
+	"context"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // TokenManager JWT 令牌管理器
+//
+// 刷新令牌按登录分组为令牌族（fam 声明），每次刷新都会轮换：旧的刷新令牌立即失效，
+// 已轮换的刷新令牌再次使用时撤销整个族。访问令牌和刷新令牌都带有 jti，
+// 可以在过期前通过 RevokeToken 撤销，VerifyAccessToken 和 Middleware 会检查撤销列表。
+//
+// 签名密钥保存在 KeyRing 中：签名使用活动密钥并写入 kid 头部，验证按 kid 选择密钥，
+// 配合 KeyRotator 可以不停机轮换密钥，其他服务通过 JWKSHandler 获取公钥。
 type TokenManager struct {
-	privateKey       *rsa.PrivateKey
-	publicKey        *rsa.PublicKey
-	issuer           string
-	accessTokenTTL   time.Duration
-	refreshTokenTTL  time.Duration
-	signingMethod    jwt.SigningMethod
+	privateKey      *rsa.PrivateKey
+	publicKey       *rsa.PublicKey
+	keys            *KeyRing
+	issuer          string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	algorithm       string
+	revocations     RevocationStore
+	families        FamilyStore
 }
 
 // Config JWT 配置
@@ -30,9 +45,23 @@ type Config struct {
 	Issuer          string        // 令牌签发者
 	AccessTokenTTL  time.Duration // 访问令牌有效期 (推荐: 15分钟)
 	RefreshTokenTTL time.Duration // 刷新令牌有效期 (推荐: 7天)
-	SigningMethod   string        // 签名算法 (RS256/ES256)
+	SigningMethod   string        // 签名算法 (RS256/RS384/RS512/ES256/ES384/EdDSA)
+
+	// KeyRing 签名密钥环，设置后忽略 PrivateKeyPath、PublicKeyPath 和 SigningMethod
+	KeyRing *KeyRing
+
+	// RevocationStore 撤销列表，为 nil 时使用 MemoryRevocationStore
+	RevocationStore RevocationStore
+	// FamilyStore 刷新令牌族存储，为 nil 时使用 MemoryFamilyStore
+	FamilyStore FamilyStore
 }
 
+// 令牌类型，写入 typ 声明
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // Claims JWT 标准声明
 type Claims struct {
 	jwt.RegisteredClaims
@@ -41,6 +70,12 @@ type Claims struct {
 	Email    string   `json:"email,omitempty"`
 	Roles    []string `json:"roles,omitempty"`
 	Scope    []string `json:"scope,omitempty"`
+	// ClientID 令牌签发给的 OAuth2 客户端
+	ClientID string `json:"client_id,omitempty"`
+	// Family 刷新令牌所属的令牌族，只有刷新令牌带有该声明
+	Family string `json:"fam,omitempty"`
+	// Type 令牌类型，TokenTypeAccess 或 TokenTypeRefresh；引入该声明之前签发的令牌为空
+	Type string `json:"typ,omitempty"`
 }
 
 // TokenPair 令牌对
@@ -68,12 +103,26 @@ func NewTokenManager(cfg Config) (*TokenManager, error) {
 		cfg.SigningMethod = "RS256"
 	}
 
+	if cfg.RevocationStore == nil {
+		cfg.RevocationStore = NewMemoryRevocationStore()
+	}
+	if cfg.FamilyStore == nil {
+		cfg.FamilyStore = NewMemoryFamilyStore()
+	}
+
 	tm := &TokenManager{
-		issuer:           cfg.Issuer,
-		accessTokenTTL:   cfg.AccessTokenTTL,
-		refreshTokenTTL:  cfg.RefreshTokenTTL,
-		signingMethod:    jwt.GetSigningMethod(cfg.SigningMethod),
+		issuer:          cfg.Issuer,
+		accessTokenTTL:  cfg.AccessTokenTTL,
+		refreshTokenTTL: cfg.RefreshTokenTTL,
+		algorithm:       cfg.SigningMethod,
+		keys:            cfg.KeyRing,
+		revocations:     cfg.RevocationStore,
+		families:        cfg.FamilyStore,
+	}
+	if tm.keys != nil {
+		return tm, nil
 	}
+	tm.keys = NewKeyRing()
 
 	// 加载密钥
 	if cfg.PrivateKeyPath != "" && cfg.PublicKeyPath != "" {
@@ -92,41 +141,106 @@ func NewTokenManager(cfg Config) (*TokenManager, error) {
 
 // GenerateAccessToken 生成访问令牌
 func (tm *TokenManager) GenerateAccessToken(userID, username, email string, roles []string) (string, error) {
-	now := time.Now()
-	claims := Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    tm.issuer,
-			Subject:   userID,
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(tm.accessTokenTTL)),
-			NotBefore: jwt.NewNumericDate(now),
-		},
+	return tm.IssueAccessToken(Claims{
 		UserID:   userID,
 		Username: username,
 		Email:    email,
 		Roles:    roles,
+	})
+}
+
+// IssueAccessToken 按模板声明签发访问令牌
+//
+// 模板中的 jti、iss、iat、nbf、exp 由 TokenManager 填写，sub 为空时使用 UserID，
+// 其余声明（如 Scope、ClientID、Audience）原样保留
+func (tm *TokenManager) IssueAccessToken(template Claims) (string, error) {
+	now := time.Now()
+	claims := template
+	claims.Family = ""
+	claims.Type = TokenTypeAccess
+	claims.ID = uuid.NewString()
+	claims.Issuer = tm.issuer
+	if claims.Subject == "" {
+		claims.Subject = claims.UserID
 	}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(tm.accessTokenTTL))
 
-	token := jwt.NewWithClaims(tm.signingMethod, claims)
-	return token.SignedString(tm.privateKey)
+	return tm.sign(claims)
+}
+
+// IssueTokenPair 按模板声明签发令牌对
+//
+// 刷新令牌创建新的令牌族并保存模板中的声明，刷新得到的访问令牌与第一个访问令牌的声明相同，
+// 用于 OAuth2 授权服务器等需要在刷新后保留 scope 和客户端的场景
+func (tm *TokenManager) IssueTokenPair(ctx context.Context, template Claims) (*TokenPair, error) {
+	accessToken, err := tm.IssueAccessToken(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	familyID := uuid.NewString()
+	jti := uuid.NewString()
+	expiresAt := time.Now().Add(tm.refreshTokenTTL)
+	if err := tm.families.Create(ctx, familyID, template.UserID, jti, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to create token family: %w", err)
+	}
+	refreshToken, err := tm.signRefreshToken(template, familyID, jti, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tm.accessTokenTTL.Seconds()),
+		IssuedAt:     time.Now(),
+	}, nil
 }
 
 // GenerateRefreshToken 生成刷新令牌
+//
+// 每次调用都会创建新的令牌族，相当于一次新的登录
 func (tm *TokenManager) GenerateRefreshToken(userID string) (string, error) {
+	familyID := uuid.NewString()
+	jti := uuid.NewString()
+	expiresAt := time.Now().Add(tm.refreshTokenTTL)
+
+	if err := tm.families.Create(context.Background(), familyID, userID, jti, expiresAt); err != nil {
+		return "", fmt.Errorf("failed to create token family: %w", err)
+	}
+	return tm.signRefreshToken(Claims{UserID: userID}, familyID, jti, expiresAt)
+}
+
+// signRefreshToken 签发令牌族中的刷新令牌，保留模板中的声明
+func (tm *TokenManager) signRefreshToken(template Claims, familyID, jti string, expiresAt time.Time) (string, error) {
 	now := time.Now()
-	claims := Claims{
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    tm.issuer,
-			Subject:   userID,
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(tm.refreshTokenTTL)),
-			NotBefore: jwt.NewNumericDate(now),
-		},
-		UserID: userID,
+	claims := template
+	claims.ID = jti
+	claims.Issuer = tm.issuer
+	if claims.Subject == "" {
+		claims.Subject = claims.UserID
 	}
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.Family = familyID
+	claims.Type = TokenTypeRefresh
 
-	token := jwt.NewWithClaims(tm.signingMethod, claims)
-	return token.SignedString(tm.privateKey)
+	return tm.sign(claims)
+}
+
+// sign 使用活动密钥签名，并在头部写入 kid
+func (tm *TokenManager) sign(claims Claims) (string, error) {
+	key, err := tm.keys.Active()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
 // GenerateTokenPair 生成令牌对
@@ -151,14 +265,10 @@ func (tm *TokenManager) GenerateTokenPair(userID, username, email string, roles
 }
 
 // ValidateToken 验证令牌
+//
+// 按头部的 kid 从密钥环中选择验证密钥；没有 kid 的令牌（启用密钥环之前签发）使用活动密钥验证
 func (tm *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名方法
-		if token.Method != tm.signingMethod {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return tm.publicKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, tm.verificationKey)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -176,19 +286,229 @@ func (tm *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// verificationKey 按 kid 选择验证密钥，并检查签名算法与密钥一致
+func (tm *TokenManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	var key *SigningKey
+	if kid, ok := token.Header["kid"].(string); ok {
+		found, exists := tm.keys.Get(kid)
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, kid)
+		}
+		key = found
+	} else {
+		active, err := tm.keys.Active()
+		if err != nil {
+			return nil, err
+		}
+		key = active
+	}
+
+	// 验证签名方法
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// KeyRing 返回签名密钥环
+func (tm *TokenManager) KeyRing() *KeyRing {
+	return tm.keys
+}
+
+// JWKSHandler 返回发布公钥的 JWKS 处理器，通常挂载在 JWKSPath
+func (tm *TokenManager) JWKSHandler() http.Handler {
+	return tm.keys.Handler()
+}
+
+// AccessTokenTTL 返回访问令牌有效期
+func (tm *TokenManager) AccessTokenTTL() time.Duration {
+	return tm.accessTokenTTL
+}
+
+// VerifyToken 验证访问令牌或刷新令牌，并检查撤销列表，用于令牌内省
+//
+// 注意事项：
+// - 只检查 jti 撤销列表：已轮换但未撤销的旧刷新令牌仍然通过验证，再次用于刷新时才会被识别为重用
+func (tm *TokenManager) VerifyToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := tm.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := tm.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// VerifyAccessToken 验证访问令牌，并检查令牌类型和撤销列表
+//
+// 返回：
+//   - *Claims: 令牌声明
+//   - error: 刷新令牌返回 ErrWrongTokenType，已撤销返回 ErrTokenRevoked，撤销列表不可用时返回 ErrRevocationUnavailable
+//
+// 注意事项：
+// - 没有 typ 声明的令牌在引入令牌类型之前签发：带有 fam 声明，或有效期超过访问令牌有效期的，按刷新令牌拒绝
+func (tm *TokenManager) VerifyAccessToken(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := tm.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !tm.isAccessToken(claims) {
+		return nil, ErrWrongTokenType
+	}
+	if err := tm.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// isAccessToken 判断令牌是否为访问令牌，兼容没有 typ 声明的旧令牌
+func (tm *TokenManager) isAccessToken(claims *Claims) bool {
+	switch claims.Type {
+	case TokenTypeAccess:
+		return claims.Family == ""
+	case "":
+		if claims.Family != "" {
+			return false
+		}
+		// 旧的刷新令牌只能通过有效期与访问令牌区分
+		if claims.IssuedAt == nil || claims.ExpiresAt == nil {
+			return false
+		}
+		return claims.ExpiresAt.Sub(claims.IssuedAt.Time) <= tm.accessTokenTTL
+	default:
+		return false
+	}
+}
+
 // RefreshAccessToken 刷新访问令牌
 func (tm *TokenManager) RefreshAccessToken(refreshToken string) (*TokenPair, error) {
+	return tm.RefreshAccessTokenContext(context.Background(), refreshToken)
+}
+
+// RefreshAccessTokenContext 使用刷新令牌换取新的令牌对，并轮换刷新令牌
+//
+// 设计原理：
+// 1. 令牌族只记录当前有效的刷新令牌 jti，刷新时原子地替换为新令牌的 jti
+// 2. 已轮换的刷新令牌再次使用，说明令牌可能已泄露，撤销整个族并返回 ErrRefreshTokenReused
+// 3. 新的刷新令牌与旧令牌属于同一个族，过期时间从本次刷新开始计算
+//
+// 返回：
+//   - *TokenPair: 新的令牌对
+//   - error: 访问令牌返回 ErrWrongTokenType，已撤销返回 ErrTokenRevoked，重用返回 ErrRefreshTokenReused
+//
+// 注意事项：
+// - 客户端并发使用同一个刷新令牌时只有一个请求成功，其余请求会被视为重用，客户端应串行刷新
+// - 族被撤销前签发的访问令牌在过期前仍然有效，需要立即失效时调用 RevokeToken
+func (tm *TokenManager) RefreshAccessTokenContext(ctx context.Context, refreshToken string) (*TokenPair, error) {
 	// 验证刷新令牌
 	claims, err := tm.ValidateToken(refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
+	if claims.Family == "" || claims.Type == TokenTypeAccess {
+		return nil, fmt.Errorf("invalid refresh token: %w", ErrWrongTokenType)
+	}
+	if err := tm.checkRevoked(ctx, claims); err != nil {
+		return nil, fmt.Errorf("invalid refresh token: %w", err)
+	}
+
+	// 轮换刷新令牌
+	jti := uuid.NewString()
+	expiresAt := time.Now().Add(tm.refreshTokenTTL)
+	if err := tm.families.Rotate(ctx, claims.Family, claims.ID, jti, expiresAt); err != nil {
+		return nil, fmt.Errorf("invalid refresh token: %w", err)
+	}
+
+	// 生成新的令牌对，保留刷新令牌中的声明
+	template := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:  claims.Subject,
+			Audience: claims.Audience,
+		},
+		UserID:   claims.UserID,
+		Username: claims.Username,
+		Email:    claims.Email,
+		Roles:    claims.Roles,
+		Scope:    claims.Scope,
+		ClientID: claims.ClientID,
+	}
+	accessToken, err := tm.IssueAccessToken(template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	newRefreshToken, err := tm.signRefreshToken(template, claims.Family, jti, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tm.accessTokenTTL.Seconds()),
+		IssuedAt:     time.Now(),
+	}, nil
+}
+
+// RevokeToken 在过期前撤销令牌
+//
+// 撤销访问令牌时把 jti 加入撤销列表；撤销刷新令牌时同时撤销所属的令牌族（如用户登出）
+func (tm *TokenManager) RevokeToken(ctx context.Context, tokenString string) error {
+	claims, err := tm.ValidateToken(tokenString)
+	if err != nil {
+		return err
+	}
+	if claims.ID == "" {
+		return errors.New("token has no jti")
+	}
+	expiresAt := time.Now().Add(max(tm.accessTokenTTL, tm.refreshTokenTTL))
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if err := tm.revocations.Revoke(ctx, claims.ID, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	if claims.Family != "" {
+		return tm.RevokeFamily(ctx, claims.Family)
+	}
+	return nil
+}
+
+// RevokeJTI 按 jti 撤销令牌，记录保留到 expiresAt
+//
+// 用于只知道 jti 的场景，如根据审计日志撤销某个令牌
+func (tm *TokenManager) RevokeJTI(ctx context.Context, jti string, expiresAt time.Time) error {
+	return tm.revocations.Revoke(ctx, jti, expiresAt)
+}
+
+// RevokeFamily 撤销令牌族，族内的刷新令牌都无法再使用
+func (tm *TokenManager) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := tm.families.Revoke(ctx, familyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return nil
+}
 
-	// 生成新的令牌对
-	return tm.GenerateTokenPair(claims.UserID, claims.Username, claims.Email, claims.Roles)
+// checkRevoked 检查令牌是否在撤销列表中
+func (tm *TokenManager) checkRevoked(ctx context.Context, claims *Claims) error {
+	if claims.ID == "" {
+		return nil
+	}
+	revoked, err := tm.revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRevocationUnavailable, err)
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
 }
 
 // LoadKeysFromFile 从文件加载密钥
+//
+// 私钥支持 PKCS#1、PKCS#8 和 SEC 1 格式，类型必须与 SigningMethod 一致。
+// 加载的密钥加入密钥环并成为活动密钥，之前的密钥保留用于验证。
 func (tm *TokenManager) LoadKeysFromFile(privateKeyPath, publicKeyPath string) error {
 	// 加载私钥
 	privateKeyData, err := os.ReadFile(privateKeyPath)
@@ -196,25 +516,10 @@ func (tm *TokenManager) LoadKeysFromFile(privateKeyPath, publicKeyPath string) e
 		return fmt.Errorf("failed to read private key: %w", err)
 	}
 
-	privateKeyBlock, _ := pem.Decode(privateKeyData)
-	if privateKeyBlock == nil {
-		return errors.New("failed to decode private key PEM")
-	}
-
-	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBlock.Bytes)
+	privateKey, err := ParsePrivateKeyPEM(privateKeyData)
 	if err != nil {
-		// 尝试 PKCS8 格式
-		key, err := x509.ParsePKCS8PrivateKey(privateKeyBlock.Bytes)
-		if err != nil {
-			return fmt.Errorf("failed to parse private key: %w", err)
-		}
-		var ok bool
-		privateKey, ok = key.(*rsa.PrivateKey)
-		if !ok {
-			return errors.New("private key is not RSA")
-		}
+		return err
 	}
-	tm.privateKey = privateKey
 
 	// 加载公钥
 	publicKeyData, err := os.ReadFile(publicKeyPath)
@@ -227,32 +532,93 @@ func (tm *TokenManager) LoadKeysFromFile(privateKeyPath, publicKeyPath string) e
 		return errors.New("failed to decode public key PEM")
 	}
 
-	publicKeyInterface, err := x509.ParsePKIXPublicKey(publicKeyBlock.Bytes)
+	publicKey, err := x509.ParsePKIXPublicKey(publicKeyBlock.Bytes)
 	if err != nil {
 		return fmt.Errorf("failed to parse public key: %w", err)
 	}
 
-	publicKey, ok := publicKeyInterface.(*rsa.PublicKey)
-	if !ok {
-		return errors.New("public key is not RSA")
+	matcher, ok := privateKey.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !matcher.Equal(publicKey) {
+		return errors.New("public key does not match private key")
 	}
-	tm.publicKey = publicKey
 
-	return nil
+	return tm.setSigningKey(privateKey)
 }
 
 // GenerateKeys 生成密钥对（仅用于开发/测试）
+//
+// bits 只对 RSA 算法有效，ES256/ES384/EdDSA 生成对应曲线的密钥
 func (tm *TokenManager) GenerateKeys(bits int) error {
-	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	var privateKey crypto.Signer
+	if strings.HasPrefix(tm.algorithm, "RS") {
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return fmt.Errorf("failed to generate private key: %w", err)
+		}
+		privateKey = key
+	} else {
+		key, err := GenerateSigningKey(tm.algorithm)
+		if err != nil {
+			return err
+		}
+		privateKey = key.Private
+	}
+
+	return tm.setSigningKey(privateKey)
+}
+
+// setSigningKey 把私钥加入密钥环并激活
+func (tm *TokenManager) setSigningKey(privateKey crypto.Signer) error {
+	key, err := NewSigningKey(tm.algorithm, privateKey)
 	if err != nil {
-		return fmt.Errorf("failed to generate private key: %w", err)
+		return err
+	}
+	tm.keys.Add(key)
+	if err := tm.keys.Activate(key.ID); err != nil {
+		return err
 	}
 
-	tm.privateKey = privateKey
-	tm.publicKey = &privateKey.PublicKey
+	// ExportKeys 只支持 RSA
+	if rsaKey, ok := privateKey.(*rsa.PrivateKey); ok {
+		tm.privateKey = rsaKey
+		tm.publicKey = &rsaKey.PublicKey
+	}
 	return nil
 }
 
+// ParsePrivateKeyPEM 解析 PEM 编码的私钥，支持 PKCS#1、PKCS#8 和 SEC 1 格式
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode private key PEM")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// MarshalPrivateKeyPEM 把私钥编码为 PKCS#8 PEM
+func MarshalPrivateKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
 // ExportKeys 导出密钥到 PEM 格式
 func (tm *TokenManager) ExportKeys() (privateKeyPEM, publicKeyPEM []byte, err error) {
 	if tm.privateKey == nil || tm.publicKey == nil {
@@ -0,0 +1,329 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JWKSPath JWKS 端点的标准路径
+const JWKSPath = "/.well-known/jwks.json"
+
+// DefaultRSAKeyBits 生成 RSA 签名密钥的默认长度
+const DefaultRSAKeyBits = 2048
+
+var (
+	// ErrNoSigningKey 密钥环中没有可用于签名的密钥
+	ErrNoSigningKey = errors.New("no active signing key")
+	// ErrUnknownKeyID 令牌的 kid 不在密钥环中
+	ErrUnknownKeyID = errors.New("unknown key id")
+	// ErrUnsupportedAlgorithm 不支持的签名算法，或者算法与密钥类型不匹配
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+)
+
+// SigningKey 签名密钥
+//
+// ID 是 JWT 头部的 kid，默认使用公钥的 RFC 7638 指纹，同一个公钥在所有副本上得到相同的 kid。
+// Private 为 nil 的密钥只能用于验证，如从其他服务导入的公钥。
+type SigningKey struct {
+	ID        string
+	Algorithm string // RS256、RS384、RS512、ES256、ES384、EdDSA
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	CreatedAt time.Time
+}
+
+// GenerateSigningKey 生成签名密钥
+//
+// RS 系列生成 DefaultRSAKeyBits 位 RSA 密钥，ES256/ES384 生成 P-256/P-384 密钥，EdDSA 生成 Ed25519 密钥
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+	switch alg {
+	case "RS256", "RS384", "RS512":
+		private, err = rsa.GenerateKey(rand.Reader, DefaultRSAKeyBits)
+	case "ES256":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ES384":
+		private, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", alg, err)
+	}
+	return NewSigningKey(alg, private)
+}
+
+// NewSigningKey 使用已有私钥创建签名密钥，kid 为公钥指纹
+//
+// 返回：
+//   - error: 算法与密钥类型不匹配时返回 ErrUnsupportedAlgorithm
+func NewSigningKey(alg string, private crypto.Signer) (*SigningKey, error) {
+	key, err := NewVerificationKey(alg, private.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.Private = private
+	return key, nil
+}
+
+// NewVerificationKey 创建只用于验证的密钥，kid 为公钥指纹
+func NewVerificationKey(alg string, public crypto.PublicKey) (*SigningKey, error) {
+	if err := checkAlgorithm(alg, public); err != nil {
+		return nil, err
+	}
+	key := &SigningKey{Algorithm: alg, Public: public, CreatedAt: time.Now()}
+	jwk, err := key.JWK()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = jwk.Thumbprint()
+	return key, nil
+}
+
+// checkAlgorithm 检查算法与公钥类型是否匹配
+func checkAlgorithm(alg string, public crypto.PublicKey) error {
+	ok := false
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		ok = alg == "RS256" || alg == "RS384" || alg == "RS512"
+	case *ecdsa.PublicKey:
+		ok = (alg == "ES256" && pub.Curve == elliptic.P256()) || (alg == "ES384" && pub.Curve == elliptic.P384())
+	case ed25519.PublicKey:
+		ok = alg == "EdDSA"
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s with %T", ErrUnsupportedAlgorithm, alg, public)
+	}
+	return nil
+}
+
+// JWK JSON Web Key（RFC 7517）公钥表示
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC / OKP
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKSet JWK 集合，即 /.well-known/jwks.json 的响应
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK 返回公钥的 JWK 表示
+func (k *SigningKey) JWK() (JWK, error) {
+	jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+	switch pub := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, fmt.Errorf("failed to encode EC key: %w", err)
+		}
+		// 非压缩格式：0x04 || X || Y
+		point := ecdhKey.Bytes()[1:]
+		size := len(point) / 2
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = b64(point[:size])
+		jwk.Y = b64(point[size:])
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = b64(pub)
+	default:
+		return JWK{}, fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, k.Public)
+	}
+	return jwk, nil
+}
+
+// Thumbprint 返回 RFC 7638 JWK 指纹（SHA-256，base64url）
+func (j JWK) Thumbprint() string {
+	// 只包含必需成员，按字典序排列
+	var members string
+	switch j.KeyType {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, j.E, j.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, j.Curve, j.X, j.Y)
+	default:
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, j.Curve, j.KeyType, j.X)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return b64(sum[:])
+}
+
+// b64 base64url 编码（无填充）
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// KeyRing 签名密钥环
+//
+// 设计原理：
+// 1. 同一时间只有一个活动密钥用于签名，签名时在 JWT 头部写入 kid
+// 2. 验证时按 kid 选择密钥，轮换后旧密钥签发的令牌在旧密钥移除前仍然有效
+// 3. 所有密钥的公钥通过 JWKS 发布，其他服务可以按 kid 验证令牌
+//
+// 使用场景：
+// - 手动管理：Add 新密钥，等待其他服务刷新 JWKS 后 Activate，旧令牌全部过期后 Remove 旧密钥
+// - 自动轮换：使用 KeyRotator 定期生成、激活和移除密钥
+type KeyRing struct {
+	mu     sync.RWMutex
+	keys   map[string]*SigningKey
+	active string
+}
+
+// NewKeyRing 创建密钥环
+//
+// 参数：
+//   - keys: 初始密钥，第一个带私钥的密钥成为活动密钥
+func NewKeyRing(keys ...*SigningKey) *KeyRing {
+	r := &KeyRing{keys: make(map[string]*SigningKey)}
+	for _, key := range keys {
+		r.Add(key)
+		if r.active == "" && key.Private != nil {
+			r.active = key.ID
+		}
+	}
+	return r
+}
+
+// Add 添加密钥，已存在相同 kid 时覆盖；新密钥只用于验证，直到调用 Activate
+func (r *KeyRing) Add(key *SigningKey) {
+	r.mu.Lock()
+	r.keys[key.ID] = key
+	r.mu.Unlock()
+}
+
+// Activate 把 kid 对应的密钥设为签名密钥
+func (r *KeyRing) Activate(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKeyID, kid)
+	}
+	if key.Private == nil {
+		return fmt.Errorf("%w: key %s has no private key", ErrNoSigningKey, kid)
+	}
+	r.active = kid
+	return nil
+}
+
+// Remove 移除密钥，该密钥签发的令牌将无法验证；不能移除活动密钥
+func (r *KeyRing) Remove(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if kid == r.active {
+		return fmt.Errorf("cannot remove active signing key %s", kid)
+	}
+	delete(r.keys, kid)
+	return nil
+}
+
+// Active 返回当前的签名密钥
+func (r *KeyRing) Active() (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[r.active]
+	if !ok {
+		return nil, ErrNoSigningKey
+	}
+	return key, nil
+}
+
+// Get 按 kid 获取密钥
+func (r *KeyRing) Get(kid string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[kid]
+	return key, ok
+}
+
+// Keys 返回所有密钥，按创建时间从新到旧排列
+func (r *KeyRing) Keys() []*SigningKey {
+	r.mu.RLock()
+	keys := make([]*SigningKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys
+}
+
+// JWKS 返回所有密钥的公钥集合
+func (r *KeyRing) JWKS() (JWKSet, error) {
+	set := JWKSet{Keys: make([]JWK, 0)}
+	for _, key := range r.Keys() {
+		jwk, err := key.JWK()
+		if err != nil {
+			return JWKSet{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// Handler 返回 JWKS 端点的处理器，通常挂载在 JWKSPath
+//
+// 响应允许缓存 5 分钟；轮换时新密钥应至少提前这么久加入密钥环再激活
+func (r *KeyRing) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		set, err := r.JWKS()
+		if err != nil {
+			http.Error(w, "failed to encode keys", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_ = json.NewEncoder(w).Encode(set)
+	})
+}
@@ -0,0 +1,190 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_Algorithms(t *testing.T) {
+	for _, alg := range []string{"RS256", "ES256", "ES384", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			tm, err := NewTokenManager(Config{SigningMethod: alg})
+			require.NoError(t, err)
+
+			token, err := tm.GenerateAccessToken("user-123", "john", "john@example.com", []string{"user"})
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			active, err := tm.KeyRing().Active()
+			require.NoError(t, err)
+			assert.Equal(t, alg, parsed.Method.Alg())
+			assert.Equal(t, active.ID, parsed.Header["kid"])
+
+			claims, err := tm.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-123", claims.UserID)
+		})
+	}
+
+	_, err := NewTokenManager(Config{SigningMethod: "HS256"})
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestKeyRing_RotationWithoutDowntime(t *testing.T) {
+	oldKey, err := GenerateSigningKey("RS256")
+	require.NoError(t, err)
+	ring := NewKeyRing(oldKey)
+	tm, err := NewTokenManager(Config{KeyRing: ring})
+	require.NoError(t, err)
+
+	oldToken, err := tm.GenerateAccessToken("user-123", "john", "john@example.com", nil)
+	require.NoError(t, err)
+
+	// 新密钥先发布再激活
+	newKey, err := GenerateSigningKey("EdDSA")
+	require.NoError(t, err)
+	ring.Add(newKey)
+	require.NoError(t, ring.Activate(newKey.ID))
+
+	newToken, err := tm.GenerateAccessToken("user-123", "john", "john@example.com", nil)
+	require.NoError(t, err)
+	_, err = tm.ValidateToken(oldToken)
+	assert.NoError(t, err, "tokens signed by the previous key stay valid")
+	_, err = tm.ValidateToken(newToken)
+	assert.NoError(t, err)
+
+	// 不能移除活动密钥；移除旧密钥后旧令牌失效
+	assert.Error(t, ring.Remove(newKey.ID))
+	require.NoError(t, ring.Remove(oldKey.ID))
+	_, err = tm.ValidateToken(oldToken)
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestTokenManager_RejectsAlgorithmMismatch(t *testing.T) {
+	tm, err := NewTokenManager(Config{SigningMethod: "ES256"})
+	require.NoError(t, err)
+	active, err := tm.KeyRing().Active()
+	require.NoError(t, err)
+
+	// 使用公钥作为 HMAC 密钥伪造令牌
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: "attacker"})
+	forged.Header["kid"] = active.ID
+	token, err := forged.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = tm.ValidateToken(token)
+	assert.Error(t, err)
+}
+
+func TestTokenManager_LegacyTokenWithoutKid(t *testing.T) {
+	tm, err := NewTokenManager(Config{})
+	require.NoError(t, err)
+
+	legacy := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{UserID: "user-123"})
+	token, err := legacy.SignedString(tm.privateKey)
+	require.NoError(t, err)
+
+	claims, err := tm.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.UserID)
+}
+
+func TestKeyRing_JWKSHandler(t *testing.T) {
+	rsaKey, err := GenerateSigningKey("RS256")
+	require.NoError(t, err)
+	ecKey, err := GenerateSigningKey("ES256")
+	require.NoError(t, err)
+	edKey, err := GenerateSigningKey("EdDSA")
+	require.NoError(t, err)
+	ring := NewKeyRing(ecKey, rsaKey, edKey)
+	tm, err := NewTokenManager(Config{KeyRing: ring})
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	tm.JWKSHandler().ServeHTTP(rr, httptest.NewRequest("GET", JWKSPath, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rr.Header().Get("Cache-Control"))
+
+	var set JWKSet
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 3)
+
+	// 其他服务只凭 JWKS 就可以验证令牌
+	published := make(map[string]JWK)
+	for _, jwk := range set.Keys {
+		published[jwk.KeyID] = jwk
+		assert.Equal(t, "sig", jwk.Use)
+	}
+	for _, key := range []*SigningKey{rsaKey, ecKey, edKey} {
+		require.NoError(t, ring.Activate(key.ID))
+		token, err := tm.GenerateAccessToken("user-123", "john", "john@example.com", nil)
+		require.NoError(t, err)
+
+		parsed, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+			jwk := published[token.Header["kid"].(string)]
+			assert.Equal(t, token.Method.Alg(), jwk.Algorithm)
+			return publicKeyFromJWK(t, jwk), nil
+		})
+		require.NoError(t, err, key.Algorithm)
+		assert.True(t, parsed.Valid)
+	}
+
+	rr = httptest.NewRecorder()
+	tm.JWKSHandler().ServeHTTP(rr, httptest.NewRequest("POST", JWKSPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestSigningKey_StableKeyID(t *testing.T) {
+	key, err := GenerateSigningKey("ES256")
+	require.NoError(t, err)
+
+	same, err := NewSigningKey("ES256", key.Private)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, same.ID)
+
+	_, err = NewSigningKey("ES384", key.Private)
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	_, err = NewSigningKey("RS256", key.Private)
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+// publicKeyFromJWK 从 JWK 还原公钥，模拟其他服务的验证逻辑
+func publicKeyFromJWK(t *testing.T, jwk JWK) interface{} {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+	switch jwk.KeyType {
+	case "RSA":
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(decode(jwk.N)),
+			E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64()),
+		}
+	case "EC":
+		require.Equal(t, "P-256", jwk.Curve)
+		point := append([]byte{4}, append(decode(jwk.X), decode(jwk.Y)...)...)
+		key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		require.NoError(t, err)
+		return key
+	case "OKP":
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+	t.Fatalf("unexpected key type %s", jwk.KeyType)
+	return nil
+}
@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -41,6 +42,8 @@ func NewMiddleware(cfg MiddlewareConfig) *Middleware {
 }
 
 // Authenticate JWT 认证中间件
+//
+// 只接受访问令牌：刷新令牌和已撤销的令牌返回 401，撤销列表不可用时返回 503
 func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// 检查是否跳过认证
@@ -65,8 +68,13 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 
 		tokenString := parts[1]
 
-		// 验证令牌
-		claims, err := m.tokenManager.ValidateToken(tokenString)
+		// 验证令牌，并检查令牌类型和撤销列表
+		claims, err := m.tokenManager.VerifyAccessToken(r.Context(), tokenString)
+		if errors.Is(err, ErrRevocationUnavailable) {
+			// 无法确认令牌是否已撤销时拒绝请求
+			http.Error(w, "Service Unavailable: token revocation check failed", http.StatusServiceUnavailable)
+			return
+		}
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Unauthorized: %v", err), http.StatusUnauthorized)
 			return
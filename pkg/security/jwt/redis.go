@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKeyPrefix Redis 存储的默认键前缀
+const DefaultRedisKeyPrefix = "jwt:"
+
+// redisStoreOptions Redis 存储的可选配置
+type redisStoreOptions struct {
+	prefix string
+}
+
+// RedisStoreOption Redis 存储配置选项
+type RedisStoreOption func(*redisStoreOptions)
+
+// WithRedisKeyPrefix 设置键前缀，默认 DefaultRedisKeyPrefix
+func WithRedisKeyPrefix(prefix string) RedisStoreOption {
+	return func(o *redisStoreOptions) {
+		o.prefix = prefix
+	}
+}
+
+// newRedisStoreOptions 应用选项
+func newRedisStoreOptions(opts []RedisStoreOption) redisStoreOptions {
+	o := redisStoreOptions{prefix: DefaultRedisKeyPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// RedisRevocationStore 基于 Redis 的撤销列表
+//
+// 每个撤销的 jti 保存为 <prefix>revoked:<jti>，在令牌过期时由 Redis 删除
+type RedisRevocationStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisRevocationStore 创建 Redis 撤销列表
+//
+// 参数：
+//   - client: Redis 客户端，由调用方负责关闭
+//   - opts: 可选配置，如 WithRedisKeyPrefix
+func NewRedisRevocationStore(client redis.UniversalClient, opts ...RedisStoreOption) *RedisRevocationStore {
+	o := newRedisStoreOptions(opts)
+	return &RedisRevocationStore{client: client, prefix: o.prefix + "revoked:"}
+}
+
+// Revoke 撤销 jti，已过期的令牌不需要记录
+func (s *RedisRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if !time.Now().Before(expiresAt) {
+		return nil
+	}
+	return s.client.SetArgs(ctx, s.prefix+jti, 1, redis.SetArgs{ExpireAt: expiresAt}).Err()
+}
+
+// IsRevoked 返回 jti 是否已撤销
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.prefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// rotateFamilyScript 原子地轮换令牌族的当前 jti
+//
+// KEYS[1]: 令牌族哈希
+// ARGV[1]: 旧 jti
+// ARGV[2]: 新 jti
+// ARGV[3]: 新的过期时间（Unix 毫秒）
+//
+// 返回 1 表示成功，0 表示检测到重用（已撤销整个族），-1 表示族不存在或已撤销
+var rotateFamilyScript = redis.NewScript(`
+local current = redis.call("HGET", KEYS[1], "current")
+if not current or redis.call("HGET", KEYS[1], "revoked") == "1" then
+	return -1
+end
+if current ~= ARGV[1] then
+	redis.call("HSET", KEYS[1], "revoked", "1")
+	return 0
+end
+redis.call("HSET", KEYS[1], "current", ARGV[2])
+redis.call("PEXPIREAT", KEYS[1], ARGV[3])
+return 1
+`)
+
+// revokeFamilyScript 标记令牌族已撤销，不为不存在的族创建没有过期时间的键
+var revokeFamilyScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("HSET", KEYS[1], "revoked", "1")
+end
+return 0
+`)
+
+// RedisFamilyStore 基于 Redis 的刷新令牌族存储
+//
+// 设计原理：
+// 1. 每个族保存为哈希 <prefix>family:<id>，字段 user、current、revoked
+// 2. Rotate 通过 Lua 脚本原子地比较并替换 current，并发刷新时只有一个请求成功
+// 3. 哈希的过期时间与族内最新刷新令牌的过期时间一致，撤销的族保留到过期，用于识别重用
+type RedisFamilyStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisFamilyStore 创建 Redis 刷新令牌族存储
+//
+// 参数：
+//   - client: Redis 客户端，由调用方负责关闭
+//   - opts: 可选配置，如 WithRedisKeyPrefix
+func NewRedisFamilyStore(client redis.UniversalClient, opts ...RedisStoreOption) *RedisFamilyStore {
+	o := newRedisStoreOptions(opts)
+	return &RedisFamilyStore{client: client, prefix: o.prefix + "family:"}
+}
+
+// Create 创建令牌族
+func (s *RedisFamilyStore) Create(ctx context.Context, familyID, userID, jti string, expiresAt time.Time) error {
+	key := s.prefix + familyID
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "user", userID, "current", jti, "revoked", "0")
+		pipe.ExpireAt(ctx, key, expiresAt)
+		return nil
+	})
+	return err
+}
+
+// Rotate 轮换令牌族的当前 jti
+func (s *RedisFamilyStore) Rotate(ctx context.Context, familyID, oldJTI, newJTI string, expiresAt time.Time) error {
+	result, err := rotateFamilyScript.Run(ctx, s.client, []string{s.prefix + familyID},
+		oldJTI, newJTI, expiresAt.UnixMilli()).Int()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case 1:
+		return nil
+	case 0:
+		return ErrRefreshTokenReused
+	default:
+		return ErrTokenRevoked
+	}
+}
+
+// Revoke 撤销令牌族
+func (s *RedisFamilyStore) Revoke(ctx context.Context, familyID string) error {
+	return revokeFamilyScript.Run(ctx, s.client, []string{s.prefix + familyID}).Err()
+}
@@ -0,0 +1,97 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRedis 启动 miniredis 并创建客户端
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func TestRedisRevocationStore(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisRevocationStore(client, WithRedisKeyPrefix("auth:"))
+	ctx := context.Background()
+
+	require.NoError(t, store.Revoke(ctx, "jti-1", time.Now().Add(time.Minute)))
+	revoked, err := store.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	assert.True(t, mr.Exists("auth:revoked:jti-1"))
+
+	// 已过期的令牌不需要记录
+	require.NoError(t, store.Revoke(ctx, "jti-2", time.Now().Add(-time.Minute)))
+	assert.False(t, mr.Exists("auth:revoked:jti-2"))
+
+	mr.FastForward(2 * time.Minute)
+	revoked, err = store.IsRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRedisFamilyStore(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisFamilyStore(client)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	require.NoError(t, store.Create(ctx, "fam-1", "user-123", "jti-1", expiresAt))
+	assert.Greater(t, mr.TTL("jwt:family:fam-1"), time.Duration(0))
+
+	require.NoError(t, store.Rotate(ctx, "fam-1", "jti-1", "jti-2", expiresAt))
+	assert.ErrorIs(t, store.Rotate(ctx, "fam-1", "jti-1", "jti-3", expiresAt), ErrRefreshTokenReused)
+	assert.ErrorIs(t, store.Rotate(ctx, "fam-1", "jti-2", "jti-3", expiresAt), ErrTokenRevoked)
+	assert.ErrorIs(t, store.Rotate(ctx, "missing", "jti-1", "jti-2", expiresAt), ErrTokenRevoked)
+
+	// 撤销不存在的族不创建键
+	require.NoError(t, store.Revoke(ctx, "missing"))
+	assert.False(t, mr.Exists("jwt:family:missing"))
+
+	require.NoError(t, store.Create(ctx, "fam-2", "user-123", "jti-1", expiresAt))
+	require.NoError(t, store.Revoke(ctx, "fam-2"))
+	assert.ErrorIs(t, store.Rotate(ctx, "fam-2", "jti-1", "jti-2", expiresAt), ErrTokenRevoked)
+}
+
+func TestTokenManager_SharedRedisStores(t *testing.T) {
+	_, client := newTestRedis(t)
+	key, err := GenerateSigningKey("ES256")
+	require.NoError(t, err)
+
+	// 两个副本使用相同的密钥和存储
+	cfg := Config{
+		KeyRing:         NewKeyRing(key),
+		RevocationStore: NewRedisRevocationStore(client),
+		FamilyStore:     NewRedisFamilyStore(client),
+	}
+	a, err := NewTokenManager(cfg)
+	require.NoError(t, err)
+	b, err := NewTokenManager(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	pair, err := a.GenerateTokenPair("user-123", "john", "john@example.com", []string{"user"})
+	require.NoError(t, err)
+
+	// 在 b 上刷新后，旧令牌在 a 上被识别为重用
+	_, err = b.RefreshAccessTokenContext(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	_, err = a.RefreshAccessTokenContext(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// 在 a 上撤销的访问令牌在 b 上失效
+	require.NoError(t, a.RevokeToken(ctx, pair.AccessToken))
+	_, err = b.VerifyAccessToken(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
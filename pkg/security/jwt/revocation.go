@@ -0,0 +1,169 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTokenRevoked 令牌已撤销（包括所属的刷新令牌族已撤销）
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrRefreshTokenReused 检测到已轮换的刷新令牌被再次使用，整个令牌族已撤销
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrWrongTokenType 令牌类型不符，如使用刷新令牌访问接口或使用访问令牌刷新
+	ErrWrongTokenType = errors.New("wrong token type")
+	// ErrRevocationUnavailable 撤销列表不可用，无法确认令牌是否已撤销
+	ErrRevocationUnavailable = errors.New("revocation store unavailable")
+)
+
+// memorySweepInterval 内存存储清理过期记录的最小间隔
+const memorySweepInterval = time.Minute
+
+// RevocationStore 按 jti 记录已撤销的令牌
+//
+// 撤销记录只需要保留到令牌过期，过期的令牌本身就无法通过验证。
+// 多副本部署时使用共享存储（如 RedisRevocationStore），任一副本撤销的令牌在所有副本上立即失效。
+type RevocationStore interface {
+	// Revoke 撤销 jti，记录保留到 expiresAt
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	// IsRevoked 返回 jti 是否已撤销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// FamilyStore 刷新令牌族存储
+//
+// 同一次登录产生的刷新令牌属于同一个族，族只记录当前有效的刷新令牌 jti。
+// 每次刷新把当前 jti 换成新令牌的 jti，旧的刷新令牌随即失效。
+// 已轮换的旧令牌再次出现说明令牌可能被盗用，此时撤销整个族，合法用户和攻击者都需要重新登录。
+type FamilyStore interface {
+	// Create 创建令牌族，jti 为第一个刷新令牌，族记录保留到 expiresAt
+	Create(ctx context.Context, familyID, userID, jti string, expiresAt time.Time) error
+	// Rotate 原子地把族的当前 jti 从 oldJTI 换成 newJTI，并把保留时间延长到 expiresAt
+	//
+	// 当前 jti 不是 oldJTI 时必须撤销整个族并返回 ErrRefreshTokenReused；
+	// 族不存在、已过期或已撤销时返回 ErrTokenRevoked
+	Rotate(ctx context.Context, familyID, oldJTI, newJTI string, expiresAt time.Time) error
+	// Revoke 撤销令牌族，族不存在时不返回错误
+	Revoke(ctx context.Context, familyID string) error
+}
+
+// MemoryRevocationStore 进程内撤销列表
+//
+// 过期记录在 Revoke 时按 memorySweepInterval 的间隔顺带清理，不需要后台协程。
+// 仅适用于单实例部署。
+type MemoryRevocationStore struct {
+	mu        sync.Mutex
+	revoked   map[string]time.Time
+	now       func() time.Time
+	nextSweep time.Time
+}
+
+// NewMemoryRevocationStore 创建进程内撤销列表
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Revoke 撤销 jti
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.After(s.nextSweep) {
+		for id, exp := range s.revoked {
+			if now.After(exp) {
+				delete(s.revoked, id)
+			}
+		}
+		s.nextSweep = now.Add(memorySweepInterval)
+	}
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked 返回 jti 是否已撤销
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exp, ok := s.revoked[jti]
+	return ok && !s.now().After(exp), nil
+}
+
+// tokenFamily 内存中的令牌族
+type tokenFamily struct {
+	userID    string
+	current   string
+	expiresAt time.Time
+	revoked   bool
+}
+
+// MemoryFamilyStore 进程内刷新令牌族存储
+//
+// 过期的族在 Create 时按 memorySweepInterval 的间隔顺带清理。仅适用于单实例部署。
+type MemoryFamilyStore struct {
+	mu        sync.Mutex
+	families  map[string]*tokenFamily
+	now       func() time.Time
+	nextSweep time.Time
+}
+
+// NewMemoryFamilyStore 创建进程内刷新令牌族存储
+func NewMemoryFamilyStore() *MemoryFamilyStore {
+	return &MemoryFamilyStore{
+		families: make(map[string]*tokenFamily),
+		now:      time.Now,
+	}
+}
+
+// Create 创建令牌族
+func (s *MemoryFamilyStore) Create(ctx context.Context, familyID, userID, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.After(s.nextSweep) {
+		for id, family := range s.families {
+			if now.After(family.expiresAt) {
+				delete(s.families, id)
+			}
+		}
+		s.nextSweep = now.Add(memorySweepInterval)
+	}
+	s.families[familyID] = &tokenFamily{userID: userID, current: jti, expiresAt: expiresAt}
+	return nil
+}
+
+// Rotate 轮换令牌族的当前 jti
+func (s *MemoryFamilyStore) Rotate(ctx context.Context, familyID, oldJTI, newJTI string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	family, ok := s.families[familyID]
+	if !ok || family.revoked || s.now().After(family.expiresAt) {
+		return ErrTokenRevoked
+	}
+	if family.current != oldJTI {
+		family.revoked = true
+		return ErrRefreshTokenReused
+	}
+	family.current = newJTI
+	family.expiresAt = expiresAt
+	return nil
+}
+
+// Revoke 撤销令牌族
+func (s *MemoryFamilyStore) Revoke(ctx context.Context, familyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if family, ok := s.families[familyID]; ok {
+		family.revoked = true
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManager_RefreshRotation(t *testing.T) {
+	tm, err := NewTokenManager(Config{})
+	require.NoError(t, err)
+
+	pair, err := tm.GenerateTokenPair("user-123", "john", "john@example.com", []string{"user"})
+	require.NoError(t, err)
+
+	first, err := tm.ValidateToken(pair.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.NotEmpty(t, first.Family)
+
+	rotated, err := tm.RefreshAccessToken(pair.RefreshToken)
+	require.NoError(t, err)
+	second, err := tm.ValidateToken(rotated.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, first.Family, second.Family)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	// 新的刷新令牌可以继续使用
+	third, err := tm.RefreshAccessToken(rotated.RefreshToken)
+	require.NoError(t, err)
+
+	// 重用已轮换的令牌会撤销整个族
+	_, err = tm.RefreshAccessToken(pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = tm.RefreshAccessToken(third.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 其他登录不受影响
+	other, err := tm.GenerateTokenPair("user-123", "john", "john@example.com", []string{"user"})
+	require.NoError(t, err)
+	_, err = tm.RefreshAccessToken(other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestTokenManager_WrongTokenType(t *testing.T) {
+	tm, err := NewTokenManager(Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	pair, err := tm.GenerateTokenPair("user-123", "john", "john@example.com", []string{"user"})
+	require.NoError(t, err)
+
+	_, err = tm.RefreshAccessToken(pair.AccessToken)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+	_, err = tm.VerifyAccessToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+
+	claims, err := tm.VerifyAccessToken(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.UserID)
+	assert.Equal(t, TokenTypeAccess, claims.Type)
+}
+
+func TestTokenManager_LegacyTokenType(t *testing.T) {
+	tm, err := NewTokenManager(Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 引入 typ 和 fam 声明之前签发的令牌，只能通过有效期区分
+	legacy := func(ttl time.Duration) string {
+		now := time.Now()
+		token, err := tm.sign(Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "user-123",
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			},
+			UserID: "user-123",
+		})
+		require.NoError(t, err)
+		return token
+	}
+
+	_, err = tm.VerifyAccessToken(ctx, legacy(tm.refreshTokenTTL))
+	assert.ErrorIs(t, err, ErrWrongTokenType)
+
+	claims, err := tm.VerifyAccessToken(ctx, legacy(tm.accessTokenTTL))
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.UserID)
+}
+
+func TestTokenManager_RevokeToken(t *testing.T) {
+	tm, err := NewTokenManager(Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	pair, err := tm.GenerateTokenPair("user-123", "john", "john@example.com", []string{"user"})
+	require.NoError(t, err)
+
+	// 撤销访问令牌
+	require.NoError(t, tm.RevokeToken(ctx, pair.AccessToken))
+	_, err = tm.VerifyAccessToken(ctx, pair.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 撤销刷新令牌（登出）同时撤销令牌族
+	rotated, err := tm.RefreshAccessToken(pair.RefreshToken)
+	require.NoError(t, err)
+	require.NoError(t, tm.RevokeToken(ctx, rotated.RefreshToken))
+	_, err = tm.RefreshAccessToken(rotated.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 按 jti 撤销
+	claims, err := tm.VerifyAccessToken(ctx, rotated.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, tm.RevokeJTI(ctx, claims.ID, claims.ExpiresAt.Time))
+	_, err = tm.VerifyAccessToken(ctx, rotated.AccessToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestMemoryStores_Expiration(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	ctx := context.Background()
+
+	revocations := NewMemoryRevocationStore()
+	revocations.now = clock
+	require.NoError(t, revocations.Revoke(ctx, "old", now.Add(time.Second)))
+
+	families := NewMemoryFamilyStore()
+	families.now = clock
+	require.NoError(t, families.Create(ctx, "fam-1", "user-123", "jti-1", now.Add(time.Second)))
+
+	now = now.Add(2 * time.Minute)
+	revoked, err := revocations.IsRevoked(ctx, "old")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	assert.ErrorIs(t, families.Rotate(ctx, "fam-1", "jti-1", "jti-2", now.Add(time.Hour)), ErrTokenRevoked)
+
+	// 下一次写入时清理过期记录
+	require.NoError(t, revocations.Revoke(ctx, "new", now.Add(time.Hour)))
+	require.NoError(t, families.Create(ctx, "fam-2", "user-123", "jti-1", now.Add(time.Hour)))
+	assert.Len(t, revocations.revoked, 1)
+	assert.Len(t, families.families, 1)
+}
+
+// failingRevocationStore 始终返回错误的撤销列表
+type failingRevocationStore struct{}
+
+func (failingRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	return errors.New("store down")
+}
+
+func (failingRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, errors.New("store down")
+}
+
+func TestMiddleware_Authenticate_Revocation(t *testing.T) {
+	tm, err := NewTokenManager(Config{})
+	require.NoError(t, err)
+	m := NewMiddleware(MiddlewareConfig{TokenManager: tm})
+	handler := m.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	pair, err := tm.GenerateTokenPair("user-123", "john", "john@example.com", []string{"user"})
+	require.NoError(t, err)
+	serve := func(token string) int {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(pair.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, serve(pair.RefreshToken))
+
+	require.NoError(t, tm.RevokeToken(context.Background(), pair.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, serve(pair.AccessToken))
+
+	// 撤销列表不可用时拒绝请求
+	failing, err := NewTokenManager(Config{RevocationStore: failingRevocationStore{}})
+	require.NoError(t, err)
+	token, err := failing.GenerateAccessToken("user-123", "john", "john@example.com", nil)
+	require.NoError(t, err)
+	m.tokenManager = failing
+	assert.Equal(t, http.StatusServiceUnavailable, serve(token))
+}
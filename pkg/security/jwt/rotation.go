@@ -0,0 +1,288 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yourusername/golang/pkg/security"
+)
+
+// KeyRotator 默认配置
+const (
+	DefaultRotationInterval   = 30 * 24 * time.Hour
+	DefaultKeyRetention       = 8 * 24 * time.Hour
+	DefaultKeyActivationDelay = 10 * time.Minute
+	DefaultRotationCheck      = time.Minute
+	DefaultSigningKeyName     = "jwt-signing"
+)
+
+// rotatorOptions KeyRotator 的可选配置
+type rotatorOptions struct {
+	algorithm       string
+	name            string
+	interval        time.Duration
+	retention       time.Duration
+	activationDelay time.Duration
+	checkInterval   time.Duration
+}
+
+// RotatorOption KeyRotator 配置选项
+type RotatorOption func(*rotatorOptions)
+
+// WithRotationAlgorithm 设置新密钥的签名算法，默认 RS256
+func WithRotationAlgorithm(alg string) RotatorOption {
+	return func(o *rotatorOptions) {
+		o.algorithm = alg
+	}
+}
+
+// WithRotationInterval 设置生成新密钥的间隔，默认 DefaultRotationInterval
+func WithRotationInterval(interval time.Duration) RotatorOption {
+	return func(o *rotatorOptions) {
+		o.interval = interval
+	}
+}
+
+// WithKeyRetention 设置密钥停止签名后继续保留用于验证的时间，默认 DefaultKeyRetention
+//
+// 必须大于激活延迟加上最长的令牌有效期（通常是刷新令牌），否则旧令牌会提前失效
+func WithKeyRetention(retention time.Duration) RotatorOption {
+	return func(o *rotatorOptions) {
+		o.retention = retention
+	}
+}
+
+// WithKeyActivationDelay 设置新密钥从发布到开始签名的延迟，默认 DefaultKeyActivationDelay
+//
+// 应当不小于其他服务缓存 JWKS 的时间，保证它们在看到新 kid 的令牌之前已经获取新公钥
+func WithKeyActivationDelay(delay time.Duration) RotatorOption {
+	return func(o *rotatorOptions) {
+		o.activationDelay = delay
+	}
+}
+
+// WithRotationCheckInterval 设置 Run 同步密钥的间隔，默认 DefaultRotationCheck
+func WithRotationCheckInterval(interval time.Duration) RotatorOption {
+	return func(o *rotatorOptions) {
+		o.checkInterval = interval
+	}
+}
+
+// WithSigningKeyName 设置密钥在 KeyManager 中的名称，默认 DefaultSigningKeyName
+//
+// 同一组副本必须使用相同的名称，不同用途的密钥环使用不同的名称
+func WithSigningKeyName(name string) RotatorOption {
+	return func(o *rotatorOptions) {
+		o.name = name
+	}
+}
+
+// KeyRotator 基于 security.KeyManager 的签名密钥定期轮换
+//
+// 设计原理：
+// 1. 签名密钥以 PKCS#8 PEM 保存在 KeyManager 中，ID 为 kid，ExpiresAt 为密钥的移除时间
+// 2. Sync 从 KeyManager 加载密钥到 KeyRing：最新密钥超过轮换间隔时生成新密钥，过期密钥从两处删除
+// 3. 新密钥先发布到 JWKS，经过激活延迟后才开始签名；旧密钥停止签名后保留到令牌全部过期
+// 4. 多副本共享同一个 KeyStore 时，每个副本定期 Sync，最终使用相同的活动密钥
+//
+// 注意事项：
+// - 多个副本可能同时生成新密钥，多出的密钥只会被发布和保留，不影响正确性
+// - 使用进程内的 MemoryKeyStore 时每个副本有各自的密钥，其他服务需要合并所有副本的 JWKS
+//
+// 示例：
+//
+//	ring := jwt.NewKeyRing()
+//	rotator := jwt.NewKeyRotator(keyManager, ring, jwt.WithRotationAlgorithm("ES256"))
+//	if err := rotator.Sync(ctx); err != nil {
+//	    return err
+//	}
+//	go rotator.Run(ctx)
+//
+//	tm, _ := jwt.NewTokenManager(jwt.Config{KeyRing: ring})
+//	mux.Handle(jwt.JWKSPath, tm.JWKSHandler())
+type KeyRotator struct {
+	keys    *security.KeyManager
+	ring    *KeyRing
+	opts    rotatorOptions
+	now     func() time.Time
+	managed map[string]bool
+}
+
+// NewKeyRotator 创建密钥轮换器
+//
+// 参数：
+//   - keys: 保存签名密钥的 KeyManager，多副本部署时应当使用共享的 KeyStore
+//   - ring: 由轮换器维护的密钥环，通常同时传给 NewTokenManager
+//   - opts: 可选配置，如 WithRotationInterval、WithKeyRetention
+func NewKeyRotator(keys *security.KeyManager, ring *KeyRing, opts ...RotatorOption) *KeyRotator {
+	o := rotatorOptions{
+		algorithm:       "RS256",
+		name:            DefaultSigningKeyName,
+		interval:        DefaultRotationInterval,
+		retention:       DefaultKeyRetention,
+		activationDelay: DefaultKeyActivationDelay,
+		checkInterval:   DefaultRotationCheck,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &KeyRotator{
+		keys:    keys,
+		ring:    ring,
+		opts:    o,
+		now:     time.Now,
+		managed: make(map[string]bool),
+	}
+}
+
+// Run 定期同步密钥，阻塞直到 ctx 取消
+//
+// 同步失败时记录日志并在下一个周期重试，密钥环保持上一次成功同步的状态
+func (r *KeyRotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Sync(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("failed to sync jwt signing keys", "name", r.opts.name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync 从 KeyManager 加载密钥，按需生成新密钥、删除过期密钥，并更新密钥环的活动密钥
+//
+// Sync 不能与同一个轮换器的 Sync 或 Run 并发调用，通常在启动时调用一次后再启动 Run
+func (r *KeyRotator) Sync(ctx context.Context) error {
+	now := r.now()
+	keys, err := r.load(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	// 最新密钥超过轮换间隔时生成新密钥
+	if len(keys) == 0 || now.Sub(keys[0].CreatedAt) >= r.opts.interval {
+		key, err := r.generate(ctx, now)
+		if err != nil {
+			return err
+		}
+		keys = append([]*SigningKey{key}, keys...)
+	}
+
+	// 选择激活延迟之前创建的最新密钥；刚启动只有新密钥时直接使用
+	active := keys[0]
+	for _, key := range keys {
+		if !key.CreatedAt.After(now.Add(-r.opts.activationDelay)) {
+			active = key
+			break
+		}
+	}
+
+	current := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		current[key.ID] = true
+		r.ring.Add(key)
+	}
+	if err := r.ring.Activate(active.ID); err != nil {
+		return err
+	}
+	for kid := range r.managed {
+		if !current[kid] {
+			_ = r.ring.Remove(kid)
+		}
+	}
+	r.managed = current
+	return nil
+}
+
+// load 从 KeyManager 读取未过期的签名密钥，按创建时间从新到旧排列
+func (r *KeyRotator) load(ctx context.Context, now time.Time) ([]*SigningKey, error) {
+	stored, err := r.keys.ListKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+
+	ring := NewKeyRing()
+	for _, k := range stored {
+		if k.Name != r.opts.name {
+			continue
+		}
+		if k.ExpiresAt != nil && now.After(*k.ExpiresAt) {
+			if err := r.keys.DeleteKey(ctx, k.ID); err != nil && !errors.Is(err, security.ErrKeyNotFound) {
+				slog.Warn("failed to delete expired jwt signing key", "kid", k.ID, "error", err)
+			}
+			continue
+		}
+
+		private, err := ParsePrivateKeyPEM(k.Data)
+		if err != nil {
+			slog.Warn("skipping invalid jwt signing key", "kid", k.ID, "error", err)
+			continue
+		}
+		key, err := NewSigningKey(k.Metadata["alg"], private)
+		if err != nil {
+			slog.Warn("skipping invalid jwt signing key", "kid", k.ID, "error", err)
+			continue
+		}
+		key.ID = k.ID
+		key.CreatedAt = k.CreatedAt
+		ring.Add(key)
+	}
+	return ring.Keys(), nil
+}
+
+// generate 生成新密钥并保存到 KeyManager
+func (r *KeyRotator) generate(ctx context.Context, now time.Time) (*SigningKey, error) {
+	key, err := GenerateSigningKey(r.opts.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = now
+
+	data, err := MarshalPrivateKeyPEM(key.Private)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := now.Add(r.opts.interval + r.opts.activationDelay + r.opts.retention)
+	err = r.keys.SaveKey(ctx, &security.Key{
+		ID:        key.ID,
+		Name:      r.opts.name,
+		Type:      keyType(key),
+		Data:      data,
+		Version:   1,
+		CreatedAt: now,
+		ExpiresAt: &expiresAt,
+		Metadata: map[string]string{
+			"alg": key.Algorithm,
+			"use": "sig",
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save signing key: %w", err)
+	}
+	slog.Info("generated jwt signing key", "name", r.opts.name, "kid", key.ID, "alg", key.Algorithm)
+	return key, nil
+}
+
+// keyType 返回密钥在 KeyManager 中的类型
+func keyType(key *SigningKey) security.KeyType {
+	switch key.Public.(type) {
+	case *ecdsa.PublicKey:
+		return security.KeyTypeEC
+	case ed25519.PublicKey:
+		return security.KeyTypeEd25519
+	case *rsa.PublicKey:
+		return security.KeyTypeRSA
+	}
+	return ""
+}
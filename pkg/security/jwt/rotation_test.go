@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/golang/pkg/security"
+)
+
+// newTestRotator 创建使用共享 KeyManager 和可控时钟的轮换器
+func newTestRotator(km *security.KeyManager, now *time.Time) (*KeyRotator, *KeyRing) {
+	ring := NewKeyRing()
+	rotator := NewKeyRotator(km, ring,
+		WithRotationAlgorithm("ES256"),
+		WithRotationInterval(24*time.Hour),
+		WithKeyActivationDelay(time.Hour),
+		WithKeyRetention(48*time.Hour),
+	)
+	rotator.now = func() time.Time { return *now }
+	return rotator, ring
+}
+
+func TestKeyRotator_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	km := security.NewKeyManager(security.NewMemoryKeyStore())
+	rotator, ring := newTestRotator(km, &now)
+	tm, err := NewTokenManager(Config{KeyRing: ring})
+	require.NoError(t, err)
+
+	// 首次同步生成并立即使用第一个密钥
+	require.NoError(t, rotator.Sync(ctx))
+	first, err := ring.Active()
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", first.Algorithm)
+	stored, err := km.GetKey(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSigningKeyName, stored.Name)
+	assert.Equal(t, security.KeyTypeEC, stored.Type)
+
+	oldToken, err := tm.GenerateAccessToken("user-123", "john", "john@example.com", nil)
+	require.NoError(t, err)
+
+	// 到期后生成新密钥，激活延迟内仍然使用旧密钥签名，但新密钥已经发布
+	now = now.Add(25 * time.Hour)
+	require.NoError(t, rotator.Sync(ctx))
+	assert.Len(t, ring.Keys(), 2)
+	active, err := ring.Active()
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, active.ID)
+
+	now = now.Add(time.Hour)
+	require.NoError(t, rotator.Sync(ctx))
+	second, err := ring.Active()
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+	_, err = tm.ValidateToken(oldToken)
+	assert.NoError(t, err, "old tokens stay valid during retention")
+
+	// 保留期结束后旧密钥从密钥环和 KeyManager 中删除
+	now = first.CreatedAt.Add(24*time.Hour + time.Hour + 48*time.Hour + time.Minute)
+	require.NoError(t, rotator.Sync(ctx))
+	_, ok := ring.Get(first.ID)
+	assert.False(t, ok)
+	_, err = km.GetKey(ctx, first.ID)
+	assert.Error(t, err)
+	_, ok = ring.Get(second.ID)
+	assert.True(t, ok)
+}
+
+func TestKeyRotator_SharedAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := security.NewMemoryKeyStore()
+
+	// 两个副本各自的 KeyManager 使用同一个 KeyStore
+	a, ringA := newTestRotator(security.NewKeyManager(store), &now)
+	b, ringB := newTestRotator(security.NewKeyManager(store), &now)
+	require.NoError(t, a.Sync(ctx))
+	require.NoError(t, b.Sync(ctx))
+
+	activeA, err := ringA.Active()
+	require.NoError(t, err)
+	activeB, err := ringB.Active()
+	require.NoError(t, err)
+	assert.Equal(t, activeA.ID, activeB.ID)
+
+	tmA, err := NewTokenManager(Config{KeyRing: ringA})
+	require.NoError(t, err)
+	tmB, err := NewTokenManager(Config{KeyRing: ringB})
+	require.NoError(t, err)
+	token, err := tmA.GenerateAccessToken("user-123", "john", "john@example.com", nil)
+	require.NoError(t, err)
+	_, err = tmB.ValidateToken(token)
+	assert.NoError(t, err)
+}
+
+func TestKeyRotator_RunStopsOnCancel(t *testing.T) {
+	ring := NewKeyRing()
+	rotator := NewKeyRotator(security.NewKeyManager(nil), ring,
+		WithRotationAlgorithm("EdDSA"), WithRotationCheckInterval(10*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		rotator.Run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := ring.Active()
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
@@ -49,6 +49,10 @@ const (
 	KeyTypeRSA KeyType = "rsa"
 	// KeyTypeHMAC HMAC 密钥
 	KeyTypeHMAC KeyType = "hmac"
+	// KeyTypeEC ECDSA 密钥对
+	KeyTypeEC KeyType = "ec"
+	// KeyTypeEd25519 Ed25519 密钥对
+	KeyTypeEd25519 KeyType = "ed25519"
 )
 
 // KeyStore 密钥存储接口
@@ -0,0 +1,220 @@
+package mfa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// COSE 算法标识（RFC 9053）
+const (
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+)
+
+// errMalformedCBOR CBOR 数据格式错误
+var errMalformedCBOR = errors.New("malformed cbor")
+
+// cborMaxDepth 嵌套层数上限，防止恶意数据耗尽栈空间
+const cborMaxDepth = 16
+
+// decodeCBOR 解码一个 CBOR 数据项（RFC 8949），返回值和剩余的字节
+//
+// 只支持 WebAuthn 用到的子集：整数统一为 int64，字节串为 []byte，文本为 string，
+// 数组为 []interface{}，映射为 map[interface{}]interface{}；不支持不定长编码。
+func decodeCBOR(data []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, fmt.Errorf("%w: nesting too deep", errMalformedCBOR)
+	}
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: unexpected end of data", errMalformedCBOR)
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	// 简单值和浮点数
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		case 26:
+			if len(data) < 4 {
+				return nil, nil, fmt.Errorf("%w: truncated float", errMalformedCBOR)
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), data[4:], nil
+		case 27:
+			if len(data) < 8 {
+				return nil, nil, fmt.Errorf("%w: truncated float", errMalformedCBOR)
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), data[8:], nil
+		}
+		return nil, nil, fmt.Errorf("%w: unsupported simple value %d", errMalformedCBOR, info)
+	}
+
+	// 参数：数值、长度或元素数量
+	var arg uint64
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24 && len(data) >= 1:
+		arg, data = uint64(data[0]), data[1:]
+	case info == 25 && len(data) >= 2:
+		arg, data = uint64(binary.BigEndian.Uint16(data)), data[2:]
+	case info == 26 && len(data) >= 4:
+		arg, data = uint64(binary.BigEndian.Uint32(data)), data[4:]
+	case info == 27 && len(data) >= 8:
+		arg, data = binary.BigEndian.Uint64(data), data[8:]
+	default:
+		return nil, nil, fmt.Errorf("%w: invalid argument encoding", errMalformedCBOR)
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errMalformedCBOR)
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, nil, fmt.Errorf("%w: integer overflow", errMalformedCBOR)
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: truncated string", errMalformedCBOR)
+		}
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+		return append([]byte(nil), value...), data[arg:], nil
+	case 4:
+		// 每个元素至少 1 字节
+		if arg > uint64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: truncated array", errMalformedCBOR)
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item interface{}
+			var err error
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data))/2 {
+			return nil, nil, fmt.Errorf("%w: truncated map", errMalformedCBOR)
+		}
+		m := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value interface{}
+			var err error
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("%w: unsupported map key %T", errMalformedCBOR, key)
+			}
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key] = value
+		}
+		return m, data, nil
+	case 6:
+		// 忽略标签，返回被标记的值
+		return decodeCBORItem(data, depth+1)
+	}
+	return nil, nil, fmt.Errorf("%w: unsupported major type %d", errMalformedCBOR, major)
+}
+
+// parseCOSEKey 解析 COSE_Key 公钥（RFC 9052 7），返回公钥和算法
+//
+// 支持 ES256（EC2 P-256）、EdDSA（OKP Ed25519）和 RS256
+func parseCOSEKey(data []byte) (crypto.PublicKey, int64, error) {
+	value, _, err := decodeCBOR(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	m, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: cose key is not a map", errMalformedCBOR)
+	}
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+	param := func(label int64) []byte {
+		b, _ := m[label].([]byte)
+		return b
+	}
+
+	switch {
+	case kty == 2 && alg == COSEAlgES256:
+		crv, _ := m[int64(-1)].(int64)
+		x, y := param(-2), param(-3)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("invalid ES256 cose key")
+		}
+		point := append([]byte{4}, append(x, y...)...)
+		key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid ES256 cose key: %w", err)
+		}
+		return key, alg, nil
+	case kty == 1 && alg == COSEAlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x := param(-2)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("invalid EdDSA cose key")
+		}
+		return ed25519.PublicKey(x), alg, nil
+	case kty == 3 && alg == COSEAlgRS256:
+		n, e := param(-1), param(-2)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("invalid RS256 cose key")
+		}
+		exponent := new(big.Int).SetBytes(e)
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, alg, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported cose key type %d with algorithm %d", kty, alg)
+}
+
+// verifyCOSESignature 使用 COSE 公钥验证签名
+func verifyCOSESignature(key crypto.PublicKey, alg int64, data, sig []byte) bool {
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return alg == COSEAlgES256 && ecdsa.VerifyASN1(pub, digest[:], sig)
+	case ed25519.PublicKey:
+		return alg == COSEAlgEdDSA && ed25519.Verify(pub, data, sig)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return alg == COSEAlgRS256 && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil
+	}
+	return false
+}
@@ -0,0 +1,602 @@
+// Package mfa 提供多因素认证：TOTP、恢复码和 WebAuthn，
+// 验证成功后提升 security.Session 的认证保证级别，配合 Middleware.RequireAssurance 保护敏感操作。
+package mfa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yourusername/golang/pkg/security"
+)
+
+// 验证失败锁定的默认配置
+const (
+	DefaultMaxFailedAttempts = 5
+	DefaultLockoutDuration   = 15 * time.Minute
+)
+
+// maxSaveRetries 验证时遇到版本冲突的最大重试次数
+const maxSaveRetries = 3
+
+var (
+	// ErrNotEnrolled 用户没有注册对应的认证因素
+	ErrNotEnrolled = errors.New("mfa factor not enrolled")
+	// ErrAssuranceRequired 已经启用多因素认证的用户修改认证因素前需要先完成多因素认证
+	ErrAssuranceRequired = errors.New("multi-factor authentication required")
+	// ErrWebAuthnDisabled 没有配置 WebAuthn
+	ErrWebAuthnDisabled = errors.New("webauthn is not configured")
+	// ErrVersionConflict 认证因素在读取后被其他请求修改
+	ErrVersionConflict = errors.New("mfa factors modified concurrently")
+	// ErrTooManyAttempts 验证失败次数过多，用户暂时被锁定
+	ErrTooManyAttempts = errors.New("too many failed mfa attempts")
+)
+
+// Factors 用户的认证因素
+type Factors struct {
+	UserID string `json:"user_id"`
+	// TOTPSecret 已确认的 TOTP 密钥，为空表示未启用
+	TOTPSecret string `json:"totp_secret,omitempty"`
+	// TOTPLastStep 最近一次验证成功的时间步，防止验证码重放
+	TOTPLastStep int64 `json:"totp_last_step,omitempty"`
+	// PendingTOTPSecret 等待用户输入第一个验证码确认的密钥
+	PendingTOTPSecret string `json:"pending_totp_secret,omitempty"`
+	// RecoveryCodes 恢复码的 PasswordHasher 哈希
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	// Credentials 已注册的 WebAuthn 凭证
+	Credentials []Credential `json:"credentials,omitempty"`
+	// FailedAttempts 连续验证失败的次数，验证成功或开始锁定时清零
+	FailedAttempts int `json:"failed_attempts,omitempty"`
+	// LockedUntil 锁定结束时间，锁定期间拒绝 TOTP 和恢复码验证
+	LockedUntil time.Time `json:"locked_until,omitzero"`
+	// WebAuthnChallenges 进行中的 WebAuthn 挑战，键为会话 ID，使用一次后删除
+	WebAuthnChallenges map[string]WebAuthnChallenge `json:"webauthn_challenges,omitempty"`
+	// Version 乐观锁版本号，由 Store.Save 维护
+	Version int64 `json:"version"`
+}
+
+// Enabled 返回是否已启用多因素认证
+func (f *Factors) Enabled() bool {
+	return f.TOTPSecret != "" || len(f.Credentials) > 0
+}
+
+// clone 深拷贝
+func (f *Factors) clone() *Factors {
+	c := *f
+	c.RecoveryCodes = slices.Clone(f.RecoveryCodes)
+	c.Credentials = slices.Clone(f.Credentials)
+	c.WebAuthnChallenges = maps.Clone(f.WebAuthnChallenges)
+	return &c
+}
+
+// Store 认证因素存储
+//
+// TOTP 密钥需要可逆保存，生产环境的实现应当使用 security.AES256Encryptor 等方式加密后落盘。
+// Save 必须是比较并交换（CAS）操作：验证码和恢复码的防重放依赖它，
+// 共享存储可以用 Redis WATCH/Lua 脚本或 SQL 的 "UPDATE ... WHERE version = ?" 实现。
+type Store interface {
+	// Get 获取用户的认证因素，没有记录时返回只有 UserID、Version 为 0 的 Factors
+	Get(ctx context.Context, userID string) (*Factors, error)
+	// Save 保存用户的认证因素
+	//
+	// factors.Version 与存储中的版本不一致时返回 ErrVersionConflict；
+	// 保存成功后存储中的版本和 factors.Version 都加 1
+	Save(ctx context.Context, factors *Factors) error
+}
+
+// MemoryStore 进程内认证因素存储，仅用于测试和单实例部署
+type MemoryStore struct {
+	mu      sync.RWMutex
+	factors map[string]*Factors
+}
+
+// NewMemoryStore 创建进程内认证因素存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{factors: make(map[string]*Factors)}
+}
+
+// Get 获取用户的认证因素
+func (s *MemoryStore) Get(ctx context.Context, userID string) (*Factors, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.factors[userID]
+	if !ok {
+		return &Factors{UserID: userID}, nil
+	}
+	return f.clone(), nil
+}
+
+// Save 保存用户的认证因素，版本不一致时返回 ErrVersionConflict
+func (s *MemoryStore) Save(ctx context.Context, factors *Factors) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var version int64
+	if current, ok := s.factors[factors.UserID]; ok {
+		version = current.Version
+	}
+	if factors.Version != version {
+		return ErrVersionConflict
+	}
+	factors.Version++
+	s.factors[factors.UserID] = factors.clone()
+	return nil
+}
+
+// Config MFA 管理器配置
+type Config struct {
+	Sessions *security.SessionManager // 会话管理器，必填
+	Store    Store                    // 认证因素存储，为 nil 时使用 MemoryStore
+	TOTP     *TOTP                    // TOTP 验证器，为 nil 时使用默认配置
+	WebAuthn *WebAuthn                // WebAuthn 依赖方，为 nil 时不支持 WebAuthn
+	Hasher   *security.PasswordHasher // 恢复码哈希器，为 nil 时使用默认配置
+	// RecoveryCodeCount 启用 TOTP 时生成的恢复码数量，默认 DefaultRecoveryCodeCount
+	RecoveryCodeCount int
+	// MaxFailedAttempts 连续验证失败多少次后锁定，默认 DefaultMaxFailedAttempts，< 0 表示不锁定
+	MaxFailedAttempts int
+	// LockoutDuration 锁定时长，默认 DefaultLockoutDuration
+	LockoutDuration time.Duration
+}
+
+// Manager MFA 管理器
+//
+// 设计原理：
+// 1. 所有操作都基于当前会话：从会话取得用户，验证成功后通过 ElevateSession 提升保证级别并更换会话 ID
+// 2. TOTP 和恢复码提升到 AAL2，带用户验证的 WebAuthn 提升到 AAL3，否则为 AAL2
+// 3. 已启用多因素认证的用户注册新因素前，会话必须已经达到 AAL2，防止只拿到密码的攻击者绑定自己的设备
+// 4. WebAuthn 挑战按会话保存在认证因素中，与凭证更新一起以比较并交换的方式取出，并发完成同一个挑战时只有一个成功
+// 5. 认证因素通过 Store.Save 的版本号比较并交换保存，冲突时重新读取并重新验证，并发提交同一个验证码、恢复码或挑战时只有一个成功
+// 6. TOTP 和恢复码共用失败计数，连续失败 MaxFailedAttempts 次后锁定 LockoutDuration，防止暴力破解 6 位验证码
+//
+// 示例：
+//
+//	manager, _ := mfa.NewManager(mfa.Config{Sessions: sessions, WebAuthn: rp})
+//	enrollment, _ := manager.EnrollTOTP(ctx, sessionID, "alice@example.com")
+//	session, recoveryCodes, err := manager.ConfirmTOTP(ctx, sessionID, code)
+//	// 之后登录：密码验证通过创建 AAL1 会话，再调用 VerifyTOTP 提升到 AAL2
+//	session, err = manager.VerifyTOTP(ctx, session.ID, code)
+//
+// 注意事项：
+// - 提升级别后会话 ID 会改变，调用方必须把返回会话的 ID 写回 Cookie
+// - 锁定期间验证返回 ErrTooManyAttempts，即使验证码正确；锁定按用户计算，攻击者可以借此锁定已知用户
+type Manager struct {
+	sessions          *security.SessionManager
+	store             Store
+	totp              *TOTP
+	webauthn          *WebAuthn
+	hasher            *security.PasswordHasher
+	recoveryCount     int
+	maxFailedAttempts int
+	lockoutDuration   time.Duration
+	now               func() time.Time
+}
+
+// NewManager 创建 MFA 管理器
+func NewManager(config Config) (*Manager, error) {
+	if config.Sessions == nil {
+		return nil, errors.New("mfa manager requires a session manager")
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.TOTP == nil {
+		config.TOTP = NewTOTP(TOTPConfig{})
+	}
+	if config.Hasher == nil {
+		config.Hasher = security.NewPasswordHasher(security.DefaultPasswordHashConfig())
+	}
+	if config.RecoveryCodeCount == 0 {
+		config.RecoveryCodeCount = DefaultRecoveryCodeCount
+	}
+	if config.MaxFailedAttempts == 0 {
+		config.MaxFailedAttempts = DefaultMaxFailedAttempts
+	}
+	if config.LockoutDuration == 0 {
+		config.LockoutDuration = DefaultLockoutDuration
+	}
+
+	return &Manager{
+		sessions:          config.Sessions,
+		store:             config.Store,
+		totp:              config.TOTP,
+		webauthn:          config.WebAuthn,
+		hasher:            config.Hasher,
+		recoveryCount:     config.RecoveryCodeCount,
+		maxFailedAttempts: config.MaxFailedAttempts,
+		lockoutDuration:   config.LockoutDuration,
+		now:               time.Now,
+	}, nil
+}
+
+// Factors 返回会话用户的认证因素
+func (m *Manager) Factors(ctx context.Context, sessionID string) (*Factors, error) {
+	_, factors, err := m.load(ctx, sessionID)
+	return factors, err
+}
+
+// EnrollTOTP 为会话用户生成待确认的 TOTP 密钥
+//
+// 参数：
+//   - account: 显示在验证器应用中的账户名，如邮箱
+func (m *Manager) EnrollTOTP(ctx context.Context, sessionID, account string) (*TOTPEnrollment, error) {
+	session, factors, err := m.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAssuranceToModify(session, factors); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := m.totp.Enroll(account)
+	if err != nil {
+		return nil, err
+	}
+	factors.PendingTOTPSecret = enrollment.Secret
+	if err := m.store.Save(ctx, factors); err != nil {
+		return nil, fmt.Errorf("failed to save mfa factors: %w", err)
+	}
+	return enrollment, nil
+}
+
+// ConfirmTOTP 使用第一个验证码确认 TOTP 注册，并提升会话到 AAL2
+//
+// 返回：
+//   - *security.Session: 提升级别后的新会话
+//   - []string: 新生成的恢复码明文，只返回这一次；已有恢复码时为 nil
+//   - error: 验证码错误返回 ErrInvalidCode；锁定期间返回 ErrTooManyAttempts
+func (m *Manager) ConfirmTOTP(ctx context.Context, sessionID, code string) (*security.Session, []string, error) {
+	session, err := m.sessions.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var codes []string
+	err = m.attempt(ctx, session.UserID, func(factors *Factors) error {
+		if factors.PendingTOTPSecret == "" {
+			return ErrNotEnrolled
+		}
+		step, err := m.totp.Verify(factors.PendingTOTPSecret, code, 0)
+		if err != nil {
+			return err
+		}
+		factors.TOTPSecret = factors.PendingTOTPSecret
+		factors.PendingTOTPSecret = ""
+		factors.TOTPLastStep = step
+
+		codes = nil
+		if len(factors.RecoveryCodes) == 0 {
+			codes, factors.RecoveryCodes, err = m.newRecoveryCodes()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err = m.sessions.ElevateSessionContext(ctx, sessionID, security.AAL2)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, codes, nil
+}
+
+// VerifyTOTP 验证 TOTP 验证码并提升会话到 AAL2
+//
+// 验证码错误返回 ErrInvalidCode，重放返回 ErrCodeReused，锁定期间返回 ErrTooManyAttempts
+func (m *Manager) VerifyTOTP(ctx context.Context, sessionID, code string) (*security.Session, error) {
+	session, err := m.sessions.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.attempt(ctx, session.UserID, func(factors *Factors) error {
+		if factors.TOTPSecret == "" {
+			return ErrNotEnrolled
+		}
+		step, err := m.totp.Verify(factors.TOTPSecret, code, factors.TOTPLastStep)
+		if err != nil {
+			return err
+		}
+		factors.TOTPLastStep = step
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.sessions.ElevateSessionContext(ctx, sessionID, security.AAL2)
+}
+
+// VerifyRecoveryCode 使用恢复码提升会话到 AAL2，恢复码使用后失效
+//
+// 恢复码错误返回 ErrInvalidRecoveryCode，锁定期间返回 ErrTooManyAttempts
+func (m *Manager) VerifyRecoveryCode(ctx context.Context, sessionID, code string) (*security.Session, error) {
+	session, err := m.sessions.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.attempt(ctx, session.UserID, func(factors *Factors) error {
+		if len(factors.RecoveryCodes) == 0 {
+			return ErrNotEnrolled
+		}
+		remaining, err := UseRecoveryCode(m.hasher, factors.RecoveryCodes, code)
+		if err != nil {
+			return err
+		}
+		factors.RecoveryCodes = remaining
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.sessions.ElevateSessionContext(ctx, sessionID, security.AAL2)
+}
+
+// RegenerateRecoveryCodes 生成新的恢复码，旧恢复码全部失效；会话必须已达到 AAL2
+func (m *Manager) RegenerateRecoveryCodes(ctx context.Context, sessionID string) ([]string, error) {
+	session, factors, err := m.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.AssuranceLevel < security.AAL2 {
+		return nil, ErrAssuranceRequired
+	}
+
+	codes, hashes, err := m.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	factors.RecoveryCodes = hashes
+	if err := m.store.Save(ctx, factors); err != nil {
+		return nil, fmt.Errorf("failed to save mfa factors: %w", err)
+	}
+	return codes, nil
+}
+
+// BeginWebAuthnRegistration 开始为会话用户注册 WebAuthn 凭证
+func (m *Manager) BeginWebAuthnRegistration(ctx context.Context, sessionID string, user WebAuthnUser) (*CredentialCreationOptions, error) {
+	if m.webauthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+	session, factors, err := m.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAssuranceToModify(session, factors); err != nil {
+		return nil, err
+	}
+
+	user.ID = session.UserID
+	opts, challenge, err := m.webauthn.BeginRegistration(user, factors.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.saveChallenge(ctx, session, challenge); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// FinishWebAuthnRegistration 完成注册并保存凭证
+//
+// 注册凭证本身不提升会话级别，首次启用 WebAuthn 的用户在下一次登录时使用凭证完成认证
+func (m *Manager) FinishWebAuthnRegistration(ctx context.Context, sessionID string, resp *RegistrationResponse) (*Credential, error) {
+	if m.webauthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+	session, err := m.sessions.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var credential *Credential
+	err = m.update(ctx, session.UserID, func(factors *Factors) (bool, error) {
+		challenge, err := takeChallenge(factors, session)
+		if err != nil {
+			return false, err
+		}
+		// 校验失败时挑战同样作废
+		credential, err = m.webauthn.FinishRegistration(challenge, resp)
+		if err != nil {
+			return true, err
+		}
+		if slices.ContainsFunc(factors.Credentials, func(c Credential) bool { return bytes.Equal(c.ID, credential.ID) }) {
+			return true, fmt.Errorf("%w: credential already registered", ErrWebAuthnFailed)
+		}
+		factors.Credentials = append(factors.Credentials, *credential)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// BeginWebAuthnLogin 开始使用 WebAuthn 凭证完成第二因素认证
+func (m *Manager) BeginWebAuthnLogin(ctx context.Context, sessionID string) (*CredentialRequestOptions, error) {
+	if m.webauthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+	session, factors, err := m.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(factors.Credentials) == 0 {
+		return nil, ErrNotEnrolled
+	}
+
+	opts, challenge, err := m.webauthn.BeginLogin(session.UserID, factors.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.saveChallenge(ctx, session, challenge); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// FinishWebAuthnLogin 校验断言并提升会话：认证器验证了用户时为 AAL3，否则为 AAL2
+func (m *Manager) FinishWebAuthnLogin(ctx context.Context, sessionID string, resp *AssertionResponse) (*security.Session, error) {
+	if m.webauthn == nil {
+		return nil, ErrWebAuthnDisabled
+	}
+	session, err := m.sessions.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *AssertionResult
+	err = m.update(ctx, session.UserID, func(factors *Factors) (bool, error) {
+		challenge, err := takeChallenge(factors, session)
+		if err != nil {
+			return false, err
+		}
+		// 校验失败时挑战同样作废
+		result, err = m.webauthn.FinishLogin(challenge, factors.Credentials, resp)
+		if err != nil {
+			return true, err
+		}
+		for i := range factors.Credentials {
+			if bytes.Equal(factors.Credentials[i].ID, result.Credential.ID) {
+				factors.Credentials[i] = *result.Credential
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	level := security.AAL2
+	if result.UserVerified {
+		level = security.AAL3
+	}
+	return m.sessions.ElevateSessionContext(ctx, sessionID, level)
+}
+
+// load 读取会话和会话用户的认证因素
+func (m *Manager) load(ctx context.Context, sessionID string) (*security.Session, *Factors, error) {
+	session, err := m.sessions.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	factors, err := m.store.Get(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load mfa factors: %w", err)
+	}
+	return session, factors, nil
+}
+
+// attempt 读取认证因素，执行一次验证并以比较并交换的方式保存结果
+//
+// verify 修改 factors 并返回 nil 表示验证成功，清零失败计数；
+// 返回 ErrInvalidCode、ErrCodeReused 或 ErrInvalidRecoveryCode 表示验证失败，计入失败次数；
+// 返回其他错误时不保存。版本冲突时重新读取并重新执行 verify
+func (m *Manager) attempt(ctx context.Context, userID string, verify func(factors *Factors) error) error {
+	return m.update(ctx, userID, func(factors *Factors) (bool, error) {
+		now := m.now()
+		if now.Before(factors.LockedUntil) {
+			return false, ErrTooManyAttempts
+		}
+
+		verifyErr := verify(factors)
+		switch {
+		case verifyErr == nil:
+			factors.FailedAttempts = 0
+			factors.LockedUntil = time.Time{}
+		case isVerificationFailure(verifyErr):
+			factors.FailedAttempts++
+			if m.maxFailedAttempts > 0 && factors.FailedAttempts >= m.maxFailedAttempts {
+				factors.FailedAttempts = 0
+				factors.LockedUntil = now.Add(m.lockoutDuration)
+			}
+		default:
+			return false, verifyErr
+		}
+		return true, verifyErr
+	})
+}
+
+// update 读取认证因素，执行 apply 并以比较并交换的方式保存
+//
+// apply 返回是否需要保存以及要返回给调用方的错误：保存成功后返回该错误，不保存时直接返回该错误。
+// 版本冲突时重新读取并重新执行 apply，最多重试 maxSaveRetries 次
+func (m *Manager) update(ctx context.Context, userID string, apply func(factors *Factors) (bool, error)) error {
+	for retries := 0; ; retries++ {
+		factors, err := m.store.Get(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to load mfa factors: %w", err)
+		}
+
+		save, applyErr := apply(factors)
+		if !save {
+			return applyErr
+		}
+
+		err = m.store.Save(ctx, factors)
+		if errors.Is(err, ErrVersionConflict) && retries < maxSaveRetries {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save mfa factors: %w", err)
+		}
+		return applyErr
+	}
+}
+
+// isVerificationFailure 返回错误是否表示用户提交的验证码或恢复码错误
+func isVerificationFailure(err error) bool {
+	return errors.Is(err, ErrInvalidCode) || errors.Is(err, ErrCodeReused) || errors.Is(err, ErrInvalidRecoveryCode)
+}
+
+// newRecoveryCodes 生成恢复码，返回明文和哈希
+func (m *Manager) newRecoveryCodes() ([]string, []string, error) {
+	codes, err := GenerateRecoveryCodes(m.recoveryCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes, err := HashRecoveryCodes(m.hasher, codes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return codes, hashes, nil
+}
+
+// saveChallenge 保存会话的 WebAuthn 挑战，替换该会话之前的挑战并清理已过期的挑战
+func (m *Manager) saveChallenge(ctx context.Context, session *security.Session, challenge *WebAuthnChallenge) error {
+	return m.update(ctx, session.UserID, func(factors *Factors) (bool, error) {
+		now := m.webauthn.now()
+		maps.DeleteFunc(factors.WebAuthnChallenges, func(_ string, c WebAuthnChallenge) bool {
+			return now.After(c.Expires)
+		})
+		if factors.WebAuthnChallenges == nil {
+			factors.WebAuthnChallenges = make(map[string]WebAuthnChallenge)
+		}
+		factors.WebAuthnChallenges[session.ID] = *challenge
+		return true, nil
+	})
+}
+
+// takeChallenge 从认证因素中取出会话的 WebAuthn 挑战，调用方保存 factors 后挑战才真正失效
+func takeChallenge(factors *Factors, session *security.Session) (*WebAuthnChallenge, error) {
+	challenge, ok := factors.WebAuthnChallenges[session.ID]
+	if !ok || challenge.UserID != session.UserID {
+		return nil, ErrChallengeExpired
+	}
+	delete(factors.WebAuthnChallenges, session.ID)
+	return &challenge, nil
+}
+
+// requireAssuranceToModify 已启用多因素认证时，修改认证因素需要 AAL2
+func requireAssuranceToModify(session *security.Session, factors *Factors) error {
+	if factors.Enabled() && session.AssuranceLevel < security.AAL2 {
+		return ErrAssuranceRequired
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package mfa
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/golang/pkg/security"
+)
+
+func newTestHasher() *security.PasswordHasher {
+	return security.NewPasswordHasher(security.PasswordHashConfig{
+		Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32,
+	})
+}
+
+func newTestManager(t *testing.T, webauthn *WebAuthn) (*Manager, *security.SessionManager, *time.Time) {
+	t.Helper()
+	sessions := security.NewSessionManager(security.DefaultSessionConfig())
+	t.Cleanup(func() { _ = sessions.Shutdown() })
+
+	now := time.Unix(1700000000, 0)
+	totp := NewTOTP(TOTPConfig{Issuer: "Example"})
+	totp.now = func() time.Time { return now }
+
+	manager, err := NewManager(Config{
+		Sessions:          sessions,
+		TOTP:              totp,
+		WebAuthn:          webauthn,
+		Hasher:            newTestHasher(),
+		RecoveryCodeCount: 3,
+	})
+	require.NoError(t, err)
+	return manager, sessions, &now
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	hasher := newTestHasher()
+	codes, err := GenerateRecoveryCodes(3)
+	require.NoError(t, err)
+	require.Len(t, codes, 3)
+	for _, code := range codes {
+		assert.Regexp(t, `^[2-9a-z]{5}-[2-9a-z]{5}$`, code)
+	}
+
+	hashes, err := HashRecoveryCodes(hasher, codes)
+	require.NoError(t, err)
+
+	// 大小写、空格和分隔符不影响匹配
+	remaining, err := UseRecoveryCode(hasher, hashes, " "+strings.ToUpper(strings.ReplaceAll(codes[1], "-", ""))+" ")
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+
+	_, err = UseRecoveryCode(hasher, remaining, codes[1])
+	assert.ErrorIs(t, err, ErrInvalidRecoveryCode)
+}
+
+func TestManager_TOTPFlow(t *testing.T) {
+	ctx := context.Background()
+	manager, sessions, now := newTestManager(t, nil)
+
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	assert.Equal(t, security.AAL1, session.AssuranceLevel)
+
+	_, err = manager.VerifyTOTP(ctx, session.ID, "123456")
+	assert.ErrorIs(t, err, ErrNotEnrolled)
+
+	enrollment, err := manager.EnrollTOTP(ctx, session.ID, "alice@example.com")
+	require.NoError(t, err)
+
+	_, _, err = manager.ConfirmTOTP(ctx, session.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	code, err := manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	elevated, recoveryCodes, err := manager.ConfirmTOTP(ctx, session.ID, code)
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, 3)
+	assert.Equal(t, security.AAL2, elevated.AssuranceLevel)
+	assert.NotEqual(t, session.ID, elevated.ID)
+	_, err = sessions.GetSession(session.ID)
+	assert.Error(t, err, "old session id must be invalidated")
+
+	// 新的 AAL1 会话：同一个验证码不能重放，下一个时间步的验证码可以
+	login, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	_, err = manager.VerifyTOTP(ctx, login.ID, code)
+	assert.ErrorIs(t, err, ErrCodeReused)
+
+	*now = now.Add(30 * time.Second)
+	code, err = manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	elevated, err = manager.VerifyTOTP(ctx, login.ID, code)
+	require.NoError(t, err)
+	assert.Equal(t, security.AAL2, elevated.AssuranceLevel)
+
+	// 恢复码只能使用一次
+	login, err = sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	elevated, err = manager.VerifyRecoveryCode(ctx, login.ID, recoveryCodes[0])
+	require.NoError(t, err)
+	assert.Equal(t, security.AAL2, elevated.AssuranceLevel)
+	_, err = manager.VerifyRecoveryCode(ctx, elevated.ID, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidRecoveryCode)
+
+	// 只有密码的会话不能重新绑定设备或重新生成恢复码
+	login, err = sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	_, err = manager.EnrollTOTP(ctx, login.ID, "alice@example.com")
+	assert.ErrorIs(t, err, ErrAssuranceRequired)
+	_, err = manager.RegenerateRecoveryCodes(ctx, login.ID)
+	assert.ErrorIs(t, err, ErrAssuranceRequired)
+
+	regenerated, err := manager.RegenerateRecoveryCodes(ctx, elevated.ID)
+	require.NoError(t, err)
+	assert.Len(t, regenerated, 3)
+	_, err = manager.VerifyRecoveryCode(ctx, login.ID, recoveryCodes[1])
+	assert.ErrorIs(t, err, ErrInvalidRecoveryCode)
+}
+
+// racingStore 让前两次 Get 互相等待，使两个请求读到同一版本的认证因素
+type racingStore struct {
+	Store
+	arrived atomic.Int32
+	ready   chan struct{}
+}
+
+func newRacingStore(store Store) *racingStore {
+	return &racingStore{Store: store, ready: make(chan struct{})}
+}
+
+func (s *racingStore) Get(ctx context.Context, userID string) (*Factors, error) {
+	factors, err := s.Store.Get(ctx, userID)
+	if s.arrived.Add(1) == 2 {
+		close(s.ready)
+	}
+	select {
+	case <-s.ready:
+	case <-time.After(time.Second):
+	}
+	return factors, err
+}
+
+// raceVerify 在两个会话上并发执行 verify，返回两个结果
+func raceVerify(t *testing.T, sessions *security.SessionManager, verify func(sessionID string) error) []error {
+	t.Helper()
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		login, err := sessions.CreateSession("user-123", nil)
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = verify(login.ID)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestManager_ConcurrentReplay(t *testing.T) {
+	ctx := context.Background()
+	manager, sessions, now := newTestManager(t, nil)
+
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	enrollment, err := manager.EnrollTOTP(ctx, session.ID, "alice@example.com")
+	require.NoError(t, err)
+	code, err := manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	_, recoveryCodes, err := manager.ConfirmTOTP(ctx, session.ID, code)
+	require.NoError(t, err)
+
+	// 同一个 TOTP 验证码并发提交，只有一个成功
+	*now = now.Add(30 * time.Second)
+	code, err = manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	store := manager.store
+	manager.store = newRacingStore(store)
+	errs := raceVerify(t, sessions, func(sessionID string) error {
+		_, err := manager.VerifyTOTP(ctx, sessionID, code)
+		return err
+	})
+	assert.ElementsMatch(t, []error{nil, ErrCodeReused}, errs)
+
+	// 同一个恢复码并发提交，只有一个成功
+	manager.store = newRacingStore(store)
+	errs = raceVerify(t, sessions, func(sessionID string) error {
+		_, err := manager.VerifyRecoveryCode(ctx, sessionID, recoveryCodes[0])
+		return err
+	})
+	assert.ElementsMatch(t, []error{nil, ErrInvalidRecoveryCode}, errs)
+}
+
+func TestManager_Lockout(t *testing.T) {
+	ctx := context.Background()
+	manager, sessions, now := newTestManager(t, nil)
+	manager.now = func() time.Time { return *now }
+
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	enrollment, err := manager.EnrollTOTP(ctx, session.ID, "alice@example.com")
+	require.NoError(t, err)
+	code, err := manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	_, recoveryCodes, err := manager.ConfirmTOTP(ctx, session.ID, code)
+	require.NoError(t, err)
+
+	login, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+
+	// 验证成功会清零失败计数
+	*now = now.Add(30 * time.Second)
+	for range DefaultMaxFailedAttempts - 1 {
+		_, err = manager.VerifyTOTP(ctx, login.ID, "000000")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	}
+	code, err = manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	_, err = manager.VerifyTOTP(ctx, login.ID, code)
+	require.NoError(t, err)
+
+	// TOTP 和恢复码共用失败计数
+	login, err = sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	for range DefaultMaxFailedAttempts - 1 {
+		_, err = manager.VerifyTOTP(ctx, login.ID, "000000")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	}
+	_, err = manager.VerifyRecoveryCode(ctx, login.ID, "aaaaa-aaaaa")
+	assert.ErrorIs(t, err, ErrInvalidRecoveryCode)
+
+	// 锁定期间正确的验证码和恢复码也被拒绝
+	*now = now.Add(30 * time.Second)
+	code, err = manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	_, err = manager.VerifyTOTP(ctx, login.ID, code)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	_, err = manager.VerifyRecoveryCode(ctx, login.ID, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	*now = now.Add(DefaultLockoutDuration)
+	code, err = manager.totp.Code(enrollment.Secret, *now)
+	require.NoError(t, err)
+	elevated, err := manager.VerifyTOTP(ctx, login.ID, code)
+	require.NoError(t, err)
+	assert.Equal(t, security.AAL2, elevated.AssuranceLevel)
+}
+
+func TestMemoryStore_VersionConflict(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	a, err := store.Get(ctx, "user-123")
+	require.NoError(t, err)
+	b, err := store.Get(ctx, "user-123")
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save(ctx, a))
+	assert.Equal(t, int64(1), a.Version)
+	assert.ErrorIs(t, store.Save(ctx, b), ErrVersionConflict)
+
+	// 保存后继续使用同一个对象
+	require.NoError(t, store.Save(ctx, a))
+	stored, err := store.Get(ctx, "user-123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stored.Version)
+}
+
+func TestManager_WebAuthnFlow(t *testing.T) {
+	ctx := context.Background()
+	manager, sessions, _ := newTestManager(t, newTestWebAuthn(t))
+	authenticator := newTestAuthenticator(t, COSEAlgES256)
+
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+
+	opts, err := manager.BeginWebAuthnRegistration(ctx, session.ID, WebAuthnUser{ID: "user-123", Name: "alice"})
+	require.NoError(t, err)
+	credential, err := manager.FinishWebAuthnRegistration(ctx, session.ID, authenticator.register(t, opts))
+	require.NoError(t, err)
+
+	// 挑战只能使用一次
+	_, err = manager.FinishWebAuthnRegistration(ctx, session.ID, authenticator.register(t, opts))
+	assert.ErrorIs(t, err, ErrChallengeExpired)
+
+	factors, err := manager.Factors(ctx, session.ID)
+	require.NoError(t, err)
+	require.Len(t, factors.Credentials, 1)
+	assert.Equal(t, credential.ID, factors.Credentials[0].ID)
+
+	login, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	loginOpts, err := manager.BeginWebAuthnLogin(ctx, login.ID)
+	require.NoError(t, err)
+	elevated, err := manager.FinishWebAuthnLogin(ctx, login.ID, authenticator.assert(t, loginOpts, true))
+	require.NoError(t, err)
+	assert.Equal(t, security.AAL3, elevated.AssuranceLevel)
+
+	login, err = sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	loginOpts, err = manager.BeginWebAuthnLogin(ctx, login.ID)
+	require.NoError(t, err)
+	elevated, err = manager.FinishWebAuthnLogin(ctx, login.ID, authenticator.assert(t, loginOpts, false))
+	require.NoError(t, err)
+	assert.Equal(t, security.AAL2, elevated.AssuranceLevel)
+
+	factors, err = manager.Factors(ctx, elevated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), factors.Credentials[0].SignCount)
+}
+
+func TestManager_WebAuthnConcurrentFinish(t *testing.T) {
+	ctx := context.Background()
+	manager, sessions, _ := newTestManager(t, newTestWebAuthn(t))
+	authenticator := newTestAuthenticator(t, COSEAlgES256)
+
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	opts, err := manager.BeginWebAuthnRegistration(ctx, session.ID, WebAuthnUser{ID: "user-123", Name: "alice"})
+	require.NoError(t, err)
+	_, err = manager.FinishWebAuthnRegistration(ctx, session.ID, authenticator.register(t, opts))
+	require.NoError(t, err)
+
+	login, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	loginOpts, err := manager.BeginWebAuthnLogin(ctx, login.ID)
+	require.NoError(t, err)
+	resp := authenticator.assert(t, loginOpts, true)
+
+	// 同一个挑战并发完成，只有一个成功
+	manager.store = newRacingStore(manager.store)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = manager.FinishWebAuthnLogin(ctx, login.ID, resp)
+		}()
+	}
+	wg.Wait()
+	assert.ElementsMatch(t, []error{nil, ErrChallengeExpired}, errs)
+}
+
+func TestManager_WebAuthnDisabled(t *testing.T) {
+	manager, sessions, _ := newTestManager(t, nil)
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+
+	_, err = manager.BeginWebAuthnLogin(context.Background(), session.ID)
+	assert.ErrorIs(t, err, ErrWebAuthnDisabled)
+}
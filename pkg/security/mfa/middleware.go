@@ -0,0 +1,92 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/yourusername/golang/pkg/security"
+)
+
+// ContextKey MFA 上下文键
+type ContextKey string
+
+const (
+	// SessionKey 通过检查的会话上下文键
+	SessionKey ContextKey = "mfa_session"
+)
+
+// Middleware 认证保证级别中间件
+type Middleware struct {
+	sessions  *security.SessionManager
+	sessionID func(r *http.Request) string
+}
+
+// MiddlewareConfig 中间件配置
+type MiddlewareConfig struct {
+	Sessions *security.SessionManager
+	// SessionID 从请求中读取会话 ID，默认读取 session_id Cookie，其次是 X-Session-ID 头部
+	SessionID func(r *http.Request) string
+}
+
+// NewMiddleware 创建认证保证级别中间件
+func NewMiddleware(cfg MiddlewareConfig) *Middleware {
+	if cfg.SessionID == nil {
+		cfg.SessionID = sessionIDFromRequest
+	}
+	return &Middleware{sessions: cfg.Sessions, sessionID: cfg.SessionID}
+}
+
+// RequireAssurance 要求会话达到指定的保证级别
+//
+// 没有有效会话返回 401；级别不足返回 403，客户端应引导用户完成多因素认证后重试
+func (m *Middleware) RequireAssurance(level security.AssuranceLevel) func(http.Handler) http.Handler {
+	return m.RequireRecentAssurance(level, 0)
+}
+
+// RequireRecentAssurance 要求会话在 maxAge 内达到过指定的保证级别，用于修改密码、转账等敏感操作
+//
+// maxAge 为 0 时不检查时间
+func (m *Middleware) RequireRecentAssurance(level security.AssuranceLevel, maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, err := m.sessions.GetSessionContext(r.Context(), m.sessionID(r))
+			if err != nil {
+				if errors.Is(err, security.ErrSessionNotFound) || errors.Is(err, security.ErrSessionExpired) ||
+					errors.Is(err, security.ErrInvalidSessionID) {
+					http.Error(w, "Unauthorized: invalid session", http.StatusUnauthorized)
+					return
+				}
+				http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+				return
+			}
+
+			if session.AssuranceLevel < level {
+				http.Error(w, "Forbidden: multi-factor authentication required", http.StatusForbidden)
+				return
+			}
+			if maxAge > 0 && time.Since(session.AssuredAt) > maxAge {
+				http.Error(w, "Forbidden: recent multi-factor authentication required", http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), SessionKey, session)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetSession 从上下文获取通过检查的会话
+func GetSession(ctx context.Context) (*security.Session, bool) {
+	session, ok := ctx.Value(SessionKey).(*security.Session)
+	return session, ok
+}
+
+// sessionIDFromRequest 与 security.SecurityMiddleware 一致：先读 Cookie，再读头部
+func sessionIDFromRequest(r *http.Request) string {
+	if cookie, err := r.Cookie("session_id"); err == nil {
+		return cookie.Value
+	}
+	return r.Header.Get("X-Session-ID")
+}
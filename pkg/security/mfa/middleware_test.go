@@ -0,0 +1,79 @@
+package mfa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/golang/pkg/security"
+)
+
+func TestMiddleware_RequireAssurance(t *testing.T) {
+	sessions := security.NewSessionManager(security.DefaultSessionConfig())
+	defer sessions.Shutdown()
+	middleware := NewMiddleware(MiddlewareConfig{Sessions: sessions})
+
+	handler := middleware.RequireAssurance(security.AAL2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := GetSession(r.Context())
+		require.True(t, ok)
+		_, _ = w.Write([]byte(session.UserID))
+	}))
+
+	serve := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("missing").Code)
+
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, serve(session.ID).Code)
+
+	elevated, err := sessions.ElevateSession(session.ID, security.AAL2)
+	require.NoError(t, err)
+	rec := serve(elevated.ID)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user-123", rec.Body.String())
+}
+
+func TestMiddleware_RequireRecentAssurance(t *testing.T) {
+	store := security.NewMemorySessionStore()
+	defer store.Close()
+	config := security.DefaultSessionConfig()
+	config.Store = store
+	sessions := security.NewSessionManager(config)
+	defer sessions.Shutdown()
+	middleware := NewMiddleware(MiddlewareConfig{Sessions: sessions})
+	handler := middleware.RequireRecentAssurance(security.AAL2, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	session, err := sessions.CreateSession("user-123", nil)
+	require.NoError(t, err)
+	elevated, err := sessions.ElevateSession(session.ID, security.AAL2)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Session-ID", elevated.ID)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// 保证级别足够但已过时
+	elevated.AssuredAt = time.Now().Add(-2 * time.Minute)
+	require.NoError(t, store.Save(context.Background(), elevated))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
@@ -0,0 +1,97 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/golang/pkg/security"
+)
+
+// DefaultRecoveryCodeCount 默认生成的恢复码数量
+const DefaultRecoveryCodeCount = 10
+
+// ErrInvalidRecoveryCode 恢复码错误或已使用
+var ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+
+// recoveryAlphabet 恢复码字符集，去掉了容易混淆的 0、1、i、l、o
+const recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// GenerateRecoveryCodes 生成一组恢复码，格式为 xxxxx-xxxxx
+//
+// 每个恢复码约 49 位熵，只在生成时向用户展示一次，保存时使用 HashRecoveryCodes
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	if n <= 0 {
+		n = DefaultRecoveryCodeCount
+	}
+
+	codes := make([]string, n)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		var b strings.Builder
+		for j, c := range buf {
+			if j == 5 {
+				b.WriteByte('-')
+			}
+			// 256 不是 31 的整数倍，存在极小的偏差，对恢复码的熵影响可以忽略
+			b.WriteByte(recoveryAlphabet[int(c)%len(recoveryAlphabet)])
+		}
+		codes[i] = b.String()
+	}
+	return codes, nil
+}
+
+// HashRecoveryCodes 使用 PasswordHasher 哈希恢复码，只保存哈希值
+func HashRecoveryCodes(hasher *security.PasswordHasher, codes []string) ([]string, error) {
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hash, err := hasher.Hash(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		hashes[i] = hash
+	}
+	return hashes, nil
+}
+
+// UseRecoveryCode 校验恢复码，成功时返回去掉该恢复码后的哈希列表
+//
+// 恢复码只能使用一次，调用方必须保存返回的列表。
+// 输入忽略大小写、空格和连字符。
+//
+// 返回：
+//   - []string: 剩余的恢复码哈希
+//   - error: 没有匹配的恢复码时返回 ErrInvalidRecoveryCode
+func UseRecoveryCode(hasher *security.PasswordHasher, hashes []string, code string) ([]string, error) {
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return nil, ErrInvalidRecoveryCode
+	}
+
+	for i, hash := range hashes {
+		ok, err := hasher.Verify(normalized, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify recovery code: %w", err)
+		}
+		if ok {
+			remaining := make([]string, 0, len(hashes)-1)
+			remaining = append(remaining, hashes[:i]...)
+			return append(remaining, hashes[i+1:]...), nil
+		}
+	}
+	return nil, ErrInvalidRecoveryCode
+}
+
+// normalizeRecoveryCode 去掉空格和连字符并转为小写
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,231 @@
+package mfa
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP 默认配置，与 Google Authenticator 等常见应用兼容
+const (
+	DefaultTOTPDigits = 6
+	DefaultTOTPPeriod = 30 * time.Second
+	DefaultTOTPSkew   = 1
+	// DefaultTOTPSecretSize 密钥长度（字节），RFC 4226 推荐 160 位
+	DefaultTOTPSecretSize = 20
+)
+
+var (
+	// ErrInvalidCode 验证码错误
+	ErrInvalidCode = errors.New("invalid verification code")
+	// ErrCodeReused 验证码所在的时间步已经使用过，防止重放
+	ErrCodeReused = errors.New("verification code already used")
+)
+
+// base32NoPadding otpauth URI 使用的无填充 Base32 编码
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPConfig TOTP 配置
+type TOTPConfig struct {
+	Issuer    string        // 签发者，显示在验证器应用中
+	Digits    int           // 验证码位数，默认 6
+	Period    time.Duration // 时间步长，默认 30 秒
+	Skew      int           // 允许前后偏移的时间步数量，默认 1，小于 0 表示不允许偏移
+	Algorithm string        // SHA1、SHA256 或 SHA512，默认 SHA1
+}
+
+// TOTP 基于时间的一次性密码（RFC 6238）
+//
+// 设计原理：
+// 1. 验证码为 HOTP(secret, floor(unix / period))（RFC 4226），按 Digits 截断
+// 2. 验证时接受前后 Skew 个时间步内的验证码，容忍客户端时钟偏差
+// 3. Verify 返回匹配的时间步，调用方保存后作为下一次的 lastStep，同一时间步的验证码不能重复使用
+//
+// 示例：
+//
+//	totp := mfa.NewTOTP(mfa.TOTPConfig{Issuer: "Example"})
+//	enrollment, _ := totp.Enroll("alice@example.com")
+//	// 把 enrollment.URI 渲染为二维码，用户扫描后输入第一个验证码确认
+//	step, err := totp.Verify(enrollment.Secret, code, 0)
+type TOTP struct {
+	issuer    string
+	digits    int
+	period    time.Duration
+	skew      int
+	algorithm string
+	hash      func() hash.Hash
+	now       func() time.Time
+}
+
+// TOTPEnrollment TOTP 注册信息
+type TOTPEnrollment struct {
+	Secret string // Base32 编码的密钥，需要加密保存
+	URI    string // otpauth:// URI，用于生成二维码
+}
+
+// NewTOTP 创建 TOTP 生成和验证器
+func NewTOTP(config TOTPConfig) *TOTP {
+	if config.Digits == 0 {
+		config.Digits = DefaultTOTPDigits
+	}
+	if config.Period == 0 {
+		config.Period = DefaultTOTPPeriod
+	}
+	if config.Skew == 0 {
+		config.Skew = DefaultTOTPSkew
+	}
+	if config.Skew < 0 {
+		config.Skew = 0
+	}
+
+	t := &TOTP{
+		issuer: config.Issuer,
+		digits: config.Digits,
+		period: config.Period,
+		skew:   config.Skew,
+		now:    time.Now,
+	}
+	switch strings.ToUpper(config.Algorithm) {
+	case "SHA256":
+		t.algorithm, t.hash = "SHA256", sha256.New
+	case "SHA512":
+		t.algorithm, t.hash = "SHA512", sha512.New
+	default:
+		t.algorithm, t.hash = "SHA1", sha1.New
+	}
+	return t
+}
+
+// GenerateSecret 生成随机的 Base32 密钥
+func GenerateSecret() (string, error) {
+	secret := make([]byte, DefaultTOTPSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return base32NoPadding.EncodeToString(secret), nil
+}
+
+// Enroll 生成新密钥和 otpauth URI
+//
+// 参数：
+//   - account: 账户名，如邮箱，显示在验证器应用中
+func (t *TOTP) Enroll(account string) (*TOTPEnrollment, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, URI: t.URI(secret, account)}, nil
+}
+
+// URI 返回 otpauth://totp URI（Key URI Format）
+func (t *TOTP) URI(secret, account string) string {
+	label := account
+	if t.issuer != "" {
+		label = t.issuer + ":" + account
+	}
+	params := url.Values{}
+	params.Set("secret", secret)
+	if t.issuer != "" {
+		params.Set("issuer", t.issuer)
+	}
+	params.Set("algorithm", t.algorithm)
+	params.Set("digits", fmt.Sprint(t.digits))
+	params.Set("period", fmt.Sprint(int(t.period/time.Second)))
+
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: params.Encode()}
+	return u.String()
+}
+
+// Code 返回 at 时刻的验证码
+func (t *TOTP) Code(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return t.hotp(key, t.step(at)), nil
+}
+
+// Verify 验证验证码
+//
+// 参数：
+//   - secret: Base32 密钥
+//   - code: 用户输入的验证码
+//   - lastStep: 上一次验证成功的时间步，首次验证传 0
+//
+// 返回：
+//   - int64: 匹配的时间步，调用方需要保存并在下一次验证时作为 lastStep 传入
+//   - error: 验证码错误返回 ErrInvalidCode，时间步已使用返回 ErrCodeReused
+func (t *TOTP) Verify(secret, code string, lastStep int64) (int64, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != t.digits {
+		return 0, ErrInvalidCode
+	}
+
+	current := t.step(t.now())
+	for offset := -t.skew; offset <= t.skew; offset++ {
+		step := current + int64(offset)
+		if step < 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(t.hotp(key, step)), []byte(code)) == 1 {
+			if step <= lastStep {
+				return 0, ErrCodeReused
+			}
+			return step, nil
+		}
+	}
+	return 0, ErrInvalidCode
+}
+
+// step 返回时刻对应的时间步
+func (t *TOTP) step(at time.Time) int64 {
+	return at.Unix() / int64(t.period/time.Second)
+}
+
+// hotp 计算 HOTP 值（RFC 4226 5.3）
+func (t *TOTP) hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(t.hash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// 动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < t.digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", t.digits, value%mod)
+}
+
+// decodeSecret 解码 Base32 密钥，忽略大小写、空格和填充
+func decodeSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	normalized = strings.TrimRight(normalized, "=")
+	key, err := base32NoPadding.DecodeString(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("invalid totp secret: %w", err)
+	}
+	if len(key) == 0 {
+		return nil, errors.New("invalid totp secret: empty")
+	}
+	return key, nil
+}
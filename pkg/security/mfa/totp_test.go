@@ -0,0 +1,86 @@
+package mfa
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 附录 B
+	tests := []struct {
+		algorithm string
+		secret    string
+		at        int64
+		code      string
+	}{
+		{"SHA1", "12345678901234567890", 59, "94287082"},
+		{"SHA1", "12345678901234567890", 1111111109, "07081804"},
+		{"SHA1", "12345678901234567890", 1234567890, "89005924"},
+		{"SHA1", "12345678901234567890", 2000000000, "69279037"},
+		{"SHA256", "12345678901234567890123456789012", 59, "46119246"},
+		{"SHA512", strings.Repeat("1234567890", 6) + "1234", 59, "90693936"},
+	}
+	for _, tt := range tests {
+		totp := NewTOTP(TOTPConfig{Digits: 8, Algorithm: tt.algorithm})
+		code, err := totp.Code(base32NoPadding.EncodeToString([]byte(tt.secret)), time.Unix(tt.at, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "%s at %d", tt.algorithm, tt.at)
+	}
+}
+
+func TestTOTP_VerifyWithDrift(t *testing.T) {
+	totp := NewTOTP(TOTPConfig{Issuer: "Example"})
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	totp.now = func() time.Time { return now }
+
+	// 前后一个时间步内的验证码都有效
+	previous, err := totp.Code(secret, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	step, err := totp.Verify(secret, previous, 0)
+	require.NoError(t, err)
+	assert.Equal(t, now.Unix()/30-1, step)
+
+	tooOld, err := totp.Code(secret, now.Add(-90*time.Second))
+	require.NoError(t, err)
+	_, err = totp.Verify(secret, tooOld, 0)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	// 同一时间步或更早的验证码不能重复使用
+	_, err = totp.Verify(secret, previous, step)
+	assert.ErrorIs(t, err, ErrCodeReused)
+	current, err := totp.Code(secret, now)
+	require.NoError(t, err)
+	next, err := totp.Verify(secret, current, step)
+	require.NoError(t, err)
+	assert.Equal(t, step+1, next)
+
+	_, err = totp.Verify(secret, "12345", 0)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	_, err = totp.Verify("not base32!", current, 0)
+	assert.Error(t, err)
+}
+
+func TestTOTP_EnrollURI(t *testing.T) {
+	totp := NewTOTP(TOTPConfig{Issuer: "Example Inc"})
+	enrollment, err := totp.Enroll("alice@example.com")
+	require.NoError(t, err)
+	assert.Len(t, enrollment.Secret, 32)
+
+	u, err := url.Parse(enrollment.URI)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Example Inc:alice@example.com", u.Path)
+	assert.Equal(t, enrollment.Secret, u.Query().Get("secret"))
+	assert.Equal(t, "Example Inc", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+	assert.Equal(t, "30", u.Query().Get("period"))
+}
@@ -0,0 +1,459 @@
+package mfa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultWebAuthnTimeout 注册和登录仪式的默认超时时间
+const DefaultWebAuthnTimeout = 5 * time.Minute
+
+var (
+	// ErrWebAuthnFailed WebAuthn 响应校验失败，错误信息中包含具体原因
+	ErrWebAuthnFailed = errors.New("webauthn verification failed")
+	// ErrChallengeExpired 挑战已过期或不存在
+	ErrChallengeExpired = errors.New("webauthn challenge expired")
+	// ErrCredentialNotFound 断言使用的凭证未注册
+	ErrCredentialNotFound = errors.New("webauthn credential not found")
+	// ErrClonedAuthenticator 签名计数器回退，认证器可能被复制
+	ErrClonedAuthenticator = errors.New("webauthn sign count regression, authenticator may be cloned")
+)
+
+// authenticatorData 标志位（WebAuthn 6.1）
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// WebAuthnConfig WebAuthn 依赖方配置
+type WebAuthnConfig struct {
+	RPID    string        // 依赖方 ID，通常是注册域名，如 example.com
+	RPName  string        // 依赖方名称，显示在浏览器提示中
+	Origins []string      // 允许的来源，如 https://example.com
+	Timeout time.Duration // 仪式超时时间，默认 DefaultWebAuthnTimeout
+	// RequireUserVerification 要求认证器验证用户（PIN、生物识别），否则只要求用户在场
+	RequireUserVerification bool
+}
+
+// WebAuthn WebAuthn 依赖方（W3C Web Authentication Level 2）
+//
+// 设计原理：
+// 1. Begin* 生成随机挑战和传给 navigator.credentials.create/get 的选项，挑战由调用方保存到会话中
+// 2. Finish* 校验 clientDataJSON 的类型、挑战和来源，以及 authenticatorData 的 RP ID 哈希和标志位
+// 3. 注册时从 attestationObject 中取出 COSE 公钥；登录时用公钥验证签名，并检查签名计数器
+//
+// 注意事项：
+// - 请求 attestation: "none"，不验证认证器证明，不能用于限制认证器型号
+// - 支持 ES256、EdDSA 和 RS256 公钥
+// - JSON 格式与浏览器 PublicKeyCredential.toJSON() 一致，二进制字段使用 base64url
+type WebAuthn struct {
+	rpID    string
+	rpName  string
+	origins []string
+	timeout time.Duration
+	uv      bool
+	now     func() time.Time
+}
+
+// WebAuthnUser 注册凭证的用户
+type WebAuthnUser struct {
+	ID          string // 用户 ID，不应包含邮箱等个人信息
+	Name        string // 用户名
+	DisplayName string // 显示名称
+}
+
+// Credential 已注册的 WebAuthn 凭证
+type Credential struct {
+	ID         []byte    `json:"id"`
+	PublicKey  []byte    `json:"public_key"` // COSE_Key 编码的公钥
+	SignCount  uint32    `json:"sign_count"`
+	Transports []string  `json:"transports,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnChallenge 进行中的注册或登录仪式，需要在 Begin 和 Finish 之间保存
+type WebAuthnChallenge struct {
+	Challenge string    `json:"challenge"` // base64url
+	UserID    string    `json:"user_id"`
+	Expires   time.Time `json:"expires"`
+}
+
+// CredentialParameter 支持的公钥算法
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// CredentialDescriptor 凭证描述
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"` // base64url
+	Transports []string `json:"transports,omitempty"`
+}
+
+// CredentialCreationOptions 注册选项，对应 PublicKeyCredentialCreationOptionsJSON
+type CredentialCreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey      string `json:"residentKey"`
+		UserVerification string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// CredentialRequestOptions 登录选项，对应 PublicKeyCredentialRequestOptionsJSON
+type CredentialRequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse 浏览器返回的注册响应，对应 RegistrationResponseJSON
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse 浏览器返回的登录响应，对应 AuthenticationResponseJSON
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResult 登录校验结果
+type AssertionResult struct {
+	Credential   *Credential // 更新了签名计数器和使用时间的凭证，调用方需要保存
+	UserVerified bool        // 认证器是否验证了用户（PIN、生物识别）
+}
+
+// clientData 客户端数据（WebAuthn 5.8.1）
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// NewWebAuthn 创建 WebAuthn 依赖方
+func NewWebAuthn(config WebAuthnConfig) (*WebAuthn, error) {
+	if config.RPID == "" {
+		return nil, errors.New("webauthn requires an RP ID")
+	}
+	if len(config.Origins) == 0 {
+		return nil, errors.New("webauthn requires at least one origin")
+	}
+	if config.RPName == "" {
+		config.RPName = config.RPID
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultWebAuthnTimeout
+	}
+
+	return &WebAuthn{
+		rpID:    config.RPID,
+		rpName:  config.RPName,
+		origins: config.Origins,
+		timeout: config.Timeout,
+		uv:      config.RequireUserVerification,
+		now:     time.Now,
+	}, nil
+}
+
+// BeginRegistration 开始注册凭证
+//
+// 参数：
+//   - user: 注册凭证的用户
+//   - existing: 用户已注册的凭证，浏览器会拒绝在同一认证器上重复注册
+func (w *WebAuthn) BeginRegistration(user WebAuthnUser, existing []Credential) (*CredentialCreationOptions, *WebAuthnChallenge, error) {
+	challenge, err := w.newChallenge(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &CredentialCreationOptions{
+		Challenge: challenge.Challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: COSEAlgES256},
+			{Type: "public-key", Alg: COSEAlgEdDSA},
+			{Type: "public-key", Alg: COSEAlgRS256},
+		},
+		Timeout:            w.timeout.Milliseconds(),
+		ExcludeCredentials: descriptors(existing),
+		Attestation:        "none",
+	}
+	opts.RP.ID = w.rpID
+	opts.RP.Name = w.rpName
+	opts.User.ID = b64(user.ID)
+	opts.User.Name = user.Name
+	opts.User.DisplayName = user.DisplayName
+	if opts.User.DisplayName == "" {
+		opts.User.DisplayName = user.Name
+	}
+	opts.AuthenticatorSelection.ResidentKey = "preferred"
+	opts.AuthenticatorSelection.UserVerification = w.userVerification()
+	return opts, challenge, nil
+}
+
+// FinishRegistration 校验注册响应并返回新凭证
+//
+// 返回：
+//   - *Credential: 新凭证，调用方需要保存
+//   - error: 挑战过期返回 ErrChallengeExpired，其他校验失败返回 ErrWebAuthnFailed
+func (w *WebAuthn) FinishRegistration(challenge *WebAuthnChallenge, resp *RegistrationResponse) (*Credential, error) {
+	if _, err := w.verifyClientData(challenge, resp.Response.ClientDataJSON, "webauthn.create"); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := base64.RawURLEncoding.DecodeString(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid attestationObject encoding", ErrWebAuthnFailed)
+	}
+	value, _, err := decodeCBOR(rawAttestation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebAuthnFailed, err)
+	}
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: attestationObject is not a map", ErrWebAuthnFailed)
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing authData", ErrWebAuthnFailed)
+	}
+
+	flags, signCount, err := w.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&flagAttested == 0 {
+		return nil, fmt.Errorf("%w: missing attested credential data", ErrWebAuthnFailed)
+	}
+
+	// attestedCredentialData：aaguid(16) | credentialIdLength(2) | credentialId | credentialPublicKey
+	rest := authData[37:]
+	if len(rest) < 18 {
+		return nil, fmt.Errorf("%w: truncated attested credential data", ErrWebAuthnFailed)
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, fmt.Errorf("%w: invalid credential id", ErrWebAuthnFailed)
+	}
+	credentialID := rest[:idLen]
+	rest = rest[idLen:]
+	_, after, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebAuthnFailed, err)
+	}
+	publicKey := rest[:len(rest)-len(after)]
+	if _, _, err := parseCOSEKey(publicKey); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebAuthnFailed, err)
+	}
+	if resp.ID != "" && resp.ID != b64(string(credentialID)) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrWebAuthnFailed)
+	}
+
+	return &Credential{
+		ID:         append([]byte(nil), credentialID...),
+		PublicKey:  append([]byte(nil), publicKey...),
+		SignCount:  signCount,
+		Transports: resp.Response.Transports,
+		CreatedAt:  w.now(),
+	}, nil
+}
+
+// BeginLogin 开始使用已注册的凭证登录（作为第二因素）
+func (w *WebAuthn) BeginLogin(userID string, credentials []Credential) (*CredentialRequestOptions, *WebAuthnChallenge, error) {
+	if len(credentials) == 0 {
+		return nil, nil, ErrCredentialNotFound
+	}
+	challenge, err := w.newChallenge(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &CredentialRequestOptions{
+		Challenge:        challenge.Challenge,
+		Timeout:          w.timeout.Milliseconds(),
+		RPID:             w.rpID,
+		AllowCredentials: descriptors(credentials),
+		UserVerification: w.userVerification(),
+	}, challenge, nil
+}
+
+// FinishLogin 校验登录响应
+//
+// 返回：
+//   - *AssertionResult: 校验结果，其中的凭证更新了签名计数器，调用方需要保存
+//   - error: 凭证未注册返回 ErrCredentialNotFound，计数器回退返回 ErrClonedAuthenticator
+func (w *WebAuthn) FinishLogin(challenge *WebAuthnChallenge, credentials []Credential, resp *AssertionResponse) (*AssertionResult, error) {
+	rawClientData, err := w.verifyClientData(challenge, resp.Response.ClientDataJSON, "webauthn.get")
+	if err != nil {
+		return nil, err
+	}
+
+	credentialID, err := base64.RawURLEncoding.DecodeString(resp.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid credential id encoding", ErrWebAuthnFailed)
+	}
+	index := slices.IndexFunc(credentials, func(c Credential) bool { return bytes.Equal(c.ID, credentialID) })
+	if index < 0 {
+		return nil, ErrCredentialNotFound
+	}
+	credential := credentials[index]
+
+	authData, err := base64.RawURLEncoding.DecodeString(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid authenticatorData encoding", ErrWebAuthnFailed)
+	}
+	flags, signCount, err := w.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(resp.Response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature encoding", ErrWebAuthnFailed)
+	}
+	publicKey, alg, err := parseCOSEKey(credential.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebAuthnFailed, err)
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+	if !verifyCOSESignature(publicKey, alg, signed, signature) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrWebAuthnFailed)
+	}
+
+	// 计数器为 0 表示认证器不支持计数（如同步的通行密钥）
+	if signCount != 0 || credential.SignCount != 0 {
+		if signCount <= credential.SignCount {
+			return nil, ErrClonedAuthenticator
+		}
+	}
+
+	credential.SignCount = signCount
+	credential.LastUsedAt = w.now()
+	return &AssertionResult{
+		Credential:   &credential,
+		UserVerified: flags&flagUserVerified != 0,
+	}, nil
+}
+
+// newChallenge 生成 32 字节的随机挑战
+func (w *WebAuthn) newChallenge(userID string) (*WebAuthnChallenge, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate webauthn challenge: %w", err)
+	}
+	return &WebAuthnChallenge{
+		Challenge: base64.RawURLEncoding.EncodeToString(buf),
+		UserID:    userID,
+		Expires:   w.now().Add(w.timeout),
+	}, nil
+}
+
+// verifyClientData 校验 clientDataJSON 的类型、挑战和来源，返回原始 JSON
+func (w *WebAuthn) verifyClientData(challenge *WebAuthnChallenge, encoded, ceremony string) ([]byte, error) {
+	if challenge == nil || challenge.Challenge == "" || w.now().After(challenge.Expires) {
+		return nil, ErrChallengeExpired
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid clientDataJSON encoding", ErrWebAuthnFailed)
+	}
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("%w: invalid clientDataJSON", ErrWebAuthnFailed)
+	}
+	if cd.Type != ceremony {
+		return nil, fmt.Errorf("%w: unexpected client data type %q", ErrWebAuthnFailed, cd.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge.Challenge)) != 1 {
+		return nil, fmt.Errorf("%w: challenge mismatch", ErrWebAuthnFailed)
+	}
+	if !slices.Contains(w.origins, cd.Origin) {
+		return nil, fmt.Errorf("%w: origin %q not allowed", ErrWebAuthnFailed, cd.Origin)
+	}
+	return raw, nil
+}
+
+// verifyAuthenticatorData 校验 RP ID 哈希和用户在场、用户验证标志，返回标志位和签名计数器
+func (w *WebAuthn) verifyAuthenticatorData(authData []byte) (byte, uint32, error) {
+	if len(authData) < 37 {
+		return 0, 0, fmt.Errorf("%w: truncated authenticator data", ErrWebAuthnFailed)
+	}
+	rpIDHash := sha256.Sum256([]byte(w.rpID))
+	if subtle.ConstantTimeCompare(authData[:32], rpIDHash[:]) != 1 {
+		return 0, 0, fmt.Errorf("%w: rp id mismatch", ErrWebAuthnFailed)
+	}
+	flags := authData[32]
+	if flags&flagUserPresent == 0 {
+		return 0, 0, fmt.Errorf("%w: user not present", ErrWebAuthnFailed)
+	}
+	if w.uv && flags&flagUserVerified == 0 {
+		return 0, 0, fmt.Errorf("%w: user not verified", ErrWebAuthnFailed)
+	}
+	return flags, binary.BigEndian.Uint32(authData[33:37]), nil
+}
+
+// userVerification 返回选项中的 userVerification
+func (w *WebAuthn) userVerification() string {
+	if w.uv {
+		return "required"
+	}
+	return "preferred"
+}
+
+// descriptors 把凭证转换为描述列表
+func descriptors(credentials []Credential) []CredentialDescriptor {
+	list := make([]CredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		list = append(list, CredentialDescriptor{
+			Type:       "public-key",
+			ID:         base64.RawURLEncoding.EncodeToString(c.ID),
+			Transports: c.Transports,
+		})
+	}
+	return list
+}
+
+// b64 base64url 编码（无填充）
+func b64(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,263 @@
+package mfa
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+// encodeCBOR 测试用的最小 CBOR 编码器
+func encodeCBOR(v interface{}) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 256:
+			return []byte{major<<5 | 24, byte(n)}
+		case n < 65536:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		default:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+	}
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case map[interface{}]interface{}:
+		out := head(5, uint64(len(v)))
+		for key, value := range v {
+			out = append(out, encodeCBOR(key)...)
+			out = append(out, encodeCBOR(value)...)
+		}
+		return out
+	}
+	panic("unsupported cbor value")
+}
+
+// testAuthenticator 软件实现的 WebAuthn 认证器
+type testAuthenticator struct {
+	id      []byte
+	ec      *ecdsa.PrivateKey
+	ed      ed25519.PrivateKey
+	counter uint32
+	rpID    string
+	origin  string
+}
+
+func newTestAuthenticator(t *testing.T, alg int) *testAuthenticator {
+	t.Helper()
+	a := &testAuthenticator{id: make([]byte, 16), rpID: testRPID, origin: testOrigin}
+	_, err := rand.Read(a.id)
+	require.NoError(t, err)
+	if alg == COSEAlgEdDSA {
+		_, a.ed, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		a.ec, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	require.NoError(t, err)
+	return a
+}
+
+func (a *testAuthenticator) coseKey(t *testing.T) []byte {
+	if a.ed != nil {
+		return encodeCBOR(map[interface{}]interface{}{
+			1: 1, 3: COSEAlgEdDSA, -1: 6, -2: []byte(a.ed.Public().(ed25519.PublicKey)),
+		})
+	}
+	pub, err := a.ec.PublicKey.ECDH()
+	require.NoError(t, err)
+	point := pub.Bytes()
+	return encodeCBOR(map[interface{}]interface{}{
+		1: 2, 3: COSEAlgES256, -1: 1, -2: point[1:33], -3: point[33:],
+	})
+}
+
+func (a *testAuthenticator) clientData(ceremony, challenge string) string {
+	data, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": a.origin})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (a *testAuthenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.counter)
+}
+
+// register 模拟 navigator.credentials.create
+func (a *testAuthenticator) register(t *testing.T, opts *CredentialCreationOptions) *RegistrationResponse {
+	t.Helper()
+	authData := a.authData(flagUserPresent | flagUserVerified | flagAttested)
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.id)))
+	authData = append(authData, a.id...)
+	authData = append(authData, a.coseKey(t)...)
+
+	resp := &RegistrationResponse{ID: base64.RawURLEncoding.EncodeToString(a.id), Type: "public-key"}
+	resp.Response.ClientDataJSON = a.clientData("webauthn.create", opts.Challenge)
+	resp.Response.AttestationObject = base64.RawURLEncoding.EncodeToString(encodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": authData,
+	}))
+	resp.Response.Transports = []string{"internal"}
+	return resp
+}
+
+// assert 模拟 navigator.credentials.get
+func (a *testAuthenticator) assert(t *testing.T, opts *CredentialRequestOptions, userVerified bool) *AssertionResponse {
+	t.Helper()
+	a.counter++
+	flags := byte(flagUserPresent)
+	if userVerified {
+		flags |= flagUserVerified
+	}
+	authData := a.authData(flags)
+	clientData := a.clientData("webauthn.get", opts.Challenge)
+	rawClientData, _ := base64.RawURLEncoding.DecodeString(clientData)
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+
+	var sig []byte
+	if a.ed != nil {
+		sig = ed25519.Sign(a.ed, signed)
+	} else {
+		digest := sha256.Sum256(signed)
+		var err error
+		sig, err = ecdsa.SignASN1(rand.Reader, a.ec, digest[:])
+		require.NoError(t, err)
+	}
+
+	resp := &AssertionResponse{ID: base64.RawURLEncoding.EncodeToString(a.id), Type: "public-key"}
+	resp.Response.ClientDataJSON = clientData
+	resp.Response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authData)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString(sig)
+	return resp
+}
+
+func newTestWebAuthn(t *testing.T) *WebAuthn {
+	t.Helper()
+	w, err := NewWebAuthn(WebAuthnConfig{RPID: testRPID, RPName: "Example", Origins: []string{testOrigin}})
+	require.NoError(t, err)
+	return w
+}
+
+func TestWebAuthn_RegisterAndLogin(t *testing.T) {
+	for _, alg := range []int{COSEAlgES256, COSEAlgEdDSA} {
+		w := newTestWebAuthn(t)
+		authenticator := newTestAuthenticator(t, alg)
+		user := WebAuthnUser{ID: "user-123", Name: "alice"}
+
+		opts, challenge, err := w.BeginRegistration(user, nil)
+		require.NoError(t, err)
+		assert.Equal(t, testRPID, opts.RP.ID)
+		assert.Equal(t, "none", opts.Attestation)
+
+		credential, err := w.FinishRegistration(challenge, authenticator.register(t, opts))
+		require.NoError(t, err)
+		assert.Equal(t, authenticator.id, credential.ID)
+		assert.Equal(t, []string{"internal"}, credential.Transports)
+
+		loginOpts, loginChallenge, err := w.BeginLogin(user.ID, []Credential{*credential})
+		require.NoError(t, err)
+		require.Len(t, loginOpts.AllowCredentials, 1)
+
+		result, err := w.FinishLogin(loginChallenge, []Credential{*credential}, authenticator.assert(t, loginOpts, true))
+		require.NoError(t, err)
+		assert.True(t, result.UserVerified)
+		assert.Equal(t, uint32(1), result.Credential.SignCount)
+	}
+}
+
+func TestWebAuthn_RejectsInvalidResponses(t *testing.T) {
+	w := newTestWebAuthn(t)
+	authenticator := newTestAuthenticator(t, COSEAlgES256)
+	opts, challenge, err := w.BeginRegistration(WebAuthnUser{ID: "user-123", Name: "alice"}, nil)
+	require.NoError(t, err)
+
+	// 钓鱼网站的来源
+	phishing := *authenticator
+	phishing.origin = "https://examp1e.com"
+	_, err = w.FinishRegistration(challenge, phishing.register(t, opts))
+	assert.ErrorIs(t, err, ErrWebAuthnFailed)
+
+	// 其他 RP ID
+	otherRP := *authenticator
+	otherRP.rpID = "evil.com"
+	_, err = w.FinishRegistration(challenge, otherRP.register(t, opts))
+	assert.ErrorIs(t, err, ErrWebAuthnFailed)
+
+	// 其他挑战
+	otherOpts, _, err := w.BeginRegistration(WebAuthnUser{ID: "user-123"}, nil)
+	require.NoError(t, err)
+	_, err = w.FinishRegistration(challenge, authenticator.register(t, otherOpts))
+	assert.ErrorIs(t, err, ErrWebAuthnFailed)
+
+	// 过期的挑战
+	w.now = func() time.Time { return time.Now().Add(DefaultWebAuthnTimeout + time.Second) }
+	_, err = w.FinishRegistration(challenge, authenticator.register(t, opts))
+	assert.ErrorIs(t, err, ErrChallengeExpired)
+	w.now = time.Now
+
+	credential, err := w.FinishRegistration(challenge, authenticator.register(t, opts))
+	require.NoError(t, err)
+	credentials := []Credential{*credential}
+
+	// 篡改签名
+	loginOpts, loginChallenge, err := w.BeginLogin("user-123", credentials)
+	require.NoError(t, err)
+	resp := authenticator.assert(t, loginOpts, false)
+	resp.Response.Signature = base64.RawURLEncoding.EncodeToString([]byte("forged"))
+	_, err = w.FinishLogin(loginChallenge, credentials, resp)
+	assert.ErrorIs(t, err, ErrWebAuthnFailed)
+
+	// 签名计数器回退
+	credentials[0].SignCount = 100
+	_, err = w.FinishLogin(loginChallenge, credentials, authenticator.assert(t, loginOpts, false))
+	assert.ErrorIs(t, err, ErrClonedAuthenticator)
+
+	// 未注册的凭证
+	_, err = w.FinishLogin(loginChallenge, nil, authenticator.assert(t, loginOpts, false))
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+}
+
+func TestDecodeCBOR_Malformed(t *testing.T) {
+	for _, data := range [][]byte{
+		{},
+		{0x5a, 0xff, 0xff, 0xff, 0xff}, // 声明长度超过剩余数据
+		{0xbf},                         // 不定长映射
+		{0xa1, 0x40, 0x00},             // 字节串作为映射键
+	} {
+		_, _, err := decodeCBOR(data)
+		assert.Error(t, err, "%x", data)
+	}
+
+	// 嵌套过深
+	nested := make([]byte, cborMaxDepth+2)
+	for i := range nested {
+		nested[i] = 0x81
+	}
+	_, _, err := decodeCBOR(append(nested, 0x00))
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package security
 
 import (
 	"context"
+	"errors"
 	"net/http"
 )
 
@@ -14,6 +15,14 @@ type SecurityMiddleware struct {
 }
 
 // SecurityMiddlewareConfig 安全中间件配置
+//
+// 多副本部署时在 CSRF.Store 和 RateLimit.Store 中使用 Redis 存储，
+// 令牌和限流计数在所有副本之间共享：
+//
+//	config := security.SecurityMiddlewareConfig{
+//	    CSRF:      &security.CSRFConfig{Store: security.NewRedisCSRFTokenStore(client)},
+//	    RateLimit: &security.RateLimiterConfig{Limit: 100, Window: time.Minute, Store: security.NewRedisRateLimitStore(client)},
+//	}
 type SecurityMiddlewareConfig struct {
 	CSRF           *CSRFConfig
 	RateLimit      *RateLimiterConfig
@@ -55,27 +64,13 @@ func (sm *SecurityMiddleware) Middleware(next http.Handler) http.Handler {
 		}
 
 		// 2. 速率限制
-		if sm.rateLimiter != nil {
-			key := sm.getRateLimitKey(r)
-			allowed, err := sm.rateLimiter.Allow(r.Context(), key)
-			if err != nil || !allowed {
-				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
-				return
-			}
+		if sm.rateLimiter != nil && !sm.allowRequest(w, r) {
+			return
 		}
 
 		// 3. CSRF 保护（仅 POST/PUT/DELETE/PATCH）
-		if sm.csrfProtection != nil && sm.requiresCSRF(r.Method) {
-			sessionID := sm.getSessionID(r)
-			token := r.Header.Get("X-CSRF-Token")
-			if token == "" {
-				token = r.FormValue("csrf_token")
-			}
-
-			if err := sm.csrfProtection.ValidateToken(sessionID, token); err != nil {
-				http.Error(w, "Invalid CSRF token", http.StatusForbidden)
-				return
-			}
+		if sm.csrfProtection != nil && sm.requiresCSRF(r.Method) && !sm.validateCSRF(w, r) {
+			return
 		}
 
 		// 4. XSS 保护（清理输入）
@@ -93,6 +88,45 @@ func (sm *SecurityMiddleware) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// allowRequest 执行速率限制，拒绝时写入响应并返回 false
+//
+// 超过限制返回 429；存储不可用时返回 503，不放行请求
+func (sm *SecurityMiddleware) allowRequest(w http.ResponseWriter, r *http.Request) bool {
+	key := sm.getRateLimitKey(r)
+	allowed, err := sm.rateLimiter.Allow(r.Context(), key)
+	if allowed {
+		return true
+	}
+	if err != nil && !errors.Is(err, ErrRateLimitExceeded) {
+		http.Error(w, "Rate limiter unavailable", http.StatusServiceUnavailable)
+		return false
+	}
+	http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+	return false
+}
+
+// validateCSRF 验证 CSRF 令牌，失败时写入响应并返回 false
+//
+// 令牌无效或过期返回 403；存储不可用时返回 503
+func (sm *SecurityMiddleware) validateCSRF(w http.ResponseWriter, r *http.Request) bool {
+	sessionID := sm.getSessionID(r)
+	token := r.Header.Get("X-CSRF-Token")
+	if token == "" {
+		token = r.FormValue("csrf_token")
+	}
+
+	err := sm.csrfProtection.ValidateTokenContext(r.Context(), sessionID, token)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, ErrInvalidCSRFToken), errors.Is(err, ErrCSRFTokenExpired):
+		http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+	default:
+		http.Error(w, "CSRF validation unavailable", http.StatusServiceUnavailable)
+	}
+	return false
+}
+
 // getRateLimitKey 获取速率限制键
 func (sm *SecurityMiddleware) getRateLimitKey(r *http.Request) string {
 	// 优先使用 IP
@@ -163,17 +197,8 @@ func (sm *SecurityMiddleware) CSRFMiddleware(next http.Handler) http.Handler {
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if sm.requiresCSRF(r.Method) {
-			sessionID := sm.getSessionID(r)
-			token := r.Header.Get("X-CSRF-Token")
-			if token == "" {
-				token = r.FormValue("csrf_token")
-			}
-
-			if err := sm.csrfProtection.ValidateToken(sessionID, token); err != nil {
-				http.Error(w, "Invalid CSRF token", http.StatusForbidden)
-				return
-			}
+		if sm.requiresCSRF(r.Method) && !sm.validateCSRF(w, r) {
+			return
 		}
 
 		next.ServeHTTP(w, r)
@@ -187,10 +212,7 @@ func (sm *SecurityMiddleware) RateLimitMiddleware(next http.Handler) http.Handle
 	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		key := sm.getRateLimitKey(r)
-		allowed, err := sm.rateLimiter.Allow(r.Context(), key)
-		if err != nil || !allowed {
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		if !sm.allowRequest(w, r) {
 			return
 		}
 
@@ -1,6 +1,7 @@
 package security
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -108,6 +109,51 @@ func TestSecurityMiddleware_CSRF(t *testing.T) {
 	}
 }
 
+// ctxCSRFStore 在 ctx 已取消时返回 ctx.Err()，用于验证请求的 ctx 传递到了存储
+type ctxCSRFStore struct {
+	CSRFTokenStore
+}
+
+func (s ctxCSRFStore) Get(ctx context.Context, sessionID string) (*CSRFToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.CSRFTokenStore.Get(ctx, sessionID)
+}
+
+func TestSecurityMiddleware_CSRFUsesRequestContext(t *testing.T) {
+	store := NewMemoryCSRFTokenStore()
+	defer store.Close()
+	csrfConfig := DefaultCSRFConfig()
+	csrfConfig.Store = ctxCSRFStore{store}
+
+	middleware := NewSecurityMiddleware(SecurityMiddlewareConfig{CSRF: &csrfConfig})
+	defer middleware.Shutdown()
+
+	sessionID := "test-session"
+	token, err := middleware.csrfProtection.GenerateToken(sessionID)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	handler := middleware.CSRFMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// 请求已取消时不再访问存储
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("POST", "/", nil).WithContext(ctx)
+	req.Header.Set("X-CSRF-Token", token)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Canceled request should not reach the store, got %d", rr.Code)
+	}
+}
+
 func TestSecurityMiddleware_SecurityHeaders(t *testing.T) {
 	headersConfig := DefaultSecurityHeadersConfig()
 	config := SecurityMiddlewareConfig{
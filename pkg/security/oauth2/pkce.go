@@ -0,0 +1,42 @@
+package oauth2
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+// CodeChallengeMethodS256 PKCE S256 方法，授权服务器只支持该方法
+const CodeChallengeMethodS256 = "S256"
+
+// S256CodeChallenge 计算 code_verifier 的 S256 code_challenge（RFC 7636 4.2）
+func S256CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyCodeChallenge 检查 code_verifier 是否与 S256 code_challenge 匹配
+//
+// code_verifier 必须是 43 到 128 个非保留字符（RFC 7636 4.1）
+func VerifyCodeChallenge(verifier, challenge string) bool {
+	if !validCodeVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(S256CodeChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// validCodeVerifier 检查 code_verifier 的长度和字符集
+func validCodeVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
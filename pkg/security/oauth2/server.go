@@ -0,0 +1,582 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yourusername/golang/pkg/security"
+	"github.com/yourusername/golang/pkg/security/jwt"
+)
+
+// 授权服务器端点的默认路径
+const (
+	AuthorizePath  = "/oauth2/authorize"
+	TokenPath      = "/oauth2/token"
+	IntrospectPath = "/oauth2/introspect"
+	RevokePath     = "/oauth2/revoke"
+)
+
+// DefaultCodeTTL 授权码的默认有效期
+const DefaultCodeTTL = time.Minute
+
+// OAuth2 错误码（RFC 6749 4.1.2.1、5.2）
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorServerError             = "server_error"
+	ErrorTemporarilyUnavailable  = "temporarily_unavailable"
+)
+
+// Error OAuth2 错误响应
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	status      int
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// newError 创建 OAuth2 错误，status 为令牌端点返回的 HTTP 状态码
+func newError(status int, code, description string) *Error {
+	return &Error{Code: code, Description: description, status: status}
+}
+
+// Authenticator 在授权端点识别当前登录的用户
+//
+// 用户未登录时由 Authenticator 自行写入响应（如重定向到登录页）并返回 ok = false，
+// 登录后应当回到原来的授权 URL。
+type Authenticator func(w http.ResponseWriter, r *http.Request) (userID string, ok bool)
+
+// ServerConfig 授权服务器配置
+type ServerConfig struct {
+	// Tokens 签发和验证令牌，必填
+	Tokens *jwt.TokenManager
+	// Clients 客户端注册表，必填
+	Clients ClientStore
+	// Codes 授权码存储，为 nil 时使用 MemoryCodeStore
+	Codes CodeStore
+	// Authenticate 识别资源所有者，使用授权码流程时必填
+	Authenticate Authenticator
+	// Hasher 验证客户端密钥，为 nil 时使用默认参数的 PasswordHasher
+	Hasher *security.PasswordHasher
+	// CodeTTL 授权码有效期，默认 DefaultCodeTTL
+	CodeTTL time.Duration
+}
+
+// Server 最小化的 OAuth2 授权服务器
+//
+// 设计原理：
+// 1. 授权码流程（RFC 6749 4.1）：公共客户端必须使用 PKCE（RFC 7636），只支持 S256
+// 2. 客户端凭证流程（RFC 6749 4.4）：只允许机密客户端，只签发访问令牌
+// 3. 刷新令牌复用 jwt.TokenManager 的令牌族，每次刷新都会轮换，重用时撤销整个族
+// 4. 令牌内省（RFC 7662）和撤销（RFC 7009）基于 TokenManager 的撤销列表
+// 5. 令牌是 jwt.TokenManager 签发的 JWT，scope 和 client_id 写入声明，资源服务器可以通过 JWKS 本地验证
+//
+// 示例：
+//
+//	server, _ := oauth2.NewServer(oauth2.ServerConfig{
+//	    Tokens:       tokenManager,
+//	    Clients:      oauth2.NewMemoryClientStore(client),
+//	    Authenticate: sessionAuthenticator,
+//	})
+//	mux.Handle("/oauth2/", server.Handler())
+//	mux.Handle(jwt.JWKSPath, tokenManager.JWKSHandler())
+//
+// 注意事项：
+// - 不提供同意页面，适用于第一方客户端；需要用户同意时在 Authenticate 中实现
+// - 令牌端点、内省端点和撤销端点只接受 POST 表单，生产环境必须使用 HTTPS
+type Server struct {
+	tokens       *jwt.TokenManager
+	clients      ClientStore
+	codes        CodeStore
+	authenticate Authenticator
+	hasher       *security.PasswordHasher
+	codeTTL      time.Duration
+}
+
+// NewServer 创建授权服务器
+func NewServer(cfg ServerConfig) (*Server, error) {
+	if cfg.Tokens == nil {
+		return nil, errors.New("oauth2 server requires a token manager")
+	}
+	if cfg.Clients == nil {
+		return nil, errors.New("oauth2 server requires a client store")
+	}
+	if cfg.Codes == nil {
+		cfg.Codes = NewMemoryCodeStore()
+	}
+	if cfg.Hasher == nil {
+		cfg.Hasher = security.NewPasswordHasher(security.DefaultPasswordHashConfig())
+	}
+	if cfg.CodeTTL == 0 {
+		cfg.CodeTTL = DefaultCodeTTL
+	}
+
+	return &Server{
+		tokens:       cfg.Tokens,
+		clients:      cfg.Clients,
+		codes:        cfg.Codes,
+		authenticate: cfg.Authenticate,
+		hasher:       cfg.Hasher,
+		codeTTL:      cfg.CodeTTL,
+	}, nil
+}
+
+// Handler 返回挂载了所有端点的处理器，使用默认路径
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(AuthorizePath, s.HandleAuthorize)
+	mux.HandleFunc(TokenPath, s.HandleToken)
+	mux.HandleFunc(IntrospectPath, s.HandleIntrospect)
+	mux.HandleFunc(RevokePath, s.HandleRevoke)
+	return mux
+}
+
+// HandleAuthorize 授权端点（RFC 6749 4.1.1）
+//
+// 客户端或 redirect_uri 无效时直接返回 400，不重定向；其他错误重定向回客户端并带上 error 和 state
+func (s *Server) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.authenticate == nil {
+		http.Error(w, "authorization endpoint is not configured", http.StatusNotFound)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// 在确认 redirect_uri 之前不能重定向
+	client, err := s.clients.GetClient(r.Context(), r.Form.Get("client_id"))
+	if err != nil {
+		http.Error(w, "unknown client", http.StatusBadRequest)
+		return
+	}
+	redirectURI := r.Form.Get("redirect_uri")
+	target := redirectURI
+	if target == "" && len(client.RedirectURIs) == 1 {
+		target = client.RedirectURIs[0]
+	}
+	if target == "" || !client.AllowsRedirectURI(target) {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	state := r.Form.Get("state")
+	fail := func(code, description string) {
+		redirectWithParams(w, r, target, url.Values{
+			"error":             {code},
+			"error_description": {description},
+			"state":             {state},
+		})
+	}
+
+	if r.Form.Get("response_type") != "code" {
+		fail(ErrorUnsupportedResponseType, "only response_type=code is supported")
+		return
+	}
+	if !client.AllowsGrant(GrantTypeAuthorizationCode) {
+		fail(ErrorUnauthorizedClient, "client is not allowed to use the authorization code grant")
+		return
+	}
+	scope := strings.Fields(r.Form.Get("scope"))
+	if !client.AllowsScope(scope) {
+		fail(ErrorInvalidScope, "requested scope is not allowed")
+		return
+	}
+
+	challenge := r.Form.Get("code_challenge")
+	if challenge == "" && client.Public() {
+		fail(ErrorInvalidRequest, "public clients must use PKCE")
+		return
+	}
+	if challenge != "" && r.Form.Get("code_challenge_method") != CodeChallengeMethodS256 {
+		fail(ErrorInvalidRequest, "code_challenge_method must be S256")
+		return
+	}
+
+	userID, ok := s.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		fail(ErrorServerError, "failed to generate authorization code")
+		return
+	}
+	err = s.codes.Save(r.Context(), &AuthorizationCode{
+		Code:          code,
+		ClientID:      client.ID,
+		UserID:        userID,
+		Scope:         scope,
+		Expires:       time.Now().Add(s.codeTTL),
+		RedirectURI:   redirectURI,
+		CodeChallenge: challenge,
+	})
+	if err != nil {
+		fail(ErrorTemporarilyUnavailable, "failed to save authorization code")
+		return
+	}
+
+	redirectWithParams(w, r, target, url.Values{"code": {code}, "state": {state}})
+}
+
+// tokenResponse 令牌端点的成功响应（RFC 6749 5.1）
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// HandleToken 令牌端点（RFC 6749 3.2）
+//
+// 支持 authorization_code、refresh_token 和 client_credentials 授权类型。
+// 机密客户端通过 HTTP Basic 或表单中的 client_secret 认证，公共客户端只提供 client_id。
+func (s *Server) HandleToken(w http.ResponseWriter, r *http.Request) {
+	client, ok := s.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	grantType := r.PostForm.Get("grant_type")
+	if !client.AllowsGrant(grantType) {
+		switch grantType {
+		case GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials:
+			writeError(w, newError(http.StatusBadRequest, ErrorUnauthorizedClient, "client is not allowed to use this grant type"))
+		default:
+			writeError(w, newError(http.StatusBadRequest, ErrorUnsupportedGrantType, ""))
+		}
+		return
+	}
+
+	var (
+		resp *tokenResponse
+		err  error
+	)
+	switch grantType {
+	case GrantTypeAuthorizationCode:
+		resp, err = s.exchangeCode(r, client)
+	case GrantTypeRefreshToken:
+		resp, err = s.refresh(r, client)
+	case GrantTypeClientCredentials:
+		resp, err = s.clientCredentials(r, client)
+	default:
+		err = newError(http.StatusBadRequest, ErrorUnsupportedGrantType, "")
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// exchangeCode 使用授权码换取令牌（RFC 6749 4.1.3）
+func (s *Server) exchangeCode(r *http.Request, client *Client) (*tokenResponse, error) {
+	code, err := s.codes.Consume(r.Context(), r.PostForm.Get("code"))
+	if errors.Is(err, ErrCodeNotFound) {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "authorization code is invalid or expired")
+	}
+	if err != nil {
+		return nil, newError(http.StatusServiceUnavailable, ErrorTemporarilyUnavailable, "")
+	}
+
+	if code.ClientID != client.ID {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "authorization code was issued to another client")
+	}
+	if code.RedirectURI != "" && r.PostForm.Get("redirect_uri") != code.RedirectURI {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "redirect_uri does not match")
+	}
+	if code.CodeChallenge != "" && !VerifyCodeChallenge(r.PostForm.Get("code_verifier"), code.CodeChallenge) {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "code_verifier does not match")
+	}
+
+	claims := jwt.Claims{UserID: code.UserID, Scope: code.Scope, ClientID: client.ID}
+	if !client.AllowsGrant(GrantTypeRefreshToken) {
+		return s.issueAccessToken(claims)
+	}
+	pair, err := s.tokens.IssueTokenPair(r.Context(), claims)
+	if err != nil {
+		return nil, newError(http.StatusInternalServerError, ErrorServerError, "failed to issue tokens")
+	}
+	return &tokenResponse{
+		AccessToken:  pair.AccessToken,
+		TokenType:    pair.TokenType,
+		ExpiresIn:    pair.ExpiresIn,
+		RefreshToken: pair.RefreshToken,
+		Scope:        strings.Join(code.Scope, " "),
+	}, nil
+}
+
+// refresh 使用刷新令牌换取新的令牌对（RFC 6749 6），scope 保持不变
+func (s *Server) refresh(r *http.Request, client *Client) (*tokenResponse, error) {
+	refreshToken := r.PostForm.Get("refresh_token")
+	claims, err := s.tokens.ValidateToken(refreshToken)
+	if err != nil || claims.Family == "" {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "refresh token is invalid")
+	}
+	if claims.ClientID != client.ID {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "refresh token was issued to another client")
+	}
+
+	pair, err := s.tokens.RefreshAccessTokenContext(r.Context(), refreshToken)
+	if errors.Is(err, jwt.ErrRevocationUnavailable) {
+		return nil, newError(http.StatusServiceUnavailable, ErrorTemporarilyUnavailable, "")
+	}
+	if err != nil {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidGrant, "refresh token is invalid")
+	}
+	return &tokenResponse{
+		AccessToken:  pair.AccessToken,
+		TokenType:    pair.TokenType,
+		ExpiresIn:    pair.ExpiresIn,
+		RefreshToken: pair.RefreshToken,
+		Scope:        strings.Join(claims.Scope, " "),
+	}, nil
+}
+
+// clientCredentials 客户端凭证授权（RFC 6749 4.4），令牌主体为客户端本身
+func (s *Server) clientCredentials(r *http.Request, client *Client) (*tokenResponse, error) {
+	if client.Public() {
+		return nil, newError(http.StatusBadRequest, ErrorUnauthorizedClient, "public clients cannot use client credentials")
+	}
+	scope := strings.Fields(r.PostForm.Get("scope"))
+	if !client.AllowsScope(scope) {
+		return nil, newError(http.StatusBadRequest, ErrorInvalidScope, "requested scope is not allowed")
+	}
+	return s.issueAccessToken(jwt.Claims{UserID: client.ID, Scope: scope, ClientID: client.ID})
+}
+
+// issueAccessToken 只签发访问令牌
+func (s *Server) issueAccessToken(claims jwt.Claims) (*tokenResponse, error) {
+	token, err := s.tokens.IssueAccessToken(claims)
+	if err != nil {
+		return nil, newError(http.StatusInternalServerError, ErrorServerError, "failed to issue token")
+	}
+	return &tokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.tokens.AccessTokenTTL().Seconds()),
+		Scope:       strings.Join(claims.Scope, " "),
+	}, nil
+}
+
+// introspectionResponse 内省响应（RFC 7662 2.2）
+type introspectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	JTI       string `json:"jti,omitempty"`
+}
+
+// HandleIntrospect 令牌内省端点（RFC 7662）
+//
+// 只允许机密客户端（通常是资源服务器）调用。令牌无效、过期或已撤销时返回 {"active": false}，
+// 不说明具体原因；撤销列表不可用时返回 503。
+func (s *Server) HandleIntrospect(w http.ResponseWriter, r *http.Request) {
+	client, ok := s.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+	if client.Public() {
+		writeError(w, newError(http.StatusUnauthorized, ErrorInvalidClient, "public clients cannot introspect tokens"))
+		return
+	}
+
+	claims, err := s.tokens.VerifyToken(r.Context(), r.PostForm.Get("token"))
+	if errors.Is(err, jwt.ErrRevocationUnavailable) {
+		writeError(w, newError(http.StatusServiceUnavailable, ErrorTemporarilyUnavailable, ""))
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusOK, &introspectionResponse{Active: false})
+		return
+	}
+
+	resp := &introspectionResponse{
+		Active:   true,
+		Scope:    strings.Join(claims.Scope, " "),
+		ClientID: claims.ClientID,
+		Username: claims.Username,
+		Subject:  claims.Subject,
+		Issuer:   claims.Issuer,
+		JTI:      claims.ID,
+	}
+	if claims.Family == "" {
+		resp.TokenType = "Bearer"
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		resp.NotBefore = claims.NotBefore.Unix()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// HandleRevoke 令牌撤销端点（RFC 7009）
+//
+// 撤销刷新令牌时同时撤销所属的令牌族。无效或已过期的令牌按 RFC 7009 返回 200；
+// 令牌不属于调用的客户端时返回 unauthorized_client。
+func (s *Server) HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	client, ok := s.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	claims, err := s.tokens.ValidateToken(token)
+	if err != nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if claims.ClientID != client.ID {
+		writeError(w, newError(http.StatusBadRequest, ErrorUnauthorizedClient, "token was issued to another client"))
+		return
+	}
+	if err := s.tokens.RevokeToken(r.Context(), token); err != nil {
+		writeError(w, newError(http.StatusServiceUnavailable, ErrorTemporarilyUnavailable, ""))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// authenticateClient 解析 POST 表单并认证客户端（RFC 6749 2.3.1）
+//
+// 失败时写入错误响应并返回 ok = false
+func (s *Server) authenticateClient(w http.ResponseWriter, r *http.Request) (*Client, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, newError(http.StatusMethodNotAllowed, ErrorInvalidRequest, "method must be POST"))
+		return nil, false
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, newError(http.StatusBadRequest, ErrorInvalidRequest, "invalid form body"))
+		return nil, false
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// Basic 认证中的凭证经过 application/x-www-form-urlencoded 编码
+		var errID, errSecret error
+		clientID, errID = url.QueryUnescape(clientID)
+		secret, errSecret = url.QueryUnescape(secret)
+		if errID != nil || errSecret != nil {
+			s.rejectClient(w, basic)
+			return nil, false
+		}
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+	if clientID == "" {
+		s.rejectClient(w, basic)
+		return nil, false
+	}
+
+	client, err := s.clients.GetClient(r.Context(), clientID)
+	if errors.Is(err, ErrClientNotFound) {
+		s.rejectClient(w, basic)
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, newError(http.StatusServiceUnavailable, ErrorTemporarilyUnavailable, ""))
+		return nil, false
+	}
+	if client.Public() {
+		return client, true
+	}
+
+	ok, err := s.hasher.Verify(secret, client.SecretHash)
+	if err != nil || !ok {
+		s.rejectClient(w, basic)
+		return nil, false
+	}
+	return client, true
+}
+
+// rejectClient 返回 invalid_client，使用 Basic 认证时带上 WWW-Authenticate
+func (s *Server) rejectClient(w http.ResponseWriter, basic bool) {
+	if basic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+	}
+	writeError(w, newError(http.StatusUnauthorized, ErrorInvalidClient, "client authentication failed"))
+}
+
+// writeError 写入 OAuth2 错误响应，非 *Error 的错误作为 server_error
+func writeError(w http.ResponseWriter, err error) {
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) {
+		oauthErr = newError(http.StatusInternalServerError, ErrorServerError, "")
+	}
+	writeJSON(w, oauthErr.status, oauthErr)
+}
+
+// writeJSON 写入不可缓存的 JSON 响应（RFC 6749 5.1）
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// redirectWithParams 把参数追加到回调地址的查询字符串后重定向，忽略空值
+func redirectWithParams(w http.ResponseWriter, r *http.Request, target string, params url.Values) {
+	u, err := url.Parse(target)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// randomToken 生成 256 位随机授权码
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
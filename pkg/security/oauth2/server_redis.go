@@ -0,0 +1,80 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKeyPrefix Redis 授权码存储的默认键前缀
+const DefaultRedisKeyPrefix = "oauth2:code:"
+
+// redisStoreOptions Redis 存储的可选配置
+type redisStoreOptions struct {
+	prefix string
+}
+
+// RedisStoreOption Redis 存储配置选项
+type RedisStoreOption func(*redisStoreOptions)
+
+// WithRedisKeyPrefix 设置键前缀，默认 DefaultRedisKeyPrefix
+func WithRedisKeyPrefix(prefix string) RedisStoreOption {
+	return func(o *redisStoreOptions) {
+		o.prefix = prefix
+	}
+}
+
+// RedisCodeStore 基于 Redis 的授权码存储
+//
+// 授权码以 JSON 保存为 <prefix><code>，Consume 使用 GETDEL 保证只能使用一次
+type RedisCodeStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisCodeStore 创建 Redis 授权码存储
+//
+// 参数：
+//   - client: Redis 客户端，由调用方负责关闭
+//   - opts: 可选配置，如 WithRedisKeyPrefix
+func NewRedisCodeStore(client redis.UniversalClient, opts ...RedisStoreOption) *RedisCodeStore {
+	o := redisStoreOptions{prefix: DefaultRedisKeyPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &RedisCodeStore{client: client, prefix: o.prefix}
+}
+
+// Save 保存授权码
+func (s *RedisCodeStore) Save(ctx context.Context, code *AuthorizationCode) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("failed to marshal authorization code: %w", err)
+	}
+	ttl := time.Until(code.Expires)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.prefix+code.Code, data, ttl).Err()
+}
+
+// Consume 取出并删除授权码
+func (s *RedisCodeStore) Consume(ctx context.Context, code string) (*AuthorizationCode, error) {
+	data, err := s.client.GetDel(ctx, s.prefix+code).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var c AuthorizationCode
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal authorization code: %w", err)
+	}
+	return &c, nil
+}
@@ -0,0 +1,183 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// 授权类型
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+var (
+	// ErrClientNotFound 客户端未注册
+	ErrClientNotFound = errors.New("oauth2 client not found")
+	// ErrCodeNotFound 授权码不存在、已过期或已使用
+	ErrCodeNotFound = errors.New("authorization code not found")
+)
+
+// Client 授权服务器中注册的 OAuth2 客户端
+//
+// SecretHash 为空的是公共客户端（如单页应用、移动应用），不能保存密钥，
+// 必须使用 PKCE，也不能使用 client_credentials 授权。
+type Client struct {
+	ID string `json:"id"`
+	// SecretHash 客户端密钥的 security.PasswordHasher 哈希，不保存明文
+	SecretHash string `json:"secret_hash,omitempty"`
+	// RedirectURIs 允许的回调地址，授权请求中的 redirect_uri 必须与其中之一完全相同
+	RedirectURIs []string `json:"redirect_uris"`
+	// GrantTypes 允许的授权类型，如 GrantTypeAuthorizationCode、GrantTypeRefreshToken
+	GrantTypes []string `json:"grant_types"`
+	// Scopes 允许申请的 scope
+	Scopes []string `json:"scopes"`
+}
+
+// Public 返回是否为公共客户端
+func (c *Client) Public() bool {
+	return c.SecretHash == ""
+}
+
+// AllowsGrant 返回是否允许使用授权类型
+func (c *Client) AllowsGrant(grantType string) bool {
+	return slices.Contains(c.GrantTypes, grantType)
+}
+
+// AllowsRedirectURI 返回回调地址是否已注册，按字符串完全匹配
+func (c *Client) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// AllowsScope 返回申请的 scope 是否都在允许范围内
+func (c *Client) AllowsScope(scope []string) bool {
+	for _, s := range scope {
+		if !slices.Contains(c.Scopes, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClientStore 客户端注册表
+type ClientStore interface {
+	// GetClient 按 ID 获取客户端，未注册时返回 ErrClientNotFound
+	GetClient(ctx context.Context, clientID string) (*Client, error)
+}
+
+// AuthorizationCode 授权码及其绑定的授权请求
+type AuthorizationCode struct {
+	Code     string    `json:"code"`
+	ClientID string    `json:"client_id"`
+	UserID   string    `json:"user_id"`
+	Scope    []string  `json:"scope,omitempty"`
+	Expires  time.Time `json:"expires"`
+	// RedirectURI 授权请求中的 redirect_uri，非空时令牌请求必须带相同的值
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	// CodeChallenge PKCE code_challenge，只支持 S256
+	CodeChallenge string `json:"code_challenge,omitempty"`
+}
+
+// CodeStore 授权码存储
+//
+// 授权码只能使用一次。多副本部署时授权请求和令牌请求可能落在不同副本上，
+// 需要使用共享存储（如 RedisCodeStore）。
+type CodeStore interface {
+	// Save 保存授权码，保留到 code.Expires
+	Save(ctx context.Context, code *AuthorizationCode) error
+	// Consume 原子地取出并删除授权码，不存在或已过期时返回 ErrCodeNotFound
+	Consume(ctx context.Context, code string) (*AuthorizationCode, error)
+}
+
+// MemoryClientStore 进程内客户端注册表
+type MemoryClientStore struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewMemoryClientStore 创建进程内客户端注册表
+func NewMemoryClientStore(clients ...*Client) *MemoryClientStore {
+	s := &MemoryClientStore{clients: make(map[string]*Client)}
+	for _, c := range clients {
+		s.Register(c)
+	}
+	return s
+}
+
+// Register 注册客户端，已存在相同 ID 时覆盖
+func (s *MemoryClientStore) Register(client *Client) {
+	s.mu.Lock()
+	s.clients[client.ID] = client
+	s.mu.Unlock()
+}
+
+// Remove 删除客户端，已签发的令牌在过期前仍然有效
+func (s *MemoryClientStore) Remove(clientID string) {
+	s.mu.Lock()
+	delete(s.clients, clientID)
+	s.mu.Unlock()
+}
+
+// GetClient 按 ID 获取客户端
+func (s *MemoryClientStore) GetClient(ctx context.Context, clientID string) (*Client, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	client, ok := s.clients[clientID]
+	if !ok {
+		return nil, ErrClientNotFound
+	}
+	return client, nil
+}
+
+// MemoryCodeStore 进程内授权码存储
+//
+// 过期的授权码在 Save 时顺带清理。仅适用于单实例部署。
+type MemoryCodeStore struct {
+	mu    sync.Mutex
+	codes map[string]*AuthorizationCode
+	now   func() time.Time
+}
+
+// NewMemoryCodeStore 创建进程内授权码存储
+func NewMemoryCodeStore() *MemoryCodeStore {
+	return &MemoryCodeStore{
+		codes: make(map[string]*AuthorizationCode),
+		now:   time.Now,
+	}
+}
+
+// Save 保存授权码
+func (s *MemoryCodeStore) Save(ctx context.Context, code *AuthorizationCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, c := range s.codes {
+		if now.After(c.Expires) {
+			delete(s.codes, id)
+		}
+	}
+	s.codes[code.Code] = code
+	return nil
+}
+
+// Consume 取出并删除授权码
+func (s *MemoryCodeStore) Consume(ctx context.Context, code string) (*AuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.codes[code]
+	if !ok {
+		return nil, ErrCodeNotFound
+	}
+	delete(s.codes, code)
+	if s.now().After(c.Expires) {
+		return nil, ErrCodeNotFound
+	}
+	return c, nil
+}
@@ -0,0 +1,388 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/golang/pkg/security"
+	"github.com/yourusername/golang/pkg/security/jwt"
+)
+
+const (
+	testRedirectURI = "https://app.example.com/callback"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// testServer 授权服务器及测试用客户端
+type testServer struct {
+	*Server
+	handler http.Handler
+	tokens  *jwt.TokenManager
+}
+
+// newTestServer 创建带有公共客户端 spa、机密客户端 api 和 worker 的授权服务器
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	hasher := security.NewPasswordHasher(security.PasswordHashConfig{
+		Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32,
+	})
+	hash := func(secret string) string {
+		h, err := hasher.Hash(secret)
+		require.NoError(t, err)
+		return h
+	}
+
+	tokens, err := jwt.NewTokenManager(jwt.Config{SigningMethod: "ES256"})
+	require.NoError(t, err)
+	clients := NewMemoryClientStore(
+		&Client{
+			ID:           "spa",
+			RedirectURIs: []string{testRedirectURI},
+			GrantTypes:   []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken},
+			Scopes:       []string{"profile", "orders:read"},
+		},
+		&Client{
+			ID:         "api",
+			SecretHash: hash("api-secret"),
+			GrantTypes: []string{GrantTypeClientCredentials},
+			Scopes:     []string{"introspect"},
+		},
+		&Client{
+			ID:         "worker",
+			SecretHash: hash("worker-secret"),
+			GrantTypes: []string{GrantTypeClientCredentials},
+			Scopes:     []string{"orders:write"},
+		},
+	)
+
+	server, err := NewServer(ServerConfig{
+		Tokens:  tokens,
+		Clients: clients,
+		Hasher:  hasher,
+		Authenticate: func(w http.ResponseWriter, r *http.Request) (string, bool) {
+			user := r.Header.Get("X-User")
+			if user == "" {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return "", false
+			}
+			return user, true
+		},
+	})
+	require.NoError(t, err)
+	return &testServer{Server: server, handler: server.Handler(), tokens: tokens}
+}
+
+// authorize 以 alice 的身份发起授权请求，返回回调地址的查询参数
+func (s *testServer) authorize(t *testing.T, params url.Values) url.Values {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, AuthorizePath+"?"+params.Encode(), nil)
+	req.Header.Set("X-User", "alice")
+	rr := httptest.NewRecorder()
+	s.handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+
+	location, err := url.Parse(rr.Header().Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, testRedirectURI, location.Scheme+"://"+location.Host+location.Path)
+	return location.Query()
+}
+
+// post 向端点提交表单，user 非空时使用 HTTP Basic 认证
+func (s *testServer) post(t *testing.T, path string, form url.Values, user, password string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	rr := httptest.NewRecorder()
+	s.handler.ServeHTTP(rr, req)
+
+	body := make(map[string]interface{})
+	if rr.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	}
+	return rr, body
+}
+
+// introspect 以 api 客户端的身份内省令牌
+func (s *testServer) introspect(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	rr, body := s.post(t, IntrospectPath, url.Values{"token": {token}}, "api", "api-secret")
+	require.Equal(t, http.StatusOK, rr.Code)
+	return body
+}
+
+func authorizeParams() url.Values {
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"spa"},
+		"redirect_uri":          {testRedirectURI},
+		"scope":                 {"profile orders:read"},
+		"state":                 {"xyz"},
+		"code_challenge":        {S256CodeChallenge(testVerifier)},
+		"code_challenge_method": {CodeChallengeMethodS256},
+	}
+}
+
+func TestServer_AuthorizationCodeWithPKCE(t *testing.T) {
+	s := newTestServer(t)
+
+	callback := s.authorize(t, authorizeParams())
+	assert.Equal(t, "xyz", callback.Get("state"))
+	code := callback.Get("code")
+	require.NotEmpty(t, code)
+
+	exchange := url.Values{
+		"grant_type":    {GrantTypeAuthorizationCode},
+		"client_id":     {"spa"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {testVerifier},
+	}
+	rr, body := s.post(t, TokenPath, exchange, "", "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, "Bearer", body["token_type"])
+	assert.Equal(t, "profile orders:read", body["scope"])
+	accessToken := body["access_token"].(string)
+	refreshToken := body["refresh_token"].(string)
+
+	claims, err := s.tokens.VerifyAccessToken(context.Background(), accessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.Equal(t, "spa", claims.ClientID)
+	assert.Equal(t, []string{"profile", "orders:read"}, claims.Scope)
+
+	// 授权码只能使用一次
+	rr, body = s.post(t, TokenPath, exchange, "", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ErrorInvalidGrant, body["error"])
+
+	info := s.introspect(t, accessToken)
+	assert.Equal(t, true, info["active"])
+	assert.Equal(t, "alice", info["sub"])
+	assert.Equal(t, "spa", info["client_id"])
+	assert.Equal(t, "profile orders:read", info["scope"])
+
+	// 刷新后保留 scope 和客户端
+	rr, body = s.post(t, TokenPath, url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"client_id":     {"spa"},
+		"refresh_token": {refreshToken},
+	}, "", "")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "profile orders:read", body["scope"])
+	refreshed, err := s.tokens.VerifyAccessToken(context.Background(), body["access_token"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "spa", refreshed.ClientID)
+	assert.Equal(t, claims.Scope, refreshed.Scope)
+
+	// 撤销后内省返回 inactive
+	rr, _ = s.post(t, RevokePath, url.Values{"client_id": {"spa"}, "token": {accessToken}}, "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, false, s.introspect(t, accessToken)["active"])
+}
+
+func TestServer_PKCERequired(t *testing.T) {
+	s := newTestServer(t)
+
+	// 公共客户端必须提供 code_challenge
+	params := authorizeParams()
+	params.Del("code_challenge")
+	callback := s.authorize(t, params)
+	assert.Equal(t, ErrorInvalidRequest, callback.Get("error"))
+	assert.Equal(t, "xyz", callback.Get("state"))
+
+	params = authorizeParams()
+	params.Set("code_challenge_method", "plain")
+	assert.Equal(t, ErrorInvalidRequest, s.authorize(t, params).Get("error"))
+
+	// code_verifier 不匹配
+	code := s.authorize(t, authorizeParams()).Get("code")
+	rr, body := s.post(t, TokenPath, url.Values{
+		"grant_type":    {GrantTypeAuthorizationCode},
+		"client_id":     {"spa"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {strings.Repeat("a", 43)},
+	}, "", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ErrorInvalidGrant, body["error"])
+}
+
+func TestServer_AuthorizeErrors(t *testing.T) {
+	s := newTestServer(t)
+
+	// 未注册的回调地址不重定向
+	params := authorizeParams()
+	params.Set("redirect_uri", "https://evil.example.com/callback")
+	req := httptest.NewRequest(http.MethodGet, AuthorizePath+"?"+params.Encode(), nil)
+	req.Header.Set("X-User", "alice")
+	rr := httptest.NewRecorder()
+	s.handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Empty(t, rr.Header().Get("Location"))
+
+	params = authorizeParams()
+	params.Set("response_type", "token")
+	assert.Equal(t, ErrorUnsupportedResponseType, s.authorize(t, params).Get("error"))
+
+	params = authorizeParams()
+	params.Set("scope", "admin")
+	assert.Equal(t, ErrorInvalidScope, s.authorize(t, params).Get("error"))
+
+	// 未登录时交给 Authenticator 处理
+	req = httptest.NewRequest(http.MethodGet, AuthorizePath+"?"+authorizeParams().Encode(), nil)
+	rr = httptest.NewRecorder()
+	s.handler.ServeHTTP(rr, req)
+	assert.Equal(t, "/login", rr.Header().Get("Location"))
+}
+
+func TestServer_ClientCredentials(t *testing.T) {
+	s := newTestServer(t)
+
+	rr, body := s.post(t, TokenPath, url.Values{
+		"grant_type": {GrantTypeClientCredentials},
+		"scope":      {"orders:write"},
+	}, "worker", "worker-secret")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Nil(t, body["refresh_token"])
+	claims, err := s.tokens.VerifyAccessToken(context.Background(), body["access_token"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "worker", claims.Subject)
+	assert.Equal(t, []string{"orders:write"}, claims.Scope)
+
+	// 表单中的 client_secret 同样可以认证
+	rr, _ = s.post(t, TokenPath, url.Values{
+		"grant_type":    {GrantTypeClientCredentials},
+		"client_id":     {"worker"},
+		"client_secret": {"worker-secret"},
+	}, "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr, body = s.post(t, TokenPath, url.Values{"grant_type": {GrantTypeClientCredentials}}, "worker", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, ErrorInvalidClient, body["error"])
+	assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+
+	rr, body = s.post(t, TokenPath, url.Values{
+		"grant_type": {GrantTypeClientCredentials},
+		"scope":      {"introspect"},
+	}, "worker", "worker-secret")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ErrorInvalidScope, body["error"])
+
+	// 公共客户端不能使用客户端凭证
+	rr, body = s.post(t, TokenPath, url.Values{
+		"grant_type": {GrantTypeClientCredentials},
+		"client_id":  {"spa"},
+	}, "", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ErrorUnauthorizedClient, body["error"])
+}
+
+func TestServer_IntrospectAndRevoke(t *testing.T) {
+	s := newTestServer(t)
+
+	_, body := s.post(t, TokenPath, url.Values{"grant_type": {GrantTypeClientCredentials}}, "worker", "worker-secret")
+	token := body["access_token"].(string)
+
+	// 公共客户端不能内省
+	rr, body := s.post(t, IntrospectPath, url.Values{"client_id": {"spa"}, "token": {token}}, "", "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, ErrorInvalidClient, body["error"])
+
+	assert.Equal(t, false, s.introspect(t, "not-a-token")["active"])
+
+	// 只能撤销签发给自己的令牌；无效令牌返回 200
+	rr, body = s.post(t, RevokePath, url.Values{"token": {token}}, "api", "api-secret")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ErrorUnauthorizedClient, body["error"])
+	rr, _ = s.post(t, RevokePath, url.Values{"token": {"not-a-token"}}, "worker", "worker-secret")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr, _ = s.post(t, RevokePath, url.Values{"token": {token}}, "worker", "worker-secret")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, false, s.introspect(t, token)["active"])
+
+	req := httptest.NewRequest(http.MethodGet, TokenPath, nil)
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestServer_RevokeRefreshTokenRevokesFamily(t *testing.T) {
+	s := newTestServer(t)
+
+	code := s.authorize(t, authorizeParams()).Get("code")
+	_, body := s.post(t, TokenPath, url.Values{
+		"grant_type":    {GrantTypeAuthorizationCode},
+		"client_id":     {"spa"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {testVerifier},
+	}, "", "")
+	refreshToken := body["refresh_token"].(string)
+
+	rr, _ := s.post(t, RevokePath, url.Values{"client_id": {"spa"}, "token": {refreshToken}}, "", "")
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr, body = s.post(t, TokenPath, url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"client_id":     {"spa"},
+		"refresh_token": {refreshToken},
+	}, "", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, ErrorInvalidGrant, body["error"])
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// RFC 7636 附录 B 的示例
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", S256CodeChallenge(testVerifier))
+	assert.True(t, VerifyCodeChallenge(testVerifier, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"))
+	assert.False(t, VerifyCodeChallenge("short", S256CodeChallenge("short")))
+	assert.False(t, VerifyCodeChallenge(strings.Repeat("a", 42)+"!", S256CodeChallenge(strings.Repeat("a", 42)+"!")))
+}
+
+func TestRedisCodeStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisCodeStore(client, WithRedisKeyPrefix("auth:code:"))
+	ctx := context.Background()
+
+	code := &AuthorizationCode{
+		Code:     "abc",
+		ClientID: "spa",
+		UserID:   "alice",
+		Scope:    []string{"profile"},
+		Expires:  time.Now().Add(time.Minute),
+	}
+	require.NoError(t, store.Save(ctx, code))
+	assert.True(t, mr.Exists("auth:code:abc"))
+
+	got, err := store.Consume(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", got.UserID)
+	assert.Equal(t, []string{"profile"}, got.Scope)
+
+	_, err = store.Consume(ctx, "abc")
+	assert.ErrorIs(t, err, ErrCodeNotFound)
+
+	require.NoError(t, store.Save(ctx, code))
+	mr.FastForward(2 * time.Minute)
+	_, err = store.Consume(ctx, "abc")
+	assert.ErrorIs(t, err, ErrCodeNotFound)
+}
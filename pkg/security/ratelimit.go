@@ -3,7 +3,6 @@ package security
 import (
 	"context"
 	"errors"
-	"sync"
 	"time"
 )
 
@@ -13,123 +12,73 @@ var (
 )
 
 // RateLimiter 速率限制器
+//
+// 使用滑动窗口计数，请求记录保存在 RateLimitStore 中，默认使用进程内的 MemoryRateLimitStore；
+// 多副本部署时通过 RateLimiterConfig.Store 传入 RedisRateLimitStore，使限制对所有副本共同生效。
 type RateLimiter struct {
 	limit     int
 	window    time.Duration
-	requests  map[string][]time.Time
-	mu        sync.RWMutex
-	cleanup   *time.Ticker
-	stopCleanup chan struct{}
+	store     RateLimitStore
+	ownsStore bool
 }
 
 // RateLimiterConfig 速率限制器配置
 type RateLimiterConfig struct {
-	Limit  int           // 限制数量
-	Window time.Duration // 时间窗口
+	Limit  int            // 限制数量
+	Window time.Duration  // 时间窗口
+	Store  RateLimitStore // 请求记录存储，为 nil 时使用 MemoryRateLimitStore
 }
 
 // NewRateLimiter 创建速率限制器
 func NewRateLimiter(config RateLimiterConfig) *RateLimiter {
 	rl := &RateLimiter{
-		limit:     config.Limit,
-		window:    config.Window,
-		requests:  make(map[string][]time.Time),
-		stopCleanup: make(chan struct{}),
+		limit:  config.Limit,
+		window: config.Window,
+		store:  config.Store,
+	}
+	if rl.store == nil {
+		rl.store = NewMemoryRateLimitStore()
+		rl.ownsStore = true
 	}
-
-	// 启动清理协程
-	rl.cleanup = time.NewTicker(1 * time.Minute)
-	go rl.cleanupExpired()
 
 	return rl
 }
 
 // Allow 检查是否允许请求
+//
+// 返回：
+//   - bool: 是否允许
+//   - error: 超过限制时返回 ErrRateLimitExceeded，存储不可用时返回存储的错误
 func (rl *RateLimiter) Allow(ctx context.Context, key string) (bool, error) {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	cutoff := now.Add(-rl.window)
-
-	// 获取或创建请求记录
-	requests, exists := rl.requests[key]
-	if !exists {
-		requests = make([]time.Time, 0)
-	}
-
-	// 清理过期请求
-	validRequests := make([]time.Time, 0)
-	for _, reqTime := range requests {
-		if reqTime.After(cutoff) {
-			validRequests = append(validRequests, reqTime)
-		}
+	allowed, err := rl.store.Allow(ctx, key, rl.limit, rl.window)
+	if err != nil {
+		return false, err
 	}
-
-	// 检查是否超过限制
-	if len(validRequests) >= rl.limit {
+	if !allowed {
 		return false, ErrRateLimitExceeded
 	}
-
-	// 添加新请求
-	validRequests = append(validRequests, now)
-	rl.requests[key] = validRequests
-
 	return true, nil
 }
 
 // Check 检查是否允许请求（不记录）
 func (rl *RateLimiter) Check(ctx context.Context, key string) (bool, error) {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
-
-	now := time.Now()
-	cutoff := now.Add(-rl.window)
-
-	requests, exists := rl.requests[key]
-	if !exists {
-		return true, nil
-	}
-
-	// 统计有效请求
-	count := 0
-	for _, reqTime := range requests {
-		if reqTime.After(cutoff) {
-			count++
-		}
+	count, err := rl.store.Count(ctx, key, rl.window)
+	if err != nil {
+		return false, err
 	}
-
 	return count < rl.limit, nil
 }
 
 // Reset 重置指定 key 的请求记录
 func (rl *RateLimiter) Reset(ctx context.Context, key string) error {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	delete(rl.requests, key)
-	return nil
+	return rl.store.Reset(ctx, key)
 }
 
 // GetRemaining 获取剩余请求次数
 func (rl *RateLimiter) GetRemaining(ctx context.Context, key string) (int, error) {
-	rl.mu.RLock()
-	defer rl.mu.RUnlock()
-
-	now := time.Now()
-	cutoff := now.Add(-rl.window)
-
-	requests, exists := rl.requests[key]
-	if !exists {
-		return rl.limit, nil
-	}
-
-	// 统计有效请求
-	count := 0
-	for _, reqTime := range requests {
-		if reqTime.After(cutoff) {
-			count++
-		}
+	count, err := rl.store.Count(ctx, key, rl.window)
+	if err != nil {
+		return 0, err
 	}
 
 	remaining := rl.limit - count
@@ -140,41 +89,13 @@ func (rl *RateLimiter) GetRemaining(ctx context.Context, key string) (int, error
 	return remaining, nil
 }
 
-// cleanupExpired 清理过期请求记录
-func (rl *RateLimiter) cleanupExpired() {
-	for {
-		select {
-		case <-rl.cleanup.C:
-			rl.mu.Lock()
-			now := time.Now()
-			cutoff := now.Add(-rl.window * 2) // 清理两倍窗口时间前的记录
-
-			for key, requests := range rl.requests {
-				validRequests := make([]time.Time, 0)
-				for _, reqTime := range requests {
-					if reqTime.After(cutoff) {
-						validRequests = append(validRequests, reqTime)
-					}
-				}
-
-				if len(validRequests) == 0 {
-					delete(rl.requests, key)
-				} else {
-					rl.requests[key] = validRequests
-				}
-			}
-			rl.mu.Unlock()
-
-		case <-rl.stopCleanup:
-			rl.cleanup.Stop()
-			return
-		}
-	}
-}
-
 // Shutdown 关闭速率限制器
+//
+// 只关闭默认创建的 MemoryRateLimitStore，通过配置传入的存储由调用方负责关闭
 func (rl *RateLimiter) Shutdown(ctx context.Context) error {
-	close(rl.stopCleanup)
+	if rl.ownsStore {
+		closeStore(rl.store)
+	}
 	return nil
 }
 
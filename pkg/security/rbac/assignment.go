@@ -0,0 +1,160 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AssignRole 在域中为用户分配角色，已分配时不做任何事
+//
+// 参数：
+//   - ctx: 上下文
+//   - domain: 域（租户 ID），GlobalDomain 表示在所有域中生效
+//   - userID: 用户 ID
+//   - roleID: 角色 ID，必须已存在
+//
+// 返回：
+//   - ErrInvalidAssignment: 域、用户或角色为空
+//   - ErrRoleNotFound: 角色不存在
+func (r *RBAC) AssignRole(ctx context.Context, domain, userID, roleID string) error {
+	if domain == "" || userID == "" || roleID == "" {
+		return ErrInvalidAssignment
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.roles[roleID]; !exists {
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
+	}
+	for _, a := range r.assignments[domain][userID] {
+		if a.RoleID == roleID {
+			return nil
+		}
+	}
+
+	assignment := Assignment{Domain: domain, UserID: userID, RoleID: roleID, CreatedAt: time.Now().UTC()}
+	if r.store != nil {
+		if err := r.store.SaveAssignment(ctx, assignment); err != nil {
+			return fmt.Errorf("rbac: failed to save assignment: %w", err)
+		}
+	}
+
+	r.addAssignment(assignment)
+	return nil
+}
+
+// RevokeRole 撤销用户在域中的角色，未分配时不做任何事
+func (r *RBAC) RevokeRole(ctx context.Context, domain, userID, roleID string) error {
+	if domain == "" || userID == "" || roleID == "" {
+		return ErrInvalidAssignment
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignments := r.assignments[domain][userID]
+	index := -1
+	for i, a := range assignments {
+		if a.RoleID == roleID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+	if r.store != nil {
+		if err := r.store.DeleteAssignment(ctx, assignments[index]); err != nil {
+			return fmt.Errorf("rbac: failed to delete assignment: %w", err)
+		}
+	}
+
+	assignments = append(assignments[:index], assignments[index+1:]...)
+	if len(assignments) == 0 {
+		delete(r.assignments[domain], userID)
+		if len(r.assignments[domain]) == 0 {
+			delete(r.assignments, domain)
+		}
+	} else {
+		r.assignments[domain][userID] = assignments
+	}
+	return nil
+}
+
+// UserRoles 返回用户在域中的角色，包括分配在 GlobalDomain 的角色
+func (r *RBAC) UserRoles(domain, userID string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.userRoles(domain, userID)
+}
+
+// userRoles 调用方必须持有读锁
+func (r *RBAC) userRoles(domain, userID string) []string {
+	var roles []string
+	seen := make(map[string]bool)
+	for _, d := range []string{domain, GlobalDomain} {
+		for _, a := range r.assignments[d][userID] {
+			if !seen[a.RoleID] {
+				seen[a.RoleID] = true
+				roles = append(roles, a.RoleID)
+			}
+		}
+	}
+	return roles
+}
+
+// ListAssignments 列出满足过滤条件的角色分配，按域、用户、角色排序
+func (r *RBAC) ListAssignments(filter AssignmentFilter) []Assignment {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []Assignment
+	for _, users := range r.assignments {
+		for _, assignments := range users {
+			for _, a := range assignments {
+				if filter.matches(a) {
+					result = append(result, a)
+				}
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Domain != result[j].Domain {
+			return result[i].Domain < result[j].Domain
+		}
+		if result[i].UserID != result[j].UserID {
+			return result[i].UserID < result[j].UserID
+		}
+		return result[i].RoleID < result[j].RoleID
+	})
+	return result
+}
+
+// CheckUserPermission 检查用户在域中是否有权限
+//
+// 使用用户在该域和 GlobalDomain 中分配的角色（包括继承的权限）检查，
+// 其他域的角色不生效
+func (r *RBAC) CheckUserPermission(ctx context.Context, domain, userID, resource, action string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := r.userRoles(domain, userID)
+	if len(roles) == 0 {
+		return false, nil
+	}
+	return r.checkRoles(roles, resource, action), nil
+}
+
+// addAssignment 调用方必须持有写锁
+func (r *RBAC) addAssignment(a Assignment) {
+	users, ok := r.assignments[a.Domain]
+	if !ok {
+		users = make(map[string][]Assignment)
+		r.assignments[a.Domain] = users
+	}
+	users[a.UserID] = append(users[a.UserID], a)
+}
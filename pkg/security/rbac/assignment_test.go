@@ -0,0 +1,147 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore 写操作总是失败的存储
+type failingStore struct {
+	Store
+}
+
+func (failingStore) SaveRole(context.Context, *Role) error { return errors.New("unavailable") }
+func (failingStore) SavePermission(context.Context, *Permission) error {
+	return errors.New("unavailable")
+}
+func (failingStore) SaveAssignment(context.Context, Assignment) error {
+	return errors.New("unavailable")
+}
+
+// TestRBAC_DomainAssignments 测试域内角色分配
+func TestRBAC_DomainAssignments(t *testing.T) {
+	ctx := context.Background()
+	r := NewRBAC()
+	require.NoError(t, r.InitializeDefaultRoles())
+
+	require.NoError(t, r.AssignRole(ctx, "tenant-a", "alice", "moderator"))
+	require.NoError(t, r.AssignRole(ctx, "tenant-a", "alice", "moderator"))
+	require.NoError(t, r.AssignRole(ctx, "tenant-b", "alice", "user"))
+	require.NoError(t, r.AssignRole(ctx, GlobalDomain, "alice", "user"))
+
+	assert.Equal(t, []string{"moderator", "user"}, r.UserRoles("tenant-a", "alice"))
+	assert.Equal(t, []string{"user"}, r.UserRoles("tenant-b", "alice"))
+	assert.Equal(t, []string{"user"}, r.UserRoles("tenant-c", "alice"))
+	assert.Empty(t, r.UserRoles("tenant-a", "bob"))
+
+	allowed, err := r.CheckUserPermission(ctx, "tenant-a", "alice", "user", "update")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = r.CheckUserPermission(ctx, "tenant-b", "alice", "user", "update")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	require.NoError(t, r.RevokeRole(ctx, "tenant-a", "alice", "moderator"))
+	require.NoError(t, r.RevokeRole(ctx, "tenant-a", "alice", "moderator"))
+	allowed, err = r.CheckUserPermission(ctx, "tenant-a", "alice", "user", "update")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	assert.Len(t, r.ListAssignments(AssignmentFilter{UserID: "alice"}), 2)
+	assert.Len(t, r.ListAssignments(AssignmentFilter{Domain: GlobalDomain}), 1)
+
+	assert.ErrorIs(t, r.AssignRole(ctx, "tenant-a", "alice", "missing"), ErrRoleNotFound)
+	assert.ErrorIs(t, r.AssignRole(ctx, "", "alice", "user"), ErrInvalidAssignment)
+}
+
+// TestRBAC_DeleteRoleRemovesAssignments 测试删除角色时移除分配
+func TestRBAC_DeleteRoleRemovesAssignments(t *testing.T) {
+	ctx := context.Background()
+	r := NewRBAC()
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "viewer"}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "editor", Inherits: []string{"viewer"}}))
+	require.NoError(t, r.AssignRole(ctx, "tenant-a", "alice", "editor"))
+	require.NoError(t, r.AssignRole(ctx, "tenant-a", "alice", "viewer"))
+
+	assert.ErrorIs(t, r.DeleteRole(ctx, "viewer"), ErrRoleInUse)
+	require.NoError(t, r.DeleteRole(ctx, "editor"))
+	assert.Equal(t, []string{"viewer"}, r.UserRoles("tenant-a", "alice"))
+	assert.ErrorIs(t, r.DeleteRole(ctx, "editor"), ErrRoleNotFound)
+}
+
+// TestRBAC_CreateRoleValidation 测试创建和更新角色时的校验
+func TestRBAC_CreateRoleValidation(t *testing.T) {
+	ctx := context.Background()
+	r := NewRBAC()
+	require.NoError(t, r.CreatePermission(ctx, &Permission{ID: "doc.read", Resource: "doc", Action: "read"}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "a", Permissions: []string{"doc.read"}}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "b", Inherits: []string{"a"}}))
+
+	assert.ErrorIs(t, r.CreateRole(ctx, &Role{ID: "a"}), ErrRoleExists)
+	assert.ErrorIs(t, r.CreateRole(ctx, &Role{ID: "c", Permissions: []string{"missing"}}), ErrPermissionNotFound)
+	assert.ErrorIs(t, r.CreateRole(ctx, &Role{ID: "c", Inherits: []string{"missing"}}), ErrRoleNotFound)
+	assert.ErrorIs(t, r.CreateRole(ctx, &Role{ID: "c", Inherits: []string{"c"}}), ErrCircularInheritance)
+	assert.ErrorIs(t, r.UpdateRole(ctx, &Role{ID: "a", Inherits: []string{"b"}}), ErrCircularInheritance)
+	assert.ErrorIs(t, r.UpdateRole(ctx, &Role{ID: "missing"}), ErrRoleNotFound)
+
+	require.NoError(t, r.UpdateRole(ctx, &Role{ID: "a", Name: "A"}))
+	role, err := r.GetRole("a")
+	require.NoError(t, err)
+	assert.Equal(t, "A", role.Name)
+	assert.Empty(t, role.Permissions)
+}
+
+// TestRBAC_PermissionLifecycle 测试授予、撤销和删除权限
+func TestRBAC_PermissionLifecycle(t *testing.T) {
+	ctx := context.Background()
+	r := NewRBAC()
+	require.NoError(t, r.CreatePermission(ctx, &Permission{ID: "doc.read", Resource: "doc", Action: "read"}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "viewer"}))
+
+	require.NoError(t, r.GrantPermission(ctx, "viewer", "doc.read"))
+	allowed, err := r.CheckPermission(ctx, []string{"viewer"}, "doc", "read")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	require.NoError(t, r.UpdatePermission(ctx, &Permission{ID: "doc.read", Resource: "doc", Action: "list"}))
+	allowed, err = r.CheckPermission(ctx, []string{"viewer"}, "doc", "list")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	require.NoError(t, r.RevokePermission(ctx, "viewer", "doc.read"))
+	allowed, err = r.CheckPermission(ctx, []string{"viewer"}, "doc", "list")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	require.NoError(t, r.GrantPermission(ctx, "viewer", "doc.read"))
+	require.NoError(t, r.DeletePermission(ctx, "doc.read"))
+	role, err := r.GetRole("viewer")
+	require.NoError(t, err)
+	assert.Empty(t, role.Permissions)
+	assert.ErrorIs(t, r.GrantPermission(ctx, "viewer", "doc.read"), ErrPermissionNotFound)
+}
+
+// TestRBAC_StoreFailureKeepsMemoryState 测试存储失败时不修改内存状态
+func TestRBAC_StoreFailureKeepsMemoryState(t *testing.T) {
+	ctx := context.Background()
+	r := NewRBAC()
+	require.NoError(t, r.CreatePermission(ctx, &Permission{ID: "doc.read", Resource: "doc", Action: "read"}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "viewer"}))
+	r.store = failingStore{}
+
+	assert.Error(t, r.CreateRole(ctx, &Role{ID: "editor"}))
+	assert.Error(t, r.GrantPermission(ctx, "viewer", "doc.read"))
+	assert.Error(t, r.AssignRole(ctx, "tenant-a", "alice", "viewer"))
+
+	_, err := r.GetRole("editor")
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	role, err := r.GetRole("viewer")
+	require.NoError(t, err)
+	assert.Empty(t, role.Permissions)
+	assert.Empty(t, r.UserRoles("tenant-a", "alice"))
+}
@@ -13,6 +13,8 @@ const (
 	UserRolesKey ContextKey = "user_roles"
 	// UserIDKey 用户ID上下文键
 	UserIDKey ContextKey = "user_id"
+	// DomainKey 域（租户）上下文键
+	DomainKey ContextKey = "domain"
 )
 
 // Middleware RBAC 中间件
@@ -54,6 +56,40 @@ func (m *Middleware) RequirePermission(resource, action string) func(http.Handle
 	}
 }
 
+// RequireDomainPermission 要求用户在当前域中拥有特定权限的中间件
+//
+// 从上下文读取用户ID（WithUserID）和域（WithDomain），使用用户在该域和 GlobalDomain 中
+// 分配的角色检查权限，不使用上下文中的 UserRolesKey
+func (m *Middleware) RequireDomainPermission(resource, action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserID(r.Context())
+			if !ok || userID == "" {
+				http.Error(w, "Unauthorized: no user found", http.StatusUnauthorized)
+				return
+			}
+			domain, ok := GetDomain(r.Context())
+			if !ok || domain == "" {
+				http.Error(w, "Forbidden: no domain found", http.StatusForbidden)
+				return
+			}
+
+			hasPermission, err := m.rbac.CheckUserPermission(r.Context(), domain, userID, resource, action)
+			if err != nil {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			if !hasPermission {
+				http.Error(w, "Forbidden: insufficient permissions", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // RequireRole 要求特定角色的中间件
 func (m *Middleware) RequireRole(requiredRoles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -111,3 +147,14 @@ func GetUserID(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(UserIDKey).(string)
 	return userID, ok
 }
+
+// WithDomain 将域（租户）添加到上下文
+func WithDomain(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, DomainKey, domain)
+}
+
+// GetDomain 从上下文获取域（租户）
+func GetDomain(ctx context.Context) (string, bool) {
+	domain, ok := ctx.Value(DomainKey).(string)
+	return domain, ok
+}
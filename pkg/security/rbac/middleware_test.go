@@ -224,3 +224,45 @@ func BenchmarkMiddleware_CheckPermission(b *testing.B) {
 		handler.ServeHTTP(rr, req)
 	}
 }
+
+func TestMiddleware_RequireDomainPermission(t *testing.T) {
+	rbac := NewRBAC()
+	require.NoError(t, rbac.InitializeDefaultRoles())
+	require.NoError(t, rbac.AssignRole(context.Background(), "tenant-a", "alice", "moderator"))
+
+	m := NewMiddleware(rbac)
+
+	tests := []struct {
+		name       string
+		userID     string
+		domain     string
+		wantStatus int
+	}{
+		{"assigned domain", "alice", "tenant-a", http.StatusOK},
+		{"other domain", "alice", "tenant-b", http.StatusForbidden},
+		{"unassigned user", "bob", "tenant-a", http.StatusForbidden},
+		{"no domain", "alice", "", http.StatusForbidden},
+		{"no user", "", "tenant-a", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := m.RequireDomainPermission("user", "update")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/test", nil)
+			ctx := req.Context()
+			if tt.userID != "" {
+				ctx = WithUserID(ctx, tt.userID)
+			}
+			if tt.domain != "" {
+				ctx = WithDomain(ctx, tt.domain)
+			}
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+			assert.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
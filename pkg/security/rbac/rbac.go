@@ -12,9 +12,23 @@ import (
 type RBAC struct {
 	roles       map[string]*Role
 	permissions map[string]*Permission
+	assignments map[string]map[string][]Assignment // domain -> userID -> 分配
+	store       Store
 	mu          sync.RWMutex
 }
 
+// Option RBAC 配置选项
+type Option func(*RBAC)
+
+// WithStore 使用持久化存储
+//
+// 配置后所有写操作先写入存储，创建后需要调用 Load 加载已保存的状态
+func WithStore(store Store) Option {
+	return func(r *RBAC) {
+		r.store = store
+	}
+}
+
 // Role 角色
 type Role struct {
 	ID          string
@@ -33,15 +47,85 @@ type Permission struct {
 }
 
 // NewRBAC 创建 RBAC 实例
-func NewRBAC() *RBAC {
-	return &RBAC{
+func NewRBAC(opts ...Option) *RBAC {
+	r := &RBAC{
 		roles:       make(map[string]*Role),
 		permissions: make(map[string]*Permission),
+		assignments: make(map[string]map[string][]Assignment),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	return r
 }
 
 // AddRole 添加角色
+//
+// 不检查角色引用的权限和父角色是否存在；需要校验时使用 CreateRole
 func (r *RBAC) AddRole(role *Role) error {
+	return r.addRole(context.Background(), role, false)
+}
+
+// AddPermission 添加权限
+func (r *RBAC) AddPermission(perm *Permission) error {
+	return r.CreatePermission(context.Background(), perm)
+}
+
+// AssignPermissionToRole 为角色分配权限
+func (r *RBAC) AssignPermissionToRole(roleID, permID string) error {
+	return r.GrantPermission(context.Background(), roleID, permID)
+}
+
+// Load 从存储加载角色、权限和角色分配，替换内存中的状态
+//
+// 没有配置存储时不做任何事。多实例部署时可以定期调用以同步其他实例的修改。
+func (r *RBAC) Load(ctx context.Context) error {
+	if r.store == nil {
+		return nil
+	}
+
+	roles, err := r.store.ListRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("rbac: failed to load roles: %w", err)
+	}
+	perms, err := r.store.ListPermissions(ctx)
+	if err != nil {
+		return fmt.Errorf("rbac: failed to load permissions: %w", err)
+	}
+	assignments, err := r.store.ListAssignments(ctx, AssignmentFilter{})
+	if err != nil {
+		return fmt.Errorf("rbac: failed to load assignments: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roles = make(map[string]*Role, len(roles))
+	for _, role := range roles {
+		r.roles[role.ID] = role
+	}
+	r.permissions = make(map[string]*Permission, len(perms))
+	for _, perm := range perms {
+		r.permissions[perm.ID] = perm
+	}
+	r.assignments = make(map[string]map[string][]Assignment)
+	for _, a := range assignments {
+		r.addAssignment(a)
+	}
+	return nil
+}
+
+// CreateRole 创建角色
+//
+// 返回：
+//   - ErrRoleExists: 角色已存在
+//   - ErrPermissionNotFound、ErrRoleNotFound: 引用的权限或父角色不存在
+//   - ErrCircularInheritance: 继承关系形成环
+func (r *RBAC) CreateRole(ctx context.Context, role *Role) error {
+	return r.addRole(ctx, role, true)
+}
+
+func (r *RBAC) addRole(ctx context.Context, role *Role, strict bool) error {
 	if role == nil || role.ID == "" {
 		return errors.New("invalid role")
 	}
@@ -50,15 +134,135 @@ func (r *RBAC) AddRole(role *Role) error {
 	defer r.mu.Unlock()
 
 	if _, exists := r.roles[role.ID]; exists {
-		return fmt.Errorf("role %s already exists", role.ID)
+		return fmt.Errorf("%w: %s", ErrRoleExists, role.ID)
+	}
+	if err := r.validateRole(role, strict); err != nil {
+		return err
+	}
+	if r.store != nil {
+		if err := r.store.SaveRole(ctx, role); err != nil {
+			return fmt.Errorf("rbac: failed to save role %s: %w", role.ID, err)
+		}
 	}
 
 	r.roles[role.ID] = role
 	return nil
 }
 
-// AddPermission 添加权限
-func (r *RBAC) AddPermission(perm *Permission) error {
+// UpdateRole 替换已存在的角色（包括权限列表和父角色）
+func (r *RBAC) UpdateRole(ctx context.Context, role *Role) error {
+	if role == nil || role.ID == "" {
+		return errors.New("invalid role")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.roles[role.ID]; !exists {
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, role.ID)
+	}
+	if err := r.validateRole(role, true); err != nil {
+		return err
+	}
+	if r.store != nil {
+		if err := r.store.SaveRole(ctx, role); err != nil {
+			return fmt.Errorf("rbac: failed to save role %s: %w", role.ID, err)
+		}
+	}
+
+	r.roles[role.ID] = role
+	return nil
+}
+
+// DeleteRole 删除角色及其所有分配
+//
+// 角色仍被其他角色继承时返回 ErrRoleInUse
+func (r *RBAC) DeleteRole(ctx context.Context, roleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.roles[roleID]; !exists {
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
+	}
+	for _, other := range r.roles {
+		for _, parent := range other.Inherits {
+			if parent == roleID {
+				return fmt.Errorf("%w: %s is inherited by %s", ErrRoleInUse, roleID, other.ID)
+			}
+		}
+	}
+	if r.store != nil {
+		if err := r.store.DeleteRole(ctx, roleID); err != nil {
+			return fmt.Errorf("rbac: failed to delete role %s: %w", roleID, err)
+		}
+	}
+
+	delete(r.roles, roleID)
+	for _, users := range r.assignments {
+		for userID, assignments := range users {
+			kept := assignments[:0]
+			for _, a := range assignments {
+				if a.RoleID != roleID {
+					kept = append(kept, a)
+				}
+			}
+			if len(kept) == 0 {
+				delete(users, userID)
+			} else {
+				users[userID] = kept
+			}
+		}
+	}
+	return nil
+}
+
+// validateRole 检查角色的继承关系，strict 为 true 时还要求权限和父角色存在
+//
+// 调用方必须持有写锁
+func (r *RBAC) validateRole(role *Role, strict bool) error {
+	// 从新的父角色出发能回到自身时形成环
+	visited := make(map[string]bool)
+	var reaches func(roleID string) bool
+	reaches = func(roleID string) bool {
+		if roleID == role.ID {
+			return true
+		}
+		if visited[roleID] {
+			return false
+		}
+		visited[roleID] = true
+		if parent, exists := r.roles[roleID]; exists {
+			for _, id := range parent.Inherits {
+				if reaches(id) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, parentID := range role.Inherits {
+		if reaches(parentID) {
+			return fmt.Errorf("%w: %s inherits %s", ErrCircularInheritance, role.ID, parentID)
+		}
+	}
+
+	if strict {
+		for _, permID := range role.Permissions {
+			if _, exists := r.permissions[permID]; !exists {
+				return fmt.Errorf("%w: %s", ErrPermissionNotFound, permID)
+			}
+		}
+		for _, parentID := range role.Inherits {
+			if _, exists := r.roles[parentID]; !exists {
+				return fmt.Errorf("%w: %s", ErrRoleNotFound, parentID)
+			}
+		}
+	}
+	return nil
+}
+
+// CreatePermission 创建权限
+func (r *RBAC) CreatePermission(ctx context.Context, perm *Permission) error {
 	if perm == nil || perm.ID == "" {
 		return errors.New("invalid permission")
 	}
@@ -67,25 +271,73 @@ func (r *RBAC) AddPermission(perm *Permission) error {
 	defer r.mu.Unlock()
 
 	if _, exists := r.permissions[perm.ID]; exists {
-		return fmt.Errorf("permission %s already exists", perm.ID)
+		return fmt.Errorf("%w: %s", ErrPermissionExists, perm.ID)
+	}
+	if r.store != nil {
+		if err := r.store.SavePermission(ctx, perm); err != nil {
+			return fmt.Errorf("rbac: failed to save permission %s: %w", perm.ID, err)
+		}
 	}
 
 	r.permissions[perm.ID] = perm
 	return nil
 }
 
-// AssignPermissionToRole 为角色分配权限
-func (r *RBAC) AssignPermissionToRole(roleID, permID string) error {
+// UpdatePermission 替换已存在的权限
+func (r *RBAC) UpdatePermission(ctx context.Context, perm *Permission) error {
+	if perm == nil || perm.ID == "" {
+		return errors.New("invalid permission")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.permissions[perm.ID]; !exists {
+		return fmt.Errorf("%w: %s", ErrPermissionNotFound, perm.ID)
+	}
+	if r.store != nil {
+		if err := r.store.SavePermission(ctx, perm); err != nil {
+			return fmt.Errorf("rbac: failed to save permission %s: %w", perm.ID, err)
+		}
+	}
+
+	r.permissions[perm.ID] = perm
+	return nil
+}
+
+// DeletePermission 删除权限，并从所有角色中移除
+func (r *RBAC) DeletePermission(ctx context.Context, permID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.permissions[permID]; !exists {
+		return fmt.Errorf("%w: %s", ErrPermissionNotFound, permID)
+	}
+	if r.store != nil {
+		if err := r.store.DeletePermission(ctx, permID); err != nil {
+			return fmt.Errorf("rbac: failed to delete permission %s: %w", permID, err)
+		}
+	}
+
+	delete(r.permissions, permID)
+	for _, role := range r.roles {
+		role.Permissions = removeString(role.Permissions, permID)
+	}
+	return nil
+}
+
+// GrantPermission 为角色分配权限，已分配时不做任何事
+func (r *RBAC) GrantPermission(ctx context.Context, roleID, permID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	role, exists := r.roles[roleID]
 	if !exists {
-		return fmt.Errorf("role %s not found", roleID)
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
 	}
 
 	if _, exists := r.permissions[permID]; !exists {
-		return fmt.Errorf("permission %s not found", permID)
+		return fmt.Errorf("%w: %s", ErrPermissionNotFound, permID)
 	}
 
 	// 检查是否已分配
@@ -95,15 +347,64 @@ func (r *RBAC) AssignPermissionToRole(roleID, permID string) error {
 		}
 	}
 
-	role.Permissions = append(role.Permissions, permID)
+	updated := *role
+	updated.Permissions = append(append([]string(nil), role.Permissions...), permID)
+	if r.store != nil {
+		if err := r.store.SaveRole(ctx, &updated); err != nil {
+			return fmt.Errorf("rbac: failed to save role %s: %w", roleID, err)
+		}
+	}
+
+	role.Permissions = updated.Permissions
+	return nil
+}
+
+// RevokePermission 从角色移除权限，未分配时不做任何事
+func (r *RBAC) RevokePermission(ctx context.Context, roleID, permID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, exists := r.roles[roleID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
+	}
+
+	permissions := removeString(append([]string(nil), role.Permissions...), permID)
+	if len(permissions) == len(role.Permissions) {
+		return nil
+	}
+	if r.store != nil {
+		updated := *role
+		updated.Permissions = permissions
+		if err := r.store.SaveRole(ctx, &updated); err != nil {
+			return fmt.Errorf("rbac: failed to save role %s: %w", roleID, err)
+		}
+	}
+
+	role.Permissions = permissions
 	return nil
 }
 
+func removeString(values []string, value string) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
 // CheckPermission 检查用户是否有权限
 func (r *RBAC) CheckPermission(ctx context.Context, userRoles []string, resource, action string) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	return r.checkRoles(userRoles, resource, action), nil
+}
+
+// checkRoles 检查角色集合是否拥有权限，调用方必须持有读锁
+func (r *RBAC) checkRoles(userRoles []string, resource, action string) bool {
 	// 收集用户所有权限（包括继承的）
 	userPermissions := make(map[string]bool)
 	for _, roleID := range userRoles {
@@ -127,24 +428,25 @@ func (r *RBAC) CheckPermission(ctx context.Context, userRoles []string, resource
 		// 检查资源和操作是否匹配
 		if (perm.Resource == resource || perm.Resource == "*") &&
 			(perm.Action == action || perm.Action == "*") {
-			return true, nil
+			return true
 		}
 	}
 
-	return false, nil
+	return false
 }
 
 // getRolePermissions 获取角色的所有权限（递归获取继承的权限）
 func (r *RBAC) getRolePermissions(roleID string, visited map[string]bool) (map[string]bool, error) {
-	// 防止循环继承
+	// 防止循环继承；visited 只记录当前继承路径，菱形继承（两个父角色继承同一角色）不是环
 	if visited[roleID] {
-		return nil, fmt.Errorf("circular role inheritance detected: %s", roleID)
+		return nil, fmt.Errorf("%w: %s", ErrCircularInheritance, roleID)
 	}
 	visited[roleID] = true
+	defer delete(visited, roleID)
 
 	role, exists := r.roles[roleID]
 	if !exists {
-		return nil, fmt.Errorf("role %s not found", roleID)
+		return nil, fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
 	}
 
 	permissions := make(map[string]bool)
@@ -175,7 +477,7 @@ func (r *RBAC) GetRole(roleID string) (*Role, error) {
 
 	role, exists := r.roles[roleID]
 	if !exists {
-		return nil, fmt.Errorf("role %s not found", roleID)
+		return nil, fmt.Errorf("%w: %s", ErrRoleNotFound, roleID)
 	}
 
 	return role, nil
@@ -188,7 +490,7 @@ func (r *RBAC) GetPermission(permID string) (*Permission, error) {
 
 	perm, exists := r.permissions[permID]
 	if !exists {
-		return nil, fmt.Errorf("permission %s not found", permID)
+		return nil, fmt.Errorf("%w: %s", ErrPermissionNotFound, permID)
 	}
 
 	return perm, nil
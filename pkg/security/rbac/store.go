@@ -0,0 +1,105 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// GlobalDomain 全局域
+//
+// 分配在全局域的角色在所有域中生效，例如平台管理员
+const GlobalDomain = "*"
+
+var (
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("rbac: role not found")
+
+	// ErrRoleExists 角色已存在
+	ErrRoleExists = errors.New("rbac: role already exists")
+
+	// ErrRoleInUse 角色仍被其他角色继承，不能删除
+	ErrRoleInUse = errors.New("rbac: role is inherited by other roles")
+
+	// ErrPermissionNotFound 权限不存在
+	ErrPermissionNotFound = errors.New("rbac: permission not found")
+
+	// ErrPermissionExists 权限已存在
+	ErrPermissionExists = errors.New("rbac: permission already exists")
+
+	// ErrCircularInheritance 角色继承形成环
+	ErrCircularInheritance = errors.New("rbac: circular role inheritance")
+
+	// ErrInvalidAssignment 角色分配缺少用户、域或角色
+	ErrInvalidAssignment = errors.New("rbac: invalid role assignment")
+)
+
+// Assignment 用户在某个域（租户）中被分配的角色
+//
+// 字段说明：
+// - Domain: 域，通常是租户 ID；GlobalDomain 表示在所有域中生效
+// - UserID: 用户 ID
+// - RoleID: 角色 ID
+// - CreatedAt: 分配时间
+type Assignment struct {
+	Domain    string    `json:"domain"`
+	UserID    string    `json:"user_id"`
+	RoleID    string    `json:"role_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AssignmentFilter 列出角色分配的过滤条件，空字段表示不过滤
+type AssignmentFilter struct {
+	Domain string
+	UserID string
+	RoleID string
+}
+
+// matches 检查分配是否满足过滤条件
+func (f AssignmentFilter) matches(a Assignment) bool {
+	return (f.Domain == "" || f.Domain == a.Domain) &&
+		(f.UserID == "" || f.UserID == a.UserID) &&
+		(f.RoleID == "" || f.RoleID == a.RoleID)
+}
+
+// Store RBAC 持久化存储
+//
+// 设计原理：
+// 1. RBAC 在内存中评估权限，Store 只在启动（Load）和管理操作时访问
+// 2. 写操作先写入 Store，成功后再更新内存，保证重启后状态一致
+// 3. 角色的权限列表和父角色随角色一起保存（整体替换）
+//
+// 实现：
+// - SQLStore: 基于 database/sql，支持 PostgreSQL 和 SQLite，可以与 Ent 客户端共享连接
+//
+// 注意事项：
+// - DeleteRole 同时删除角色的权限关联、父角色关联和角色分配
+// - DeletePermission 同时删除权限与角色的关联
+type Store interface {
+	// ListRoles 列出所有角色（包括权限和父角色）
+	ListRoles(ctx context.Context) ([]*Role, error)
+
+	// SaveRole 创建或替换角色
+	SaveRole(ctx context.Context, role *Role) error
+
+	// DeleteRole 删除角色
+	DeleteRole(ctx context.Context, roleID string) error
+
+	// ListPermissions 列出所有权限
+	ListPermissions(ctx context.Context) ([]*Permission, error)
+
+	// SavePermission 创建或替换权限
+	SavePermission(ctx context.Context, perm *Permission) error
+
+	// DeletePermission 删除权限
+	DeletePermission(ctx context.Context, permID string) error
+
+	// ListAssignments 列出满足过滤条件的角色分配
+	ListAssignments(ctx context.Context, filter AssignmentFilter) ([]Assignment, error)
+
+	// SaveAssignment 保存角色分配，已存在时不报错
+	SaveAssignment(ctx context.Context, assignment Assignment) error
+
+	// DeleteAssignment 删除角色分配，不存在时不报错
+	DeleteAssignment(ctx context.Context, assignment Assignment) error
+}
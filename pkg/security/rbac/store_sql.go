@@ -0,0 +1,278 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/golang/internal/infra/database/sqldialect"
+)
+
+// Dialect SQL 方言
+type Dialect = sqldialect.Dialect
+
+const (
+	// DialectPostgres PostgreSQL 方言（$1 占位符）
+	DialectPostgres = sqldialect.Postgres
+
+	// DialectSQLite SQLite 方言（? 占位符）
+	DialectSQLite = sqldialect.SQLite
+)
+
+// SQLStore 基于 database/sql 的 RBAC 存储
+//
+// 设计原理：
+// 1. 角色、权限、角色-权限、角色-父角色、角色分配各一张表
+// 2. 保存角色时在一个事务中替换角色的权限和父角色关联
+// 3. 不依赖外键级联，删除时在同一事务中显式删除关联数据
+//
+// 表结构：migrations/postgres/003_create_rbac_tables.up.sql、migrations/sqlite3/002_create_rbac_tables.up.sql
+//
+// 与 Ent 共享连接：
+//
+//	db, _ := sql.Open("pgx", dsn)
+//	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.Postgres, db)))
+//	store := rbac.NewSQLStore(db, rbac.DialectPostgres)
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore 创建 SQL 存储
+func NewSQLStore(db *sql.DB, dialect Dialect) *SQLStore {
+	return &SQLStore{db: db, dialect: dialect}
+}
+
+// ListRoles 列出所有角色
+func (s *SQLStore) ListRoles(ctx context.Context) ([]*Role, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, description FROM rbac_roles ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*Role
+	byID := make(map[string]*Role)
+	for rows.Next() {
+		role := &Role{}
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+		byID[role.ID] = role
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = s.scanPairs(ctx, "SELECT role_id, permission_id FROM rbac_role_permissions ORDER BY role_id, position",
+		func(roleID, permID string) {
+			if role, ok := byID[roleID]; ok {
+				role.Permissions = append(role.Permissions, permID)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	err = s.scanPairs(ctx, "SELECT role_id, parent_id FROM rbac_role_parents ORDER BY role_id, position",
+		func(roleID, parentID string) {
+			if role, ok := byID[roleID]; ok {
+				role.Inherits = append(role.Inherits, parentID)
+			}
+		})
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// scanPairs 查询两列字符串并逐行回调
+func (s *SQLStore) scanPairs(ctx context.Context, query string, fn func(a, b string)) error {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a, b string
+		if err := rows.Scan(&a, &b); err != nil {
+			return err
+		}
+		fn(a, b)
+	}
+	return rows.Err()
+}
+
+// SaveRole 创建或替换角色
+func (s *SQLStore) SaveRole(ctx context.Context, role *Role) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf(
+			"INSERT INTO rbac_roles (id, name, description) VALUES (%s) "+
+				"ON CONFLICT (id) DO UPDATE SET name = excluded.name, description = excluded.description",
+			s.dialect.Placeholders(3),
+		)
+		if _, err := tx.ExecContext(ctx, query, role.ID, role.Name, role.Description); err != nil {
+			return err
+		}
+
+		if err := s.replaceLinks(ctx, tx, "rbac_role_permissions", "permission_id", role.ID, role.Permissions); err != nil {
+			return err
+		}
+		return s.replaceLinks(ctx, tx, "rbac_role_parents", "parent_id", role.ID, role.Inherits)
+	})
+}
+
+// replaceLinks 替换角色在关联表中的记录，position 保留原有顺序
+func (s *SQLStore) replaceLinks(ctx context.Context, tx *sql.Tx, table, column, roleID string, values []string) error {
+	del := fmt.Sprintf("DELETE FROM %s WHERE role_id = %s", table, s.dialect.Placeholder(1))
+	if _, err := tx.ExecContext(ctx, del, roleID); err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (role_id, %s, position) VALUES (%s) ON CONFLICT DO NOTHING",
+		table, column, s.dialect.Placeholders(3))
+	for i, value := range values {
+		if _, err := tx.ExecContext(ctx, insert, roleID, value, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteRole 删除角色及其权限关联、父角色关联和角色分配
+func (s *SQLStore) DeleteRole(ctx context.Context, roleID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		for _, query := range []string{
+			"DELETE FROM rbac_role_permissions WHERE role_id = %s",
+			"DELETE FROM rbac_role_parents WHERE role_id = %s",
+			"DELETE FROM rbac_assignments WHERE role_id = %s",
+			"DELETE FROM rbac_roles WHERE id = %s",
+		} {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(query, s.dialect.Placeholder(1)), roleID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListPermissions 列出所有权限
+func (s *SQLStore) ListPermissions(ctx context.Context) ([]*Permission, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, resource, action, description FROM rbac_permissions ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []*Permission
+	for rows.Next() {
+		perm := &Permission{}
+		if err := rows.Scan(&perm.ID, &perm.Resource, &perm.Action, &perm.Description); err != nil {
+			return nil, err
+		}
+		perms = append(perms, perm)
+	}
+	return perms, rows.Err()
+}
+
+// SavePermission 创建或替换权限
+func (s *SQLStore) SavePermission(ctx context.Context, perm *Permission) error {
+	query := fmt.Sprintf(
+		"INSERT INTO rbac_permissions (id, resource, action, description) VALUES (%s) "+
+			"ON CONFLICT (id) DO UPDATE SET resource = excluded.resource, action = excluded.action, description = excluded.description",
+		s.dialect.Placeholders(4),
+	)
+	_, err := s.db.ExecContext(ctx, query, perm.ID, perm.Resource, perm.Action, perm.Description)
+	return err
+}
+
+// DeletePermission 删除权限及其与角色的关联
+func (s *SQLStore) DeletePermission(ctx context.Context, permID string) error {
+	return s.withTx(ctx, func(tx *sql.Tx) error {
+		for _, query := range []string{
+			"DELETE FROM rbac_role_permissions WHERE permission_id = %s",
+			"DELETE FROM rbac_permissions WHERE id = %s",
+		} {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(query, s.dialect.Placeholder(1)), permID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListAssignments 列出满足过滤条件的角色分配
+func (s *SQLStore) ListAssignments(ctx context.Context, filter AssignmentFilter) ([]Assignment, error) {
+	var conditions []string
+	var args []interface{}
+	for _, f := range []struct {
+		column string
+		value  string
+	}{
+		{"domain", filter.Domain},
+		{"user_id", filter.UserID},
+		{"role_id", filter.RoleID},
+	} {
+		if f.value != "" {
+			args = append(args, f.value)
+			conditions = append(conditions, fmt.Sprintf("%s = %s", f.column, s.dialect.Placeholder(len(args))))
+		}
+	}
+
+	query := "SELECT domain, user_id, role_id, created_at FROM rbac_assignments"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY domain, user_id, role_id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []Assignment
+	for rows.Next() {
+		var a Assignment
+		if err := rows.Scan(&a.Domain, &a.UserID, &a.RoleID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// SaveAssignment 保存角色分配，已存在时不报错
+func (s *SQLStore) SaveAssignment(ctx context.Context, a Assignment) error {
+	query := fmt.Sprintf(
+		"INSERT INTO rbac_assignments (domain, user_id, role_id, created_at) VALUES (%s) ON CONFLICT DO NOTHING",
+		s.dialect.Placeholders(4),
+	)
+	_, err := s.db.ExecContext(ctx, query, a.Domain, a.UserID, a.RoleID, a.CreatedAt.UTC())
+	return err
+}
+
+// DeleteAssignment 删除角色分配，不存在时不报错
+func (s *SQLStore) DeleteAssignment(ctx context.Context, a Assignment) error {
+	query := fmt.Sprintf(
+		"DELETE FROM rbac_assignments WHERE domain = %s AND user_id = %s AND role_id = %s",
+		s.dialect.Placeholder(1), s.dialect.Placeholder(2), s.dialect.Placeholder(3),
+	)
+	_, err := s.db.ExecContext(ctx, query, a.Domain, a.UserID, a.RoleID)
+	return err
+}
+
+// withTx 在事务中执行 fn，fn 返回错误时回滚
+func (s *SQLStore) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
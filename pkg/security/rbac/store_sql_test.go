@@ -0,0 +1,133 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestStore 打开内存数据库并执行 SQLite 迁移
+func openTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// :memory: 数据库每个连接独立，限制为单连接
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migration, err := os.ReadFile("../../../migrations/sqlite3/002_create_rbac_tables.up.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(string(migration))
+	require.NoError(t, err)
+
+	return NewSQLStore(db, DialectSQLite)
+}
+
+// TestSQLStore_SurvivesRestart 测试修改持久化后由新实例加载
+func TestSQLStore_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	r := NewRBAC(WithStore(store))
+	require.NoError(t, r.InitializeDefaultRoles())
+	require.NoError(t, r.CreatePermission(ctx, &Permission{ID: "doc.write", Resource: "doc", Action: "write"}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "editor", Name: "Editor", Permissions: []string{"doc.write"}, Inherits: []string{"moderator", "user"}}))
+	require.NoError(t, r.AssignRole(ctx, "tenant-a", "alice", "editor"))
+	require.NoError(t, r.AssignRole(ctx, GlobalDomain, "root", "admin"))
+
+	restarted := NewRBAC(WithStore(store))
+	require.NoError(t, restarted.Load(ctx))
+
+	editor, err := restarted.GetRole("editor")
+	require.NoError(t, err)
+	assert.Equal(t, "Editor", editor.Name)
+	assert.Equal(t, []string{"doc.write"}, editor.Permissions)
+	assert.Equal(t, []string{"moderator", "user"}, editor.Inherits)
+	assert.Len(t, restarted.ListPermissions(), 6)
+
+	allowed, err := restarted.CheckUserPermission(ctx, "tenant-a", "alice", "user", "update")
+	require.NoError(t, err)
+	assert.True(t, allowed, "moderator permission inherited by editor")
+
+	allowed, err = restarted.CheckUserPermission(ctx, "tenant-b", "alice", "doc", "write")
+	require.NoError(t, err)
+	assert.False(t, allowed, "assignment is scoped to tenant-a")
+
+	allowed, err = restarted.CheckUserPermission(ctx, "tenant-b", "root", "doc", "delete")
+	require.NoError(t, err)
+	assert.True(t, allowed, "global assignment applies to every domain")
+
+	assignments := restarted.ListAssignments(AssignmentFilter{UserID: "alice"})
+	require.Len(t, assignments, 1)
+	assert.Equal(t, "tenant-a", assignments[0].Domain)
+	assert.False(t, assignments[0].CreatedAt.IsZero())
+}
+
+// TestSQLStore_Deletes 测试删除角色和权限时清理关联数据
+func TestSQLStore_Deletes(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	r := NewRBAC(WithStore(store))
+	require.NoError(t, r.CreatePermission(ctx, &Permission{ID: "doc.read", Resource: "doc", Action: "read"}))
+	require.NoError(t, r.CreatePermission(ctx, &Permission{ID: "doc.write", Resource: "doc", Action: "write"}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "viewer", Permissions: []string{"doc.read"}}))
+	require.NoError(t, r.CreateRole(ctx, &Role{ID: "editor", Permissions: []string{"doc.write"}, Inherits: []string{"viewer"}}))
+	require.NoError(t, r.AssignRole(ctx, "tenant-a", "alice", "editor"))
+	require.NoError(t, r.AssignRole(ctx, "tenant-a", "bob", "viewer"))
+
+	assert.ErrorIs(t, r.DeleteRole(ctx, "viewer"), ErrRoleInUse)
+	require.NoError(t, r.DeleteRole(ctx, "editor"))
+	require.NoError(t, r.DeletePermission(ctx, "doc.read"))
+	require.NoError(t, r.RevokeRole(ctx, "tenant-a", "bob", "viewer"))
+
+	assignments, err := store.ListAssignments(ctx, AssignmentFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, assignments)
+
+	roles, err := store.ListRoles(ctx)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "viewer", roles[0].ID)
+	assert.Empty(t, roles[0].Permissions)
+
+	perms, err := store.ListPermissions(ctx)
+	require.NoError(t, err)
+	require.Len(t, perms, 1)
+	assert.Equal(t, "doc.write", perms[0].ID)
+}
+
+// TestSQLStore_Upsert 测试保存已存在的角色和权限时整体替换
+func TestSQLStore_Upsert(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+
+	require.NoError(t, store.SavePermission(ctx, &Permission{ID: "p", Resource: "doc", Action: "read"}))
+	require.NoError(t, store.SavePermission(ctx, &Permission{ID: "p", Resource: "doc", Action: "write"}))
+	require.NoError(t, store.SaveRole(ctx, &Role{ID: "r", Name: "old", Permissions: []string{"a", "b"}}))
+	require.NoError(t, store.SaveRole(ctx, &Role{ID: "r", Name: "new", Permissions: []string{"c", "a"}}))
+
+	perms, err := store.ListPermissions(ctx)
+	require.NoError(t, err)
+	require.Len(t, perms, 1)
+	assert.Equal(t, "write", perms[0].Action)
+
+	roles, err := store.ListRoles(ctx)
+	require.NoError(t, err)
+	require.Len(t, roles, 1)
+	assert.Equal(t, "new", roles[0].Name)
+	assert.Equal(t, []string{"c", "a"}, roles[0].Permissions)
+
+	a := Assignment{Domain: "d", UserID: "u", RoleID: "r"}
+	require.NoError(t, store.SaveAssignment(ctx, a))
+	require.NoError(t, store.SaveAssignment(ctx, a))
+	assignments, err := store.ListAssignments(ctx, AssignmentFilter{Domain: "d", RoleID: "r"})
+	require.NoError(t, err)
+	assert.Len(t, assignments, 1)
+}
@@ -0,0 +1,490 @@
+package rebac
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultMaxDepth 默认的最大求值深度
+const DefaultMaxDepth = 25
+
+// Engine ReBAC 求值引擎
+//
+// 设计原理：
+// 1. 命名空间配置保存在内存中，元组通过 Store 读写
+// 2. Check/Expand 每一步都从 Store 读取元组，不做缓存，写入后立即生效
+// 3. 递归深度受 maxDepth 限制，防止过深的用户集嵌套拖垮请求
+// 4. ListObjects 从主体出发沿改写规则反向查找候选对象，不扫描整个命名空间
+type Engine struct {
+	store    Store
+	maxDepth int
+
+	mu         sync.RWMutex
+	namespaces map[string]*NamespaceConfig
+}
+
+// Option 引擎选项
+type Option func(*Engine)
+
+// WithMaxDepth 设置最大求值深度
+func WithMaxDepth(depth int) Option {
+	return func(e *Engine) {
+		if depth > 0 {
+			e.maxDepth = depth
+		}
+	}
+}
+
+// NewEngine 创建 ReBAC 引擎
+func NewEngine(store Store, opts ...Option) *Engine {
+	e := &Engine{
+		store:      store,
+		maxDepth:   DefaultMaxDepth,
+		namespaces: make(map[string]*NamespaceConfig),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// AddNamespace 添加或替换命名空间配置
+func (e *Engine) AddNamespace(cfg *NamespaceConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.namespaces[cfg.Name] = cfg
+	return nil
+}
+
+// rewrite 返回命名空间中关系的改写规则
+func (e *Engine) rewrite(namespace, relation string) (*Rewrite, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	ns, ok := e.namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNamespace, namespace)
+	}
+	r, ok := ns.relation(relation)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s#%s", ErrUnknownRelation, namespace, relation)
+	}
+	return r, nil
+}
+
+// WriteTuples 写入关系元组
+//
+// 元组对象的命名空间和关系必须已配置；用户集主体的命名空间和关系也必须已配置
+func (e *Engine) WriteTuples(ctx context.Context, tuples ...RelationTuple) error {
+	for _, t := range tuples {
+		if err := e.validateTuple(t); err != nil {
+			return err
+		}
+	}
+	return e.store.Write(ctx, tuples, nil)
+}
+
+// DeleteTuples 删除关系元组，不存在的元组会被忽略
+func (e *Engine) DeleteTuples(ctx context.Context, tuples ...RelationTuple) error {
+	return e.store.Write(ctx, nil, tuples)
+}
+
+// validateTuple 检查元组引用的命名空间和关系
+func (e *Engine) validateTuple(t RelationTuple) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	if _, err := e.rewrite(t.Object.Namespace, t.Relation); err != nil {
+		return err
+	}
+	if t.Subject.IsUserset() {
+		if _, err := e.rewrite(t.Subject.Namespace, t.Subject.Relation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check 检查 subject 是否与 object 存在 relation 关系
+//
+// subject 可以是具体主体（user:alice），也可以是用户集（group:eng#member）。
+// 关系经过 Exclusion 的被减规则引用自身时返回 ErrCyclicExclusion
+func (e *Engine) Check(ctx context.Context, object Object, relation string, subject Subject) (bool, error) {
+	if subject.Namespace == "" || subject.ID == "" {
+		return false, fmt.Errorf("%w: empty subject", ErrInvalidTuple)
+	}
+	return e.check(ctx, object, relation, subject, 0, &checkPath{nodes: make(map[string]int)})
+}
+
+// checkPath Check 的当前求值路径，用于截断环
+//
+// 环被截断时按"不匹配"处理，这只在单调的规则中成立：
+// 环跨过 Exclusion 的被减规则时，截断得到的"不匹配"取反后会变成"匹配"，此时返回 ErrCyclicExclusion
+type checkPath struct {
+	nodes      map[string]int // 路径上的节点 -> 进入该节点时所在的被减规则层数
+	exclusions int            // 当前所在的被减规则层数
+}
+
+// check 求值 object#relation，path 记录当前路径上的节点用于截断环
+func (e *Engine) check(ctx context.Context, object Object, relation string, subject Subject, depth int, path *checkPath) (bool, error) {
+	if depth > e.maxDepth {
+		return false, ErrMaxDepthExceeded
+	}
+	key := object.String() + "#" + relation
+	if level, ok := path.nodes[key]; ok {
+		if level < path.exclusions {
+			return false, fmt.Errorf("%w: %s", ErrCyclicExclusion, key)
+		}
+		return false, nil
+	}
+	path.nodes[key] = path.exclusions
+	defer delete(path.nodes, key)
+
+	r, err := e.rewrite(object.Namespace, relation)
+	if err != nil {
+		return false, err
+	}
+	return e.checkRewrite(ctx, object, relation, r, subject, depth, path)
+}
+
+func (e *Engine) checkRewrite(ctx context.Context, object Object, relation string, r *Rewrite, subject Subject, depth int, path *checkPath) (bool, error) {
+	switch r.Op {
+	case OpThis:
+		tuples, err := e.store.Read(ctx, TupleFilter{Namespace: object.Namespace, ObjectID: object.ID, Relation: relation})
+		if err != nil {
+			return false, err
+		}
+		for _, t := range tuples {
+			if t.Subject == subject {
+				return true, nil
+			}
+		}
+		for _, t := range tuples {
+			if !t.Subject.IsUserset() {
+				continue
+			}
+			ok, err := e.check(ctx, t.Subject.Object(), t.Subject.Relation, subject, depth+1, path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case OpComputedUserset:
+		return e.check(ctx, object, r.Relation, subject, depth+1, path)
+
+	case OpTupleToUserset:
+		tuples, err := e.store.Read(ctx, TupleFilter{Namespace: object.Namespace, ObjectID: object.ID, Relation: r.Tupleset})
+		if err != nil {
+			return false, err
+		}
+		for _, t := range tuples {
+			// 找到的对象没有该关系时跳过（例如 parent 可以指向不同类型的对象）
+			if _, err := e.rewrite(t.Subject.Namespace, r.Relation); err != nil {
+				continue
+			}
+			ok, err := e.check(ctx, t.Subject.Object(), r.Relation, subject, depth+1, path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case OpUnion:
+		for _, child := range r.Children {
+			ok, err := e.checkRewrite(ctx, object, relation, child, subject, depth, path)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case OpIntersection:
+		for _, child := range r.Children {
+			ok, err := e.checkRewrite(ctx, object, relation, child, subject, depth, path)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+
+	case OpExclusion:
+		ok, err := e.checkRewrite(ctx, object, relation, r.Children[0], subject, depth, path)
+		if err != nil || !ok {
+			return false, err
+		}
+		path.exclusions++
+		excluded, err := e.checkRewrite(ctx, object, relation, r.Children[1], subject, depth, path)
+		path.exclusions--
+		if err != nil {
+			return false, err
+		}
+		return !excluded, nil
+	}
+	return false, fmt.Errorf("%w: unknown rewrite op %q", ErrInvalidNamespace, r.Op)
+}
+
+// Tree 用户集树，由 Expand 返回
+//
+// 节点说明：
+// - this: Subjects 是直接写入的主体，Children 是其中用户集主体展开后的子树
+// - computed_userset/tuple_to_userset: Children 是被引用关系展开后的子树
+// - union/intersection/exclusion: Children 是子规则对应的子树
+// - Truncated 为 true 表示该节点在当前路径上已展开过（环），没有继续展开
+type Tree struct {
+	Op        RewriteOp `json:"op"`
+	Object    Object    `json:"object"`
+	Relation  string    `json:"relation"`
+	Subjects  []Subject `json:"subjects,omitempty"`
+	Children  []*Tree   `json:"children,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// Expand 展开 object#relation 的用户集树，用于调试和审计"谁有权限、为什么有"
+func (e *Engine) Expand(ctx context.Context, object Object, relation string) (*Tree, error) {
+	return e.expand(ctx, object, relation, 0, make(map[string]bool))
+}
+
+func (e *Engine) expand(ctx context.Context, object Object, relation string, depth int, path map[string]bool) (*Tree, error) {
+	if depth > e.maxDepth {
+		return nil, ErrMaxDepthExceeded
+	}
+	r, err := e.rewrite(object.Namespace, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	key := object.String() + "#" + relation
+	if path[key] {
+		return &Tree{Op: r.Op, Object: object, Relation: relation, Truncated: true}, nil
+	}
+	path[key] = true
+	defer delete(path, key)
+
+	return e.expandRewrite(ctx, object, relation, r, depth, path)
+}
+
+func (e *Engine) expandRewrite(ctx context.Context, object Object, relation string, r *Rewrite, depth int, path map[string]bool) (*Tree, error) {
+	node := &Tree{Op: r.Op, Object: object, Relation: relation}
+
+	switch r.Op {
+	case OpThis:
+		tuples, err := e.store.Read(ctx, TupleFilter{Namespace: object.Namespace, ObjectID: object.ID, Relation: relation})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tuples {
+			node.Subjects = append(node.Subjects, t.Subject)
+			if !t.Subject.IsUserset() {
+				continue
+			}
+			child, err := e.expand(ctx, t.Subject.Object(), t.Subject.Relation, depth+1, path)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+
+	case OpComputedUserset:
+		child, err := e.expand(ctx, object, r.Relation, depth+1, path)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+
+	case OpTupleToUserset:
+		tuples, err := e.store.Read(ctx, TupleFilter{Namespace: object.Namespace, ObjectID: object.ID, Relation: r.Tupleset})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tuples {
+			if _, err := e.rewrite(t.Subject.Namespace, r.Relation); err != nil {
+				continue
+			}
+			child, err := e.expand(ctx, t.Subject.Object(), r.Relation, depth+1, path)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+
+	default:
+		for _, c := range r.Children {
+			child, err := e.expandRewrite(ctx, object, relation, c, depth, path)
+			if err != nil {
+				return nil, err
+			}
+			node.Children = append(node.Children, child)
+		}
+	}
+	return node, nil
+}
+
+// ListObjects 列出 namespace 中 subject 具有 relation 关系的对象 ID
+//
+// 实现方式：从 subject 出发沿改写规则反向遍历，找出可能包含 subject 的 对象#关系，
+// 其中 namespace#relation 的对象作为候选，再逐个执行 Check 排除交集和排除规则不满足的对象。
+//
+// 反向遍历的边：
+// - this: 以当前用户集为主体的元组（按主体查询，SQL 存储使用主体索引）
+// - computed_userset: 同一对象上引用当前关系的关系
+// - tuple_to_userset: 通过 tupleset 元组指向当前对象的对象
+// - union/intersection: 任一子规则；exclusion 只沿被减规则
+//
+// 注意事项：
+// - 代价与从 subject 反向可达的元组数量和候选对象数量成正比，与命名空间中的对象总数无关
+// - subject 属于很大的用户集（如全员组）时候选仍然很多，应在业务层分页或缩小范围
+// - tuple_to_userset 只沿主体为具体对象（如 folder:docs）的 tupleset 元组反向查找
+// - 反向路径超过最大求值深度时返回 ErrMaxDepthExceeded
+func (e *Engine) ListObjects(ctx context.Context, namespace, relation string, subject Subject) ([]string, error) {
+	if _, err := e.rewrite(namespace, relation); err != nil {
+		return nil, err
+	}
+	if subject.Namespace == "" || subject.ID == "" {
+		return nil, fmt.Errorf("%w: empty subject", ErrInvalidTuple)
+	}
+
+	candidates, err := e.reverseLookup(ctx, namespace, relation, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, id := range candidates {
+		ok, err := e.Check(ctx, Object{Namespace: namespace, ID: id}, relation, subject)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// reverseEdge 改写规则的反向边：到达被引用的关系后，可能到达 namespace 中的 relation 关系
+//
+// tupleset 为空表示同一对象上的 computed_userset，否则表示沿 tupleset 元组的 tuple_to_userset
+type reverseEdge struct {
+	namespace string
+	relation  string
+	tupleset  string
+}
+
+// reverseEdges 按被引用的关系建立改写规则的反向索引
+//
+// 返回：
+//   - computed: 键为 "<namespace>#<relation>"，同一对象上引用该关系的关系
+//   - ttu: 键为被引用的关系名，被引用对象的命名空间由 tupleset 元组决定
+func (e *Engine) reverseEdges() (computed, ttu map[string][]reverseEdge) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	computed = make(map[string][]reverseEdge)
+	ttu = make(map[string][]reverseEdge)
+	var collect func(namespace, relation string, r *Rewrite)
+	collect = func(namespace, relation string, r *Rewrite) {
+		switch r.Op {
+		case OpComputedUserset:
+			key := namespace + "#" + r.Relation
+			computed[key] = append(computed[key], reverseEdge{namespace: namespace, relation: relation})
+		case OpTupleToUserset:
+			ttu[r.Relation] = append(ttu[r.Relation], reverseEdge{namespace: namespace, relation: relation, tupleset: r.Tupleset})
+		case OpUnion, OpIntersection:
+			for _, child := range r.Children {
+				collect(namespace, relation, child)
+			}
+		case OpExclusion:
+			collect(namespace, relation, r.Children[0])
+		}
+	}
+	for _, ns := range e.namespaces {
+		for _, rc := range ns.Relations {
+			if rc.Rewrite != nil {
+				collect(ns.Name, rc.Name, rc.Rewrite)
+			}
+		}
+	}
+	return computed, ttu
+}
+
+// reverseLookup 从 subject 反向遍历，返回 namespace#relation 中可能包含 subject 的对象 ID（已排序）
+func (e *Engine) reverseLookup(ctx context.Context, namespace, relation string, subject Subject) ([]string, error) {
+	computed, ttu := e.reverseEdges()
+
+	type node struct {
+		userset Subject
+		depth   int
+	}
+	reached := make(map[Subject]bool)
+	candidates := make(map[string]bool)
+	var queue []node
+	visit := func(userset Subject, depth int) error {
+		if reached[userset] {
+			return nil
+		}
+		if depth > e.maxDepth {
+			return ErrMaxDepthExceeded
+		}
+		reached[userset] = true
+		if userset.Namespace == namespace && userset.Relation == relation {
+			candidates[userset.ID] = true
+		}
+		queue = append(queue, node{userset: userset, depth: depth})
+		return nil
+	}
+	// visitTuples 把匹配 filter 的元组对象的 relation 关系加入遍历，relation 为空时使用元组的关系
+	visitTuples := func(filter TupleFilter, relation string, depth int) error {
+		tuples, err := e.store.Read(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, t := range tuples {
+			rel := relation
+			if rel == "" {
+				rel = t.Relation
+			}
+			if err := visit(Userset(t.Object, rel), depth); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := visitTuples(TupleFilter{Subject: &subject}, "", 1); err != nil {
+		return nil, err
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if err := visitTuples(TupleFilter{Subject: &n.userset}, "", n.depth+1); err != nil {
+			return nil, err
+		}
+		for _, edge := range computed[n.userset.Namespace+"#"+n.userset.Relation] {
+			if err := visit(Userset(n.userset.Object(), edge.relation), n.depth+1); err != nil {
+				return nil, err
+			}
+		}
+		object := Subject{Namespace: n.userset.Namespace, ID: n.userset.ID}
+		for _, edge := range ttu[n.userset.Relation] {
+			filter := TupleFilter{Namespace: edge.namespace, Relation: edge.tupleset, Subject: &object}
+			if err := visitTuples(filter, edge.relation, n.depth+1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
@@ -0,0 +1,357 @@
+package rebac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEngine 创建带有 group/folder/document 命名空间的引擎
+//
+// document 的关系：
+// - owner: 直接写入
+// - editor: 直接写入 ∪ owner
+// - viewer: 直接写入 ∪ editor ∪ 上级文件夹的 viewer
+// - banned: 直接写入
+// - commenter: (viewer ∩ 直接写入) - banned
+func newTestEngine(t *testing.T, store Store) *Engine {
+	t.Helper()
+
+	e := NewEngine(store)
+	require.NoError(t, e.AddNamespace(Namespace("group", Relation("member"))))
+	require.NoError(t, e.AddNamespace(Namespace("folder",
+		Relation("parent"),
+		Relation("viewer", Union(This(), TupleToUserset("parent", "viewer"))),
+	)))
+	require.NoError(t, e.AddNamespace(Namespace("document",
+		Relation("parent"),
+		Relation("owner"),
+		Relation("editor", Union(This(), ComputedUserset("owner"))),
+		Relation("viewer", Union(This(), ComputedUserset("editor"), TupleToUserset("parent", "viewer"))),
+		Relation("banned"),
+		Relation("commenter", Exclusion(Intersection(This(), ComputedUserset("viewer")), ComputedUserset("banned"))),
+	)))
+	return e
+}
+
+// writeTuples 解析并写入元组
+func writeTuples(t *testing.T, e *Engine, tuples ...string) {
+	t.Helper()
+
+	parsed := make([]RelationTuple, len(tuples))
+	for i, s := range tuples {
+		parsed[i] = MustParseTuple(s)
+	}
+	require.NoError(t, e.WriteTuples(context.Background(), parsed...))
+}
+
+var readme = Object{Namespace: "document", ID: "readme"}
+
+// TestEngine_Check 测试各类改写规则的求值
+func TestEngine_Check(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e,
+		"document:readme#owner@user:alice",
+		"document:readme#editor@group:eng#member",
+		"group:eng#member@user:bob",
+		"group:eng#member@group:interns#member",
+		"group:interns#member@user:carol",
+		"folder:root#viewer@user:dave",
+		"folder:docs#parent@folder:root",
+		"document:readme#parent@folder:docs",
+		"document:readme#commenter@user:bob",
+		"document:readme#commenter@user:carol",
+		"document:readme#commenter@user:erin",
+		"document:readme#banned@user:carol",
+	)
+
+	tests := []struct {
+		name     string
+		relation string
+		subject  Subject
+		want     bool
+	}{
+		{"direct owner", "owner", User("alice"), true},
+		{"owner is editor", "editor", User("alice"), true},
+		{"owner is viewer", "viewer", User("alice"), true},
+		{"group member is editor", "editor", User("bob"), true},
+		{"group member is not owner", "owner", User("bob"), false},
+		{"nested group member", "viewer", User("carol"), true},
+		{"userset subject", "editor", Userset(Object{Namespace: "group", ID: "eng"}, "member"), true},
+		{"viewer of ancestor folder", "viewer", User("dave"), true},
+		{"folder viewer is not editor", "editor", User("dave"), false},
+		{"commenter", "commenter", User("bob"), true},
+		{"banned commenter", "commenter", User("carol"), false},
+		{"commenter without view access", "commenter", User("erin"), false},
+		{"stranger", "viewer", User("mallory"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.Check(context.Background(), readme, tt.relation, tt.subject)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestEngine_CheckAfterDelete 测试删除元组后立即生效
+func TestEngine_CheckAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e, "document:readme#viewer@user:alice")
+
+	ok, err := e.Check(ctx, readme, "viewer", User("alice"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, e.DeleteTuples(ctx, MustParseTuple("document:readme#viewer@user:alice")))
+	ok, err = e.Check(ctx, readme, "viewer", User("alice"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// TestEngine_CheckCycle 测试用户集形成环时不会死循环
+func TestEngine_CheckCycle(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e,
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+		"group:b#member@user:alice",
+	)
+
+	a := Object{Namespace: "group", ID: "a"}
+	ok, err := e.Check(context.Background(), a, "member", User("alice"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = e.Check(context.Background(), a, "member", User("bob"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// TestEngine_CheckCycleInExclusion 测试环跨过 Exclusion 的被减规则时返回错误
+func TestEngine_CheckCycleInExclusion(t *testing.T) {
+	e := NewEngine(NewMemoryStore())
+	require.NoError(t, e.AddNamespace(Namespace("doc",
+		Relation("viewer", Exclusion(This(), ComputedUserset("blocked"))),
+		Relation("blocked", Union(This(), ComputedUserset("viewer"))),
+	)))
+	writeTuples(t, e, "doc:x#viewer@user:alice")
+
+	_, err := e.Check(context.Background(), Object{Namespace: "doc", ID: "x"}, "viewer", User("alice"))
+	assert.ErrorIs(t, err, ErrCyclicExclusion)
+
+	// 环完全位于被减规则内部时仍然按不匹配截断
+	e = newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e,
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+		"group:b#member@user:carol",
+		"document:readme#banned@group:a#member",
+		"document:readme#viewer@user:carol",
+		"document:readme#viewer@user:erin",
+		"document:readme#commenter@user:carol",
+		"document:readme#commenter@user:erin",
+	)
+
+	ok, err := e.Check(context.Background(), readme, "commenter", User("erin"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = e.Check(context.Background(), readme, "commenter", User("carol"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// TestEngine_MaxDepth 测试超过最大深度时返回错误
+func TestEngine_MaxDepth(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	e.maxDepth = 2
+	writeTuples(t, e,
+		"group:a#member@group:b#member",
+		"group:b#member@group:c#member",
+		"group:c#member@group:d#member",
+		"group:d#member@user:alice",
+	)
+
+	_, err := e.Check(context.Background(), Object{Namespace: "group", ID: "a"}, "member", User("alice"))
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+
+	_, err = e.Expand(context.Background(), Object{Namespace: "group", ID: "a"}, "member")
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+}
+
+// TestEngine_WriteValidation 测试写入未配置的命名空间或关系
+func TestEngine_WriteValidation(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEngine(t, NewMemoryStore())
+
+	err := e.WriteTuples(ctx, MustParseTuple("repo:api#owner@user:alice"))
+	assert.ErrorIs(t, err, ErrUnknownNamespace)
+
+	err = e.WriteTuples(ctx, MustParseTuple("document:readme#admin@user:alice"))
+	assert.ErrorIs(t, err, ErrUnknownRelation)
+
+	err = e.WriteTuples(ctx, MustParseTuple("document:readme#viewer@group:eng#admin"))
+	assert.ErrorIs(t, err, ErrUnknownRelation)
+
+	err = e.WriteTuples(ctx, RelationTuple{Object: readme, Relation: "viewer"})
+	assert.ErrorIs(t, err, ErrInvalidTuple)
+
+	// 一个元组无效时整批都不写入
+	err = e.WriteTuples(ctx,
+		MustParseTuple("document:readme#viewer@user:alice"),
+		MustParseTuple("document:readme#admin@user:alice"),
+	)
+	assert.Error(t, err)
+	ok, err := e.Check(ctx, readme, "viewer", User("alice"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = e.Check(ctx, Object{Namespace: "repo", ID: "api"}, "owner", User("alice"))
+	assert.ErrorIs(t, err, ErrUnknownNamespace)
+}
+
+// TestEngine_ListObjects 测试列出主体可访问的对象
+func TestEngine_ListObjects(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e,
+		"document:a#owner@user:alice",
+		"document:b#viewer@group:eng#member",
+		"document:c#parent@folder:shared",
+		"document:d#viewer@user:bob",
+		"group:eng#member@user:alice",
+		"folder:shared#viewer@user:alice",
+	)
+
+	ids, err := e.ListObjects(context.Background(), "document", "viewer", User("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+
+	ids, err = e.ListObjects(context.Background(), "document", "editor", User("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, ids)
+
+	_, err = e.ListObjects(context.Background(), "document", "admin", User("alice"))
+	assert.ErrorIs(t, err, ErrUnknownRelation)
+}
+
+// recordingStore 记录 Read 的查询条件
+type recordingStore struct {
+	Store
+	filters []TupleFilter
+}
+
+func (s *recordingStore) Read(ctx context.Context, filter TupleFilter) ([]RelationTuple, error) {
+	s.filters = append(s.filters, filter)
+	return s.Store.Read(ctx, filter)
+}
+
+// TestEngine_ListObjectsReverseLookup 测试 ListObjects 从主体反向查找，不扫描整个命名空间
+func TestEngine_ListObjectsReverseLookup(t *testing.T) {
+	store := &recordingStore{Store: NewMemoryStore()}
+	e := newTestEngine(t, store)
+	writeTuples(t, e,
+		"document:a#viewer@user:alice",
+		"document:b#parent@folder:docs",
+		"folder:docs#parent@folder:root",
+		"folder:root#viewer@group:eng#member",
+		"group:eng#member@group:interns#member",
+		"group:interns#member@user:alice",
+		"document:c#commenter@user:alice",
+		"document:c#viewer@user:alice",
+		"document:d#commenter@user:alice",
+		"document:d#viewer@user:alice",
+		"document:d#banned@user:alice",
+		"document:e#commenter@user:alice",
+		"document:f#viewer@user:bob",
+	)
+	store.filters = nil
+
+	ctx := context.Background()
+	ids, err := e.ListObjects(ctx, "document", "viewer", User("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, ids)
+	for _, filter := range store.filters {
+		assert.False(t, filter.Subject == nil && filter.ObjectID == "", "unexpected namespace scan: %+v", filter)
+	}
+
+	// 交集和排除规则由 Check 确认
+	ids, err = e.ListObjects(ctx, "document", "commenter", User("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids)
+
+	// 用户集主体
+	ids, err = e.ListObjects(ctx, "document", "viewer", Userset(Object{Namespace: "group", ID: "interns"}, "member"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, ids)
+
+	ids, err = e.ListObjects(ctx, "document", "viewer", User("mallory"))
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	_, err = e.ListObjects(ctx, "document", "viewer", Subject{})
+	assert.ErrorIs(t, err, ErrInvalidTuple)
+}
+
+// TestEngine_ListObjectsCycle 测试反向遍历遇到环时终止
+func TestEngine_ListObjectsCycle(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e,
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+		"group:b#member@user:alice",
+		"document:readme#viewer@group:a#member",
+	)
+
+	ids, err := e.ListObjects(context.Background(), "group", "member", User("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, ids)
+
+	ids, err = e.ListObjects(context.Background(), "document", "viewer", User("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"readme"}, ids)
+}
+
+// TestEngine_Expand 测试展开用户集树
+func TestEngine_Expand(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e,
+		"document:readme#owner@user:alice",
+		"document:readme#viewer@group:eng#member",
+		"group:eng#member@user:bob",
+		"group:eng#member@group:eng#member",
+	)
+
+	tree, err := e.Expand(context.Background(), readme, "viewer")
+	require.NoError(t, err)
+
+	assert.Equal(t, OpUnion, tree.Op)
+	require.Len(t, tree.Children, 3)
+
+	// 直接写入：group:eng#member，展开为 bob 和被截断的自身引用
+	direct := tree.Children[0]
+	assert.Equal(t, OpThis, direct.Op)
+	assert.Equal(t, []Subject{Userset(Object{Namespace: "group", ID: "eng"}, "member")}, direct.Subjects)
+	require.Len(t, direct.Children, 1)
+	group := direct.Children[0]
+	assert.ElementsMatch(t, []Subject{User("bob"), Userset(Object{Namespace: "group", ID: "eng"}, "member")}, group.Subjects)
+	require.Len(t, group.Children, 1)
+	assert.True(t, group.Children[0].Truncated)
+
+	// editor -> owner -> alice
+	editor := tree.Children[1]
+	assert.Equal(t, OpComputedUserset, editor.Op)
+	require.Len(t, editor.Children, 1)
+	assert.Equal(t, "editor", editor.Children[0].Relation)
+	owner := editor.Children[0].Children[1].Children[0]
+	assert.Equal(t, "owner", owner.Relation)
+	assert.Equal(t, []Subject{User("alice")}, owner.Subjects)
+
+	// 没有 parent 元组
+	assert.Equal(t, OpTupleToUserset, tree.Children[2].Op)
+	assert.Empty(t, tree.Children[2].Children)
+}
@@ -0,0 +1,94 @@
+package rebac
+
+import (
+	"net/http"
+
+	"github.com/yourusername/golang/pkg/security/rbac"
+)
+
+// SubjectResolver 从请求中解析主体
+type SubjectResolver func(r *http.Request) (Subject, bool)
+
+// ObjectIDFunc 从请求中解析对象 ID
+type ObjectIDFunc func(r *http.Request) string
+
+// Middleware ReBAC 中间件
+//
+// 与 rbac.Middleware 共用上下文：默认从 rbac.GetUserID 读取用户ID，
+// 因此可以与 rbac.Middleware 组合使用（先检查角色权限，再检查对象关系）
+type Middleware struct {
+	engine   *Engine
+	resolver SubjectResolver
+}
+
+// MiddlewareOption 中间件选项
+type MiddlewareOption func(*Middleware)
+
+// WithSubjectResolver 设置主体解析函数
+func WithSubjectResolver(resolver SubjectResolver) MiddlewareOption {
+	return func(m *Middleware) {
+		m.resolver = resolver
+	}
+}
+
+// NewMiddleware 创建 ReBAC 中间件
+func NewMiddleware(engine *Engine, opts ...MiddlewareOption) *Middleware {
+	m := &Middleware{
+		engine:   engine,
+		resolver: userFromRBACContext,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// userFromRBACContext 默认主体解析：rbac 上下文中的用户ID
+func userFromRBACContext(r *http.Request) (Subject, bool) {
+	userID, ok := rbac.GetUserID(r.Context())
+	if !ok || userID == "" {
+		return Subject{}, false
+	}
+	return User(userID), true
+}
+
+// RequireRelation 要求当前主体与请求对象存在关系的中间件
+//
+// 示例：
+//
+//	r.With(m.RequireRelation("document", "viewer", rebac.PathValue("id"))).Get("/documents/{id}", h.Get)
+func (m *Middleware) RequireRelation(namespace, relation string, objectID ObjectIDFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			subject, ok := m.resolver(r)
+			if !ok {
+				http.Error(w, "Unauthorized: no subject found", http.StatusUnauthorized)
+				return
+			}
+			id := objectID(r)
+			if id == "" {
+				http.Error(w, "Bad request: missing object id", http.StatusBadRequest)
+				return
+			}
+
+			allowed, err := m.engine.Check(r.Context(), Object{Namespace: namespace, ID: id}, relation, subject)
+			if err != nil {
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden: no relation to object", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// PathValue 返回读取路径参数的 ObjectIDFunc（兼容 net/http 和 chi 路由）
+func PathValue(name string) ObjectIDFunc {
+	return func(r *http.Request) string {
+		return r.PathValue(name)
+	}
+}
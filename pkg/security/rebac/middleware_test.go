@@ -0,0 +1,107 @@
+package rebac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourusername/golang/pkg/security/rbac"
+)
+
+// TestMiddleware_RequireRelation 测试对象关系中间件
+func TestMiddleware_RequireRelation(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e, "document:readme#viewer@user:alice")
+
+	mux := http.NewServeMux()
+	m := NewMiddleware(e)
+	mux.Handle("GET /documents/{id}", m.RequireRelation("document", "viewer", PathValue("id"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	tests := []struct {
+		name           string
+		userID         string
+		path           string
+		expectedStatus int
+	}{
+		{"viewer", "alice", "/documents/readme", http.StatusOK},
+		{"no relation", "bob", "/documents/readme", http.StatusForbidden},
+		{"other object", "alice", "/documents/guide", http.StatusForbidden},
+		{"no user", "", "/documents/readme", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.userID != "" {
+				req = req.WithContext(rbac.WithUserID(req.Context(), tt.userID))
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+// TestMiddleware_Errors 测试缺少对象 ID 和求值错误
+func TestMiddleware_Errors(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	alice := WithSubjectResolver(func(r *http.Request) (Subject, bool) {
+		return User("alice"), true
+	})
+	m := NewMiddleware(e, alice)
+
+	w := httptest.NewRecorder()
+	m.RequireRelation("document", "viewer", PathValue("id"))(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	objectID := func(r *http.Request) string { return "readme" }
+	m.RequireRelation("repo", "viewer", objectID)(ok).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+// TestMiddleware_WithRBAC 测试与 rbac.Middleware 组合使用
+func TestMiddleware_WithRBAC(t *testing.T) {
+	e := newTestEngine(t, NewMemoryStore())
+	writeTuples(t, e, "document:readme#editor@user:alice", "document:readme#editor@user:bob")
+
+	r := rbac.NewRBAC()
+	if err := r.InitializeDefaultRoles(); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := rbac.NewMiddleware(r).RequirePermission("user", "read")(
+		NewMiddleware(e).RequireRelation("document", "editor", func(r *http.Request) string { return "readme" })(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})))
+
+	tests := []struct {
+		name           string
+		userID         string
+		roles          []string
+		expectedStatus int
+	}{
+		{"role and relation", "alice", []string{"user"}, http.StatusOK},
+		{"relation without role", "bob", []string{"guest"}, http.StatusForbidden},
+		{"role without relation", "carol", []string{"user"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			ctx := rbac.WithUserRoles(req.Context(), tt.roles)
+			ctx = rbac.WithUserID(ctx, tt.userID)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req.WithContext(ctx))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
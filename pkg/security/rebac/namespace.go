@@ -0,0 +1,188 @@
+package rebac
+
+import (
+	"fmt"
+)
+
+// RewriteOp 改写规则类型
+type RewriteOp string
+
+const (
+	// OpThis 直接写入该关系的元组
+	OpThis RewriteOp = "this"
+	// OpComputedUserset 同一对象的另一个关系
+	OpComputedUserset RewriteOp = "computed_userset"
+	// OpTupleToUserset 沿 Tupleset 关系找到的对象的 Relation 关系
+	OpTupleToUserset RewriteOp = "tuple_to_userset"
+	// OpUnion 任一子规则匹配
+	OpUnion RewriteOp = "union"
+	// OpIntersection 所有子规则都匹配
+	OpIntersection RewriteOp = "intersection"
+	// OpExclusion 第一个子规则匹配且第二个不匹配
+	OpExclusion RewriteOp = "exclusion"
+)
+
+// Rewrite 用户集改写规则
+//
+// 字段说明：
+// - Op: 规则类型
+// - Relation: ComputedUserset 的目标关系；TupleToUserset 中在找到的对象上求值的关系
+// - Tupleset: TupleToUserset 中用于查找对象的关系（如 parent）
+// - Children: Union/Intersection/Exclusion 的子规则
+type Rewrite struct {
+	Op       RewriteOp  `json:"op"`
+	Relation string     `json:"relation,omitempty"`
+	Tupleset string     `json:"tupleset,omitempty"`
+	Children []*Rewrite `json:"children,omitempty"`
+}
+
+// This 直接写入的元组
+func This() *Rewrite {
+	return &Rewrite{Op: OpThis}
+}
+
+// ComputedUserset 同一对象的另一个关系，例如 editor 也是 viewer：
+//
+//	rebac.Relation("viewer", rebac.Union(rebac.This(), rebac.ComputedUserset("editor")))
+func ComputedUserset(relation string) *Rewrite {
+	return &Rewrite{Op: OpComputedUserset, Relation: relation}
+}
+
+// TupleToUserset 沿 tupleset 关系找到其他对象，再取这些对象的 relation 关系，
+// 例如文档所在文件夹的 viewer 也是文档的 viewer：
+//
+//	rebac.Relation("viewer", rebac.Union(rebac.This(), rebac.TupleToUserset("parent", "viewer")))
+func TupleToUserset(tupleset, relation string) *Rewrite {
+	return &Rewrite{Op: OpTupleToUserset, Tupleset: tupleset, Relation: relation}
+}
+
+// Union 任一子规则匹配
+func Union(children ...*Rewrite) *Rewrite {
+	return &Rewrite{Op: OpUnion, Children: children}
+}
+
+// Intersection 所有子规则都匹配
+func Intersection(children ...*Rewrite) *Rewrite {
+	return &Rewrite{Op: OpIntersection, Children: children}
+}
+
+// Exclusion base 匹配且 subtract 不匹配
+func Exclusion(base, subtract *Rewrite) *Rewrite {
+	return &Rewrite{Op: OpExclusion, Children: []*Rewrite{base, subtract}}
+}
+
+// RelationConfig 关系配置，Rewrite 为 nil 时等同于 This()
+type RelationConfig struct {
+	Name    string   `json:"name"`
+	Rewrite *Rewrite `json:"rewrite,omitempty"`
+}
+
+// NamespaceConfig 命名空间配置
+type NamespaceConfig struct {
+	Name      string           `json:"name"`
+	Relations []RelationConfig `json:"relations"`
+}
+
+// Relation 创建关系配置，不传改写规则时只包含直接写入的元组
+func Relation(name string, rewrite ...*Rewrite) RelationConfig {
+	rc := RelationConfig{Name: name}
+	if len(rewrite) > 0 {
+		rc.Rewrite = rewrite[0]
+	}
+	return rc
+}
+
+// Namespace 创建命名空间配置
+func Namespace(name string, relations ...RelationConfig) *NamespaceConfig {
+	return &NamespaceConfig{Name: name, Relations: relations}
+}
+
+// relation 返回关系的改写规则
+func (n *NamespaceConfig) relation(name string) (*Rewrite, bool) {
+	for _, rc := range n.Relations {
+		if rc.Name == name {
+			if rc.Rewrite == nil {
+				return This(), true
+			}
+			return rc.Rewrite, true
+		}
+	}
+	return nil, false
+}
+
+// validate 检查命名空间配置
+//
+// ComputedUserset 和 Tupleset 引用的关系必须在同一命名空间中定义；
+// TupleToUserset 的 Relation 属于其他命名空间，在求值时检查
+func (n *NamespaceConfig) validate() error {
+	if n == nil || n.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidNamespace)
+	}
+	if len(n.Relations) == 0 {
+		return fmt.Errorf("%w: namespace %s has no relations", ErrInvalidNamespace, n.Name)
+	}
+
+	seen := make(map[string]bool, len(n.Relations))
+	for _, rc := range n.Relations {
+		if rc.Name == "" {
+			return fmt.Errorf("%w: namespace %s has a relation without name", ErrInvalidNamespace, n.Name)
+		}
+		if seen[rc.Name] {
+			return fmt.Errorf("%w: duplicate relation %s#%s", ErrInvalidNamespace, n.Name, rc.Name)
+		}
+		seen[rc.Name] = true
+	}
+	for _, rc := range n.Relations {
+		if rc.Rewrite == nil {
+			continue
+		}
+		if err := n.validateRewrite(rc.Name, rc.Rewrite, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *NamespaceConfig) validateRewrite(relation string, r *Rewrite, relations map[string]bool) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s#%s: %s", ErrInvalidNamespace, n.Name, relation, fmt.Sprintf(format, args...))
+	}
+
+	if r == nil {
+		return invalid("nil rewrite")
+	}
+	switch r.Op {
+	case OpThis:
+		return nil
+	case OpComputedUserset:
+		if !relations[r.Relation] {
+			return invalid("computed userset references unknown relation %q", r.Relation)
+		}
+		return nil
+	case OpTupleToUserset:
+		if !relations[r.Tupleset] {
+			return invalid("tupleset references unknown relation %q", r.Tupleset)
+		}
+		if r.Relation == "" {
+			return invalid("tuple to userset requires a computed relation")
+		}
+		return nil
+	case OpUnion, OpIntersection:
+		if len(r.Children) == 0 {
+			return invalid("%s requires at least one child", r.Op)
+		}
+	case OpExclusion:
+		if len(r.Children) != 2 {
+			return invalid("exclusion requires exactly two children")
+		}
+	default:
+		return invalid("unknown rewrite op %q", r.Op)
+	}
+
+	for _, child := range r.Children {
+		if err := n.validateRewrite(relation, child, relations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
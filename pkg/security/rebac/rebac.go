@@ -0,0 +1,200 @@
+// Package rebac 提供基于关系的访问控制（ReBAC，Zanzibar 风格）实现
+//
+// 设计原理：
+// 1. 权限由关系元组（relation tuple）表达：object#relation@subject，如 document:readme#viewer@group:eng#member
+// 2. 命名空间配置为每个关系定义改写规则（userset rewrite），见下方"改写规则"
+// 3. Check 递归求值改写规则，Expand 返回用户集树，ListObjects 列出主体可访问的对象
+//
+// 改写规则：
+// - This: 直接写入的元组
+// - ComputedUserset: 同一对象的另一个关系（editor 也是 viewer）
+// - TupleToUserset: 沿着关系找到其他对象，再取它们的某个关系（文件夹的 viewer 也是文档的 viewer）
+// - Union/Intersection/Exclusion: 组合改写规则
+//
+// 与 RBAC、ABAC 的关系：
+// - RBAC 回答"用户的角色能否对某类资源执行操作"
+// - ABAC 基于属性做策略判断
+// - ReBAC 回答"用户与某个具体对象是否存在（直接或间接的）关系"，适合对象级共享
+//
+// 示例：
+//
+//	engine := rebac.NewEngine(rebac.NewMemoryStore())
+//	engine.AddNamespace(rebac.Namespace("group", rebac.Relation("member")))
+//	engine.AddNamespace(rebac.Namespace("document",
+//	    rebac.Relation("parent"),
+//	    rebac.Relation("owner"),
+//	    rebac.Relation("editor", rebac.Union(rebac.This(), rebac.ComputedUserset("owner"))),
+//	    rebac.Relation("viewer", rebac.Union(rebac.This(), rebac.ComputedUserset("editor"))),
+//	))
+//
+//	engine.WriteTuples(ctx,
+//	    rebac.MustParseTuple("group:eng#member@user:alice"),
+//	    rebac.MustParseTuple("document:readme#editor@group:eng#member"),
+//	)
+//	ok, err := engine.Check(ctx, rebac.Object{Namespace: "document", ID: "readme"}, "viewer",
+//	    rebac.User("alice"))
+//
+// 注意事项：
+// - 主体的命名空间不需要配置（如 user），用户集主体（group:eng#member）的命名空间和关系必须已配置
+// - 改写规则形成环（如组互相包含）时，环上重复访问的节点视为不匹配
+package rebac
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// UserNamespace 默认的用户命名空间
+const UserNamespace = "user"
+
+var (
+	// ErrInvalidTuple 关系元组格式错误
+	ErrInvalidTuple = errors.New("rebac: invalid relation tuple")
+
+	// ErrUnknownNamespace 命名空间未配置
+	ErrUnknownNamespace = errors.New("rebac: unknown namespace")
+
+	// ErrUnknownRelation 命名空间中没有该关系
+	ErrUnknownRelation = errors.New("rebac: unknown relation")
+
+	// ErrInvalidNamespace 命名空间配置无效
+	ErrInvalidNamespace = errors.New("rebac: invalid namespace config")
+
+	// ErrMaxDepthExceeded 求值深度超过上限
+	ErrMaxDepthExceeded = errors.New("rebac: max depth exceeded")
+
+	// ErrCyclicExclusion 关系经过 Exclusion 的被减规则引用了自身，结果无法确定
+	ErrCyclicExclusion = errors.New("rebac: cycle through exclusion")
+)
+
+// Object 对象，字符串形式为 namespace:id
+type Object struct {
+	Namespace string `json:"namespace"`
+	ID        string `json:"id"`
+}
+
+// String 返回 namespace:id
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+// Subject 主体
+//
+// Relation 为空时是具体主体（user:alice），否则是用户集（group:eng#member，
+// 表示 group:eng 的所有 member）
+type Subject struct {
+	Namespace string `json:"namespace"`
+	ID        string `json:"id"`
+	Relation  string `json:"relation,omitempty"`
+}
+
+// User 创建 user 命名空间的主体
+func User(id string) Subject {
+	return Subject{Namespace: UserNamespace, ID: id}
+}
+
+// Userset 创建用户集主体
+func Userset(object Object, relation string) Subject {
+	return Subject{Namespace: object.Namespace, ID: object.ID, Relation: relation}
+}
+
+// IsUserset 是否是用户集
+func (s Subject) IsUserset() bool {
+	return s.Relation != ""
+}
+
+// Object 返回主体对应的对象
+func (s Subject) Object() Object {
+	return Object{Namespace: s.Namespace, ID: s.ID}
+}
+
+// String 返回 namespace:id 或 namespace:id#relation
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Namespace + ":" + s.ID
+	}
+	return s.Namespace + ":" + s.ID + "#" + s.Relation
+}
+
+// RelationTuple 关系元组 object#relation@subject
+type RelationTuple struct {
+	Object   Object  `json:"object"`
+	Relation string  `json:"relation"`
+	Subject  Subject `json:"subject"`
+}
+
+// String 返回 object#relation@subject
+func (t RelationTuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// validate 检查元组的各个部分都不为空
+func (t RelationTuple) validate() error {
+	if t.Object.Namespace == "" || t.Object.ID == "" || t.Relation == "" ||
+		t.Subject.Namespace == "" || t.Subject.ID == "" {
+		return fmt.Errorf("%w: %s", ErrInvalidTuple, t)
+	}
+	return nil
+}
+
+// ParseTuple 解析 object#relation@subject 形式的关系元组
+//
+// 示例：
+//   - document:readme#owner@user:alice
+//   - document:readme#viewer@group:eng#member
+//   - document:readme#parent@folder:docs
+func ParseTuple(s string) (RelationTuple, error) {
+	objectRelation, subject, ok := strings.Cut(s, "@")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf("%w: %q", ErrInvalidTuple, s)
+	}
+	object, relation, ok := strings.Cut(objectRelation, "#")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf("%w: %q", ErrInvalidTuple, s)
+	}
+
+	t := RelationTuple{Relation: relation}
+	var err error
+	if t.Object, err = ParseObject(object); err != nil {
+		return RelationTuple{}, fmt.Errorf("%w: %q", ErrInvalidTuple, s)
+	}
+	if t.Subject, err = ParseSubject(subject); err != nil {
+		return RelationTuple{}, fmt.Errorf("%w: %q", ErrInvalidTuple, s)
+	}
+	if err := t.validate(); err != nil {
+		return RelationTuple{}, err
+	}
+	return t, nil
+}
+
+// MustParseTuple 解析关系元组，格式错误时 panic，用于常量和测试
+func MustParseTuple(s string) RelationTuple {
+	t, err := ParseTuple(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// ParseObject 解析 namespace:id 形式的对象
+func ParseObject(s string) (Object, error) {
+	namespace, id, ok := strings.Cut(s, ":")
+	if !ok || namespace == "" || id == "" || strings.ContainsAny(namespace, "#@") || strings.ContainsAny(id, "#@") {
+		return Object{}, fmt.Errorf("%w: object %q", ErrInvalidTuple, s)
+	}
+	return Object{Namespace: namespace, ID: id}, nil
+}
+
+// ParseSubject 解析 namespace:id 或 namespace:id#relation 形式的主体
+func ParseSubject(s string) (Subject, error) {
+	object, relation, hasRelation := strings.Cut(s, "#")
+	o, err := ParseObject(object)
+	if err != nil {
+		return Subject{}, err
+	}
+	if hasRelation && relation == "" {
+		return Subject{}, fmt.Errorf("%w: subject %q", ErrInvalidTuple, s)
+	}
+	return Subject{Namespace: o.Namespace, ID: o.ID, Relation: relation}, nil
+}
@@ -0,0 +1,99 @@
+package rebac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseTuple 测试关系元组解析
+func TestParseTuple(t *testing.T) {
+	tests := []struct {
+		input string
+		want  RelationTuple
+	}{
+		{
+			input: "document:readme#owner@user:alice",
+			want: RelationTuple{
+				Object:   Object{Namespace: "document", ID: "readme"},
+				Relation: "owner",
+				Subject:  User("alice"),
+			},
+		},
+		{
+			input: "document:readme#viewer@group:eng#member",
+			want: RelationTuple{
+				Object:   Object{Namespace: "document", ID: "readme"},
+				Relation: "viewer",
+				Subject:  Userset(Object{Namespace: "group", ID: "eng"}, "member"),
+			},
+		},
+		{
+			input: "document:a:b#parent@folder:docs",
+			want: RelationTuple{
+				Object:   Object{Namespace: "document", ID: "a:b"},
+				Relation: "parent",
+				Subject:  Subject{Namespace: "folder", ID: "docs"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTuple(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.input, got.String())
+		})
+	}
+}
+
+// TestParseTuple_Invalid 测试格式错误的元组
+func TestParseTuple_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"document:readme#owner",
+		"document:readme@user:alice",
+		"readme#owner@user:alice",
+		"document:#owner@user:alice",
+		"document:readme#@user:alice",
+		"document:readme#owner@user",
+		"document:readme#viewer@group:eng#",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseTuple(input)
+			assert.ErrorIs(t, err, ErrInvalidTuple)
+		})
+	}
+}
+
+// TestNamespaceConfig_Validate 测试命名空间配置校验
+func TestNamespaceConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   *NamespaceConfig
+		valid bool
+	}{
+		{"valid", Namespace("doc", Relation("owner"), Relation("viewer", Union(This(), ComputedUserset("owner")))), true},
+		{"missing name", Namespace("", Relation("owner")), false},
+		{"no relations", Namespace("doc"), false},
+		{"duplicate relation", Namespace("doc", Relation("owner"), Relation("owner")), false},
+		{"unknown computed relation", Namespace("doc", Relation("viewer", ComputedUserset("owner"))), false},
+		{"unknown tupleset", Namespace("doc", Relation("viewer", TupleToUserset("parent", "viewer"))), false},
+		{"empty union", Namespace("doc", Relation("viewer", Union())), false},
+		{"bad exclusion", Namespace("doc", Relation("viewer", &Rewrite{Op: OpExclusion, Children: []*Rewrite{This()}})), false},
+		{"unknown op", Namespace("doc", Relation("viewer", &Rewrite{Op: "xor"})), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidNamespace)
+			}
+		})
+	}
+}
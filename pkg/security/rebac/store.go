@@ -0,0 +1,87 @@
+package rebac
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// TupleFilter 元组查询条件，空字段不参与过滤
+type TupleFilter struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+	Subject   *Subject
+}
+
+// matches 元组是否满足过滤条件
+func (f TupleFilter) matches(t RelationTuple) bool {
+	if f.Namespace != "" && f.Namespace != t.Object.Namespace {
+		return false
+	}
+	if f.ObjectID != "" && f.ObjectID != t.Object.ID {
+		return false
+	}
+	if f.Relation != "" && f.Relation != t.Relation {
+		return false
+	}
+	if f.Subject != nil && *f.Subject != t.Subject {
+		return false
+	}
+	return true
+}
+
+// Store 关系元组存储
+//
+// 实现要求：
+// - Write 必须是原子的：写入和删除要么全部生效，要么全部不生效
+// - 写入已存在的元组、删除不存在的元组都不报错
+type Store interface {
+	// Write 写入 writes 并删除 deletes
+	Write(ctx context.Context, writes, deletes []RelationTuple) error
+
+	// Read 读取满足过滤条件的元组
+	Read(ctx context.Context, filter TupleFilter) ([]RelationTuple, error)
+}
+
+// MemoryStore 内存元组存储，适用于测试和单实例部署
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tuples map[RelationTuple]struct{}
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tuples: make(map[RelationTuple]struct{})}
+}
+
+// Write 写入并删除元组
+func (s *MemoryStore) Write(ctx context.Context, writes, deletes []RelationTuple) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range writes {
+		s.tuples[t] = struct{}{}
+	}
+	for _, t := range deletes {
+		delete(s.tuples, t)
+	}
+	return nil
+}
+
+// Read 读取满足过滤条件的元组，按字符串形式排序
+func (s *MemoryStore) Read(ctx context.Context, filter TupleFilter) ([]RelationTuple, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []RelationTuple
+	for t := range s.tuples {
+		if filter.matches(t) {
+			result = append(result, t)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package rebac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/golang/internal/infra/database/sqldialect"
+)
+
+// Dialect SQL 方言
+type Dialect = sqldialect.Dialect
+
+const (
+	// DialectPostgres PostgreSQL 方言（$1 占位符）
+	DialectPostgres = sqldialect.Postgres
+
+	// DialectSQLite SQLite 方言（? 占位符）
+	DialectSQLite = sqldialect.SQLite
+)
+
+// SQLStore 基于 database/sql 的元组存储
+//
+// 设计原理：
+// 1. 每个元组一行，六列联合主键保证元组唯一
+// 2. subject_relation 为空字符串表示具体主体，避免 NULL 参与主键
+// 3. Check 按 (namespace, object_id, relation) 读取，命中主键前缀
+//
+// 表结构：migrations/postgres/004_create_rebac_tuples.up.sql、migrations/sqlite3/003_create_rebac_tuples.up.sql
+type SQLStore struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// NewSQLStore 创建 SQL 存储
+func NewSQLStore(db *sql.DB, dialect Dialect) *SQLStore {
+	return &SQLStore{db: db, dialect: dialect}
+}
+
+// Write 在一个事务中写入并删除元组
+func (s *SQLStore) Write(ctx context.Context, writes, deletes []RelationTuple) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf(
+		"INSERT INTO rebac_tuples (namespace, object_id, relation, subject_namespace, subject_id, subject_relation) "+
+			"VALUES (%s) ON CONFLICT DO NOTHING",
+		s.dialect.Placeholders(6),
+	)
+	for _, t := range writes {
+		if _, err := tx.ExecContext(ctx, insert, tupleArgs(t)...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	del := fmt.Sprintf(
+		"DELETE FROM rebac_tuples WHERE namespace = %s AND object_id = %s AND relation = %s "+
+			"AND subject_namespace = %s AND subject_id = %s AND subject_relation = %s",
+		s.dialect.Placeholder(1), s.dialect.Placeholder(2), s.dialect.Placeholder(3),
+		s.dialect.Placeholder(4), s.dialect.Placeholder(5), s.dialect.Placeholder(6),
+	)
+	for _, t := range deletes {
+		if _, err := tx.ExecContext(ctx, del, tupleArgs(t)...); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Read 读取满足过滤条件的元组
+func (s *SQLStore) Read(ctx context.Context, filter TupleFilter) ([]RelationTuple, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(column, value string) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = %s", column, s.dialect.Placeholder(len(args))))
+	}
+
+	if filter.Namespace != "" {
+		add("namespace", filter.Namespace)
+	}
+	if filter.ObjectID != "" {
+		add("object_id", filter.ObjectID)
+	}
+	if filter.Relation != "" {
+		add("relation", filter.Relation)
+	}
+	if filter.Subject != nil {
+		add("subject_namespace", filter.Subject.Namespace)
+		add("subject_id", filter.Subject.ID)
+		add("subject_relation", filter.Subject.Relation)
+	}
+
+	query := "SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation FROM rebac_tuples"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY namespace, object_id, relation, subject_namespace, subject_id, subject_relation"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tuples []RelationTuple
+	for rows.Next() {
+		var t RelationTuple
+		if err := rows.Scan(&t.Object.Namespace, &t.Object.ID, &t.Relation,
+			&t.Subject.Namespace, &t.Subject.ID, &t.Subject.Relation); err != nil {
+			return nil, err
+		}
+		tuples = append(tuples, t)
+	}
+	return tuples, rows.Err()
+}
+
+// tupleArgs 返回元组的六个列值
+func tupleArgs(t RelationTuple) []interface{} {
+	return []interface{}{
+		t.Object.Namespace, t.Object.ID, t.Relation,
+		t.Subject.Namespace, t.Subject.ID, t.Subject.Relation,
+	}
+}
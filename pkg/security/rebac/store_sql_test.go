@@ -0,0 +1,98 @@
+package rebac
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openTestStore 打开内存数据库并执行 SQLite 迁移
+func openTestStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// :memory: 数据库每个连接独立，限制为单连接
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migration, err := os.ReadFile("../../../migrations/sqlite3/003_create_rebac_tuples.up.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(string(migration))
+	require.NoError(t, err)
+
+	return NewSQLStore(db, DialectSQLite)
+}
+
+// TestStores_ReadWrite 测试内存存储和 SQL 存储的读写行为一致
+func TestStores_ReadWrite(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"sql":    func(t *testing.T) Store { return openTestStore(t) },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			alice := MustParseTuple("document:readme#owner@user:alice")
+			eng := MustParseTuple("document:readme#viewer@group:eng#member")
+			other := MustParseTuple("document:guide#viewer@user:alice")
+
+			// 重复写入不报错
+			require.NoError(t, store.Write(ctx, []RelationTuple{alice, eng, other, alice}, nil))
+
+			all, err := store.Read(ctx, TupleFilter{})
+			require.NoError(t, err)
+			assert.Len(t, all, 3)
+
+			byObject, err := store.Read(ctx, TupleFilter{Namespace: "document", ObjectID: "readme"})
+			require.NoError(t, err)
+			assert.Equal(t, []RelationTuple{alice, eng}, byObject)
+
+			subject := User("alice")
+			bySubject, err := store.Read(ctx, TupleFilter{Subject: &subject})
+			require.NoError(t, err)
+			assert.Equal(t, []RelationTuple{other, alice}, bySubject)
+
+			userset := eng.Subject
+			byUserset, err := store.Read(ctx, TupleFilter{Relation: "viewer", Subject: &userset})
+			require.NoError(t, err)
+			assert.Equal(t, []RelationTuple{eng}, byUserset)
+
+			// 同一批次中写入和删除，删除不存在的元组不报错
+			moved := MustParseTuple("document:readme#owner@user:bob")
+			missing := MustParseTuple("document:readme#owner@user:nobody")
+			require.NoError(t, store.Write(ctx, []RelationTuple{moved}, []RelationTuple{alice, missing}))
+
+			owners, err := store.Read(ctx, TupleFilter{Namespace: "document", ObjectID: "readme", Relation: "owner"})
+			require.NoError(t, err)
+			assert.Equal(t, []RelationTuple{moved}, owners)
+		})
+	}
+}
+
+// TestSQLStore_Engine 测试引擎使用 SQL 存储
+func TestSQLStore_Engine(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEngine(t, openTestStore(t))
+	writeTuples(t, e,
+		"document:readme#parent@folder:docs",
+		"folder:docs#viewer@group:eng#member",
+		"group:eng#member@user:bob",
+	)
+
+	ok, err := e.Check(ctx, readme, "viewer", User("bob"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ids, err := e.ListObjects(ctx, "document", "viewer", User("bob"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"readme"}, ids)
+}
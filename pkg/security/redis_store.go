@@ -0,0 +1,277 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKeyPrefix Redis 存储的默认键前缀
+const DefaultRedisKeyPrefix = "security:"
+
+// redisStoreOptions Redis 存储的可选配置
+type redisStoreOptions struct {
+	prefix string
+}
+
+// RedisStoreOption Redis 存储配置选项
+type RedisStoreOption func(*redisStoreOptions)
+
+// WithRedisKeyPrefix 设置键前缀，默认 DefaultRedisKeyPrefix
+//
+// 会话、CSRF 令牌和限流记录分别保存在 <prefix>session:、<prefix>csrf:、<prefix>ratelimit: 下
+func WithRedisKeyPrefix(prefix string) RedisStoreOption {
+	return func(o *redisStoreOptions) {
+		o.prefix = prefix
+	}
+}
+
+// newRedisStoreOptions 应用选项
+func newRedisStoreOptions(opts []RedisStoreOption) redisStoreOptions {
+	o := redisStoreOptions{prefix: DefaultRedisKeyPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// RedisSessionStore 基于 Redis 的会话存储
+//
+// 设计原理：
+// 1. 每个会话保存为一个 JSON 字符串，键为 <prefix>session:<id>
+// 2. 键的过期时间与 ExpiresAt 一致，过期会话由 Redis 删除，不需要清理协程
+// 3. List 使用 SCAN 遍历，不阻塞 Redis
+//
+// 注意事项：
+// - Data 经过 JSON 序列化，数字读回后是 float64，自定义类型读回后是 map[string]interface{}
+// - 会话在 Redis 中过期后 GetSession 返回 ErrSessionNotFound 而不是 ErrSessionExpired
+//
+// 示例：
+//
+//	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	sm := security.NewSessionManager(security.SessionConfig{
+//	    Store: security.NewRedisSessionStore(client),
+//	})
+type RedisSessionStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisSessionStore 创建 Redis 会话存储
+//
+// 参数：
+//   - client: Redis 客户端，由调用方负责关闭
+//   - opts: 可选配置，如 WithRedisKeyPrefix
+func NewRedisSessionStore(client redis.UniversalClient, opts ...RedisStoreOption) *RedisSessionStore {
+	o := newRedisStoreOptions(opts)
+	return &RedisSessionStore{client: client, prefix: o.prefix + "session:"}
+}
+
+// Save 保存会话，已过期的会话直接删除
+func (s *RedisSessionStore) Save(ctx context.Context, session *Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return s.Delete(ctx, session.ID)
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	return s.client.Set(ctx, s.prefix+session.ID, data, ttl).Err()
+}
+
+// Get 获取会话
+func (s *RedisSessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	data, err := s.client.Get(ctx, s.prefix+sessionID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSession(data)
+}
+
+// Delete 删除会话
+func (s *RedisSessionStore) Delete(ctx context.Context, sessionID string) error {
+	return s.client.Del(ctx, s.prefix+sessionID).Err()
+}
+
+// List 列出所有会话
+//
+// 遍历期间过期的会话会被跳过；出错时返回已读取的会话和错误
+func (s *RedisSessionStore) List(ctx context.Context) ([]*Session, error) {
+	sessions := make([]*Session, 0)
+	iter := s.client.Scan(ctx, 0, s.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := s.client.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return sessions, err
+		}
+		session, err := decodeSession(data)
+		if err != nil {
+			return sessions, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, iter.Err()
+}
+
+// decodeSession 反序列化会话
+func decodeSession(data []byte) (*Session, error) {
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+	if session.Data == nil {
+		session.Data = make(map[string]interface{})
+	}
+	return &session, nil
+}
+
+// RedisCSRFTokenStore 基于 Redis 的 CSRF 令牌存储
+//
+// 令牌保存为 JSON 字符串，键为 <prefix>csrf:<sessionID>，过期时间与 ExpiresAt 一致。
+// 令牌在 Redis 中过期后 ValidateToken 返回 ErrInvalidCSRFToken 而不是 ErrCSRFTokenExpired。
+type RedisCSRFTokenStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisCSRFTokenStore 创建 Redis CSRF 令牌存储
+//
+// 参数：
+//   - client: Redis 客户端，由调用方负责关闭
+//   - opts: 可选配置，如 WithRedisKeyPrefix
+func NewRedisCSRFTokenStore(client redis.UniversalClient, opts ...RedisStoreOption) *RedisCSRFTokenStore {
+	o := newRedisStoreOptions(opts)
+	return &RedisCSRFTokenStore{client: client, prefix: o.prefix + "csrf:"}
+}
+
+// Save 保存会话的令牌，已过期的令牌直接删除
+func (s *RedisCSRFTokenStore) Save(ctx context.Context, sessionID string, token *CSRFToken) error {
+	ttl := time.Until(token.ExpiresAt)
+	if ttl <= 0 {
+		return s.Delete(ctx, sessionID)
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal CSRF token: %w", err)
+	}
+	return s.client.Set(ctx, s.prefix+sessionID, data, ttl).Err()
+}
+
+// Get 获取会话的令牌
+func (s *RedisCSRFTokenStore) Get(ctx context.Context, sessionID string) (*CSRFToken, error) {
+	data, err := s.client.Get(ctx, s.prefix+sessionID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrInvalidCSRFToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	var token CSRFToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal CSRF token: %w", err)
+	}
+	return &token, nil
+}
+
+// Delete 删除会话的令牌
+func (s *RedisCSRFTokenStore) Delete(ctx context.Context, sessionID string) error {
+	return s.client.Del(ctx, s.prefix+sessionID).Err()
+}
+
+// rateLimitScript 原子地清理窗口外的记录、检查数量并记录请求
+//
+// KEYS[1]: 有序集合键
+// ARGV[1]: 当前时间（毫秒）
+// ARGV[2]: 窗口大小（毫秒）
+// ARGV[3]: 限制数量
+// ARGV[4]: 本次请求的成员名
+var rateLimitScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], now, ARGV[4])
+redis.call("PEXPIRE", KEYS[1], window)
+return 1
+`)
+
+// RedisRateLimitStore 基于 Redis 的滑动窗口速率限制存储
+//
+// 设计原理：
+// 1. 每个 key 对应一个有序集合，成员是请求，分数是请求时间（毫秒）
+// 2. Allow 通过 Lua 脚本原子地执行清理、计数和记录，多个副本并发请求不会超过限制
+// 3. 键的过期时间等于窗口大小，空闲的 key 由 Redis 删除
+//
+// 注意事项：
+// - 请求时间取自各副本的本地时钟，副本之间需要时钟同步（如 NTP）
+// - 被拒绝的请求不计入窗口
+type RedisRateLimitStore struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisRateLimitStore 创建 Redis 速率限制存储
+//
+// 参数：
+//   - client: Redis 客户端，由调用方负责关闭
+//   - opts: 可选配置，如 WithRedisKeyPrefix
+func NewRedisRateLimitStore(client redis.UniversalClient, opts ...RedisStoreOption) *RedisRateLimitStore {
+	o := newRedisStoreOptions(opts)
+	return &RedisRateLimitStore{client: client, prefix: o.prefix + "ratelimit:", now: time.Now}
+}
+
+// Allow 检查并记录请求
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	now := s.now()
+	member, err := requestMember(now)
+	if err != nil {
+		return false, err
+	}
+	result, err := rateLimitScript.Run(ctx, s.client, []string{s.prefix + key},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+// Count 返回窗口内的请求数
+func (s *RedisRateLimitStore) Count(ctx context.Context, key string, window time.Duration) (int, error) {
+	cutoff := s.now().Add(-window).UnixMilli()
+	count, err := s.client.ZCount(ctx, s.prefix+key, "("+strconv.FormatInt(cutoff, 10), "+inf").Result()
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// Reset 清除 key 的请求记录
+func (s *RedisRateLimitStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
+
+// requestMember 生成有序集合成员名，同一毫秒内的请求也互不相同
+func requestMember(now time.Time) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate request id: %w", err)
+	}
+	return strconv.FormatInt(now.UnixNano(), 10) + "-" + hex.EncodeToString(suffix), nil
+}
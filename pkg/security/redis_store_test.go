@@ -0,0 +1,233 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis 启动 miniredis 并创建客户端
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { _ = client.Close() })
+	return mr, client
+}
+
+func TestRedisSessionStore_SharedAcrossManagers(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisSessionStore(client)
+
+	// 两个副本共享同一个存储
+	a := NewSessionManager(SessionConfig{Store: store, DefaultTTL: time.Hour})
+	b := NewSessionManager(SessionConfig{Store: store, DefaultTTL: time.Hour})
+	defer a.Shutdown()
+	defer b.Shutdown()
+
+	session, err := a.CreateSession("user-123", map[string]interface{}{"role": "admin"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if ttl := mr.TTL("security:session:" + session.ID); ttl <= 0 || ttl > time.Hour {
+		t.Errorf("Unexpected session TTL %v", ttl)
+	}
+
+	if err := b.UpdateSession(session.ID, map[string]interface{}{"theme": "dark"}); err != nil {
+		t.Fatalf("Failed to update session on other replica: %v", err)
+	}
+
+	got, err := a.GetSession(session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if got.UserID != "user-123" || got.Data["role"] != "admin" || got.Data["theme"] != "dark" {
+		t.Errorf("Unexpected session %+v", got)
+	}
+
+	if sessions := b.ListSessions(); len(sessions) != 1 {
+		t.Errorf("Expected 1 session, got %d", len(sessions))
+	}
+
+	if err := b.DeleteSession(session.ID); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if _, err := a.GetSession(session.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestRedisSessionStore_Expiration(t *testing.T) {
+	mr, client := newTestRedis(t)
+	sm := NewSessionManager(SessionConfig{
+		Store:      NewRedisSessionStore(client, WithRedisKeyPrefix("app:")),
+		DefaultTTL: time.Minute,
+	})
+	defer sm.Shutdown()
+
+	session, err := sm.CreateSession("user-123", nil)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if err := sm.RefreshSession(session.ID, 2*time.Hour); err != nil {
+		t.Fatalf("Failed to refresh session: %v", err)
+	}
+	if ttl := mr.TTL("app:session:" + session.ID); ttl <= time.Hour {
+		t.Errorf("Session TTL should be extended, got %v", ttl)
+	}
+
+	mr.FastForward(3 * time.Hour)
+	if _, err := sm.GetSession(session.ID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestRedisCSRFTokenStore(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisCSRFTokenStore(client)
+
+	a := NewCSRFProtection(CSRFConfig{Store: store})
+	b := NewCSRFProtection(CSRFConfig{Store: store})
+	defer a.Shutdown()
+	defer b.Shutdown()
+
+	token, err := a.GenerateToken("session-123")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	if err := b.ValidateToken("session-123", token); err != nil {
+		t.Errorf("Token should be valid on other replica: %v", err)
+	}
+	if err := b.ValidateToken("session-123", "wrong-token"); !errors.Is(err, ErrInvalidCSRFToken) {
+		t.Errorf("Expected ErrInvalidCSRFToken, got %v", err)
+	}
+
+	if err := b.RevokeToken("session-123"); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+	if err := a.ValidateToken("session-123", token); !errors.Is(err, ErrInvalidCSRFToken) {
+		t.Errorf("Expected ErrInvalidCSRFToken after revocation, got %v", err)
+	}
+
+	// 存储不可用时返回存储的错误
+	mr.SetError("DOWN")
+	if err := a.ValidateToken("session-123", token); err == nil || errors.Is(err, ErrInvalidCSRFToken) {
+		t.Errorf("Expected store error, got %v", err)
+	}
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	mr, client := newTestRedis(t)
+	store := NewRedisRateLimitStore(client)
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	a := NewRateLimiter(RateLimiterConfig{Limit: 3, Window: time.Second, Store: store})
+	b := NewRateLimiter(RateLimiterConfig{Limit: 3, Window: time.Second, Store: store})
+	defer a.Shutdown(context.Background())
+	defer b.Shutdown(context.Background())
+	ctx := context.Background()
+
+	// 两个副本共同计数
+	for i, rl := range []*RateLimiter{a, b, a} {
+		if allowed, err := rl.Allow(ctx, "key"); !allowed || err != nil {
+			t.Fatalf("Request %d should be allowed: %v", i+1, err)
+		}
+	}
+	if allowed, err := b.Allow(ctx, "key"); allowed || !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded, got %v", err)
+	}
+	if remaining, _ := a.GetRemaining(ctx, "key"); remaining != 0 {
+		t.Errorf("Expected 0 remaining, got %d", remaining)
+	}
+
+	// 窗口滑动后恢复
+	now = now.Add(time.Second)
+	if ok, _ := a.Check(ctx, "key"); !ok {
+		t.Error("Requests should be allowed after window")
+	}
+	if allowed, err := b.Allow(ctx, "key"); !allowed || err != nil {
+		t.Errorf("Request should be allowed after window: %v", err)
+	}
+	if remaining, _ := a.GetRemaining(ctx, "key"); remaining != 2 {
+		t.Errorf("Expected 2 remaining, got %d", remaining)
+	}
+	if ttl := mr.TTL("security:ratelimit:key"); ttl != time.Second {
+		t.Errorf("Expected key TTL to equal window, got %v", ttl)
+	}
+
+	if err := a.Reset(ctx, "key"); err != nil {
+		t.Fatalf("Failed to reset: %v", err)
+	}
+	if remaining, _ := b.GetRemaining(ctx, "key"); remaining != 3 {
+		t.Errorf("Expected 3 remaining after reset, got %d", remaining)
+	}
+}
+
+func TestSecurityMiddleware_SharedAcrossReplicas(t *testing.T) {
+	mr, client := newTestRedis(t)
+	newReplica := func() *SecurityMiddleware {
+		return NewSecurityMiddleware(SecurityMiddlewareConfig{
+			CSRF: &CSRFConfig{Store: NewRedisCSRFTokenStore(client)},
+			RateLimit: &RateLimiterConfig{
+				Limit:  2,
+				Window: time.Minute,
+				Store:  NewRedisRateLimitStore(client),
+			},
+		})
+	}
+	a, b := newReplica(), newReplica()
+	defer a.Shutdown()
+	defer b.Shutdown()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handlers := []http.Handler{a.Middleware(ok), b.Middleware(ok)}
+
+	// 在 a 上生成的令牌在 b 上有效
+	token, err := a.csrfProtection.GenerateToken("session-1")
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.Header.Set("X-CSRF-Token", token)
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: "session-1"})
+		return req
+	}
+
+	rr := httptest.NewRecorder()
+	handlers[1].ServeHTTP(rr, newRequest())
+	if rr.Code != http.StatusOK {
+		t.Errorf("Request with token from other replica should succeed, got %d", rr.Code)
+	}
+
+	// 限流计数在副本之间共享
+	rr = httptest.NewRecorder()
+	handlers[0].ServeHTTP(rr, newRequest())
+	if rr.Code != http.StatusOK {
+		t.Errorf("Second request should succeed, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	handlers[1].ServeHTTP(rr, newRequest())
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Third request should be rate limited, got %d", rr.Code)
+	}
+
+	// 存储不可用时拒绝请求
+	mr.SetError("DOWN")
+	rr = httptest.NewRecorder()
+	handlers[0].ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 when store is unavailable, got %d", rr.Code)
+	}
+}
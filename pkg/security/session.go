@@ -1,10 +1,12 @@
 package security
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
-	"sync"
+	"fmt"
+	"log/slog"
 	"time"
 )
 
@@ -18,24 +20,41 @@ var (
 )
 
 // SessionManager 会话管理器
+//
+// 会话保存在 SessionStore 中，默认使用进程内的 MemorySessionStore；
+// 多副本部署时通过 SessionConfig.Store 传入 RedisSessionStore 等共享存储。
 type SessionManager struct {
-	sessions    map[string]*Session
-	mu          sync.RWMutex
-	secret      []byte
-	idLength    int
-	defaultTTL  time.Duration
-	cleanup     *time.Ticker
-	stopCleanup chan struct{}
+	store      SessionStore
+	ownsStore  bool
+	secret     []byte
+	idLength   int
+	defaultTTL time.Duration
 }
 
+// AssuranceLevel 会话的认证保证级别（参考 NIST SP 800-63B）
+type AssuranceLevel int
+
+const (
+	// AAL1 单因素认证，如密码登录；CreateSession 创建的会话默认为该级别
+	AAL1 AssuranceLevel = 1
+	// AAL2 多因素认证，如密码加 TOTP 或恢复码
+	AAL2 AssuranceLevel = 2
+	// AAL3 抗钓鱼的多因素认证，如带用户验证的 WebAuthn
+	AAL3 AssuranceLevel = 3
+)
+
 // Session 会话
 type Session struct {
-	ID        string
-	UserID    string
-	Data      map[string]interface{}
-	CreatedAt time.Time
-	ExpiresAt time.Time
-	LastAccess time.Time
+	ID         string                 `json:"id"`
+	UserID     string                 `json:"user_id"`
+	Data       map[string]interface{} `json:"data"`
+	CreatedAt  time.Time              `json:"created_at"`
+	ExpiresAt  time.Time              `json:"expires_at"`
+	LastAccess time.Time              `json:"last_access"`
+	// AssuranceLevel 当前的认证保证级别，通过 ElevateSession 提升
+	AssuranceLevel AssuranceLevel `json:"aal,omitempty"`
+	// AssuredAt 最近一次提升保证级别的时间，用于要求近期完成多因素认证的操作
+	AssuredAt time.Time `json:"assured_at,omitempty"`
 }
 
 // SessionConfig 会话配置
@@ -43,6 +62,7 @@ type SessionConfig struct {
 	Secret     []byte        // 密钥
 	IDLength   int           // 会话 ID 长度
 	DefaultTTL time.Duration // 默认过期时间
+	Store      SessionStore  // 会话存储，为 nil 时使用 MemorySessionStore
 }
 
 // DefaultSessionConfig 默认会话配置
@@ -60,7 +80,7 @@ func DefaultSessionConfig() SessionConfig {
 // NewSessionManager 创建会话管理器
 func NewSessionManager(config SessionConfig) *SessionManager {
 	if config.Secret == nil {
-		config = DefaultSessionConfig()
+		config.Secret = DefaultSessionConfig().Secret
 	}
 	if config.IDLength == 0 {
 		config.IDLength = 32
@@ -70,22 +90,26 @@ func NewSessionManager(config SessionConfig) *SessionManager {
 	}
 
 	sm := &SessionManager{
-		sessions:    make(map[string]*Session),
-		secret:      config.Secret,
-		idLength:    config.IDLength,
-		defaultTTL:  config.DefaultTTL,
-		stopCleanup: make(chan struct{}),
+		store:      config.Store,
+		secret:     config.Secret,
+		idLength:   config.IDLength,
+		defaultTTL: config.DefaultTTL,
+	}
+	if sm.store == nil {
+		sm.store = NewMemorySessionStore()
+		sm.ownsStore = true
 	}
-
-	// 启动清理协程
-	sm.cleanup = time.NewTicker(1 * time.Hour)
-	go sm.cleanupExpired()
 
 	return sm
 }
 
-// CreateSession 创建会话
+// CreateSession 创建会话，等同于使用 context.Background() 调用 CreateSessionContext
 func (sm *SessionManager) CreateSession(userID string, data map[string]interface{}) (*Session, error) {
+	return sm.CreateSessionContext(context.Background(), userID, data)
+}
+
+// CreateSessionContext 创建会话，ctx 用于控制存储访问的超时和取消
+func (sm *SessionManager) CreateSessionContext(ctx context.Context, userID string, data map[string]interface{}) (*Session, error) {
 	if userID == "" {
 		return nil, errors.New("user ID is required")
 	}
@@ -100,80 +124,131 @@ func (sm *SessionManager) CreateSession(userID string, data map[string]interface
 		CreatedAt:  now,
 		ExpiresAt:  now.Add(sm.defaultTTL),
 		LastAccess: now,
+
+		AssuranceLevel: AAL1,
+		AssuredAt:      now,
 	}
 
 	if session.Data == nil {
 		session.Data = make(map[string]interface{})
 	}
 
-	sm.mu.Lock()
-	sm.sessions[sessionID] = session
-	sm.mu.Unlock()
+	if err := sm.store.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
 
 	return session, nil
 }
 
-// GetSession 获取会话
+// GetSession 获取会话，等同于使用 context.Background() 调用 GetSessionContext
 func (sm *SessionManager) GetSession(sessionID string) (*Session, error) {
+	return sm.GetSessionContext(context.Background(), sessionID)
+}
+
+// GetSessionContext 获取会话
+//
+// 返回的会话是存储中的副本，修改后需要通过 UpdateSession 或 RefreshSession 写回。
+// 在 HTTP 处理中应传入 r.Context()，客户端断开或超时后不再等待存储
+func (sm *SessionManager) GetSessionContext(ctx context.Context, sessionID string) (*Session, error) {
 	if sessionID == "" {
 		return nil, ErrInvalidSessionID
 	}
 
-	sm.mu.RLock()
-	session, exists := sm.sessions[sessionID]
-	sm.mu.RUnlock()
-
-	if !exists {
-		return nil, ErrSessionNotFound
+	session, err := sm.store.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 检查是否过期
 	if time.Now().After(session.ExpiresAt) {
-		sm.mu.Lock()
-		delete(sm.sessions, sessionID)
-		sm.mu.Unlock()
+		_ = sm.store.Delete(ctx, sessionID)
 		return nil, ErrSessionExpired
 	}
 
 	// 更新最后访问时间
-	sm.mu.Lock()
 	session.LastAccess = time.Now()
-	sm.mu.Unlock()
+	if err := sm.store.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
 
 	return session, nil
 }
 
-// UpdateSession 更新会话
+// UpdateSession 更新会话，等同于使用 context.Background() 调用 UpdateSessionContext
 func (sm *SessionManager) UpdateSession(sessionID string, data map[string]interface{}) error {
-	session, err := sm.GetSession(sessionID)
+	return sm.UpdateSessionContext(context.Background(), sessionID, data)
+}
+
+// UpdateSessionContext 更新会话
+//
+// 合并 data 到会话数据中。读取和写回不是原子的，多个副本并发更新同一会话时后写入的生效
+func (sm *SessionManager) UpdateSessionContext(ctx context.Context, sessionID string, data map[string]interface{}) error {
+	session, err := sm.GetSessionContext(ctx, sessionID)
 	if err != nil {
 		return err
 	}
 
-	sm.mu.Lock()
-	if data != nil {
-		for k, v := range data {
-			session.Data[k] = v
-		}
+	for k, v := range data {
+		session.Data[k] = v
 	}
 	session.LastAccess = time.Now()
-	sm.mu.Unlock()
 
-	return nil
+	return sm.store.Save(ctx, session)
+}
+
+// ElevateSession 等同于使用 context.Background() 调用 ElevateSessionContext
+func (sm *SessionManager) ElevateSession(sessionID string, level AssuranceLevel) (*Session, error) {
+	return sm.ElevateSessionContext(context.Background(), sessionID, level)
+}
+
+// ElevateSessionContext 在完成多因素认证后提升会话的保证级别
+//
+// 设计原理：
+// 1. 提升权限时更换会话 ID，防止会话固定攻击：攻击者事先植入的会话 ID 不会获得更高的保证级别
+// 2. 新会话保留原会话的数据和过期时间，旧会话被删除
+// 3. 保证级别只升不降，AssuredAt 更新为当前时间
+//
+// 返回：
+//   - *Session: 新会话，调用方需要把新的会话 ID 写回客户端（如 Set-Cookie）
+func (sm *SessionManager) ElevateSessionContext(ctx context.Context, sessionID string, level AssuranceLevel) (*Session, error) {
+	session, err := sm.GetSessionContext(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session.ID = sm.generateSessionID()
+	session.AssuranceLevel = max(session.AssuranceLevel, level)
+	session.AssuredAt = now
+	session.LastAccess = now
+
+	if err := sm.store.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	if err := sm.store.Delete(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to delete previous session: %w", err)
+	}
+	return session, nil
 }
 
-// DeleteSession 删除会话
+// DeleteSession 删除会话，等同于使用 context.Background() 调用 DeleteSessionContext
 func (sm *SessionManager) DeleteSession(sessionID string) error {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+	return sm.DeleteSessionContext(context.Background(), sessionID)
+}
 
-	delete(sm.sessions, sessionID)
-	return nil
+// DeleteSessionContext 删除会话
+func (sm *SessionManager) DeleteSessionContext(ctx context.Context, sessionID string) error {
+	return sm.store.Delete(ctx, sessionID)
 }
 
-// RefreshSession 刷新会话（延长过期时间）
+// RefreshSession 刷新会话，等同于使用 context.Background() 调用 RefreshSessionContext
 func (sm *SessionManager) RefreshSession(sessionID string, ttl time.Duration) error {
-	session, err := sm.GetSession(sessionID)
+	return sm.RefreshSessionContext(context.Background(), sessionID, ttl)
+}
+
+// RefreshSessionContext 刷新会话（延长过期时间）
+func (sm *SessionManager) RefreshSessionContext(ctx context.Context, sessionID string, ttl time.Duration) error {
+	session, err := sm.GetSessionContext(ctx, sessionID)
 	if err != nil {
 		return err
 	}
@@ -182,24 +257,25 @@ func (sm *SessionManager) RefreshSession(sessionID string, ttl time.Duration) er
 		ttl = sm.defaultTTL
 	}
 
-	sm.mu.Lock()
 	session.ExpiresAt = time.Now().Add(ttl)
 	session.LastAccess = time.Now()
-	sm.mu.Unlock()
 
-	return nil
+	return sm.store.Save(ctx, session)
 }
 
-// ListSessions 列出所有会话
+// ListSessions 列出所有会话，等同于使用 context.Background() 调用 ListSessionsContext
 func (sm *SessionManager) ListSessions() []*Session {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
+	return sm.ListSessionsContext(context.Background())
+}
 
-	sessions := make([]*Session, 0, len(sm.sessions))
-	for _, session := range sm.sessions {
-		sessions = append(sessions, session)
+// ListSessionsContext 列出所有会话
+//
+// 存储读取失败时返回已读取的部分
+func (sm *SessionManager) ListSessionsContext(ctx context.Context) []*Session {
+	sessions, err := sm.store.List(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to list sessions", "error", err)
 	}
-
 	return sessions
 }
 
@@ -210,29 +286,12 @@ func (sm *SessionManager) generateSessionID() string {
 	return base64.URLEncoding.EncodeToString(bytes)
 }
 
-// cleanupExpired 清理过期会话
-func (sm *SessionManager) cleanupExpired() {
-	for {
-		select {
-		case <-sm.cleanup.C:
-			sm.mu.Lock()
-			now := time.Now()
-			for sessionID, session := range sm.sessions {
-				if now.After(session.ExpiresAt) {
-					delete(sm.sessions, sessionID)
-				}
-			}
-			sm.mu.Unlock()
-
-		case <-sm.stopCleanup:
-			sm.cleanup.Stop()
-			return
-		}
-	}
-}
-
 // Shutdown 关闭会话管理器
+//
+// 只关闭默认创建的 MemorySessionStore，通过配置传入的存储由调用方负责关闭
 func (sm *SessionManager) Shutdown() error {
-	close(sm.stopCleanup)
+	if sm.ownsStore {
+		closeStore(sm.store)
+	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package security
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -146,3 +148,80 @@ func TestSessionManager_RefreshSession(t *testing.T) {
 		t.Error("Session expiry should be extended")
 	}
 }
+
+func TestSessionManager_ElevateSession(t *testing.T) {
+	sm := NewSessionManager(DefaultSessionConfig())
+	defer sm.Shutdown()
+
+	session, err := sm.CreateSession("user-123", map[string]interface{}{"name": "Test User"})
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if session.AssuranceLevel != AAL1 {
+		t.Errorf("Expected new session at AAL1, got %d", session.AssuranceLevel)
+	}
+
+	elevated, err := sm.ElevateSession(session.ID, AAL2)
+	if err != nil {
+		t.Fatalf("Failed to elevate session: %v", err)
+	}
+	if elevated.ID == session.ID {
+		t.Error("Session ID should change on elevation")
+	}
+	if elevated.AssuranceLevel != AAL2 {
+		t.Errorf("Expected AAL2, got %d", elevated.AssuranceLevel)
+	}
+	if elevated.Data["name"] != "Test User" {
+		t.Error("Session data should be preserved")
+	}
+	if _, err := sm.GetSession(session.ID); err != ErrSessionNotFound {
+		t.Errorf("Expected old session to be deleted, got %v", err)
+	}
+
+	// 保证级别只升不降
+	lowered, err := sm.ElevateSession(elevated.ID, AAL1)
+	if err != nil {
+		t.Fatalf("Failed to elevate session: %v", err)
+	}
+	if lowered.AssuranceLevel != AAL2 {
+		t.Errorf("Assurance level should not decrease, got %d", lowered.AssuranceLevel)
+	}
+}
+
+// ctxSessionStore 在 ctx 已取消时返回 ctx.Err()，用于验证调用方的 ctx 传递到了存储
+type ctxSessionStore struct {
+	SessionStore
+}
+
+func (s ctxSessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.SessionStore.Get(ctx, sessionID)
+}
+
+func TestSessionManager_GetSessionContext(t *testing.T) {
+	store := NewMemorySessionStore()
+	defer store.Close()
+	config := DefaultSessionConfig()
+	config.Store = ctxSessionStore{store}
+	sm := NewSessionManager(config)
+
+	session, err := sm.CreateSession("user-123", nil)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	if _, err := sm.GetSessionContext(context.Background(), session.ID); err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sm.GetSessionContext(ctx, session.ID); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if err := sm.RefreshSessionContext(ctx, session.ID, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
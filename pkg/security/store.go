@@ -0,0 +1,339 @@
+package security
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// 内存存储的清理间隔
+const (
+	memoryStoreCleanupInterval     = 1 * time.Hour
+	memoryRateLimitCleanupInterval = 1 * time.Minute
+)
+
+// SessionStore 会话存储
+//
+// SessionManager 通过 SessionStore 读写会话，默认使用 MemorySessionStore。
+// 多副本部署时使用共享存储（如 RedisSessionStore），任一副本创建的会话在其他副本上都可以读取。
+//
+// 实现要求：
+// - Get 在会话不存在时返回 ErrSessionNotFound
+// - Save 以 ExpiresAt 作为过期时间，过期会话可以由存储自行删除
+// - Get 返回的会话与存储内部状态互不影响，调用方修改后需要 Save
+type SessionStore interface {
+	// Save 保存会话，已存在时覆盖
+	Save(ctx context.Context, session *Session) error
+	// Get 获取会话
+	Get(ctx context.Context, sessionID string) (*Session, error)
+	// Delete 删除会话，会话不存在时不返回错误
+	Delete(ctx context.Context, sessionID string) error
+	// List 列出所有会话
+	List(ctx context.Context) ([]*Session, error)
+}
+
+// CSRFTokenStore CSRF 令牌存储，按会话 ID 保存令牌
+//
+// 实现要求与 SessionStore 相同：Get 在令牌不存在时返回 ErrInvalidCSRFToken，Save 以 ExpiresAt 作为过期时间
+type CSRFTokenStore interface {
+	// Save 保存会话的令牌，已存在时覆盖
+	Save(ctx context.Context, sessionID string, token *CSRFToken) error
+	// Get 获取会话的令牌
+	Get(ctx context.Context, sessionID string) (*CSRFToken, error)
+	// Delete 删除会话的令牌，令牌不存在时不返回错误
+	Delete(ctx context.Context, sessionID string) error
+}
+
+// RateLimitStore 滑动窗口速率限制存储
+//
+// 每个 key 记录窗口内的请求时间，Allow 的检查和记录必须是原子的，
+// 否则多个副本并发请求时可能超过限制。
+type RateLimitStore interface {
+	// Allow 窗口内请求数小于 limit 时记录本次请求并返回 true，否则返回 false
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error)
+	// Count 返回窗口内的请求数，不记录请求
+	Count(ctx context.Context, key string, window time.Duration) (int, error)
+	// Reset 清除 key 的请求记录
+	Reset(ctx context.Context, key string) error
+}
+
+// MemorySessionStore 进程内会话存储
+//
+// 后台 goroutine 每小时清理一次过期会话，不再使用时调用 Close。
+// 仅适用于单实例部署，多副本部署请使用 RedisSessionStore。
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+	stop     *stopper
+}
+
+// NewMemorySessionStore 创建进程内会话存储
+func NewMemorySessionStore() *MemorySessionStore {
+	s := &MemorySessionStore{sessions: make(map[string]*Session)}
+	s.stop = startCleanup(memoryStoreCleanupInterval, s.deleteExpired)
+	return s
+}
+
+// Save 保存会话
+func (s *MemorySessionStore) Save(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	s.sessions[session.ID] = session.clone()
+	s.mu.Unlock()
+	return nil
+}
+
+// Get 获取会话，过期但尚未清理的会话也会返回
+func (s *MemorySessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	s.mu.RLock()
+	session, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return session.clone(), nil
+}
+
+// Delete 删除会话
+func (s *MemorySessionStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+	return nil
+}
+
+// List 列出所有会话
+func (s *MemorySessionStore) List(ctx context.Context) ([]*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session.clone())
+	}
+	return sessions, nil
+}
+
+// Close 停止清理协程
+func (s *MemorySessionStore) Close() error {
+	s.stop.close()
+	return nil
+}
+
+// deleteExpired 清理过期会话
+func (s *MemorySessionStore) deleteExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// MemoryCSRFTokenStore 进程内 CSRF 令牌存储
+//
+// 后台 goroutine 每小时清理一次过期令牌，不再使用时调用 Close
+type MemoryCSRFTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]CSRFToken
+	stop   *stopper
+}
+
+// NewMemoryCSRFTokenStore 创建进程内 CSRF 令牌存储
+func NewMemoryCSRFTokenStore() *MemoryCSRFTokenStore {
+	s := &MemoryCSRFTokenStore{tokens: make(map[string]CSRFToken)}
+	s.stop = startCleanup(memoryStoreCleanupInterval, s.deleteExpired)
+	return s
+}
+
+// Save 保存会话的令牌
+func (s *MemoryCSRFTokenStore) Save(ctx context.Context, sessionID string, token *CSRFToken) error {
+	s.mu.Lock()
+	s.tokens[sessionID] = *token
+	s.mu.Unlock()
+	return nil
+}
+
+// Get 获取会话的令牌，过期但尚未清理的令牌也会返回
+func (s *MemoryCSRFTokenStore) Get(ctx context.Context, sessionID string) (*CSRFToken, error) {
+	s.mu.RLock()
+	token, ok := s.tokens[sessionID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrInvalidCSRFToken
+	}
+	return &token, nil
+}
+
+// Delete 删除会话的令牌
+func (s *MemoryCSRFTokenStore) Delete(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	delete(s.tokens, sessionID)
+	s.mu.Unlock()
+	return nil
+}
+
+// Close 停止清理协程
+func (s *MemoryCSRFTokenStore) Close() error {
+	s.stop.close()
+	return nil
+}
+
+// deleteExpired 清理过期令牌
+func (s *MemoryCSRFTokenStore) deleteExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sessionID, token := range s.tokens {
+		if now.After(token.ExpiresAt) {
+			delete(s.tokens, sessionID)
+		}
+	}
+}
+
+// MemoryRateLimitStore 进程内速率限制存储
+//
+// 后台 goroutine 每分钟清理一次两倍窗口时间之前的记录，不再使用时调用 Close
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	entries map[string]*rateLimitEntry
+	now     func() time.Time
+	stop    *stopper
+}
+
+// rateLimitEntry 单个 key 的请求记录
+type rateLimitEntry struct {
+	requests []time.Time
+	window   time.Duration
+}
+
+// NewMemoryRateLimitStore 创建进程内速率限制存储
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{
+		entries: make(map[string]*rateLimitEntry),
+		now:     time.Now,
+	}
+	s.stop = startCleanup(memoryRateLimitCleanupInterval, s.deleteExpired)
+	return s
+}
+
+// Allow 检查并记录请求
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &rateLimitEntry{}
+		s.entries[key] = entry
+	}
+	entry.window = window
+	entry.requests = pruneRequests(entry.requests, now.Add(-window))
+
+	if len(entry.requests) >= limit {
+		return false, nil
+	}
+	entry.requests = append(entry.requests, now)
+	return true, nil
+}
+
+// Count 返回窗口内的请求数
+func (s *MemoryRateLimitStore) Count(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	cutoff := s.now().Add(-window)
+	count := 0
+	for _, reqTime := range entry.requests {
+		if reqTime.After(cutoff) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Reset 清除 key 的请求记录
+func (s *MemoryRateLimitStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Close 停止清理协程
+func (s *MemoryRateLimitStore) Close() error {
+	s.stop.close()
+	return nil
+}
+
+// deleteExpired 清理两倍窗口时间前的记录
+func (s *MemoryRateLimitStore) deleteExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, entry := range s.entries {
+		entry.requests = pruneRequests(entry.requests, now.Add(-entry.window*2))
+		if len(entry.requests) == 0 {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// pruneRequests 删除 cutoff 及之前的请求时间，请求时间按先后顺序排列
+func pruneRequests(requests []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(requests) && !requests[i].After(cutoff) {
+		i++
+	}
+	return requests[i:]
+}
+
+// stopper 后台清理协程
+type stopper struct {
+	once sync.Once
+	done chan struct{}
+}
+
+// startCleanup 启动按 interval 调用 fn 的清理协程
+func startCleanup(interval time.Duration, fn func(now time.Time)) *stopper {
+	s := &stopper{done: make(chan struct{})}
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				fn(now)
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// close 停止清理协程，可以重复调用
+func (s *stopper) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// clone 复制会话，Data 做浅拷贝
+func (s *Session) clone() *Session {
+	c := *s
+	c.Data = maps.Clone(s.Data)
+	if c.Data == nil {
+		c.Data = make(map[string]interface{})
+	}
+	return &c
+}
+
+// closeStore 关闭由组件自己创建的存储
+func closeStore(store any) {
+	if c, ok := store.(interface{ Close() error }); ok {
+		_ = c.Close()
+	}
+}